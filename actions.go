@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// openActionPicker lists the actions of the services of the focused group.
+// Enter asks to confirm the selected one, Esc cancels.
+func openActionPicker() {
+	group := focusedServiceGroup()
+	if group == nil || refuseReadOnly() {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(fmt.Sprintf(" Actions of %s ", group.Name))
+	for _, service := range group.Services {
+		for _, name := range homepage.ActionNames(service) {
+			service, name := service, name
+			list.AddItem(tview.Escape(fmt.Sprintf("%s: %s", service.Name, name)), "", 0, func() {
+				closeServicePicker()
+				confirmAction(service, name)
+			})
+		}
+	}
+	if list.GetItemCount() == 0 {
+		return
+	}
+	list.SetDoneFunc(closeServicePicker)
+	showPicker(list, min(list.GetItemCount(), 10)+2)
+}
+
+// refuseReadOnly returns whether the dashboard is read-only, showing it in the
+// header, so actions changing services are not run
+func refuseReadOnly() bool {
+	if globalSettings == nil || !globalSettings.ReadOnly {
+		return false
+	}
+	headerError = "Read-only dashboard: actions are disabled"
+	rebuildLayout()
+	return true
+}
+
+// confirmAction asks whether to run an action of a service, showing its
+// command
+func confirmAction(service *homepage.Service, name string) {
+	modal := tview.NewModal().
+		SetText(tview.Escape(fmt.Sprintf("Run %s of %s?\n\n%s", name, service.Name, service.Actions[name]))).
+		AddButtons([]string{"Run", "Cancel"}).
+		SetDoneFunc(func(_ int, label string) {
+			closeServicePicker()
+			if label == "Run" {
+				runServiceAction(service, name)
+			}
+		})
+	showOverlay(modal, modal)
+}
+
+// runServiceAction runs an action of a service away from the UI goroutine
+// and shows its output in a modal, closed with Enter or Esc. The action is
+// recorded in the status history of the service with who ran it.
+func runServiceAction(service *homepage.Service, name string) {
+	view := tview.NewTextView().SetScrollable(true).SetWrap(true)
+	view.SetBorder(true).SetTitle(fmt.Sprintf(" %s: %s ", service.Name, name))
+	view.SetText(fmt.Sprintf("Running %s...", service.Actions[name]))
+	view.SetDoneFunc(func(tcell.Key) { closeServicePicker() })
+
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(view, 0, 4, true).
+			AddItem(nil, 0, 1, false), 0, 4, true).
+		AddItem(nil, 0, 1, false)
+	showOverlay(centered, view)
+
+	go func() {
+		result, err := homepage.RunAction(context.Background(), service, name)
+		audit := fmt.Sprintf("Action %s failed: %v", name, err)
+		if err == nil {
+			audit = fmt.Sprintf("Ran action %s: exit code %d", name, result.ExitCode)
+		} else {
+			logging.Error("Action %s of %s: %v", name, service.Name, err)
+		}
+		if monitor := homepage.GetStatusMonitor(); monitor != nil {
+			monitor.RecordAudit(service.Name, homepage.AuditUser(), audit)
+		}
+		app.QueueUpdateDraw(func() {
+			var text strings.Builder
+			if result != nil {
+				text.WriteString(result.Output)
+				if result.Output != "" && !strings.HasSuffix(result.Output, "\n") {
+					text.WriteString("\n")
+				}
+				fmt.Fprintf(&text, "\nExited with code %d after %s", result.ExitCode, result.Duration.Round(time.Millisecond))
+			}
+			if err != nil {
+				fmt.Fprintf(&text, "\n%v", err)
+			}
+			view.SetText(strings.TrimLeft(text.String(), "\n"))
+			view.ScrollToEnd()
+		})
+	}()
+}
+
+// showOverlay shows a window over the dashboard and focuses focus. Like
+// pickers, it is closed with closeServicePicker.
+func showOverlay(overlay, focus tview.Primitive) {
+	if isMaximized {
+		toggleMaximize()
+	}
+	pages := tview.NewPages().
+		AddPage("dashboard", originalLayout, true, true).
+		AddPage("overlay", overlay, true, true)
+
+	pickerActive = true
+	app.SetRoot(pages, true)
+	app.SetFocus(focus)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/config"
+)
+
+// newAddCommand creates the `termhome add` command and its service and
+// bookmark subcommands, which append entries to services.yaml and bookmarks.yaml
+func newAddCommand() *cli.Command {
+	cmd := cli.NewCommand("add", "Add a service or bookmark to the configuration")
+	cmd.AddCommand(newAddServiceCommand(), newAddBookmarkCommand())
+	return cmd
+}
+
+// newAddServiceCommand creates the `termhome add service` command
+func newAddServiceCommand() *cli.Command {
+	cmd := cli.NewCommand("service", "Append a service to services.yaml, creating the group if needed")
+	configDir := addConfigDirFlag(cmd)
+	group := cmd.Flags.String("group", "", "Group to add the service to (created if missing)")
+	name := cmd.Flags.String("name", "", "Name of the service")
+	href := cmd.Flags.String("href", "", "URL the service links to")
+	description := cmd.Flags.String("description", "", "Description shown below the name")
+	icon := cmd.Flags.String("icon", "", "Icon of the service")
+	siteMonitor := cmd.Flags.String("site-monitor", "", "URL to monitor with HTTP requests")
+	ping := cmd.Flags.String("ping", "", "Host to monitor with ping")
+	tcpCheck := cmd.Flags.String("tcp-check", "", "host:port to monitor with TCP connections")
+	container := cmd.Flags.String("container", "", "Docker container to monitor")
+	server := cmd.Flags.String("server", "", "Docker server of the container")
+	cmd.FlagValues["group"] = func(string) []string { return serviceGroupNames(*configDir) }
+
+	cmd.Run = func(args []string) int {
+		if *group == "" || *name == "" {
+			fmt.Fprintln(os.Stderr, "Both --group and --name are required")
+			return 2
+		}
+		return appendEntry(filepath.Join(*configDir, "services.yaml"), "service", *group, *name, nonEmptyFields(
+			config.Field{Key: "icon", Value: *icon},
+			config.Field{Key: "href", Value: *href},
+			config.Field{Key: "description", Value: *description},
+			config.Field{Key: "siteMonitor", Value: *siteMonitor},
+			config.Field{Key: "ping", Value: *ping},
+			config.Field{Key: "tcpCheck", Value: *tcpCheck},
+			config.Field{Key: "container", Value: *container},
+			config.Field{Key: "server", Value: *server},
+		))
+	}
+	return cmd
+}
+
+// newAddBookmarkCommand creates the `termhome add bookmark` command
+func newAddBookmarkCommand() *cli.Command {
+	cmd := cli.NewCommand("bookmark", "Append a bookmark to bookmarks.yaml, creating the group if needed")
+	configDir := addConfigDirFlag(cmd)
+	group := cmd.Flags.String("group", "", "Group to add the bookmark to (created if missing)")
+	name := cmd.Flags.String("name", "", "Name of the bookmark")
+	href := cmd.Flags.String("href", "", "URL the bookmark links to")
+	abbr := cmd.Flags.String("abbr", "", "Abbreviation shown when the bookmark has no icon")
+	description := cmd.Flags.String("description", "", "Description shown below the name")
+	icon := cmd.Flags.String("icon", "", "Icon of the bookmark")
+	cmd.FlagValues["group"] = func(string) []string { return bookmarkGroupNames(*configDir) }
+
+	cmd.Run = func(args []string) int {
+		if *group == "" || *name == "" || *href == "" {
+			fmt.Fprintln(os.Stderr, "--group, --name and --href are required")
+			return 2
+		}
+		return appendEntry(filepath.Join(*configDir, "bookmarks.yaml"), "bookmark", *group, *name, nonEmptyFields(
+			config.Field{Key: "abbr", Value: *abbr},
+			config.Field{Key: "icon", Value: *icon},
+			config.Field{Key: "href", Value: *href},
+			config.Field{Key: "description", Value: *description},
+		))
+	}
+	return cmd
+}
+
+// appendEntry appends an entry to a configuration file and reports the result
+func appendEntry(filePath, kind, group, name string, fields []config.Field) int {
+	if err := config.AppendEntry(filePath, group, name, fields); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Added %s %q to group %q in %s\n", kind, name, group, filePath)
+	return 0
+}
+
+// nonEmptyFields returns the fields that have a value set
+func nonEmptyFields(fields ...config.Field) []config.Field {
+	var out []config.Field
+	for _, field := range fields {
+		if field.Value != "" {
+			out = append(out, field)
+		}
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/deblasis/termhome/pkg/server"
+)
+
+// newAgentCommand creates the `termhome agent` command, which runs the locally
+// configured checks and pushes their results to a central termhome instance
+func newAgentCommand() *cli.Command {
+	cmd := cli.NewCommand("agent", "Run local checks and push the results to a central termhome")
+	configDir := addConfigDirFlag(cmd)
+	logLevel := addLogLevelFlag(cmd)
+	profile := addProfileFlag(cmd)
+	serverURL := cmd.Flags.String("server", "", "Base URL of the central termhome API (required)")
+	token := cmd.Flags.String("token", "", "Bearer token of the central termhome API")
+	name := cmd.Flags.String("name", "", "Name of this agent (default: instanceName setting or hostname)")
+	interval := cmd.Flags.Duration("interval", 30*time.Second, "Push interval")
+	skipVerify := cmd.Flags.Bool("skip-verify", false, "Skip TLS certificate verification of the server")
+	cmd.Run = func(args []string) int {
+		if *serverURL == "" {
+			fmt.Fprintln(os.Stderr, "--server is required")
+			return 2
+		}
+		if *interval < time.Second {
+			fmt.Fprintln(os.Stderr, "--interval must be at least 1s")
+			return 2
+		}
+		selectProfile(*profile)
+		return runAgent(*configDir, *logLevel, *serverURL, *token, *name, *interval, *skipVerify)
+	}
+	return cmd
+}
+
+// runAgent runs the status monitor and pushes snapshots until interrupted
+func runAgent(configDir, logLevel, serverURL, token, name string, interval time.Duration, skipVerify bool) int {
+	logging.SetGlobalLogLevel(logging.ParseLogLevel(logLevel))
+	logging.Info("Using config directory: %s", configDir)
+
+	cfg := loadConfig(configDir)
+	if name == "" {
+		name = cfg.settings.InstanceName
+	}
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+
+	statusMonitor := homepage.NewStatusMonitor(nil)
+	homepage.SetStatusMonitor(statusMonitor)
+	defer statusMonitor.Stop()
+
+	startStatusMonitor(statusMonitor, cfg)
+
+	fmt.Printf("Pushing statuses of %q to %s every %s\n", name, serverURL, interval)
+
+	push := func() {
+		snapshot := statusMonitor.Snapshot(name)
+		snapshot.Interval = int(interval.Seconds())
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+		if err := server.Push(ctx, serverURL, token, skipVerify, snapshot); err != nil {
+			logging.Error("Push to %s failed: %v", serverURL, err)
+			return
+		}
+		logging.Debug("Pushed %d groups to %s", len(snapshot.Groups), serverURL)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	// Give the initial checks a moment to complete before the first push
+	initial := time.NewTimer(agentStartupDelay)
+	defer initial.Stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-initial.C:
+			push()
+		case <-ticker.C:
+			push()
+		case <-sigCh:
+			logging.Info("Received signal, shutting down...")
+			return 0
+		}
+	}
+}
+
+// agentStartupDelay is the time the agent waits for the initial checks before its first push
+const agentStartupDelay = 5 * time.Second
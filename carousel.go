@@ -0,0 +1,76 @@
+package main
+
+import (
+	"cmp"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Seconds the carousel waits after the last key press or click by default
+const defaultCarouselPause = 60
+
+var (
+	// Fires the next step of the carousel, nil while it is off
+	carouselTimer *time.Timer
+
+	// Position of the box maximized by the carousel in allFocusableBoxes
+	carouselIndex = -1
+
+	// Time of the last key press or click, the carousel waits while recent
+	lastInput time.Time
+)
+
+// scheduleCarousel starts, restarts or stops the carousel, which maximizes
+// the groups one after the other. It must run on the UI goroutine.
+func scheduleCarousel(settings homepage.CarouselSettings) {
+	if carouselTimer != nil {
+		carouselTimer.Stop()
+		carouselTimer = nil
+	}
+	if settings.Interval <= 0 {
+		return
+	}
+
+	interval := time.Duration(settings.Interval) * time.Second
+	pause := time.Duration(cmp.Or(settings.Pause, defaultCarouselPause)) * time.Second
+	var timer *time.Timer
+	timer = time.AfterFunc(interval, func() {
+		app.QueueUpdateDraw(func() {
+			// A reload replaced the carousel meanwhile
+			if carouselTimer != timer {
+				return
+			}
+			if time.Since(lastInput) >= pause {
+				advanceCarousel()
+			}
+			timer.Reset(interval)
+		})
+	})
+	carouselTimer = timer
+}
+
+// advanceCarousel maximizes the box following the one shown, unless the
+// pre-flight screen, a picker or the search is open or a group is maximized
+// because a service turned critical
+func advanceCarousel() {
+	if preflightActive || pickerActive || searchActive || autoMaximized || len(allFocusableBoxes) == 0 {
+		return
+	}
+	carouselIndex = (carouselIndex + 1) % len(allFocusableBoxes)
+	if isMaximized {
+		toggleMaximize()
+	}
+	currentFocus = allFocusableBoxes[carouselIndex]
+	toggleMaximize()
+}
+
+// recordMouseInput notes the time of a click or scroll, ignoring moves
+func recordMouseInput(event *tcell.EventMouse, action tview.MouseAction) (*tcell.EventMouse, tview.MouseAction) {
+	if action != tview.MouseMove {
+		lastInput = time.Now()
+	}
+	return event, action
+}
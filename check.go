@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// Exit codes of the check subcommand, following the Nagios plugin convention
+const (
+	exitOK       = 0
+	exitWarning  = 1
+	exitCritical = 2
+	exitUnknown  = 3
+)
+
+// newCheckCommand creates the `termhome check "<service name>"` command,
+// which runs the check of a single service once, prints a detailed result and
+// exits with the code mapped from the resulting status
+func newCheckCommand() *cli.Command {
+	cmd := cli.NewCommand("check", "Run the check of a single service once and print a detailed result")
+	cmd.Usage = "[flags] <service name>"
+	configDir := addConfigDirFlag(cmd)
+	logLevel := addLogLevelFlag(cmd)
+	profile := addProfileFlag(cmd)
+	timeout := cmd.Flags.Duration("timeout", 2*time.Minute, "Maximum time to wait for the check")
+	cmd.Args = func(string) []string { return serviceNames(*configDir) }
+	cmd.Run = func(args []string) int {
+		if len(args) != 1 {
+			cmd.PrintUsage()
+			return exitUnknown
+		}
+		selectProfile(*profile)
+		return runCheck(*configDir, *logLevel, *timeout, args[0])
+	}
+	return cmd
+}
+
+// runCheck runs the check of the named service and prints the result
+func runCheck(configDir, logLevel string, timeout time.Duration, name string) int {
+	logging.SetGlobalLogLevel(logging.ParseLogLevel(logLevel))
+
+	serviceGroups, err := homepage.LoadServices(filepath.Join(configDir, "services.yaml"))
+	var invalidValues *homepage.InvalidValuesError
+	if errors.As(err, &invalidValues) {
+		for _, message := range invalidValues.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+		}
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading services: %v\n", err)
+		return exitUnknown
+	}
+
+	// A misspelled key is the most common reason a check doesn't do what
+	// was configured
+	for _, key := range homepage.CheckConfigKeys(filepath.Join(configDir, "services.yaml")) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", key)
+	}
+
+	settings, err := homepage.LoadSettings(filepath.Join(configDir, "settings.yaml"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading settings: %v\n", err)
+		return exitUnknown
+	}
+	serviceGroups, _, err = homepage.ApplyProfile(settings, configProfile, serviceGroups, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUnknown
+	}
+
+	service, groupName := findService(serviceGroups, name)
+	if service == nil {
+		fmt.Fprintf(os.Stderr, "Service %q not found in %s\n", name, configDir)
+		return exitUnknown
+	}
+	homepage.SetPluginDir(pluginDir(configDir, settings))
+	homepage.SetScriptDir(configDir)
+
+	dockerConfig, err := homepage.LoadDockerConfig(filepath.Join(configDir, "docker.yaml"))
+	if err != nil {
+		logging.Warn("Warning: Error loading Docker config: %v", err)
+	}
+	homepage.SetDockerConfig(dockerConfig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	result := homepage.CheckOnce(ctx, service, dockerConfig)
+	elapsed := time.Since(startTime)
+
+	fmt.Printf("Service:  %s (%s)\n", service.Name, groupName)
+	fmt.Printf("Check:    %s\n", checkKind(service))
+	fmt.Printf("Status:   %s\n", strings.ToUpper(string(result.State)))
+	if result.Message != "" {
+		fmt.Printf("Message:  %s\n", result.Message)
+	}
+	fmt.Printf("Duration: %s\n", elapsed.Round(time.Millisecond))
+
+	printDetails(result.Details)
+
+	return exitCode(result.State)
+}
+
+// printDetails prints the diagnostic details of a check, aligned by label
+func printDetails(details []homepage.StatusDetail) {
+	if len(details) == 0 {
+		return
+	}
+	width := 0
+	for _, detail := range details {
+		if len(detail.Label)+1 > width {
+			width = len(detail.Label) + 1
+		}
+	}
+	fmt.Println()
+	for _, detail := range details {
+		fmt.Printf("  %-*s  %s\n", width, detail.Label+":", detail.Value)
+	}
+}
+
+// findService looks up a service by name (case-insensitive) and returns it
+// together with the name of its group
+func findService(groups []*homepage.ServiceGroup, name string) (*homepage.Service, string) {
+	for _, group := range groups {
+		for _, service := range group.Services {
+			if strings.EqualFold(service.Name, name) {
+				return service, group.Name
+			}
+		}
+	}
+	return nil, ""
+}
+
+// checkKind describes which check will be run for a service
+func checkKind(service *homepage.Service) string {
+	switch {
+	case service.Ping != "":
+		return "ping " + service.Ping
+	case service.SiteMonitor != "":
+		return "siteMonitor " + service.SiteMonitor
+	case service.TCPCheck != "":
+		return "tcpCheck " + service.TCPCheck
+	case service.DNSCheck != "":
+		return "dnsCheck " + service.DNSCheck
+	case service.SMTPCheck != "":
+		return "smtpCheck " + service.SMTPCheck
+	case service.Redis != "":
+		return "redis " + redactURL(service.Redis)
+	case service.Plugin != "":
+		return "plugin " + service.Plugin
+	case service.Script != "":
+		return "script " + service.Script
+	case service.WindowsService != "":
+		return "windowsService " + service.WindowsService
+	case service.Launchd != "":
+		return "launchd " + service.Launchd
+	case service.Mdadm != "":
+		return "mdadm " + service.Mdadm
+	case service.FileAge != "":
+		return "fileAge " + service.FileAge
+	case service.DockerVolume != "":
+		return "dockerVolume " + service.DockerVolume
+	case service.DockerNetwork != "":
+		return "dockerNetwork " + service.DockerNetwork
+	case service.FileServer != "":
+		return "fileServer " + redactURL(service.FileServer)
+	case service.OIDC != "":
+		return "oidc " + service.OIDC
+	case service.Broker != "":
+		return "broker " + redactURL(service.Broker)
+	case service.Elasticsearch != "":
+		return "elasticsearch " + redactURL(service.Elasticsearch)
+	case service.S3 != "":
+		return "s3 " + service.S3
+	case service.Git != "":
+		return "git " + redactURL(service.Git)
+	case service.Container != "":
+		return "container " + service.Container
+	case service.HeartbeatPeriod > 0:
+		return "heartbeat " + homepage.HeartbeatID(service)
+	case service.Widget != nil:
+		return "widget " + service.Widget.Type
+	case service.Status != "":
+		return "static status"
+	}
+	return "none"
+}
+
+// redactURL hides the password of a URL
+func redactURL(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		return parsed.Redacted()
+	}
+	return rawURL
+}
+
+// exitCode maps a status state to the exit code of the check subcommand
+func exitCode(state homepage.StatusState) int {
+	switch state {
+	case homepage.StatusOK:
+		return exitOK
+	case homepage.StatusWarning:
+		return exitWarning
+	case homepage.StatusCritical:
+		return exitCritical
+	}
+	return exitUnknown
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCheckExitCode(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "services.yaml"), []byte(`- Apps:
+    - Healthy:
+        status: ok
+    - Degraded:
+        status: warning
+    - Down:
+        status: critical
+    - Maintenance:
+        status: maintenance
+    - Backup:
+        heartbeatPeriod: 3600
+`), 0644))
+
+	tests := []struct {
+		service string
+		code    int
+	}{
+		{"Healthy", exitOK},
+		{"degraded", exitWarning},
+		{"Down", exitCritical},
+		{"Maintenance", exitUnknown},
+		{"Backup", exitUnknown},
+		{"Missing", exitUnknown},
+	}
+	for _, test := range tests {
+		t.Run(test.service, func(t *testing.T) {
+			assert.Equal(t, test.code, runCheck(dir, "error", time.Second, test.service))
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, exitOK, exitCode(homepage.StatusOK))
+	assert.Equal(t, exitWarning, exitCode(homepage.StatusWarning))
+	assert.Equal(t, exitCritical, exitCode(homepage.StatusCritical))
+	assert.Equal(t, exitUnknown, exitCode(homepage.StatusUnknown))
+}
+
+func TestCheckKind(t *testing.T) {
+	assert.Equal(t, "ping nas.lan", checkKind(&homepage.Service{Ping: "nas.lan", Status: "ok"}))
+	assert.Equal(t, "redis redis://:xxxxx@cache.lan", checkKind(&homepage.Service{Redis: "redis://:secret@cache.lan"}))
+	assert.Equal(t, "widget grafana", checkKind(&homepage.Service{Widget: &homepage.WidgetConfig{Type: "grafana"}}))
+	assert.Equal(t, "static status", checkKind(&homepage.Service{Status: "ok"}))
+	assert.Equal(t, "none", checkKind(&homepage.Service{}))
+}
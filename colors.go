@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Theme colors, downsampled to palette colors on terminals without 256 colors
+var (
+	colorMuted = "#888888" // Descriptions and other secondary text
+	colorLink  = "#2db7f5" // Links
+	colorNote  = "#e5c07b" // Notes attached to services and groups
+
+	// Number of colors the terminal supports
+	colorDepth = 1 << 24
+)
+
+// basicColors are the 16 ANSI colors, which all color terminals support
+var basicColors = []tcell.Color{
+	tcell.ColorBlack, tcell.ColorMaroon, tcell.ColorGreen, tcell.ColorOlive,
+	tcell.ColorNavy, tcell.ColorPurple, tcell.ColorTeal, tcell.ColorSilver,
+	tcell.ColorGray, tcell.ColorRed, tcell.ColorLime, tcell.ColorYellow,
+	tcell.ColorBlue, tcell.ColorFuchsia, tcell.ColorAqua, tcell.ColorWhite,
+}
+
+// basicColorNames are the tview names of basicColors
+var basicColorNames = []string{
+	"black", "maroon", "green", "olive", "navy", "purple", "teal", "silver",
+	"gray", "red", "lime", "yellow", "blue", "fuchsia", "aqua", "white",
+}
+
+var (
+	// styleTagPattern matches tview style tags such as [#ff8800:black:b]
+	styleTagPattern = regexp.MustCompile(`\[[a-zA-Z0-9#]*(:[a-zA-Z0-9#]*)?(:[a-zA-Z\-]*)?\]`)
+
+	// hexColorPattern matches hex colors within a style tag
+	hexColorPattern = regexp.MustCompile(`#[0-9a-fA-F]{6}`)
+
+	// responseTimePattern matches the response time in a status message, e.g.
+	// 12 ms in "Up (12 ms)" or 1.5ms in "Up (1.5ms)"
+	responseTimePattern = regexp.MustCompile(`\d+(\.\d+)? ?(µs|ms|s)\b`)
+)
+
+// setupScreen creates the terminal screen of the application and sets the
+// color depth, detected from the terminal unless the colors setting forces it
+func setupScreen(setting string) {
+	setting = strings.ToLower(setting)
+	forced := 0
+	switch setting {
+	case "", "auto":
+	case "truecolor", "24bit":
+		// Honored by tcell even if the terminfo entry lacks RGB support
+		os.Setenv("COLORTERM", "truecolor")
+	case "256", "16", "8":
+		forced, _ = strconv.Atoi(setting)
+		os.Setenv("TCELL_TRUECOLOR", "disable")
+	default:
+		logging.Warn("Unknown colors setting %q, detecting the color depth", setting)
+	}
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		logging.Error("Failed to create screen: %v", err)
+		return
+	}
+	app.SetScreen(screen)
+
+	depth := screen.Colors()
+	if forced > 0 && (depth <= 0 || forced < depth) {
+		depth = forced
+	}
+	setColorDepth(depth)
+}
+
+// setColorDepth picks the theme colors for a terminal supporting the given
+// number of colors. Hex colors are kept on 256-color terminals, where tcell
+// maps them to the closest palette entry well enough.
+func setColorDepth(depth int) {
+	colorDepth = depth
+	logging.Info("Terminal supports %d colors", depth)
+
+	switch {
+	case depth >= 256:
+		colorMuted, colorLink, colorNote = "#888888", "#2db7f5", "#e5c07b"
+	case depth >= 16:
+		colorMuted, colorLink, colorNote = "gray", "aqua", "yellow"
+	default:
+		colorMuted, colorLink, colorNote = "silver", "teal", "olive"
+	}
+}
+
+// downsampleTags replaces hex colors in the style tags of text, e.g. card
+// lines rendered by scripts, by the closest basic color on terminals without
+// 256 colors
+func downsampleTags(text string) string {
+	if colorDepth >= 256 {
+		return text
+	}
+	palette := basicColors
+	if colorDepth < len(basicColors) {
+		palette = basicColors[:max(colorDepth, 2)]
+	}
+	return styleTagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		return hexColorPattern.ReplaceAllStringFunc(tag, func(hex string) string {
+			return basicColorName(tcell.FindColor(tcell.GetColor(hex), palette))
+		})
+	})
+}
+
+// basicColorName returns the tview name of a basic color
+func basicColorName(color tcell.Color) string {
+	for i, c := range basicColors {
+		if c == color {
+			return basicColorNames[i]
+		}
+	}
+	return "white"
+}
+
+// colorResponseTime colors the response time in the message of a status
+// result with the color of its latency bucket, going back to the color of the
+// state after it, so slow services stand out even while up
+func colorResponseTime(message string, result *homepage.StatusResult, stateColor string) string {
+	var color string
+	if globalSettings != nil {
+		color = globalSettings.Status.ResponseTimes.Color(result.ResponseTime)
+	}
+	loc := responseTimePattern.FindStringIndex(message)
+	if color == "" || loc == nil {
+		return message
+	}
+	return fmt.Sprintf("%s%s[%s]%s",
+		message[:loc[0]], downsampleTags("["+color+"]"+message[loc[0]:loc[1]]), stateColor, message[loc[1]:])
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/config"
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/deblasis/termhome/pkg/version"
+)
+
+// logLevels lists the accepted values of the --log-level flag
+var logLevels = []string{"DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// newRootCommand creates the command tree of the termhome binary. Running it
+// without a subcommand starts the dashboard.
+func newRootCommand() *cli.Command {
+	root := cli.NewCommand("termhome", "A terminal homepage for your services and bookmarks")
+	configDir := addConfigDirFlag(root)
+	logLevel := addLogLevelFlag(root)
+	showVersion := root.Flags.Bool("version", false, "Print version information and exit")
+	profile := addProfileFlag(root)
+	pprofAddr := root.Flags.String("pprof", "", "Serve pprof endpoints at this address, e.g. localhost:6060")
+	tags := root.Flags.String("tags", "", "Only show the services and bookmarks with one of these comma-separated tags")
+	stats := root.Flags.Bool("stats", false, "Show the time each draw takes and the number of goroutines")
+	root.Run = func(args []string) int {
+		if *showVersion {
+			fmt.Printf("termhome %s\n", version.Get().Short())
+			return 0
+		}
+		selectProfile(*profile)
+		return runDashboard(*configDir, *logLevel, *pprofAddr, homepage.ParseTags(*tags), *stats)
+	}
+
+	root.AddCommand(
+		newInitCommand(),
+		newCheckCommand(),
+		newAddCommand(),
+		newServeCommand(),
+		newAgentCommand(),
+		newCtlCommand(),
+		newReportCommand(),
+		newDoctorCommand(),
+		newSchemaCommand(),
+		newVersionCommand(),
+		cli.NewCompletionCommand(),
+	)
+	return root
+}
+
+// newInitCommand creates the `termhome init` command
+func newInitCommand() *cli.Command {
+	cmd := cli.NewCommand("init", "Initialize example configuration files")
+	configDir := cmd.Flags.String("config-dir", "./config", "Directory to create example configuration files in")
+	cmd.FlagValues["config-dir"] = cli.CompleteDirs
+	cmd.Run = func(args []string) int {
+		if err := config.InitializeConfigDir(*configDir); err != nil {
+			logging.Fatal("Failed to initialize example configs: %v", err)
+		}
+		logging.Info("Example configuration files created in %s", *configDir)
+		fmt.Printf("Example configuration files created in %s\n", *configDir)
+		return 0
+	}
+	return cmd
+}
+
+// newVersionCommand creates the `termhome version` command
+func newVersionCommand() *cli.Command {
+	cmd := cli.NewCommand("version", "Print version and build information")
+	cmd.Run = func(args []string) int {
+		fmt.Print(version.Get())
+		return 0
+	}
+	return cmd
+}
+
+// addConfigDirFlag adds the --config-dir flag to a command
+func addConfigDirFlag(cmd *cli.Command) *string {
+	cmd.FlagValues["config-dir"] = cli.CompleteDirs
+	return cmd.Flags.String("config-dir", "./config", "Directory containing the configuration files")
+}
+
+// addLogLevelFlag adds the --log-level flag to a command
+func addLogLevelFlag(cmd *cli.Command) *string {
+	cmd.FlagValues["log-level"] = cli.CompleteValues(logLevels...)
+	return cmd.Flags.String("log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR, FATAL)")
+}
+
+// addProfileFlag adds the --profile flag to a command
+func addProfileFlag(cmd *cli.Command) *string {
+	return cmd.Flags.String("profile", "", "Profile of settings.yaml to apply (default: $"+profileEnv+")")
+}
+
+// serviceNames returns the names of the services configured in configDir,
+// used for shell completion
+func serviceNames(configDir string) []string {
+	// Services with invalid values are still loaded
+	groups, _ := homepage.LoadServices(filepath.Join(configDir, "services.yaml"))
+	var names []string
+	for _, group := range groups {
+		for _, service := range group.Services {
+			names = append(names, service.Name)
+		}
+	}
+	return names
+}
+
+// serviceGroupNames returns the names of the service groups configured in configDir
+func serviceGroupNames(configDir string) []string {
+	// Services with invalid values are still loaded
+	groups, _ := homepage.LoadServices(filepath.Join(configDir, "services.yaml"))
+	var names []string
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names
+}
+
+// bookmarkGroupNames returns the names of the bookmark groups configured in configDir
+func bookmarkGroupNames(configDir string) []string {
+	groups, err := homepage.LoadBookmarks(filepath.Join(configDir, "bookmarks.yaml"))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Terminals narrower or shorter than this switch to compact mode
+const (
+	compactMaxWidth  = 80
+	compactMaxHeight = 20
+)
+
+var (
+	// Compact mode renders one line per service in a single column
+	compactMode bool
+
+	// Set by the toggle key, disables the automatic detection
+	compactOverride bool
+)
+
+// isSmallTerminal reports whether a terminal of the given size is too small
+// for the full layout
+func isSmallTerminal(width, height int) bool {
+	return width < compactMaxWidth || height < compactMaxHeight
+}
+
+// detectCompactMode switches compact mode on or off when the terminal is
+// resized across the thresholds, unless it was toggled manually. It is called
+// before each draw, so the rebuild is queued rather than run right away.
+func detectCompactMode(screen tcell.Screen) bool {
+	if compactOverride {
+		return false
+	}
+	width, height := screen.Size()
+	if small := isSmallTerminal(width, height); small != compactMode {
+		compactMode = small
+		go app.QueueUpdateDraw(rebuildLayout)
+	}
+	return false
+}
+
+// toggleCompactMode switches between the compact and the full layout
+func toggleCompactMode() {
+	compactMode = !compactMode
+	compactOverride = true
+	rebuildLayout()
+}
+
+// createCompactContainer creates the condensed UI: a one-line header, the
+// service groups followed by the bookmark groups in a single column, and a
+// one-line footer
+func createCompactContainer(settings *homepage.Settings, serviceGroups []*homepage.ServiceGroup, bookmarkGroups []*homepage.BookmarkGroup) *tview.Flex {
+	mainFlex := tview.NewFlex().
+		SetDirection(tview.FlexRow)
+
+	header := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter).
+		SetText(headerText(settings))
+
+	// Size groups by their number of lines, including the heading
+	content := tview.NewFlex().
+		SetDirection(tview.FlexRow)
+	allFocusableBoxes = []tview.Primitive{}
+	for _, group := range serviceGroups {
+		content.AddItem(createServiceGroupBox(group), 0, len(group.Services)+1, false)
+	}
+	for _, group := range bookmarkGroups {
+		content.AddItem(createBookmarkGroupBox(group), 0, len(group.Bookmarks)+1, false)
+	}
+
+	footer := newStatusBar(footerHints(settings.Footer.CompactHints, "Q: Quit | Tab: Navigate | C: Full view"))
+
+	mainFlex.AddItem(header, 1, 1, false)
+	mainFlex.AddItem(content, 0, 1, true)
+	mainFlex.AddItem(footer, 1, 1, false)
+
+	originalLayout = mainFlex
+	if len(allFocusableBoxes) > 0 {
+		currentFocus = allFocusableBoxes[0]
+		app.SetFocus(currentFocus)
+	}
+	return mainFlex
+}
+
+// renderCompactService displays a service on a single line: its status icon,
+// its name and, unless it is healthy, its status message
+func renderCompactService(view *tview.TextView, service *homepage.Service, status *homepage.StatusEntry) {
+	name := fmt.Sprintf("[white::b]%s[-:-:-]", service.Name)
+	if service.ShowOnlyWhenDown {
+		name = fmt.Sprintf("[white:red:b]%s[-:-:-]", service.Name)
+	}
+
+	if service.DisableStatus || status == nil {
+		fmt.Fprintf(view, "  %s\n", name)
+		return
+	}
+
+	result := &status.StatusResult
+	color, icon := statusIcon(result.State)
+	if result.Stale {
+		// Restored from the previous run, not checked yet
+		color = colorMuted
+	}
+	if result.State == homepage.StatusOK || result.Message == "" {
+		fmt.Fprintf(view, "[%s]%s[-] %s\n", color, icon, name)
+		return
+	}
+	fmt.Fprintf(view, "[%s]%s[-] %s [%s]%s[-]\n", color, icon, name, color, colorResponseTime(result.Message, result, color))
+}
+
+// renderCompactBookmark displays a bookmark on a single line
+func renderCompactBookmark(view *tview.TextView, bookmark *homepage.Bookmark) {
+	displayName := bookmark.Name
+	if displayName == "" {
+		displayName = bookmark.Abbr
+	}
+	if deadBookmark(bookmark) != nil {
+		fmt.Fprintf(view, "[%s::s]%s[::-] [red]%s[-]\n", colorMuted, displayName, glyphs.critical)
+		return
+	}
+	fmt.Fprintf(view, "[white::u]%s[::-]\n", displayName)
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/deblasis/termhome/pkg/control"
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// appConfig holds the configuration loaded from the config directory
+type appConfig struct {
+	settings       *homepage.Settings
+	serviceGroups  []*homepage.ServiceGroup
+	bookmarkGroups []*homepage.BookmarkGroup
+	dockerConfig   *homepage.DockerConfig
+}
+
+// profileEnv is the environment variable selecting the profile when the
+// --profile flag is not set
+const profileEnv = "TERMHOME_PROFILE"
+
+// configProfile is the profile of settings.yaml applied when reading the
+// configuration, empty for none
+var configProfile string
+
+// selectProfile sets the profile applied when reading the configuration,
+// falling back to the TERMHOME_PROFILE environment variable
+func selectProfile(name string) {
+	configProfile = cmp.Or(name, os.Getenv(profileEnv))
+}
+
+// loadConfig loads the configuration files from configDir at startup and
+// stores them for status updates. Missing or invalid services and bookmarks
+// files are logged and treated as empty; invalid settings are fatal, as are
+// unknown keys if the unknownKeys setting is "error".
+func loadConfig(configDir string) *appConfig {
+	cfg, err := readConfig(configDir, false)
+	if err != nil {
+		// The log file alone would hide why termhome didn't start
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		logging.Fatal("Error loading configuration: %v", err)
+	}
+	logging.Info("Settings loaded successfully.")
+
+	storeConfig(configDir, cfg, cfg.serviceGroups)
+	return cfg
+}
+
+// readConfig reads the configuration files from configDir, applies the
+// selected profile and orders groups, services and bookmarks by weight. Missing files are treated as empty. Invalid
+// services, bookmarks and Docker files are logged and treated as empty, and
+// invalid values of services are logged and left out, unless strict is set,
+// in which case their error is returned. Unknown keys are
+// logged, or returned as an error if the unknownKeys setting is "error".
+func readConfig(configDir string, strict bool) (*appConfig, error) {
+	// --- Configuration paths ---
+	settingsPath := filepath.Join(configDir, "settings.yaml")
+	servicesPath := filepath.Join(configDir, "services.yaml")
+	bookmarksPath := filepath.Join(configDir, "bookmarks.yaml")
+	dockerPath := filepath.Join(configDir, "docker.yaml")
+
+	logging.Info("Config paths: settings=%s, services=%s, bookmarks=%s, docker=%s",
+		settingsPath, servicesPath, bookmarksPath, dockerPath)
+
+	// Load the files concurrently, as reading and parsing them adds up on slow
+	// storage such as the SD card of a Raspberry Pi
+	var (
+		wg             sync.WaitGroup
+		settings       *homepage.Settings
+		serviceGroups  []*homepage.ServiceGroup
+		bookmarkGroups []*homepage.BookmarkGroup
+		dockerConfig   *homepage.DockerConfig
+		settingsErr    error
+		servicesErr    error
+		bookmarksErr   error
+		dockerErr      error
+		unknownKeys    [4][]homepage.UnknownKey
+	)
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		settings, settingsErr = homepage.LoadSettings(settingsPath)
+		unknownKeys[0] = homepage.CheckConfigKeys(settingsPath)
+	}()
+	go func() {
+		defer wg.Done()
+		serviceGroups, servicesErr = homepage.LoadServices(servicesPath)
+		unknownKeys[1] = homepage.CheckConfigKeys(servicesPath)
+	}()
+	go func() {
+		defer wg.Done()
+		bookmarkGroups, bookmarksErr = homepage.LoadBookmarks(bookmarksPath)
+		unknownKeys[2] = homepage.CheckConfigKeys(bookmarksPath)
+	}()
+	go func() {
+		defer wg.Done()
+		dockerConfig, dockerErr = homepage.LoadDockerConfig(dockerPath)
+		unknownKeys[3] = homepage.CheckConfigKeys(dockerPath)
+	}()
+	wg.Wait()
+
+	// Settings
+	if settingsErr != nil {
+		return nil, settingsErr
+	}
+
+	// Misspelled keys are otherwise silently ignored
+	if err := reportUnknownKeys(settings.UnknownKeys, slices.Concat(unknownKeys[:]...)); err != nil {
+		return nil, err
+	}
+
+	// Service groups. Invalid values are left out of services that are
+	// otherwise loaded.
+	var invalidValues *homepage.InvalidValuesError
+	if servicesErr != nil {
+		if strict {
+			return nil, servicesErr
+		}
+		logging.Warn("Warning: Error loading services: %v", servicesErr)
+		if !errors.As(servicesErr, &invalidValues) {
+			serviceGroups = []*homepage.ServiceGroup{}
+		}
+	} else {
+		logging.Info("Services loaded successfully: %d groups found.", len(serviceGroups))
+	}
+
+	// Bookmark groups
+	if bookmarksErr != nil {
+		if strict {
+			return nil, bookmarksErr
+		}
+		logging.Warn("Warning: Error loading bookmarks: %v", bookmarksErr)
+		bookmarkGroups = []*homepage.BookmarkGroup{}
+	} else {
+		logging.Info("Bookmarks loaded successfully: %d groups found.", len(bookmarkGroups))
+	}
+
+	// Docker configuration
+	if dockerErr != nil {
+		if strict {
+			return nil, dockerErr
+		}
+		logging.Warn("Warning: Error loading Docker config: %v", dockerErr)
+	} else if dockerConfig != nil {
+		logging.Info("Docker config loaded successfully.")
+	}
+
+	// Keep the groups of the profile and rewrite their URLs
+	serviceGroups, bookmarkGroups, err := homepage.ApplyProfile(settings, configProfile, serviceGroups, bookmarkGroups)
+	if err != nil {
+		return nil, err
+	}
+	if configProfile != "" {
+		logging.Info("Using profile: %s", configProfile)
+	}
+
+	// Order groups, services and bookmarks by weight
+	homepage.SortServiceGroups(serviceGroups, settings.Layout)
+	homepage.SortBookmarkGroups(bookmarkGroups, settings.Layout)
+
+	return &appConfig{
+		settings:       settings,
+		serviceGroups:  serviceGroups,
+		bookmarkGroups: bookmarkGroups,
+		dockerConfig:   dockerConfig,
+	}, nil
+}
+
+// reportUnknownKeys logs the unknown keys of the configuration files, or
+// returns them as an error when mode is "error"
+func reportUnknownKeys(mode string, unknownKeys []homepage.UnknownKey) error {
+	if len(unknownKeys) == 0 {
+		return nil
+	}
+	switch mode {
+	case "ignore":
+		return nil
+	case "error":
+		messages := make([]string, len(unknownKeys))
+		for i, key := range unknownKeys {
+			messages[i] = key.String()
+		}
+		return fmt.Errorf("unknown configuration keys:\n%s", strings.Join(messages, "\n"))
+	}
+	for _, key := range unknownKeys {
+		logging.Warn("Warning: %s", key)
+	}
+	return nil
+}
+
+// storeConfig stores the groups shown by the UI, the directories plugins and
+// scripts are looked up in, the rate limits of widget requests, the CAs
+// trusted by checks and the Docker daemon of volume and network checks
+func storeConfig(configDir string, cfg *appConfig, serviceGroups []*homepage.ServiceGroup) {
+	homepage.SetPluginDir(pluginDir(configDir, cfg.settings))
+	homepage.SetScriptDir(configDir)
+	homepage.SetRateLimits(cfg.settings.RateLimits)
+	homepage.SetCAs(cfg.settings.CAFile, cfg.settings.CADir)
+	homepage.SetDockerConfig(cfg.dockerConfig)
+
+	// Store groups for status updates
+	homepage.StoreCachedLayout(cfg.settings.Layout)
+	homepage.StoreCachedGroups(serviceGroups)
+	homepage.StoreCachedBookmarks(cfg.bookmarkGroups)
+}
+
+// reloadConfig re-reads the configuration files of a running instance,
+// compares the services with the previous configuration and replaces the
+// groups shown by the UI. Added services start being monitored, removed ones
+// stop, and changed ones are restarted. Unchanged services keep their current
+// status. Services discovered at runtime, such as Docker containers and remote
+// instances, are kept. previous is the configuration loaded before.
+func reloadConfig(configDir string, previous *appConfig, monitor *homepage.StatusMonitor) (*appConfig, error) {
+	cfg, err := readConfig(configDir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := mergeDiscoveredServices(cfg.serviceGroups, homepage.GetCachedGroups(), previous.serviceGroups)
+	homepage.SortServiceGroups(groups, cfg.settings.Layout)
+	storeConfig(configDir, cfg, groups)
+
+	added, removed, changed := diffServices(previous.serviceGroups, cfg.serviceGroups)
+	monitor.SetHistoryLimits(cfg.settings.Status.HistorySize, cfg.settings.Status.HistoryMemory<<20)
+
+	// Changed intervals apply to all services, so restart them all
+	if cfg.settings.Status.CheckInterval != previous.settings.Status.CheckInterval ||
+		cfg.settings.Status.RetryInterval != previous.settings.Status.RetryInterval {
+		monitor.SetGlobalInterval(cfg.settings.Status.CheckInterval)
+		monitor.SetGlobalRetryInterval(cfg.settings.Status.RetryInterval)
+		changed = nil
+		for _, group := range cfg.serviceGroups {
+			for _, service := range group.Services {
+				if service.Name != "" && !containsService(added, service.Name) {
+					changed = append(changed, service)
+				}
+			}
+		}
+	}
+
+	for _, name := range removed {
+		monitor.RemoveService(name)
+	}
+	for _, service := range changed {
+		monitor.UpdateService(service)
+	}
+	for _, service := range added {
+		if !monitor.IsMonitored(service.Name) {
+			monitor.AddService(service)
+		}
+	}
+
+	logging.Info("Configuration reloaded from %s: %d services added, %d removed, %d changed",
+		configDir, len(added), len(removed), len(changed))
+	return cfg, nil
+}
+
+// diffServices compares the services of two configurations by name and
+// returns the services that were added, the names of those that were removed
+// and the services whose configuration changed
+func diffServices(previous, current []*homepage.ServiceGroup) (added []*homepage.Service, removed []string, changed []*homepage.Service) {
+	before := make(map[string]*homepage.Service)
+	for _, group := range previous {
+		for _, service := range group.Services {
+			if service.Name != "" {
+				before[service.Name] = service
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, group := range current {
+		for _, service := range group.Services {
+			if service.Name == "" || seen[service.Name] {
+				continue
+			}
+			seen[service.Name] = true
+			old, exists := before[service.Name]
+			switch {
+			case !exists:
+				added = append(added, service)
+			case !reflect.DeepEqual(old, service):
+				changed = append(changed, service)
+			}
+		}
+	}
+
+	for _, group := range previous {
+		for _, service := range group.Services {
+			if _, exists := before[service.Name]; exists && !seen[service.Name] {
+				removed = append(removed, service.Name)
+				delete(before, service.Name)
+			}
+		}
+	}
+	return added, removed, changed
+}
+
+// containsService reports whether a service with the given name is in services
+func containsService(services []*homepage.Service, name string) bool {
+	for _, service := range services {
+		if service.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeDiscoveredServices returns the configured groups together with the
+// services of the shown groups that did not come from the previous
+// configuration, i.e. those discovered at runtime
+func mergeDiscoveredServices(configured, shown, previous []*homepage.ServiceGroup) []*homepage.ServiceGroup {
+	fromConfig := make(map[string]bool)
+	for _, group := range previous {
+		for _, service := range group.Services {
+			fromConfig[service.Name] = true
+		}
+	}
+
+	merged := make([]*homepage.ServiceGroup, 0, len(configured))
+	index := make(map[string]int)
+	for _, group := range configured {
+		index[group.Name] = len(merged)
+		merged = append(merged, &homepage.ServiceGroup{Name: group.Name, Services: append([]*homepage.Service{}, group.Services...)})
+	}
+	for _, group := range shown {
+		for _, service := range group.Services {
+			if fromConfig[service.Name] {
+				continue
+			}
+			i, exists := index[group.Name]
+			if !exists {
+				i = len(merged)
+				index[group.Name] = i
+				merged = append(merged, &homepage.ServiceGroup{Name: group.Name})
+			}
+			merged[i].Services = append(merged[i].Services, service)
+		}
+	}
+	return merged
+}
+
+// pluginDir returns the directory exec plugins are looked up in, resolving a
+// relative pluginDir setting against the config directory
+func pluginDir(configDir string, settings *homepage.Settings) string {
+	dir := settings.PluginDir
+	if dir == "" {
+		dir = "plugins"
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(configDir, dir)
+}
+
+// startStatusMonitor configures the status monitor from the loaded
+// configuration, runs the initial Docker autodiscovery and starts monitoring
+// the configured services
+func startStatusMonitor(statusMonitor *homepage.StatusMonitor, cfg *appConfig) {
+	settings, serviceGroups, dockerConfig := cfg.settings, cfg.serviceGroups, cfg.dockerConfig
+
+	// Set the global interval if configured
+	if settings.Status.CheckInterval > 0 {
+		statusMonitor.SetGlobalInterval(settings.Status.CheckInterval)
+	}
+	statusMonitor.SetGlobalRetryInterval(settings.Status.RetryInterval)
+	statusMonitor.SetHistoryLimits(settings.Status.HistorySize, settings.Status.HistoryMemory<<20)
+
+	// Run Docker autodiscovery if configured
+	if dockerConfig != nil {
+		logging.Info("Running initial Docker container autodiscovery...")
+		if err := statusMonitor.RunInitialDockerDiscovery(dockerConfig); err != nil {
+			logging.Warn("Failed to run initial Docker discovery: %v", err)
+		} else {
+			logging.Info("Initial Docker discovery completed successfully.")
+		}
+
+		// Add Docker monitoring for ongoing updates
+		if err := statusMonitor.AddDockerMonitoring(dockerConfig); err != nil {
+			logging.Warn("Failed to initialize Docker monitoring: %v", err)
+		} else {
+			logging.Info("Docker monitoring initialized successfully.")
+		}
+	}
+
+	// Add services to the status monitor
+	for _, group := range serviceGroups {
+		for _, service := range group.Services {
+			if service.Name == "" {
+				continue
+			}
+			statusMonitor.AddService(service)
+		}
+	}
+}
+
+// startControlSocket starts the control socket unless it is disabled in the
+// settings. It returns nil if the socket is disabled or can't be created.
+func startControlSocket(settings *homepage.Settings, monitor *homepage.StatusMonitor, reload control.ReloadFunc) *control.Server {
+	if settings.ControlSocket == "off" {
+		return nil
+	}
+	ctl := control.New(settings.ControlSocket, monitor, reload)
+	if err := ctl.Start(); err != nil {
+		logging.Warn("Control socket disabled: %v", err)
+		return nil
+	}
+	return ctl
+}
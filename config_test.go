@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfigInvalidValues(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "services.yaml"), []byte(`- Media:
+    - Plex:
+        siteMonitor: https://plex.lan
+        siteMonitorInterval: 5x
+`), 0644))
+
+	// Services keep their valid settings unless the configuration is strict
+	cfg, err := readConfig(dir, false)
+	require.NoError(t, err)
+	require.Len(t, cfg.serviceGroups, 1)
+	assert.Equal(t, "https://plex.lan", cfg.serviceGroups[0].Services[0].SiteMonitor)
+
+	_, err = readConfig(dir, true)
+	var invalidValues *homepage.InvalidValuesError
+	require.ErrorAs(t, err, &invalidValues)
+	assert.Equal(t, []string{filepath.Join(dir, "services.yaml") + `:4: invalid duration "5x": use a number of seconds or a duration such as 30s, 5m or 1h`}, invalidValues.Errors)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/control"
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/rivo/uniseg"
+)
+
+// newCtlCommand creates the `termhome ctl` command, whose subcommands talk to
+// a running dashboard or serve instance through its control socket
+func newCtlCommand() *cli.Command {
+	cmd := cli.NewCommand("ctl", "Control a running instance through its control socket")
+	cmd.AddCommand(
+		newCtlActionCommand(control.CommandReload, "Re-read the configuration files"),
+		newCtlActionCommand(control.CommandPause, "Pause status checks"),
+		newCtlActionCommand(control.CommandResume, "Resume paused status checks"),
+		newCtlCheckCommand(),
+		newCtlStatusCommand(),
+		newCtlNoteCommand(),
+		newCtlAuditCommand(),
+	)
+	return cmd
+}
+
+// addSocketFlags adds the --socket and --config-dir flags to a ctl command and
+// returns a function resolving the socket path: the --socket flag, otherwise
+// controlSocket from the settings, otherwise the default path
+func addSocketFlags(cmd *cli.Command) (func() string, *string) {
+	configDir := addConfigDirFlag(cmd)
+	socket := cmd.Flags.String("socket", "", "Path of the control socket (default: controlSocket from the settings)")
+	return func() string {
+		if *socket != "" {
+			return *socket
+		}
+		if settings, err := homepage.LoadSettings(filepath.Join(*configDir, "settings.yaml")); err == nil {
+			return settings.ControlSocket
+		}
+		return ""
+	}, configDir
+}
+
+// newCtlActionCommand creates a ctl subcommand sending a command without arguments
+func newCtlActionCommand(command, short string) *cli.Command {
+	cmd := cli.NewCommand(command, short)
+	socketPath, _ := addSocketFlags(cmd)
+	cmd.Run = func(args []string) int {
+		if len(args) != 0 {
+			cmd.PrintUsage()
+			return 2
+		}
+		resp, err := control.Call(socketPath(), &control.Request{Command: command})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(resp.Message)
+		return 0
+	}
+	return cmd
+}
+
+// newCtlCheckCommand creates the `termhome ctl check` command, which runs the
+// check of a service in the running instance right away
+func newCtlCheckCommand() *cli.Command {
+	cmd := cli.NewCommand("check", "Run the check of a service in the running instance now")
+	cmd.Usage = "[flags] <service name>"
+	socketPath, configDir := addSocketFlags(cmd)
+	cmd.Args = func(string) []string { return serviceNames(*configDir) }
+	cmd.Run = func(args []string) int {
+		if len(args) != 1 {
+			cmd.PrintUsage()
+			return exitUnknown
+		}
+		resp, err := control.Call(socketPath(), &control.Request{Command: control.CommandCheck, Args: args})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUnknown
+		}
+
+		result := resp.Check
+		fmt.Printf("Service:  %s\n", result.Name)
+		fmt.Printf("Status:   %s\n", strings.ToUpper(string(result.State)))
+		if result.Message != "" {
+			fmt.Printf("Message:  %s\n", result.Message)
+		}
+		if result.ResponseTimeMs > 0 {
+			fmt.Printf("Duration: %s\n", time.Duration(result.ResponseTimeMs)*time.Millisecond)
+		}
+		printDetails(result.Details)
+		return exitCode(result.State)
+	}
+	return cmd
+}
+
+// newCtlStatusCommand creates the `termhome ctl status` command, which prints
+// the current status of all services of the running instance
+func newCtlStatusCommand() *cli.Command {
+	cmd := cli.NewCommand("status", "Print the current status of all services")
+	socketPath, _ := addSocketFlags(cmd)
+	asJSON := cmd.Flags.Bool("json", false, "Print the statuses as JSON")
+	cmd.Run = func(args []string) int {
+		if len(args) != 0 {
+			cmd.PrintUsage()
+			return 2
+		}
+		resp, err := control.Call(socketPath(), &control.Request{Command: control.CommandStatus})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(resp); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			return 0
+		}
+
+		if resp.Paused {
+			fmt.Println("Monitoring is paused")
+			fmt.Println()
+		}
+		// Notes are shown in their own column when there are any
+		hasNotes := slices.ContainsFunc(resp.Statuses, func(status control.ServiceStatus) bool { return status.Note != "" })
+		rows := [][]string{{"STATE", "SERVICE", "GROUP", "CHECKED", "MESSAGE"}}
+		if hasNotes {
+			rows[0] = append(rows[0], "NOTE")
+		}
+		for _, status := range resp.Statuses {
+			checked := "-"
+			if !status.LastChecked.IsZero() {
+				checked = status.LastChecked.Format("15:04:05")
+			}
+			row := []string{strings.ToUpper(string(status.State)), status.Name, status.Group, checked, status.Message}
+			if hasNotes {
+				row = append(row, status.Note)
+			}
+			rows = append(rows, row)
+		}
+		printTable(rows)
+		return 0
+	}
+	return cmd
+}
+
+// newCtlNoteCommand creates the `termhome ctl note` command, which attaches a
+// note to a service or group of the running instance, or clears it
+func newCtlNoteCommand() *cli.Command {
+	cmd := cli.NewCommand("note", "Attach a note to a service or group, or clear it without a note")
+	cmd.Usage = "[flags] <service name> [note]"
+	socketPath, configDir := addSocketFlags(cmd)
+	group := cmd.Flags.Bool("group", false, "Attach the note to the group of that name rather than to a service")
+	cmd.Args = func(string) []string { return serviceNames(*configDir) }
+	cmd.Run = func(args []string) int {
+		if len(args) == 0 {
+			cmd.PrintUsage()
+			return 2
+		}
+		kind := "service"
+		if *group {
+			kind = "group"
+		}
+		// The words of the note may be passed unquoted
+		resp, err := control.Call(socketPath(), &control.Request{
+			Command: control.CommandNote,
+			Args:    []string{kind, args[0], strings.Join(args[1:], " ")},
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(resp.Message)
+		return 0
+	}
+	return cmd
+}
+
+// newCtlAuditCommand creates the `termhome ctl audit` command, which prints
+// who ran actions on the services of the running instance, and when
+func newCtlAuditCommand() *cli.Command {
+	cmd := cli.NewCommand("audit", "Print the actions run from the dashboard, oldest first")
+	socketPath, _ := addSocketFlags(cmd)
+	cmd.Run = func(args []string) int {
+		if len(args) != 0 {
+			cmd.PrintUsage()
+			return 2
+		}
+		resp, err := control.Call(socketPath(), &control.Request{Command: control.CommandHistory})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		type auditEntry struct {
+			service string
+			event   control.HistoryEvent
+		}
+		var entries []auditEntry
+		for _, service := range resp.History {
+			for _, event := range service.Events {
+				if event.User != "" {
+					entries = append(entries, auditEntry{service: service.Name, event: event})
+				}
+			}
+		}
+		if len(entries) == 0 {
+			fmt.Println("No actions were run")
+			return 0
+		}
+		slices.SortStableFunc(entries, func(a, b auditEntry) int { return a.event.Time.Compare(b.event.Time) })
+
+		rows := [][]string{{"TIME", "USER", "SERVICE", "ACTION"}}
+		for _, entry := range entries {
+			rows = append(rows, []string{entry.event.Time.Format("2006-01-02 15:04:05"), entry.event.User, entry.service, entry.event.Message})
+		}
+		printTable(rows)
+		return 0
+	}
+	return cmd
+}
+
+// printTable prints rows as columns aligned by display width, so names with
+// wide characters such as CJK and emoji line up, unlike with text/tabwriter
+func printTable(rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], uniseg.StringWidth(cell))
+		}
+	}
+
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-uniseg.StringWidth(cell)+2))
+			}
+		}
+		fmt.Println(strings.TrimRight(line.String(), " "))
+	}
+}
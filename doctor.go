@@ -0,0 +1,385 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/gdamore/tcell/v2"
+	"golang.org/x/term"
+)
+
+// doctorStatus is the outcome of a doctor check
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorSkip
+	doctorWarn
+	doctorFail
+)
+
+func (s doctorStatus) String() string {
+	switch s {
+	case doctorOK:
+		return " OK "
+	case doctorSkip:
+		return "SKIP"
+	case doctorWarn:
+		return "WARN"
+	}
+	return "FAIL"
+}
+
+// doctorResult is the result of a doctor check, with a way to fix it unless
+// it passed
+type doctorResult struct {
+	name   string
+	status doctorStatus
+	detail string
+	fix    string
+}
+
+// newDoctorCommand creates the `termhome doctor` command, which checks the
+// environment termhome runs in and prints how to fix what would keep checks
+// or the dashboard from working
+func newDoctorCommand() *cli.Command {
+	cmd := cli.NewCommand("doctor", "Diagnose the environment and print how to fix problems")
+	configDir := addConfigDirFlag(cmd)
+	profile := addProfileFlag(cmd)
+	timeout := cmd.Flags.Duration("timeout", 5*time.Second, "Maximum time to wait for each network check")
+	cmd.Run = func(args []string) int {
+		selectProfile(*profile)
+		return runDoctor(*configDir, *timeout)
+	}
+	return cmd
+}
+
+// runDoctor runs the doctor checks and prints their results. It fails if any
+// check fails.
+func runDoctor(configDir string, timeout time.Duration) int {
+	cfg, results := doctorConfig(configDir)
+	results = append(results,
+		doctorLogDir("./logs"),
+		doctorTerminal(),
+		doctorDNS(cfg, timeout),
+		doctorPing(timeout),
+		doctorDocker(cfg, timeout),
+	)
+
+	failed := false
+	for _, result := range results {
+		fmt.Printf("[%s] %s: %s\n", result.status, result.name, result.detail)
+		if result.fix != "" && result.status != doctorOK {
+			fmt.Printf("       Fix: %s\n", result.fix)
+		}
+		failed = failed || result.status == doctorFail
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// doctorConfig loads the configuration files, reporting those that don't
+// parse or have unknown keys. The configuration is nil if it can't be loaded.
+func doctorConfig(configDir string) (*appConfig, []doctorResult) {
+	var results []doctorResult
+	for _, file := range []string{"settings.yaml", "services.yaml", "bookmarks.yaml", "docker.yaml"} {
+		path := filepath.Join(configDir, file)
+		result := doctorResult{name: "Config " + file, status: doctorOK, detail: "valid"}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			result.status = doctorSkip
+			result.detail = "not found in " + configDir
+			result.fix = "run `termhome init --config-dir " + configDir + "` to create example files"
+			results = append(results, result)
+			continue
+		}
+
+		var err error
+		switch file {
+		case "settings.yaml":
+			_, err = homepage.LoadSettings(path)
+		case "services.yaml":
+			_, err = homepage.LoadServices(path)
+		case "bookmarks.yaml":
+			_, err = homepage.LoadBookmarks(path)
+		case "docker.yaml":
+			_, err = homepage.LoadDockerConfig(path)
+		}
+		if err != nil {
+			result.status = doctorFail
+			result.detail = err.Error()
+			result.fix = "correct the YAML at the reported line"
+		} else if unknown := homepage.CheckConfigKeys(path); len(unknown) > 0 {
+			result.status = doctorWarn
+			result.detail = fmt.Sprintf("%d unknown keys, first %s", len(unknown), unknown[0])
+			result.fix = "correct or remove the unknown keys, which are ignored"
+		}
+		results = append(results, result)
+	}
+
+	cfg, err := readConfig(configDir, false)
+	if errors.Is(err, homepage.ErrUnknownProfile) {
+		results = append(results, doctorResult{
+			name:   "Config profile",
+			status: doctorFail,
+			detail: err.Error(),
+			fix:    "add the profile under profiles in settings.yaml or select another one",
+		})
+	}
+	if err != nil {
+		return nil, results
+	}
+	return cfg, results
+}
+
+// doctorLogDir checks that the log directory can be written
+func doctorLogDir(dir string) doctorResult {
+	result := doctorResult{name: "Log directory", fix: "create " + dir + " and make it writable by the user running termhome, or run termhome from another directory"}
+	file, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		result.status = doctorFail
+		result.detail = fmt.Sprintf("cannot write to %s: %v", dir, err)
+		return result
+	}
+	file.Close()
+	os.Remove(file.Name())
+	result.status = doctorOK
+	result.detail = dir + " is writable"
+	return result
+}
+
+// doctorTerminal checks that the terminal can show the dashboard: its colors,
+// from the terminfo entry of $TERM, and the UTF-8 locale the Unicode icons and
+// borders need
+func doctorTerminal() doctorResult {
+	result := doctorResult{name: "Terminal", status: doctorOK}
+	var details, fixes []string
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		result.status = doctorWarn
+		details = append(details, "output is not a terminal")
+		fixes = append(fixes, "run the dashboard in an interactive terminal")
+	}
+
+	if runtime.GOOS != "windows" {
+		name := os.Getenv("TERM")
+		ti, err := tcell.LookupTerminfo(name)
+		switch {
+		case name == "":
+			result.status = doctorFail
+			details = append(details, "TERM is not set")
+			fixes = append(fixes, "set TERM, e.g. export TERM=xterm-256color")
+		case err != nil:
+			result.status = doctorFail
+			details = append(details, fmt.Sprintf("TERM=%s is not supported: %v", name, err))
+			fixes = append(fixes, "set TERM to a known terminal, e.g. export TERM=xterm-256color")
+		default:
+			colors := fmt.Sprintf("%d colors", ti.Colors)
+			colorTerm := os.Getenv("COLORTERM")
+			if ti.SetFgBgRGB != "" || colorTerm == "truecolor" || colorTerm == "24bit" {
+				colors = "true color"
+			}
+			details = append(details, fmt.Sprintf("TERM=%s with %s", name, colors))
+			if ti.Colors < 256 && colors != "true color" {
+				result.status = max(result.status, doctorWarn)
+				fixes = append(fixes, "use a 256 color terminal, e.g. export TERM=xterm-256color")
+			}
+		}
+
+		locale := cmp.Or(os.Getenv("LC_ALL"), os.Getenv("LC_CTYPE"), os.Getenv("LANG"))
+		if !strings.Contains(strings.ToUpper(strings.ReplaceAll(locale, "-", "")), "UTF8") {
+			result.status = max(result.status, doctorWarn)
+			details = append(details, fmt.Sprintf("locale %q is not UTF-8", locale))
+			fixes = append(fixes, "set a UTF-8 locale, e.g. export LANG=C.UTF-8, or set asciiOnly: true in settings.yaml")
+		}
+	}
+
+	result.detail = strings.Join(details, ", ")
+	result.fix = strings.Join(fixes, "; ")
+	return result
+}
+
+// doctorDNS resolves the hosts the services check
+func doctorDNS(cfg *appConfig, timeout time.Duration) doctorResult {
+	result := doctorResult{name: "DNS"}
+	hosts := checkedHosts(cfg)
+	if len(hosts) == 0 {
+		result.status = doctorSkip
+		result.detail = "no hosts to resolve"
+		return result
+	}
+
+	var failed []string
+	for _, host := range hosts {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		_, err := net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			failed = append(failed, host)
+		}
+	}
+	switch {
+	case len(failed) == 0:
+		result.status = doctorOK
+		result.detail = fmt.Sprintf("%d hosts resolved", len(hosts))
+	case len(failed) == len(hosts):
+		result.status = doctorFail
+		result.detail = fmt.Sprintf("none of %d hosts resolved: %s", len(hosts), strings.Join(failed, ", "))
+		result.fix = "check the nameservers in /etc/resolv.conf and the network connection"
+	default:
+		result.status = doctorWarn
+		result.detail = fmt.Sprintf("%d of %d hosts not resolved: %s", len(failed), len(hosts), strings.Join(failed, ", "))
+		result.fix = "check the spelling of these hosts, or add them to your DNS server or /etc/hosts"
+	}
+	return result
+}
+
+// checkedHosts returns the host names, not IP addresses, of the URLs, ping
+// and TCP targets of the services
+func checkedHosts(cfg *appConfig) []string {
+	if cfg == nil {
+		return nil
+	}
+	var hosts []string
+	add := func(host string) {
+		if host != "" && net.ParseIP(host) == nil && !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	addURL := func(rawURL string) {
+		if u, err := url.Parse(rawURL); err == nil {
+			add(u.Hostname())
+		}
+	}
+	for _, group := range cfg.serviceGroups {
+		for _, service := range group.Services {
+			addURL(service.SiteMonitor)
+			add(service.Ping)
+			if host, _, err := net.SplitHostPort(service.TCPCheck); err == nil {
+				add(host)
+			}
+			if service.Widget != nil {
+				addURL(service.Widget.URL)
+			}
+		}
+	}
+	return hosts
+}
+
+// doctorPing checks that ICMP echo requests can be sent
+func doctorPing(timeout time.Duration) doctorResult {
+	result := doctorResult{name: "Ping"}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	status := homepage.CheckOnce(ctx, &homepage.Service{Name: "doctor", Ping: "127.0.0.1", PingCount: 1}, nil)
+	if status.State == homepage.StatusOK {
+		result.status = doctorOK
+		result.detail = "127.0.0.1 answers"
+		return result
+	}
+	result.status = doctorFail
+	result.detail = status.Message
+	if strings.Contains(status.Message, "permitted") || strings.Contains(status.Message, "permission") {
+		result.fix = "allow ICMP without root: sudo sysctl -w net.ipv4.ping_group_range=\"0 2147483647\", " +
+			"or sudo setcap cap_net_raw+ep $(command -v termhome)"
+	} else {
+		result.fix = "check that a firewall doesn't drop ICMP on the loopback interface"
+	}
+	return result
+}
+
+// doctorDocker checks that the Docker daemon, if used, can be reached
+func doctorDocker(cfg *appConfig, timeout time.Duration) doctorResult {
+	result := doctorResult{name: "Docker"}
+	if cfg == nil || !usesDocker(cfg) {
+		result.status = doctorSkip
+		result.detail = "not configured"
+		return result
+	}
+	dockerConfig := cfg.dockerConfig
+	if dockerConfig == nil {
+		dockerConfig = &homepage.DockerConfig{}
+	}
+
+	if socket := dockerSocket(dockerConfig); socket != "" {
+		conn, err := net.DialTimeout("unix", socket, timeout)
+		if err != nil {
+			result.status = doctorFail
+			result.detail = fmt.Sprintf("cannot connect to %s: %v", socket, err)
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				result.fix = "start Docker, or set the socket in docker.yaml or DOCKER_HOST to its path"
+			case errors.Is(err, os.ErrPermission):
+				result.fix = "add the user to the docker group: sudo usermod -aG docker $USER, then log in again"
+			case errors.Is(err, syscall.ECONNREFUSED):
+				result.fix = "start the Docker daemon, e.g. sudo systemctl start docker"
+			}
+			return result
+		}
+		conn.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	count, err := homepage.CountDockerContainers(ctx, dockerConfig)
+	if err != nil {
+		result.status = doctorFail
+		result.detail = err.Error()
+		result.fix = "check the host and port in docker.yaml, or DOCKER_HOST, and that the daemon is running"
+		return result
+	}
+	result.status = doctorOK
+	result.detail = fmt.Sprintf("%d containers", count)
+	return result
+}
+
+// usesDocker reports whether docker.yaml is set or a service monitors a
+// container
+func usesDocker(cfg *appConfig) bool {
+	if cfg.dockerConfig != nil {
+		return true
+	}
+	for _, group := range cfg.serviceGroups {
+		for _, service := range group.Services {
+			if service.Container != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dockerSocket returns the path of the Unix socket of the Docker daemon, or
+// "" if it is reached over TCP or a named pipe
+func dockerSocket(config *homepage.DockerConfig) string {
+	switch {
+	case config.Socket != "":
+		return config.Socket
+	case config.Host != "":
+		return ""
+	}
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		path, ok := strings.CutPrefix(host, "unix://")
+		if !ok {
+			return ""
+		}
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	return "/var/run/docker.sock"
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Values of the criticalFocus setting
+const (
+	criticalFocusScroll   = "scroll"
+	criticalFocusMaximize = "maximize"
+	criticalFocusOff      = "off"
+)
+
+const (
+	// How long the border of a group flashes after a service turns critical
+	criticalFlashDuration = 3 * time.Second
+
+	// How long a group stays maximized after a service turns critical
+	criticalMaximizeDuration = 30 * time.Second
+)
+
+var (
+	// Last state of each service seen by the UI, to detect services turning critical
+	lastStates = make(map[string]homepage.StatusState)
+
+	// Set while a group is maximized because a service turned critical
+	autoMaximized bool
+)
+
+// trackCriticalState records the new state of a service and draws attention
+// to its group when it just turned critical. It must run on the UI goroutine.
+func trackCriticalState(serviceName string, state homepage.StatusState) {
+	previous := lastStates[serviceName]
+	lastStates[serviceName] = state
+
+	// Services failing their first check are not news
+	if state != homepage.StatusCritical || previous == homepage.StatusCritical ||
+		previous == homepage.StatusUnknown || previous == "" {
+		return
+	}
+
+	mode := criticalFocusScroll
+	if globalSettings != nil && globalSettings.CriticalFocus != "" {
+		mode = strings.ToLower(globalSettings.CriticalFocus)
+	}
+	if mode == criticalFocusOff {
+		return
+	}
+	// Muted services keep their state quietly
+	if monitor := homepage.GetStatusMonitor(); monitor != nil && monitor.IsMuted(serviceName) {
+		return
+	}
+
+	view, ok := serviceViews[findServiceGroupName(serviceName)]
+	if !ok {
+		return
+	}
+	focusService(view, serviceName)
+	flashBorder(view)
+
+	if mode == criticalFocusMaximize && !isMaximized && !preflightActive {
+		toggleMaximize()
+		autoMaximized = true
+		time.AfterFunc(criticalMaximizeDuration, func() {
+			app.QueueUpdateDraw(func() {
+				// Leave the layout alone if the user changed it meanwhile
+				if autoMaximized && isMaximized && maximizedBox == view {
+					toggleMaximize()
+				}
+				autoMaximized = false
+			})
+		})
+	}
+}
+
+// focusService focuses a group view and scrolls to the line of a service
+func focusService(view *tview.TextView, serviceName string) {
+	if !isMaximized {
+		currentFocus = view
+		app.SetFocus(view)
+	}
+	for i, line := range strings.Split(view.GetText(true), "\n") {
+		if strings.Contains(line, serviceName) {
+			view.ScrollTo(i, 0)
+			return
+		}
+	}
+}
+
+// flashBorder turns the border of a view red for a moment
+func flashBorder(view *tview.TextView) {
+	view.SetBorderColor(tcell.ColorRed)
+	time.AfterFunc(criticalFlashDuration, func() {
+		app.QueueUpdateDraw(func() {
+			view.SetBorderColor(tview.Styles.BorderColor)
+		})
+	})
+}
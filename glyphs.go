@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/rivo/tview"
+)
+
+// glyphSet holds the characters used for status icons and scrollbars
+type glyphSet struct {
+	ok          string
+	warning     string
+	critical    string
+	unknown     string
+	note        string
+	spinner     string // Frames shown while a check is running
+	scrollUp    rune
+	scrollDown  rune
+	scrollThumb rune
+	scrollTrack rune
+}
+
+var (
+	unicodeGlyphs = glyphSet{
+		ok:          "✓",
+		warning:     "!",
+		critical:    "✗",
+		unknown:     "?",
+		note:        "✎",
+		spinner:     "⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏",
+		scrollUp:    '▲',
+		scrollDown:  '▼',
+		scrollThumb: '█',
+		scrollTrack: '│',
+	}
+
+	asciiGlyphs = glyphSet{
+		ok:          "+",
+		warning:     "!",
+		critical:    "x",
+		unknown:     "?",
+		note:        "*",
+		spinner:     `|/-\`,
+		scrollUp:    '^',
+		scrollDown:  'v',
+		scrollThumb: '#',
+		scrollTrack: '|',
+	}
+
+	// glyphs are the characters currently in use
+	glyphs = unicodeGlyphs
+
+	// unicodeBorders are the default box-drawing borders of tview
+	unicodeBorders = tview.Borders
+)
+
+// setASCIIOnly switches icons, scrollbars, sparklines and borders between
+// Unicode and ASCII characters, for terminals and fonts that render Unicode badly
+func setASCIIOnly(asciiOnly bool) {
+	homepage.SetASCIIOnly(asciiOnly)
+	if !asciiOnly {
+		glyphs = unicodeGlyphs
+		tview.Borders = unicodeBorders
+		return
+	}
+
+	glyphs = asciiGlyphs
+	borders := &tview.Borders
+	borders.Horizontal, borders.Vertical = '-', '|'
+	borders.TopLeft, borders.TopRight, borders.BottomLeft, borders.BottomRight = '+', '+', '+', '+'
+	borders.LeftT, borders.RightT, borders.TopT, borders.BottomT, borders.Cross = '+', '+', '+', '+', '+'
+	// Focused boxes use double lines by default
+	borders.HorizontalFocus, borders.VerticalFocus = '=', '|'
+	borders.TopLeftFocus, borders.TopRightFocus, borders.BottomLeftFocus, borders.BottomRightFocus = '#', '#', '#', '#'
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// drawFunc draws the decorations of a box and returns the rectangle left for
+// its content, as set with SetDrawFunc
+type drawFunc func(screen tcell.Screen, x, y, width, height int) (int, int, int, int)
+
+// stickyHeaderDrawFunc wraps the draw function of the box of a service group,
+// keeping a header line with the group name and the states of its services
+// above the content once it is scrolled, so the context of the lines shown
+// isn't lost in long groups
+func stickyHeaderDrawFunc(textView *tview.TextView, groupName string, draw drawFunc) drawFunc {
+	return func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		left, top, innerWidth, innerHeight := draw(screen, x, y, width, height)
+		if rows, _ := textView.GetScrollOffset(); rows == 0 || innerHeight < 3 {
+			return left, top, innerWidth, innerHeight
+		}
+
+		header := fmt.Sprintf("[green::b]%s[-:-:-]", tview.Escape(groupName))
+		for _, group := range homepage.GetCachedGroups() {
+			if group.Name == groupName {
+				if rollup := groupRollup(group); rollup != "" {
+					header += " " + rollup
+				}
+				break
+			}
+		}
+		tview.Print(screen, header, left, top, innerWidth, tview.AlignLeft, tcell.ColorDefault)
+
+		// The content starts below the header
+		return left, top + 1, innerWidth, innerHeight - 1
+	}
+}
+
+// groupRollup returns the number of monitored services of a group in each
+// state, e.g. "✓ 5 ! 1 ✗ 2", leaving out the states no service is in
+func groupRollup(group *homepage.ServiceGroup) string {
+	monitor := homepage.GetStatusMonitor()
+	if monitor == nil {
+		return ""
+	}
+
+	counts := make(map[homepage.StatusState]int)
+	for _, entry := range monitor.GetAllStatuses() {
+		if entry.Group == group.Name && entry.Monitored && serviceShown(entry.Service, group.Name) {
+			counts[entry.State]++
+		}
+	}
+	var parts []string
+	for _, state := range []homepage.StatusState{homepage.StatusOK, homepage.StatusWarning, homepage.StatusCritical, homepage.StatusUnknown} {
+		if counts[state] > 0 {
+			color, icon := statusIcon(state)
+			parts = append(parts, fmt.Sprintf("[%s]%s %d[-]", color, icon, counts[state]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// groupColumns lays out the boxes of the service groups in as many columns as
+// fit its width, each at least minWidth wide. The columns are recomputed on
+// every draw, so they follow the terminal as it is resized.
+type groupColumns struct {
+	*tview.Flex
+	boxes    []tview.Primitive
+	minWidth int // Minimum width of a column, 0 for a single column
+	columns  int // Number of columns the boxes are arranged in
+}
+
+// newGroupColumns creates the columns of the boxes of the service groups
+func newGroupColumns(boxes []tview.Primitive, minWidth int) *groupColumns {
+	g := &groupColumns{
+		Flex:     tview.NewFlex().SetDirection(tview.FlexColumn),
+		boxes:    boxes,
+		minWidth: minWidth,
+	}
+	g.arrange(1)
+	return g
+}
+
+// Draw rearranges the boxes if the number of columns that fit changed since
+// the last draw, then draws them
+func (g *groupColumns) Draw(screen tcell.Screen) {
+	_, _, width, _ := g.GetRect()
+	if columns := columnCount(width, g.minWidth, len(g.boxes)); columns != g.columns {
+		g.arrange(columns)
+	}
+	g.Flex.Draw(screen)
+}
+
+// arrange splits the boxes into columns, keeping their order from top to
+// bottom and left to right, the first columns taking the extra boxes
+func (g *groupColumns) arrange(columns int) {
+	g.columns = columns
+	g.Clear()
+	for i := 0; i < columns; i++ {
+		column := tview.NewFlex().SetDirection(tview.FlexRow)
+		for _, box := range g.boxes[i*len(g.boxes)/columns : (i+1)*len(g.boxes)/columns] {
+			column.AddItem(box, 0, 1, false)
+		}
+		g.AddItem(column, 0, 1, false)
+	}
+}
+
+// columnCount returns the number of columns of at least minWidth that fit
+// width, at least one and at most one per box
+func columnCount(width, minWidth, boxes int) int {
+	if minWidth <= 0 {
+		return 1
+	}
+	return max(min(width/minWidth, boxes), 1)
+}
+
+// navigateWithArrows moves focus to the nearest box in the direction of an
+// arrow key, comparing where the boxes were last drawn so it follows the
+// layout whatever the size of the terminal
+func navigateWithArrows(key tcell.Key) {
+	if len(allFocusableBoxes) == 0 || currentFocus == nil {
+		return
+	}
+
+	x, y, width, height := currentFocus.GetRect()
+	var nearest tview.Primitive
+	nearestDistance := 0
+	for _, box := range allFocusableBoxes {
+		boxX, boxY, boxWidth, boxHeight := box.GetRect()
+		if box == currentFocus || boxWidth <= 0 || boxHeight <= 0 {
+			continue
+		}
+
+		// The gap to the box in the direction of the key, and how far it is
+		// off to the side
+		var gap, offset int
+		switch key {
+		case tcell.KeyUp:
+			gap, offset = y-(boxY+boxHeight), (boxX+boxWidth/2)-(x+width/2)
+		case tcell.KeyDown:
+			gap, offset = boxY-(y+height), (boxX+boxWidth/2)-(x+width/2)
+		case tcell.KeyLeft:
+			gap, offset = x-(boxX+boxWidth), (boxY+boxHeight/2)-(y+height/2)
+		case tcell.KeyRight:
+			gap, offset = boxX-(x+width), (boxY+boxHeight/2)-(y+height/2)
+		default:
+			return
+		}
+		if gap < 0 {
+			continue
+		}
+		if distance := gap + 2*max(offset, -offset); nearest == nil || distance < nearestDistance {
+			nearest, nearestDistance = box, distance
+		}
+	}
+
+	if nearest != nil {
+		currentFocus = nearest
+		app.SetFocus(currentFocus)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// logBox is a log file tailed in a box of the logs panel
+type logBox struct {
+	name       string
+	tail       *homepage.LogTail
+	highlights []logHighlight
+	view       *tview.TextView // nil until the layout is built
+}
+
+// logHighlight is a compiled highlight pattern of a log box
+type logHighlight struct {
+	pattern *regexp.Regexp
+	color   string
+}
+
+var (
+	// Tailed log files, in configuration order
+	logBoxes []*logBox
+
+	// Configuration of the tailed log files, to restart them only on changes
+	logConfigs []homepage.LogConfig
+)
+
+// startLogTails starts tailing the configured log files, stopping those of a
+// previous configuration. It must run on the UI goroutine.
+func startLogTails(configs []homepage.LogConfig) {
+	if reflect.DeepEqual(configs, logConfigs) && len(logBoxes) == len(configs) {
+		return
+	}
+	stopLogTails()
+	logConfigs = configs
+
+	for _, config := range configs {
+		if config.Path == "" {
+			logging.Warn("Log %q has no path, skipping it", config.Name)
+			continue
+		}
+		box := &logBox{name: config.Name}
+		if box.name == "" {
+			box.name = filepath.Base(config.Path)
+		}
+		for _, highlight := range config.Highlight {
+			pattern, err := regexp.Compile(highlight.Pattern)
+			if err != nil {
+				logging.Warn("Invalid highlight pattern %q of log %s: %v", highlight.Pattern, box.name, err)
+				continue
+			}
+			color := highlight.Color
+			if color == "" {
+				color = "yellow"
+			}
+			box.highlights = append(box.highlights, logHighlight{pattern: pattern, color: color})
+		}
+		box.tail = homepage.NewLogTail(config.Path, config.Lines, func() {
+			if appInitialized {
+				app.QueueUpdateDraw(box.render)
+			}
+		})
+		box.tail.Start()
+		logBoxes = append(logBoxes, box)
+	}
+}
+
+// stopLogTails stops tailing all log files
+func stopLogTails() {
+	for _, box := range logBoxes {
+		box.tail.Stop()
+	}
+	logBoxes = nil
+	logConfigs = nil
+}
+
+// createLogsPanel creates a panel with a box per tailed log file
+func createLogsPanel() tview.Primitive {
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow)
+
+	// Add title
+	title := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter).
+		SetText("[::b]Logs[-]")
+	title.SetBorder(true)
+	flex.AddItem(title, 3, 1, false)
+
+	for _, box := range logBoxes {
+		textView := tview.NewTextView().
+			SetDynamicColors(true).
+			SetWrap(false).
+			SetScrollable(true)
+		textView.SetBorder(true).
+			SetTitle(box.name).
+			SetTitleColor(tcell.ColorYellow)
+		setupFocusableBox(textView)
+
+		box.view = textView
+		box.render()
+		// Follow new lines until the user scrolls up
+		textView.ScrollToEnd()
+		flex.AddItem(textView, 0, 1, false)
+	}
+	return flex
+}
+
+// render shows the last lines of the log file in its box
+func (b *logBox) render() {
+	if b.view == nil {
+		return
+	}
+	b.view.Clear()
+	lines, err := b.tail.Lines()
+	if err != nil {
+		fmt.Fprintf(b.view, "[red]%s[-]\n", tview.Escape(err.Error()))
+		return
+	}
+	for i, line := range lines {
+		lines[i] = highlightLogLine(line, b.highlights)
+	}
+	fmt.Fprint(b.view, strings.Join(lines, "\n"))
+}
+
+// highlightLogLine escapes a log line for a text view and colors the matches
+// of the highlight patterns, the first pattern matching a part of the line
+// taking precedence
+func highlightLogLine(line string, highlights []logHighlight) string {
+	if len(highlights) == 0 {
+		return tview.Escape(line)
+	}
+
+	colors := make([]string, len(line))
+	for i := len(highlights) - 1; i >= 0; i-- {
+		for _, match := range highlights[i].pattern.FindAllStringIndex(line, -1) {
+			for j := match[0]; j < match[1]; j++ {
+				colors[j] = highlights[i].color
+			}
+		}
+	}
+
+	var b strings.Builder
+	start := 0
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && colors[i] == colors[start] {
+			continue
+		}
+		segment := tview.Escape(line[start:i])
+		if colors[start] != "" {
+			segment = downsampleTags("["+colors[start]+"]") + segment + "[-]"
+		}
+		b.WriteString(segment)
+		start = i
+	}
+	return b.String()
+}
@@ -2,20 +2,22 @@ package main
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
+	"unicode"
 
-	"github.com/deblasis/termhome/pkg/config"
 	"github.com/deblasis/termhome/pkg/homepage"
 	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/deblasis/termhome/pkg/server"
+	"github.com/deblasis/termhome/pkg/version"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
 )
 
 // Global variables for UI management
@@ -39,6 +41,14 @@ var (
 	// Global settings
 	globalSettings *homepage.Settings
 
+	// Configuration loaded from the config directory, replaced on reload
+	activeConfig *appConfig
+	reloadMutex  sync.Mutex
+	headerError  string // Last error of an action, e.g. a failed reload, shown in the header
+
+	// Formats the time services were last checked
+	timestamps = homepage.NewTimestampFormatter(homepage.TimestampSettings{})
+
 	// Navigation state
 	currentFocus      tview.Primitive
 	allFocusableBoxes []tview.Primitive
@@ -74,106 +84,59 @@ func init() {
 }
 
 func main() {
-	logging.Info("Starting Termhome")
-
-	// --- Command line handling ---
-	// Check if there's a subcommand
-	if len(os.Args) > 1 && os.Args[1] == "init" {
-		// Parse flags for the init subcommand
-		initCmd := flag.NewFlagSet("init", flag.ExitOnError)
-		configDirInit := initCmd.String("config-dir", "./config", "Directory to create example configuration files in")
-		if len(os.Args) > 2 {
-			initCmd.Parse(os.Args[2:])
-		}
-
-		// Initialize configs
-		if err := config.InitializeConfigDir(*configDirInit); err != nil {
-			logging.Fatal("Failed to initialize example configs: %v", err)
-		}
-		logging.Info("Example configuration files created in %s", *configDirInit)
-		fmt.Printf("Example configuration files created in %s\n", *configDirInit)
-		return
-	}
+	logging.Info("Starting Termhome %s", version.Get().Short())
+	os.Exit(newRootCommand().Execute(os.Args[1:]))
+}
 
-	// Main application flags
-	mainCmd := flag.NewFlagSet("termhome", flag.ExitOnError)
-	configDir := mainCmd.String("config-dir", "./config", "Directory containing the configuration files")
-	logLevel := mainCmd.String("log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR, FATAL)")
-	mainCmd.Parse(os.Args[1:])
+// runDashboard loads the configuration and runs the terminal dashboard,
+// serving pprof endpoints at profileAddr if not empty, showing only the
+// services and bookmarks with one of the tags if any and the time each draw
+// takes if stats is set
+func runDashboard(configDir, logLevel, profileAddr string, tags []string, stats bool) int {
+	startTime := time.Now()
 
 	// Set log level from command line
-	logging.SetGlobalLogLevel(logging.ParseLogLevel(*logLevel))
+	logging.SetGlobalLogLevel(logging.ParseLogLevel(logLevel))
+	activeTags = tags
 
-	logging.Info("Using config directory: %s", *configDir)
-
-	// --- Configuration paths ---
-	settingsPath := filepath.Join(*configDir, "settings.yaml")
-	servicesPath := filepath.Join(*configDir, "services.yaml")
-	bookmarksPath := filepath.Join(*configDir, "bookmarks.yaml")
-	dockerPath := filepath.Join(*configDir, "docker.yaml")
+	if profileAddr != "" {
+		if profiler := startProfiler(profileAddr); profiler != nil {
+			defer profiler.Close()
+		}
+	}
 
-	logging.Info("Config paths: settings=%s, services=%s, bookmarks=%s, docker=%s",
-		settingsPath, servicesPath, bookmarksPath, dockerPath)
+	logging.Info("Using config directory: %s", configDir)
 
-	// Load settings
-	settings, err := homepage.LoadSettings(settingsPath)
-	if err != nil {
-		logging.Fatal("Error loading settings: %v", err)
-	} else {
-		logging.Info("Settings loaded successfully.")
-	}
+	cfg := loadConfig(configDir)
+	logging.Info("Configuration loaded in %s", time.Since(startTime).Round(time.Millisecond))
+	settings, serviceGroups, bookmarkGroups := cfg.settings, cfg.serviceGroups, cfg.bookmarkGroups
 
 	// Store settings globally
 	globalSettings = settings
-
-	// Load service groups
-	serviceGroups, err := homepage.LoadServices(servicesPath)
-	if err != nil {
-		logging.Warn("Warning: Error loading services: %v", err)
-		serviceGroups = []*homepage.ServiceGroup{}
-	} else {
-		logging.Info("Services loaded successfully: %d groups found.", len(serviceGroups))
-	}
-
-	// Load bookmark groups
-	bookmarkGroups, err := homepage.LoadBookmarks(bookmarksPath)
-	if err != nil {
-		logging.Warn("Warning: Error loading bookmarks: %v", err)
-		bookmarkGroups = []*homepage.BookmarkGroup{}
-	} else {
-		logging.Info("Bookmarks loaded successfully: %d groups found.", len(bookmarkGroups))
-	}
-
-	// Load Docker configuration
-	dockerConfig, err := homepage.LoadDockerConfig(dockerPath)
-	if err != nil {
-		logging.Warn("Warning: Error loading Docker config: %v", err)
-	} else if dockerConfig != nil {
-		logging.Info("Docker config loaded successfully.")
-	}
+	activeConfig = cfg
+	applyDisplaySettings(settings)
 
 	// Create a context that will be canceled when the program exits
 	ctx, cancel := context.WithCancel(context.Background())
 	globalCtx = ctx
 	globalCancel = cancel
 
-	// Store groups for status updates
-	homepage.StoreCachedGroups(serviceGroups)
-	homepage.StoreCachedBookmarks(bookmarkGroups)
-
 	// Initialize status monitor
 	logging.Info("Initializing status monitor...")
 	statusMonitor := homepage.NewStatusMonitor(statusUpdateCallback)
 	homepage.SetStatusMonitor(statusMonitor) // Set global monitor
 	defer statusMonitor.Stop()               // Ensure it stops when program exits
 
-	// Set the global interval if configured
-	if settings.Status.CheckInterval > 0 {
-		statusMonitor.SetGlobalInterval(settings.Status.CheckInterval)
+	// Services disabled and notes set from the dashboard are kept across restarts
+	restoreDashboardState(statusMonitor, statePath(configDir, settings))
+
+	// Page titles fetched by previous runs are shown right away
+	if err := homepage.LoadBookmarkTitles(homepage.DefaultBookmarkTitleCache()); err != nil {
+		logging.Warn("Warning: %v", err)
 	}
 
 	// Check if we have any content to display, and show a message if not
-	noServices := len(serviceGroups) == 0
+	noServices := len(serviceGroups) == 0 && len(settings.Remotes) == 0
 	noBookmarks := len(bookmarkGroups) == 0
 
 	if noServices && noBookmarks {
@@ -185,7 +148,7 @@ func main() {
 				"[red]No services or bookmarks are defined.[:-:-]\n\n"+
 				"Please adjust the configuration in [green]%s[:-:-]\n\n"+
 				"[red]If you want example configuration files,\n"+
-				"run [green]termhome init[:-:-]", *configDir))
+				"run [green]termhome init[:-:-]", configDir))
 
 		messageBox.SetBorder(true)
 
@@ -201,44 +164,78 @@ func main() {
 		if err := app.SetRoot(messageBox, true).Run(); err != nil {
 			logging.Fatal("Application error: %v", err)
 		}
-		return
+		return 0
 	}
 
 	// Initialize the application
 	app = tview.NewApplication()
+	setupScreen(settings.Colors)
 
-	// Create main container
-	mainContainer = createMainContainer(settings, serviceGroups, bookmarkGroups)
+	// Tail the log files shown in the logs panel
+	startLogTails(settings.Logs)
+	defer stopLogTails()
 
-	// Run Docker autodiscovery if configured
-	if dockerConfig != nil {
-		logging.Info("Running initial Docker container autodiscovery...")
-		if err := statusMonitor.RunInitialDockerDiscovery(dockerConfig); err != nil {
-			logging.Warn("Failed to run initial Docker discovery: %v", err)
-		} else {
-			logging.Info("Initial Docker discovery completed successfully.")
+	// Rebuild the layout when services are discovered at runtime
+	homepage.RegisterUIRebuildFunc(func() {
+		if appInitialized {
+			app.QueueUpdateDraw(rebuildLayout)
 		}
+	})
 
-		// Add Docker monitoring for ongoing updates
-		if err := statusMonitor.AddDockerMonitoring(dockerConfig); err != nil {
-			logging.Warn("Failed to initialize Docker monitoring: %v", err)
-		} else {
-			logging.Info("Docker monitoring initialized successfully.")
-		}
+	// Keep relative timestamps and countdowns current
+	go refreshTimestamps(ctx)
+
+	// Switch to compact mode on small terminals
+	app.SetBeforeDrawFunc(detectCompactMode)
+
+	// Create main container
+	mainContainer = createMainContainer(settings, homepage.GetCachedGroups(), bookmarkGroups)
+
+	// Set app as initialized
+	appInitialized = true
+
+	// Start monitoring once the dashboard is shown, so the first checks and
+	// Docker autodiscovery don't delay it. Discovered services and the groups
+	// of remote instances are added as they arrive.
+	var startMonitoring sync.Once
+	monitoringStarted := make(chan struct{})
+	app.SetAfterDrawFunc(func(tcell.Screen) {
+		startMonitoring.Do(func() {
+			logging.Info("Dashboard shown %s after start", time.Since(startTime).Round(time.Millisecond))
+			go func() {
+				// Monitor the configuration of a reload done in the meantime
+				reloadMutex.Lock()
+				defer reloadMutex.Unlock()
+				startStatusMonitor(statusMonitor, activeConfig)
+				close(monitoringStarted)
+				statusMonitor.StartBookmarkChecks(activeConfig.settings.BookmarkChecks)
+				go fetchBookmarkTitles(ctx, activeConfig.settings)
+				for _, remote := range activeConfig.settings.Remotes {
+					statusMonitor.AddRemote(remote)
+				}
+				statusMonitor.StartMDNSDiscovery(activeConfig.settings.MDNS)
+			}()
+		})
+	})
+	if stats {
+		enableStatsOverlay()
 	}
 
-	// Add services to the status monitor
-	for _, group := range serviceGroups {
-		for _, service := range group.Services {
-			if service.Name == "" {
-				continue
-			}
-			statusMonitor.AddService(service)
+	// Serve the status API, which also accepts pushes from agents
+	if settings.API.Listen != "" {
+		apiServer := server.New(settings.API.Listen, settings.API.Token, settings.InstanceName, statusMonitor)
+		apiServer.SetStatusPage(settings.API.StatusPage)
+		if err := apiServer.Start(); err != nil {
+			logging.Error("Failed to start API server: %v", err)
+		} else {
+			defer apiServer.Shutdown(context.Background())
 		}
 	}
 
-	// Set app as initialized
-	appInitialized = true
+	// Accept commands from `termhome ctl`
+	if ctl := startControlSocket(settings, statusMonitor, func() error { return reloadDashboard(configDir, statusMonitor) }); ctl != nil {
+		defer ctl.Close()
+	}
 
 	// Handle OS signals
 	sigCh := make(chan os.Signal, 1)
@@ -250,14 +247,42 @@ func main() {
 		app.Stop()
 	}()
 
+	// Rotate the maximized group, pausing while the dashboard is used
+	scheduleCarousel(settings.Carousel)
+	app.SetMouseCapture(recordMouseInput)
+
 	// Set up key handlers
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		lastInput = time.Now()
+
+		// Keys go to the search input while it is shown
+		if searchActive {
+			if event.Key() == tcell.KeyEscape {
+				closeSearch()
+				return nil
+			}
+			return event
+		}
+		if pickerActive {
+			if event.Key() == tcell.KeyEscape {
+				closeServicePicker()
+				return nil
+			}
+			return event
+		}
+
 		// Global key handlers
 		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' || event.Rune() == 'Q' {
 			app.Stop()
 			return nil
 		}
 
+		// Other keys skip the pre-flight checks
+		if preflightActive {
+			endPreflight()
+			return nil
+		}
+
 		// Tab key to cycle focus between boxes
 		if event.Key() == tcell.KeyTab {
 			if len(allFocusableBoxes) > 0 {
@@ -285,21 +310,127 @@ func main() {
 			return nil
 		}
 
+		// F5 or Ctrl+R to reload the configuration files
+		if event.Key() == tcell.KeyF5 || event.Key() == tcell.KeyCtrlR {
+			go func() {
+				if err := reloadDashboard(configDir, statusMonitor); err != nil {
+					logging.Error("Reload failed: %v", err)
+				}
+			}()
+			return nil
+		}
+
+		// C to switch between the compact and the full layout
+		if event.Rune() == 'c' || event.Rune() == 'C' {
+			toggleCompactMode()
+			return nil
+		}
+
+		// S to search the web
+		if event.Rune() == 's' || event.Rune() == 'S' {
+			openSearch()
+			return nil
+		}
+
+		// D to turn the monitoring of a service of the focused group on or off
+		if event.Rune() == 'd' || event.Rune() == 'D' {
+			openServicePicker()
+			return nil
+		}
+
+		// N to attach a note to the focused group or one of its services
+		if event.Rune() == 'n' || event.Rune() == 'N' {
+			openNotePicker()
+			return nil
+		}
+
+		// T to filter the services and bookmarks by tag
+		if event.Rune() == 't' || event.Rune() == 'T' {
+			openTagPicker()
+			return nil
+		}
+
+		// A to run an action of a service of the focused group
+		if event.Rune() == 'a' || event.Rune() == 'A' {
+			openActionPicker()
+			return nil
+		}
+
+		// M to mute a flapping service of the focused group for a while
+		if event.Rune() == 'm' || event.Rune() == 'M' {
+			openMutePicker()
+			return nil
+		}
+
+		// W to wake up the host of a service of the focused group
+		if event.Rune() == 'w' || event.Rune() == 'W' {
+			openWakePicker()
+			return nil
+		}
+
 		// Space key to maximize/restore focused box
 		if event.Rune() == ' ' {
 			toggleMaximize()
 			return nil
 		}
 
+		// Other letters jump to the next group whose name begins with them
+		if event.Key() == tcell.KeyRune && event.Modifiers()&(tcell.ModAlt|tcell.ModCtrl) == 0 &&
+			unicode.IsLetter(event.Rune()) {
+			jumpToGroup(event.Rune())
+			return nil
+		}
+
 		return event
 	})
 
+	// Show the results of the first checks before the dashboard if enabled
+	root := tview.Primitive(mainContainer)
+	if settings.Preflight.Enabled {
+		root = newPreflightView(ctx, settings.Preflight, statusMonitor, monitoringStarted)
+	}
+
 	// Run the application
-	if err := app.SetRoot(mainContainer, true).EnableMouse(true).Run(); err != nil {
+	if err := app.SetRoot(root, true).EnableMouse(true).Run(); err != nil {
 		logging.Fatal("Application error: %v", err)
 	}
 
+	// The next run shows the last statuses until it checks the services again
+	if err := saveMonitorState(statusMonitor, activeStatePath); err != nil {
+		logging.Error("Failed to save the dashboard state: %v", err)
+	}
+
 	logging.Info("Termhome exiting...")
+	return 0
+}
+
+// reloadDashboard re-reads the configuration and rebuilds the layout. It must
+// not be called from the UI goroutine.
+func reloadDashboard(configDir string, monitor *homepage.StatusMonitor) error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	cfg, err := reloadConfig(configDir, activeConfig, monitor)
+	if err != nil {
+		app.QueueUpdateDraw(func() {
+			headerError = "Reload failed: " + err.Error()
+			rebuildLayout()
+		})
+		return err
+	}
+	activeConfig = cfg
+	monitor.StartBookmarkChecks(cfg.settings.BookmarkChecks)
+	go fetchBookmarkTitles(context.Background(), cfg.settings)
+
+	app.QueueUpdateDraw(func() {
+		globalSettings = cfg.settings
+		headerError = ""
+		applyDisplaySettings(cfg.settings)
+		startLogTails(cfg.settings.Logs)
+		scheduleCarousel(cfg.settings.Carousel)
+		rebuildLayout()
+	})
+	return nil
 }
 
 // createMainContainer creates the main UI with individual boxes
@@ -308,11 +439,20 @@ func createMainContainer(settings *homepage.Settings, serviceGroups []*homepage.
 	mainFlex := tview.NewFlex().
 		SetDirection(tview.FlexRow)
 
+	// Leave out the groups hidden by the tag filter, and their stale views
+	serviceGroups = filterServiceGroups(serviceGroups)
+	bookmarkGroups = filterBookmarkGroups(bookmarkGroups)
+	serviceViews = make(map[string]*tview.TextView)
+
+	if compactMode {
+		return createCompactContainer(settings, serviceGroups, bookmarkGroups)
+	}
+
 	// Create header with title
 	header := tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignCenter).
-		SetText(fmt.Sprintf("[yellow::b]%s[-]", settings.Title))
+		SetText(headerText(settings))
 	header.SetBorder(true)
 
 	// Create content area split into services and bookmarks columns
@@ -324,29 +464,31 @@ func createMainContainer(settings *homepage.Settings, serviceGroups []*homepage.
 
 	// Create services panel if available
 	if len(serviceGroups) > 0 {
-		servicesPanel := createServicesPanel(serviceGroups)
+		servicesPanel := createServicesPanel(settings, serviceGroups)
 		contentFlex.AddItem(servicesPanel, 0, 1, true) // Weight 1, focusable
 	}
 
+	// Create logs panel if log files are tailed
+	if len(logBoxes) > 0 {
+		contentFlex.AddItem(createLogsPanel(), 0, 1, false)
+	}
+
 	// Create bookmarks panel if available
 	if len(bookmarkGroups) > 0 {
 		bookmarksPanel := createBookmarksPanel(bookmarkGroups)
 		contentFlex.AddItem(bookmarksPanel, 0, 1, false) // Weight 1, not focusable by default
 	}
 
-	// Create footer with help - smaller, just text
-	footer := tview.NewTextView().
-		SetDynamicColors(true).
-		SetTextAlign(tview.AlignCenter).
-		SetText("[red]Q/Esc: Quit | Tab/Arrows: Navigate | Space/DoubleClick: Maximize[-]")
-
-	// No border for status bar, make it smaller
-	footer.SetBorder(false)
+	// Create footer with the status bar and help - smaller, just text
+	footer := newStatusBar(footerHints(settings.Footer.Hints, "Q/Esc: Quit | Tab/Arrows: Navigate | Space/DoubleClick: Maximize | F5/Ctrl+R: Reload | C: Compact | S: Search | D: Disable checks | M: Mute | N: Note | T: Tags | A: Actions | W: Wake | Other letters: Jump to group"))
 
 	// Add components to main layout
 	mainFlex.AddItem(header, 3, 1, false)     // Height 3, not focusable
 	mainFlex.AddItem(contentFlex, 0, 1, true) // Expand to fill space, focusable
 	mainFlex.AddItem(footer, 1, 1, false)     // Height 1, not focusable - reduced from 3 to 1
+	if lines := settings.Footer.Lines; len(lines) > 0 {
+		mainFlex.AddItem(newFooterLines(lines), len(lines), 0, false)
+	}
 
 	// Save original layout for maximize/restore
 	originalLayout = mainFlex
@@ -360,8 +502,82 @@ func createMainContainer(settings *homepage.Settings, serviceGroups []*homepage.
 	return mainFlex
 }
 
+// applyDisplaySettings applies the settings controlling how the dashboard
+// renders, on start and after a reload
+func applyDisplaySettings(settings *homepage.Settings) {
+	setASCIIOnly(settings.ASCIIOnly)
+	timestamps = homepage.NewTimestampFormatter(settings.Timestamps)
+}
+
+// refreshTimestamps re-renders the status bar and the service groups every
+// second so relative timestamps and the countdowns in their titles stay
+// current, until ctx is canceled
+func refreshTimestamps(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			app.QueueUpdateDraw(func() {
+				updateStatusBar(time.Now())
+				if compactMode {
+					return
+				}
+				for _, group := range homepage.GetCachedGroups() {
+					view, ok := serviceViews[group.Name]
+					if !ok {
+						continue
+					}
+					// Only the countdown in the title changes with absolute timestamps
+					if timestamps.Relative() {
+						renderServiceGroup(view, group)
+					} else {
+						view.SetTitle(groupTitle(group, time.Now()))
+					}
+				}
+			})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// headerText returns the title, followed by the version unless hidden, the
+// profile and the paused, tags and error notices
+func headerText(settings *homepage.Settings) string {
+	text := fmt.Sprintf("[yellow::b]%s[-:-:-]", settings.Title)
+	if !settings.HideVersion {
+		text += fmt.Sprintf(" [gray]%s[-]", version.Get().Short())
+	}
+	if configProfile != "" {
+		text += fmt.Sprintf(" [aqua]%s[-]", tview.Escape(configProfile))
+	}
+	if monitor := homepage.GetStatusMonitor(); monitor != nil && monitor.Paused() {
+		text += " [black:yellow] PAUSED [-:-]"
+	}
+	if len(activeTags) > 0 {
+		text += fmt.Sprintf(" [black:aqua] TAGS: %s [-:-]", tview.Escape(strings.Join(activeTags, ", ")))
+	}
+	if headerError != "" {
+		text += fmt.Sprintf(" [white:red] %s [-:-]", tview.Escape(headerError))
+	}
+	return text
+}
+
+// rebuildLayout recreates the main container from the cached groups
+func rebuildLayout() {
+	isMaximized = false
+	searchActive = false
+	mainContainer = createMainContainer(globalSettings, homepage.GetCachedGroups(), homepage.GetCachedBookmarks())
+	// The pre-flight screen shows the dashboard once done
+	if !preflightActive {
+		app.SetRoot(mainContainer, true)
+	}
+}
+
 // createServicesPanel creates a panel with service groups
-func createServicesPanel(serviceGroups []*homepage.ServiceGroup) tview.Primitive {
+func createServicesPanel(settings *homepage.Settings, serviceGroups []*homepage.ServiceGroup) tview.Primitive {
 	// Create a flex layout for services
 	flex := tview.NewFlex().
 		SetDirection(tview.FlexRow)
@@ -374,12 +590,12 @@ func createServicesPanel(serviceGroups []*homepage.ServiceGroup) tview.Primitive
 	title.SetBorder(true)
 	flex.AddItem(title, 3, 1, false)
 
-	// Add each service group
+	// Add each service group, in as many columns as fit
+	var boxes []tview.Primitive
 	for _, group := range serviceGroups {
-		// Create a box for the group
-		groupView := createServiceGroupBox(group)
-		flex.AddItem(groupView, 0, 1, false)
+		boxes = append(boxes, createServiceGroupBox(group))
 	}
+	flex.AddItem(newGroupColumns(boxes, settings.ColumnWidth), 0, 1, false)
 
 	return flex
 }
@@ -397,9 +613,26 @@ func createServiceGroupBox(group *homepage.ServiceGroup) *tview.TextView {
 		SetTitle(group.Name).
 		SetTitleColor(tcell.ColorGreen)
 
+	// Compact mode shows the group name as a heading line instead
+	if compactMode {
+		textView.SetWrap(false).SetBorder(false)
+	}
+
 	// Save the view for updates
 	serviceViews[group.Name] = textView
 
+	setupFocusableBox(textView)
+	textView.SetDrawFunc(stickyHeaderDrawFunc(textView, group.Name, scrollbarDrawFunc(textView)))
+
+	// Generate initial content
+	renderServiceGroup(textView, group)
+
+	return textView
+}
+
+// setupFocusableBox adds a box to the focusable boxes, maximizing it on
+// double-click, and draws its scrollbar
+func setupFocusableBox(textView *tview.TextView) {
 	// Make it focusable and add keyboard handler for scrolling
 	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyTab {
@@ -438,50 +671,10 @@ func createServiceGroupBox(group *homepage.ServiceGroup) *tview.TextView {
 	})
 
 	// Set custom draw function to draw scrollbar
-	textView.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
-		// Call original drawing first
-		left, top, innerWidth, innerHeight := textView.Box.GetInnerRect()
-
-		// Draw scrollbar if needed
-		rows, _ := textView.GetScrollOffset()
-		totalRows := len(strings.Split(textView.GetText(false), "\n"))
-
-		if totalRows > innerHeight {
-			// Calculate scrollbar position and size
-			scrollHeight := innerHeight - 2 // Adjust for arrows
-			scrollPosition := int(float64(rows) / float64(totalRows) * float64(scrollHeight))
-			scrollSize := int(float64(innerHeight) / float64(totalRows) * float64(scrollHeight))
-			if scrollSize < 1 {
-				scrollSize = 1
-			}
-
-			// Draw up arrow at top
-			screen.SetContent(left+innerWidth-1, top, '▲', nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
-
-			// Draw scrollbar track and thumb
-			for i := 0; i < scrollHeight; i++ {
-				if i >= scrollPosition && i < scrollPosition+scrollSize {
-					screen.SetContent(left+innerWidth-1, top+i+1, '█', nil, tcell.StyleDefault.Foreground(tcell.ColorWhite))
-				} else {
-					screen.SetContent(left+innerWidth-1, top+i+1, '│', nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
-				}
-			}
-
-			// Draw down arrow at bottom
-			screen.SetContent(left+innerWidth-1, top+innerHeight-1, '▼', nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
-		}
-
-		// Return original inner rectangle
-		return left, top, innerWidth, innerHeight
-	})
+	textView.SetDrawFunc(scrollbarDrawFunc(textView))
 
 	// Add to focusable boxes
 	allFocusableBoxes = append(allFocusableBoxes, textView)
-
-	// Generate initial content
-	renderServiceGroup(textView, group)
-
-	return textView
 }
 
 // renderServiceGroup renders a service group to a text view
@@ -489,12 +682,101 @@ func renderServiceGroup(view *tview.TextView, group *homepage.ServiceGroup) {
 	// Clear current content
 	view.Clear()
 
-	// Add each service
+	if compactMode {
+		fmt.Fprintf(view, "[green::b]%s[-:-:-]\n", group.Name)
+	} else {
+		view.SetTitle(groupTitle(group, time.Now()))
+	}
+	if monitor := homepage.GetStatusMonitor(); monitor != nil {
+		if note := monitor.GroupNote(group.Name); note != "" {
+			fmt.Fprintf(view, "[%s]%s %s[-]\n", colorNote, glyphs.note, tview.Escape(note))
+			if !compactMode {
+				fmt.Fprintln(view)
+			}
+		}
+	}
+
+	// Add each service, skipping healthy services that are only shown when down
+	statuses := groupStatuses(group.Name)
+	hidden := 0
 	for _, service := range group.Services {
-		renderService(view, service)
+		if !serviceShown(service, group.Name) {
+			continue
+		}
+		status := statuses[service.Name]
+		state := homepage.StatusUnknown
+		if status != nil {
+			state = status.State
+		}
+		if hiddenWhileHealthy(service, state) {
+			hidden++
+			continue
+		}
+		if compactMode {
+			renderCompactService(view, service, status)
+		} else {
+			renderService(view, service, status)
+		}
+	}
+
+	if hidden > 0 {
+		fmt.Fprintf(view, "[%s]%d hidden service(s) healthy[-]\n", colorMuted, hidden)
 	}
 }
 
+// groupTitle returns the name of a group followed by a muted countdown to the
+// next check of its services, or a spinner while one is running, so it's
+// clear how fresh the statuses are
+func groupTitle(group *homepage.ServiceGroup, now time.Time) string {
+	monitor := homepage.GetStatusMonitor()
+	if monitor == nil || monitor.Paused() || !timestamps.ShowsCountdown() {
+		return group.Name
+	}
+
+	var next time.Time
+	scheduled := false
+	for _, service := range group.Services {
+		if !serviceShown(service, group.Name) {
+			continue
+		}
+		due, ok := monitor.NextCheck(service.Name)
+		if ok && (!scheduled || due.Before(next)) {
+			next, scheduled = due, true
+		}
+	}
+	if !scheduled {
+		return group.Name
+	}
+	if !next.After(now) {
+		frames := []rune(glyphs.spinner)
+		return fmt.Sprintf("%s [%s]%c[-]", group.Name, colorMuted, frames[now.Unix()%int64(len(frames))])
+	}
+	return fmt.Sprintf("%s [%s]%s[-]", group.Name, colorMuted, homepage.FormatCountdown(next.Sub(now)))
+}
+
+// groupStatuses returns the statuses of the services of a group by name, or
+// nil when no status monitor is running
+func groupStatuses(groupName string) map[string]*homepage.StatusEntry {
+	monitor := homepage.GetStatusMonitor()
+	if monitor == nil {
+		return nil
+	}
+	entries := monitor.GetAllStatuses()
+	statuses := make(map[string]*homepage.StatusEntry)
+	for i := range entries {
+		if entries[i].Group == groupName {
+			statuses[entries[i].Name] = &entries[i]
+		}
+	}
+	return statuses
+}
+
+// hiddenWhileHealthy reports whether a service shown only when down is hidden
+// in the given state, i.e. unless it is in a warning or critical state
+func hiddenWhileHealthy(service *homepage.Service, state homepage.StatusState) bool {
+	return service.ShowOnlyWhenDown && state != homepage.StatusWarning && state != homepage.StatusCritical
+}
+
 // createBookmarksPanel creates a panel with bookmark groups
 func createBookmarksPanel(bookmarkGroups []*homepage.BookmarkGroup) tview.Primitive {
 	// Create a flex layout for bookmarks
@@ -531,146 +813,163 @@ func createBookmarkGroupBox(group *homepage.BookmarkGroup) *tview.TextView {
 	textView.SetBorder(true).
 		SetTitle(group.Name).
 		SetTitleColor(tcell.ColorBlue)
+	if compactMode {
+		textView.SetWrap(false).SetBorder(false)
+		fmt.Fprintf(textView, "[blue::b]%s[-:-:-]\n", group.Name)
+	}
 
-	// Make it focusable and add keyboard handler for scrolling
-	textView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyTab {
-			// Let main handler handle this
-			return event
-		}
-		// Let arrow keys be handled by main handler
-		if event.Key() == tcell.KeyUp || event.Key() == tcell.KeyDown ||
-			event.Key() == tcell.KeyLeft || event.Key() == tcell.KeyRight {
-			return event
-		}
-		return event
-	})
-
-	// Add mouse capture for double-click
-	textView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
-		if action == tview.MouseLeftClick {
-			currentTime := time.Now().UnixNano() / int64(time.Millisecond)
-
-			// Check if this is a double click on the same box
-			if textView == lastClickedBox && currentTime-lastClickTime < doubleClickDelay {
-				// Double click detected, toggle maximize
-				toggleMaximize()
-				lastClickTime = 0 // Reset to prevent triple-click detection
-			} else {
-				// First click, record time and box
-				lastClickTime = currentTime
-				lastClickedBox = textView
-
-				// Focus the clicked box
-				currentFocus = textView
-				app.SetFocus(currentFocus)
-			}
-		}
-		return action, event
-	})
-
-	// Set custom draw function to draw scrollbar
-	textView.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
-		// Call original drawing first
-		left, top, innerWidth, innerHeight := textView.Box.GetInnerRect()
-
-		// Draw scrollbar if needed
-		rows, _ := textView.GetScrollOffset()
-		totalRows := len(strings.Split(textView.GetText(false), "\n"))
-
-		if totalRows > innerHeight {
-			// Calculate scrollbar position and size
-			scrollHeight := innerHeight - 2 // Adjust for arrows
-			scrollPosition := int(float64(rows) / float64(totalRows) * float64(scrollHeight))
-			scrollSize := int(float64(innerHeight) / float64(totalRows) * float64(scrollHeight))
-			if scrollSize < 1 {
-				scrollSize = 1
-			}
-
-			// Draw up arrow at top
-			screen.SetContent(left+innerWidth-1, top, '▲', nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
-
-			// Draw scrollbar track and thumb
-			for i := 0; i < scrollHeight; i++ {
-				if i >= scrollPosition && i < scrollPosition+scrollSize {
-					screen.SetContent(left+innerWidth-1, top+i+1, '█', nil, tcell.StyleDefault.Foreground(tcell.ColorWhite))
-				} else {
-					screen.SetContent(left+innerWidth-1, top+i+1, '│', nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
-				}
-			}
-
-			// Draw down arrow at bottom
-			screen.SetContent(left+innerWidth-1, top+innerHeight-1, '▼', nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
-		}
-
-		// Return original inner rectangle
-		return left, top, innerWidth, innerHeight
-	})
-
-	// Add to focusable boxes
-	allFocusableBoxes = append(allFocusableBoxes, textView)
+	setupFocusableBox(textView)
 
 	// Generate content
 	for _, bookmark := range group.Bookmarks {
-		renderBookmark(textView, bookmark)
+		if !bookmarkShown(bookmark, group.Name) {
+			continue
+		}
+		if compactMode {
+			renderCompactBookmark(textView, bookmark)
+		} else {
+			renderBookmark(textView, bookmark)
+		}
 	}
 
 	return textView
 }
 
-// renderService displays a single service with its status
-func renderService(view *tview.TextView, service *homepage.Service) {
-	// Name and link
+// renderService displays a single service with its status, if known
+func renderService(view *tview.TextView, service *homepage.Service, status *homepage.StatusEntry) {
+	// Name color turns red when the widget reports a critical state
+	nameColor := "white"
+	if service.Widget != nil {
+		if monitor := homepage.GetStatusMonitor(); monitor != nil {
+			if result := monitor.GetWidgetResult(service.Name); result != nil && result.State == homepage.StatusCritical {
+				nameColor = "red"
+			}
+		}
+	}
+
+	// Name and link, highlighted when a hidden service is shown because it is down
+	name := fmt.Sprintf("[%s::b]%s", nameColor, service.Name)
+	if service.ShowOnlyWhenDown {
+		name = fmt.Sprintf("[white:red:b] %s [-:-:-]", service.Name)
+	}
 	if service.Href != "" {
-		fmt.Fprintf(view, "[white::b]%s[::] [#2db7f5](%s)[-]\n", service.Name, service.Href)
+		fmt.Fprintf(view, "%s[::] [%s](%s)[-]\n", name, colorLink, service.Href)
 	} else {
-		fmt.Fprintf(view, "[white::b]%s[-]\n", service.Name)
+		fmt.Fprintf(view, "%s[-]\n", name)
 	}
 
 	// Description if available
 	if service.Description != "" {
-		fmt.Fprintf(view, "  [#888888]%s[-]\n", service.Description)
+		fmt.Fprintf(view, "  [%s]%s[-]\n", colorMuted, service.Description)
 	}
 
 	// Status if not disabled
 	if !service.DisableStatus {
 		monitor := homepage.GetStatusMonitor()
-		if monitor != nil {
-			result := monitor.GetStatus(service.Name)
-			status := result.State
+		if monitor != nil && status != nil {
+			result := &status.StatusResult
 			message := result.Message
 
-			if status == homepage.StatusUnknown {
+			if result.State == homepage.StatusUnknown && message != homepage.MonitoringDisabledMessage {
 				message = "Status unknown"
 			}
 
-			// Format status based on state
-			var statusColor string
-			var statusIcon string
-
-			switch status {
-			case homepage.StatusOK:
-				statusColor = "green"
-				statusIcon = "✓"
-			case homepage.StatusWarning:
-				statusColor = "yellow"
-				statusIcon = "!"
-			case homepage.StatusCritical:
-				statusColor = "red"
-				statusIcon = "✗"
+			statusColor, icon := statusIcon(result.State)
+			checked := ""
+			switch checkKind(service) {
+			case "static status", "none":
 			default:
-				statusColor = "gray"
-				statusIcon = "?"
+				if !monitor.IsServiceEnabled(service.Name) {
+					break
+				}
+				if text := timestamps.Format(result.LastChecked, time.Now()); text != "" {
+					checked = fmt.Sprintf(" [%s](%s)", colorMuted, text)
+				}
+			}
+			if result.Stale {
+				checked += fmt.Sprintf(" [%s](stale)", colorMuted)
 			}
+			if result.Unconfirmed != "" {
+				checked += fmt.Sprintf(" [%s](unconfirmed: %s)", colorMuted, result.Unconfirmed)
+			}
+			if until := monitor.MutedUntil(service.Name); !until.IsZero() {
+				checked += fmt.Sprintf(" [%s](muted %s)", colorMuted, timestamps.Until(until, time.Now()))
+			}
+			message = colorResponseTime(message, result, statusColor)
+			fmt.Fprintf(view, "  [%s]%s %s%s[-]\n", statusColor, icon, message, checked)
+		}
+	}
+
+	if status != nil {
+		// Note attached at runtime
+		if status.Note != "" {
+			fmt.Fprintf(view, "  [%s]%s %s[-]\n", colorNote, glyphs.note, tview.Escape(status.Note))
+		}
 
-			fmt.Fprintf(view, "  [%s]%s %s[-]\n", statusColor, statusIcon, message)
+		// Card lines rendered by a script
+		for _, line := range status.Card {
+			fmt.Fprintf(view, "    %s[-:-:-]\n", downsampleTags(line))
 		}
 	}
 
+	// Widget fields if available
+	if service.Widget != nil {
+		renderWidget(view, service, status)
+	}
+
 	// Separator
 	fmt.Fprintf(view, "\n")
 }
 
+// statusIcon returns the color and icon a status state is shown with
+func statusIcon(state homepage.StatusState) (string, string) {
+	switch state {
+	case homepage.StatusOK:
+		return "green", glyphs.ok
+	case homepage.StatusWarning:
+		return "yellow", glyphs.warning
+	case homepage.StatusCritical:
+		return "red", glyphs.critical
+	}
+	return "gray", glyphs.unknown
+}
+
+// renderWidget displays the latest widget data of a service
+func renderWidget(view *tview.TextView, service *homepage.Service, status *homepage.StatusEntry) {
+	monitor := homepage.GetStatusMonitor()
+	if monitor == nil {
+		return
+	}
+
+	result := monitor.GetWidgetResult(service.Name)
+	if result == nil {
+		fmt.Fprintf(view, "    [%s]Loading %s...[-]\n", colorMuted, service.Widget.Type)
+		return
+	}
+
+	// Widget errors are already shown as the service status when there is no other check
+	if len(result.Fields) == 0 {
+		if result.State == homepage.StatusCritical && (status == nil || result.Message != status.Message) {
+			fmt.Fprintf(view, "    [red]%s[-]\n", result.Message)
+		}
+		return
+	}
+
+	for _, field := range result.Fields {
+		valueColor := "white"
+		switch field.State {
+		case homepage.StatusOK:
+			valueColor = "green"
+		case homepage.StatusWarning:
+			valueColor = "yellow"
+		case homepage.StatusCritical:
+			valueColor = "red"
+		}
+		// Values are plain text, e.g. log lines, which may contain brackets
+		fmt.Fprintf(view, "    [%s]%s:[-] [%s]%s[-]\n", colorMuted, tview.Escape(field.Label), valueColor, tview.Escape(field.Value))
+	}
+}
+
 // renderBookmark displays a single bookmark
 func renderBookmark(view *tview.TextView, bookmark *homepage.Bookmark) {
 	// Get display name
@@ -679,18 +978,61 @@ func renderBookmark(view *tview.TextView, bookmark *homepage.Bookmark) {
 		displayName = bookmark.Abbr
 	}
 
-	// Name and link
-	fmt.Fprintf(view, "[white::bu]%s[::-] [#2db7f5](%s)[-]\n", displayName, bookmark.Href)
+	// Name and link, dimmed and struck through when the link is dead
+	if result := deadBookmark(bookmark); result != nil {
+		fmt.Fprintf(view, "[%s::s]%s[::-] [%s](%s)[-]\n", colorMuted, displayName, colorMuted, bookmark.Href)
+		fmt.Fprintf(view, "  [red]%s Dead link: %s[-]\n", glyphs.critical, tview.Escape(result.Message))
+	} else {
+		fmt.Fprintf(view, "[white::bu]%s[::-] [%s](%s)[-]\n", displayName, colorLink, bookmark.Href)
+	}
 
-	// Description if available
-	if bookmark.Description != "" {
-		fmt.Fprintf(view, "  [#888888]%s[-]\n", bookmark.Description)
+	// Description if available, otherwise the title of the page
+	if description := bookmarkDescription(bookmark); description != "" {
+		fmt.Fprintf(view, "  [%s]%s[-]\n", colorMuted, description)
 	}
 
 	// Separator
 	fmt.Fprintf(view, "\n")
 }
 
+// bookmarkDescription returns the description of a bookmark, or the fetched
+// title of its page if it has none and bookmarkTitles is set
+func bookmarkDescription(bookmark *homepage.Bookmark) string {
+	if bookmark.Description != "" || !globalSettings.BookmarkTitles {
+		return bookmark.Description
+	}
+	// Titles are plain text, which may contain brackets
+	if title := homepage.BookmarkTitle(bookmark.Href); title != bookmark.Name {
+		return tview.Escape(title)
+	}
+	return ""
+}
+
+// fetchBookmarkTitles fetches the page titles of the bookmarks without a
+// description that are not cached yet, and redraws the bookmarks if one
+// changed. It must not be called from the UI goroutine.
+func fetchBookmarkTitles(ctx context.Context, settings *homepage.Settings) {
+	if !settings.BookmarkTitles {
+		return
+	}
+	if homepage.FetchBookmarkTitles(ctx, homepage.GetCachedBookmarks(), 10*time.Second) {
+		homepage.RequestUIRebuild()
+	}
+}
+
+// deadBookmark returns the result of the check of a bookmark link if the
+// link is dead, or nil
+func deadBookmark(bookmark *homepage.Bookmark) *homepage.BookmarkResult {
+	monitor := homepage.GetStatusMonitor()
+	if monitor == nil {
+		return nil
+	}
+	if result := monitor.BookmarkResult(bookmark.Href); result != nil && result.Dead {
+		return result
+	}
+	return nil
+}
+
 // formatLine creates a line with the specified character
 func formatLine(char rune, length int) string {
 	line := ""
@@ -719,6 +1061,9 @@ func statusUpdateCallback(serviceName string, state homepage.StatusState, messag
 				renderServiceGroup(textView, group)
 			}
 		}
+
+		// Draw attention to services that just turned critical
+		trackCriticalState(serviceName, state)
 	})
 }
 
@@ -784,61 +1129,110 @@ func toggleMaximize() {
 	}
 }
 
-// getBoxPosition returns the row and column position of a box in the grid
-func getBoxPosition(box tview.Primitive) (row, col int) {
-	for i, b := range allFocusableBoxes {
-		if b == box {
-			// Assume 2 columns layout (services and bookmarks)
-			// This is a simplified approach assuming a grid-like layout
-			// Modify this based on actual layout structure
-			col = i % 2
-			row = i / 2
-			return
+// scrollbarDrawFunc returns a draw function drawing a scrollbar in the last
+// column of a text view whose content doesn't fit. The column is reserved, so
+// text, including wide characters, never runs under the scrollbar.
+func scrollbarDrawFunc(textView *tview.TextView) func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+	var lastWidth, lastHeight int
+	// Stripping the tags of the text is slow, so the rows it takes are only
+	// measured again when it changed
+	var measuredText string
+	var measuredWidth, measuredRows int
+	return func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		left, top, innerWidth, innerHeight := textView.Box.GetInnerRect()
+
+		// The view only settles its scroll offset for a new size once its
+		// content is drawn, after this function, so draw again to move the
+		// thumb where it belongs
+		if innerWidth != lastWidth || innerHeight != lastHeight {
+			lastWidth, lastHeight = innerWidth, innerHeight
+			if app != nil {
+				go app.Draw()
+			}
 		}
-	}
-	return -1, -1
-}
 
-// findBoxAt finds the box at the specified row and column
-func findBoxAt(row, col int) tview.Primitive {
-	// Simple 2-column layout assumption
-	// Modify based on actual layout structure
-	index := row*2 + col
-	if index >= 0 && index < len(allFocusableBoxes) {
-		return allFocusableBoxes[index]
+		if text := textView.GetText(false); text != measuredText || innerWidth != measuredWidth {
+			measuredText, measuredWidth = text, innerWidth
+			measuredRows = displayRows(textView.GetText(true), innerWidth-1)
+		}
+		totalRows := measuredRows
+		if totalRows <= innerHeight || innerWidth < 2 || innerHeight < 3 {
+			return left, top, innerWidth, innerHeight
+		}
+
+		// The offset may still be past the end of the content until then
+		rows, _ := textView.GetScrollOffset()
+		rows = min(max(rows, 0), totalRows-innerHeight)
+
+		// Calculate scrollbar position and size, the thumb reaching the
+		// bottom of the track at the end of the content
+		scrollHeight := innerHeight - 2 // Adjust for arrows
+		scrollSize := max(innerHeight*scrollHeight/totalRows, 1)
+		scrollPosition := rows * (scrollHeight - scrollSize) / (totalRows - innerHeight)
+
+		column := left + innerWidth - 1
+		screen.SetContent(column, top, glyphs.scrollUp, nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
+		for i := 0; i < scrollHeight; i++ {
+			if i >= scrollPosition && i < scrollPosition+scrollSize {
+				screen.SetContent(column, top+i+1, glyphs.scrollThumb, nil, tcell.StyleDefault.Foreground(tcell.ColorWhite))
+			} else {
+				screen.SetContent(column, top+i+1, glyphs.scrollTrack, nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
+			}
+		}
+		screen.SetContent(column, top+innerHeight-1, glyphs.scrollDown, nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
+
+		// Leave the scrollbar column to the scrollbar
+		return left, top, innerWidth - 1, innerHeight
 	}
-	return nil
 }
 
-// navigateWithArrows moves focus based on arrow key direction
-func navigateWithArrows(key tcell.Key) {
-	if len(allFocusableBoxes) == 0 || currentFocus == nil {
-		return
+// displayRows estimates the number of rows text takes when wrapped at width
+// columns, measuring lines by their display width so wide characters such as
+// CJK and emoji count twice
+func displayRows(text string, width int) int {
+	if width < 1 {
+		width = 1
 	}
-
-	// Get current position
-	currentRow, currentCol := getBoxPosition(currentFocus)
-	if currentRow < 0 || currentCol < 0 {
-		return
+	rows := 0
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		rows += max((uniseg.StringWidth(line)+width-1)/width, 1)
 	}
+	return rows
+}
 
-	// Calculate new position based on key
-	newRow, newCol := currentRow, currentCol
-	switch key {
-	case tcell.KeyUp:
-		newRow--
-	case tcell.KeyDown:
-		newRow++
-	case tcell.KeyLeft:
-		newCol--
-	case tcell.KeyRight:
-		newCol++
+// jumpToGroup moves focus to the next group after the focused one whose name
+// begins with letter, wrapping around, so repeated presses cycle through them
+func jumpToGroup(letter rune) {
+	count := len(allFocusableBoxes)
+	start := 0
+	for i, box := range allFocusableBoxes {
+		if box == currentFocus {
+			start = i + 1
+			break
+		}
 	}
 
-	// Find box at new position
-	newBox := findBoxAt(newRow, newCol)
-	if newBox != nil {
-		currentFocus = newBox
-		app.SetFocus(currentFocus)
+	for i := 0; i < count; i++ {
+		box := allFocusableBoxes[(start+i)%count]
+		view, ok := box.(*tview.TextView)
+		if !ok {
+			continue
+		}
+		name := []rune(strings.TrimSpace(view.GetTitle()))
+		if len(name) == 0 || unicode.ToLower(name[0]) != unicode.ToLower(letter) {
+			continue
+		}
+
+		// Keep the maximized view, showing the new group instead
+		maximized := isMaximized
+		if maximized {
+			toggleMaximize()
+		}
+		currentFocus = box
+		app.SetFocus(box)
+		if maximized {
+			toggleMaximize()
+		}
+		return
 	}
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/rivo/tview"
+)
+
+// muteDurations are the durations offered by the mute picker
+var muteDurations = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"15 minutes", 15 * time.Minute},
+	{"1 hour", time.Hour},
+	{"4 hours", 4 * time.Hour},
+	{"1 day", 24 * time.Hour},
+}
+
+// openMutePicker lists the monitored services of the focused group below the
+// dashboard. Enter asks how long to mute the selected service, Esc cancels.
+func openMutePicker() {
+	group := focusedServiceGroup()
+	monitor := homepage.GetStatusMonitor()
+	if group == nil || monitor == nil || refuseReadOnly() {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(fmt.Sprintf(" Mute a service of %s ", group.Name))
+	for _, service := range group.Services {
+		if !monitor.IsMonitored(service.Name) {
+			continue
+		}
+		label := service.Name
+		if until := monitor.MutedUntil(service.Name); !until.IsZero() {
+			label += " (muted " + timestamps.Until(until, time.Now()) + ")"
+		}
+		name := service.Name
+		list.AddItem(tview.Escape(label), "", 0, func() {
+			openMuteDurationPicker(monitor, name)
+		})
+	}
+	if list.GetItemCount() == 0 {
+		return
+	}
+	list.SetDoneFunc(closeServicePicker)
+	showPicker(list, min(list.GetItemCount(), 10)+2)
+}
+
+// openMuteDurationPicker lists how long a service can be muted, and a choice
+// to unmute it if it is muted
+func openMuteDurationPicker(monitor *homepage.StatusMonitor, name string) {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(fmt.Sprintf(" Mute %s for ", name))
+	for _, choice := range muteDurations {
+		duration := choice.duration
+		list.AddItem(choice.label, "", 0, func() {
+			closeServicePicker()
+			go muteService(monitor, name, duration)
+		})
+	}
+	if monitor.IsMuted(name) {
+		list.AddItem("Unmute", "", 0, func() {
+			closeServicePicker()
+			go muteService(monitor, name, 0)
+		})
+	}
+	list.SetDoneFunc(closeServicePicker)
+	showPicker(list, list.GetItemCount()+2)
+}
+
+// muteService mutes a service for a duration, or unmutes it for a zero one,
+// recording who did, and saves the mutes to the state file. It must not be
+// called from the UI goroutine.
+func muteService(monitor *homepage.StatusMonitor, name string, duration time.Duration) {
+	if err := monitor.MuteService(name, duration); err != nil {
+		logging.Error("Failed to mute %s: %v", name, err)
+		return
+	}
+	if duration > 0 {
+		monitor.RecordAudit(name, homepage.AuditUser(), fmt.Sprintf("Muted for %s", duration))
+	} else {
+		monitor.RecordAudit(name, homepage.AuditUser(), "Unmuted")
+	}
+	dashboardStateChanged(monitor)
+}
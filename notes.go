@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// openNotePicker lists the focused group and its services below the
+// dashboard. Enter edits the note of the selected one, Esc cancels.
+func openNotePicker() {
+	group := focusedServiceGroup()
+	monitor := homepage.GetStatusMonitor()
+	if group == nil || monitor == nil {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(fmt.Sprintf(" Notes of %s ", group.Name))
+	addItem := func(target, note string, set func(string) error) {
+		label := target
+		if note != "" {
+			label += ": " + note
+		}
+		list.AddItem(tview.Escape(label), "", 0, func() {
+			openNoteInput(target, note, set)
+		})
+	}
+
+	groupName := group.Name
+	addItem("Group "+groupName, monitor.GroupNote(groupName), func(note string) error {
+		return monitor.SetGroupNote(groupName, note)
+	})
+	for _, service := range group.Services {
+		name := service.Name
+		addItem(name, monitor.ServiceNote(name), func(note string) error {
+			return monitor.SetServiceNote(name, note)
+		})
+	}
+	list.SetDoneFunc(closeServicePicker)
+	showPicker(list, min(list.GetItemCount(), 10)+2)
+}
+
+// openNoteInput shows an input line to edit a note. Enter saves the note, an
+// empty note clearing it, Esc cancels.
+func openNoteInput(target, note string, set func(string) error) {
+	input := tview.NewInputField().
+		SetLabel(fmt.Sprintf("Note of %s: ", target)).
+		SetText(note).
+		SetFieldWidth(0)
+	input.SetDoneFunc(func(key tcell.Key) {
+		text := input.GetText()
+		closeServicePicker()
+		if key != tcell.KeyEnter || text == note {
+			return
+		}
+		// The note function saves the state file, away from the UI goroutine
+		go func() {
+			if err := set(text); err != nil {
+				logging.Error("Failed to set the note of %s: %v", target, err)
+			}
+		}()
+	})
+	showPicker(input, 1)
+}
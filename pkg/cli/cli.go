@@ -0,0 +1,224 @@
+// Package cli implements a small subcommand framework on top of the standard
+// flag package, with shell completion support.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CompleteFunc returns completion candidates for the word being completed
+type CompleteFunc func(toComplete string) []string
+
+// Command is a command of the command line interface. Commands form a tree:
+// the root command is the program itself and each subcommand is selected by
+// its name on the command line.
+type Command struct {
+	Name   string        // Name used to invoke the command
+	Usage  string        // Synopsis of the arguments, e.g. "[flags] <service name>"
+	Short  string        // One-line description shown in help output
+	Flags  *flag.FlagSet // Flags accepted by the command
+	Hidden bool          // Hide the command from help output and completions
+
+	// Run executes the command with its positional arguments and returns the exit code
+	Run func(args []string) int
+
+	// Args completes positional arguments
+	Args CompleteFunc
+
+	// FlagValues completes the values of flags, keyed by flag name
+	FlagValues map[string]CompleteFunc
+
+	commands []*Command
+	parent   *Command
+	output   io.Writer
+}
+
+// NewCommand creates a command with an empty flag set
+func NewCommand(name, short string) *Command {
+	cmd := &Command{
+		Name:       name,
+		Short:      short,
+		Flags:      flag.NewFlagSet(name, flag.ContinueOnError),
+		FlagValues: make(map[string]CompleteFunc),
+	}
+	cmd.Flags.Usage = cmd.PrintUsage
+	return cmd
+}
+
+// AddCommand adds subcommands to the command
+func (c *Command) AddCommand(commands ...*Command) {
+	for _, sub := range commands {
+		sub.parent = c
+		c.commands = append(c.commands, sub)
+	}
+}
+
+// Commands returns the subcommands of the command
+func (c *Command) Commands() []*Command {
+	return c.commands
+}
+
+// Path returns the full name of the command, e.g. "termhome add service"
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Root returns the root of the command tree
+func (c *Command) Root() *Command {
+	if c.parent == nil {
+		return c
+	}
+	return c.parent.Root()
+}
+
+// SetOutput sets the writer for usage and error messages (default: stderr)
+func (c *Command) SetOutput(w io.Writer) {
+	c.output = w
+}
+
+// Output returns the writer for usage and error messages
+func (c *Command) Output() io.Writer {
+	if c.output != nil {
+		return c.output
+	}
+	if c.parent != nil {
+		return c.parent.Output()
+	}
+	return os.Stderr
+}
+
+// Execute runs the command selected by args and returns its exit code
+func (c *Command) Execute(args []string) int {
+	if len(args) > 0 && args[0] == completeCommandName {
+		return c.complete(args[1:])
+	}
+
+	cmd, rest := c.find(args)
+	cmd.Flags.SetOutput(cmd.Output())
+
+	positional, err := parseInterspersed(cmd.Flags, rest)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	if len(cmd.commands) > 0 && cmd.Args == nil && len(positional) > 0 {
+		fmt.Fprintf(cmd.Output(), "Unknown command %q for %s\n\n", positional[0], cmd.Path())
+		cmd.PrintUsage()
+		return 2
+	}
+	if cmd.Run == nil {
+		cmd.PrintUsage()
+		return 2
+	}
+	return cmd.Run(positional)
+}
+
+// find returns the subcommand named by the leading arguments and the remaining arguments
+func (c *Command) find(args []string) (*Command, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return c, args
+	}
+	for _, sub := range c.commands {
+		if sub.Name == args[0] {
+			return sub.find(args[1:])
+		}
+	}
+	return c, args
+}
+
+// PrintUsage prints the help text of the command
+func (c *Command) PrintUsage() {
+	w := c.Output()
+
+	synopsis := c.Path()
+	if len(c.visibleCommands()) > 0 {
+		synopsis += " [command]"
+	}
+	if c.Usage != "" {
+		synopsis += " " + c.Usage
+	} else if hasFlags(c.Flags) {
+		synopsis += " [flags]"
+	}
+	fmt.Fprintf(w, "Usage: %s\n", synopsis)
+
+	if c.Short != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Short)
+	}
+
+	if commands := c.visibleCommands(); len(commands) > 0 {
+		width := 0
+		for _, sub := range commands {
+			if len(sub.Name) > width {
+				width = len(sub.Name)
+			}
+		}
+		fmt.Fprintf(w, "\nCommands:\n")
+		for _, sub := range commands {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, sub.Name, sub.Short)
+		}
+	}
+
+	if hasFlags(c.Flags) {
+		fmt.Fprintf(w, "\nFlags:\n")
+		c.Flags.SetOutput(w)
+		c.Flags.PrintDefaults()
+	}
+}
+
+// visibleCommands returns the subcommands that are not hidden
+func (c *Command) visibleCommands() []*Command {
+	var commands []*Command
+	for _, sub := range c.commands {
+		if !sub.Hidden {
+			commands = append(commands, sub)
+		}
+	}
+	return commands
+}
+
+// hasFlags reports whether a flag set defines any flags
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// flagNames returns the sorted names of the flags of a flag set
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	sort.Strings(names)
+	return names
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments, and returns the positional arguments. Everything after
+// a "--" argument is treated as positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		remaining := fs.Args()
+		if consumed := len(args) - len(remaining); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, remaining...), nil
+		}
+		if len(remaining) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, remaining[0])
+		args = remaining[1:]
+	}
+}
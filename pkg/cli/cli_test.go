@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTree() (*Command, *[]string, *string) {
+	var got []string
+	root := NewCommand("app", "Test app")
+	root.SetOutput(&bytes.Buffer{})
+	root.Run = func(args []string) int { return 0 }
+
+	check := NewCommand("check", "Check a service")
+	name := check.Flags.String("config-dir", "./config", "Config dir")
+	check.Flags.Bool("verbose", false, "Verbose output")
+	check.FlagValues["config-dir"] = CompleteValues("./config", "/etc/app")
+	check.Args = func(string) []string { return []string{"Grafana", "Git" + *name} }
+	check.Run = func(args []string) int {
+		got = args
+		return 3
+	}
+
+	root.AddCommand(check, NewCompletionCommand())
+	return root, &got, name
+}
+
+func TestExecute_DispatchAndInterspersedFlags(t *testing.T) {
+	root, got, configDir := newTestTree()
+
+	code := root.Execute([]string{"check", "My Service", "--config-dir", "/tmp/x", "--", "-literal"})
+	assert.Equal(t, 3, code)
+	assert.Equal(t, []string{"My Service", "-literal"}, *got)
+	assert.Equal(t, "/tmp/x", *configDir)
+}
+
+func TestExecute_UnknownCommandAndBadFlag(t *testing.T) {
+	root, _, _ := newTestTree()
+
+	assert.Equal(t, 2, root.Execute([]string{"bogus"}))
+	assert.Equal(t, 2, root.Execute([]string{"check", "--nope"}))
+	assert.Equal(t, 0, root.Execute([]string{"check", "-h"}))
+}
+
+func TestComplete(t *testing.T) {
+	root, _, _ := newTestTree()
+
+	assert.Equal(t, []string{"check", "completion"}, root.Complete([]string{"c"}))
+	assert.Equal(t, []string{"Grafana", "Git./config"}, root.Complete([]string{"check", "G"}))
+	assert.Equal(t, []string{"Git/etc/app"}, root.Complete([]string{"check", "--config-dir", "/etc/app", "Git"}))
+	assert.Equal(t, []string{"/etc/app"}, root.Complete([]string{"check", "--config-dir", "/"}))
+	assert.Equal(t, []string{"--config-dir", "--verbose"}, root.Complete([]string{"check", "--"}))
+	assert.Equal(t, []string{"zsh"}, root.Complete([]string{"completion", "z"}))
+}
+
+func TestCompletionScript(t *testing.T) {
+	for _, shell := range Shells {
+		script, err := CompletionScript("term-home", shell)
+		assert.NoError(t, err)
+		assert.Contains(t, script, "__complete")
+		assert.Contains(t, script, "term-home")
+		assert.Contains(t, script, "_term_home_complete")
+	}
+
+	_, err := CompletionScript("app", "powershell")
+	assert.Error(t, err)
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// completeCommandName is the hidden command the completion scripts call to
+// get candidates for the word under the cursor
+const completeCommandName = "__complete"
+
+// Shells lists the shells completion scripts can be generated for
+var Shells = []string{"bash", "zsh", "fish"}
+
+// complete prints the completion candidates for a command line, one per line.
+// The last word of words is the (possibly empty) word being completed.
+func (c *Command) complete(words []string) int {
+	for _, candidate := range c.Complete(words) {
+		fmt.Fprintln(os.Stdout, candidate)
+	}
+	return 0
+}
+
+// Complete returns the completion candidates for a command line. The last
+// word of words is the (possibly empty) word being completed.
+func (c *Command) Complete(words []string) []string {
+	toComplete := ""
+	if len(words) > 0 {
+		toComplete = strings.TrimLeft(words[len(words)-1], `"'`)
+		words = words[:len(words)-1]
+	}
+
+	cmd, rest := c.find(words)
+
+	// Parse the words typed so far so completion functions can look at flag
+	// values such as the configuration directory
+	cmd.SetOutput(io.Discard)
+	cmd.Flags.SetOutput(io.Discard)
+	positional, _ := parseInterspersed(cmd.Flags, rest)
+
+	// Complete the value of a flag given as the previous word
+	if len(rest) > 0 {
+		if name, ok := flagAwaitingValue(cmd.Flags, rest[len(rest)-1]); ok {
+			if complete := cmd.FlagValues[name]; complete != nil {
+				return filterPrefix(complete(toComplete), toComplete)
+			}
+			return nil
+		}
+	}
+
+	if strings.HasPrefix(toComplete, "-") {
+		var candidates []string
+		for _, name := range flagNames(cmd.Flags) {
+			candidates = append(candidates, "--"+name)
+		}
+		return filterPrefix(candidates, toComplete)
+	}
+
+	var candidates []string
+	if len(positional) == 0 {
+		for _, sub := range cmd.visibleCommands() {
+			candidates = append(candidates, sub.Name)
+		}
+	}
+	if cmd.Args != nil {
+		candidates = append(candidates, cmd.Args(toComplete)...)
+	}
+	return filterPrefix(candidates, toComplete)
+}
+
+// flagAwaitingValue reports whether word is a non-boolean flag without an
+// inline value, and returns the flag name
+func flagAwaitingValue(fs *flag.FlagSet, word string) (string, bool) {
+	if !strings.HasPrefix(word, "-") || word == "-" || word == "--" || strings.Contains(word, "=") {
+		return "", false
+	}
+	name := strings.TrimLeft(word, "-")
+	f := fs.Lookup(name)
+	if f == nil {
+		return "", false
+	}
+	if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+		return "", false
+	}
+	return name, true
+}
+
+// filterPrefix returns the candidates starting with prefix
+func filterPrefix(candidates []string, prefix string) []string {
+	var out []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			out = append(out, candidate)
+		}
+	}
+	return out
+}
+
+// CompleteValues returns a completion function offering a fixed set of values
+func CompleteValues(values ...string) CompleteFunc {
+	return func(string) []string {
+		return values
+	}
+}
+
+// CompleteDirs completes directory names
+func CompleteDirs(toComplete string) []string {
+	matches, _ := filepath.Glob(toComplete + "*")
+	var dirs []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			dirs = append(dirs, match+string(filepath.Separator))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// NewCompletionCommand creates the command printing completion scripts for
+// the root command
+func NewCompletionCommand() *Command {
+	cmd := NewCommand("completion", "Print the shell completion script (bash, zsh or fish)")
+	cmd.Usage = "bash|zsh|fish"
+	cmd.Args = CompleteValues(Shells...)
+	cmd.Run = func(args []string) int {
+		if len(args) != 1 {
+			cmd.PrintUsage()
+			return 2
+		}
+		script, err := CompletionScript(cmd.Root().Name, args[0])
+		if err != nil {
+			fmt.Fprintln(cmd.Output(), err)
+			return 2
+		}
+		fmt.Fprint(os.Stdout, script)
+		return 0
+	}
+	return cmd
+}
+
+// CompletionScript returns the completion script of a program for a shell
+func CompletionScript(program, shell string) (string, error) {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return "", fmt.Errorf("unsupported shell %q, expected one of %s", shell, strings.Join(Shells, ", "))
+	}
+	name := strings.NewReplacer("-", "_", ".", "_").Replace(program)
+	return strings.NewReplacer("{{program}}", program, "{{name}}", name).Replace(script), nil
+}
+
+const bashCompletion = `# bash completion for {{program}}
+# Load with: source <({{program}} completion bash)
+_{{name}}_complete() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local candidate
+    COMPREPLY=()
+    while IFS= read -r candidate; do
+        [ -n "$candidate" ] && COMPREPLY+=("$(printf '%q' "$candidate")")
+    done < <("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)
+    if [ ${#COMPREPLY[@]} -eq 0 ]; then
+        COMPREPLY=($(compgen -f -- "$cur"))
+    fi
+}
+complete -F _{{name}}_complete {{program}}
+`
+
+const zshCompletion = `#compdef {{program}}
+# Load with: source <({{program}} completion zsh)
+_{{name}}_complete() {
+    local -a candidates
+    candidates=("${(@f)$(${words[1]} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _{{name}}_complete {{program}}
+`
+
+const fishCompletion = `# fish completion for {{program}}
+# Load with: {{program}} completion fish | source
+function __{{name}}_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    $tokens[1] __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c {{program}} -f -a '(__{{name}}_complete)'
+`
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Field is a single property of a service or bookmark entry, written in order
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// AppendEntry adds an entry named name with the given fields to a group of a
+// services.yaml or bookmarks.yaml file. The group is created at the end of the
+// file if it does not exist yet.
+//
+// The file is edited as text rather than re-encoded, so comments, blank lines
+// and the formatting of existing entries are left untouched.
+func AppendEntry(filePath, group, name string, fields []Field) error {
+	if group == "" || name == "" {
+		return fmt.Errorf("group and name are required")
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	updated, err := appendEntry(data, group, name, fields)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", filePath, err)
+	}
+
+	return writeFileAtomic(filePath, updated)
+}
+
+// appendEntry returns data with the entry inserted into the given group
+func appendEntry(data []byte, group, name string, fields []Field) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	// An empty file (or one holding only comments) gets a new top-level list
+	if len(doc.Content) == 0 || isEmptyNode(doc.Content[0]) {
+		out := ensureTrailingNewline(data)
+		if len(bytes.TrimSpace(data)) > 0 {
+			out = append(out, '\n')
+		}
+		return append(out, renderGroup(group, name, fields)...), nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.SequenceNode || root.Style&yaml.FlowStyle != 0 {
+		return nil, fmt.Errorf("expected a list of groups at the top level")
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+
+	for i, groupEntry := range root.Content {
+		if groupEntry.Kind != yaml.MappingNode || len(groupEntry.Content) != 2 || groupEntry.Content[0].Value != group {
+			continue
+		}
+
+		items := groupEntry.Content[1]
+		if items.Kind != yaml.SequenceNode || items.Style&yaml.FlowStyle != 0 || len(items.Content) == 0 {
+			return nil, fmt.Errorf("group %q is not a block list", group)
+		}
+		for _, item := range items.Content {
+			if item.Kind == yaml.MappingNode && len(item.Content) > 0 && item.Content[0].Value == name {
+				return nil, fmt.Errorf("%q already exists in group %q", name, group)
+			}
+		}
+
+		// Insert after the last entry, i.e. before the next group (or at the
+		// end of the file), skipping back over blank lines and comments that
+		// belong to the next group
+		insertAt := len(lines)
+		if i+1 < len(root.Content) {
+			insertAt = root.Content[i+1].Line - 1
+		}
+		for insertAt > 0 && isBlankOrTopLevelComment(lines[insertAt-1]) {
+			insertAt--
+		}
+
+		indent := items.Content[0].Column - 1
+		// Block sequence items start with "- ", which precedes the node column
+		if indent >= 2 {
+			indent -= 2
+		}
+
+		entry := renderEntry(name, fields, indent)
+		if insertAt > 0 && !strings.HasSuffix(lines[insertAt-1], "\n") {
+			lines[insertAt-1] += "\n"
+		}
+		out := strings.Join(lines[:insertAt], "") + entry + strings.Join(lines[insertAt:], "")
+		return []byte(out), nil
+	}
+
+	// The group does not exist yet: append it at the end of the file
+	out := ensureTrailingNewline(data)
+	return append(append(out, '\n'), renderGroup(group, name, fields)...), nil
+}
+
+// renderGroup renders a new group holding a single entry
+func renderGroup(group, name string, fields []Field) string {
+	return "- " + yamlKey(group) + ":\n" + renderEntry(name, fields, 4)
+}
+
+// renderEntry renders an entry as a list item indented by indent spaces, with
+// its fields indented four more, matching the layout of the example configs
+func renderEntry(name string, fields []Field, indent int) string {
+	pad := strings.Repeat(" ", indent)
+	var b strings.Builder
+	b.WriteString(pad + "- " + yamlKey(name) + ":")
+	if len(fields) == 0 {
+		b.WriteString(" {}\n")
+		return b.String()
+	}
+	b.WriteString("\n")
+
+	props := &yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range fields {
+		value := &yaml.Node{}
+		if err := value.Encode(field.Value); err != nil {
+			value = &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(field.Value)}
+		}
+		props.Content = append(props.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Key}, value)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(4)
+	_ = enc.Encode(props)
+	_ = enc.Close()
+
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		b.WriteString(pad + "    " + line)
+	}
+	return b.String()
+}
+
+// yamlKey returns s formatted as a YAML mapping key, quoted if necessary
+func yamlKey(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// isEmptyNode reports whether a document node holds no value
+func isEmptyNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// isBlankOrTopLevelComment reports whether a line is blank or a comment that
+// starts in the first column
+func isBlankOrTopLevelComment(line string) bool {
+	return strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#")
+}
+
+// ensureTrailingNewline returns a copy of data that ends with a newline,
+// unless data is empty
+func ensureTrailingNewline(data []byte) []byte {
+	out := append([]byte{}, data...)
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over filePath
+func writeFileAtomic(filePath string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", filePath, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filePath, err)
+	}
+	return nil
+}
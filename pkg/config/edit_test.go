@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendEntry_ExistingGroup(t *testing.T) {
+	input := `# Services
+---
+- Apps:
+    - GitHub:
+        href: https://github.com # main forge
+
+# Monitoring services
+- Monitoring:
+    - Router:
+        ping: 192.168.1.1
+`
+	out, err := appendEntry([]byte(input), "Apps", "Grafana", []Field{
+		{Key: "href", Value: "https://grafana.lan"},
+		{Key: "siteMonitor", Value: "https://grafana.lan/api/health"},
+	})
+	require.NoError(t, err)
+
+	expected := `# Services
+---
+- Apps:
+    - GitHub:
+        href: https://github.com # main forge
+    - Grafana:
+        href: https://grafana.lan
+        siteMonitor: https://grafana.lan/api/health
+
+# Monitoring services
+- Monitoring:
+    - Router:
+        ping: 192.168.1.1
+`
+	assert.Equal(t, expected, string(out))
+}
+
+func TestAppendEntry_LastGroupAndNewGroup(t *testing.T) {
+	input := "- Apps:\n    - GitHub:\n        href: https://github.com"
+
+	out, err := appendEntry([]byte(input), "Apps", "Docs", []Field{{Key: "href", Value: "https://docs.lan"}})
+	require.NoError(t, err)
+	assert.Equal(t, "- Apps:\n    - GitHub:\n        href: https://github.com\n    - Docs:\n        href: https://docs.lan\n", string(out))
+
+	out, err = appendEntry(out, "Media: Home", "Plex", []Field{{Key: "ping", Value: "plex.lan"}})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "\n\n- 'Media: Home':\n    - Plex:\n        ping: plex.lan\n")
+}
+
+func TestAppendEntry_Errors(t *testing.T) {
+	input := "- Apps:\n    - GitHub:\n        href: https://github.com\n"
+
+	_, err := appendEntry([]byte(input), "Apps", "GitHub", nil)
+	assert.ErrorContains(t, err, "already exists")
+
+	_, err = appendEntry([]byte("apps: {}\n"), "Apps", "GitHub", nil)
+	assert.Error(t, err)
+}
+
+func TestAppendEntry_CreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.yaml")
+
+	require.NoError(t, AppendEntry(path, "Search", "Google", []Field{{Key: "abbr", Value: "G"}}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- Search:\n    - Google:\n        abbr: G\n", string(data))
+}
@@ -16,14 +16,43 @@ description: A simple terminal homepage dashboard
 theme: dark
 showStats: false
 hideVersion: false
+colors: auto # Color depth of the terminal: auto, truecolor, 256, 16 or 8
+asciiOnly: false # Use ASCII instead of Unicode icons and borders, e.g. on serial consoles
 status:
   checkInterval: 10 # Default status check interval in seconds, overrides individual services if set
+  retryInterval: 5  # Recheck failing services every 5 seconds until they recover
+# pluginDir: plugins # Directory of exec plugins, relative to the config directory
+# timestamps:
+#   format: relative # When services were last checked: relative, absolute or hidden
+#   timezone: Europe/Rome # Timezone of absolute timestamps (default: local)
+#   clock: 24h # 24h or 12h
+# criticalFocus: scroll # Focus the group of a service turning critical: scroll, maximize or off
+# mdns:
+#   enabled: true # List the printers, cast devices and other services advertised on the local network
+# carousel:
+#   interval: 15 # Maximize each group in turn for this many seconds, pausing after a key press or click
+# search:
+#   provider: duckduckgo # Web search opened with the s key: duckduckgo, google, bing, brave, startpage or custom
+#   url: https://search.lan/?q={query} # URL of the custom provider
+# controlSocket: /run/user/1000/termhome.sock # Socket used by "termhome ctl", "off" to disable
+# readOnly: true # Refuse service actions, Wake-on-LAN and monitoring toggles, e.g. on shared dashboards
+# stateFile: state.json # Services disabled with the d key and notes, relative to the config directory
+# bookmarkChecks:
+#   enabled: true # Flag bookmarks whose links are dead, checked with HEAD requests
+#   interval: 3600 # Seconds between checks
+# bookmarkTitles: true # Show the page titles of bookmarks without a description, cached for a week
+# profiles: # Selected with --profile or TERMHOME_PROFILE
+#   work:
+#     groups: [Applications] # Only load these groups
+#     baseUrls:
+#       http://nas.lan: https://nas.example.com # Replace the beginning of URLs
 
 # Layout configuration example (uncomment to use)
 # layout:
 #   Applications:
 #     style: row
 #     columns: 3
+#     tags: [work] # Filter the dashboard by tag with T or --tags
 #   Documentation:
 #     style: column
 #     iconsOnly: true
@@ -0,0 +1,305 @@
+// Package control implements the control socket of a running termhome
+// instance, used by `termhome ctl` to reload, pause, resume and check it and
+// to attach notes to its services without key presses.
+//
+// The protocol is one JSON request and one JSON response per connection.
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// Commands understood by the control socket
+const (
+	CommandStatus  = "status"
+	CommandCheck   = "check"
+	CommandPause   = "pause"
+	CommandResume  = "resume"
+	CommandReload  = "reload"
+	CommandNote    = "note"
+	CommandHistory = "history"
+)
+
+// requestTimeout bounds the time a single request may take, including checks
+const requestTimeout = 2 * time.Minute
+
+// Request is a command sent to the control socket
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Response is the reply of the control socket
+type Response struct {
+	Error    string           `json:"error,omitempty"`
+	Message  string           `json:"message,omitempty"`
+	Paused   bool             `json:"paused"`
+	Statuses []ServiceStatus  `json:"statuses,omitempty"`
+	Check    *CheckResult     `json:"check,omitempty"`
+	History  []ServiceHistory `json:"history,omitempty"`
+}
+
+// ServiceStatus is the current status of a service
+type ServiceStatus struct {
+	Name           string               `json:"name"`
+	Group          string               `json:"group,omitempty"`
+	State          homepage.StatusState `json:"state"`
+	Message        string               `json:"message,omitempty"`
+	ResponseTimeMs int64                `json:"responseTimeMs,omitempty"`
+	LastChecked    time.Time            `json:"lastChecked"`
+	Note           string               `json:"note,omitempty"`
+}
+
+// ServiceHistory is the recent status changes of a service, oldest first
+type ServiceHistory struct {
+	Name   string         `json:"name"`
+	Group  string         `json:"group,omitempty"`
+	Events []HistoryEvent `json:"events"`
+}
+
+// HistoryEvent is a status change of a service, or an action run on it
+type HistoryEvent struct {
+	Time    time.Time            `json:"time"`
+	State   homepage.StatusState `json:"state"`
+	Message string               `json:"message,omitempty"`
+	User    string               `json:"user,omitempty"` // Who ran the action of an audit event
+}
+
+// CheckResult is the result of a check run through the control socket
+type CheckResult struct {
+	Name           string                  `json:"name"`
+	State          homepage.StatusState    `json:"state"`
+	Message        string                  `json:"message,omitempty"`
+	ResponseTimeMs int64                   `json:"responseTimeMs,omitempty"`
+	Details        []homepage.StatusDetail `json:"details,omitempty"`
+}
+
+// ReloadFunc re-reads the configuration of the running instance
+type ReloadFunc func() error
+
+// DefaultSocketPath returns the control socket path used when none is
+// configured: termhome.sock in $XDG_RUNTIME_DIR, or a per-user file in the
+// temporary directory
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "termhome.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("termhome-%d.sock", os.Getuid()))
+}
+
+// Server serves the control socket of a status monitor
+type Server struct {
+	path     string
+	monitor  *homepage.StatusMonitor
+	reload   ReloadFunc
+	listener net.Listener
+}
+
+// New creates a control server listening on the socket at path. reload may be
+// nil if the instance can't reload its configuration.
+func New(path string, monitor *homepage.StatusMonitor, reload ReloadFunc) *Server {
+	if path == "" {
+		path = DefaultSocketPath()
+	}
+	return &Server{path: path, monitor: monitor, reload: reload}
+}
+
+// Start creates the socket and serves requests in the background. A stale
+// socket left by a crashed instance is replaced, but a live one is not.
+func (s *Server) Start() error {
+	if conn, err := net.DialTimeout("unix", s.path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another instance", s.path)
+	}
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return err
+	}
+	// Only the owner may control the instance
+	if err := os.Chmod(s.path, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	s.listener = listener
+	logging.Info("Control socket listening on %s", s.path)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					logging.Error("Control socket error: %v", err)
+				}
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return nil
+}
+
+// Close stops accepting requests and removes the socket
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// serve handles a single request
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var req Request
+	var resp *Response
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		resp = &Response{Error: fmt.Sprintf("invalid request: %v", err)}
+	} else {
+		logging.Debug("Control command %q %v", req.Command, req.Args)
+		resp = s.Handle(&req)
+	}
+	resp.Paused = s.monitor.Paused()
+
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		logging.Warn("Control socket: failed to send response: %v", err)
+	}
+}
+
+// Handle executes a request and returns its response
+func (s *Server) Handle(req *Request) *Response {
+	switch req.Command {
+	case CommandStatus:
+		var statuses []ServiceStatus
+		for _, entry := range s.monitor.GetAllStatuses() {
+			if !entry.Monitored {
+				continue
+			}
+			statuses = append(statuses, ServiceStatus{
+				Name:           entry.Name,
+				Group:          entry.Group,
+				State:          entry.State,
+				Message:        entry.Message,
+				ResponseTimeMs: entry.ResponseTime.Milliseconds(),
+				LastChecked:    entry.LastChecked,
+				Note:           entry.Note,
+			})
+		}
+		return &Response{Statuses: statuses}
+
+	case CommandHistory:
+		var history []ServiceHistory
+		seen := make(map[string]bool)
+		for _, entry := range s.monitor.GetAllStatuses() {
+			// A service shown in several groups has a single history
+			if !entry.Monitored || seen[entry.Name] {
+				continue
+			}
+			seen[entry.Name] = true
+			service := ServiceHistory{Name: entry.Name, Group: entry.Group, Events: []HistoryEvent{}}
+			for _, event := range s.monitor.History(entry.Name) {
+				service.Events = append(service.Events, HistoryEvent{Time: event.Time, State: event.State, Message: event.Message, User: event.User})
+			}
+			history = append(history, service)
+		}
+		return &Response{History: history}
+
+	case CommandCheck:
+		if len(req.Args) != 1 {
+			return &Response{Error: "check requires a service name"}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+		name, result, err := s.monitor.CheckNow(ctx, req.Args[0])
+		if err != nil {
+			return &Response{Error: fmt.Sprintf("%s: %q", err, req.Args[0])}
+		}
+		return &Response{Check: &CheckResult{
+			Name:           name,
+			State:          result.State,
+			Message:        result.Message,
+			ResponseTimeMs: result.ResponseTime.Milliseconds(),
+			Details:        result.Details,
+		}}
+
+	case CommandPause:
+		s.monitor.Pause()
+		return &Response{Message: "Monitoring paused"}
+
+	case CommandResume:
+		s.monitor.Resume()
+		return &Response{Message: "Monitoring resumed"}
+
+	case CommandReload:
+		if s.reload == nil {
+			return &Response{Error: "this instance can't reload its configuration"}
+		}
+		if err := s.reload(); err != nil {
+			return &Response{Error: fmt.Sprintf("reload failed: %v", err)}
+		}
+		return &Response{Message: "Configuration reloaded"}
+
+	case CommandNote:
+		// Arguments: "service" or "group", its name, and the note, if any
+		if len(req.Args) < 2 || len(req.Args) > 3 || (req.Args[0] != "service" && req.Args[0] != "group") {
+			return &Response{Error: "note requires a service or group name"}
+		}
+		kind, name, note := req.Args[0], req.Args[1], ""
+		if len(req.Args) == 3 {
+			note = req.Args[2]
+		}
+		set := s.monitor.SetServiceNote
+		if kind == "group" {
+			set = s.monitor.SetGroupNote
+		}
+		if err := set(name, note); err != nil {
+			return &Response{Error: fmt.Sprintf("%s: %q", err, name)}
+		}
+		if strings.TrimSpace(note) == "" {
+			return &Response{Message: fmt.Sprintf("Note of %s %q cleared", kind, name)}
+		}
+		return &Response{Message: fmt.Sprintf("Note of %s %q set", kind, name)}
+	}
+	return &Response{Error: fmt.Sprintf("unknown command %q", req.Command)}
+}
+
+// Call sends a request to the control socket at path and returns the
+// response. Errors reported by the instance are returned as errors.
+func Call(path string, req *Request) (*Response, error) {
+	if path == "" {
+		path = DefaultSocketPath()
+	}
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("no running instance found at %s: %w", path, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(requestTimeout + 5*time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.Error != "" {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
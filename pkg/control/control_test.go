@@ -0,0 +1,137 @@
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlSocket(t *testing.T) {
+	homepage.StoreCachedGroups([]*homepage.ServiceGroup{{
+		Name:     "Apps",
+		Services: []*homepage.Service{{Name: "Static", Status: "warning"}},
+	}})
+	defer homepage.StoreCachedGroups(nil)
+
+	monitor := homepage.NewStatusMonitor(nil)
+	defer monitor.Stop()
+	monitor.AddService(&homepage.Service{Name: "Static", Status: "warning"})
+
+	// Keep the path short, unix socket paths are limited to about 100 bytes
+	dir, err := os.MkdirTemp("", "ctl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "termhome.sock")
+
+	reloads := 0
+	srv := New(path, monitor, func() error {
+		reloads++
+		return nil
+	})
+	require.NoError(t, srv.Start())
+	defer srv.Close()
+
+	// A second instance must not take over a live socket
+	assert.Error(t, New(path, monitor, nil).Start())
+
+	resp, err := Call(path, &Request{Command: CommandStatus})
+	require.NoError(t, err)
+	require.Len(t, resp.Statuses, 1)
+	assert.Equal(t, "Static", resp.Statuses[0].Name)
+	assert.Equal(t, "Apps", resp.Statuses[0].Group)
+	assert.Equal(t, homepage.StatusWarning, resp.Statuses[0].State)
+	assert.False(t, resp.Paused)
+
+	resp, err = Call(path, &Request{Command: CommandPause})
+	require.NoError(t, err)
+	assert.True(t, resp.Paused)
+	assert.True(t, monitor.Paused())
+
+	resp, err = Call(path, &Request{Command: CommandResume})
+	require.NoError(t, err)
+	assert.False(t, resp.Paused)
+
+	resp, err = Call(path, &Request{Command: CommandCheck, Args: []string{"static"}})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Check)
+	assert.Equal(t, "Static", resp.Check.Name)
+	assert.Equal(t, homepage.StatusWarning, resp.Check.State)
+
+	_, err = Call(path, &Request{Command: CommandCheck, Args: []string{"Missing"}})
+	assert.ErrorContains(t, err, "unknown service")
+
+	_, err = Call(path, &Request{Command: CommandReload})
+	require.NoError(t, err)
+	assert.Equal(t, 1, reloads)
+
+	_, err = Call(path, &Request{Command: "restart"})
+	assert.ErrorContains(t, err, "unknown command")
+}
+
+func TestReloadUnsupported(t *testing.T) {
+	monitor := homepage.NewStatusMonitor(nil)
+	defer monitor.Stop()
+
+	resp := New("", monitor, nil).Handle(&Request{Command: CommandReload})
+	assert.Contains(t, resp.Error, "can't reload")
+}
+
+func TestNoteCommand(t *testing.T) {
+	homepage.StoreCachedGroups([]*homepage.ServiceGroup{{
+		Name:     "Apps",
+		Services: []*homepage.Service{{Name: "Static", Status: "warning"}},
+	}})
+	defer homepage.StoreCachedGroups(nil)
+
+	monitor := homepage.NewStatusMonitor(nil)
+	defer monitor.Stop()
+	monitor.AddService(&homepage.Service{Name: "Static", Status: "warning"})
+	srv := New("", monitor, nil)
+
+	resp := srv.Handle(&Request{Command: CommandNote, Args: []string{"service", "Static", "migrating DB until 18:00"}})
+	assert.Empty(t, resp.Error)
+	resp = srv.Handle(&Request{Command: CommandNote, Args: []string{"group", "Apps", "maintenance window"}})
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, "maintenance window", monitor.GroupNote("Apps"))
+
+	resp = srv.Handle(&Request{Command: CommandStatus})
+	require.Len(t, resp.Statuses, 1)
+	assert.Equal(t, "migrating DB until 18:00", resp.Statuses[0].Note)
+
+	resp = srv.Handle(&Request{Command: CommandNote, Args: []string{"service", "Static"}})
+	assert.Equal(t, `Note of service "Static" cleared`, resp.Message)
+	assert.Empty(t, monitor.ServiceNote("Static"))
+
+	resp = srv.Handle(&Request{Command: CommandNote, Args: []string{"group", "Missing", "note"}})
+	assert.Contains(t, resp.Error, "unknown group")
+	resp = srv.Handle(&Request{Command: CommandNote, Args: []string{"Static"}})
+	assert.NotEmpty(t, resp.Error)
+}
+
+func TestHistoryCommand(t *testing.T) {
+	homepage.StoreCachedGroups([]*homepage.ServiceGroup{{
+		Name:     "Apps",
+		Services: []*homepage.Service{{Name: "Static", Status: "warning"}},
+	}})
+	defer homepage.StoreCachedGroups(nil)
+
+	monitor := homepage.NewStatusMonitor(nil)
+	defer monitor.Stop()
+	monitor.AddService(&homepage.Service{Name: "Static", Status: "warning"})
+
+	resp := New("", monitor, nil).Handle(&Request{Command: CommandHistory})
+	require.Len(t, resp.History, 1)
+	assert.Equal(t, "Static", resp.History[0].Name)
+	assert.Equal(t, "Apps", resp.History[0].Group)
+	require.Len(t, resp.History[0].Events, 1)
+	assert.Equal(t, homepage.StatusWarning, resp.History[0].Events[0].State)
+
+	monitor.RecordAudit("Static", "alice", "Ran action restart: exit code 0")
+	resp = New("", monitor, nil).Handle(&Request{Command: CommandHistory})
+	require.Len(t, resp.History[0].Events, 2)
+	assert.Equal(t, "alice", resp.History[0].Events[1].User)
+}
@@ -0,0 +1,75 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// actionOutputLimit bounds the output of an action kept for display
+const actionOutputLimit = 64 << 10
+
+// ActionResult is the outcome of an action command of a service
+type ActionResult struct {
+	Output   string        // Combined standard output and error, truncated to 64 KB
+	ExitCode int           // Exit code of the command
+	Duration time.Duration // Time the command ran
+}
+
+// ActionNames returns the names of the actions of a service, sorted
+func ActionNames(service *Service) []string {
+	return slices.Sorted(maps.Keys(service.Actions))
+}
+
+// RunAction runs an action command of a service with the shell of the local
+// host, sh or cmd on Windows. A command exiting with an error is not an error
+// of RunAction: its exit code is returned with the output.
+func RunAction(ctx context.Context, service *Service, name string) (*ActionResult, error) {
+	command, ok := service.Actions[name]
+	if !ok {
+		return nil, fmt.Errorf("%s has no action %q", service.Name, name)
+	}
+	timeout := service.ActionTimeout
+	if timeout <= 0 {
+		timeout = 60
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	output := limitedBuffer{limit: actionOutputLimit}
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Don't wait for children of the shell keeping the output open once it is killed
+	cmd.WaitDelay = time.Second
+
+	logging.Info("Running action %s of %s: %s", name, service.Name, command)
+	start := time.Now()
+	err := cmd.Run()
+	result := &ActionResult{
+		Output:   output.String(),
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Duration: time.Since(start),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return result, fmt.Errorf("action %s timed out after %ds", name, timeout)
+	case errors.As(err, &exitErr):
+		return result, nil
+	case err != nil:
+		return nil, fmt.Errorf("action %s failed: %w", name, err)
+	}
+	return result, nil
+}
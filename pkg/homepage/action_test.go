@@ -0,0 +1,45 @@
+package homepage
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("actions use sh syntax")
+	}
+	service := &Service{Name: "Web", Actions: map[string]string{
+		"status":  "echo running; echo warning >&2",
+		"restart": "echo failed; exit 3",
+		"hang":    "sleep 5",
+		"flood":   "yes | head -c 100000",
+	}, ActionTimeout: 1}
+	assert.Equal(t, []string{"flood", "hang", "restart", "status"}, ActionNames(service))
+
+	result, err := RunAction(context.Background(), service, "status")
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "running\nwarning\n", result.Output)
+
+	result, err = RunAction(context.Background(), service, "restart")
+	require.NoError(t, err, "A failing command should return its exit code")
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "failed\n", result.Output)
+
+	result, err = RunAction(context.Background(), service, "flood")
+	require.NoError(t, err)
+	assert.Len(t, result.Output, actionOutputLimit)
+	assert.True(t, strings.HasPrefix(result.Output, "y\ny\n"))
+
+	_, err = RunAction(context.Background(), service, "hang")
+	assert.EqualError(t, err, "action hang timed out after 1s")
+
+	_, err = RunAction(context.Background(), service, "stop")
+	assert.EqualError(t, err, `Web has no action "stop"`)
+}
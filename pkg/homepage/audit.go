@@ -0,0 +1,36 @@
+package homepage
+
+import (
+	"cmp"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// AuditUser returns who runs the dashboard, recorded with the actions run
+// from it: the user name, followed by the client address over SSH, as in
+// "alice from 192.168.1.20"
+func AuditUser() string {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	name = cmp.Or(name, "unknown")
+	if client := strings.Fields(os.Getenv("SSH_CONNECTION")); len(client) > 0 {
+		name += " from " + client[0]
+	}
+	return name
+}
+
+// RecordAudit adds an action run on a service by user to the status history
+// of the service and to the log. The state of the service is unchanged.
+func (sm *StatusMonitor) RecordAudit(serviceName, user, message string) {
+	state := StatusUnknown
+	if result := sm.GetStatus(serviceName); result != nil {
+		state = result.State
+	}
+	sm.history.add(serviceName, StatusEvent{Time: sm.clock.Now(), State: state, Message: message, User: user})
+	logging.Info("Audit: %s by %s on %s", message, user, serviceName)
+}
@@ -0,0 +1,34 @@
+package homepage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAudit(t *testing.T) {
+	sm := NewStatusMonitor(nil)
+	sm.updateServiceStatus("Web", StatusCritical, "Connection refused")
+	sm.RecordAudit("Web", "alice from 192.168.1.20", "Ran action restart: exit code 0")
+
+	events := sm.History("Web")
+	require.Len(t, events, 2)
+	assert.Equal(t, StatusCritical, events[1].State, "The audit entry should keep the state")
+	assert.Equal(t, "Ran action restart: exit code 0", events[1].Message)
+	assert.Equal(t, "alice from 192.168.1.20", events[1].User)
+	assert.Equal(t, "Connection refused", sm.GetStatus("Web").Message, "The status should be unchanged")
+
+	// Audit entries don't change the incidents or the downtime
+	end := events[1].Time.Add(time.Minute)
+	assert.Equal(t, ComputeAvailability(events[:1], events[0].Time, end), ComputeAvailability(events, events[0].Time, end))
+}
+
+func TestAuditUser(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "192.168.1.20 52144 192.168.1.2 22")
+	assert.Regexp(t, `^\S+ from 192\.168\.1\.20$`, AuditUser())
+
+	t.Setenv("SSH_CONNECTION", "")
+	assert.NotContains(t, AuditUser(), " from ")
+}
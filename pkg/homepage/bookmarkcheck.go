@@ -0,0 +1,178 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+const (
+	// defaultBookmarkInterval is the interval between bookmark checks by default
+	defaultBookmarkInterval = time.Hour
+	// defaultBookmarkTimeout is the timeout of a bookmark check by default
+	defaultBookmarkTimeout = 10 * time.Second
+	// maxBookmarkChecks is the number of bookmarks checked at once
+	maxBookmarkChecks = 4
+	// bookmarkStopKey is the stop channel key of the bookmark checks
+	bookmarkStopKey = "bookmarks:"
+)
+
+// BookmarkResult is the result of the check of a bookmark link
+type BookmarkResult struct {
+	Dead        bool      // The link is broken
+	Message     string    // Why the link is dead, or its HTTP status
+	LastChecked time.Time // When the link was checked
+}
+
+// StartBookmarkChecks checks the links of the shown bookmarks now and on a
+// slow interval, with lightweight HEAD requests. The checks started before
+// are stopped, so it can be called again when the settings are reloaded.
+func (sm *StatusMonitor) StartBookmarkChecks(settings BookmarkCheckSettings) {
+	sm.mutex.Lock()
+	if stop, ok := sm.stopChannels[bookmarkStopKey]; ok {
+		close(stop)
+		delete(sm.stopChannels, bookmarkStopKey)
+	}
+	if !settings.Enabled {
+		sm.bookmarkResults = make(map[string]*BookmarkResult)
+		sm.mutex.Unlock()
+		return
+	}
+	sm.mutex.Unlock()
+	stop := sm.addStopChannel(bookmarkStopKey)
+
+	interval := time.Duration(settings.Interval) * time.Second
+	if settings.Interval <= 0 {
+		interval = defaultBookmarkInterval
+	}
+	timeout := time.Duration(settings.Timeout) * time.Second
+	if settings.Timeout <= 0 {
+		timeout = defaultBookmarkTimeout
+	}
+	logging.Info("Checking bookmark links every %s", interval)
+
+	go func() {
+		timer := sm.clock.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C():
+				sm.checkBookmarks(timeout, stop)
+				timer.Reset(interval)
+			}
+		}
+	}()
+}
+
+// BookmarkResult returns the result of the check of a bookmark link, or nil
+// if it wasn't checked
+func (sm *StatusMonitor) BookmarkResult(href string) *BookmarkResult {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.bookmarkResults[href]
+}
+
+// checkBookmarks checks the links of all bookmarks and rebuilds the UI if a
+// link died or came back
+func (sm *StatusMonitor) checkBookmarks(timeout time.Duration, stop <-chan struct{}) {
+	var hrefs []string
+	seen := make(map[string]bool)
+	for _, group := range GetCachedBookmarks() {
+		for _, bookmark := range group.Bookmarks {
+			href := bookmark.Href
+			if seen[href] || !(strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://")) {
+				continue
+			}
+			seen[href] = true
+			hrefs = append(hrefs, href)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var changedMutex sync.Mutex
+	changed := false
+	limit := make(chan struct{}, maxBookmarkChecks)
+	for _, href := range hrefs {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			ctx, cancel := stopContext(stop, timeout)
+			defer cancel()
+			result := checkBookmarkLink(ctx, href)
+			if isStopped(stop) {
+				return
+			}
+			result.LastChecked = sm.clock.Now()
+
+			sm.mutex.Lock()
+			previous := sm.bookmarkResults[href]
+			sm.bookmarkResults[href] = result
+			sm.mutex.Unlock()
+			if previous == nil && result.Dead || previous != nil && previous.Dead != result.Dead {
+				logging.Info("Bookmark %s: %s", href, result.Message)
+				changedMutex.Lock()
+				changed = true
+				changedMutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if changed && !isStopped(stop) {
+		RequestUIRebuild()
+	}
+}
+
+// checkBookmarkLink requests a link with HEAD, or GET for servers that don't
+// support HEAD. Links answering 404, 410 or a server error are dead, while
+// other errors, such as 401 or 403 of pages behind a login, are not.
+func checkBookmarkLink(ctx context.Context, href string) *BookmarkResult {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialCached
+	tlsConfig, err := clientTLSConfig(false, "", "")
+	if err != nil {
+		return &BookmarkResult{Dead: true, Message: fmt.Sprintf("Error loading CA certificates: %v", err)}
+	}
+	transport.TLSClientConfig = tlsConfig
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport}
+
+	var resp *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, href, nil)
+		if err != nil {
+			return &BookmarkResult{Dead: true, Message: fmt.Sprintf("Invalid link: %v", err)}
+		}
+		resp, err = client.Do(req)
+		if err != nil {
+			// The link is shown already, so only the cause is kept
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return &BookmarkResult{Dead: true, Message: fmt.Sprintf("Request failed: %v", err)}
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	message := fmt.Sprintf("HTTP %d", resp.StatusCode)
+	dead := resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone || resp.StatusCode >= 500
+	return &BookmarkResult{Dead: dead, Message: message}
+}
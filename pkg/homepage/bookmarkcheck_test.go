@@ -0,0 +1,66 @@
+package homepage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookmarkChecks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/login":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	StoreCachedBookmarks([]*BookmarkGroup{{
+		Name: "Links",
+		Bookmarks: []*Bookmark{
+			{Name: "OK", Href: srv.URL + "/"},
+			{Name: "Gone", Href: srv.URL + "/gone"},
+			{Name: "Login", Href: srv.URL + "/login"},
+			{Name: "No HEAD", Href: srv.URL + "/nohead"},
+			{Name: "Down", Href: closed.URL},
+			{Name: "SSH", Href: "ssh://nas.lan"},
+		},
+	}})
+	defer StoreCachedBookmarks(nil)
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	sm.StartBookmarkChecks(BookmarkCheckSettings{Enabled: true, Timeout: 5})
+
+	require.Eventually(t, func() bool {
+		for _, path := range []string{"/", "/gone", "/login", "/nohead"} {
+			if sm.BookmarkResult(srv.URL+path) == nil {
+				return false
+			}
+		}
+		return sm.BookmarkResult(closed.URL) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.False(t, sm.BookmarkResult(srv.URL+"/").Dead)
+	assert.Equal(t, &BookmarkResult{Dead: true, Message: "HTTP 404", LastChecked: sm.BookmarkResult(srv.URL + "/gone").LastChecked}, sm.BookmarkResult(srv.URL+"/gone"))
+	assert.False(t, sm.BookmarkResult(srv.URL+"/login").Dead)
+	assert.Equal(t, "HTTP 200", sm.BookmarkResult(srv.URL+"/nohead").Message)
+	assert.True(t, sm.BookmarkResult(closed.URL).Dead)
+	assert.Nil(t, sm.BookmarkResult("ssh://nas.lan"))
+
+	// Turning the checks off forgets the results
+	sm.StartBookmarkChecks(BookmarkCheckSettings{})
+	assert.Nil(t, sm.BookmarkResult(srv.URL+"/gone"))
+}
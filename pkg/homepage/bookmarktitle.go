@@ -0,0 +1,207 @@
+package homepage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"golang.org/x/net/html"
+)
+
+const (
+	// bookmarkTitleTTL is the time a fetched title is used before it is
+	// fetched again
+	bookmarkTitleTTL = 7 * 24 * time.Hour
+	// bookmarkTitleRetry is the time before a page whose title couldn't be
+	// fetched is requested again
+	bookmarkTitleRetry = 24 * time.Hour
+	// maxBookmarkTitleBody bounds the part of a page searched for its title
+	maxBookmarkTitleBody = 512 << 10
+	// maxBookmarkTitle is the length in characters titles are truncated to
+	maxBookmarkTitle = 100
+)
+
+// bookmarkTitle is a page title kept in the title cache. Title is empty if
+// the page had none or couldn't be fetched.
+type bookmarkTitle struct {
+	Title   string    `json:"title,omitempty"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// bookmarkTitles holds the page titles of bookmarks by link, loaded from and
+// saved to a cache file
+var bookmarkTitles = struct {
+	mutex  sync.Mutex
+	path   string
+	titles map[string]bookmarkTitle
+}{titles: make(map[string]bookmarkTitle)}
+
+// DefaultBookmarkTitleCache returns the path of the cache file of bookmark
+// titles in the user cache directory
+func DefaultBookmarkTitleCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "termhome", "bookmark-titles.json")
+}
+
+// LoadBookmarkTitles reads the bookmark titles cached in a file, so they are
+// shown right away. A missing file is an empty cache.
+func LoadBookmarkTitles(path string) error {
+	bookmarkTitles.mutex.Lock()
+	defer bookmarkTitles.mutex.Unlock()
+	bookmarkTitles.path = path
+	bookmarkTitles.titles = make(map[string]bookmarkTitle)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read bookmark titles: %w", err)
+	}
+	if err := json.Unmarshal(data, &bookmarkTitles.titles); err != nil {
+		return fmt.Errorf("failed to parse bookmark titles %s: %w", path, err)
+	}
+	return nil
+}
+
+// BookmarkTitle returns the fetched page title of a bookmark link, or "" if
+// it isn't known
+func BookmarkTitle(href string) string {
+	bookmarkTitles.mutex.Lock()
+	defer bookmarkTitles.mutex.Unlock()
+	return bookmarkTitles.titles[href].Title
+}
+
+// FetchBookmarkTitles fetches the page titles of the bookmarks without a
+// description that are not cached or whose cached title is stale, and saves
+// them to the cache file. It reports whether a title changed.
+func FetchBookmarkTitles(ctx context.Context, groups []*BookmarkGroup, timeout time.Duration) bool {
+	now := time.Now()
+	var hrefs []string
+	bookmarkTitles.mutex.Lock()
+	for _, group := range groups {
+		for _, bookmark := range group.Bookmarks {
+			href := bookmark.Href
+			if bookmark.Description != "" || !(strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://")) {
+				continue
+			}
+			cached, ok := bookmarkTitles.titles[href]
+			ttl := bookmarkTitleTTL
+			if cached.Title == "" {
+				ttl = bookmarkTitleRetry
+			}
+			if !ok || now.Sub(cached.Fetched) > ttl {
+				hrefs = append(hrefs, href)
+			}
+		}
+	}
+	bookmarkTitles.mutex.Unlock()
+
+	changed := false
+	for _, href := range hrefs {
+		if ctx.Err() != nil {
+			break
+		}
+		title, err := fetchPageTitle(ctx, href, timeout)
+		if err != nil {
+			logging.Debug("Title of bookmark %s: %v", href, err)
+		}
+
+		bookmarkTitles.mutex.Lock()
+		changed = changed || bookmarkTitles.titles[href].Title != title
+		bookmarkTitles.titles[href] = bookmarkTitle{Title: title, Fetched: now}
+		bookmarkTitles.mutex.Unlock()
+	}
+
+	if len(hrefs) > 0 {
+		if err := saveBookmarkTitles(); err != nil {
+			logging.Warn("Warning: %v", err)
+		}
+	}
+	return changed
+}
+
+// saveBookmarkTitles writes the titles to the cache file
+func saveBookmarkTitles() error {
+	bookmarkTitles.mutex.Lock()
+	defer bookmarkTitles.mutex.Unlock()
+	if bookmarkTitles.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(bookmarkTitles.titles, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(bookmarkTitles.path), 0755); err != nil {
+		return fmt.Errorf("failed to save bookmark titles: %w", err)
+	}
+	if err := os.WriteFile(bookmarkTitles.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save bookmark titles: %w", err)
+	}
+	return nil
+}
+
+// fetchPageTitle returns the title of an HTML page, or "" if it has none
+func fetchPageTitle(ctx context.Context, href string, timeout time.Duration) (string, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialCached
+	tlsConfig, err := clientTLSConfig(false, "", "")
+	if err != nil {
+		return "", err
+	}
+	transport.TLSClientConfig = tlsConfig
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: timeout}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, href, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.Contains(contentType, "html") {
+		return "", nil
+	}
+	return parseTitle(io.LimitReader(resp.Body, maxBookmarkTitleBody)), nil
+}
+
+// parseTitle returns the text of the first title element of an HTML page,
+// whitespace collapsed and truncated, or "" if it has none
+func parseTitle(r io.Reader) string {
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) != "title" || tokenizer.Next() != html.TextToken {
+				continue
+			}
+			title := []rune(strings.Join(strings.Fields(string(tokenizer.Text())), " "))
+			if len(title) > maxBookmarkTitle {
+				title = append(title[:maxBookmarkTitle-1], '…')
+			}
+			return string(title)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package homepage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTitle(t *testing.T) {
+	assert.Equal(t, "Jellyfin & friends", parseTitle(strings.NewReader("<html><head><title>\n  Jellyfin &amp;\n friends </title></head></html>")))
+	assert.Equal(t, "", parseTitle(strings.NewReader("<html><body>No title</body></html>")))
+	long := parseTitle(strings.NewReader("<title>" + strings.Repeat("a", 200) + "</title>"))
+	assert.Len(t, []rune(long), maxBookmarkTitle)
+}
+
+func TestFetchBookmarkTitles(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/wiki":
+			w.Write([]byte("<title>Team Wiki</title>"))
+		case "/file":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cache", "titles.json")
+	require.NoError(t, LoadBookmarkTitles(path))
+	defer LoadBookmarkTitles("")
+
+	groups := []*BookmarkGroup{{Name: "Links", Bookmarks: []*Bookmark{
+		{Name: "Wiki", Href: srv.URL + "/wiki"},
+		{Name: "Manual", Href: srv.URL + "/file"},
+		{Name: "Missing", Href: srv.URL + "/missing"},
+		{Name: "Described", Href: srv.URL + "/described", Description: "Set in the config"},
+	}}}
+
+	assert.True(t, FetchBookmarkTitles(context.Background(), groups, 5*time.Second))
+	assert.Equal(t, "Team Wiki", BookmarkTitle(srv.URL+"/wiki"))
+	assert.Empty(t, BookmarkTitle(srv.URL+"/file"))
+	assert.Empty(t, BookmarkTitle(srv.URL+"/missing"))
+	assert.EqualValues(t, 3, requests.Load())
+
+	// Cached titles, and failures, are not fetched again
+	assert.False(t, FetchBookmarkTitles(context.Background(), groups, 5*time.Second))
+	assert.EqualValues(t, 3, requests.Load())
+
+	// The cache file is read by the next run
+	require.NoError(t, LoadBookmarkTitles(path))
+	assert.Equal(t, "Team Wiki", BookmarkTitle(srv.URL+"/wiki"))
+}
@@ -0,0 +1,714 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// brokerCheck checks a message broker with the cheapest request its protocol
+// offers: a metadata request for Kafka, a PING for NATS, the connection
+// handshake for AMQP and the management API for RabbitMQ
+type brokerCheck struct {
+	url          *url.URL
+	err          error // Error parsing the URL
+	queueWarning int
+	skipVerify   bool
+	caFile       string
+	caDir        string
+	timeout      time.Duration
+}
+
+// brokerReport is what a broker check found
+type brokerReport struct {
+	state   StatusState
+	message string
+	details []StatusDetail
+}
+
+// brokerPorts are the default ports of the broker URL schemes
+var brokerPorts = map[string]string{
+	"kafka":     "9092",
+	"nats":      "4222",
+	"amqp":      "5672",
+	"amqps":     "5671",
+	"rabbitmq":  "15672",
+	"rabbitmqs": "15671",
+}
+
+// newBrokerCheck creates a message broker check for a service
+func newBrokerCheck(service *Service) *brokerCheck {
+	timeout := service.BrokerTimeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	check := &brokerCheck{
+		queueWarning: service.BrokerQueueWarning,
+		skipVerify:   service.BrokerSkipVerify,
+		caFile:       service.CAFile,
+		caDir:        service.CADir,
+		timeout:      time.Duration(timeout) * time.Second,
+	}
+	check.url, check.err = url.Parse(service.Broker)
+	if check.err == nil && brokerPorts[check.url.Scheme] == "" {
+		check.err = fmt.Errorf("unsupported scheme %q, use kafka, nats, amqp, amqps, rabbitmq or rabbitmqs", check.url.Scheme)
+	}
+	return check
+}
+
+// run checks the broker with the protocol of the URL scheme
+func (c *brokerCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	if c.err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid broker URL: %v", c.err)}
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var report *brokerReport
+	var err error
+	switch c.url.Scheme {
+	case "kafka":
+		report, err = c.kafka(ctx)
+	case "nats":
+		report, err = c.nats(ctx)
+	case "amqp", "amqps":
+		report, err = c.amqp(ctx)
+	default:
+		report, err = c.rabbitMQ(ctx)
+	}
+	elapsed := time.Since(start)
+
+	details := []StatusDetail{{Label: "Broker", Value: c.url.Redacted()}}
+	if err != nil {
+		message := err.Error()
+		return &StatusResult{State: StatusCritical, Message: strings.ToUpper(message[:1]) + message[1:], Details: details}
+	}
+	details = append(details, report.details...)
+	details = append(details, StatusDetail{Label: "Total", Value: elapsed.Round(time.Microsecond).String()})
+	return &StatusResult{
+		State:        report.state,
+		Message:      fmt.Sprintf("%s (%s)", report.message, elapsed.Round(time.Millisecond)),
+		ResponseTime: elapsed,
+		Details:      details,
+	}
+}
+
+// address returns the host and port of an address of the URL, with the
+// default port of the scheme if none is set
+func (c *brokerCheck) address(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), brokerPorts[c.url.Scheme])
+}
+
+// tlsConfig returns the TLS configuration of connections to the broker
+func (c *brokerCheck) tlsConfig() (*tls.Config, error) {
+	config, err := clientTLSConfig(c.skipVerify, c.caFile, c.caDir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading CA certificates: %w", err)
+	}
+	config.ServerName = c.url.Hostname()
+	return config, nil
+}
+
+// dialBroker connects to an address of the broker, with the deadline of the
+// context
+func dialBroker(ctx context.Context, address string) (net.Conn, error) {
+	conn, err := dialCached(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// kafka sends a metadata request to the first bootstrap server answering
+// and reports the brokers, the topics and the unhealthy partitions
+func (c *brokerCheck) kafka(ctx context.Context) (*brokerReport, error) {
+	var metadata *kafkaMetadata
+	var err error
+	for _, host := range strings.Split(c.url.Host, ",") {
+		if metadata, err = kafkaFetchMetadata(ctx, c.address(host)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &brokerReport{state: StatusOK, message: fmt.Sprintf("%d brokers, %d topics", len(metadata.brokers), len(metadata.topics))}
+	report.details = append(report.details, StatusDetail{Label: "Brokers", Value: strings.Join(metadata.brokers, ", ")})
+	var offline, underReplicated []string
+	for _, topic := range metadata.topics {
+		for _, partition := range topic.partitions {
+			name := fmt.Sprintf("%s-%d", topic.name, partition.id)
+			switch {
+			case partition.leader < 0:
+				offline = append(offline, name)
+			case partition.isr < partition.replicas:
+				underReplicated = append(underReplicated, name)
+			}
+		}
+	}
+	if len(underReplicated) > 0 {
+		report.state = StatusWarning
+		report.message = fmt.Sprintf("%d under-replicated partitions", len(underReplicated))
+		report.details = append(report.details, StatusDetail{Label: "Under-replicated", Value: strings.Join(underReplicated, ", ")})
+	}
+	if len(offline) > 0 {
+		report.state = StatusCritical
+		report.message = fmt.Sprintf("%d partitions without leader", len(offline))
+		report.details = append(report.details, StatusDetail{Label: "Without leader", Value: strings.Join(offline, ", ")})
+	}
+	return report, nil
+}
+
+// kafkaMetadata is the part of a metadata response the check uses
+type kafkaMetadata struct {
+	brokers []string // Addresses of the brokers
+	topics  []kafkaTopic
+}
+
+// kafkaTopic is a topic and its partitions
+type kafkaTopic struct {
+	name       string
+	partitions []kafkaPartition
+}
+
+// kafkaPartition is the leader and the replica counts of a partition
+type kafkaPartition struct {
+	id       int32
+	leader   int32 // -1 without leader
+	replicas int
+	isr      int // In-sync replicas
+}
+
+// kafkaFetchMetadata sends a version 0 metadata request for all topics
+func kafkaFetchMetadata(ctx context.Context, address string) (*kafkaMetadata, error) {
+	conn, err := dialBroker(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	const correlationID = 1
+	request := binary.BigEndian.AppendUint16(nil, 3) // Metadata
+	request = binary.BigEndian.AppendUint16(request, 0)
+	request = binary.BigEndian.AppendUint32(request, correlationID)
+	request = binary.BigEndian.AppendUint16(request, uint16(len("termhome")))
+	request = append(request, "termhome"...)
+	request = binary.BigEndian.AppendUint32(request, 0) // No topics means all of them
+	if _, err := conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(request))), request...)); err != nil {
+		return nil, fmt.Errorf("metadata request failed: %w", err)
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, fmt.Errorf("metadata request failed: %w", err)
+	}
+	if length := binary.BigEndian.Uint32(size[:]); length > 16<<20 {
+		return nil, fmt.Errorf("invalid metadata response of %d bytes", length)
+	}
+	response := &kafkaReader{data: make([]byte, binary.BigEndian.Uint32(size[:]))}
+	if _, err := io.ReadFull(conn, response.data); err != nil {
+		return nil, fmt.Errorf("metadata request failed: %w", err)
+	}
+
+	if response.int32() != correlationID {
+		return nil, fmt.Errorf("invalid metadata response")
+	}
+	metadata := &kafkaMetadata{}
+	for i := response.int32(); i > 0 && response.err == nil; i-- {
+		response.int32() // Node ID
+		host := response.string()
+		port := response.int32()
+		metadata.brokers = append(metadata.brokers, net.JoinHostPort(host, fmt.Sprint(port)))
+	}
+	for i := response.int32(); i > 0 && response.err == nil; i-- {
+		response.int16() // Error code
+		topic := kafkaTopic{name: response.string()}
+		for j := response.int32(); j > 0 && response.err == nil; j-- {
+			response.int16() // Error code
+			partition := kafkaPartition{id: response.int32(), leader: response.int32()}
+			partition.replicas = response.skipInt32s()
+			partition.isr = response.skipInt32s()
+			topic.partitions = append(topic.partitions, partition)
+		}
+		metadata.topics = append(metadata.topics, topic)
+	}
+	if response.err != nil {
+		return nil, fmt.Errorf("invalid metadata response: %w", response.err)
+	}
+	return metadata, nil
+}
+
+// kafkaReader decodes the fields of a Kafka response, keeping the first
+// error so that a response is checked once at the end
+type kafkaReader struct {
+	data []byte
+	err  error
+}
+
+// kafkaZeros is returned for the scalars read past an error, so that a
+// malformed response doesn't make the reader allocate
+var kafkaZeros [4]byte
+
+func (r *kafkaReader) next(n int) []byte {
+	if r.err != nil || n < 0 || len(r.data) < n {
+		if r.err == nil {
+			r.err = io.ErrUnexpectedEOF
+		}
+		return kafkaZeros[:min(max(n, 0), len(kafkaZeros))]
+	}
+	field := r.data[:n]
+	r.data = r.data[n:]
+	return field
+}
+
+func (r *kafkaReader) int16() int16 { return int16(binary.BigEndian.Uint16(r.next(2))) }
+func (r *kafkaReader) int32() int32 { return int32(binary.BigEndian.Uint32(r.next(4))) }
+
+func (r *kafkaReader) string() string {
+	length := r.int16()
+	if length < 0 {
+		return ""
+	}
+	field := r.next(int(length))
+	if r.err != nil {
+		return ""
+	}
+	return string(field)
+}
+
+// skipInt32s skips an array of int32 and returns its length
+func (r *kafkaReader) skipInt32s() int {
+	count := int(r.int32())
+	if r.err != nil || count < 0 || count > len(r.data)/4 {
+		if r.err == nil {
+			r.err = io.ErrUnexpectedEOF
+		}
+		return 0
+	}
+	r.data = r.data[4*count:]
+	return count
+}
+
+// natsInfo is the part of the INFO message of a NATS server the check uses
+type natsInfo struct {
+	ServerName  string `json:"server_name"`
+	Version     string `json:"version"`
+	TLSRequired bool   `json:"tls_required"`
+	JetStream   bool   `json:"jetstream"`
+}
+
+// nats connects to a NATS server, with the credentials of the URL, and
+// waits for the answer to a PING
+func (c *brokerCheck) nats(ctx context.Context) (*brokerReport, error) {
+	conn, err := dialBroker(ctx, c.address(c.url.Host))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("no INFO from the server: %w", err)
+	}
+	var info natsInfo
+	payload, found := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !found || json.Unmarshal([]byte(payload), &info) != nil {
+		return nil, fmt.Errorf("not a NATS server: %q", firstLine(line))
+	}
+
+	var writer io.Writer = conn
+	if info.TLSRequired {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		reader, writer = bufio.NewReader(tlsConn), tlsConn
+	}
+
+	connect := map[string]any{"verbose": false, "pedantic": false, "name": "termhome", "lang": "go"}
+	if c.url.User != nil {
+		if password, ok := c.url.User.Password(); ok {
+			connect["user"], connect["pass"] = c.url.User.Username(), password
+		} else {
+			connect["auth_token"] = c.url.User.Username()
+		}
+	}
+	options, _ := json.Marshal(connect)
+	if _, err := fmt.Fprintf(writer, "CONNECT %s\r\nPING\r\n", options); err != nil {
+		return nil, fmt.Errorf("CONNECT failed: %w", err)
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("no PONG from the server: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if message, found := strings.CutPrefix(line, "-ERR "); found {
+			return nil, fmt.Errorf("server error: %s", strings.Trim(message, "'"))
+		}
+		if line == "PONG" {
+			break
+		}
+	}
+
+	report := &brokerReport{state: StatusOK, message: "PONG from " + info.ServerName}
+	report.details = append(report.details, StatusDetail{Label: "Version", Value: info.Version})
+	if info.JetStream {
+		report.details = append(report.details, StatusDetail{Label: "JetStream", Value: "enabled"})
+	}
+	return report, nil
+}
+
+// AMQP 0-9-1 frame and method identifiers
+const (
+	amqpFrameMethod    = 1
+	amqpFrameEnd       = 0xce
+	amqpConnection     = 10
+	amqpStart          = 10
+	amqpStartOk        = 11
+	amqpTune           = 30
+	amqpTuneOk         = 31
+	amqpOpen           = 40
+	amqpOpenOk         = 41
+	amqpClose          = 50
+	amqpCloseOk        = 51
+	amqpProtocolHeader = "AMQP\x00\x00\x09\x01"
+)
+
+// amqp performs the AMQP 0-9-1 handshake. With credentials in the URL it
+// also logs in and opens the virtual host of the path.
+func (c *brokerCheck) amqp(ctx context.Context) (*brokerReport, error) {
+	conn, err := dialBroker(ctx, c.address(c.url.Host))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if c.url.Scheme == "amqps" {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	if _, err := io.WriteString(conn, amqpProtocolHeader); err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	if header, err := reader.Peek(4); err == nil && string(header) == "AMQP" {
+		return nil, fmt.Errorf("server does not support AMQP 0-9-1")
+	}
+	arguments, err := amqpReadMethod(reader, amqpStart)
+	if err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	if len(arguments) < 2 {
+		return nil, fmt.Errorf("handshake failed: invalid Connection.Start")
+	}
+	properties := amqpTableStrings(arguments[2:])
+	report := &brokerReport{state: StatusOK, message: "Handshake with " + strings.TrimSpace(properties["product"]+" "+properties["version"])}
+	if cluster := properties["cluster_name"]; cluster != "" {
+		report.details = append(report.details, StatusDetail{Label: "Cluster", Value: cluster})
+	}
+	if c.url.User == nil {
+		return report, nil
+	}
+
+	user := c.url.User.Username()
+	password, _ := c.url.User.Password()
+	startOk := binary.BigEndian.AppendUint32(nil, 0) // No client properties
+	startOk = amqpShortString(startOk, "PLAIN")
+	startOk = binary.BigEndian.AppendUint32(startOk, uint32(len(user)+len(password)+2))
+	startOk = append(startOk, "\x00"+user+"\x00"+password...)
+	startOk = amqpShortString(startOk, "en_US")
+	if err := amqpWriteMethod(conn, amqpStartOk, startOk); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	// Brokers close the connection on bad credentials, some without a reason
+	tune, err := amqpReadMethod(reader, amqpTune)
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	if len(tune) < 6 {
+		return nil, fmt.Errorf("login failed: invalid Connection.Tune")
+	}
+	tuneOk := append(tune[:6:6], 0, 0) // No heartbeats
+	if err := amqpWriteMethod(conn, amqpTuneOk, tuneOk); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	vhost := "/"
+	if path := strings.TrimPrefix(c.url.Path, "/"); path != "" {
+		vhost = path
+	}
+	if err := amqpWriteMethod(conn, amqpOpen, append(amqpShortString(amqpShortString(nil, vhost), ""), 0)); err != nil {
+		return nil, fmt.Errorf("opening virtual host %s failed: %w", vhost, err)
+	}
+	if _, err := amqpReadMethod(reader, amqpOpenOk); err != nil {
+		return nil, fmt.Errorf("opening virtual host %s failed: %w", vhost, err)
+	}
+	closeArgs := amqpShortString(binary.BigEndian.AppendUint16(nil, 200), "Goodbye")
+	if amqpWriteMethod(conn, amqpClose, append(closeArgs, 0, 0, 0, 0)) == nil {
+		amqpReadMethod(reader, amqpCloseOk)
+	}
+
+	report.message = fmt.Sprintf("Logged in as %s to %s on %s", user, vhost, strings.TrimSpace(properties["product"]+" "+properties["version"]))
+	return report, nil
+}
+
+// amqpWriteMethod writes a method frame of the connection class on channel 0
+func amqpWriteMethod(w io.Writer, method uint16, arguments []byte) error {
+	payload := binary.BigEndian.AppendUint16(nil, amqpConnection)
+	payload = binary.BigEndian.AppendUint16(payload, method)
+	payload = append(payload, arguments...)
+	frame := []byte{amqpFrameMethod, 0, 0}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+	_, err := w.Write(append(frame, amqpFrameEnd))
+	return err
+}
+
+// amqpReadMethod reads the next method frame and returns its arguments if
+// it is the expected method of the connection class. A Connection.Close of
+// the broker is returned as an error with its reason.
+func amqpReadMethod(r io.Reader, method uint16) ([]byte, error) {
+	for {
+		var header [7]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, fmt.Errorf("connection closed by the broker")
+			}
+			return nil, err
+		}
+		size := binary.BigEndian.Uint32(header[3:])
+		if size > 1<<20 {
+			return nil, fmt.Errorf("invalid frame of %d bytes", size)
+		}
+		payload := make([]byte, size+1)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+		if payload[size] != amqpFrameEnd {
+			return nil, fmt.Errorf("invalid frame end")
+		}
+		if header[0] != amqpFrameMethod {
+			continue // Heartbeats
+		}
+		if size < 4 || binary.BigEndian.Uint16(payload) != amqpConnection {
+			return nil, fmt.Errorf("unexpected frame")
+		}
+		arguments := payload[4:size]
+		switch binary.BigEndian.Uint16(payload[2:]) {
+		case method:
+			return arguments, nil
+		case amqpClose:
+			if len(arguments) >= 3 && len(arguments) >= 3+int(arguments[2]) {
+				return nil, fmt.Errorf("%s", arguments[3:3+int(arguments[2])])
+			}
+			return nil, fmt.Errorf("connection closed by the broker")
+		}
+		return nil, fmt.Errorf("unexpected method %d", binary.BigEndian.Uint16(payload[2:]))
+	}
+}
+
+// amqpShortString appends a string of up to 255 bytes prefixed by its length
+func amqpShortString(data []byte, s string) []byte {
+	return append(append(data, byte(len(s))), s...)
+}
+
+// amqpTableStrings returns the string values of a field table, such as the
+// product and version of the server properties. Decoding stops at the first
+// value of a type it does not know.
+func amqpTableStrings(data []byte) map[string]string {
+	values := make(map[string]string)
+	if len(data) < 4 {
+		return values
+	}
+	size := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < size {
+		return values
+	}
+	table := data[4 : 4+size]
+	for len(table) > 2 {
+		nameLength := int(table[0])
+		if len(table) < 2+nameLength {
+			break
+		}
+		name, valueType := string(table[1:1+nameLength]), table[1+nameLength]
+		table = table[2+nameLength:]
+		var length int
+		switch valueType {
+		case 'S', 'F', 'A', 'x':
+			if len(table) < 4 {
+				return values
+			}
+			length = 4 + int(binary.BigEndian.Uint32(table))
+			if valueType == 'S' && len(table) >= length {
+				values[name] = string(table[4:length])
+			}
+		case 't', 'b', 'B':
+			length = 1
+		case 's', 'u':
+			length = 2
+		case 'I', 'i', 'f':
+			length = 4
+		case 'l', 'L', 'd', 'T':
+			length = 8
+		case 'D':
+			length = 5
+		case 'V':
+			length = 0
+		default:
+			return values
+		}
+		if len(table) < length {
+			break
+		}
+		table = table[length:]
+	}
+	return values
+}
+
+// rabbitMQ reads the overview, the nodes and, with a queue warning, the
+// queues of the RabbitMQ management API
+func (c *brokerCheck) rabbitMQ(ctx context.Context) (*brokerReport, error) {
+	tlsConfig, err := clientTLSConfig(c.skipVerify, c.caFile, c.caDir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading CA certificates: %w", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialCached
+	transport.TLSClientConfig = tlsConfig
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport}
+
+	base := url.URL{Scheme: "http", Host: c.address(c.url.Host), Path: strings.TrimSuffix(c.url.Path, "/")}
+	if c.url.Scheme == "rabbitmqs" {
+		base.Scheme = "https"
+	}
+	get := func(path string, value any) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base.String()+path, nil)
+		if err != nil {
+			return err
+		}
+		if c.url.User != nil {
+			password, _ := c.url.User.Password()
+			req.SetBasicAuth(c.url.User.Username(), password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s returned HTTP %d", path, resp.StatusCode)
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(value); err != nil {
+			return fmt.Errorf("invalid %s response: %w", path, err)
+		}
+		return nil
+	}
+
+	var overview struct {
+		Version     string `json:"rabbitmq_version"`
+		ClusterName string `json:"cluster_name"`
+		QueueTotals struct {
+			Messages int `json:"messages"`
+		} `json:"queue_totals"`
+		ObjectTotals struct {
+			Queues      int `json:"queues"`
+			Connections int `json:"connections"`
+		} `json:"object_totals"`
+	}
+	if err := get("/api/overview", &overview); err != nil {
+		return nil, err
+	}
+	var nodes []struct {
+		Name          string `json:"name"`
+		Running       bool   `json:"running"`
+		MemAlarm      bool   `json:"mem_alarm"`
+		DiskFreeAlarm bool   `json:"disk_free_alarm"`
+	}
+	if err := get("/api/nodes?columns=name,running,mem_alarm,disk_free_alarm", &nodes); err != nil {
+		return nil, err
+	}
+
+	report := &brokerReport{
+		state:   StatusOK,
+		message: fmt.Sprintf("RabbitMQ %s, %d queues, %d messages", overview.Version, overview.ObjectTotals.Queues, overview.QueueTotals.Messages),
+		details: []StatusDetail{
+			{Label: "Cluster", Value: overview.ClusterName},
+			{Label: "Connections", Value: fmt.Sprint(overview.ObjectTotals.Connections)},
+		},
+	}
+	if c.queueWarning > 0 {
+		var queues []struct {
+			Name     string `json:"name"`
+			VHost    string `json:"vhost"`
+			Messages int    `json:"messages"`
+		}
+		if err := get("/api/queues?columns=name,vhost,messages", &queues); err != nil {
+			return nil, err
+		}
+		var deep []string
+		for _, queue := range queues {
+			if queue.Messages > c.queueWarning {
+				deep = append(deep, fmt.Sprintf("%s (%d)", queue.Name, queue.Messages))
+			}
+		}
+		if len(deep) > 0 {
+			report.state = StatusWarning
+			report.message = fmt.Sprintf("%d queues over %d messages", len(deep), c.queueWarning)
+			report.details = append(report.details, StatusDetail{Label: "Deep queues", Value: strings.Join(deep, ", ")})
+		}
+	}
+
+	var down, alarms []string
+	for _, node := range nodes {
+		switch {
+		case !node.Running:
+			down = append(down, node.Name)
+		case node.MemAlarm:
+			alarms = append(alarms, node.Name+" (memory)")
+		case node.DiskFreeAlarm:
+			alarms = append(alarms, node.Name+" (disk)")
+		}
+	}
+	// Alarms block all publishers, which is as bad as a node being down
+	if len(alarms) > 0 {
+		report.state = StatusCritical
+		report.message = "Resource alarm on " + strings.Join(alarms, ", ")
+	}
+	if len(down) > 0 {
+		report.state = StatusCritical
+		report.message = "Nodes down: " + strings.Join(down, ", ")
+	}
+	return report, nil
+}
@@ -0,0 +1,299 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveFakeBroker accepts connections and hands them to serve, returning
+// the address of the listener
+func serveFakeBroker(t *testing.T, serve func(conn net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// fakeKafka answers a metadata request with two brokers and the partitions
+// of an orders topic, given as leader, replicas and in-sync replicas
+func fakeKafka(partitions [][3]int32) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		request := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, request); err != nil || binary.BigEndian.Uint16(request) != 3 {
+			return
+		}
+		appendString := func(data []byte, s string) []byte {
+			return append(binary.BigEndian.AppendUint16(data, uint16(len(s))), s...)
+		}
+		response := append([]byte(nil), request[4:8]...) // Correlation ID
+		response = binary.BigEndian.AppendUint32(response, 2)
+		for id, host := range []string{"kafka-1", "kafka-2"} {
+			response = binary.BigEndian.AppendUint32(response, uint32(id+1))
+			response = appendString(response, host)
+			response = binary.BigEndian.AppendUint32(response, 9092)
+		}
+		response = binary.BigEndian.AppendUint32(response, 1)
+		response = binary.BigEndian.AppendUint16(response, 0)
+		response = appendString(response, "orders")
+		response = binary.BigEndian.AppendUint32(response, uint32(len(partitions)))
+		for id, partition := range partitions {
+			response = binary.BigEndian.AppendUint16(response, 0)
+			response = binary.BigEndian.AppendUint32(response, uint32(id))
+			response = binary.BigEndian.AppendUint32(response, uint32(partition[0]))
+			for _, count := range partition[1:] {
+				response = binary.BigEndian.AppendUint32(response, uint32(count))
+				for replica := int32(1); replica <= count; replica++ {
+					response = binary.BigEndian.AppendUint32(response, uint32(replica))
+				}
+			}
+		}
+		conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(response))), response...))
+	}
+}
+
+func TestBrokerCheckKafka(t *testing.T) {
+	healthy := serveFakeBroker(t, fakeKafka([][3]int32{{1, 2, 2}, {2, 2, 2}}))
+	result := newBrokerCheck(&Service{Broker: "kafka://" + healthy}).run(context.Background(), "Kafka")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "2 brokers, 1 topics ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Brokers", Value: "kafka-1:9092, kafka-2:9092"})
+
+	// The first bootstrap server is down, the second answers
+	down := serveFakeBroker(t, func(conn net.Conn) {})
+	degraded := serveFakeBroker(t, fakeKafka([][3]int32{{1, 2, 2}, {2, 2, 1}}))
+	result = newBrokerCheck(&Service{Broker: "kafka://" + down + "," + degraded}).run(context.Background(), "Kafka")
+	assert.Equal(t, StatusWarning, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "1 under-replicated partitions ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Under-replicated", Value: "orders-1"})
+
+	offline := serveFakeBroker(t, fakeKafka([][3]int32{{-1, 2, 0}, {2, 2, 1}}))
+	result = newBrokerCheck(&Service{Broker: "kafka://" + offline}).run(context.Background(), "Kafka")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "1 partitions without leader ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Without leader", Value: "orders-0"})
+
+	result = newBrokerCheck(&Service{Broker: "kafka://" + down}).run(context.Background(), "Kafka")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Metadata request failed: "), result.Message)
+}
+
+func TestKafkaReaderMalformed(t *testing.T) {
+	// A huge array count is reported without allocating the array
+	data := binary.BigEndian.AppendUint32(nil, 0x7fffffff)
+	var count int
+	var next int32
+	var response *kafkaReader
+	allocs := testing.AllocsPerRun(1, func() {
+		response = &kafkaReader{data: data}
+		count = response.skipInt32s()
+		next = response.int32()
+	})
+	assert.LessOrEqual(t, allocs, 1.0) // The reader itself
+	assert.Equal(t, 0, count)
+	assert.Equal(t, int32(0), next)
+	assert.ErrorIs(t, response.err, io.ErrUnexpectedEOF)
+
+	// Truncated strings and scalars read as empty
+	response = &kafkaReader{data: []byte{0, 9, 'k'}}
+	assert.Equal(t, "", response.string())
+	assert.Equal(t, int16(0), response.int16())
+	assert.ErrorIs(t, response.err, io.ErrUnexpectedEOF)
+
+	// A service on the Kafka port answering garbage fails the check
+	addr := serveFakeBroker(t, func(conn net.Conn) {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		io.ReadFull(conn, make([]byte, binary.BigEndian.Uint32(size[:])))
+		response := binary.BigEndian.AppendUint32(nil, 1) // Correlation ID
+		response = binary.BigEndian.AppendUint32(response, 0)
+		response = binary.BigEndian.AppendUint32(response, 1)
+		response = binary.BigEndian.AppendUint16(response, 0)
+		response = binary.BigEndian.AppendUint16(response, 0)
+		response = binary.BigEndian.AppendUint32(response, 1)
+		response = binary.BigEndian.AppendUint16(response, 0)
+		response = binary.BigEndian.AppendUint32(response, 0)
+		response = binary.BigEndian.AppendUint32(response, 1)
+		response = binary.BigEndian.AppendUint32(response, 0x7fffffff) // Replicas
+		conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(response))), response...))
+	})
+	_, err := kafkaFetchMetadata(context.Background(), addr)
+	assert.EqualError(t, err, "invalid metadata response: unexpected EOF")
+}
+
+func TestBrokerCheckNATS(t *testing.T) {
+	addr := serveFakeBroker(t, func(conn net.Conn) {
+		fmt.Fprint(conn, `INFO {"server_name":"nats-1","version":"2.10.4","jetstream":true,"auth_required":true}`+"\r\n")
+		reader := bufio.NewReader(conn)
+		line, _ := reader.ReadString('\n')
+		var options map[string]any
+		json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &options)
+		if ping, _ := reader.ReadString('\n'); ping != "PING\r\n" {
+			return
+		}
+		if options["user"] != "app" || options["pass"] != "secret" {
+			fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+			return
+		}
+		fmt.Fprint(conn, "PONG\r\n")
+	})
+
+	result := newBrokerCheck(&Service{Broker: "nats://app:secret@" + addr}).run(context.Background(), "NATS")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "PONG from nats-1 ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Version", Value: "2.10.4"})
+	assert.Contains(t, result.Details, StatusDetail{Label: "JetStream", Value: "enabled"})
+	assert.Contains(t, result.Details, StatusDetail{Label: "Broker", Value: "nats://app:xxxxx@" + addr})
+
+	result = newBrokerCheck(&Service{Broker: "nats://app:wrong@" + addr}).run(context.Background(), "NATS")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Server error: Authorization Violation", result.Message)
+}
+
+// fakeAMQP performs the connection handshake of an AMQP broker accepting
+// guest:guest on the virtual host /
+func fakeAMQP(conn net.Conn) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil || string(header) != amqpProtocolHeader {
+		return
+	}
+	var properties []byte
+	for _, property := range [][2]string{{"product", "RabbitMQ"}, {"version", "3.13.0"}, {"cluster_name", "rabbit@mq"}} {
+		properties = amqpShortString(properties, property[0])
+		properties = append(properties, 'S')
+		properties = binary.BigEndian.AppendUint32(properties, uint32(len(property[1])))
+		properties = append(properties, property[1]...)
+	}
+	properties = append(amqpShortString(properties, "capabilities"), 'F', 0, 0, 0, 0)
+	start := append([]byte{0, 9}, binary.BigEndian.AppendUint32(nil, uint32(len(properties)))...)
+	start = append(start, properties...)
+	start = append(binary.BigEndian.AppendUint32(start, 5), "PLAIN"...)
+	start = append(binary.BigEndian.AppendUint32(start, 5), "en_US"...)
+	amqpWriteMethod(conn, amqpStart, start)
+
+	reader := bufio.NewReader(conn)
+	startOk, err := amqpReadMethod(reader, amqpStartOk)
+	if err != nil {
+		return
+	}
+	response := startOk[4+1+len("PLAIN")+4:]
+	if !strings.HasPrefix(string(response), "\x00guest\x00guest") {
+		closeArgs := amqpShortString(binary.BigEndian.AppendUint16(nil, 403), "ACCESS_REFUSED - Login was refused")
+		amqpWriteMethod(conn, amqpClose, append(closeArgs, 0, 0, 0, 0))
+		return
+	}
+	tune := binary.BigEndian.AppendUint16(nil, 2047)
+	tune = binary.BigEndian.AppendUint32(tune, 131072)
+	amqpWriteMethod(conn, amqpTune, binary.BigEndian.AppendUint16(tune, 60))
+	if _, err := amqpReadMethod(reader, amqpTuneOk); err != nil {
+		return
+	}
+	open, err := amqpReadMethod(reader, amqpOpen)
+	if err != nil {
+		return
+	}
+	if vhost := string(open[1 : 1+open[0]]); vhost != "/" {
+		closeArgs := amqpShortString(binary.BigEndian.AppendUint16(nil, 530), "NOT_ALLOWED - vhost "+vhost+" not found")
+		amqpWriteMethod(conn, amqpClose, append(closeArgs, 0, 0, 0, 0))
+		return
+	}
+	amqpWriteMethod(conn, amqpOpenOk, amqpShortString(nil, ""))
+	if _, err := amqpReadMethod(reader, amqpClose); err == nil {
+		amqpWriteMethod(conn, amqpCloseOk, nil)
+	}
+}
+
+func TestBrokerCheckAMQP(t *testing.T) {
+	addr := serveFakeBroker(t, fakeAMQP)
+
+	result := newBrokerCheck(&Service{Broker: "amqp://" + addr}).run(context.Background(), "RabbitMQ")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Handshake with RabbitMQ 3.13.0 ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Cluster", Value: "rabbit@mq"})
+
+	result = newBrokerCheck(&Service{Broker: "amqp://guest:guest@" + addr}).run(context.Background(), "RabbitMQ")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Logged in as guest to / on RabbitMQ 3.13.0 ("), result.Message)
+
+	result = newBrokerCheck(&Service{Broker: "amqp://guest:wrong@" + addr}).run(context.Background(), "RabbitMQ")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Login failed: ACCESS_REFUSED - Login was refused", result.Message)
+
+	result = newBrokerCheck(&Service{Broker: "amqp://guest:guest@" + addr + "/orders"}).run(context.Background(), "RabbitMQ")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Opening virtual host orders failed: NOT_ALLOWED - vhost orders not found", result.Message)
+}
+
+func TestBrokerCheckRabbitMQ(t *testing.T) {
+	memAlarm := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "monitor" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/overview":
+			fmt.Fprint(w, `{"rabbitmq_version":"3.13.0","cluster_name":"rabbit@mq","queue_totals":{"messages":1250},"object_totals":{"queues":2,"connections":7}}`)
+		case "/api/nodes":
+			fmt.Fprintf(w, `[{"name":"rabbit@mq","running":true,"mem_alarm":%t,"disk_free_alarm":false}]`, memAlarm)
+		case "/api/queues":
+			fmt.Fprint(w, `[{"name":"orders","vhost":"/","messages":1200},{"name":"emails","vhost":"/","messages":50}]`)
+		}
+	}))
+	defer server.Close()
+	broker := "rabbitmq://monitor:secret@" + strings.TrimPrefix(server.URL, "http://")
+
+	result := newBrokerCheck(&Service{Broker: broker}).run(context.Background(), "RabbitMQ")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "RabbitMQ 3.13.0, 2 queues, 1250 messages ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Connections", Value: "7"})
+
+	result = newBrokerCheck(&Service{Broker: broker, BrokerQueueWarning: 1000}).run(context.Background(), "RabbitMQ")
+	assert.Equal(t, StatusWarning, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "1 queues over 1000 messages ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Deep queues", Value: "orders (1200)"})
+
+	memAlarm = true
+	result = newBrokerCheck(&Service{Broker: broker}).run(context.Background(), "RabbitMQ")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Resource alarm on rabbit@mq (memory) ("), result.Message)
+
+	result = newBrokerCheck(&Service{Broker: strings.Replace(broker, "secret", "wrong", 1)}).run(context.Background(), "RabbitMQ")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "/api/overview returned HTTP 401", result.Message)
+}
+
+func TestBrokerCheckInvalidURL(t *testing.T) {
+	result := newBrokerCheck(&Service{Broker: "mqtt://broker:1883"}).run(context.Background(), "MQTT")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, `Invalid broker URL: unsupported scheme "mqtt", use kafka, nats, amqp, amqps, rabbitmq or rabbitmqs`, result.Message)
+}
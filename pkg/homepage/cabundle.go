@@ -0,0 +1,128 @@
+package homepage
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// caBundles holds the CA certificates of settings.yaml and the pools built
+// with them, so certificate files are read once per configuration
+var caBundles = struct {
+	mutex sync.Mutex
+	file  string
+	dir   string
+	pools map[string]*x509.CertPool
+}{pools: make(map[string]*x509.CertPool)}
+
+// SetCAs sets the CA bundle file and directory of certificates trusted by all
+// checks, widgets and remotes in addition to the system CAs, e.g. those of a
+// private CA signing internal services. Certificates are read again after
+// the next call.
+func SetCAs(file, dir string) {
+	caBundles.mutex.Lock()
+	defer caBundles.mutex.Unlock()
+	caBundles.file, caBundles.dir = file, dir
+	caBundles.pools = make(map[string]*x509.CertPool)
+}
+
+// clientTLSConfig returns the TLS configuration of a client trusting the
+// system CAs, the CAs of settings.yaml and those of caFile and caDir
+func clientTLSConfig(skipVerify bool, caFile, caDir string) (*tls.Config, error) {
+	pool, err := caPool(caFile, caDir)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{InsecureSkipVerify: skipVerify, RootCAs: pool}, nil
+}
+
+// caPool returns the pool of the system CAs and the configured ones, or nil
+// for the system CAs alone when none are configured
+func caPool(caFile, caDir string) (*x509.CertPool, error) {
+	caBundles.mutex.Lock()
+	defer caBundles.mutex.Unlock()
+
+	files := []string{caBundles.file, caFile}
+	dirs := []string{caBundles.dir, caDir}
+	if strings.Join(files, "") == "" && strings.Join(dirs, "") == "" {
+		return nil, nil
+	}
+	key := strings.Join(append(files, dirs...), "\x00")
+	if pool, ok := caBundles.pools[key]; ok {
+		return pool, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if err := addCAFile(pool, expandHome(file)); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(expandHome(dir))
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA directory: %w", err)
+		}
+		found := false
+		for _, entry := range entries {
+			if entry.IsDir() || !isCertFile(entry.Name()) {
+				continue
+			}
+			// Files without certificates, such as keys, are skipped
+			if data, err := os.ReadFile(filepath.Join(expandHome(dir), entry.Name())); err == nil && pool.AppendCertsFromPEM(data) {
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no PEM certificates in %s", dir)
+		}
+	}
+	caBundles.pools[key] = pool
+	return pool, nil
+}
+
+// addCAFile adds the PEM certificates of a file to a pool
+func addCAFile(pool *x509.CertPool, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading CA file: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no PEM certificates in %s", path)
+	}
+	return nil
+}
+
+// isCertFile reports whether a file in a CA directory holds certificates,
+// judging by its extension as update-ca-certificates does
+func isCertFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pem", ".crt", ".cer":
+		return true
+	}
+	return false
+}
+
+// failingTransport fails every request with an error, such as a CA bundle
+// that could not be loaded, so it surfaces where the request is made
+type failingTransport struct {
+	err error
+}
+
+func (t failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
@@ -0,0 +1,68 @@
+package homepage
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTLSServer starts an HTTPS server and writes its self-signed certificate
+// to dir/ca.pem
+func testTLSServer(t *testing.T, dir string) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	writeTestFile(t, filepath.Join(dir, "ca.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	return server
+}
+
+func TestHTTPCheckCAs(t *testing.T) {
+	dir := t.TempDir()
+	server := testTLSServer(t, dir)
+	writeTestFile(t, filepath.Join(dir, "ca.key"), []byte("not a certificate"))
+	t.Cleanup(func() { SetCAs("", "") })
+
+	result := newHTTPCheck(&Service{SiteMonitor: server.URL}).run(context.Background(), "Internal")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "certificate")
+
+	result = newHTTPCheck(&Service{SiteMonitor: server.URL, CAFile: filepath.Join(dir, "ca.pem")}).run(context.Background(), "Internal")
+	assert.Equal(t, StatusOK, result.State, result.Message)
+
+	// Files of a CA directory without certificates are skipped
+	result = newHTTPCheck(&Service{SiteMonitor: server.URL, CADir: dir}).run(context.Background(), "Internal")
+	assert.Equal(t, StatusOK, result.State, result.Message)
+
+	result = newHTTPCheck(&Service{SiteMonitor: server.URL, CAFile: filepath.Join(dir, "ca.key")}).run(context.Background(), "Internal")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "Error loading CA certificates: no PEM certificates")
+
+	// Global CAs are trusted by all checks
+	SetCAs(filepath.Join(dir, "ca.pem"), "")
+	result = newHTTPCheck(&Service{SiteMonitor: server.URL}).run(context.Background(), "Internal")
+	assert.Equal(t, StatusOK, result.State, result.Message)
+}
+
+func TestWidgetCAs(t *testing.T) {
+	dir := t.TempDir()
+	server := testTLSServer(t, dir)
+
+	config := &WidgetConfig{URL: server.URL, CAFile: filepath.Join(dir, "ca.pem")}
+	var out map[string]interface{}
+	require.NoError(t, config.getJSON(context.Background(), "/", nil, &out))
+
+	config = &WidgetConfig{URL: server.URL, CAFile: filepath.Join(dir, "missing.pem")}
+	err := config.getJSON(context.Background(), "/", nil, &out)
+	assert.ErrorContains(t, err, "error reading CA file")
+}
@@ -0,0 +1,258 @@
+package homepage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time and timers of the status monitor. The real clock
+// is used by default; tests use a FakeClock to advance time deterministically.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a single event timer created by a Clock, see time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker delivers ticks at intervals, see time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock is the Clock backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return realTimer{time.AfterFunc(d, f)} }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// FakeClock is a Clock whose time only moves when Advance is called. Timers
+// and tickers fire during Advance, in order of their deadlines.
+type FakeClock struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock creates a fake clock set to the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mutex)
+	return c
+}
+
+// fakeTimer is a timer or ticker of a FakeClock
+type fakeTimer struct {
+	clock  *FakeClock
+	c      chan time.Time
+	f      func()        // Called instead of sending on c, for AfterFunc timers
+	period time.Duration // Interval of tickers, zero for timers
+	when   time.Time     // Next deadline
+	active bool
+}
+
+// Now returns the current fake time
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// NewTimer creates a timer firing once d has elapsed on the fake clock
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.addTimer(&fakeTimer{clock: c, c: make(chan time.Time, 1)}, d)
+}
+
+// NewTicker creates a ticker firing every d on the fake clock
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{c.addTimer(&fakeTimer{clock: c, c: make(chan time.Time, 1), period: d}, d)}
+}
+
+// AfterFunc calls f once d has elapsed on the fake clock. f is called on the
+// goroutine calling Advance.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.addTimer(&fakeTimer{clock: c, f: f}, d)
+}
+
+// addTimer schedules a new timer and fires it right away if it is already due
+func (c *FakeClock) addTimer(t *fakeTimer, d time.Duration) *fakeTimer {
+	c.mutex.Lock()
+	t.when = c.now.Add(d)
+	t.active = true
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	c.mutex.Unlock()
+
+	c.fireDue()
+	return t
+}
+
+// Advance moves the fake time forward by d, firing the timers and tickers
+// that become due along the way
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	target := c.now.Add(d)
+	c.mutex.Unlock()
+
+	for {
+		c.mutex.Lock()
+		next := c.nextDue(target)
+		if next == nil {
+			c.now = target
+			c.mutex.Unlock()
+			return
+		}
+		c.now = next.when
+		c.mutex.Unlock()
+
+		c.fireDue()
+	}
+}
+
+// BlockUntil waits until at least n timers and tickers are pending. Tests use
+// it to wait for the monitor goroutines to finish a check and schedule the
+// next one.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for c.pending() < n {
+		c.cond.Wait()
+	}
+}
+
+// pending returns the number of active timers. The caller must hold the mutex.
+func (c *FakeClock) pending() int {
+	count := 0
+	for _, t := range c.timers {
+		if t.active {
+			count++
+		}
+	}
+	return count
+}
+
+// nextDue returns the active timer with the earliest deadline not after
+// limit, or nil. The caller must hold the mutex.
+func (c *FakeClock) nextDue(limit time.Time) *fakeTimer {
+	var due []*fakeTimer
+	for _, t := range c.timers {
+		if t.active && !t.when.After(limit) {
+			due = append(due, t)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].when.Before(due[j].when) })
+	return due[0]
+}
+
+// fireDue fires the timers that are due at the current fake time
+func (c *FakeClock) fireDue() {
+	for {
+		c.mutex.Lock()
+		t := c.nextDue(c.now)
+		if t == nil {
+			c.mutex.Unlock()
+			return
+		}
+		now := c.now
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+		} else {
+			t.active = false
+			c.removeTimer(t)
+		}
+		c.cond.Broadcast()
+		c.mutex.Unlock()
+
+		if t.f != nil {
+			t.f()
+			continue
+		}
+		// Like time.Ticker, drop ticks a slow receiver can't keep up with
+		select {
+		case t.c <- now:
+		default:
+		}
+	}
+}
+
+// removeTimer forgets an inactive timer. The caller must hold the mutex.
+func (c *FakeClock) removeTimer(t *fakeTimer) {
+	for i, existing := range c.timers {
+		if existing == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+// fakeTicker adapts a periodic fakeTimer to the Ticker interface
+type fakeTicker struct{ *fakeTimer }
+
+func (t fakeTicker) Stop() { t.fakeTimer.Stop() }
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	wasActive := t.active
+	t.active = false
+	t.clock.removeTimer(t)
+	t.clock.cond.Broadcast()
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mutex.Lock()
+	wasActive := t.active
+	if !wasActive {
+		c.timers = append(c.timers, t)
+	}
+	t.active = true
+	t.when = c.now.Add(d)
+	if t.period > 0 {
+		t.period = d
+	}
+	// Drop a stale value, as time.Timer does since Go 1.23
+	if t.c != nil {
+		select {
+		case <-t.c:
+		default:
+		}
+	}
+	c.cond.Broadcast()
+	c.mutex.Unlock()
+
+	c.fireDue()
+	return wasActive
+}
@@ -18,27 +18,188 @@ type Settings struct {
 	Language          string                 `yaml:"language"`          // Optional: Interface language
 	LinkTarget        string                 `yaml:"linkTarget"`        // Optional: Link target (_blank, _self, etc.)
 	HideVersion       bool                   `yaml:"hideVersion"`       // Optional: Hide version display
+	ASCIIOnly         bool                   `yaml:"asciiOnly"`         // Optional: Render status icons, scrollbars and borders with ASCII characters only
+	Colors            string                 `yaml:"colors"`            // Optional: Color depth of the terminal: auto (default), truecolor, 256, 16 or 8
 	ShowStats         bool                   `yaml:"showStats"`         // Optional: Show Docker stats
 	BookmarksStyle    string                 `yaml:"bookmarksStyle"`    // Optional: Bookmarks style (default/icons)
+	ColumnWidth       int                    `yaml:"columnWidth"`       // Optional: Minimum width of the columns the service groups are split into, as many as fit the terminal (default: 0, one column)
 	Status            StatusSettings         `yaml:"status"`            // Optional: Status monitoring settings
 	InstanceName      string                 `yaml:"instanceName"`      // Optional: Instance name
 	HideErrors        bool                   `yaml:"hideErrors"`        // Optional: Hide widget error messages
+	API               APISettings            `yaml:"api"`               // Optional: HTTP API served by `termhome serve`
+	Remotes           []RemoteConfig         `yaml:"remotes"`           // Optional: Remote termhome instances to aggregate
+	PluginDir         string                 `yaml:"pluginDir"`         // Optional: Directory of exec plugins, relative to the config directory (default: plugins)
+	ControlSocket     string                 `yaml:"controlSocket"`     // Optional: Path of the control socket used by `termhome ctl`, "off" to disable
+	Timestamps        TimestampSettings      `yaml:"timestamps"`        // Optional: How the time of the last check is shown
+	StatusBar         StatusBarSettings      `yaml:"statusBar"`         // Optional: Segments shown in the footer before the key hints
+	Footer            FooterSettings         `yaml:"footer"`            // Optional: Key hints and static lines of the footer
+	CriticalFocus     string                 `yaml:"criticalFocus"`     // Optional: Draw attention to services turning critical: scroll (default), maximize or off
+	Carousel          CarouselSettings       `yaml:"carousel"`          // Optional: Maximize the groups one after the other
+	Preflight         PreflightSettings      `yaml:"preflight"`         // Optional: Check all the services and show a summary before the dashboard
+	Search            SearchSettings         `yaml:"search"`            // Optional: Web search launched with the s key
+	Logs              []LogConfig            `yaml:"logs"`              // Optional: Log files tailed in the logs panel
+	RateLimits        map[string]int         `yaml:"rateLimits"`        // Optional: Maximum widget API requests per hour by host name
+	CAFile            string                 `yaml:"caFile"`            // Optional: PEM bundle of CAs trusted by all checks, widgets and remotes, in addition to the system ones
+	CADir             string                 `yaml:"caDir"`             // Optional: Directory of PEM CA certificates trusted by all checks, widgets and remotes
+	UnknownKeys       string                 `yaml:"unknownKeys"`       // Optional: How unknown keys of the configuration files are reported: warn (default), error or ignore
+	StateFile         string                 `yaml:"stateFile"`         // Optional: File keeping what is changed from the dashboard, such as disabled services and notes, and the last statuses, relative to the config directory (default: state.json)
+	BookmarkChecks    BookmarkCheckSettings  `yaml:"bookmarkChecks"`    // Optional: Flag bookmarks whose links are dead
+	BookmarkTitles    bool                   `yaml:"bookmarkTitles"`    // Optional: Show the page titles of bookmarks without a description
+	Profiles          map[string]Profile     `yaml:"profiles"`          // Optional: Overrides selected with --profile or TERMHOME_PROFILE
+	MDNS              MDNSSettings           `yaml:"mdns"`              // Optional: List the services advertised on the local network
+	ReadOnly          bool                   `yaml:"readOnly"`          // Optional: Refuse actions, Wake-on-LAN, monitoring toggles and mutes from the dashboard
+}
+
+// BookmarkCheckSettings holds the settings of the checks of bookmark links
+type BookmarkCheckSettings struct {
+	Enabled  bool    `yaml:"enabled"`  // Optional: Check the links of the bookmarks
+	Interval Seconds `yaml:"interval"` // Optional: Interval between checks in seconds (default: 3600)
+	Timeout  Seconds `yaml:"timeout"`  // Optional: Timeout of a check in seconds (default: 10)
+}
+
+// MDNSSettings holds the settings of the discovery of the services advertised
+// on the local network with mDNS
+type MDNSSettings struct {
+	Enabled  bool     `yaml:"enabled"`  // Optional: Browse the local network
+	Group    string   `yaml:"group"`    // Optional: Group listing the services found (default: Discovered)
+	Services []string `yaml:"services"` // Optional: Service types browsed, e.g. _ipp._tcp (default: web servers, printers, cast and AirPlay devices, file shares, SSH and HomeKit)
+	Interval Seconds  `yaml:"interval"` // Optional: Seconds between browses (default: 300)
+	Timeout  Seconds  `yaml:"timeout"`  // Optional: Seconds answers are waited for (default: 3)
+}
+
+// Profile holds the overrides of a profile of settings.yaml, such as work or
+// home, so the same configuration can be used at several locations
+type Profile struct {
+	Groups   []string          `yaml:"groups"`   // Optional: Only load these service and bookmark groups (default: all)
+	BaseURLs map[string]string `yaml:"baseUrls"` // Optional: URL prefixes of links, checks, widgets and remotes, replaced by their value
+}
+
+// CarouselSettings holds the settings of the carousel, which maximizes the
+// groups one after the other so small screens show everything over time
+type CarouselSettings struct {
+	Interval Seconds `yaml:"interval"` // Optional: Seconds each group is shown, 0 disables the carousel (default: 0)
+	Pause    Seconds `yaml:"pause"`    // Optional: Seconds the carousel waits after a key press or click (default: 60)
+}
+
+// LogConfig describes a log file tailed in its own box of the logs panel
+type LogConfig struct {
+	Name      string         `yaml:"name"`      // Optional: Title of the box (default: the file name)
+	Path      string         `yaml:"path"`      // Required: Path of the log file
+	Lines     int            `yaml:"lines"`     // Optional: Number of lines kept (default: 100)
+	Highlight []LogHighlight `yaml:"highlight"` // Optional: Patterns highlighted in the lines, the first matching one wins
+}
+
+// LogHighlight colors the matches of a regular expression in log lines
+type LogHighlight struct {
+	Pattern string `yaml:"pattern"` // Required: Regular expression, e.g. (?i)error
+	Color   string `yaml:"color"`   // Optional: Color of the matches (default: yellow)
+}
+
+// SearchSettings configures the web search provider, like the search widget
+// of gethomepage
+type SearchSettings struct {
+	Provider string `yaml:"provider"` // Optional: duckduckgo (default), google, bing, brave, startpage or custom
+	URL      string `yaml:"url"`      // Optional: URL of a custom provider, {query} is replaced by the query or it is appended
+}
+
+// TimestampSettings controls how the time a service was last checked is shown
+type TimestampSettings struct {
+	Format        string `yaml:"format"`        // Optional: relative (default), absolute or hidden
+	Timezone      string `yaml:"timezone"`      // Optional: IANA timezone of absolute timestamps (default: local)
+	Clock         string `yaml:"clock"`         // Optional: 24h (default) or 12h
+	HideCountdown bool   `yaml:"hideCountdown"` // Optional: Hide the time until the next check in the group titles
+}
+
+// PreflightSettings holds the settings of the checks run on startup before
+// the dashboard is shown, so it starts with real statuses
+type PreflightSettings struct {
+	Enabled bool    `yaml:"enabled"` // Optional: Show the progress of the first checks, then a summary, before the dashboard; any key skips them
+	Timeout Seconds `yaml:"timeout"` // Optional: Longest wait for the first checks in seconds (default: 30)
+	Summary Seconds `yaml:"summary"` // Optional: Seconds the summary is shown (default: 5)
+}
+
+// StatusBarSettings selects the segments of the status bar in the footer
+type StatusBarSettings struct {
+	HideClock        bool `yaml:"hideClock"`        // Optional: Hide the current time
+	HideNextCheck    bool `yaml:"hideNextCheck"`    // Optional: Hide the time until the next check of any service
+	HideLastIncident bool `yaml:"hideLastIncident"` // Optional: Hide when a service last turned critical
+	HideMuted        bool `yaml:"hideMuted"`        // Optional: Hide the number of services with checks disabled or muted
+}
+
+// FooterSettings customizes the footer, e.g. to keep operational details
+// such as the on-call phone number on screen
+type FooterSettings struct {
+	Hints        string   `yaml:"hints"`        // Optional: Text replacing the key hints of the full layout, "off" to hide them
+	CompactHints string   `yaml:"compactHints"` // Optional: Text replacing the key hints of the compact layout, "off" to hide them
+	Lines        []string `yaml:"lines"`        // Optional: Static lines shown below the status bar in the full layout, may use color tags such as [yellow]
+}
+
+// APISettings holds the settings of the HTTP API served in serve mode
+type APISettings struct {
+	Listen     string             `yaml:"listen"`     // Optional: Address to listen on (default: 127.0.0.1:8080)
+	Token      string             `yaml:"token"`      // Optional: Bearer token required by API clients
+	StatusPage StatusPageSettings `yaml:"statusPage"` // Optional: Public status page served at /status
+}
+
+// StatusPageSettings holds the settings of the public status page, served
+// without the API token
+type StatusPageSettings struct {
+	Enabled   bool       `yaml:"enabled"`   // Optional: Serve the status page
+	Title     string     `yaml:"title"`     // Optional: Title of the page (default: the instance name)
+	Groups    []string   `yaml:"groups"`    // Required: Groups shown on the page, none are shown by default
+	Hide      []string   `yaml:"hide"`      // Optional: Services of these groups not shown on the page
+	ShowNotes bool       `yaml:"showNotes"` // Optional: Show the notes of the services and groups shown
+	Incidents []Incident `yaml:"incidents"` // Optional: Incident notes shown above the services
+}
+
+// Incident is a note about an incident or a maintenance shown on the status page
+type Incident struct {
+	Title   string      `yaml:"title"`   // Required: Title of the note
+	Message string      `yaml:"message"` // Optional: Details
+	Date    string      `yaml:"date"`    // Optional: When it happened, shown as written
+	State   StatusState `yaml:"state"`   // Optional: ok, warning (default) or critical
+}
+
+// RemoteConfig describes a remote termhome instance running in serve mode
+// whose statuses are pulled and shown as additional groups
+type RemoteConfig struct {
+	Name       string  `yaml:"name"`       // Required: Name of the remote, used as group prefix
+	URL        string  `yaml:"url"`        // Required: Base URL of the remote API (e.g. http://site-b:8080)
+	Token      string  `yaml:"token"`      // Optional: Bearer token of the remote API
+	Interval   Seconds `yaml:"interval"`   // Optional: Poll interval in seconds (default: 30)
+	Timeout    Seconds `yaml:"timeout"`    // Optional: Request timeout in seconds (default: 10)
+	SkipVerify bool    `yaml:"skipVerify"` // Optional: Skip TLS certificate verification
+	CAFile     string  `yaml:"caFile"`     // Optional: PEM bundle of CAs trusted in addition to the system and global ones
+	CADir      string  `yaml:"caDir"`      // Optional: Directory of PEM CA certificates trusted in addition
 }
 
 // GroupLayout holds layout configuration for a service or bookmark group
 type GroupLayout struct {
-	Style       string `yaml:"style"`       // Optional: Layout style (row/column)
-	Columns     int    `yaml:"columns"`     // Optional: Number of columns
-	IconsOnly   bool   `yaml:"iconsOnly"`   // Optional: Icons only mode for bookmarks
-	Collapsible bool   `yaml:"collapsible"` // Optional: Make section collapsible
-	Collapsed   bool   `yaml:"collapsed"`   // Optional: Initial collapsed state
-	EqualHeight bool   `yaml:"equalHeight"` // Optional: Use equal height cards
+	Style       string   `yaml:"style"`       // Optional: Layout style (row/column)
+	Columns     int      `yaml:"columns"`     // Optional: Number of columns
+	IconsOnly   bool     `yaml:"iconsOnly"`   // Optional: Icons only mode for bookmarks
+	Collapsible bool     `yaml:"collapsible"` // Optional: Make section collapsible
+	Collapsed   bool     `yaml:"collapsed"`   // Optional: Initial collapsed state
+	EqualHeight bool     `yaml:"equalHeight"` // Optional: Use equal height cards
+	Weight      int      `yaml:"weight"`      // Optional: Sort weight of the group (lower comes first)
+	Tags        []string `yaml:"tags"`        // Optional: Tags of all the services or bookmarks of the group
 }
 
 // StatusSettings holds global status monitoring settings
 type StatusSettings struct {
-	CheckInterval int                    `yaml:"checkInterval"` // Global status check interval in seconds
+	CheckInterval Seconds                `yaml:"checkInterval"` // Global status check interval in seconds
+	RetryInterval Seconds                `yaml:"retryInterval"` // Default interval in seconds to recheck failing services
 	DefaultStyle  map[string]StatusStyle `yaml:"style"`         // Default status styles
+	HistorySize   int                    `yaml:"historySize"`   // Status changes kept per service (default: 100)
+	HistoryMemory int                    `yaml:"historyMemory"` // Memory budget of the status changes of all services in MB (default: 4)
+	ResponseTimes ResponseTimeColors     `yaml:"responseTimes"` // Optional: Colors of the response times in the status lines, by latency bucket
+}
+
+// ResponseTimeColor is a latency bucket coloring the response times below a
+// threshold
+type ResponseTimeColor struct {
+	Below int    `yaml:"below"` // Optional: Response time in milliseconds below which the color applies (default: any, for the last bucket)
+	Color string `yaml:"color"` // Required: Color name or hex color, e.g. green or #ff8800
 }
 
 // StatusStyle defines custom styling for status indicators
@@ -56,21 +217,116 @@ type Service struct {
 	Status                   string                 `yaml:"status"`                   // Optional: Custom field for static text status
 	Ping                     string                 `yaml:"ping"`                     // Optional: Host to ping (simple ICMP check)
 	PingCount                int                    `yaml:"pingCount"`                // Optional: Number of pings to send (default: 3)
-	PingInterval             int                    `yaml:"pingInterval"`             // Optional: Ping interval in seconds (default: 60)
+	PingInterval             Seconds                `yaml:"pingInterval"`             // Optional: Ping interval in seconds (default: 60)
 	SiteMonitor              string                 `yaml:"siteMonitor"`              // Optional: URL to monitor (simple HTTP check)
 	SiteMonitorMethod        string                 `yaml:"siteMonitorMethod"`        // Optional: HTTP method for site monitor (default: HEAD)
-	SiteMonitorTimeout       int                    `yaml:"siteMonitorTimeout"`       // Optional: Request timeout for site monitor in seconds (default: 10)
-	SiteMonitorInterval      int                    `yaml:"siteMonitorInterval"`      // Optional: Check interval for site monitor in seconds (default: 60)
+	SiteMonitorTimeout       Seconds                `yaml:"siteMonitorTimeout"`       // Optional: Request timeout for site monitor in seconds (default: 10)
+	SiteMonitorInterval      Seconds                `yaml:"siteMonitorInterval"`      // Optional: Check interval for site monitor in seconds (default: 60)
 	SiteMonitorExpectedCodes []int                  `yaml:"siteMonitorExpectedCodes"` // Optional: HTTP codes to consider "up" (default: [200])
 	SiteMonitorHeaders       map[string]string      `yaml:"siteMonitorHeaders"`       // Optional: Headers to include in the site monitor request
+	SiteMonitorExpectedBody  string                 `yaml:"siteMonitorExpectedBody"`  // Optional: Text the response body must contain, or a regular expression between slashes
 	SiteMonitorSkipVerify    bool                   `yaml:"siteMonitorSkipVerify"`    // Optional: Skip TLS certificate verification for site monitor
+	SiteMonitorClientCert    string                 `yaml:"siteMonitorClientCert"`    // Optional: PEM client certificate for site monitors behind mutual TLS
+	SiteMonitorClientKey     string                 `yaml:"siteMonitorClientKey"`     // Optional: PEM private key of the client certificate (default: in the certificate file)
+	CAFile                   string                 `yaml:"caFile"`                   // Optional: PEM bundle of CAs trusted by the site monitor and the other checks using TLS, in addition to the system and global ones
+	CADir                    string                 `yaml:"caDir"`                    // Optional: Directory of PEM CA certificates trusted by the site monitor and the other checks using TLS
+	TCPCheck                 string                 `yaml:"tcpCheck"`                 // Optional: host:port connected to (simple TCP check)
+	TCPCheckTimeout          Seconds                `yaml:"tcpCheckTimeout"`          // Optional: Connect timeout for the TCP check in seconds (default: 10)
+	TCPCheckInterval         Seconds                `yaml:"tcpCheckInterval"`         // Optional: TCP check interval in seconds (default: 60)
+	DNSCheck                 string                 `yaml:"dnsCheck"`                 // Optional: Host name resolved (simple DNS check)
+	DNSCheckServer           string                 `yaml:"dnsCheckServer"`           // Optional: Nameserver queried, as host or host:port (default: the system resolver)
+	DNSCheckType             string                 `yaml:"dnsCheckType"`             // Optional: Record type: A, AAAA, CNAME, MX, NS or TXT (default: A and AAAA)
+	DNSCheckExpected         []string               `yaml:"dnsCheckExpected"`         // Optional: Values the answer may contain, any other is critical
+	DNSCheckTimeout          Seconds                `yaml:"dnsCheckTimeout"`          // Optional: Timeout of the DNS check in seconds (default: 5)
+	DNSCheckInterval         Seconds                `yaml:"dnsCheckInterval"`         // Optional: DNS check interval in seconds (default: 60)
+	SMTPCheck                string                 `yaml:"smtpCheck"`                // Optional: Mail server greeted with SMTP, as host or host:port; port 465 uses implicit TLS (default port: 25)
+	SMTPCheckStartTLS        bool                   `yaml:"smtpCheckStartTls"`        // Optional: Require STARTTLS and switch to TLS, e.g. on the submission port 587
+	SMTPCheckEHLO            string                 `yaml:"smtpCheckEhlo"`            // Optional: Name sent with EHLO to list the extensions of the server (default: no EHLO, or localhost with STARTTLS)
+	SMTPCheckSkipVerify      bool                   `yaml:"smtpCheckSkipVerify"`      // Optional: Skip TLS certificate verification for the SMTP check
+	SMTPCheckTimeout         Seconds                `yaml:"smtpCheckTimeout"`         // Optional: Timeout of the SMTP check in seconds (default: 10)
+	SMTPCheckInterval        Seconds                `yaml:"smtpCheckInterval"`        // Optional: SMTP check interval in seconds (default: 60)
+	Redis                    string                 `yaml:"redis"`                    // Optional: redis:// or rediss:// (TLS) URL of a Redis server sent a PING, with the password or the user and password, e.g. redis://:secret@cache:6379
+	RedisSkipVerify          bool                   `yaml:"redisSkipVerify"`          // Optional: Skip TLS certificate verification for the Redis check
+	RedisTimeout             Seconds                `yaml:"redisTimeout"`             // Optional: Timeout of the Redis check in seconds (default: 10)
+	RedisInterval            Seconds                `yaml:"redisInterval"`            // Optional: Redis check interval in seconds (default: 60)
 	StatusStyle              map[string]StatusStyle `yaml:"statusStyle"`              // Optional: Custom styling for status indicators
 	DisableStatus            bool                   `yaml:"disableStatus"`            // Optional: Disable status monitoring for this service
 	Server                   string                 `yaml:"server"`                   // Optional: Docker server reference
 	Container                string                 `yaml:"container"`                // Optional: Docker container name
 	ShowStats                bool                   `yaml:"showStats"`                // Optional: Show Docker stats
-	Widget                   interface{}            `yaml:"widget"`                   // Optional: Widget configuration
+	Widget                   *WidgetConfig          `yaml:"widget"`                   // Optional: Widget configuration
 	SubtitleURL              string                 `yaml:"subtitleUrl"`              // Optional: URL for subtitle content
+	Weight                   int                    `yaml:"weight"`                   // Optional: Sort weight within the group (lower comes first, alias: order)
+	ShowOnlyWhenDown         bool                   `yaml:"showOnlyWhenDown"`         // Optional: Hide the service while healthy (alias: hidden)
+	Tags                     []string               `yaml:"tags"`                     // Optional: Tags the dashboard can be filtered by
+	RetryInterval            Seconds                `yaml:"retryInterval"`            // Optional: Check interval in seconds while the service is failing (default: normal interval)
+	Retries                  int                    `yaml:"retries"`                  // Optional: Consecutive checks that must agree before the state changes, so a single dropped packet doesn't turn the service critical (default: 1)
+	ResponseTimeWarn         int                    `yaml:"responseTimeWarn"`         // Optional: Response time in milliseconds turning a successful ping, HTTP, TCP, DNS, SMTP or Redis check warning
+	ResponseTimeCritical     int                    `yaml:"responseTimeCritical"`     // Optional: Response time in milliseconds turning a successful ping, HTTP, TCP, DNS, SMTP or Redis check critical
+	MuteFor                  Seconds                `yaml:"muteFor"`                  // Optional: Keep checking but don't draw attention to the service for this long after loading, e.g. 2h
+	HeartbeatPeriod          Seconds                `yaml:"heartbeatPeriod"`          // Optional: Expected seconds between heartbeats, enables the heartbeat check
+	HeartbeatGrace           Seconds                `yaml:"heartbeatGrace"`           // Optional: Extra seconds to wait for a late heartbeat (default: 60)
+	HeartbeatToken           string                 `yaml:"heartbeatToken"`           // Optional: Secret heartbeat URL token (default: service name slug, requires the API token)
+	Plugin                   string                 `yaml:"plugin"`                   // Optional: Exec plugin implementing the check, looked up in the plugin directory
+	PluginConfig             map[string]interface{} `yaml:"pluginConfig"`             // Optional: Configuration passed to the plugin on stdin
+	PluginInterval           Seconds                `yaml:"pluginInterval"`           // Optional: Plugin check interval in seconds (default: 60)
+	PluginTimeout            Seconds                `yaml:"pluginTimeout"`            // Optional: Time the plugin may run in seconds (default: 30)
+	Script                   string                 `yaml:"script"`                   // Optional: Starlark script implementing the check, relative to the config directory
+	ScriptConfig             map[string]interface{} `yaml:"scriptConfig"`             // Optional: Configuration passed to the check function of the script
+	ScriptInterval           Seconds                `yaml:"scriptInterval"`           // Optional: Script check interval in seconds (default: 60)
+	ScriptTimeout            Seconds                `yaml:"scriptTimeout"`            // Optional: Time the script may run in seconds (default: 30)
+	WindowsService           string                 `yaml:"windowsService"`           // Optional: Name of a Windows service whose state is checked (Windows only)
+	WindowsServiceInterval   Seconds                `yaml:"windowsServiceInterval"`   // Optional: Windows service check interval in seconds (default: 60)
+	Launchd                  string                 `yaml:"launchd"`                  // Optional: Label of a launchd job whose state is checked, optionally prefixed by its domain (macOS only)
+	LaunchdInterval          Seconds                `yaml:"launchdInterval"`          // Optional: launchd check interval in seconds (default: 60)
+	Mdadm                    string                 `yaml:"mdadm"`                    // Optional: Software RAID array whose state is checked in /proc/mdstat, e.g. md0, or all (Linux only)
+	MdadmInterval            Seconds                `yaml:"mdadmInterval"`            // Optional: mdadm check interval in seconds (default: 60)
+	FileAge                  string                 `yaml:"fileAge"`                  // Optional: File, or glob whose newest file, must have been modified within fileAgeMax
+	FileAgeMax               Seconds                `yaml:"fileAgeMax"`               // Optional: Maximum age of the file in seconds (default: 86400)
+	FileAgeInterval          Seconds                `yaml:"fileAgeInterval"`          // Optional: File age check interval in seconds (default: 60)
+	DockerVolume             string                 `yaml:"dockerVolume"`             // Optional: Named Docker volume that must exist
+	DockerVolumeMounted      bool                   `yaml:"dockerVolumeMounted"`      // Optional: Also require a running container to mount the volume
+	DockerVolumeInterval     Seconds                `yaml:"dockerVolumeInterval"`     // Optional: Docker volume check interval in seconds (default: 60)
+	DockerNetwork            string                 `yaml:"dockerNetwork"`            // Optional: Docker network that must exist
+	DockerNetworkContainers  []string               `yaml:"dockerNetworkContainers"`  // Optional: Containers that must be attached to the network
+	DockerNetworkInterval    Seconds                `yaml:"dockerNetworkInterval"`    // Optional: Docker network check interval in seconds (default: 60)
+	FileServer               string                 `yaml:"fileServer"`               // Optional: ftp://, ftps:// or sftp:// URL of a file server to log in to, with the user, password and a path to list
+	FileServerKey            string                 `yaml:"fileServerKey"`            // Optional: Private key of SFTP logins (default: the ssh agent and ~/.ssh/id_ed25519, id_ecdsa or id_rsa)
+	FileServerSkipVerify     bool                   `yaml:"fileServerSkipVerify"`     // Optional: Skip the verification of the FTPS certificate or of the SFTP host key in ~/.ssh/known_hosts
+	FileServerTimeout        Seconds                `yaml:"fileServerTimeout"`        // Optional: Timeout of the login and listing in seconds (default: 10)
+	FileServerInterval       Seconds                `yaml:"fileServerInterval"`       // Optional: File server check interval in seconds (default: 60)
+	OIDC                     string                 `yaml:"oidc"`                     // Optional: Issuer URL of an OpenID Connect provider whose discovery document and signing keys are checked
+	OIDCSkipVerify           bool                   `yaml:"oidcSkipVerify"`           // Optional: Skip TLS certificate verification for the OIDC check
+	OIDCTimeout              Seconds                `yaml:"oidcTimeout"`              // Optional: Timeout of each OIDC request in seconds (default: 10)
+	OIDCInterval             Seconds                `yaml:"oidcInterval"`             // Optional: OIDC check interval in seconds (default: 60)
+	Broker                   string                 `yaml:"broker"`                   // Optional: URL of a message broker to check: kafka://, nats://, amqp://, amqps://, or rabbitmq:// and rabbitmqs:// for the management API
+	BrokerQueueWarning       int                    `yaml:"brokerQueueWarning"`       // Optional: Messages in a RabbitMQ queue above which the service is a warning (default: 0, no warning)
+	BrokerSkipVerify         bool                   `yaml:"brokerSkipVerify"`         // Optional: Skip TLS certificate verification for the broker check
+	BrokerTimeout            Seconds                `yaml:"brokerTimeout"`            // Optional: Timeout of the broker check in seconds (default: 10)
+	BrokerInterval           Seconds                `yaml:"brokerInterval"`           // Optional: Broker check interval in seconds (default: 60)
+	Elasticsearch            string                 `yaml:"elasticsearch"`            // Optional: URL of an Elasticsearch or OpenSearch cluster whose health is checked, with the user and password
+	ElasticsearchAPIKey      string                 `yaml:"elasticsearchApiKey"`      // Optional: Encoded Elasticsearch API key sent instead of the credentials of the URL
+	ElasticsearchSkipVerify  bool                   `yaml:"elasticsearchSkipVerify"`  // Optional: Skip TLS certificate verification for the cluster health check
+	ElasticsearchTimeout     Seconds                `yaml:"elasticsearchTimeout"`     // Optional: Timeout of the cluster health request in seconds (default: 10)
+	ElasticsearchInterval    Seconds                `yaml:"elasticsearchInterval"`    // Optional: Cluster health check interval in seconds (default: 60)
+	S3                       string                 `yaml:"s3"`                       // Optional: Bucket or object checked with a HEAD request: s3://bucket/key on AWS, or the path-style URL of another store
+	S3Region                 string                 `yaml:"s3Region"`                 // Optional: Region the request is signed for (default: AWS_REGION, or us-east-1)
+	S3AccessKeyEnv           string                 `yaml:"s3AccessKeyEnv"`           // Optional: Environment variable of the access key ID (default: AWS_ACCESS_KEY_ID)
+	S3SecretKeyEnv           string                 `yaml:"s3SecretKeyEnv"`           // Optional: Environment variable of the secret access key (default: AWS_SECRET_ACCESS_KEY)
+	S3SkipVerify             bool                   `yaml:"s3SkipVerify"`             // Optional: Skip TLS certificate verification for the S3 check
+	S3Timeout                Seconds                `yaml:"s3Timeout"`                // Optional: Timeout of the S3 request in seconds (default: 10)
+	S3Interval               Seconds                `yaml:"s3Interval"`               // Optional: S3 check interval in seconds (default: 60)
+	Git                      string                 `yaml:"git"`                      // Optional: Remote repository whose ref is listed with git ls-remote
+	GitRef                   string                 `yaml:"gitRef"`                   // Optional: Branch, tag or ref checked (default: HEAD)
+	GitMirror                string                 `yaml:"gitMirror"`                // Optional: Local clone or mirror whose ref must match the remote one
+	GitMaxLag                Seconds                `yaml:"gitMaxLag"`                // Optional: Seconds the mirror may be behind the remote before a warning (default: 3600)
+	GitTimeout               Seconds                `yaml:"gitTimeout"`               // Optional: Timeout of the git commands in seconds (default: 30)
+	GitInterval              Seconds                `yaml:"gitInterval"`              // Optional: Git check interval in seconds (default: 60)
+	MAC                      string                 `yaml:"mac"`                      // Optional: MAC address of the host, to wake it with Wake-on-LAN
+	WakeBroadcast            string                 `yaml:"wakeBroadcast"`            // Optional: Broadcast address Wake-on-LAN packets are sent to (default: 255.255.255.255:9)
+	Actions                  map[string]string      `yaml:"actions"`                  // Optional: Shell commands by name, run from the dashboard, e.g. restart: systemctl restart foo
+	ActionTimeout            Seconds                `yaml:"actionTimeout"`            // Optional: Time an action may run in seconds (default: 60)
+	Remote                   string                 `yaml:"-"`                        // Name of the remote instance the service is pulled from, if any
 }
 
 // ServiceGroup represents a group of services in services.yaml.
@@ -86,11 +342,13 @@ type ServicesConfig map[string][]*Service
 
 // Bookmark represents a single bookmark entry within a group in bookmarks.yaml.
 type Bookmark struct {
-	Name        string `yaml:"name"`        // Optional: Display name of the bookmark
-	Abbr        string `yaml:"abbr"`        // Optional: Abbreviation, used if name is missing
-	Href        string `yaml:"href"`        // Required: URL for the bookmark
-	Description string `yaml:"description"` // Optional: Description shown on hover/tooltip (or below name)
-	Icon        string `yaml:"icon"`        // Optional: Icon for the bookmark
+	Name        string   `yaml:"name"`        // Optional: Display name of the bookmark
+	Abbr        string   `yaml:"abbr"`        // Optional: Abbreviation, used if name is missing
+	Href        string   `yaml:"href"`        // Required: URL for the bookmark
+	Description string   `yaml:"description"` // Optional: Description shown on hover/tooltip (or below name)
+	Icon        string   `yaml:"icon"`        // Optional: Icon for the bookmark
+	Weight      int      `yaml:"weight"`      // Optional: Sort weight within the group (lower comes first, alias: order)
+	Tags        []string `yaml:"tags"`        // Optional: Tags the dashboard can be filtered by
 }
 
 // BookmarkGroup represents a group of bookmarks in bookmarks.yaml.
@@ -109,7 +367,7 @@ type DockerConfig struct {
 	Socket               string   `yaml:"socket"`               // Unix socket path
 	Host                 string   `yaml:"host"`                 // Remote host
 	Port                 int      `yaml:"port"`                 // Remote port
-	Interval             int      `yaml:"interval"`             // Check interval in seconds
+	Interval             Seconds  `yaml:"interval"`             // Check interval in seconds
 	Includes             []string `yaml:"includes"`             // Container name patterns to include
 	Excludes             []string `yaml:"excludes"`             // Container name patterns to exclude
 	DisableAutodiscovery bool     `yaml:"disableAutodiscovery"` // Disable auto-discovery based on labels
@@ -0,0 +1,280 @@
+package homepage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownKey is a key of a configuration file that termhome doesn't know, most
+// often a misspelled one such as siteMonitorIntervall that is otherwise
+// silently ignored
+type UnknownKey struct {
+	File       string // Path of the configuration file
+	Line       int
+	Column     int
+	Key        string
+	Context    string // Where the key is, e.g. service "Plex" or status
+	Suggestion string // Known key closest to Key, if any
+}
+
+func (k UnknownKey) String() string {
+	message := fmt.Sprintf("%s:%d:%d: unknown key %q", k.File, k.Line, k.Column, k.Key)
+	if k.Context != "" {
+		message += " in " + k.Context
+	}
+	if k.Suggestion != "" {
+		message += fmt.Sprintf(", did you mean %q?", k.Suggestion)
+	}
+	return message
+}
+
+var (
+	settingsType = reflect.TypeOf(Settings{})
+	serviceType  = reflect.TypeOf(Service{})
+	bookmarkType = reflect.TypeOf(Bookmark{})
+	dockerType   = reflect.TypeOf(map[string]*DockerConfig{})
+)
+
+// CheckConfigKeys returns the keys of a configuration file that are not
+// known, with the file picked by name: settings.yaml, services.yaml,
+// bookmarks.yaml or docker.yaml. Missing files, other files and invalid YAML,
+// which is reported when the file is loaded, have no unknown keys.
+func CheckConfigKeys(filePath string) []UnknownKey {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	root, origins, err := parseYAML(filePath, data)
+	if err != nil {
+		return nil
+	}
+
+	checker := &keyChecker{file: filePath, origins: origins, merged: make(map[*yaml.Node]bool)}
+	node := resolveNode(root)
+	switch filepath.Base(filePath) {
+	case "settings.yaml":
+		checker.check(node, settingsType, "")
+	case "docker.yaml":
+		checker.check(node, dockerType, "")
+	case "services.yaml":
+		for _, group := range sequenceItems(node) {
+			for _, entry := range mappingPairs(group) {
+				if entry[0].Value == serviceDefaultsKey {
+					checker.checkStruct(entry[1], serviceType, serviceDefaultsKey, "order", "hidden")
+				}
+			}
+		}
+		checker.checkEntries(node, func(group, name string, props *yaml.Node) {
+			context := fmt.Sprintf("service %q", name)
+			if name == serviceDefaultsKey {
+				context = fmt.Sprintf("defaults of group %q", group)
+			}
+			checker.checkStruct(props, serviceType, context, "order", "hidden")
+		})
+	case "bookmarks.yaml":
+		checker.checkEntries(node, func(group, name string, props *yaml.Node) {
+			// Bookmarks are either a map or a list holding a map
+			if props.Kind == yaml.SequenceNode && len(props.Content) > 0 {
+				props = resolveNode(props.Content[0])
+			}
+			checker.checkStruct(props, bookmarkType, fmt.Sprintf("bookmark %q", name), "order")
+		})
+	}
+	return checker.unknown
+}
+
+// keyChecker collects the unknown keys of a file by walking its YAML nodes
+// along the type they are decoded into
+type keyChecker struct {
+	file    string
+	origins map[*yaml.Node]string // Files of the nodes of included files
+	unknown []UnknownKey
+	merged  map[*yaml.Node]bool // Mappings merged with << already checked
+}
+
+// checkEntries walks the groups of services.yaml and bookmarks.yaml, lists of
+// single key maps from group name to a list of single key maps from entry
+// name to its properties
+func (c *keyChecker) checkEntries(node *yaml.Node, checkEntry func(group, name string, props *yaml.Node)) {
+	for _, group := range sequenceItems(node) {
+		for _, groupEntry := range mappingPairs(group) {
+			for _, item := range sequenceItems(groupEntry[1]) {
+				for _, entry := range mappingPairs(item) {
+					checkEntry(groupEntry[0].Value, entry[0].Value, entry[1])
+				}
+			}
+		}
+	}
+}
+
+// check walks a node decoded into a value of type t
+func (c *keyChecker) check(node *yaml.Node, t reflect.Type, context string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		c.checkStruct(node, t, context)
+	case reflect.Slice:
+		for _, item := range sequenceItems(node) {
+			c.check(item, t.Elem(), context)
+		}
+	case reflect.Map:
+		for _, pair := range mappingPairs(node) {
+			c.check(pair[1], t.Elem(), joinContext(context, pair[0].Value))
+		}
+	}
+}
+
+// checkStruct checks the keys of a mapping node decoded into a struct of type
+// t, accepting its yaml tags and aliases handled by the parser
+func (c *keyChecker) checkStruct(node *yaml.Node, t reflect.Type, context string, aliases ...string) {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if options == "inline" {
+			// Extra keys are collected, such as the options of widgets
+			return
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	for _, alias := range aliases {
+		fields[alias] = nil
+	}
+
+	for _, pair := range mappingPairs(node) {
+		key := pair[0]
+		if key.Value == "<<" {
+			// Merged mappings are checked once, where they are defined
+			merged := sequenceItems(pair[1])
+			if merged == nil {
+				merged = []*yaml.Node{pair[1]}
+			}
+			for _, node := range merged {
+				if !c.merged[node] {
+					c.merged[node] = true
+					c.checkStruct(node, t, context, aliases...)
+				}
+			}
+			continue
+		}
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			file, ok := c.origins[key]
+			if !ok {
+				file = c.file
+			}
+			c.unknown = append(c.unknown, UnknownKey{
+				File:       file,
+				Line:       key.Line,
+				Column:     key.Column,
+				Key:        key.Value,
+				Context:    context,
+				Suggestion: closestKey(key.Value, fields),
+			})
+			continue
+		}
+		if fieldType != nil {
+			c.check(pair[1], fieldType, joinContext(context, key.Value))
+		}
+	}
+}
+
+// joinContext appends a key to the context of a nested value
+func joinContext(context, key string) string {
+	if context == "" {
+		return key
+	}
+	return context + "." + key
+}
+
+// resolveNode returns the node a document or alias node stands for
+func resolveNode(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			if len(node.Content) == 0 {
+				return nil
+			}
+			node = node.Content[0]
+		case yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+	return nil
+}
+
+// sequenceItems returns the items of a sequence node, or nil for other nodes
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	node = resolveNode(node)
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	items := make([]*yaml.Node, len(node.Content))
+	for i, item := range node.Content {
+		items[i] = resolveNode(item)
+	}
+	return items
+}
+
+// mappingPairs returns the key and value nodes of a mapping node, or nil for
+// other nodes
+func mappingPairs(node *yaml.Node) [][2]*yaml.Node {
+	node = resolveNode(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], resolveNode(node.Content[i+1])})
+	}
+	return pairs
+}
+
+// closestKey returns the known key most similar to key, or "" if none is
+// close enough to be a likely misspelling
+func closestKey(key string, known map[string]reflect.Type) string {
+	best, bestDistance := "", max(2, len(key)/5)+1
+	for name := range known {
+		distance := editDistance(strings.ToLower(key), strings.ToLower(name))
+		if distance < bestDistance || (distance == bestDistance && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance of two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
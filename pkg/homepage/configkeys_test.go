@@ -0,0 +1,153 @@
+package homepage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConfigKeysServices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	writeTestFile(t, path, []byte(`- Media:
+    - Plex:
+        href: http://plex.lan
+        siteMonitor: http://plex.lan
+        siteMonitorIntervall: 30
+        order: 1
+        statusStyle:
+          ok:
+            colour: green
+        widget:
+          type: opnsense
+          anyOption: true
+- Network:
+    - Router:
+        Ping: 192.168.1.1
+        pingCount: 3
+        frobnicate: true
+`))
+
+	unknown := CheckConfigKeys(path)
+	require.Len(t, unknown, 4)
+	assert.Equal(t, UnknownKey{
+		File:       path,
+		Line:       5,
+		Column:     9,
+		Key:        "siteMonitorIntervall",
+		Context:    `service "Plex"`,
+		Suggestion: "siteMonitorInterval",
+	}, unknown[0])
+	assert.Equal(t, `service "Plex".statusStyle.ok`, unknown[1].Context)
+	assert.Equal(t, "color", unknown[1].Suggestion)
+	assert.Equal(t, "ping", unknown[2].Suggestion)
+	assert.Equal(t, path+`:15:9: unknown key "Ping" in service "Router", did you mean "ping"?`, unknown[2].String())
+	assert.Equal(t, "frobnicate", unknown[3].Key)
+	assert.Empty(t, unknown[3].Suggestion)
+}
+
+func TestCheckConfigKeysSettings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.yaml")
+	writeTestFile(t, path, []byte(`title: Home
+defaults: &defaults
+  checkInterval: 30
+status:
+  <<: *defaults
+  retryIntervall: 10
+remotes:
+  - name: site-b
+    url: http://site-b:8080
+    tokn: secret
+quicklaunch:
+  searchDescriptions: true
+`))
+
+	var keys []string
+	for _, key := range CheckConfigKeys(path) {
+		keys = append(keys, key.Context+"/"+key.Key+"/"+key.Suggestion)
+	}
+	assert.Equal(t, []string{"/defaults/", "status/retryIntervall/retryInterval", "remotes/tokn/token", "/quicklaunch/"}, keys)
+
+	// Missing files and invalid YAML are reported when loading
+	assert.Empty(t, CheckConfigKeys(filepath.Join(dir, "missing", "settings.yaml")))
+	writeTestFile(t, path, []byte("title: [unclosed"))
+	assert.Empty(t, CheckConfigKeys(path))
+}
+
+func TestCheckConfigKeysBookmarksAndDocker(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.yaml")
+	writeTestFile(t, path, []byte(`- Developer:
+    - Github:
+        - abbr: GH
+          href: https://github.com/
+          order: 2
+    - Docs:
+        href: https://go.dev/doc
+        descripton: Go documentation
+`))
+	unknown := CheckConfigKeys(path)
+	require.Len(t, unknown, 1)
+	assert.Equal(t, "descripton", unknown[0].Key)
+	assert.Equal(t, `bookmark "Docs"`, unknown[0].Context)
+	assert.Equal(t, "description", unknown[0].Suggestion)
+
+	path = filepath.Join(dir, "docker.yaml")
+	writeTestFile(t, path, []byte(`my-docker:
+  socket: /var/run/docker.sock
+  intervall: 30
+`))
+	unknown = CheckConfigKeys(path)
+	require.Len(t, unknown, 1)
+	assert.Equal(t, "my-docker", unknown[0].Context)
+	assert.Equal(t, "interval", unknown[0].Suggestion)
+}
+
+func TestCheckConfigKeysMergeKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	writeTestFile(t, path, []byte(`- x-monitor: &monitor
+    siteMonitorTimout: 5
+- x-internal: &internal
+    siteMonitorSkipVerify: true
+- Media:
+    - Plex:
+        <<: *monitor
+        siteMonitor: https://plex.lan
+    - Jellyfin:
+        <<: [*monitor, *internal]
+        siteMonitor: https://jellyfin.lan
+`))
+
+	// Keys of merged mappings are reported once, where they are defined
+	unknown := CheckConfigKeys(path)
+	require.Len(t, unknown, 1)
+	assert.Equal(t, 2, unknown[0].Line)
+	assert.Equal(t, "siteMonitorTimeout", unknown[0].Suggestion)
+}
+
+func TestCheckConfigKeysDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	writeTestFile(t, path, []byte(`- defaults:
+    siteMonitorTimout: 5
+- Media:
+    - defaults:
+        siteMonitorIntervall: 30
+    - Plex:
+        siteMonitor: https://plex.lan
+`))
+
+	var contexts []string
+	for _, key := range CheckConfigKeys(path) {
+		contexts = append(contexts, key.Context)
+	}
+	assert.Equal(t, []string{"defaults", `defaults of group "Media"`}, contexts)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("ping", "ping"))
+	assert.Equal(t, 1, editDistance("siteMonitorIntervall", "siteMonitorInterval"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 4, editDistance("", "ping"))
+}
@@ -0,0 +1,395 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http/httptrace"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// defaultDNSTTL is how long addresses are cached when their TTL is unknown,
+	// for names of the hosts file or resolved by the system resolver
+	defaultDNSTTL = time.Minute
+
+	// maxDNSTTL caps the TTL of cached addresses, so changes are picked up
+	// within the hour even for records with days of TTL
+	maxDNSTTL = time.Hour
+
+	// negativeDNSTTL is how long a failed lookup is cached
+	negativeDNSTTL = 10 * time.Second
+
+	// dnsLookupTimeout bounds a lookup, shared by the checks waiting for it
+	dnsLookupTimeout = 10 * time.Second
+
+	// dnsQueryTimeout bounds a query to one nameserver
+	dnsQueryTimeout = 2 * time.Second
+
+	hostsPath      = "/etc/hosts"
+	resolvConfPath = "/etc/resolv.conf"
+)
+
+// checkDNSCache is shared by all checks, so checks of the same host look it
+// up once per TTL instead of every run
+var checkDNSCache = newDNSCache()
+
+// dnsLookup is the result of resolving a host name
+type dnsLookup struct {
+	ips    []net.IP
+	ttl    time.Duration // Time left until the addresses expire
+	cached bool          // Resolved by an earlier or concurrent lookup
+}
+
+// dnsCache caches resolved host names for the TTL of their records. Checks
+// starting together wait for a single lookup of their host.
+type dnsCache struct {
+	mutex   sync.Mutex
+	entries map[string]*dnsCacheEntry
+	lookup  func(ctx context.Context, host string) ([]net.IP, time.Duration, error)
+	now     func() time.Time
+}
+
+// dnsCacheEntry is a lookup of a host name, complete once ready is closed
+type dnsCacheEntry struct {
+	ready   chan struct{}
+	ips     []net.IP
+	err     error
+	expires time.Time
+}
+
+func newDNSCache() *dnsCache {
+	return &dnsCache{
+		entries: make(map[string]*dnsCacheEntry),
+		lookup:  lookupWithTTL,
+		now:     time.Now,
+	}
+}
+
+// resolve returns the addresses of a host, from the cache while their TTL has
+// not expired. IP addresses are returned as is.
+func (c *dnsCache) resolve(ctx context.Context, host string) (*dnsLookup, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return &dnsLookup{ips: []net.IP{ip}}, nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	c.mutex.Lock()
+	entry, cached := c.entries[host]
+	if cached {
+		select {
+		case <-entry.ready:
+			cached = c.now().Before(entry.expires)
+		default:
+			// Being looked up
+		}
+	}
+	if !cached {
+		entry = &dnsCacheEntry{ready: make(chan struct{})}
+		c.entries[host] = entry
+	}
+	c.mutex.Unlock()
+
+	if !cached {
+		// Not bound to the context of the check, as other checks may wait for it
+		lookupCtx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+		ips, ttl, err := c.lookup(lookupCtx, host)
+		cancel()
+		if err != nil {
+			ttl = negativeDNSTTL
+		}
+		entry.ips, entry.err, entry.expires = ips, err, c.now().Add(min(ttl, maxDNSTTL))
+		close(entry.ready)
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return &dnsLookup{ips: entry.ips, ttl: max(0, entry.expires.Sub(c.now())), cached: cached}, nil
+}
+
+// cached returns the cached addresses of a host, or nil if they expired or
+// the host was not looked up
+func (c *dnsCache) cached(host string) *dnsLookup {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	c.mutex.Lock()
+	entry, ok := c.entries[host]
+	c.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+	select {
+	case <-entry.ready:
+	default:
+		return nil
+	}
+	now := c.now()
+	if entry.err != nil || !now.Before(entry.expires) {
+		return nil
+	}
+	return &dnsLookup{ips: entry.ips, ttl: entry.expires.Sub(now), cached: true}
+}
+
+// details describes the addresses of a lookup for the details view
+func (l *dnsLookup) details() []StatusDetail {
+	ips := make([]string, len(l.ips))
+	for i, ip := range l.ips {
+		ips[i] = ip.String()
+	}
+	return []StatusDetail{
+		{Label: "Resolved IPs", Value: strings.Join(ips, ", ")},
+		{Label: "DNS TTL", Value: shortDuration(l.ttl.Round(time.Second)) + " left"},
+	}
+}
+
+// dnsLookupDetail describes how long resolving a host took, or that its
+// addresses were cached
+func dnsLookupDetail(cached bool, elapsed time.Duration) StatusDetail {
+	if cached {
+		return StatusDetail{Label: "DNS lookup", Value: "cached"}
+	}
+	return StatusDetail{Label: "DNS lookup", Value: elapsed.Round(time.Microsecond).String()}
+}
+
+// dialCached connects to an address, resolving its host through the DNS cache
+// and trying its addresses in turn. The lookup is reported to the client trace
+// of the context, as the resolver of a dialer would.
+func dialCached(ctx context.Context, network, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	lookup, err := checkDNSCache.resolve(ctx, host)
+	if trace != nil && trace.DNSDone != nil {
+		info := httptrace.DNSDoneInfo{Err: err}
+		if lookup != nil {
+			info.Coalesced = lookup.cached
+			for _, ip := range lookup.ips {
+				info.Addrs = append(info.Addrs, net.IPAddr{IP: ip})
+			}
+		}
+		trace.DNSDone(info)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	for _, ip := range lookup.ips {
+		if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("no addresses for %s", host)
+	}
+	return nil, err
+}
+
+// lookupWithTTL resolves a host name with the TTL of its records. On Linux,
+// names not in the hosts file are queried from the nameservers of
+// resolv.conf, which return the TTL. Elsewhere, and for names the nameservers
+// do not resolve, such as names completed with search domains, the system
+// resolver is used and the addresses cached for defaultDNSTTL.
+func lookupWithTTL(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	if runtime.GOOS == "linux" && strings.Contains(host, ".") {
+		hosts, _ := os.ReadFile(hostsPath)
+		if ips := hostsFileAddrs(string(hosts), host); len(ips) > 0 {
+			return ips, defaultDNSTTL, nil
+		}
+		resolvConf, _ := os.ReadFile(resolvConfPath)
+		if servers := resolvConfServers(string(resolvConf)); len(servers) > 0 {
+			ips, ttl, err := queryDNS(ctx, servers, host)
+			if err == nil {
+				return ips, ttl, nil
+			}
+			logging.Debug("DNS query for %s failed, using the system resolver: %v", host, err)
+		}
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, 0, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	return ips, defaultDNSTTL, nil
+}
+
+// hostsFileAddrs returns the addresses of a host name in a hosts file
+func hostsFileAddrs(hosts, host string) []net.IP {
+	var ips []net.IP
+	for _, line := range strings.Split(hosts, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+		for _, name := range fields[1:] {
+			if strings.EqualFold(strings.TrimSuffix(name, "."), host) {
+				ips = append(ips, ip)
+				break
+			}
+		}
+	}
+	return ips
+}
+
+// resolvConfServers returns the addresses of the nameservers of a resolv.conf
+func resolvConfServers(resolvConf string) []string {
+	var servers []string
+	for _, line := range strings.Split(resolvConf, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" && net.ParseIP(strings.Split(fields[1], "%")[0]) != nil {
+			servers = append(servers, net.JoinHostPort(fields[1], "53"))
+		}
+	}
+	return servers
+}
+
+// queryDNS queries the A and AAAA records of a host from the first nameserver
+// answering, returning IPv4 addresses first and the lowest TTL of the answers
+func queryDNS(ctx context.Context, servers []string, host string) ([]net.IP, time.Duration, error) {
+	var lastErr error
+	for _, server := range servers {
+		ips, ttl4, err := exchangeDNS(ctx, server, host, dnsmessage.TypeA)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ips6, ttl6, err := exchangeDNS(ctx, server, host, dnsmessage.TypeAAAA)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ips = append(ips, ips6...)
+		if len(ips) == 0 {
+			return nil, 0, fmt.Errorf("no addresses for %s", host)
+		}
+		switch {
+		case ttl4 < 0:
+			return ips, ttl6, nil
+		case ttl6 < 0:
+			return ips, ttl4, nil
+		}
+		return ips, min(ttl4, ttl6), nil
+	}
+	return nil, 0, lastErr
+}
+
+// exchangeDNS sends a query to a nameserver over UDP and returns the addresses
+// answered, with the lowest TTL of the answers, or -1 if there are none
+func exchangeDNS(ctx context.Context, server, host string, qtype dnsmessage.Type) ([]net.IP, time.Duration, error) {
+	name, err := dnsmessage.NewName(host + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+	id := uint16(rand.Uint32())
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(dnsQueryTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	if _, err := conn.Write(packed); err != nil {
+		return nil, 0, err
+	}
+
+	buf := make([]byte, 1232)
+	var parser dnsmessage.Parser
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, 0, err
+		}
+		header, err := parser.Start(buf[:n])
+		if err == nil && header.ID == id && header.Response {
+			if header.Truncated {
+				return nil, 0, errors.New("truncated DNS response")
+			}
+			if header.RCode != dnsmessage.RCodeSuccess {
+				return nil, 0, fmt.Errorf("DNS query for %s: %s", host, header.RCode)
+			}
+			break
+		}
+		// Not the response to the query, keep waiting
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return nil, 0, err
+	}
+
+	var ips []net.IP
+	ttl := time.Duration(-1)
+	for {
+		answer, err := parser.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		// The lowest TTL of the answers, including CNAME records
+		if recordTTL := time.Duration(answer.TTL) * time.Second; ttl < 0 || recordTTL < ttl {
+			ttl = recordTTL
+		}
+		switch answer.Type {
+		case dnsmessage.TypeA:
+			record, err := parser.AResource()
+			if err != nil {
+				return nil, 0, err
+			}
+			ips = append(ips, net.IP(record.A[:]))
+		case dnsmessage.TypeAAAA:
+			record, err := parser.AAAAResource()
+			if err != nil {
+				return nil, 0, err
+			}
+			ips = append(ips, net.IP(record.AAAA[:]))
+		default:
+			if err := parser.SkipAnswer(); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return ips, ttl, nil
+}
@@ -0,0 +1,219 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDNSCacheTTL(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var lookups atomic.Int32
+	cache := newDNSCache()
+	cache.now = func() time.Time { return now }
+	cache.lookup = func(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+		lookups.Add(1)
+		return []net.IP{net.ParseIP("192.0.2.1")}, 5 * time.Minute, nil
+	}
+
+	lookup, err := cache.resolve(context.Background(), "Example.com.")
+	require.NoError(t, err)
+	assert.False(t, lookup.cached)
+	assert.Equal(t, "192.0.2.1", lookup.ips[0].String())
+	assert.Equal(t, 5*time.Minute, lookup.ttl)
+
+	// Cached until the TTL expires
+	now = now.Add(4 * time.Minute)
+	lookup, err = cache.resolve(context.Background(), "example.com")
+	require.NoError(t, err)
+	assert.True(t, lookup.cached)
+	assert.Equal(t, time.Minute, lookup.ttl)
+	assert.Equal(t, int32(1), lookups.Load())
+	require.NotNil(t, cache.cached("example.com"))
+
+	now = now.Add(time.Minute)
+	assert.Nil(t, cache.cached("example.com"))
+	lookup, err = cache.resolve(context.Background(), "example.com")
+	require.NoError(t, err)
+	assert.False(t, lookup.cached)
+	assert.Equal(t, int32(2), lookups.Load())
+
+	// IP addresses are not looked up
+	lookup, err = cache.resolve(context.Background(), "2001:db8::1")
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::1", lookup.ips[0].String())
+	assert.Equal(t, int32(2), lookups.Load())
+}
+
+func TestDNSCacheErrors(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var lookups atomic.Int32
+	cache := newDNSCache()
+	cache.now = func() time.Time { return now }
+	cache.lookup = func(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+		lookups.Add(1)
+		return nil, 0, errors.New("no such host")
+	}
+
+	_, err := cache.resolve(context.Background(), "missing.example")
+	assert.EqualError(t, err, "no such host")
+	_, err = cache.resolve(context.Background(), "missing.example")
+	assert.EqualError(t, err, "no such host")
+	assert.Equal(t, int32(1), lookups.Load())
+	assert.Nil(t, cache.cached("missing.example"))
+
+	now = now.Add(negativeDNSTTL)
+	_, err = cache.resolve(context.Background(), "missing.example")
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), lookups.Load())
+}
+
+func TestDNSCacheConcurrentLookups(t *testing.T) {
+	var lookups atomic.Int32
+	release := make(chan struct{})
+	cache := newDNSCache()
+	cache.lookup = func(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+		lookups.Add(1)
+		<-release
+		return []net.IP{net.ParseIP("192.0.2.1")}, time.Minute, nil
+	}
+
+	var wg sync.WaitGroup
+	var cached atomic.Int32
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lookup, err := cache.resolve(context.Background(), "example.com")
+			if assert.NoError(t, err) && lookup.cached {
+				cached.Add(1)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), lookups.Load())
+	assert.Equal(t, int32(9), cached.Load())
+}
+
+func TestHostsFileAddrs(t *testing.T) {
+	hosts := `# Static table lookup for hostnames
+127.0.0.1	localhost
+192.168.1.10	nas.home.arpa nas # the NAS
+::1		localhost ip6-localhost
+`
+	assert.Equal(t, []net.IP{net.ParseIP("192.168.1.10")}, hostsFileAddrs(hosts, "NAS.home.arpa"))
+	assert.Len(t, hostsFileAddrs(hosts, "localhost"), 2)
+	assert.Empty(t, hostsFileAddrs(hosts, "home.arpa"))
+}
+
+func TestResolvConfServers(t *testing.T) {
+	resolvConf := `# Generated by NetworkManager
+search home.arpa
+nameserver 192.168.1.1
+nameserver fe80::1%eth0
+nameserver invalid
+options edns0
+`
+	assert.Equal(t, []string{"192.168.1.1:53", "[fe80::1%eth0]:53"}, resolvConfServers(resolvConf))
+}
+
+// serveDNS answers the queries received on a UDP socket with a CNAME to
+// target.example and the addresses of its type, returning the server address
+func serveDNS(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if query.Unpack(buf[:n]) != nil || len(query.Questions) != 1 {
+				continue
+			}
+			question := query.Questions[0]
+			if question.Name.String() == "missing.example." {
+				response, _ := (&dnsmessage.Message{
+					Header:    dnsmessage.Header{ID: query.ID, Response: true, RCode: dnsmessage.RCodeNameError},
+					Questions: query.Questions,
+				}).Pack()
+				conn.WriteTo(response, addr)
+				continue
+			}
+
+			target := dnsmessage.MustNewName("target.example.")
+			builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true})
+			builder.StartQuestions()
+			builder.Question(question)
+			builder.StartAnswers()
+			builder.CNAMEResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 3600}, dnsmessage.CNAMEResource{CNAME: target})
+			switch question.Type {
+			case dnsmessage.TypeA:
+				builder.AResource(dnsmessage.ResourceHeader{Name: target, Class: dnsmessage.ClassINET, TTL: 300}, dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+			case dnsmessage.TypeAAAA:
+				builder.AAAAResource(dnsmessage.ResourceHeader{Name: target, Class: dnsmessage.ClassINET, TTL: 120}, dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}})
+			}
+			response, _ := builder.Finish()
+			conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestQueryDNS(t *testing.T) {
+	server := serveDNS(t)
+
+	ips, ttl, err := queryDNS(context.Background(), []string{server}, "www.example")
+	require.NoError(t, err)
+	require.Len(t, ips, 2)
+	assert.Equal(t, "192.0.2.1", ips[0].String())
+	assert.Equal(t, "2001:db8::1", ips[1].String())
+	assert.Equal(t, 2*time.Minute, ttl)
+
+	_, _, err = queryDNS(context.Background(), []string{server}, "missing.example")
+	assert.ErrorContains(t, err, "RCodeNameError")
+}
+
+func TestDialCached(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	saved := checkDNSCache
+	defer func() { checkDNSCache = saved }()
+	checkDNSCache = newDNSCache()
+	checkDNSCache.lookup = func(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+		// The first address refuses connections
+		return []net.IP{net.ParseIP("127.0.0.2"), net.ParseIP("127.0.0.1")}, time.Minute, nil
+	}
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	conn, err := dialCached(context.Background(), "tcp", net.JoinHostPort("service.example", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, listener.Addr().String(), conn.RemoteAddr().String())
+	assert.NotNil(t, checkDNSCache.cached("service.example"))
+}
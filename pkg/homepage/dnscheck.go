@@ -0,0 +1,177 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// dnsCheck resolves a host name, bypassing the DNS cache of the other checks,
+// optionally against a given nameserver, and compares the answer to the
+// expected values, so a broken or hijacked resolver gets noticed
+type dnsCheck struct {
+	host       string
+	server     string // host:port of the nameserver, or empty for the system resolver
+	recordType string // A, AAAA, CNAME, MX, NS or TXT, or empty for the addresses
+	expected   []string
+	timeout    time.Duration
+}
+
+// dnsCheckTypes lists the supported record types
+var dnsCheckTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT"}
+
+// newDNSCheck creates a DNS check for a service
+func newDNSCheck(service *Service) *dnsCheck {
+	timeout := service.DNSCheckTimeout
+	if timeout <= 0 {
+		timeout = 5
+	}
+	server := service.DNSCheckServer
+	if _, _, err := net.SplitHostPort(server); server != "" && err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return &dnsCheck{
+		host:       service.DNSCheck,
+		server:     server,
+		recordType: strings.ToUpper(service.DNSCheckType),
+		expected:   service.DNSCheckExpected,
+		timeout:    time.Duration(timeout) * time.Second,
+	}
+}
+
+// run resolves the host name and compares the answer
+func (c *dnsCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	server := c.server
+	if server == "" {
+		server = "system resolver"
+	}
+	recordType := c.recordType
+	if recordType == "" {
+		recordType = "A/AAAA"
+	}
+	details := []StatusDetail{{Label: "Nameserver", Value: server}, {Label: "Type", Value: recordType}}
+	if c.recordType != "" && !slices.Contains(dnsCheckTypes, c.recordType) {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Unsupported record type %s, use %s", c.recordType, strings.Join(dnsCheckTypes, ", ")), Details: details}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	answers, err := c.lookup(ctx)
+	elapsed := time.Since(start)
+	details = append(details, StatusDetail{Label: "Total", Value: elapsed.Round(time.Microsecond).String()})
+	if err != nil {
+		logging.Debug("DNS check for %s: Resolving %s failed: %v", serviceName, c.host, err)
+	}
+
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound, err == nil && len(answers) == 0:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("No %s record for %s", recordType, c.host), ResponseTime: elapsed, Details: details}
+	case errors.As(err, &dnsErr) && dnsErr.IsTimeout:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("No answer from %s after %s", server, c.timeout), Details: details}
+	case err != nil:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Resolution failed: %v", err), ResponseTime: elapsed, Details: details}
+	}
+
+	for _, answer := range answers {
+		details = append(details, StatusDetail{Label: "Answer", Value: answer})
+	}
+	if unexpected := c.unexpected(answers); len(unexpected) > 0 {
+		return &StatusResult{
+			State:        StatusCritical,
+			Message:      fmt.Sprintf("Unexpected answer %s, expected %s", strings.Join(unexpected, ", "), strings.Join(c.expected, ", ")),
+			ResponseTime: elapsed,
+			Details:      details,
+		}
+	}
+
+	shown := answers
+	if len(shown) > 3 {
+		shown = append(slices.Clone(shown[:3]), fmt.Sprintf("%d more", len(answers)-3))
+	}
+	return &StatusResult{State: StatusOK, Message: fmt.Sprintf("%s (%s)", strings.Join(shown, ", "), elapsed.Round(time.Millisecond)), ResponseTime: elapsed, Details: details}
+}
+
+// lookup queries the records of the host, returning them as strings
+func (c *dnsCheck) lookup(ctx context.Context) ([]string, error) {
+	resolver := net.DefaultResolver
+	if c.server != "" {
+		// The Go resolver sends its queries to the configured nameserver
+		// instead of those of the system
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, c.server)
+			},
+		}
+	}
+
+	var answers []string
+	switch c.recordType {
+	case "", "A", "AAAA":
+		network := map[string]string{"": "ip", "A": "ip4", "AAAA": "ip6"}[c.recordType]
+		ips, err := resolver.LookupIP(ctx, network, c.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, c.host)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, strings.TrimSuffix(cname, "."))
+	case "MX":
+		records, err := resolver.LookupMX(ctx, c.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			answers = append(answers, strings.TrimSuffix(record.Host, "."))
+		}
+	case "NS":
+		records, err := resolver.LookupNS(ctx, c.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			answers = append(answers, strings.TrimSuffix(record.Host, "."))
+		}
+	case "TXT":
+		return resolver.LookupTXT(ctx, c.host)
+	}
+	return answers, nil
+}
+
+// unexpected returns the answers that are not expected, if expected values
+// are configured. Addresses are compared parsed and names case-insensitively.
+func (c *dnsCheck) unexpected(answers []string) []string {
+	if len(c.expected) == 0 {
+		return nil
+	}
+	var unexpected []string
+	for _, answer := range answers {
+		if !slices.ContainsFunc(c.expected, func(expected string) bool { return dnsAnswerEqual(answer, expected) }) {
+			unexpected = append(unexpected, answer)
+		}
+	}
+	return unexpected
+}
+
+// dnsAnswerEqual reports whether an answer matches an expected value
+func dnsAnswerEqual(answer, expected string) bool {
+	if ip := net.ParseIP(expected); ip != nil {
+		return ip.Equal(net.ParseIP(answer))
+	}
+	return strings.EqualFold(strings.TrimSuffix(answer, "."), strings.TrimSuffix(expected, "."))
+}
@@ -0,0 +1,56 @@
+package homepage
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSCheck(t *testing.T) {
+	server := serveDNS(t)
+
+	result := newDNSCheck(&Service{DNSCheck: "www.example", DNSCheckServer: server, DNSCheckType: "a", DNSCheckExpected: []string{"192.0.2.1"}}).run(context.Background(), "DNS")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "192.0.2.1 ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Nameserver", Value: server})
+
+	result = newDNSCheck(&Service{DNSCheck: "www.example", DNSCheckServer: server}).run(context.Background(), "DNS")
+	assert.Equal(t, StatusOK, result.State)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Answer", Value: "192.0.2.1"})
+	assert.Contains(t, result.Details, StatusDetail{Label: "Answer", Value: "2001:db8::1"})
+
+	result = newDNSCheck(&Service{DNSCheck: "www.example", DNSCheckServer: server, DNSCheckType: "CNAME", DNSCheckExpected: []string{"Target.Example."}}).run(context.Background(), "DNS")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "target.example ("), result.Message)
+
+	result = newDNSCheck(&Service{DNSCheck: "www.example", DNSCheckServer: server, DNSCheckType: "A", DNSCheckExpected: []string{"192.168.1.10"}}).run(context.Background(), "DNS")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Unexpected answer 192.0.2.1, expected 192.168.1.10", result.Message)
+
+	result = newDNSCheck(&Service{DNSCheck: "missing.example", DNSCheckServer: server, DNSCheckType: "A"}).run(context.Background(), "DNS")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "No A record for missing.example", result.Message)
+
+	result = newDNSCheck(&Service{DNSCheck: "www.example", DNSCheckType: "SOA"}).run(context.Background(), "DNS")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Unsupported record type SOA, use A, AAAA, CNAME, MX, NS, TXT", result.Message)
+
+	// A nameserver that never answers
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer silent.Close()
+	result = newDNSCheck(&Service{DNSCheck: "www.example", DNSCheckServer: silent.LocalAddr().String(), DNSCheckTimeout: 1}).run(context.Background(), "DNS")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "No answer from "+silent.LocalAddr().String()+" after 1s", result.Message)
+}
+
+func TestNewDNSCheckServer(t *testing.T) {
+	assert.Equal(t, "192.168.1.1:53", newDNSCheck(&Service{DNSCheckServer: "192.168.1.1"}).server)
+	assert.Equal(t, "[fd00::1]:53", newDNSCheck(&Service{DNSCheckServer: "fd00::1"}).server)
+	assert.Equal(t, "[fd00::1]:5353", newDNSCheck(&Service{DNSCheckServer: "[fd00::1]:5353"}).server)
+	assert.Equal(t, "ns1.home.arpa:53", newDNSCheck(&Service{DNSCheckServer: "ns1.home.arpa"}).server)
+}
@@ -0,0 +1,380 @@
+package homepage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+)
+
+// dockerAPI is the part of the Docker API used to monitor and discover
+// containers. It is implemented by the Docker SDK client and by a fake in
+// tests, so container matching and autodiscovery can be tested without a
+// Docker daemon.
+type dockerAPI interface {
+	// ListContainers lists all containers, running or not
+	ListContainers(ctx context.Context) ([]dockerContainer, error)
+	// Inspect returns the details of a container
+	Inspect(ctx context.Context, id string) (dockerContainerInfo, error)
+	// Events streams container events until ctx is done or an error is sent
+	Events(ctx context.Context) (<-chan dockerEvent, <-chan error)
+	// Stats returns the resource usage of a running container
+	Stats(ctx context.Context, id string) (dockerStats, error)
+	// Engine returns the container counts and the disk usage of the daemon
+	Engine(ctx context.Context) (dockerEngine, error)
+	// Volume returns a named volume, or errDockerNotFound if it doesn't exist
+	Volume(ctx context.Context, name string) (dockerVolume, error)
+	// Network returns a network, or errDockerNotFound if it doesn't exist
+	Network(ctx context.Context, name string) (dockerNetwork, error)
+	// Close releases the connection to the Docker daemon
+	Close() error
+}
+
+// Basic container information for status display
+type dockerContainer struct {
+	ID     string
+	Name   string
+	Image  string
+	Status string
+	Health string
+}
+
+// dockerContainerInfo holds the details of an inspected container
+type dockerContainerInfo struct {
+	Labels map[string]string
+}
+
+// dockerEvent is a change of a container, such as "start", "die" or
+// "health_status: healthy"
+type dockerEvent struct {
+	ID     string
+	Name   string
+	Action string
+	Time   time.Time
+}
+
+// dockerStats is the resource usage of a container
+type dockerStats struct {
+	CPUPercent  float64 // Percent of one CPU, up to 100 per CPU
+	MemoryUsage uint64  // Bytes used, without the page cache
+	MemoryLimit uint64  // Bytes the container may use
+}
+
+// dockerEngine is the state of a Docker daemon, as shown by docker info and
+// docker system df
+type dockerEngine struct {
+	Version        string
+	Running        int
+	Paused         int
+	Stopped        int
+	Images         int
+	ImagesSize     int64 // Bytes used by image layers
+	Volumes        int
+	VolumesSize    int64 // Bytes used by volumes, as far as the daemon knows
+	BuildCacheSize int64 // Bytes used by the build cache
+}
+
+// dockerVolume is a named volume and the running containers mounting it
+type dockerVolume struct {
+	Name       string
+	Driver     string
+	Mountpoint string
+	UsedBy     []string // Names of the running containers mounting the volume
+}
+
+// dockerNetwork is a network and the containers attached to it
+type dockerNetwork struct {
+	Name       string
+	Driver     string
+	Containers []string // Names of the attached containers
+}
+
+// errDockerNotFound is returned when a volume or a network doesn't exist
+var errDockerNotFound = errors.New("not found")
+
+// newDockerAPI connects to the Docker daemon of the config. Tests replace it
+// to return a fake.
+var newDockerAPI = func(config *DockerConfig) (dockerAPI, error) {
+	cli, err := createDockerClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &sdkDockerClient{cli: cli}, nil
+}
+
+// createDockerClient creates a Docker client based on the config
+func createDockerClient(config *DockerConfig) (*client.Client, error) {
+	var cli *client.Client
+	var err error
+
+	// Create client based on configuration
+	if config.Socket != "" {
+		// Socket connection
+		logging.Debug("Using Docker socket: %s", config.Socket)
+		cli, err = client.NewClientWithOpts(
+			client.WithHost(fmt.Sprintf("unix://%s", config.Socket)),
+			client.WithAPIVersionNegotiation(),
+		)
+	} else if config.Host != "" {
+		// Remote host connection
+		hostArg := config.Host
+		if config.Port > 0 {
+			hostArg = fmt.Sprintf("%s:%d", config.Host, config.Port)
+		}
+		logging.Debug("Using Docker remote host: %s", hostArg)
+		cli, err = client.NewClientWithOpts(
+			client.WithHost(fmt.Sprintf("tcp://%s", hostArg)),
+			client.WithAPIVersionNegotiation(),
+		)
+	} else {
+		// Default connection
+		logging.Debug("Using default Docker connection")
+		cli, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+
+	return cli, nil
+}
+
+// sdkDockerClient implements dockerAPI with the Docker SDK
+type sdkDockerClient struct {
+	cli *client.Client
+}
+
+func (c *sdkDockerClient) ListContainers(ctx context.Context) ([]dockerContainer, error) {
+	containerList, err := c.cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to our internal container representation
+	var containers []dockerContainer
+	for _, c := range containerList {
+		// Container name comes with a leading slash we need to remove
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		containers = append(containers, dockerContainer{
+			ID:     c.ID,
+			Name:   name,
+			Image:  c.Image,
+			Status: c.Status,
+			Health: containerHealth(c.State, c.Status),
+		})
+	}
+
+	return containers, nil
+}
+
+func (c *sdkDockerClient) Inspect(ctx context.Context, id string) (dockerContainerInfo, error) {
+	info, err := c.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return dockerContainerInfo{}, err
+	}
+	var labels map[string]string
+	if info.Config != nil {
+		labels = info.Config.Labels
+	}
+	return dockerContainerInfo{Labels: labels}, nil
+}
+
+func (c *sdkDockerClient) Events(ctx context.Context) (<-chan dockerEvent, <-chan error) {
+	messages, errs := c.cli.Events(ctx, events.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("type", string(events.ContainerEventType))),
+	})
+
+	out := make(chan dockerEvent)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case message := <-messages:
+				event := dockerEvent{
+					ID:     message.Actor.ID,
+					Name:   message.Actor.Attributes["name"],
+					Action: string(message.Action),
+					Time:   time.Unix(0, message.TimeNano),
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, errs
+}
+
+func (c *sdkDockerClient) Stats(ctx context.Context, id string) (dockerStats, error) {
+	// Without streaming, the daemon samples twice so the CPU usage can be
+	// computed from the difference
+	reader, err := c.cli.ContainerStats(ctx, id, false)
+	if err != nil {
+		return dockerStats{}, err
+	}
+	defer reader.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(reader.Body).Decode(&stats); err != nil {
+		return dockerStats{}, fmt.Errorf("error decoding container stats: %w", err)
+	}
+	return statsFromResponse(stats), nil
+}
+
+func (c *sdkDockerClient) Engine(ctx context.Context) (dockerEngine, error) {
+	info, err := c.cli.Info(ctx)
+	if err != nil {
+		return dockerEngine{}, err
+	}
+	engine := dockerEngine{
+		Version: info.ServerVersion,
+		Running: info.ContainersRunning,
+		Paused:  info.ContainersPaused,
+		Stopped: info.ContainersStopped,
+		Images:  info.Images,
+	}
+
+	// Containers are counted by the info already and are the slowest part
+	// of the disk usage
+	usage, err := c.cli.DiskUsage(ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.ImageObject, types.VolumeObject, types.BuildCacheObject},
+	})
+	if err != nil {
+		return dockerEngine{}, fmt.Errorf("error getting disk usage: %w", err)
+	}
+	engine.ImagesSize = usage.LayersSize
+	engine.Volumes = len(usage.Volumes)
+	for _, volume := range usage.Volumes {
+		// The size is -1 when unknown, e.g. for volumes of other drivers
+		if volume.UsageData != nil && volume.UsageData.Size > 0 {
+			engine.VolumesSize += volume.UsageData.Size
+		}
+	}
+	for _, cache := range usage.BuildCache {
+		if !cache.Shared {
+			engine.BuildCacheSize += cache.Size
+		}
+	}
+	return engine, nil
+}
+
+func (c *sdkDockerClient) Volume(ctx context.Context, name string) (dockerVolume, error) {
+	info, err := c.cli.VolumeInspect(ctx, name)
+	if client.IsErrNotFound(err) {
+		return dockerVolume{}, errDockerNotFound
+	}
+	if err != nil {
+		return dockerVolume{}, err
+	}
+	volume := dockerVolume{Name: info.Name, Driver: info.Driver, Mountpoint: info.Mountpoint}
+
+	containers, err := c.cli.ContainerList(ctx, container.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("volume", name)),
+	})
+	if err != nil {
+		return dockerVolume{}, err
+	}
+	for _, c := range containers {
+		if len(c.Names) > 0 {
+			volume.UsedBy = append(volume.UsedBy, strings.TrimPrefix(c.Names[0], "/"))
+		}
+	}
+	return volume, nil
+}
+
+func (c *sdkDockerClient) Network(ctx context.Context, name string) (dockerNetwork, error) {
+	info, err := c.cli.NetworkInspect(ctx, name, network.InspectOptions{})
+	if client.IsErrNotFound(err) {
+		return dockerNetwork{}, errDockerNotFound
+	}
+	if err != nil {
+		return dockerNetwork{}, err
+	}
+	result := dockerNetwork{Name: info.Name, Driver: info.Driver}
+	for _, endpoint := range info.Containers {
+		result.Containers = append(result.Containers, endpoint.Name)
+	}
+	return result, nil
+}
+
+func (c *sdkDockerClient) Close() error {
+	return c.cli.Close()
+}
+
+// containerHealth returns the health of a running container from its status
+// text, e.g. "Up 2 hours (healthy)", or "" if it has no health check
+func containerHealth(state, status string) string {
+	if state != "running" {
+		return ""
+	}
+	switch {
+	case strings.Contains(status, "(healthy)"):
+		return "healthy"
+	case strings.Contains(status, "(unhealthy)"):
+		return "unhealthy"
+	case strings.Contains(status, "(health: starting)"):
+		return "starting"
+	}
+	return ""
+}
+
+// statsFromResponse computes the resource usage of a container the way
+// `docker stats` does
+func statsFromResponse(stats container.StatsResponse) dockerStats {
+	result := dockerStats{MemoryLimit: stats.MemoryStats.Limit}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		result.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// The page cache can be reclaimed, so it isn't counted as used, with
+	// the key of cgroup v2 first and that of cgroup v1 second
+	result.MemoryUsage = stats.MemoryStats.Usage
+	cache, ok := stats.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		cache = stats.MemoryStats.Stats["total_inactive_file"]
+	}
+	if cache < result.MemoryUsage {
+		result.MemoryUsage -= cache
+	}
+	return result
+}
+
+// CountDockerContainers connects to the Docker daemon of the config and
+// returns the number of its containers, to diagnose the connection
+func CountDockerContainers(ctx context.Context, config *DockerConfig) (int, error) {
+	api, err := newDockerAPI(config)
+	if err != nil {
+		return 0, err
+	}
+	defer api.Close()
+	containers, err := api.ListContainers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(containers), nil
+}
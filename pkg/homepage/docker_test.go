@@ -0,0 +1,287 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker is a dockerAPI serving fixed containers
+type fakeDocker struct {
+	containers []dockerContainer
+	labels     map[string]map[string]string // Labels by container ID
+	stats      map[string]dockerStats       // Stats by container ID
+	events     chan dockerEvent
+	engine     dockerEngine
+	volumes    map[string]dockerVolume  // Volumes by name
+	networks   map[string]dockerNetwork // Networks by name
+	err        error                    // Returned by ListContainers
+	closed     int
+}
+
+func (f *fakeDocker) ListContainers(ctx context.Context) ([]dockerContainer, error) {
+	return f.containers, f.err
+}
+
+func (f *fakeDocker) Inspect(ctx context.Context, id string) (dockerContainerInfo, error) {
+	for _, c := range f.containers {
+		if c.ID == id {
+			return dockerContainerInfo{Labels: f.labels[id]}, nil
+		}
+	}
+	return dockerContainerInfo{}, errors.New("no such container")
+}
+
+func (f *fakeDocker) Events(ctx context.Context) (<-chan dockerEvent, <-chan error) {
+	return f.events, make(chan error)
+}
+
+func (f *fakeDocker) Stats(ctx context.Context, id string) (dockerStats, error) {
+	stats, ok := f.stats[id]
+	if !ok {
+		return dockerStats{}, errors.New("no such container")
+	}
+	return stats, nil
+}
+
+func (f *fakeDocker) Engine(ctx context.Context) (dockerEngine, error) {
+	return f.engine, f.err
+}
+
+func (f *fakeDocker) Volume(ctx context.Context, name string) (dockerVolume, error) {
+	volume, ok := f.volumes[name]
+	if !ok {
+		return dockerVolume{}, errDockerNotFound
+	}
+	return volume, nil
+}
+
+func (f *fakeDocker) Network(ctx context.Context, name string) (dockerNetwork, error) {
+	network, ok := f.networks[name]
+	if !ok {
+		return dockerNetwork{}, errDockerNotFound
+	}
+	return network, nil
+}
+
+func (f *fakeDocker) Close() error {
+	f.closed++
+	return nil
+}
+
+// useFakeDocker makes the Docker code use fake until the test ends
+func useFakeDocker(t *testing.T, fake *fakeDocker) {
+	previous := newDockerAPI
+	newDockerAPI = func(*DockerConfig) (dockerAPI, error) { return fake, nil }
+	t.Cleanup(func() { newDockerAPI = previous })
+}
+
+// monitoredService returns a service of the monitor by name
+func monitoredService(sm *StatusMonitor, name string) (*Service, bool) {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	service, ok := sm.services[name]
+	return service, ok
+}
+
+func testContainers() []dockerContainer {
+	return []dockerContainer{
+		{ID: "1", Name: "plex", Image: "plexinc/pms-docker", Status: "Up 2 hours (healthy)", Health: "healthy"},
+		{ID: "2", Name: "grafana-server", Image: "grafana/grafana", Status: "Exited (1) 5 minutes ago"},
+		{ID: "3", Name: "whoami", Image: "traefik/whoami", Status: "Up 3 days"},
+		{ID: "4", Name: "postgres", Image: "postgres:16", Status: "Up 3 days"},
+	}
+}
+
+func TestCheckDockerContainers(t *testing.T) {
+	fake := &fakeDocker{
+		containers: testContainers(),
+		labels: map[string]map[string]string{
+			"3": {"homepage.name": "Who Am I", "homepage.group": "Tools", "homepage.weight": "5", "homepage.href": "http://whoami.lan"},
+		},
+	}
+	useFakeDocker(t, fake)
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	sm.AddService(&Service{Name: "Plex", Container: "plex"})
+	sm.AddService(&Service{Name: "Grafana", Container: "grafana"})
+	sm.AddService(&Service{Name: "Pi-hole", Container: "pihole"})
+
+	require.NoError(t, sm.RunInitialDockerDiscovery(&DockerConfig{}))
+	assert.Equal(t, 1, fake.closed)
+
+	// Exact matches
+	result := sm.GetStatus("Plex")
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Up (healthy)", result.Message)
+
+	// Substring matches
+	result = sm.GetStatus("Grafana")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Stopped (Exited (1) 5 minutes ago)", result.Message)
+
+	result = sm.GetStatus("Pi-hole")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Container not found", result.Message)
+
+	// Only containers with homepage labels are discovered
+	service, ok := monitoredService(sm, "Who Am I")
+	require.True(t, ok)
+	assert.Equal(t, "whoami", service.Container)
+	assert.Equal(t, "http://whoami.lan", service.Href)
+	assert.Equal(t, 5, service.Weight)
+	assert.Equal(t, StatusOK, sm.GetStatus("Who Am I").State)
+	_, ok = monitoredService(sm, "postgres")
+	assert.False(t, ok)
+}
+
+func TestDockerDiscoveryGroupsByWeight(t *testing.T) {
+	fake := &fakeDocker{
+		containers: testContainers(),
+		labels: map[string]map[string]string{
+			"1": {"homepage.name": "Plex", "homepage.group": "Media", "homepage.weight": "10"},
+			"3": {"homepage.name": "Who Am I", "homepage.group": "Media", "homepage.weight": "-5", "homepage.tags": "web, test"},
+			"4": {"homepage.name": "Postgres", "homepage.group": "Media"},
+		},
+	}
+	useFakeDocker(t, fake)
+	StoreCachedGroups([]*ServiceGroup{{Name: "Media", Services: []*Service{{Name: "Jellyfin", Weight: 1}}}})
+	defer StoreCachedGroups(nil)
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	require.NoError(t, sm.RunInitialDockerDiscovery(&DockerConfig{}))
+
+	groups := GetCachedGroups()
+	require.Len(t, groups, 1)
+	var names []string
+	for _, service := range groups[0].Services {
+		names = append(names, service.Name)
+	}
+	assert.Equal(t, []string{"Who Am I", "Postgres", "Jellyfin", "Plex"}, names)
+	assert.Equal(t, []string{"web", "test"}, groups[0].Services[0].Tags)
+}
+
+func TestCheckDockerContainersRetiresRemoved(t *testing.T) {
+	fake := &fakeDocker{
+		containers: testContainers(),
+		labels:     map[string]map[string]string{"3": {"homepage.name": "Who Am I", "homepage.group": "Tools"}},
+	}
+	useFakeDocker(t, fake)
+	StoreCachedGroups(nil)
+	defer StoreCachedGroups(nil)
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	sm.AddService(&Service{Name: "Plex", Container: "plex"})
+	require.NoError(t, sm.RunInitialDockerDiscovery(&DockerConfig{}))
+	require.True(t, sm.IsMonitored("Who Am I"))
+	require.Len(t, GetCachedGroups(), 1)
+
+	// Discovered services go away with their container, configured ones stay
+	fake.containers = nil
+	require.NoError(t, sm.checkDockerContainers(&DockerConfig{}))
+	assert.False(t, sm.IsMonitored("Who Am I"))
+	assert.Empty(t, GetCachedGroups())
+	assert.Equal(t, "Container not found", sm.GetStatus("Plex").Message)
+}
+
+func TestCheckDockerContainersDisabledAutodiscovery(t *testing.T) {
+	fake := &fakeDocker{
+		containers: testContainers(),
+		labels:     map[string]map[string]string{"3": {"homepage.name": "Who Am I"}},
+	}
+	useFakeDocker(t, fake)
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	require.NoError(t, sm.RunInitialDockerDiscovery(&DockerConfig{DisableAutodiscovery: true}))
+	_, ok := monitoredService(sm, "Who Am I")
+	assert.False(t, ok)
+}
+
+func TestCheckDockerContainersError(t *testing.T) {
+	useFakeDocker(t, &fakeDocker{err: errors.New("connection refused")})
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	assert.ErrorContains(t, sm.RunInitialDockerDiscovery(&DockerConfig{}), "connection refused")
+
+	result := CheckOnce(context.Background(), &Service{Name: "Plex", Container: "plex"}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Docker error: connection refused", result.Message)
+}
+
+func TestCheckContainerOnce(t *testing.T) {
+	fake := &fakeDocker{containers: testContainers()}
+	useFakeDocker(t, fake)
+
+	// An exact match is preferred over an earlier substring match
+	fake.containers = append([]dockerContainer{{ID: "0", Name: "plex-old", Status: "Exited (0) 1 day ago"}}, fake.containers...)
+	result := CheckOnce(context.Background(), &Service{Name: "Plex", Container: "plex"}, nil)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Contains(t, result.Details, StatusDetail{Label: "ID", Value: "1"})
+
+	result = CheckOnce(context.Background(), &Service{Name: "Grafana", Container: "grafana"}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Container", Value: "grafana-server"})
+
+	result = CheckOnce(context.Background(), &Service{Name: "Pi-hole", Container: "pihole"}, nil)
+	assert.Equal(t, "Container not found", result.Message)
+	assert.Equal(t, 3, fake.closed)
+}
+
+func TestContainerStatus(t *testing.T) {
+	tests := []struct {
+		status  string
+		health  string
+		state   StatusState
+		message string
+	}{
+		{"Up 2 hours (healthy)", "healthy", StatusOK, "Up (healthy)"},
+		{"Up 2 hours (unhealthy)", "unhealthy", StatusCritical, "Unhealthy (Up 2 hours (unhealthy))"},
+		{"Up 5 seconds (health: starting)", "starting", StatusOK, "Running (Up 5 seconds (health: starting))"},
+		{"Exited (0) 1 hour ago", "", StatusWarning, "Exited (Exited (0) 1 hour ago)"},
+		{"Exited (137) 1 hour ago", "", StatusCritical, "Stopped (Exited (137) 1 hour ago)"},
+		{"Restarting (1) 3 seconds ago", "", StatusWarning, "Restarting (Restarting (1) 3 seconds ago)"},
+		{"Created", "", StatusUnknown, "Unknown (Created)"},
+	}
+	for _, tt := range tests {
+		state, message := containerStatus(dockerContainer{Status: tt.status, Health: tt.health})
+		assert.Equal(t, tt.state, state, tt.status)
+		assert.Equal(t, tt.message, message, tt.status)
+	}
+}
+
+func TestContainerHealth(t *testing.T) {
+	assert.Equal(t, "healthy", containerHealth("running", "Up 2 hours (healthy)"))
+	assert.Equal(t, "unhealthy", containerHealth("running", "Up 2 hours (unhealthy)"))
+	assert.Equal(t, "starting", containerHealth("running", "Up 5 seconds (health: starting)"))
+	assert.Equal(t, "", containerHealth("running", "Up 2 hours"))
+	assert.Equal(t, "", containerHealth("exited", "Exited (0) (healthy)"))
+}
+
+func TestStatsFromResponse(t *testing.T) {
+	var response container.StatsResponse
+	response.CPUStats.CPUUsage.TotalUsage = 3_000_000
+	response.CPUStats.SystemUsage = 20_000_000
+	response.CPUStats.OnlineCPUs = 4
+	response.PreCPUStats.CPUUsage.TotalUsage = 2_000_000
+	response.PreCPUStats.SystemUsage = 10_000_000
+	response.MemoryStats.Usage = 300 << 20
+	response.MemoryStats.Limit = 1 << 30
+	response.MemoryStats.Stats = map[string]uint64{"inactive_file": 100 << 20}
+
+	stats := statsFromResponse(response)
+	assert.InDelta(t, 40.0, stats.CPUPercent, 0.001)
+	assert.Equal(t, uint64(200<<20), stats.MemoryUsage)
+	assert.Equal(t, uint64(1<<30), stats.MemoryLimit)
+
+	// A single sample has no CPU usage yet
+	assert.Zero(t, statsFromResponse(container.StatsResponse{}).CPUPercent)
+}
@@ -0,0 +1,146 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// Docker daemon the volume and network checks connect to, nil for the
+	// one of DOCKER_HOST or the local socket
+	checkDockerConfig      *DockerConfig
+	checkDockerConfigMutex sync.RWMutex
+)
+
+// SetDockerConfig sets the Docker daemon the volume and network checks
+// connect to, from docker.yaml. It may be nil.
+func SetDockerConfig(config *DockerConfig) {
+	checkDockerConfigMutex.Lock()
+	defer checkDockerConfigMutex.Unlock()
+	checkDockerConfig = config
+}
+
+// dockerCheckAPI connects to the Docker daemon of the volume and network checks
+func dockerCheckAPI() (dockerAPI, error) {
+	checkDockerConfigMutex.RLock()
+	config := checkDockerConfig
+	checkDockerConfigMutex.RUnlock()
+	if config == nil {
+		config = &DockerConfig{}
+	}
+	return newDockerAPI(config)
+}
+
+// dockerVolumeCheck checks that a named Docker volume exists and, if
+// required, that a running container mounts it
+type dockerVolumeCheck struct {
+	name    string
+	mounted bool
+}
+
+// newDockerVolumeCheck creates a Docker volume check for a service
+func newDockerVolumeCheck(service *Service) *dockerVolumeCheck {
+	return &dockerVolumeCheck{name: service.DockerVolume, mounted: service.DockerVolumeMounted}
+}
+
+// run inspects the volume
+func (c *dockerVolumeCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	api, err := dockerCheckAPI()
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Docker unavailable: %v", err)}
+	}
+	defer api.Close()
+
+	volume, err := api.Volume(ctx, c.name)
+	return dockerVolumeStatus(c.name, volume, err, c.mounted)
+}
+
+// dockerVolumeStatus returns the status of a volume. Missing volumes are
+// critical, volumes not mounted by a running container a warning if a mount
+// is required.
+func dockerVolumeStatus(name string, volume dockerVolume, err error, mounted bool) *StatusResult {
+	if errors.Is(err, errDockerNotFound) {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Volume %s does not exist", name)}
+	}
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Docker error: %v", err)}
+	}
+
+	result := &StatusResult{
+		State:   StatusOK,
+		Message: fmt.Sprintf("Volume %s exists", name),
+		Details: []StatusDetail{
+			{Label: "Driver", Value: volume.Driver},
+			{Label: "Mountpoint", Value: volume.Mountpoint},
+		},
+	}
+	if len(volume.UsedBy) > 0 {
+		result.Message = fmt.Sprintf("Volume %s mounted by %s", name, strings.Join(volume.UsedBy, ", "))
+		result.Details = append(result.Details, StatusDetail{Label: "Mounted by", Value: strings.Join(volume.UsedBy, ", ")})
+	} else if mounted {
+		result.State = StatusWarning
+		result.Message = fmt.Sprintf("Volume %s is not mounted by a running container", name)
+	}
+	return result
+}
+
+// dockerNetworkCheck checks that a Docker network exists with the expected
+// containers attached, catching stacks started without their network
+type dockerNetworkCheck struct {
+	name       string
+	containers []string
+}
+
+// newDockerNetworkCheck creates a Docker network check for a service
+func newDockerNetworkCheck(service *Service) *dockerNetworkCheck {
+	return &dockerNetworkCheck{name: service.DockerNetwork, containers: service.DockerNetworkContainers}
+}
+
+// run inspects the network
+func (c *dockerNetworkCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	api, err := dockerCheckAPI()
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Docker unavailable: %v", err)}
+	}
+	defer api.Close()
+
+	network, err := api.Network(ctx, c.name)
+	return dockerNetworkStatus(c.name, network, err, c.containers)
+}
+
+// dockerNetworkStatus returns the status of a network. Missing networks and
+// expected containers that are not attached are critical.
+func dockerNetworkStatus(name string, network dockerNetwork, err error, expected []string) *StatusResult {
+	if errors.Is(err, errDockerNotFound) {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Network %s does not exist", name)}
+	}
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Docker error: %v", err)}
+	}
+
+	attached := slices.Sorted(slices.Values(network.Containers))
+	result := &StatusResult{
+		State:   StatusOK,
+		Message: fmt.Sprintf("Network %s, %d containers attached", name, len(attached)),
+		Details: []StatusDetail{
+			{Label: "Driver", Value: network.Driver},
+			{Label: "Containers", Value: strings.Join(attached, ", ")},
+		},
+	}
+
+	var missing []string
+	for _, container := range expected {
+		if !slices.Contains(attached, container) {
+			missing = append(missing, container)
+		}
+	}
+	if len(missing) > 0 {
+		result.State = StatusCritical
+		result.Message = fmt.Sprintf("Not attached to %s: %s", name, strings.Join(missing, ", "))
+	}
+	return result
+}
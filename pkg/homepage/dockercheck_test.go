@@ -0,0 +1,57 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerVolumeCheck(t *testing.T) {
+	useFakeDocker(t, &fakeDocker{volumes: map[string]dockerVolume{
+		"media": {Name: "media", Driver: "local", Mountpoint: "/var/lib/docker/volumes/media/_data", UsedBy: []string{"plex"}},
+		"spare": {Name: "spare", Driver: "local"},
+	}})
+
+	result := newDockerVolumeCheck(&Service{DockerVolume: "media", DockerVolumeMounted: true}).run(context.Background(), "Media")
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Volume media mounted by plex", result.Message)
+
+	result = newDockerVolumeCheck(&Service{DockerVolume: "spare"}).run(context.Background(), "Spare")
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Volume spare exists", result.Message)
+
+	result = newDockerVolumeCheck(&Service{DockerVolume: "spare", DockerVolumeMounted: true}).run(context.Background(), "Spare")
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "Volume spare is not mounted by a running container", result.Message)
+
+	result = newDockerVolumeCheck(&Service{DockerVolume: "backup"}).run(context.Background(), "Backup")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Volume backup does not exist", result.Message)
+}
+
+func TestDockerNetworkCheck(t *testing.T) {
+	useFakeDocker(t, &fakeDocker{networks: map[string]dockerNetwork{
+		"proxy": {Name: "proxy", Driver: "bridge", Containers: []string{"traefik", "plex"}},
+	}})
+
+	result := newDockerNetworkCheck(&Service{DockerNetwork: "proxy", DockerNetworkContainers: []string{"plex", "traefik"}}).run(context.Background(), "Proxy")
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Network proxy, 2 containers attached", result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Containers", Value: "plex, traefik"})
+
+	result = newDockerNetworkCheck(&Service{DockerNetwork: "proxy", DockerNetworkContainers: []string{"plex", "sonarr", "radarr"}}).run(context.Background(), "Proxy")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Not attached to proxy: sonarr, radarr", result.Message)
+
+	result = newDockerNetworkCheck(&Service{DockerNetwork: "backend"}).run(context.Background(), "Backend")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Network backend does not exist", result.Message)
+}
+
+func TestDockerNetworkStatus_Error(t *testing.T) {
+	result := dockerNetworkStatus("proxy", dockerNetwork{}, errors.New("connection refused"), nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Docker error: connection refused", result.Message)
+}
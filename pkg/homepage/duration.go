@@ -0,0 +1,57 @@
+package homepage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Seconds is an interval or timeout of the configuration in whole seconds.
+// It is written as a number of seconds, or as a Go duration such as 90s, 5m
+// or 1h30m, which is less error-prone for long intervals.
+type Seconds int
+
+// Duration returns the seconds as a time.Duration
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+// UnmarshalYAML decodes a number of seconds or a duration. Invalid values are
+// reported as type errors, so the rest of a service is still decoded.
+func (s *Seconds) UnmarshalYAML(node *yaml.Node) error {
+	seconds, err := parseSeconds(node.Value)
+	if node.Kind != yaml.ScalarNode {
+		err = fmt.Errorf("expected a number of seconds or a duration such as 30s, 5m or 1h")
+	}
+	if err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", node.Line, err)}}
+	}
+	*s = seconds
+	return nil
+}
+
+// parseSeconds parses a number of seconds or a duration string, refusing
+// negative values and fractions of seconds
+func parseSeconds(value string) (Seconds, error) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("invalid duration %s: must not be negative", value)
+		}
+		return Seconds(n), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("invalid duration %q: use a number of seconds or a duration such as 30s, 5m or 1h", value)
+	case d < 0:
+		return 0, fmt.Errorf("invalid duration %s: must not be negative", value)
+	case d%time.Second != 0:
+		return 0, fmt.Errorf("invalid duration %s: must be a whole number of seconds", value)
+	}
+	return Seconds(d / time.Second), nil
+}
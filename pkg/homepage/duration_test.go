@@ -0,0 +1,103 @@
+package homepage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSeconds(t *testing.T) {
+	for value, expected := range map[string]Seconds{
+		"30":    30,
+		" 0 ":   0,
+		"30s":   30,
+		"5m":    300,
+		"1h30m": 5400,
+	} {
+		seconds, err := parseSeconds(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, seconds, value)
+	}
+
+	for _, value := range []string{"-5", "-1m", "5 minutes", "1d", "", "2.5"} {
+		_, err := parseSeconds(value)
+		assert.Error(t, err, value)
+	}
+	_, err := parseSeconds("1500ms")
+	assert.ErrorContains(t, err, "whole number of seconds")
+	assert.Equal(t, 90*time.Second, Seconds(90).Duration())
+}
+
+func TestLoadServices_Durations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	writeTestFile(t, path, []byte(`- defaults:
+    siteMonitorTimeout: 5s
+- Media:
+    - Plex:
+        siteMonitor: https://plex.lan
+        siteMonitorInterval: 2m
+        retryInterval: 15
+        heartbeatPeriod: "24h"
+        widget:
+          type: plex
+          interval: 1h
+    - Jellyfin:
+        siteMonitor: https://jellyfin.lan
+        siteMonitorInterval: 5 minutes
+        siteMonitorTimeout: 250ms
+- Backups: !include backups.yaml
+`))
+	included := filepath.Join(filepath.Dir(path), "backups.yaml")
+	writeTestFile(t, included, []byte(`- Restic:
+    heartbeatPeriod: 1d
+    widget:
+      type: restic
+      interval: soon
+`))
+
+	// Invalid durations are reported at their file and line, in file order
+	groups, err := LoadServices(path)
+	var invalidValues *InvalidValuesError
+	require.ErrorAs(t, err, &invalidValues)
+	assert.Equal(t, []string{
+		path + `:14: invalid duration "5 minutes": use a number of seconds or a duration such as 30s, 5m or 1h`,
+		path + ":15: invalid duration 250ms: must be a whole number of seconds",
+		included + `:2: invalid duration "1d": use a number of seconds or a duration such as 30s, 5m or 1h`,
+		included + `:5: invalid duration "soon": use a number of seconds or a duration such as 30s, 5m or 1h`,
+	}, invalidValues.Errors)
+	require.Len(t, groups, 2)
+	require.Len(t, groups[0].Services, 2)
+
+	plex := groups[0].Services[0]
+	assert.Equal(t, Seconds(5), plex.SiteMonitorTimeout)
+	assert.Equal(t, Seconds(120), plex.SiteMonitorInterval)
+	assert.Equal(t, Seconds(15), plex.RetryInterval)
+	assert.Equal(t, Seconds(86400), plex.HeartbeatPeriod)
+	assert.Equal(t, Seconds(3600), plex.Widget.Interval)
+
+	// Invalid durations are left out, keeping the rest of the service
+	jellyfin := groups[0].Services[1]
+	assert.Equal(t, "https://jellyfin.lan", jellyfin.SiteMonitor)
+	assert.Equal(t, Seconds(0), jellyfin.SiteMonitorInterval)
+	assert.Equal(t, Seconds(0), jellyfin.SiteMonitorTimeout)
+	require.Len(t, groups[1].Services, 1)
+	assert.Equal(t, "restic", groups[1].Services[0].Widget.Type)
+	assert.Equal(t, Seconds(5), groups[1].Services[0].SiteMonitorTimeout)
+}
+
+func TestLoadSettings_Durations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yaml")
+	writeTestFile(t, path, []byte("status:\n  checkInterval: 5m\n  retryInterval: 30s\ncarousel:\n  interval: 15\n"))
+	settings, err := LoadSettings(path)
+	require.NoError(t, err)
+	assert.Equal(t, Seconds(300), settings.Status.CheckInterval)
+	assert.Equal(t, Seconds(30), settings.Status.RetryInterval)
+	assert.Equal(t, Seconds(15), settings.Carousel.Interval)
+
+	writeTestFile(t, path, []byte("status:\n  checkInterval: soon\n"))
+	_, err = LoadSettings(path)
+	assert.ErrorContains(t, err, `invalid duration "soon"`)
+}
@@ -0,0 +1,134 @@
+package homepage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// elasticsearchCheck maps the cluster health of Elasticsearch or OpenSearch
+// onto the status: green is OK, yellow a warning and red critical
+type elasticsearchCheck struct {
+	url        string
+	apiKey     string
+	skipVerify bool
+	caFile     string
+	caDir      string
+	timeout    time.Duration
+}
+
+// elasticsearchHealth is the response of _cluster/health
+type elasticsearchHealth struct {
+	ClusterName         string `json:"cluster_name"`
+	Status              string `json:"status"`
+	Nodes               int    `json:"number_of_nodes"`
+	DataNodes           int    `json:"number_of_data_nodes"`
+	ActivePrimaryShards int    `json:"active_primary_shards"`
+	ActiveShards        int    `json:"active_shards"`
+	RelocatingShards    int    `json:"relocating_shards"`
+	InitializingShards  int    `json:"initializing_shards"`
+	UnassignedShards    int    `json:"unassigned_shards"`
+	PendingTasks        int    `json:"number_of_pending_tasks"`
+}
+
+// newElasticsearchCheck creates a cluster health check for a service
+func newElasticsearchCheck(service *Service) *elasticsearchCheck {
+	timeout := service.ElasticsearchTimeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	return &elasticsearchCheck{
+		url:        service.Elasticsearch,
+		apiKey:     service.ElasticsearchAPIKey,
+		skipVerify: service.ElasticsearchSkipVerify,
+		caFile:     service.CAFile,
+		caDir:      service.CADir,
+		timeout:    time.Duration(timeout) * time.Second,
+	}
+}
+
+// run requests the cluster health
+func (c *elasticsearchCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	endpoint, err := url.Parse(c.url)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid Elasticsearch URL: %v", err)}
+	}
+	details := []StatusDetail{{Label: "URL", Value: endpoint.Redacted()}}
+
+	tlsConfig, err := clientTLSConfig(c.skipVerify, c.caFile, c.caDir)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Error loading CA certificates: %v", err), Details: details}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialCached
+	transport.TLSClientConfig = tlsConfig
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: c.timeout, Transport: transport}
+
+	// Credentials of the URL are sent as basic authentication
+	user := endpoint.User
+	endpoint.User = nil
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/_cluster/health"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Error creating request: %v", err), Details: details}
+	}
+	if user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+c.apiKey)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Request failed: %v", err), Details: details}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Cluster health returned HTTP %d", resp.StatusCode), ResponseTime: elapsed, Details: details}
+	}
+	var health elasticsearchHealth
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&health); err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid cluster health: %v", err), ResponseTime: elapsed, Details: details}
+	}
+
+	details = append(details,
+		StatusDetail{Label: "Cluster", Value: health.ClusterName},
+		StatusDetail{Label: "Nodes", Value: fmt.Sprintf("%d (%d data)", health.Nodes, health.DataNodes)},
+		StatusDetail{Label: "Shards", Value: fmt.Sprintf("%d active, %d primary", health.ActiveShards, health.ActivePrimaryShards)},
+	)
+	if moving := health.RelocatingShards + health.InitializingShards; moving > 0 {
+		details = append(details, StatusDetail{Label: "Moving shards", Value: fmt.Sprintf("%d relocating, %d initializing", health.RelocatingShards, health.InitializingShards)})
+	}
+	if health.PendingTasks > 0 {
+		details = append(details, StatusDetail{Label: "Pending tasks", Value: fmt.Sprint(health.PendingTasks)})
+	}
+	details = append(details, StatusDetail{Label: "Total", Value: elapsed.Round(time.Microsecond).String()})
+
+	var state StatusState
+	switch health.Status {
+	case "green":
+		state = StatusOK
+	case "yellow":
+		state = StatusWarning
+	case "red":
+		state = StatusCritical
+	default:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Unknown cluster status %q", health.Status), ResponseTime: elapsed, Details: details}
+	}
+	return &StatusResult{
+		State:        state,
+		Message:      fmt.Sprintf("Cluster %s, %d active and %d unassigned shards (%s)", health.Status, health.ActiveShards, health.UnassignedShards, elapsed.Round(time.Millisecond)),
+		ResponseTime: elapsed,
+		Details:      details,
+	}
+}
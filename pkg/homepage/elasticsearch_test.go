@@ -0,0 +1,48 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticsearchCheck(t *testing.T) {
+	status := "green"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if r.URL.Path != "/es/_cluster/health" || (user != "elastic" || password != "secret") && r.Header.Get("Authorization") != "ApiKey a2V5" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"cluster_name":"logs","status":%q,"number_of_nodes":3,"number_of_data_nodes":2,
+			"active_primary_shards":60,"active_shards":118,"relocating_shards":0,"initializing_shards":1,
+			"unassigned_shards":2,"number_of_pending_tasks":0}`, status)
+	}))
+	defer server.Close()
+	withCredentials := strings.Replace(server.URL, "http://", "http://elastic:secret@", 1) + "/es/"
+
+	result := newElasticsearchCheck(&Service{Elasticsearch: withCredentials}).run(context.Background(), "Logs")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Cluster green, 118 active and 2 unassigned shards ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "URL", Value: strings.Replace(withCredentials, "secret", "xxxxx", 1)})
+	assert.Contains(t, result.Details, StatusDetail{Label: "Nodes", Value: "3 (2 data)"})
+	assert.Contains(t, result.Details, StatusDetail{Label: "Moving shards", Value: "0 relocating, 1 initializing"})
+
+	status = "yellow"
+	result = newElasticsearchCheck(&Service{Elasticsearch: server.URL + "/es", ElasticsearchAPIKey: "a2V5"}).run(context.Background(), "Logs")
+	assert.Equal(t, StatusWarning, result.State)
+
+	status = "red"
+	result = newElasticsearchCheck(&Service{Elasticsearch: withCredentials}).run(context.Background(), "Logs")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Cluster red, "), result.Message)
+
+	result = newElasticsearchCheck(&Service{Elasticsearch: server.URL + "/es"}).run(context.Background(), "Logs")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Cluster health returned HTTP 401", result.Message)
+}
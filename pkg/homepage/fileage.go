@@ -0,0 +1,87 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultFileAgeMax is the maximum age of a checked file by default, suiting
+// nightly jobs
+const defaultFileAgeMax = 24 * time.Hour
+
+// fileAgeCheck checks that a file, or the newest file matching a glob, was
+// modified recently, e.g. the output of a backup or export job
+type fileAgeCheck struct {
+	pattern string
+	maxAge  time.Duration
+}
+
+// newFileAgeCheck creates a file age check for a service
+func newFileAgeCheck(service *Service) *fileAgeCheck {
+	maxAge := time.Duration(service.FileAgeMax) * time.Second
+	if service.FileAgeMax <= 0 {
+		maxAge = defaultFileAgeMax
+	}
+	return &fileAgeCheck{pattern: expandHome(service.FileAge), maxAge: maxAge}
+}
+
+// run finds the newest matching file and compares its age to the maximum
+func (c *fileAgeCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	return fileAgeStatus(c.pattern, c.maxAge, time.Now())
+}
+
+// fileAgeStatus returns the status of the newest file matching a pattern
+func fileAgeStatus(pattern string, maxAge time.Duration, now time.Time) *StatusResult {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid pattern %s: %v", pattern, err)}
+	}
+
+	var newest os.FileInfo
+	var newestPath string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if newest == nil || info.ModTime().After(newest.ModTime()) {
+			newest, newestPath = info, path
+		}
+	}
+	if newest == nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("No file matches %s", pattern)}
+	}
+
+	age := now.Sub(newest.ModTime())
+	result := &StatusResult{
+		State:   StatusOK,
+		Message: fmt.Sprintf("%s modified %s", filepath.Base(newestPath), relativeTime(age)),
+		Details: []StatusDetail{
+			{Label: "File", Value: newestPath},
+			{Label: "Modified", Value: newest.ModTime().Format("2006-01-02 15:04:05")},
+			{Label: "Size", Value: fmt.Sprintf("%d bytes", newest.Size())},
+		},
+	}
+	if age > maxAge {
+		result.State = StatusCritical
+		result.Message += ", older than " + shortDuration(maxAge)
+	}
+	return result
+}
+
+// shortDuration formats a duration without trailing zero units, e.g. 24h
+// instead of 24h0m0s
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package homepage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileAgeStatus(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for name, age := range map[string]time.Duration{
+		"backup-1.tar.gz": 50 * time.Hour,
+		"backup-2.tar.gz": 3 * time.Hour,
+		"export.csv":      30 * time.Hour,
+	} {
+		path := filepath.Join(dir, name)
+		writeTestFile(t, path, []byte("data"))
+		assert.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+	}
+
+	result := fileAgeStatus(filepath.Join(dir, "backup-*.tar.gz"), 24*time.Hour, now)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "backup-2.tar.gz modified 3h ago", result.Message)
+	assert.Equal(t, StatusDetail{Label: "File", Value: filepath.Join(dir, "backup-2.tar.gz")}, result.Details[0])
+	assert.Equal(t, StatusDetail{Label: "Size", Value: "4 bytes"}, result.Details[2])
+
+	result = fileAgeStatus(filepath.Join(dir, "export.csv"), 24*time.Hour, now)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "export.csv modified 1d ago, older than 24h", result.Message)
+
+	result = fileAgeStatus(filepath.Join(dir, "*.json"), 24*time.Hour, now)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "No file matches "+filepath.Join(dir, "*.json"), result.Message)
+}
+
+func TestShortDuration(t *testing.T) {
+	assert.Equal(t, "24h", shortDuration(24*time.Hour))
+	assert.Equal(t, "1h30m", shortDuration(90*time.Minute))
+	assert.Equal(t, "45s", shortDuration(45*time.Second))
+}
@@ -0,0 +1,584 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// fileServerCheck logs in to an FTP, FTPS or SFTP server and optionally lists
+// a directory, for file servers that answer neither HTTP nor ping usefully
+type fileServerCheck struct {
+	url        *url.URL
+	err        error // Error parsing the URL
+	key        string
+	skipVerify bool
+	caFile     string
+	caDir      string
+	timeout    time.Duration
+}
+
+// fileServerLogin is what a successful login to a file server found
+type fileServerLogin struct {
+	user    string
+	banner  string // Welcome message of FTP servers or version of SSH servers
+	entries int    // Entries of the listed path, -1 if no path is listed
+}
+
+// newFileServerCheck creates a file server check for a service
+func newFileServerCheck(service *Service) *fileServerCheck {
+	timeout := service.FileServerTimeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	check := &fileServerCheck{
+		key:        service.FileServerKey,
+		skipVerify: service.FileServerSkipVerify,
+		caFile:     service.CAFile,
+		caDir:      service.CADir,
+		timeout:    time.Duration(timeout) * time.Second,
+	}
+	check.url, check.err = url.Parse(service.FileServer)
+	if check.err == nil && check.url.Scheme != "ftp" && check.url.Scheme != "ftps" && check.url.Scheme != "sftp" {
+		check.err = fmt.Errorf("unsupported scheme %q, use ftp, ftps or sftp", check.url.Scheme)
+	}
+	return check
+}
+
+// run logs in and lists the path of the URL, if any
+func (c *fileServerCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	if c.err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid file server URL: %v", c.err)}
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var login *fileServerLogin
+	var err error
+	if c.url.Scheme == "sftp" {
+		login, err = c.sftpLogin(ctx)
+	} else {
+		login, err = c.ftpLogin(ctx)
+	}
+	elapsed := time.Since(start)
+
+	details := []StatusDetail{{Label: "Server", Value: c.url.Redacted()}}
+	if err != nil {
+		message := err.Error()
+		return &StatusResult{State: StatusCritical, Message: strings.ToUpper(message[:1]) + message[1:], Details: details}
+	}
+	if login.banner != "" {
+		details = append(details, StatusDetail{Label: "Banner", Value: login.banner})
+	}
+	details = append(details, StatusDetail{Label: "Total", Value: elapsed.Round(time.Microsecond).String()})
+
+	message := fmt.Sprintf("Logged in as %s (%s)", login.user, elapsed.Round(time.Millisecond))
+	if login.entries >= 0 {
+		message = fmt.Sprintf("Listed %s as %s, %d entries (%s)", c.path(), login.user, login.entries, elapsed.Round(time.Millisecond))
+	}
+	return &StatusResult{State: StatusOK, Message: message, ResponseTime: elapsed, Details: details}
+}
+
+// path returns the path to list, empty if none
+func (c *fileServerCheck) path() string {
+	if c.url.Path == "/" {
+		return ""
+	}
+	return c.url.Path
+}
+
+// address returns the host and port of the server, with the default port of
+// the scheme if none is set
+func (c *fileServerCheck) address() string {
+	if c.url.Port() != "" {
+		return c.url.Host
+	}
+	if c.url.Scheme == "sftp" {
+		return net.JoinHostPort(c.url.Hostname(), "22")
+	}
+	return net.JoinHostPort(c.url.Hostname(), "21")
+}
+
+// ftpLogin logs in to an FTP server, switching to TLS first for ftps, and
+// lists the path with NLST
+func (c *fileServerCheck) ftpLogin(ctx context.Context) (*fileServerLogin, error) {
+	conn, err := dialCached(ctx, "tcp", c.address())
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	text := textproto.NewConn(conn)
+	_, banner, err := ftpResponse(text, 220)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected greeting: %w", err)
+	}
+	login := &fileServerLogin{user: "anonymous", banner: firstLine(banner), entries: -1}
+
+	var tlsConfig *tls.Config
+	if c.url.Scheme == "ftps" {
+		// Explicit TLS, resuming the session on data connections as servers
+		// such as vsftpd require
+		if tlsConfig, err = clientTLSConfig(c.skipVerify, c.caFile, c.caDir); err != nil {
+			return nil, fmt.Errorf("error loading CA certificates: %w", err)
+		}
+		tlsConfig.ServerName = c.url.Hostname()
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(1)
+		if _, _, err := ftpCommand(text, 234, "AUTH TLS"); err != nil {
+			return nil, fmt.Errorf("TLS not supported: %w", err)
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		text = textproto.NewConn(tlsConn)
+		if _, _, err := ftpCommand(text, 200, "PBSZ 0"); err != nil {
+			return nil, fmt.Errorf("PBSZ failed: %w", err)
+		}
+		if _, _, err := ftpCommand(text, 200, "PROT P"); err != nil {
+			return nil, fmt.Errorf("PROT failed: %w", err)
+		}
+	}
+
+	password := "anonymous@"
+	if c.url.User != nil {
+		login.user = c.url.User.Username()
+		if pass, ok := c.url.User.Password(); ok {
+			password = pass
+		}
+	}
+	code, message, err := ftpCommand(text, 0, "USER %s", login.user)
+	if err == nil && code == 331 {
+		code, message, err = ftpCommand(text, 0, "PASS %s", password)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	if code != 230 {
+		return nil, fmt.Errorf("login failed: %d %s", code, firstLine(message))
+	}
+
+	if path := c.path(); path != "" {
+		if login.entries, err = ftpList(ctx, text, conn, tlsConfig, path); err != nil {
+			return nil, fmt.Errorf("listing %s failed: %w", path, err)
+		}
+	}
+	ftpCommand(text, 221, "QUIT")
+	return login, nil
+}
+
+// ftpCommand sends a command and reads its response. With an expected code
+// other than 0, other codes are returned as errors.
+func ftpCommand(text *textproto.Conn, expect int, format string, args ...any) (int, string, error) {
+	if _, err := text.Cmd(format, args...); err != nil {
+		return 0, "", err
+	}
+	return ftpResponse(text, expect)
+}
+
+// ftpResponse reads a response, returning unexpected codes as errors with
+// the first line of the message of the server
+func ftpResponse(text *textproto.Conn, expect int) (int, string, error) {
+	code, message, err := text.ReadResponse(expect)
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return code, message, fmt.Errorf("%d %s", protoErr.Code, firstLine(protoErr.Msg))
+	}
+	return code, message, err
+}
+
+// ftpList lists a path through a passive data connection and returns the
+// number of entries
+func ftpList(ctx context.Context, text *textproto.Conn, control net.Conn, tlsConfig *tls.Config, path string) (int, error) {
+	// The data connection goes to the host of the control connection, as
+	// servers behind NAT often advertise their private address with PASV
+	host, _, _ := net.SplitHostPort(control.RemoteAddr().String())
+	var port int
+	if _, message, err := ftpCommand(text, 229, "EPSV"); err == nil {
+		// 229 Entering Extended Passive Mode (|||port|)
+		if start := strings.Index(message, "(|||"); start >= 0 {
+			port, _ = strconv.Atoi(strings.TrimSuffix(strings.SplitN(message[start+4:], "|", 2)[0], ")"))
+		}
+	} else if _, message, err := ftpCommand(text, 227, "PASV"); err == nil {
+		// 227 Entering Passive Mode (h1,h2,h3,h4,p1,p2)
+		fields := strings.Split(strings.Trim(message[strings.Index(message, "(")+1:], ")."), ",")
+		if len(fields) == 6 {
+			high, _ := strconv.Atoi(fields[4])
+			low, _ := strconv.Atoi(fields[5])
+			port = high*256 + low
+		}
+	} else {
+		return 0, fmt.Errorf("passive mode refused: %w", err)
+	}
+	if port <= 0 {
+		return 0, fmt.Errorf("invalid passive mode response")
+	}
+
+	var dialer net.Dialer
+	data, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return 0, fmt.Errorf("data connection failed: %w", err)
+	}
+	defer data.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		data.SetDeadline(deadline)
+	}
+
+	if _, err := text.Cmd("NLST %s", path); err != nil {
+		return 0, err
+	}
+	if _, _, err := ftpResponse(text, 1); err != nil {
+		return 0, err
+	}
+	var reader io.Reader = data
+	if tlsConfig != nil {
+		reader = tls.Client(data, tlsConfig)
+	}
+	entries := 0
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" && name != "." && name != ".." {
+			entries++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if _, _, err := ftpResponse(text, 2); err != nil {
+		return 0, err
+	}
+	return entries, nil
+}
+
+// firstLine returns the first line of a multi-line message
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(line)
+}
+
+// sftpLogin logs in to an SSH server, starts the SFTP subsystem and lists
+// the path
+func (c *fileServerCheck) sftpLogin(ctx context.Context) (*fileServerLogin, error) {
+	config, closeAgent, err := c.sshConfig()
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+
+	conn, err := dialCached(ctx, "tcp", c.address())
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, c.address(), config)
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	client := ssh.NewClient(sshConn, channels, requests)
+	defer client.Close()
+	login := &fileServerLogin{user: config.User, banner: string(sshConn.ServerVersion()), entries: -1}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("session refused: %w", err)
+	}
+	defer session.Close()
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, fmt.Errorf("SFTP not available: %w", err)
+	}
+
+	sftp := &sftpConn{w: stdin, r: stdout}
+	if err := sftp.init(); err != nil {
+		return nil, fmt.Errorf("SFTP handshake failed: %w", err)
+	}
+	if path := c.path(); path != "" {
+		if login.entries, err = sftp.list(path); err != nil {
+			return nil, fmt.Errorf("listing %s failed: %w", path, err)
+		}
+	}
+	return login, nil
+}
+
+// sshConfig returns the client configuration of SFTP logins: the password of
+// the URL, the key file or the default keys and those of the ssh agent, and
+// the host keys of ~/.ssh/known_hosts. The returned function closes the
+// connection to the agent.
+func (c *fileServerCheck) sshConfig() (*ssh.ClientConfig, func(), error) {
+	config := &ssh.ClientConfig{Timeout: c.timeout, HostKeyCallback: ssh.InsecureIgnoreHostKey()}
+	closeAgent := func() {}
+	if c.url.User != nil {
+		config.User = c.url.User.Username()
+	}
+	if config.User == "" {
+		if current, err := user.Current(); err == nil {
+			config.User = current.Username
+		}
+	}
+
+	if !c.skipVerify {
+		hostKeys, err := knownhosts.New(expandHome("~/.ssh/known_hosts"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't verify the host key: %w", err)
+		}
+		config.HostKeyCallback = hostKeys
+	}
+
+	if password, ok := c.url.User.Password(); ok {
+		config.Auth = append(config.Auth, ssh.Password(password))
+	}
+	var signers []ssh.Signer
+	keyFiles := []string{"~/.ssh/id_ed25519", "~/.ssh/id_ecdsa", "~/.ssh/id_rsa"}
+	if c.key != "" {
+		keyFiles = []string{c.key}
+	}
+	for _, keyFile := range keyFiles {
+		data, err := os.ReadFile(expandHome(keyFile))
+		if err != nil {
+			if c.key != "" {
+				return nil, nil, fmt.Errorf("error reading key: %w", err)
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			if c.key != "" {
+				return nil, nil, fmt.Errorf("error parsing key %s: %w", filepath.Base(keyFile), err)
+			}
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" && c.key == "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			closeAgent = func() { conn.Close() }
+			if agentSigners, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, agentSigners...)
+			}
+		}
+	}
+	if len(signers) > 0 {
+		config.Auth = append(config.Auth, ssh.PublicKeys(signers...))
+	}
+	return config, closeAgent, nil
+}
+
+// SFTP packet types (draft-ietf-secsh-filexfer-02, version 3)
+const (
+	sftpInit     = 1
+	sftpVersion  = 2
+	sftpClose    = 4
+	sftpOpenDir  = 11
+	sftpReadDir  = 12
+	sftpStatus   = 101
+	sftpHandle   = 102
+	sftpName     = 104
+	sftpStatusOK = 0
+	sftpEOF      = 1
+)
+
+// sftpConn speaks the few SFTP requests needed to list a directory
+type sftpConn struct {
+	w  io.Writer
+	r  io.Reader
+	id uint32
+}
+
+// send writes a packet of the given type, with a request ID unless it is
+// the init packet
+func (s *sftpConn) send(packetType byte, fields ...[]byte) error {
+	payload := []byte{packetType}
+	if packetType == sftpInit {
+		payload = binary.BigEndian.AppendUint32(payload, 3)
+	} else {
+		s.id++
+		payload = binary.BigEndian.AppendUint32(payload, s.id)
+	}
+	for _, field := range fields {
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(field)))
+		payload = append(payload, field...)
+	}
+	_, err := s.w.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(payload))), payload...))
+	return err
+}
+
+// receive reads a packet and returns its type and its data after the type
+// and, except for the version packet, the request ID
+func (s *sftpConn) receive() (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length < 5 || length > 256<<10 {
+		return 0, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(s.r, packet); err != nil {
+		return 0, nil, err
+	}
+	return packet[0], packet[5:], nil
+}
+
+// init exchanges the versions of the protocol
+func (s *sftpConn) init() error {
+	if err := s.send(sftpInit); err != nil {
+		return err
+	}
+	packetType, _, err := s.receive()
+	if err != nil {
+		return err
+	}
+	if packetType != sftpVersion {
+		return fmt.Errorf("unexpected packet %d", packetType)
+	}
+	return nil
+}
+
+// list returns the number of entries of a directory, without . and ..
+func (s *sftpConn) list(path string) (int, error) {
+	if err := s.send(sftpOpenDir, []byte(path)); err != nil {
+		return 0, err
+	}
+	packetType, data, err := s.receive()
+	if err != nil {
+		return 0, err
+	}
+	if packetType != sftpHandle {
+		return 0, sftpError(packetType, data)
+	}
+	handle, _ := sftpString(data)
+	defer func() {
+		if s.send(sftpClose, handle) == nil {
+			s.receive()
+		}
+	}()
+
+	entries := 0
+	for {
+		if err := s.send(sftpReadDir, handle); err != nil {
+			return 0, err
+		}
+		packetType, data, err := s.receive()
+		if err != nil {
+			return 0, err
+		}
+		if packetType == sftpStatus && len(data) >= 4 && binary.BigEndian.Uint32(data) == sftpEOF {
+			return entries, nil
+		}
+		if packetType != sftpName || len(data) < 4 {
+			return 0, sftpError(packetType, data)
+		}
+		// Each name is followed by its long name and attributes, which are
+		// only parsed as far as needed to find the next name
+		count := binary.BigEndian.Uint32(data)
+		rest := data[4:]
+		for i := uint32(0); i < count; i++ {
+			name, after := sftpString(rest)
+			if name == nil {
+				return 0, fmt.Errorf("truncated directory listing")
+			}
+			if string(name) != "." && string(name) != ".." {
+				entries++
+			}
+			_, after = sftpString(after)
+			if rest = skipSFTPAttrs(after); rest == nil {
+				return 0, fmt.Errorf("truncated directory listing")
+			}
+		}
+	}
+}
+
+// sftpError describes an unexpected response, such as a status with the
+// message of the server
+func sftpError(packetType byte, data []byte) error {
+	if packetType != sftpStatus || len(data) < 4 {
+		return fmt.Errorf("unexpected packet %d", packetType)
+	}
+	if message, _ := sftpString(data[4:]); len(message) > 0 {
+		return errors.New(string(message))
+	}
+	return fmt.Errorf("error %d", binary.BigEndian.Uint32(data))
+}
+
+// sftpString reads a length-prefixed string, returning nil if truncated
+func sftpString(data []byte) ([]byte, []byte) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+	length := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < length {
+		return nil, nil
+	}
+	return data[4 : 4+length], data[4+length:]
+}
+
+// skipSFTPAttrs skips the attributes of a file, returning nil if truncated
+func skipSFTPAttrs(data []byte) []byte {
+	if len(data) < 4 {
+		return nil
+	}
+	flags := binary.BigEndian.Uint32(data)
+	size := 4
+	if flags&0x1 != 0 { // Size
+		size += 8
+	}
+	if flags&0x2 != 0 { // UID and GID
+		size += 8
+	}
+	if flags&0x4 != 0 { // Permissions
+		size += 4
+	}
+	if flags&0x8 != 0 { // Access and modification times
+		size += 8
+	}
+	if len(data) < size {
+		return nil
+	}
+	data = data[size:]
+	if flags&0x80000000 != 0 { // Extended attributes
+		if len(data) < 4 {
+			return nil
+		}
+		count := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		for i := uint32(0); i < 2*count; i++ {
+			if _, data = sftpString(data); data == nil {
+				return nil
+			}
+		}
+	}
+	return data
+}
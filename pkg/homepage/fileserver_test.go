@@ -0,0 +1,244 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// serveFakeFTP answers FTP logins with password secret and lists files
+// through EPSV, returning the address of the server
+func serveFakeFTP(t *testing.T, files []string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				fmt.Fprint(conn, "220-Welcome to the fake FTP server\r\n220 Ready\r\n")
+				var data net.Listener
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					command, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+					switch command {
+					case "USER":
+						fmt.Fprint(conn, "331 Password required\r\n")
+					case "PASS":
+						if arg != "secret" {
+							fmt.Fprint(conn, "530 Login incorrect\r\n")
+							continue
+						}
+						fmt.Fprint(conn, "230 Logged in\r\n")
+					case "EPSV":
+						data, _ = net.Listen("tcp", "127.0.0.1:0")
+						fmt.Fprintf(conn, "229 Entering Extended Passive Mode (|||%d|)\r\n", data.Addr().(*net.TCPAddr).Port)
+					case "NLST":
+						if arg != "/pub" {
+							fmt.Fprint(conn, "550 No such directory\r\n")
+							continue
+						}
+						dataConn, err := data.Accept()
+						data.Close()
+						if err != nil {
+							return
+						}
+						fmt.Fprint(conn, "150 Opening data connection\r\n")
+						for _, file := range files {
+							fmt.Fprintf(dataConn, "%s\r\n", file)
+						}
+						dataConn.Close()
+						fmt.Fprint(conn, "226 Transfer complete\r\n")
+					case "QUIT":
+						fmt.Fprint(conn, "221 Goodbye\r\n")
+						return
+					default:
+						fmt.Fprint(conn, "502 Not implemented\r\n")
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestFileServerCheckFTP(t *testing.T) {
+	addr := serveFakeFTP(t, []string{"a.iso", "b.iso", "c.iso"})
+
+	result := newFileServerCheck(&Service{FileServer: "ftp://backup:secret@" + addr}).run(context.Background(), "NAS")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Logged in as backup ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Server", Value: "ftp://backup:xxxxx@" + addr})
+	assert.Contains(t, result.Details, StatusDetail{Label: "Banner", Value: "Welcome to the fake FTP server"})
+
+	result = newFileServerCheck(&Service{FileServer: "ftp://backup:secret@" + addr + "/pub"}).run(context.Background(), "NAS")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Listed /pub as backup, 3 entries ("), result.Message)
+
+	result = newFileServerCheck(&Service{FileServer: "ftp://backup:secret@" + addr + "/missing"}).run(context.Background(), "NAS")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Listing /missing failed: 550 No such directory", result.Message)
+
+	result = newFileServerCheck(&Service{FileServer: "ftp://backup:wrong@" + addr}).run(context.Background(), "NAS")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Login failed: 530 Login incorrect", result.Message)
+}
+
+// serveFakeSFTP answers SSH logins with password secret and lists the files
+// of /data through the SFTP subsystem, returning the address of the server
+func serveFakeSFTP(t *testing.T, files []string) string {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != "secret" {
+				return nil, fmt.Errorf("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, channels, requests, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(requests)
+				for newChannel := range channels {
+					channel, requests, err := newChannel.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for request := range requests {
+							ok := request.Type == "subsystem" && string(request.Payload[4:]) == "sftp"
+							request.Reply(ok, nil)
+							if ok {
+								go serveFakeSFTPSubsystem(channel, files)
+							}
+						}
+					}()
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// serveFakeSFTPSubsystem answers the SFTP requests of a directory listing
+func serveFakeSFTPSubsystem(channel ssh.Channel, files []string) {
+	defer channel.Close()
+	reply := func(packetType byte, id uint32, payload []byte) {
+		packet := append([]byte{packetType}, binary.BigEndian.AppendUint32(nil, id)...)
+		packet = append(packet, payload...)
+		channel.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(packet))), packet...))
+	}
+	appendString := func(data []byte, s string) []byte {
+		return append(binary.BigEndian.AppendUint32(data, uint32(len(s))), s...)
+	}
+	listed := false
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(channel, header[:]); err != nil {
+			return
+		}
+		packet := make([]byte, binary.BigEndian.Uint32(header[:]))
+		if _, err := io.ReadFull(channel, packet); err != nil {
+			return
+		}
+		id := binary.BigEndian.Uint32(packet[1:])
+		switch packet[0] {
+		case sftpInit:
+			reply(sftpVersion, 3, nil)
+		case sftpOpenDir:
+			path, _ := sftpString(packet[5:])
+			if string(path) != "/data" {
+				status := binary.BigEndian.AppendUint32(nil, 2)
+				reply(sftpStatus, id, appendString(appendString(status, "No such file"), ""))
+				continue
+			}
+			reply(sftpHandle, id, appendString(nil, "handle"))
+		case sftpReadDir:
+			if listed {
+				reply(sftpStatus, id, binary.BigEndian.AppendUint32(nil, sftpEOF))
+				continue
+			}
+			listed = true
+			names := binary.BigEndian.AppendUint32(nil, uint32(len(files)+2))
+			for _, name := range append([]string{".", ".."}, files...) {
+				names = appendString(names, name)
+				names = appendString(names, "-rw-r--r-- 1 backup backup 0 "+name)
+				names = binary.BigEndian.AppendUint32(names, 0x1|0x4) // Size and permissions
+				names = binary.BigEndian.AppendUint64(names, 1024)
+				names = binary.BigEndian.AppendUint32(names, 0o644)
+			}
+			reply(sftpName, id, names)
+		case sftpClose:
+			reply(sftpStatus, id, binary.BigEndian.AppendUint32(nil, sftpStatusOK))
+		}
+	}
+}
+
+func TestFileServerCheckSFTP(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("HOME", t.TempDir())
+	addr := serveFakeSFTP(t, []string{"db.sql.gz", "photos.tar"})
+
+	result := newFileServerCheck(&Service{FileServer: "sftp://backup:secret@" + addr + "/data", FileServerSkipVerify: true}).run(context.Background(), "Backup")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Listed /data as backup, 2 entries ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Banner", Value: "SSH-2.0-Go"})
+
+	result = newFileServerCheck(&Service{FileServer: "sftp://backup:secret@" + addr + "/missing", FileServerSkipVerify: true}).run(context.Background(), "Backup")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Listing /missing failed: No such file", result.Message)
+
+	result = newFileServerCheck(&Service{FileServer: "sftp://backup:wrong@" + addr, FileServerSkipVerify: true}).run(context.Background(), "Backup")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Login failed: "), result.Message)
+
+	// Without skipping the verification, the host key must be known
+	result = newFileServerCheck(&Service{FileServer: "sftp://backup:secret@" + addr}).run(context.Background(), "Backup")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Can't verify the host key: "), result.Message)
+}
+
+func TestFileServerCheckInvalidURL(t *testing.T) {
+	result := newFileServerCheck(&Service{FileServer: "smb://nas/share"}).run(context.Background(), "NAS")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, `Invalid file server URL: unsupported scheme "smb", use ftp, ftps or sftp`, result.Message)
+}
@@ -0,0 +1,152 @@
+package homepage
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gitCheck lists a ref of a remote repository with git ls-remote and, with a
+// mirror, compares it to the same ref of the local clone, warning once the
+// mirror has been behind for longer than maxLag
+type gitCheck struct {
+	remote  string
+	ref     string
+	mirror  string
+	maxLag  time.Duration
+	timeout time.Duration
+
+	mutex       sync.Mutex
+	behind      string    // Remote commit the mirror is behind of
+	behindSince time.Time // When the mirror was first seen behind it
+}
+
+// newGitCheck creates a git check for a service
+func newGitCheck(service *Service) *gitCheck {
+	timeout := service.GitTimeout
+	if timeout <= 0 {
+		timeout = 30
+	}
+	maxLag := service.GitMaxLag
+	if maxLag <= 0 {
+		maxLag = 3600
+	}
+	return &gitCheck{
+		remote:  service.Git,
+		ref:     cmp.Or(service.GitRef, "HEAD"),
+		mirror:  expandHome(service.GitMirror),
+		maxLag:  time.Duration(maxLag) * time.Second,
+		timeout: time.Duration(timeout) * time.Second,
+	}
+}
+
+// run lists the remote ref and compares it to the mirror
+func (c *gitCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	details := []StatusDetail{{Label: "Remote", Value: redactGitRemote(c.remote)}, {Label: "Ref", Value: c.ref}}
+
+	start := time.Now()
+	// Annotated tags are only listed peeled with a pattern of their own
+	output, err := runGit(ctx, "ls-remote", c.remote, c.ref, c.ref+"^{}")
+	elapsed := time.Since(start)
+	details = append(details, StatusDetail{Label: "ls-remote", Value: elapsed.Round(time.Millisecond).String()})
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("ls-remote failed: %v", err), Details: details}
+	}
+	remote := gitRemoteCommit(output, c.ref)
+	if remote == "" {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Ref %s not found", c.ref), ResponseTime: elapsed, Details: details}
+	}
+	details = append(details, StatusDetail{Label: "Remote commit", Value: remote})
+
+	if c.mirror == "" {
+		return &StatusResult{State: StatusOK, Message: fmt.Sprintf("%s at %s (%s)", c.ref, remote[:7], elapsed.Round(time.Millisecond)), ResponseTime: elapsed, Details: details}
+	}
+
+	output, err = runGit(ctx, "-C", c.mirror, "rev-parse", "--verify", c.ref+"^{commit}")
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Mirror unreadable: %v", err), ResponseTime: elapsed, Details: details}
+	}
+	local := strings.TrimSpace(output)
+	details = append(details, StatusDetail{Label: "Mirror", Value: c.mirror}, StatusDetail{Label: "Mirror commit", Value: local})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if local == remote {
+		c.behind, c.behindSince = "", time.Time{}
+		return &StatusResult{State: StatusOK, Message: fmt.Sprintf("Mirror up to date at %s (%s)", remote[:7], elapsed.Round(time.Millisecond)), ResponseTime: elapsed, Details: details}
+	}
+
+	// The lag counts from the first check seeing the current remote commit,
+	// so a remote moving on while the mirror catches up restarts it
+	if c.behind != remote {
+		c.behind, c.behindSince = remote, time.Now()
+	}
+	lag := time.Since(c.behindSince)
+	message := fmt.Sprintf("at %s, remote at %s", local[:min(7, len(local))], remote[:7])
+	if lag < c.maxLag {
+		return &StatusResult{State: StatusOK, Message: "Mirror syncing, " + message, ResponseTime: elapsed, Details: details}
+	}
+	return &StatusResult{State: StatusWarning, Message: fmt.Sprintf("Mirror behind for %s, %s", formatUptime(lag), message), ResponseTime: elapsed, Details: details}
+}
+
+// runGit runs git without prompting for credentials and returns its output,
+// or the first line of its error output as error
+func runGit(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("timed out")
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && stderr.Len() > 0 {
+		return "", errors.New(strings.TrimPrefix(firstLine(stderr.String()), "fatal: "))
+	}
+	if err != nil {
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// gitRemoteCommit returns the commit of a ref in the output of ls-remote,
+// peeling annotated tags, or an empty string if the ref is not listed
+func gitRemoteCommit(output, ref string) string {
+	commits := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if commit, name, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok {
+			commits[name] = commit
+		}
+	}
+	for _, name := range []string{ref, "refs/heads/" + ref, "refs/tags/" + ref} {
+		if commit := cmp.Or(commits[name+"^{}"], commits[name]); commit != "" {
+			return commit
+		}
+	}
+	return ""
+}
+
+// redactGitRemote hides the password of HTTP remotes
+func redactGitRemote(remote string) string {
+	if strings.Contains(remote, "://") {
+		if parsed, err := url.Parse(remote); err == nil {
+			return parsed.Redacted()
+		}
+	}
+	return remote
+}
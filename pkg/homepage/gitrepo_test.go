@@ -0,0 +1,83 @@
+package homepage
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testGit runs git with a test identity and returns its trimmed output
+func testGit(t *testing.T, args ...string) string {
+	output, err := runGit(context.Background(), append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	require.NoError(t, err)
+	return strings.TrimSpace(output)
+}
+
+// gitCommit commits an empty change to a work tree and returns its commit
+func gitCommit(t *testing.T, dir, message string) string {
+	testGit(t, "-C", dir, "commit", "--allow-empty", "-q", "-m", message)
+	return testGit(t, "-C", dir, "rev-parse", "HEAD")
+}
+
+func TestGitCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	origin, mirror := filepath.Join(dir, "origin"), filepath.Join(dir, "mirror.git")
+	testGit(t, "init", "-q", "-b", "main", origin)
+	first := gitCommit(t, origin, "first")
+	testGit(t, "-C", origin, "tag", "-a", "v1", "-m", "v1")
+	testGit(t, "clone", "-q", "--mirror", origin, mirror)
+
+	result := newGitCheck(&Service{Git: origin}).run(context.Background(), "Repo")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "HEAD at "+first[:7]+" ("), result.Message)
+
+	// Annotated tags are compared by the commit they point to
+	result = newGitCheck(&Service{Git: origin, GitRef: "v1", GitMirror: mirror}).run(context.Background(), "Repo")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Mirror up to date at "+first[:7]+" ("), result.Message)
+
+	second := gitCommit(t, origin, "second")
+	check := newGitCheck(&Service{Git: origin, GitRef: "main", GitMirror: mirror, GitMaxLag: 600})
+	result = check.run(context.Background(), "Repo")
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Mirror syncing, at "+first[:7]+", remote at "+second[:7], result.Message)
+
+	check.behindSince = time.Now().Add(-90 * time.Minute)
+	result = check.run(context.Background(), "Repo")
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "Mirror behind for 1h 30m, at "+first[:7]+", remote at "+second[:7], result.Message)
+
+	testGit(t, "-C", mirror, "fetch", "-q")
+	result = check.run(context.Background(), "Repo")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Mirror up to date at "+second[:7]+" ("), result.Message)
+
+	result = newGitCheck(&Service{Git: origin, GitRef: "release"}).run(context.Background(), "Repo")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Ref release not found", result.Message)
+
+	result = newGitCheck(&Service{Git: filepath.Join(dir, "missing")}).run(context.Background(), "Repo")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "ls-remote failed: "), result.Message)
+}
+
+func TestGitRemoteCommit(t *testing.T) {
+	output := "1111111111111111111111111111111111111111\tHEAD\n" +
+		"1111111111111111111111111111111111111111\trefs/heads/main\n" +
+		"2222222222222222222222222222222222222222\trefs/tags/v1\n" +
+		"3333333333333333333333333333333333333333\trefs/tags/v1^{}\n"
+	assert.Equal(t, "1111111111111111111111111111111111111111", gitRemoteCommit(output, "HEAD"))
+	assert.Equal(t, "1111111111111111111111111111111111111111", gitRemoteCommit(output, "main"))
+	assert.Equal(t, "3333333333333333333333333333333333333333", gitRemoteCommit(output, "v1"))
+	assert.Equal(t, "3333333333333333333333333333333333333333", gitRemoteCommit(output, "refs/tags/v1"))
+	assert.Empty(t, gitRemoteCommit(output, "develop"))
+}
@@ -0,0 +1,157 @@
+package homepage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// ErrUnknownHeartbeat is returned for heartbeats of services that don't exist
+var ErrUnknownHeartbeat = errors.New("unknown heartbeat")
+
+// defaultHeartbeatGrace is the time a late heartbeat is waited for by default
+const defaultHeartbeatGrace = 60 * time.Second
+
+// heartbeat tracks a passive heartbeat check
+type heartbeat struct {
+	service  *Service
+	timeout  time.Duration // Period plus grace time
+	lastBeat time.Time     // When the last heartbeat was received
+	timer    Timer         // Fires when the next heartbeat is overdue
+}
+
+// HeartbeatID returns the identifier used in the heartbeat URL of a service:
+// its heartbeat token if set, otherwise a slug of its name
+func HeartbeatID(service *Service) string {
+	if service.HeartbeatToken != "" {
+		return service.HeartbeatToken
+	}
+	return slugify(service.Name)
+}
+
+// slugify converts a name to lowercase words joined by dashes
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// startHeartbeat starts waiting for heartbeats of a service. The service turns
+// critical when no heartbeat arrives within its period plus grace time.
+func (sm *StatusMonitor) startHeartbeat(service *Service) {
+	id := HeartbeatID(service)
+	grace := time.Duration(service.HeartbeatGrace) * time.Second
+	if service.HeartbeatGrace <= 0 {
+		grace = defaultHeartbeatGrace
+	}
+	timeout := time.Duration(service.HeartbeatPeriod)*time.Second + grace
+
+	sm.heartbeatsMutex.Lock()
+	if existing, exists := sm.heartbeats[id]; exists {
+		sm.heartbeatsMutex.Unlock()
+		logging.Error("Heartbeat for %s: id %q is already used by %s, set a different heartbeatToken",
+			service.Name, id, existing.service.Name)
+		sm.updateServiceStatus(service.Name, StatusCritical, "Duplicate heartbeat id")
+		return
+	}
+	sm.heartbeats[id] = &heartbeat{
+		service: service,
+		timeout: timeout,
+		timer:   sm.clock.AfterFunc(timeout, func() { sm.heartbeatMissed(id) }),
+	}
+	sm.heartbeatsMutex.Unlock()
+
+	logging.Info("Expecting heartbeats for %s every %ds at /api/heartbeat/%s", service.Name, service.HeartbeatPeriod, id)
+	sm.updateServiceStatus(service.Name, StatusUnknown, "Waiting for first heartbeat")
+}
+
+// RecordHeartbeat records a heartbeat received for the given id. A failed
+// heartbeat, sent by a job reporting its own failure, turns the service
+// critical right away.
+func (sm *StatusMonitor) RecordHeartbeat(id string, failed bool) error {
+	sm.heartbeatsMutex.Lock()
+	hb, exists := sm.heartbeats[id]
+	if !exists {
+		sm.heartbeatsMutex.Unlock()
+		return ErrUnknownHeartbeat
+	}
+	hb.lastBeat = sm.clock.Now()
+	hb.timer.Reset(hb.timeout)
+	sm.heartbeatsMutex.Unlock()
+
+	if failed {
+		sm.updateServiceStatus(hb.service.Name, StatusCritical, fmt.Sprintf("Job failed at %s", hb.lastBeat.Format("15:04")))
+	} else {
+		sm.updateServiceStatus(hb.service.Name, StatusOK, fmt.Sprintf("Last heartbeat %s", hb.lastBeat.Format("15:04")))
+	}
+	return nil
+}
+
+// HeartbeatRequiresAuth reports whether heartbeats for id must carry the API
+// token, which is the case when the service has no secret heartbeat token
+func (sm *StatusMonitor) HeartbeatRequiresAuth(id string) bool {
+	sm.heartbeatsMutex.Lock()
+	defer sm.heartbeatsMutex.Unlock()
+
+	hb, exists := sm.heartbeats[id]
+	return !exists || hb.service.HeartbeatToken == ""
+}
+
+// heartbeatMissed turns a service critical when its heartbeat is overdue
+func (sm *StatusMonitor) heartbeatMissed(id string) {
+	sm.heartbeatsMutex.Lock()
+	hb, exists := sm.heartbeats[id]
+	var lastBeat time.Time
+	if exists {
+		lastBeat = hb.lastBeat
+	}
+	sm.heartbeatsMutex.Unlock()
+	if !exists || (!lastBeat.IsZero() && sm.clock.Now().Sub(lastBeat) < hb.timeout) {
+		// Unknown, or a heartbeat arrived while the timer fired
+		return
+	}
+
+	message := fmt.Sprintf("No heartbeat within %s", hb.timeout)
+	if !lastBeat.IsZero() {
+		message = fmt.Sprintf("No heartbeat since %s", lastBeat.Format("Jan 2 15:04"))
+	}
+	logging.Warn("Heartbeat for %s: %s", hb.service.Name, message)
+	sm.updateServiceStatus(hb.service.Name, StatusCritical, message)
+}
+
+// stopHeartbeat stops waiting for heartbeats of a removed service
+func (sm *StatusMonitor) stopHeartbeat(service *Service) {
+	sm.heartbeatsMutex.Lock()
+	defer sm.heartbeatsMutex.Unlock()
+
+	id := HeartbeatID(service)
+	if hb, exists := sm.heartbeats[id]; exists && hb.service == service {
+		hb.timer.Stop()
+		delete(sm.heartbeats, id)
+	}
+}
+
+// stopHeartbeats stops the timers of all heartbeat checks
+func (sm *StatusMonitor) stopHeartbeats() {
+	sm.heartbeatsMutex.Lock()
+	defer sm.heartbeatsMutex.Unlock()
+
+	for _, hb := range sm.heartbeats {
+		hb.timer.Stop()
+	}
+}
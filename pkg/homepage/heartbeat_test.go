@@ -0,0 +1,66 @@
+package homepage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatID(t *testing.T) {
+	assert.Equal(t, "nightly-backup-nas", HeartbeatID(&Service{Name: "Nightly Backup (NAS)"}))
+	assert.Equal(t, "s3cr3t", HeartbeatID(&Service{Name: "Backup", HeartbeatToken: "s3cr3t"}))
+}
+
+func TestHeartbeat(t *testing.T) {
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+
+	sm.AddService(&Service{Name: "Backup", HeartbeatPeriod: 3600, HeartbeatToken: "s3cr3t"})
+	sm.AddService(&Service{Name: "Cron Job", HeartbeatPeriod: 60})
+
+	assert.Equal(t, StatusUnknown, sm.GetStatus("Backup").State)
+	assert.False(t, sm.HeartbeatRequiresAuth("s3cr3t"))
+	assert.True(t, sm.HeartbeatRequiresAuth("cron-job"))
+
+	require.NoError(t, sm.RecordHeartbeat("s3cr3t", false))
+	assert.Equal(t, StatusOK, sm.GetStatus("Backup").State)
+
+	// A heartbeat that just arrived is not overdue
+	sm.heartbeatMissed("s3cr3t")
+	assert.Equal(t, StatusOK, sm.GetStatus("Backup").State)
+
+	require.NoError(t, sm.RecordHeartbeat("s3cr3t", true))
+	assert.Equal(t, StatusCritical, sm.GetStatus("Backup").State)
+
+	// Without any heartbeat the service turns critical when the timer fires
+	sm.heartbeatMissed("cron-job")
+	assert.Equal(t, StatusCritical, sm.GetStatus("Cron Job").State)
+	assert.Contains(t, sm.GetStatus("Cron Job").Message, "No heartbeat within 2m0s")
+
+	assert.ErrorIs(t, sm.RecordHeartbeat("nope", false), ErrUnknownHeartbeat)
+}
+
+func TestHeartbeatTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(nil)
+	sm.SetClock(clock)
+	defer sm.Stop()
+
+	sm.AddService(&Service{Name: "Backup", HeartbeatPeriod: 3600, HeartbeatGrace: 300})
+	require.NoError(t, sm.RecordHeartbeat("backup", false))
+	assert.Equal(t, "Last heartbeat 12:00", sm.GetStatus("Backup").Message)
+
+	// Overdue only after the period plus grace time
+	clock.Advance(3899 * time.Second)
+	assert.Equal(t, StatusOK, sm.GetStatus("Backup").State)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, StatusCritical, sm.GetStatus("Backup").State)
+	assert.Equal(t, "No heartbeat since Jan 1 12:00", sm.GetStatus("Backup").Message)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, sm.RecordHeartbeat("backup", false))
+	assert.Equal(t, "Last heartbeat 13:06", sm.GetStatus("Backup").Message)
+}
@@ -0,0 +1,336 @@
+package homepage
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+const (
+	// defaultHistorySize is the number of status events kept per service
+	defaultHistorySize = 100
+
+	// defaultHistoryMemory is the memory budget of the events of all services
+	defaultHistoryMemory = 4 << 20
+
+	// maxHistoryMessage caps the length of messages kept in the history, so a
+	// check reporting whole command outputs can't use up the budget alone
+	maxHistoryMessage = 512
+
+	// statusEventSize is the memory used by an event besides its strings
+	statusEventSize = int(unsafe.Sizeof(StatusEvent{}))
+)
+
+// StatusEvent is a change of the state or message of a service, or an action
+// run on it from the dashboard
+type StatusEvent struct {
+	Time    time.Time
+	State   StatusState
+	Message string
+	User    string // Who ran the audited action, empty for status changes
+}
+
+// statusHistory keeps the recent events of each service in a ring buffer of
+// at most size events. Once the events of all services use more memory than
+// the budget, the oldest events of any service are evicted, so instances
+// running for months with chatty checks use bounded memory.
+type statusHistory struct {
+	mutex  sync.Mutex
+	size   int                   // Events kept per service
+	budget int                   // Bytes the events of all services may use
+	used   int                   // Bytes currently used
+	rings  map[string]*eventRing // Events by service name
+}
+
+// eventRing is a ring buffer of the events of a service
+type eventRing struct {
+	events []StatusEvent
+	start  int // Index of the oldest event
+	count  int
+}
+
+func newStatusHistory() *statusHistory {
+	return &statusHistory{
+		size:   defaultHistorySize,
+		budget: defaultHistoryMemory,
+		rings:  make(map[string]*eventRing),
+	}
+}
+
+// setLimits changes the number of events kept per service and the memory
+// budget, evicting events beyond them. Values of 0 or less keep the defaults.
+func (h *statusHistory) setLimits(size, budget int) {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	if budget <= 0 {
+		budget = defaultHistoryMemory
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if size != h.size {
+		for name, ring := range h.rings {
+			events := ring.list()
+			for len(events) > size {
+				h.used -= eventBytes(events[0])
+				events = events[1:]
+			}
+			h.rings[name] = &eventRing{events: events, count: len(events)}
+		}
+	}
+	h.size, h.budget = size, budget
+	h.evict()
+}
+
+// add appends an event to the history of a service, evicting its oldest
+// event when full and the oldest events of all services when over budget
+func (h *statusHistory) add(serviceName string, event StatusEvent) {
+	if len(event.Message) > maxHistoryMessage {
+		// Copied, so the history doesn't keep the whole message in memory
+		event.Message = strings.Clone(strings.ToValidUTF8(event.Message[:maxHistoryMessage], ""))
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	ring, ok := h.rings[serviceName]
+	if !ok {
+		ring = &eventRing{}
+		h.rings[serviceName] = ring
+	}
+	switch {
+	case ring.count < len(ring.events):
+		// A slot was freed by eviction
+	case len(ring.events) < h.size:
+		// Grow up to size, so services with few events use little memory
+		if ring.start != 0 {
+			ring.events, ring.start = ring.list(), 0
+		}
+		ring.events = append(ring.events, StatusEvent{})
+	default:
+		h.used -= eventBytes(ring.removeOldest())
+	}
+	ring.events[(ring.start+ring.count)%len(ring.events)] = event
+	ring.count++
+	h.used += eventBytes(event)
+	h.evict()
+}
+
+// evict removes the oldest events of all services until the budget is met
+func (h *statusHistory) evict() {
+	for h.used > h.budget {
+		var oldest *eventRing
+		for _, ring := range h.rings {
+			if ring.count > 0 && (oldest == nil || ring.events[ring.start].Time.Before(oldest.events[oldest.start].Time)) {
+				oldest = ring
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		h.used -= eventBytes(oldest.removeOldest())
+	}
+}
+
+// events returns the events of a service, oldest first
+func (h *statusHistory) events(serviceName string) []StatusEvent {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if ring, ok := h.rings[serviceName]; ok {
+		return ring.list()
+	}
+	return nil
+}
+
+// lastOutage returns the service whose outage began last and when, or false
+// if no service turned critical. Audited actions are skipped.
+func (h *statusHistory) lastOutage() (string, time.Time, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	var name string
+	var start time.Time
+	for serviceName, ring := range h.rings {
+		critical := false
+		for _, event := range ring.list() {
+			if event.User != "" {
+				continue
+			}
+			if event.State == StatusCritical && !critical && event.Time.After(start) {
+				name, start = serviceName, event.Time
+			}
+			critical = event.State == StatusCritical
+		}
+	}
+	return name, start, !start.IsZero()
+}
+
+// list returns a copy of the events, oldest first
+func (r *eventRing) list() []StatusEvent {
+	events := make([]StatusEvent, r.count)
+	for i := range events {
+		events[i] = r.events[(r.start+i)%len(r.events)]
+	}
+	return events
+}
+
+// removeOldest removes and returns the oldest event
+func (r *eventRing) removeOldest() StatusEvent {
+	event := r.events[r.start]
+	r.events[r.start] = StatusEvent{}
+	r.start = (r.start + 1) % len(r.events)
+	r.count--
+	return event
+}
+
+// eventBytes estimates the memory used by an event
+func eventBytes(event StatusEvent) int {
+	return statusEventSize + len(event.State) + len(event.Message) + len(event.User)
+}
+
+// UptimeBar is the state of a service during one period of an uptime bar
+type UptimeBar struct {
+	Start time.Time
+	End   time.Time
+	State StatusState // Worst state during the period, unknown without data
+}
+
+// Uptime splits the time between start and end into bars of equal periods
+// and returns the worst state of each bar, from the status changes of a
+// service, oldest first. It also returns the percentage of the time with a
+// known state the service was up, warnings counting as up, or -1 if no state
+// is known in the period. The state before the first change is unknown.
+func Uptime(events []StatusEvent, start, end time.Time, bars int) ([]UptimeBar, float64) {
+	if bars <= 0 || !end.After(start) {
+		return nil, -1
+	}
+
+	result := make([]UptimeBar, bars)
+	period := end.Sub(start) / time.Duration(bars)
+	for i := range result {
+		result[i] = UptimeBar{Start: start.Add(time.Duration(i) * period), End: start.Add(time.Duration(i+1) * period), State: StatusUnknown}
+	}
+	result[bars-1].End = end
+
+	var up, known time.Duration
+	for i, event := range events {
+		from, to := event.Time, end
+		if i+1 < len(events) {
+			to = events[i+1].Time
+		}
+		from, to = maxTime(from, start), minTime(to, end)
+		if !to.After(from) || event.State == StatusUnknown {
+			continue
+		}
+
+		known += to.Sub(from)
+		if event.State != StatusCritical {
+			up += to.Sub(from)
+		}
+		for j := range result {
+			bar := &result[j]
+			if bar.End.After(from) && to.After(bar.Start) {
+				bar.State = worstState(bar.State, event.State)
+			}
+		}
+	}
+
+	if known == 0 {
+		return result, -1
+	}
+	return result, float64(up) / float64(known) * 100
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Outage is a period a service was in a critical state
+type Outage struct {
+	Start time.Time
+	End   time.Time // Zero if the outage goes on at the end of the period
+}
+
+// Availability summarizes the status changes of a service over a period
+type Availability struct {
+	Known   time.Duration // Time with a known state
+	Down    time.Duration // Time in a critical state
+	Outages []Outage      // Outages overlapping the period, oldest first
+}
+
+// ComputeAvailability summarizes the status changes of a service between
+// start and end, oldest first. Outages keep their whole duration, even when
+// they began before the period, and end at the first state that isn't critical.
+func ComputeAvailability(events []StatusEvent, start, end time.Time) Availability {
+	var result Availability
+	var outage *Outage
+	for i, event := range events {
+		if !event.Time.Before(end) {
+			break
+		}
+		to := end
+		if i+1 < len(events) {
+			to = minTime(events[i+1].Time, end)
+		}
+
+		switch {
+		case event.State == StatusCritical && outage == nil:
+			outage = &Outage{Start: event.Time}
+		case event.State != StatusCritical && outage != nil:
+			outage.End = event.Time
+			if outage.End.After(start) {
+				result.Outages = append(result.Outages, *outage)
+			}
+			outage = nil
+		}
+
+		from := maxTime(event.Time, start)
+		if !to.After(from) || event.State == StatusUnknown {
+			continue
+		}
+		result.Known += to.Sub(from)
+		if event.State == StatusCritical {
+			result.Down += to.Sub(from)
+		}
+	}
+	if outage != nil {
+		result.Outages = append(result.Outages, *outage)
+	}
+	return result
+}
+
+// Uptime returns the percentage of the known time the service was up, or -1
+// if no state is known
+func (a Availability) Uptime() float64 {
+	if a.Known == 0 {
+		return -1
+	}
+	return float64(a.Known-a.Down) / float64(a.Known) * 100
+}
+
+// MTTR returns the mean time to recovery of the outages that ended, or 0 if
+// none did
+func (a Availability) MTTR() time.Duration {
+	var total time.Duration
+	ended := 0
+	for _, outage := range a.Outages {
+		if !outage.End.IsZero() {
+			total += outage.End.Sub(outage.Start)
+			ended++
+		}
+	}
+	if ended == 0 {
+		return 0
+	}
+	return total / time.Duration(ended)
+}
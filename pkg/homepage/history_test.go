@@ -0,0 +1,177 @@
+package homepage
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(start time.Time, i int) StatusEvent {
+	return StatusEvent{Time: start.Add(time.Duration(i) * time.Minute), State: StatusOK, Message: strconv.Itoa(i)}
+}
+
+func historyMessages(events []StatusEvent) []string {
+	var messages []string
+	for _, event := range events {
+		messages = append(messages, event.Message)
+	}
+	return messages
+}
+
+func TestStatusHistoryRing(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	history := newStatusHistory()
+	history.setLimits(3, 0)
+
+	for i := range 5 {
+		history.add("NAS", testEvent(start, i))
+	}
+	assert.Equal(t, []string{"2", "3", "4"}, historyMessages(history.events("NAS")))
+	assert.Equal(t, 3*eventBytes(testEvent(start, 0)), history.used)
+	assert.Nil(t, history.events("Missing"))
+
+	// Shrinking keeps the newest events
+	history.setLimits(2, 0)
+	assert.Equal(t, []string{"3", "4"}, historyMessages(history.events("NAS")))
+	history.add("NAS", testEvent(start, 5))
+	assert.Equal(t, []string{"4", "5"}, historyMessages(history.events("NAS")))
+	assert.Equal(t, 2*eventBytes(testEvent(start, 0)), history.used)
+}
+
+func TestStatusHistoryBudget(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	history := newStatusHistory()
+	history.setLimits(10, 4*eventBytes(testEvent(start, 0)))
+
+	// The oldest events of any service are evicted
+	history.add("NAS", testEvent(start, 0))
+	history.add("Router", testEvent(start, 1))
+	history.add("NAS", testEvent(start, 2))
+	history.add("Router", testEvent(start, 3))
+	history.add("Router", testEvent(start, 4))
+	history.add("Router", testEvent(start, 5))
+	assert.Equal(t, []string{"2"}, historyMessages(history.events("NAS")))
+	assert.Equal(t, []string{"3", "4", "5"}, historyMessages(history.events("Router")))
+
+	// Adding to a service may evict the older events of another one
+	history.add("NAS", testEvent(start, 6))
+	assert.Equal(t, []string{"6"}, historyMessages(history.events("NAS")))
+	assert.Equal(t, []string{"3", "4", "5"}, historyMessages(history.events("Router")))
+	assert.Equal(t, 4*eventBytes(testEvent(start, 0)), history.used)
+}
+
+func TestStatusHistoryLongMessages(t *testing.T) {
+	history := newStatusHistory()
+	history.add("Script", StatusEvent{Message: strings.Repeat("é", maxHistoryMessage)})
+	events := history.events("Script")
+	require.Len(t, events, 1)
+	assert.Equal(t, strings.Repeat("é", maxHistoryMessage/2), events[0].Message)
+}
+
+func TestStatusMonitorHistory(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(nil)
+	sm.SetClock(clock)
+	defer sm.Stop()
+
+	sm.AddService(&Service{Name: "Static", Status: "ok"})
+	sm.updateServiceStatus("Static", StatusOK, "")
+	clock.Advance(time.Minute)
+	sm.updateServiceStatus("Static", StatusCritical, "Down")
+	sm.updateServiceStatus("Static", StatusCritical, "Down")
+
+	// Only changes are recorded, and kept when the service is restarted
+	sm.RemoveService("Static")
+	events := sm.History("Static")
+	require.Len(t, events, 2)
+	assert.Equal(t, StatusOK, events[0].State)
+	assert.Equal(t, StatusEvent{Time: clock.Now(), State: StatusCritical, Message: "Down"}, events[1])
+}
+
+func TestLastIncident(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+
+	_, _, ok := sm.LastIncident()
+	assert.False(t, ok)
+
+	sm.history.add("NAS", StatusEvent{Time: start, State: StatusCritical})
+	sm.history.add("NAS", StatusEvent{Time: start.Add(time.Minute), State: StatusOK})
+	sm.history.add("Router", StatusEvent{Time: start.Add(2 * time.Minute), State: StatusCritical})
+	// Staying critical doesn't start a new incident, nor do audited actions
+	sm.history.add("Router", StatusEvent{Time: start.Add(3 * time.Minute), State: StatusCritical, Message: "Still down"})
+	sm.history.add("NAS", StatusEvent{Time: start.Add(4 * time.Minute), State: StatusCritical, User: "alice"})
+
+	name, since, ok := sm.LastIncident()
+	require.True(t, ok)
+	assert.Equal(t, "Router", name)
+	assert.Equal(t, start.Add(2*time.Minute), since)
+}
+
+func TestUptime(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []StatusEvent{
+		{Time: start.Add(-time.Hour), State: StatusOK},
+		{Time: start.Add(90 * time.Minute), State: StatusCritical},
+		{Time: start.Add(2 * time.Hour), State: StatusWarning},
+		{Time: start.Add(3 * time.Hour), State: StatusUnknown},
+	}
+
+	bars, uptime := Uptime(events, start, start.Add(4*time.Hour), 4)
+	require.Len(t, bars, 4)
+	var states []StatusState
+	for _, bar := range bars {
+		states = append(states, bar.State)
+	}
+	assert.Equal(t, []StatusState{StatusOK, StatusCritical, StatusWarning, StatusUnknown}, states)
+	assert.Equal(t, start.Add(3*time.Hour), bars[3].Start)
+	assert.Equal(t, start.Add(4*time.Hour), bars[3].End)
+	// Down for 30 minutes of the 3 hours with a known state
+	assert.InDelta(t, 100*5.0/6, uptime, 0.001)
+
+	bars, uptime = Uptime(events[:1], start.Add(-3*time.Hour), start.Add(-2*time.Hour), 2)
+	assert.Equal(t, StatusUnknown, bars[0].State)
+	assert.Equal(t, -1.0, uptime)
+
+	bars, uptime = Uptime(nil, start, start, 2)
+	assert.Nil(t, bars)
+	assert.Equal(t, -1.0, uptime)
+}
+
+func TestComputeAvailability(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []StatusEvent{
+		{Time: start.Add(-time.Hour), State: StatusCritical},
+		{Time: start.Add(30 * time.Minute), State: StatusOK},
+		{Time: start.Add(2 * time.Hour), State: StatusCritical, Message: "timeout"},
+		// A new message doesn't start a new outage
+		{Time: start.Add(150 * time.Minute), State: StatusCritical, Message: "refused"},
+		{Time: start.Add(3 * time.Hour), State: StatusWarning},
+		{Time: start.Add(4 * time.Hour), State: StatusUnknown},
+		{Time: start.Add(5 * time.Hour), State: StatusCritical},
+	}
+
+	availability := ComputeAvailability(events, start, start.Add(6*time.Hour))
+	assert.Equal(t, 5*time.Hour, availability.Known)
+	assert.Equal(t, 150*time.Minute, availability.Down)
+	assert.InDelta(t, 50.0, availability.Uptime(), 0.001)
+	require.Len(t, availability.Outages, 3)
+	// The first outage began before the period and keeps its whole duration
+	assert.Equal(t, Outage{Start: start.Add(-time.Hour), End: start.Add(30 * time.Minute)}, availability.Outages[0])
+	assert.Equal(t, Outage{Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour)}, availability.Outages[1])
+	assert.True(t, availability.Outages[2].End.IsZero())
+	assert.Equal(t, 75*time.Minute, availability.MTTR())
+
+	// Outages that ended before the period are left out
+	availability = ComputeAvailability(events, start.Add(time.Hour), start.Add(2*time.Hour))
+	assert.Empty(t, availability.Outages)
+	assert.Equal(t, 100.0, availability.Uptime())
+	assert.Zero(t, availability.MTTR())
+
+	assert.Equal(t, -1.0, ComputeAvailability(nil, start, start.Add(time.Hour)).Uptime())
+}
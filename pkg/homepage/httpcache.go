@@ -0,0 +1,235 @@
+package homepage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// widgetHTTPCache is shared by all widget API requests, so widgets polling the
+// same API share its responses and its rate limit
+var widgetHTTPCache = newHTTPCache()
+
+// SetRateLimits sets the maximum number of widget API requests per hour to
+// each host, e.g. 60 for api.github.com
+func SetRateLimits(limits map[string]int) {
+	widgetHTTPCache.setLimits(limits)
+}
+
+// httpCache caches widget API responses and limits the rate of requests per
+// host. Responses are reused without a request while fresh according to their
+// Cache-Control max-age, then revalidated with If-None-Match and
+// If-Modified-Since, which most APIs answer without counting against quotas.
+// When a host's limit is reached, or the host asked to wait with Retry-After
+// or X-RateLimit headers, the last response is served instead.
+type httpCache struct {
+	mutex   sync.Mutex
+	entries map[string]*httpCacheEntry
+	limits  map[string]int         // Requests per hour by host
+	buckets map[string]*rateBucket // Requests left by host
+	blocked map[string]time.Time   // Hosts that asked to wait, until when
+	now     func() time.Time
+}
+
+// httpCacheEntry is a cached response body with its validators
+type httpCacheEntry struct {
+	body         []byte
+	etag         string
+	lastModified string
+	expires      time.Time // Reused without revalidation until then
+}
+
+// rateBucket is a token bucket holding up to an hour of requests
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newHTTPCache() *httpCache {
+	return &httpCache{
+		entries: make(map[string]*httpCacheEntry),
+		buckets: make(map[string]*rateBucket),
+		blocked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// setLimits replaces the rate limits, resetting the requests left
+func (hc *httpCache) setLimits(limits map[string]int) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	hc.limits = make(map[string]int)
+	for host, limit := range limits {
+		hc.limits[strings.ToLower(host)] = limit
+	}
+	hc.buckets = make(map[string]*rateBucket)
+}
+
+// do performs a GET request through the cache and returns the response body
+// and status code. Non-2xx responses are returned without being cached.
+func (hc *httpCache) do(client *http.Client, req *http.Request) ([]byte, int, error) {
+	key := httpCacheKey(req)
+	host := strings.ToLower(req.URL.Hostname())
+
+	hc.mutex.Lock()
+	now := hc.now()
+	entry := hc.entries[key]
+	if entry != nil && now.Before(entry.expires) {
+		hc.mutex.Unlock()
+		return entry.body, http.StatusOK, nil
+	}
+	if err := hc.take(host, now); err != nil {
+		hc.mutex.Unlock()
+		if entry != nil {
+			logging.Debug("Serving cached response of %s: %v", req.URL.Path, err)
+			return entry.body, http.StatusOK, nil
+		}
+		return nil, 0, err
+	}
+	hc.mutex.Unlock()
+
+	if entry != nil {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	now = hc.now()
+	hc.observe(host, resp, now)
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		entry.expires = now.Add(maxAge(resp.Header))
+		return entry.body, http.StatusOK, nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error reading response: %w", err)
+		}
+		if cacheable(resp.Header) {
+			hc.entries[key] = &httpCacheEntry{
+				body:         body,
+				etag:         resp.Header.Get("ETag"),
+				lastModified: resp.Header.Get("Last-Modified"),
+				expires:      now.Add(maxAge(resp.Header)),
+			}
+		}
+		return body, resp.StatusCode, nil
+	case entry != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable):
+		logging.Debug("Serving cached response of %s: HTTP %d", req.URL.Path, resp.StatusCode)
+		return entry.body, http.StatusOK, nil
+	}
+	return nil, resp.StatusCode, nil
+}
+
+// take counts a request to a host against its limit, returning an error if it
+// must not be sent
+func (hc *httpCache) take(host string, now time.Time) error {
+	if until, ok := hc.blocked[host]; ok {
+		if now.Before(until) {
+			return fmt.Errorf("%s asked to wait until %s", host, until.Local().Format("15:04:05"))
+		}
+		delete(hc.blocked, host)
+	}
+
+	limit := hc.limits[host]
+	if limit <= 0 {
+		return nil
+	}
+	bucket, ok := hc.buckets[host]
+	if !ok {
+		bucket = &rateBucket{tokens: float64(limit), last: now}
+		hc.buckets[host] = bucket
+	}
+	bucket.tokens = min(float64(limit), bucket.tokens+now.Sub(bucket.last).Hours()*float64(limit))
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return fmt.Errorf("rate limit of %d requests per hour to %s reached", limit, host)
+	}
+	bucket.tokens--
+	return nil
+}
+
+// observe blocks a host when its response asks to wait, with Retry-After on
+// 429 and 503 responses or with an exhausted X-RateLimit quota
+func (hc *httpCache) observe(host string, resp *http.Response, now time.Time) {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			if seconds, err := strconv.Atoi(retryAfter); err == nil {
+				hc.blocked[host] = now.Add(time.Duration(seconds) * time.Second)
+			} else if until, err := http.ParseTime(retryAfter); err == nil {
+				hc.blocked[host] = until
+			}
+			return
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			hc.blocked[host] = time.Unix(reset, 0)
+		}
+	}
+}
+
+// httpCacheKey identifies a request by its URL and headers, which include
+// credentials, so responses are only reused for identical requests
+func httpCacheKey(req *http.Request) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, req.URL)
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s: %s\n", name, strings.Join(req.Header[name], ", "))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// maxAge returns the time a response may be reused without revalidation,
+// from its Cache-Control header
+func maxAge(header http.Header) time.Duration {
+	var age time.Duration
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache":
+			return 0
+		case "max-age":
+			if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+				age = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return age
+}
+
+// cacheable reports whether a response may be stored
+func cacheable(header http.Header) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return false
+		}
+	}
+	return true
+}
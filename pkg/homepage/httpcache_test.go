@@ -0,0 +1,136 @@
+package homepage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCache returns a cache whose clock is advanced by the test
+func testCache() (*httpCache, *time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache := newHTTPCache()
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func cacheGet(t *testing.T, cache *httpCache, url string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	return cache.do(http.DefaultClient, req)
+}
+
+func TestHTTPCacheRevalidation(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"version":1}`))
+	}))
+	defer server.Close()
+
+	cache, now := testCache()
+	body, status, err := cacheGet(t, cache, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"version":1}`, string(body))
+
+	// Fresh responses are reused without a request
+	*now = now.Add(30 * time.Second)
+	body, _, err = cacheGet(t, cache, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":1}`, string(body))
+	assert.Equal(t, int32(1), requests.Load())
+
+	// Stale ones are revalidated
+	*now = now.Add(time.Minute)
+	body, status, err = cacheGet(t, cache, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"version":1}`, string(body))
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+}
+
+func TestHTTPCacheRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(strconv.Itoa(int(requests.Load()))))
+	}))
+	defer server.Close()
+
+	cache, now := testCache()
+	cache.setLimits(map[string]int{"127.0.0.1": 2})
+
+	for _, want := range []string{"1", "2"} {
+		body, _, err := cacheGet(t, cache, server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(body))
+	}
+
+	// Over the limit, the last response is served
+	body, _, err := cacheGet(t, cache, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(body))
+	_, _, err = cacheGet(t, cache, server.URL+"/other")
+	assert.EqualError(t, err, "rate limit of 2 requests per hour to 127.0.0.1 reached")
+
+	// Requests are allowed again as the hour passes
+	*now = now.Add(30 * time.Minute)
+	body, _, err = cacheGet(t, cache, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(body))
+}
+
+func TestHTTPCacheRetryAfter(t *testing.T) {
+	var limited atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limited.Load() {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "10")
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	cache, now := testCache()
+	_, _, err := cacheGet(t, cache, server.URL)
+	require.NoError(t, err)
+
+	limited.Store(true)
+	body, status, err := cacheGet(t, cache, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", string(body))
+
+	_, _, err = cacheGet(t, cache, server.URL+"/other")
+	assert.ErrorContains(t, err, "127.0.0.1 asked to wait until")
+
+	limited.Store(false)
+	*now = now.Add(3 * time.Minute)
+	_, status, err = cacheGet(t, cache, server.URL+"/other")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestMaxAge(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, maxAge(http.Header{"Cache-Control": {"public, max-age=300"}}))
+	assert.Equal(t, time.Duration(0), maxAge(http.Header{"Cache-Control": {"max-age=300, no-cache"}}))
+	assert.Equal(t, time.Duration(0), maxAge(http.Header{}))
+	assert.False(t, cacheable(http.Header{"Cache-Control": {"private, no-store"}}))
+}
@@ -0,0 +1,142 @@
+package homepage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeTag tags a scalar naming a YAML file whose content replaces it, so
+// fragments such as a common set of bookmarks can be shared by dashboards
+const includeTag = "!include"
+
+// decodeYAML decodes the YAML of a configuration file into out after
+// resolving its !include tags
+func decodeYAML(filePath string, data []byte, out interface{}) error {
+	root, _, err := parseYAML(filePath, data)
+	if err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		// Empty file
+		return nil
+	}
+	return root.Decode(out)
+}
+
+// parseYAML parses the YAML of a configuration file and replaces the nodes
+// tagged !include with the content of the files they name, relative to the
+// including file. Included lists are spliced into the list they are an item
+// of. It also returns the file of each node from an included file.
+func parseYAML(filePath string, data []byte) (*yaml.Node, map[*yaml.Node]string, error) {
+	resolver := &includeResolver{origins: make(map[*yaml.Node]string)}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	root, err := resolver.parse(absPath, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, resolver.origins, nil
+}
+
+// includeResolver resolves the includes of a file and of the files it
+// includes
+type includeResolver struct {
+	stack   []string              // Files being included, to detect cycles
+	origins map[*yaml.Node]string // Files of the nodes of included files
+}
+
+// parse parses a file and resolves its includes
+func (r *includeResolver) parse(filePath string, data []byte) (*yaml.Node, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	r.stack = append(r.stack, filePath)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+	if _, err := r.resolve(&root, filepath.Dir(filePath)); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// resolve replaces node, if tagged !include, and the include nodes among its
+// descendants. It reports whether node was replaced.
+func (r *includeResolver) resolve(node *yaml.Node, dir string) (bool, error) {
+	if node.Kind == yaml.SequenceNode {
+		content := make([]*yaml.Node, 0, len(node.Content))
+		for _, item := range node.Content {
+			included, err := r.resolve(item, dir)
+			if err != nil {
+				return false, err
+			}
+			if included && item.Kind == yaml.SequenceNode {
+				content = append(content, item.Content...)
+				continue
+			}
+			content = append(content, item)
+		}
+		node.Content = content
+		return false, nil
+	}
+
+	for _, child := range node.Content {
+		if _, err := r.resolve(child, dir); err != nil {
+			return false, err
+		}
+	}
+	if node.Kind != yaml.ScalarNode || node.Tag != includeTag {
+		return false, nil
+	}
+
+	included, err := r.include(expandHome(node.Value), dir)
+	if err != nil {
+		return false, fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*node = *included
+	if file, ok := r.origins[included]; ok {
+		r.origins[node] = file
+	}
+	return true, nil
+}
+
+// include parses an included file and returns its root node
+func (r *includeResolver) include(path, dir string) (*yaml.Node, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if slices.Contains(r.stack, path) {
+		return nil, fmt.Errorf("%s includes itself", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to include %s: %w", path, err)
+	}
+	root, err := r.parse(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to include %s: %w", path, err)
+	}
+	if root.Kind == 0 {
+		// An empty file stands for null
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	setOrigin(root, path, r.origins)
+	return root.Content[0], nil
+}
+
+// setOrigin records the file of a node and its descendants, unless set by a
+// nested include
+func setOrigin(node *yaml.Node, path string, origins map[*yaml.Node]string) {
+	if _, ok := origins[node]; ok {
+		return
+	}
+	origins[node] = path
+	for _, child := range node.Content {
+		setOrigin(child, path, origins)
+	}
+}
@@ -0,0 +1,126 @@
+package homepage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadServicesIncludes(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared")
+	require.NoError(t, os.Mkdir(shared, 0755))
+	writeTestFile(t, filepath.Join(shared, "monitor.yaml"), []byte("siteMonitorInterval: 30\nsiteMonitorTimeout: 5\n"))
+	writeTestFile(t, filepath.Join(shared, "network.yaml"), []byte(`- Network:
+    - Router:
+        ping: 192.168.1.1
+    - !include switches.yaml
+`))
+	writeTestFile(t, filepath.Join(shared, "switches.yaml"), []byte(`- Core Switch:
+    ping: 192.168.1.2
+- Access Switch:
+    ping: 192.168.1.3
+`))
+	path := filepath.Join(dir, "services.yaml")
+	writeTestFile(t, path, []byte(`- Media:
+    - Plex:
+        <<: !include shared/monitor.yaml
+        siteMonitor: https://plex.lan
+- !include shared/network.yaml
+`))
+
+	groups, err := LoadServices(path)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	plex := groups[0].Services[0]
+	assert.Equal(t, Seconds(30), plex.SiteMonitorInterval)
+	assert.Equal(t, Seconds(5), plex.SiteMonitorTimeout)
+
+	// Included lists are spliced, with paths relative to the including file
+	assert.Equal(t, "Network", groups[1].Name)
+	var names []string
+	for _, service := range groups[1].Services {
+		names = append(names, service.Name)
+	}
+	assert.Equal(t, []string{"Router", "Core Switch", "Access Switch"}, names)
+}
+
+func TestLoadBookmarksIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "common.yaml"), []byte(`- Developer:
+    - Github:
+        - abbr: GH
+          href: https://github.com/
+`))
+	writeTestFile(t, filepath.Join(dir, "empty.yaml"), nil)
+	path := filepath.Join(dir, "bookmarks.yaml")
+	writeTestFile(t, path, []byte(`- !include common.yaml
+- Social:
+    - Reddit: !include empty.yaml
+    - Mastodon:
+        - href: https://mastodon.social
+`))
+
+	groups, err := LoadBookmarks(path)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "Developer", groups[0].Name)
+	assert.Equal(t, "https://github.com/", groups[0].Bookmarks[0].Href)
+
+	// An empty file is null
+	require.Len(t, groups[1].Bookmarks, 1)
+	assert.Equal(t, "Mastodon", groups[1].Bookmarks[0].Name)
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "services.yaml")
+	writeTestFile(t, path, []byte("- Media:\n    - !include media.yaml\n"))
+
+	_, err := LoadServices(path)
+	assert.ErrorContains(t, err, "line 2: failed to include "+filepath.Join(dir, "media.yaml"))
+
+	writeTestFile(t, filepath.Join(dir, "media.yaml"), []byte("- Plex: !include plex.yaml\n"))
+	writeTestFile(t, filepath.Join(dir, "plex.yaml"), []byte("href: !include media.yaml\n"))
+	_, err = LoadServices(path)
+	assert.ErrorContains(t, err, filepath.Join(dir, "media.yaml")+" includes itself")
+
+	writeTestFile(t, filepath.Join(dir, "plex.yaml"), []byte("href: [unclosed\n"))
+	_, err = LoadServices(path)
+	assert.ErrorContains(t, err, "failed to include "+filepath.Join(dir, "plex.yaml"))
+}
+
+func TestSettingsIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "remotes.yaml"), []byte("- name: site-b\n  url: http://site-b:8080\n"))
+	path := filepath.Join(dir, "settings.yaml")
+	writeTestFile(t, path, []byte("title: Home\nremotes: !include remotes.yaml\n"))
+
+	settings, err := LoadSettings(path)
+	require.NoError(t, err)
+	assert.Equal(t, []RemoteConfig{{Name: "site-b", URL: "http://site-b:8080"}}, settings.Remotes)
+}
+
+func TestCheckConfigKeysIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "monitor.yaml"), []byte("siteMonitorInterval: 30\nsiteMonitorTimout: 5\n"))
+	path := filepath.Join(dir, "services.yaml")
+	writeTestFile(t, path, []byte(`- Media:
+    - Plex:
+        <<: !include monitor.yaml
+        siteMonitor: https://plex.lan
+        icn: plex.png
+`))
+
+	// Keys are reported in the file they are written in
+	unknown := CheckConfigKeys(path)
+	require.Len(t, unknown, 2)
+	assert.Equal(t, filepath.Join(dir, "monitor.yaml"), unknown[0].File)
+	assert.Equal(t, 2, unknown[0].Line)
+	assert.Equal(t, path, unknown[1].File)
+	assert.Equal(t, 5, unknown[1].Line)
+}
@@ -0,0 +1,21 @@
+package homepage
+
+import "time"
+
+// ResponseTimeColors are the latency buckets of the response times shown in
+// the status lines, in increasing order of their thresholds
+type ResponseTimeColors []ResponseTimeColor
+
+// Color returns the color of the first bucket a response time is below, or ""
+// if there is none or the response time is unknown
+func (c ResponseTimeColors) Color(responseTime time.Duration) string {
+	if responseTime <= 0 {
+		return ""
+	}
+	for _, bucket := range c {
+		if bucket.Below <= 0 || responseTime < time.Duration(bucket.Below)*time.Millisecond {
+			return bucket.Color
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package homepage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseTimeColors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yaml")
+	writeTestFile(t, path, []byte(`status:
+  responseTimes:
+    - below: 100
+      color: green
+    - below: 500
+      color: yellow
+    - color: orange
+`))
+	settings, err := LoadSettings(path)
+	require.NoError(t, err)
+	colors := settings.Status.ResponseTimes
+
+	assert.Equal(t, "green", colors.Color(99*time.Millisecond))
+	assert.Equal(t, "yellow", colors.Color(100*time.Millisecond))
+	assert.Equal(t, "orange", colors.Color(2*time.Second))
+	assert.Empty(t, colors.Color(0))
+
+	// Response times above all thresholds keep the color of the status
+	assert.Empty(t, colors[:2].Color(time.Second))
+	assert.Empty(t, ResponseTimeColors(nil).Color(time.Second))
+}
@@ -0,0 +1,128 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// launchdCheck checks that a launchd job is loaded and healthy on macOS
+type launchdCheck struct {
+	label string
+}
+
+// newLaunchdCheck creates a launchd check for a service
+func newLaunchdCheck(service *Service) *launchdCheck {
+	return &launchdCheck{label: service.Launchd}
+}
+
+// launchdJob is the state of a job as printed by launchctl print
+type launchdJob struct {
+	state        string
+	path         string
+	pid          int
+	lastExitCode int
+	exited       bool
+}
+
+// launchdTargets returns the service targets looked up for a label: the label
+// itself if it names a domain (e.g. system/com.example.job), otherwise the
+// label in the GUI domain of the user and in the system domain
+func launchdTargets(label string, uid int) []string {
+	if strings.Contains(label, "/") {
+		return []string{label}
+	}
+	return []string{fmt.Sprintf("gui/%d/%s", uid, label), "system/" + label}
+}
+
+// run looks the job up with launchctl print and returns its status
+func (c *launchdCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	if runtime.GOOS != "darwin" {
+		return &StatusResult{State: StatusWarning, Message: fmt.Sprintf("launchd checks not supported on %s", runtime.GOOS)}
+	}
+
+	details := []StatusDetail{{Label: "Label", Value: c.label}}
+	for _, target := range launchdTargets(c.label, os.Getuid()) {
+		output, err := exec.CommandContext(ctx, "launchctl", "print", target).Output()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			// The job is not loaded in this domain
+			logging.Debug("launchd check for %s: %s not found: %s", serviceName, target, strings.TrimSpace(string(exitErr.Stderr)))
+			continue
+		} else if err != nil {
+			logging.Error("launchd check for %s: launchctl failed: %v", serviceName, err)
+			return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("launchctl failed: %v", err), Details: details}
+		}
+
+		details = append(details, StatusDetail{Label: "Target", Value: target})
+		return launchdStatus(parseLaunchdJob(string(output)), details)
+	}
+	return &StatusResult{State: StatusCritical, Message: "Not loaded", Details: details}
+}
+
+// parseLaunchdJob parses the top level properties of the output of launchctl
+// print. Nested blocks such as the environment are indented deeper and skipped.
+func parseLaunchdJob(output string) launchdJob {
+	var job launchdJob
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " = ")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "state":
+			job.state = value
+		case "path":
+			job.path = value
+		case "pid":
+			job.pid, _ = strconv.Atoi(value)
+		case "last exit code":
+			// e.g. "0", "78: EX_CONFIG" or "(never exited)"
+			code, _, _ := strings.Cut(value, ":")
+			if n, err := strconv.Atoi(strings.TrimSpace(code)); err == nil {
+				job.lastExitCode, job.exited = n, true
+			}
+		}
+	}
+	return job
+}
+
+// launchdStatus determines the status of a job. Jobs that are loaded but not
+// running, such as periodic or on-demand jobs, are fine unless their last run
+// failed.
+func launchdStatus(job launchdJob, details []StatusDetail) *StatusResult {
+	if job.state != "" {
+		details = append(details, StatusDetail{Label: "State", Value: job.state})
+	}
+	if job.pid > 0 {
+		details = append(details, StatusDetail{Label: "PID", Value: strconv.Itoa(job.pid)})
+	}
+	if job.exited {
+		details = append(details, StatusDetail{Label: "Last exit code", Value: strconv.Itoa(job.lastExitCode)})
+	}
+	if job.path != "" {
+		details = append(details, StatusDetail{Label: "Path", Value: job.path})
+	}
+
+	switch {
+	case job.state == "running":
+		return &StatusResult{State: StatusOK, Message: fmt.Sprintf("Running (PID %d)", job.pid), Details: details}
+	case job.exited && job.lastExitCode != 0:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Exited with code %d", job.lastExitCode), Details: details}
+	}
+	return &StatusResult{State: StatusOK, Message: "Loaded", Details: details}
+}
@@ -0,0 +1,50 @@
+package homepage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const launchctlPrintOutput = `gui/501/homebrew.mxcl.syncthing = {
+	active count = 1
+	path = /Users/me/Library/LaunchAgents/homebrew.mxcl.syncthing.plist
+	type = LaunchAgent
+	state = running
+
+	program = /opt/homebrew/opt/syncthing/bin/syncthing
+	environment = {
+		state = ignored
+		PATH = /usr/bin:/bin
+	}
+
+	pid = 4242
+	last exit code = (never exited)
+}
+`
+
+func TestLaunchdTargets(t *testing.T) {
+	assert.Equal(t, []string{"gui/501/com.example.job", "system/com.example.job"}, launchdTargets("com.example.job", 501))
+	assert.Equal(t, []string{"system/com.example.job"}, launchdTargets("system/com.example.job", 501))
+}
+
+func TestParseLaunchdJob(t *testing.T) {
+	job := parseLaunchdJob(launchctlPrintOutput)
+	assert.Equal(t, "running", job.state)
+	assert.Equal(t, 4242, job.pid)
+	assert.False(t, job.exited)
+	assert.Equal(t, "/Users/me/Library/LaunchAgents/homebrew.mxcl.syncthing.plist", job.path)
+
+	result := launchdStatus(job, nil)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Running (PID 4242)", result.Message)
+
+	job = parseLaunchdJob("system/com.example.backup = {\n\tstate = not running\n\tlast exit code = 78: EX_CONFIG\n}\n")
+	assert.Equal(t, 78, job.lastExitCode)
+	result = launchdStatus(job, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Exited with code 78", result.Message)
+
+	job = parseLaunchdJob("system/com.example.backup = {\n\tstate = not running\n\tlast exit code = 0\n}\n")
+	assert.Equal(t, StatusOK, launchdStatus(job, nil).State)
+}
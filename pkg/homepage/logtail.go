@@ -0,0 +1,192 @@
+package homepage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLogLines is the number of lines a log tail keeps by default
+	defaultLogLines = 100
+
+	// logTailInterval is how often a tailed log file is polled for new lines
+	logTailInterval = time.Second
+
+	// logTailLineBytes is the assumed average line length, used to read only
+	// the end of a log file when it is opened
+	logTailLineBytes = 256
+)
+
+// LogTail follows a log file like tail -f, keeping its last lines. The file is
+// polled, which works on any file system, and reopened from its start when it
+// is rotated or truncated.
+type LogTail struct {
+	path     string
+	maxLines int
+	onUpdate func()
+	stop     chan struct{}
+
+	mutex sync.Mutex
+	lines []string
+	err   error
+
+	// Owned by the polling goroutine
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial string // Last line read, until it is terminated
+}
+
+// NewLogTail creates a tail of the last maxLines lines of a file, calling
+// onUpdate from its own goroutine whenever the lines change
+func NewLogTail(path string, maxLines int, onUpdate func()) *LogTail {
+	if maxLines <= 0 {
+		maxLines = defaultLogLines
+	}
+	return &LogTail{path: expandHome(path), maxLines: maxLines, onUpdate: onUpdate}
+}
+
+// Start starts following the file
+func (t *LogTail) Start() {
+	t.stop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(logTailInterval)
+		defer ticker.Stop()
+		defer t.closeFile()
+
+		for {
+			if t.poll() && t.onUpdate != nil {
+				t.onUpdate()
+			}
+			select {
+			case <-ticker.C:
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops following the file
+func (t *LogTail) Stop() {
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+}
+
+// Lines returns the last lines of the file, or the error preventing it from
+// being read
+func (t *LogTail) Lines() ([]string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]string{}, t.lines...), t.err
+}
+
+// poll reads the lines appended since the last poll and reports whether the
+// lines or the error changed
+func (t *LogTail) poll() bool {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		t.closeFile()
+		return t.setError(err)
+	}
+
+	switch {
+	case t.file == nil:
+		// Start with the end of the file, skipping the partial first line
+		if err := t.open(info, max(0, info.Size()-int64(t.maxLines*logTailLineBytes))); err != nil {
+			return t.setError(err)
+		}
+	case !os.SameFile(info, t.info) || info.Size() < t.offset:
+		// Rotated or truncated, the whole new content is new
+		t.closeFile()
+		if err := t.open(info, 0); err != nil {
+			return t.setError(err)
+		}
+	}
+	t.info = info
+
+	if info.Size() == t.offset {
+		return t.setError(nil)
+	}
+	data, err := io.ReadAll(io.LimitReader(t.file, info.Size()-t.offset))
+	if err != nil {
+		return t.setError(err)
+	}
+	t.offset += int64(len(data))
+
+	lines := strings.Split(t.partial+string(data), "\n")
+	t.partial = lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+	if len(lines) == 0 {
+		return t.setError(nil)
+	}
+
+	t.mutex.Lock()
+	for _, line := range lines {
+		t.lines = append(t.lines, strings.TrimRight(line, "\r"))
+	}
+	if len(t.lines) > t.maxLines {
+		t.lines = append([]string{}, t.lines[len(t.lines)-t.maxLines:]...)
+	}
+	t.err = nil
+	t.mutex.Unlock()
+	return true
+}
+
+// open opens the file and seeks to offset. A line cut by a nonzero offset is
+// skipped.
+func (t *LogTail) open(info os.FileInfo, offset int64) error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	t.file, t.info, t.offset, t.partial = file, info, offset, ""
+
+	if offset > 0 {
+		// Read up to the first line break, found within the first lines
+		buf := make([]byte, logTailLineBytes)
+		for {
+			n, err := file.Read(buf)
+			if i := strings.IndexByte(string(buf[:n]), '\n'); i >= 0 {
+				t.offset += int64(i + 1)
+				_, err = file.Seek(t.offset, io.SeekStart)
+				return err
+			}
+			t.offset += int64(n)
+			if err != nil {
+				// No complete line yet
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// closeFile closes the followed file, if open
+func (t *LogTail) closeFile() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+// setError records the error reading the file and reports whether it changed
+func (t *LogTail) setError(err error) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if fmt.Sprint(err) == fmt.Sprint(t.err) {
+		return false
+	}
+	t.err = err
+	return true
+}
@@ -0,0 +1,78 @@
+package homepage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendFile(t *testing.T, path, text string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(text)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
+
+func TestLogTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	tail := NewLogTail(path, 3, nil)
+	defer tail.closeFile()
+
+	// Missing files are reported until they appear
+	assert.True(t, tail.poll())
+	_, err := tail.Lines()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.False(t, tail.poll())
+
+	appendFile(t, path, "one\ntwo\nthree\nfour\nfi")
+	assert.True(t, tail.poll())
+	lines, err := tail.Lines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"two", "three", "four"}, lines)
+	assert.False(t, tail.poll())
+
+	// The partial line is shown once it is terminated
+	appendFile(t, path, "ve\r\n")
+	assert.True(t, tail.poll())
+	lines, _ = tail.Lines()
+	assert.Equal(t, []string{"three", "four", "five"}, lines)
+
+	// A rotated file is read from its start
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendFile(t, path, "six\n")
+	assert.True(t, tail.poll())
+	lines, _ = tail.Lines()
+	assert.Equal(t, []string{"four", "five", "six"}, lines)
+
+	// So is a truncated one
+	require.NoError(t, os.Truncate(path, 0))
+	assert.False(t, tail.poll())
+	appendFile(t, path, "seven\n")
+	assert.True(t, tail.poll())
+	lines, _ = tail.Lines()
+	assert.Equal(t, []string{"five", "six", "seven"}, lines)
+}
+
+func TestLogTailLargeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var text strings.Builder
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintf(&text, "line %d\n", i)
+	}
+	appendFile(t, path, text.String())
+
+	// Only the end of the file is read, starting at a whole line
+	tail := NewLogTail(path, 2, nil)
+	defer tail.closeFile()
+	assert.True(t, tail.poll())
+	lines, err := tail.Lines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line 999", "line 1000"}, lines)
+	assert.Greater(t, tail.offset, int64(0))
+}
@@ -0,0 +1,298 @@
+package homepage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DefaultMDNSGroup is the group listing the services found with mDNS
+const DefaultMDNSGroup = "Discovered"
+
+// defaultMDNSServices are the service types browsed unless configured
+var defaultMDNSServices = []string{
+	"_http._tcp", "_https._tcp", "_ipp._tcp", "_printer._tcp", "_googlecast._tcp",
+	"_airplay._tcp", "_raop._tcp", "_smb._tcp", "_ssh._tcp", "_hap._tcp",
+}
+
+// mdnsLabels are the labels of well-known service types
+var mdnsLabels = map[string]string{
+	"_http._tcp":       "Web server",
+	"_https._tcp":      "Web server",
+	"_ipp._tcp":        "Printer",
+	"_ipps._tcp":       "Printer",
+	"_printer._tcp":    "Printer",
+	"_googlecast._tcp": "Cast device",
+	"_airplay._tcp":    "AirPlay device",
+	"_raop._tcp":       "AirPlay speaker",
+	"_smb._tcp":        "File share",
+	"_ssh._tcp":        "SSH server",
+	"_hap._tcp":        "HomeKit accessory",
+}
+
+// mdnsAddr is the multicast address mDNS queries are sent to
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// mdnsService is a service instance advertised on the local network
+type mdnsService struct {
+	Instance string // Instance name, e.g. "Office Printer"
+	Type     string // Service type, e.g. "_ipp._tcp"
+	Host     string // Host name, e.g. "printer.local", empty if not announced
+	IP       net.IP // IPv4 address of the host, nil if not announced
+	Port     int
+}
+
+// label returns a description of the kind of service
+func (s mdnsService) label() string {
+	return cmp.Or(mdnsLabels[s.Type], s.Type)
+}
+
+// address returns where the service is reachable, as IP or host and port
+func (s mdnsService) address() string {
+	host := s.Host
+	if s.IP != nil {
+		host = s.IP.String()
+	}
+	if host == "" {
+		return ""
+	}
+	return net.JoinHostPort(strings.TrimSuffix(host, "."), fmt.Sprint(s.Port))
+}
+
+// href returns the link of web servers, empty for other services
+func (s mdnsService) href() string {
+	address := s.address()
+	switch {
+	case address == "":
+		return ""
+	case s.Type == "_http._tcp":
+		return "http://" + address
+	case s.Type == "_https._tcp":
+		return "https://" + address
+	}
+	return ""
+}
+
+// StartMDNSDiscovery browses the local network with mDNS every interval and
+// lists the advertised services in a dynamic group. Services are kept once
+// found, turning unknown when they are no longer advertised.
+func (sm *StatusMonitor) StartMDNSDiscovery(settings MDNSSettings) {
+	if !settings.Enabled {
+		return
+	}
+	interval := time.Duration(cmp.Or(settings.Interval, 300)) * time.Second
+	timeout := time.Duration(cmp.Or(settings.Timeout, 3)) * time.Second
+	group := cmp.Or(settings.Group, DefaultMDNSGroup)
+	types := settings.Services
+	if len(types) == 0 {
+		types = defaultMDNSServices
+	}
+
+	stop := sm.addStopChannel("mdns:")
+	logging.Info("Browsing %d mDNS service types every %s", len(types), interval)
+
+	go func() {
+		ticker := sm.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		known := make(map[string]string) // Local names by instance and type
+		sm.browseMDNS(stop, group, types, timeout, known)
+		for {
+			select {
+			case <-ticker.C():
+				if !sm.Paused() {
+					sm.browseMDNS(stop, group, types, timeout, known)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// browseMDNS queries the service types once and updates the services found
+func (sm *StatusMonitor) browseMDNS(stop <-chan struct{}, group string, types []string, timeout time.Duration, known map[string]string) {
+	ctx, cancel := stopContext(stop, timeout+time.Second)
+	defer cancel()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		logging.Error("mDNS: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	services, err := queryMDNS(ctx, conn, mdnsAddr, types, timeout)
+	if err != nil {
+		logging.Error("mDNS: %v", err)
+		return
+	}
+	if isStopped(stop) {
+		return
+	}
+	sm.applyMDNSServices(group, services, known)
+}
+
+// applyMDNSServices adds the services that were not found before and
+// updates the status of all known ones
+func (sm *StatusMonitor) applyMDNSServices(group string, services []mdnsService, known map[string]string) {
+	seen := make(map[string]bool)
+	for _, found := range services {
+		key := found.Instance + "." + found.Type
+		name, ok := known[key]
+		if !ok {
+			// A device advertising several services, such as a printer with
+			// a web interface, is listed once per service
+			service := &Service{Name: found.Instance, Href: found.href(), Description: found.label()}
+			if !sm.addPassiveService(service) {
+				service.Name = fmt.Sprintf("%s (%s)", found.Instance, found.label())
+				if !sm.addPassiveService(service) {
+					continue
+				}
+			}
+			AddDynamicServiceGroup(group, service)
+			name = service.Name
+			known[key] = name
+		}
+		seen[name] = true
+
+		message := "Advertised"
+		if address := found.address(); address != "" {
+			message = "Advertised at " + address
+		}
+		sm.recordResult(name, &StatusResult{State: StatusOK, Message: message})
+	}
+
+	for _, name := range known {
+		if !seen[name] {
+			sm.updateServiceStatus(name, StatusUnknown, "No longer advertised")
+		}
+	}
+}
+
+// queryMDNS sends PTR queries for the service types to dst from conn, which
+// must not use port 5353 so that responders answer it directly, and collects
+// the services answered until the timeout
+func queryMDNS(ctx context.Context, conn net.PacketConn, dst net.Addr, types []string, timeout time.Duration) ([]mdnsService, error) {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	for _, serviceType := range types {
+		name, err := dnsmessage.NewName(serviceType + ".local.")
+		if err != nil {
+			return nil, fmt.Errorf("invalid service type %q: %w", serviceType, err)
+		}
+		if err := builder.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}); err != nil {
+			return nil, err
+		}
+	}
+	query, err := builder.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteTo(query, dst); err != nil {
+		return nil, fmt.Errorf("error sending query: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	records := newMDNSRecords()
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			// The deadline ends the collection of answers
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return nil, fmt.Errorf("error reading answers: %w", err)
+		}
+		records.add(buf[:n])
+	}
+	return records.services(types), nil
+}
+
+// mdnsRecords holds the records of the mDNS answers received
+type mdnsRecords struct {
+	instances map[string][]string // Instance names by service type name
+	srv       map[string]dnsmessage.SRVResource
+	a         map[string]net.IP
+}
+
+func newMDNSRecords() *mdnsRecords {
+	return &mdnsRecords{
+		instances: make(map[string][]string),
+		srv:       make(map[string]dnsmessage.SRVResource),
+		a:         make(map[string]net.IP),
+	}
+}
+
+// add parses an answer, ignoring malformed ones and unused records
+func (r *mdnsRecords) add(packet []byte) {
+	var parser dnsmessage.Parser
+	if _, err := parser.Start(packet); err != nil {
+		return
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return
+	}
+	answers, err := parser.AllAnswers()
+	if err != nil {
+		return
+	}
+	if err := parser.SkipAllAuthorities(); err != nil {
+		return
+	}
+	additionals, _ := parser.AllAdditionals()
+
+	for _, resource := range append(answers, additionals...) {
+		name := strings.ToLower(resource.Header.Name.String())
+		switch body := resource.Body.(type) {
+		case *dnsmessage.PTRResource:
+			instance := body.PTR.String()
+			if !slices.ContainsFunc(r.instances[name], func(known string) bool { return strings.EqualFold(known, instance) }) {
+				r.instances[name] = append(r.instances[name], instance)
+			}
+		case *dnsmessage.SRVResource:
+			r.srv[name] = *body
+		case *dnsmessage.AResource:
+			r.a[name] = net.IP(body.A[:])
+		}
+	}
+}
+
+// services returns the instances of the service types, with the address of
+// their host when it was announced
+func (r *mdnsRecords) services(types []string) []mdnsService {
+	var services []mdnsService
+	for _, serviceType := range types {
+		suffix := "." + serviceType + ".local."
+		for _, instance := range r.instances[strings.ToLower(serviceType+".local.")] {
+			service := mdnsService{
+				Instance: strings.TrimSuffix(instance, suffix),
+				Type:     serviceType,
+			}
+			if srv, ok := r.srv[strings.ToLower(instance)]; ok {
+				service.Host = srv.Target.String()
+				service.Port = int(srv.Port)
+				service.IP = r.a[strings.ToLower(service.Host)]
+			}
+			services = append(services, service)
+		}
+	}
+	return services
+}
@@ -0,0 +1,106 @@
+package homepage
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mdnsAnswer builds an mDNS answer announcing an instance of a service type
+// with its SRV and A records as additionals
+func mdnsAnswer(t *testing.T, instance, serviceType, host string, port uint16, ip [4]byte) []byte {
+	t.Helper()
+	header := func(name string, recordType dnsmessage.Type) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: recordType, Class: dnsmessage.ClassINET, TTL: 120}
+	}
+	instanceName := instance + "." + serviceType + ".local."
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+	builder.EnableCompression()
+	require.NoError(t, builder.StartAnswers())
+	require.NoError(t, builder.PTRResource(header(serviceType+".local.", dnsmessage.TypePTR),
+		dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(instanceName)}))
+	require.NoError(t, builder.StartAdditionals())
+	require.NoError(t, builder.SRVResource(header(instanceName, dnsmessage.TypeSRV),
+		dnsmessage.SRVResource{Target: dnsmessage.MustNewName(host), Port: port}))
+	require.NoError(t, builder.AResource(header(host, dnsmessage.TypeA), dnsmessage.AResource{A: ip}))
+	packet, err := builder.Finish()
+	require.NoError(t, err)
+	return packet
+}
+
+func TestQueryMDNS(t *testing.T) {
+	responder, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer responder.Close()
+
+	// Answer the query like two devices would, plus a malformed packet
+	questions := make(chan []dnsmessage.Question, 1)
+	go func() {
+		buf := make([]byte, 1500)
+		n, from, err := responder.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var parser dnsmessage.Parser
+		if _, err := parser.Start(buf[:n]); err == nil {
+			all, _ := parser.AllQuestions()
+			questions <- all
+		}
+		responder.WriteTo(mdnsAnswer(t, "Office Printer", "_ipp._tcp", "printer.local.", 631, [4]byte{192, 168, 1, 5}), from)
+		responder.WriteTo([]byte("garbage"), from)
+		responder.WriteTo(mdnsAnswer(t, "NAS", "_http._tcp", "nas.local.", 5000, [4]byte{192, 168, 1, 10}), from)
+	}()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	services, err := queryMDNS(context.Background(), conn, responder.LocalAddr(), []string{"_http._tcp", "_ipp._tcp"}, 300*time.Millisecond)
+	require.NoError(t, err)
+
+	asked := <-questions
+	require.Len(t, asked, 2)
+	assert.Equal(t, "_http._tcp.local.", asked[0].Name.String())
+	assert.Equal(t, dnsmessage.TypePTR, asked[0].Type)
+
+	require.Len(t, services, 2)
+	assert.Equal(t, "NAS", services[0].Instance)
+	assert.Equal(t, "http://192.168.1.10:5000", services[0].href())
+	assert.Equal(t, "Office Printer", services[1].Instance)
+	assert.Equal(t, "192.168.1.5:631", services[1].address())
+	assert.Equal(t, "Printer", services[1].label())
+	assert.Empty(t, services[1].href(), "Only web servers should be linked")
+}
+
+func TestApplyMDNSServices(t *testing.T) {
+	StoreCachedGroups(nil)
+	defer StoreCachedGroups(nil)
+
+	sm := NewStatusMonitor(nil)
+	known := make(map[string]string)
+	printer := mdnsService{Instance: "Office Printer", Type: "_ipp._tcp", IP: net.IPv4(192, 168, 1, 5), Port: 631}
+	web := mdnsService{Instance: "Office Printer", Type: "_http._tcp", IP: net.IPv4(192, 168, 1, 5), Port: 80}
+	sm.applyMDNSServices(DefaultMDNSGroup, []mdnsService{printer, web}, known)
+
+	assert.Equal(t, StatusOK, sm.GetStatus("Office Printer").State)
+	assert.Equal(t, "Advertised at 192.168.1.5:631", sm.GetStatus("Office Printer").Message)
+	assert.Equal(t, StatusOK, sm.GetStatus("Office Printer (Web server)").State, "A second service of a device should get its own name")
+
+	groups := GetCachedGroups()
+	require.Len(t, groups, 1)
+	assert.Equal(t, DefaultMDNSGroup, groups[0].Name)
+	assert.Len(t, groups[0].Services, 2)
+
+	// Services that are gone are kept with an unknown status
+	sm.applyMDNSServices(DefaultMDNSGroup, []mdnsService{printer}, known)
+	assert.Equal(t, StatusOK, sm.GetStatus("Office Printer").State)
+	assert.Equal(t, StatusUnknown, sm.GetStatus("Office Printer (Web server)").State)
+	assert.Equal(t, "No longer advertised", sm.GetStatus("Office Printer (Web server)").Message)
+	assert.Len(t, GetCachedGroups()[0].Services, 2)
+}
@@ -0,0 +1,169 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// mdadmCheck checks Linux software RAID arrays in /proc/mdstat
+type mdadmCheck struct {
+	array      string // Array name such as md0, or all
+	mdstatPath string
+}
+
+// newMdadmCheck creates an mdadm check for a service
+func newMdadmCheck(service *Service) *mdadmCheck {
+	return &mdadmCheck{array: strings.TrimPrefix(service.Mdadm, "/dev/"), mdstatPath: "/proc/mdstat"}
+}
+
+// mdArray is an array as described by /proc/mdstat
+type mdArray struct {
+	name     string
+	active   bool
+	level    string
+	devices  int      // Number of devices the array should have, 0 if unknown
+	working  int      // Number of working devices
+	failed   []string // Members marked faulty
+	action   string   // Running sync action, e.g. recovery, resync or check
+	progress string   // Progress of the action, e.g. "8.5%"
+	finish   string   // Estimated time left for the action, e.g. "79.0min"
+}
+
+var (
+	// mdDevicesPattern matches the device counts of an array, e.g. [3/2]
+	mdDevicesPattern = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	// mdActionPattern matches the progress line of a sync action, e.g.
+	// "[=>....]  recovery =  8.5% (83081344/976630272) finish=79.0min speed=188473K/sec"
+	mdActionPattern = regexp.MustCompile(`(\w+)\s*=\s*([\d.]+%)(?:.*finish=(\S+))?`)
+)
+
+// parseMdstat parses the arrays of /proc/mdstat
+func parseMdstat(data string) []*mdArray {
+	var arrays []*mdArray
+	var current *mdArray
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			current = nil
+			continue
+		}
+
+		// Array lines look like "md0 : active raid1 sdb1[1] sda1[0](F)"
+		if name, description, ok := strings.Cut(line, " : "); ok && !strings.HasPrefix(line, " ") && name != "Personalities" {
+			current = &mdArray{name: name}
+			arrays = append(arrays, current)
+			for i, field := range strings.Fields(description) {
+				switch {
+				case i == 0:
+					current.active = field == "active"
+				case strings.HasPrefix(field, "raid") || field == "linear" || field == "multipath":
+					current.level = field
+				case strings.HasSuffix(field, "(F)"):
+					member, _, _ := strings.Cut(field, "[")
+					current.failed = append(current.failed, member)
+				}
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if matches := mdDevicesPattern.FindStringSubmatch(line); matches != nil {
+			current.devices, _ = strconv.Atoi(matches[1])
+			current.working, _ = strconv.Atoi(matches[2])
+		}
+		if matches := mdActionPattern.FindStringSubmatch(line); matches != nil && strings.Contains(line, "finish=") {
+			current.action, current.progress, current.finish = matches[1], matches[2], matches[3]
+		} else if strings.Contains(line, "=DELAYED") || strings.Contains(line, "=PENDING") {
+			action, _, _ := strings.Cut(strings.TrimSpace(line), "=")
+			current.action, current.progress = action, "pending"
+		}
+	}
+	return arrays
+}
+
+// status determines the state of an array. A degraded array is critical
+// unless it is being rebuilt, and an initial resync leaves it without
+// redundancy until done.
+func (a *mdArray) status() (StatusState, string) {
+	var message string
+	state := StatusOK
+	switch {
+	case !a.active:
+		return StatusCritical, "inactive"
+	case a.devices > 0 && a.working < a.devices:
+		message = fmt.Sprintf("degraded [%d/%d]", a.working, a.devices)
+		state = StatusCritical
+		if a.action == "recovery" || a.action == "reshape" {
+			state = StatusWarning
+		}
+	case len(a.failed) > 0:
+		message = "failed " + strings.Join(a.failed, ", ")
+		state = StatusWarning
+	default:
+		message = "clean"
+		if a.action == "resync" {
+			state = StatusWarning
+		}
+	}
+
+	if a.action != "" {
+		message += fmt.Sprintf(", %s %s", a.action, a.progress)
+		if a.finish != "" {
+			message += " (finish in " + a.finish + ")"
+		}
+	}
+	return state, message
+}
+
+// run reads /proc/mdstat and returns the status of the array, or of all
+// arrays
+func (c *mdadmCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	if runtime.GOOS != "linux" {
+		return &StatusResult{State: StatusWarning, Message: fmt.Sprintf("mdadm checks not supported on %s", runtime.GOOS)}
+	}
+
+	data, err := os.ReadFile(c.mdstatPath)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Failed to read %s: %v", c.mdstatPath, err)}
+	}
+	return mdadmStatus(parseMdstat(string(data)), c.array)
+}
+
+// mdadmStatus returns the status of an array, or of all arrays
+func mdadmStatus(arrays []*mdArray, array string) *StatusResult {
+	result := &StatusResult{State: StatusOK}
+	var messages []string
+	for _, a := range arrays {
+		if array != "all" && a.name != array {
+			continue
+		}
+
+		state, message := a.status()
+		result.State = worstState(result.State, state)
+		result.Details = append(result.Details, StatusDetail{Label: a.name, Value: strings.TrimSpace(a.level + " " + message)})
+		if state != StatusOK || array != "all" {
+			messages = append(messages, fmt.Sprintf("%s %s", a.name, message))
+		}
+	}
+
+	switch {
+	case len(result.Details) == 0 && array == "all":
+		return &StatusResult{State: StatusUnknown, Message: "No arrays"}
+	case len(result.Details) == 0:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Array %s not found", array)}
+	case len(messages) == 0:
+		result.Message = "All arrays clean"
+	default:
+		result.Message = strings.Join(messages, "; ")
+	}
+	return result
+}
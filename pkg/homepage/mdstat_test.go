@@ -0,0 +1,59 @@
+package homepage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mdstat = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md0 : active raid1 sdb1[1] sda1[0]
+      976630464 blocks super 1.2 [2/2] [UU]
+      bitmap: 0/8 pages [0KB], 65536KB chunk
+
+md1 : active raid5 sdc1[3] sdd1[1] sde1[0]
+      1953260544 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+      [=>...................]  recovery =  8.5% (83081344/976630272) finish=79.0min speed=188473K/sec
+
+md2 : active raid1 sdg1[1](F) sdf1[0]
+      488253440 blocks super 1.2 [2/1] [U_]
+
+md3 : inactive sdh1[0](S)
+      976630464 blocks super 1.2
+
+unused devices: <none>
+`
+
+func TestParseMdstat(t *testing.T) {
+	arrays := parseMdstat(mdstat)
+	require.Len(t, arrays, 4)
+	assert.Equal(t, &mdArray{name: "md0", active: true, level: "raid1", devices: 2, working: 2}, arrays[0])
+	assert.Equal(t, &mdArray{name: "md1", active: true, level: "raid5", devices: 3, working: 2,
+		action: "recovery", progress: "8.5%", finish: "79.0min"}, arrays[1])
+	assert.Equal(t, []string{"sdg1"}, arrays[2].failed)
+	assert.False(t, arrays[3].active)
+}
+
+func TestMdadmStatus(t *testing.T) {
+	arrays := parseMdstat(mdstat)
+
+	result := mdadmStatus(arrays, "md0")
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "md0 clean", result.Message)
+
+	result = mdadmStatus(arrays, "md1")
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "md1 degraded [2/3], recovery 8.5% (finish in 79.0min)", result.Message)
+
+	assert.Equal(t, StatusCritical, mdadmStatus(arrays, "md2").State)
+	assert.Equal(t, StatusCritical, mdadmStatus(arrays, "md3").State)
+	assert.Equal(t, "Array md9 not found", mdadmStatus(arrays, "md9").Message)
+
+	result = mdadmStatus(arrays, "all")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Len(t, result.Details, 4)
+	assert.Equal(t, "md1 degraded [2/3], recovery 8.5% (finish in 79.0min); md2 degraded [1/2]; md3 inactive", result.Message)
+
+	assert.Equal(t, "All arrays clean", mdadmStatus(arrays[:1], "all").Message)
+}
@@ -0,0 +1,113 @@
+package homepage
+
+import (
+	"maps"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// MuteService silences a flapping service for a while: it is still checked,
+// but the dashboard doesn't draw attention to it when it turns critical. The
+// mute ends by itself after d, or at once for a zero d.
+func (sm *StatusMonitor) MuteService(serviceName string, d time.Duration) error {
+	if !sm.IsMonitored(serviceName) {
+		return ErrUnknownService
+	}
+	if d <= 0 {
+		sm.mutex.Lock()
+		_, muted := sm.mutes[serviceName]
+		delete(sm.mutes, serviceName)
+		sm.mutex.Unlock()
+		if muted {
+			logging.Info("Unmuted service %s", serviceName)
+			sm.notifyMuteChange(serviceName)
+		}
+		return nil
+	}
+	sm.muteUntil(serviceName, sm.clock.Now().Add(d))
+	logging.Info("Muted service %s for %s", serviceName, d)
+	sm.notifyMuteChange(serviceName)
+	return nil
+}
+
+// muteUntil mutes a service until the given time and schedules the end of
+// the mute
+func (sm *StatusMonitor) muteUntil(serviceName string, until time.Time) {
+	sm.mutex.Lock()
+	sm.mutes[serviceName] = until
+	sm.mutex.Unlock()
+
+	sm.clock.AfterFunc(until.Sub(sm.clock.Now()), func() {
+		sm.mutex.Lock()
+		// The service may have been muted again meanwhile
+		ended := sm.mutes[serviceName].Equal(until)
+		if ended {
+			delete(sm.mutes, serviceName)
+		}
+		sm.mutex.Unlock()
+		if ended {
+			logging.Info("Mute of service %s ended", serviceName)
+			sm.notifyMuteChange(serviceName)
+		}
+	})
+}
+
+// notifyMuteChange calls the update function with the unchanged status of a
+// service, so the UI shows whether it is muted
+func (sm *StatusMonitor) notifyMuteChange(serviceName string) {
+	if sm.updateFunc == nil {
+		return
+	}
+	result := sm.GetStatus(serviceName)
+	sm.updateFunc(serviceName, result.State, result.Message)
+}
+
+// MutedUntil returns when the mute of a service ends, or the zero time if it
+// isn't muted
+func (sm *StatusMonitor) MutedUntil(serviceName string) time.Time {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	if until, ok := sm.mutes[serviceName]; ok && until.After(sm.clock.Now()) {
+		return until
+	}
+	return time.Time{}
+}
+
+// IsMuted reports whether a service is muted
+func (sm *StatusMonitor) IsMuted(serviceName string) bool {
+	return !sm.MutedUntil(serviceName).IsZero()
+}
+
+// Mutes returns a copy of the ends of the mutes, by service name
+func (sm *StatusMonitor) Mutes() map[string]time.Time {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	mutes := maps.Clone(sm.mutes)
+	maps.DeleteFunc(mutes, func(_ string, until time.Time) bool { return !until.After(sm.clock.Now()) })
+	return mutes
+}
+
+// RestoreMutes sets the mutes saved by a previous run, skipping those that
+// ended meanwhile. Unlike MuteService, it accepts names that are not known
+// yet.
+func (sm *StatusMonitor) RestoreMutes(mutes map[string]time.Time) {
+	for name, until := range mutes {
+		if until.After(sm.clock.Now()) {
+			sm.muteUntil(name, until)
+		}
+	}
+}
+
+// applyConfiguredMute mutes a service for the muteFor duration of its
+// configuration, if any
+func (sm *StatusMonitor) applyConfiguredMute(service *Service) {
+	if service.MuteFor <= 0 {
+		return
+	}
+	// A longer mute set from the dashboard is kept
+	if until := sm.clock.Now().Add(service.MuteFor.Duration()); until.After(sm.MutedUntil(service.Name)) {
+		sm.muteUntil(service.Name, until)
+		logging.Info("Muted service %s for %s as configured", service.Name, service.MuteFor.Duration())
+	}
+}
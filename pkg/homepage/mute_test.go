@@ -0,0 +1,67 @@
+package homepage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMuteService(t *testing.T) {
+	var updates []string
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(func(serviceName string, state StatusState, message string) {
+		updates = append(updates, serviceName)
+	})
+	sm.SetClock(clock)
+	defer sm.Stop()
+	sm.AddService(&Service{Name: "NAS", Status: "ok"})
+	updates = nil
+
+	assert.ErrorIs(t, sm.MuteService("Missing", time.Hour), ErrUnknownService)
+	require.NoError(t, sm.MuteService("NAS", time.Hour))
+	assert.True(t, sm.IsMuted("NAS"))
+	assert.Equal(t, clock.Now().Add(time.Hour), sm.MutedUntil("NAS"))
+	assert.Equal(t, map[string]time.Time{"NAS": clock.Now().Add(time.Hour)}, sm.Mutes())
+
+	// Muting again replaces the previous end, whose timer does nothing
+	require.NoError(t, sm.MuteService("NAS", 2*time.Hour))
+	clock.Advance(time.Hour)
+	assert.True(t, sm.IsMuted("NAS"))
+
+	// The mute ends by itself, updating the UI
+	clock.Advance(time.Hour)
+	assert.False(t, sm.IsMuted("NAS"))
+	assert.Empty(t, sm.Mutes())
+	assert.Equal(t, []string{"NAS", "NAS", "NAS"}, updates)
+
+	require.NoError(t, sm.MuteService("NAS", time.Hour))
+	require.NoError(t, sm.MuteService("NAS", 0))
+	assert.False(t, sm.IsMuted("NAS"))
+
+	// Restored mutes may name services that are not loaded yet
+	sm.RestoreMutes(map[string]time.Time{"Later": clock.Now().Add(time.Minute), "Ended": clock.Now().Add(-time.Minute)})
+	assert.True(t, sm.IsMuted("Later"))
+	assert.False(t, sm.IsMuted("Ended"))
+}
+
+func TestConfiguredMute(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(nil)
+	sm.SetClock(clock)
+	defer sm.Stop()
+
+	sm.AddService(&Service{Name: "Flaky", Status: "ok", MuteFor: 1800})
+	assert.Equal(t, clock.Now().Add(30*time.Minute), sm.MutedUntil("Flaky"))
+
+	// Reloading an unchanged configuration doesn't extend the mute
+	clock.Advance(10 * time.Minute)
+	sm.UpdateService(&Service{Name: "Flaky", Status: "ok", MuteFor: 1800})
+	assert.Equal(t, clock.Now().Add(20*time.Minute), sm.MutedUntil("Flaky"))
+
+	// A longer mute set from the dashboard is kept when the setting changes
+	require.NoError(t, sm.MuteService("Flaky", 2*time.Hour))
+	sm.UpdateService(&Service{Name: "Flaky", Status: "ok", MuteFor: 3600})
+	assert.Equal(t, clock.Now().Add(2*time.Hour), sm.MutedUntil("Flaky"))
+}
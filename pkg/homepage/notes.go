@@ -0,0 +1,98 @@
+package homepage
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownGroup is returned for groups that are not shown
+var ErrUnknownGroup = errors.New("unknown group")
+
+// SetNoteFunc sets a function called after a note is set or cleared, e.g. to
+// save the notes. It is called from the goroutine setting the note.
+func (sm *StatusMonitor) SetNoteFunc(fn func()) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.noteFunc = fn
+}
+
+// SetServiceNote attaches a short note to a service, such as "migrating DB
+// until 18:00", shown under its status. An empty note clears it.
+func (sm *StatusMonitor) SetServiceNote(serviceName, note string) error {
+	if !sm.IsMonitored(serviceName) && findGroupOf(serviceName) == nil {
+		return ErrUnknownService
+	}
+	sm.setNote(sm.serviceNotes, serviceName, note)
+	return nil
+}
+
+// SetGroupNote attaches a short note to a group, shown above its services.
+// An empty note clears it.
+func (sm *StatusMonitor) SetGroupNote(groupName, note string) error {
+	if !slices.ContainsFunc(GetCachedGroups(), func(group *ServiceGroup) bool { return group.Name == groupName }) {
+		return ErrUnknownGroup
+	}
+	sm.setNote(sm.groupNotes, groupName, note)
+	return nil
+}
+
+// setNote sets or clears a note and calls the note function
+func (sm *StatusMonitor) setNote(notes map[string]string, name, note string) {
+	sm.mutex.Lock()
+	if note = strings.TrimSpace(note); note != "" {
+		notes[name] = note
+	} else {
+		delete(notes, name)
+	}
+	fn := sm.noteFunc
+	sm.mutex.Unlock()
+
+	if fn != nil {
+		fn()
+	}
+}
+
+// ServiceNote returns the note attached to a service, or "" if none
+func (sm *StatusMonitor) ServiceNote(serviceName string) string {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.serviceNotes[serviceName]
+}
+
+// GroupNote returns the note attached to a group, or "" if none
+func (sm *StatusMonitor) GroupNote(groupName string) string {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.groupNotes[groupName]
+}
+
+// Notes returns copies of the notes of services and groups, by name
+func (sm *StatusMonitor) Notes() (services, groups map[string]string) {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return maps.Clone(sm.serviceNotes), maps.Clone(sm.groupNotes)
+}
+
+// RestoreNotes sets the notes saved by a previous run. Unlike SetServiceNote
+// and SetGroupNote, it accepts names that are not known yet and doesn't call
+// the note function.
+func (sm *StatusMonitor) RestoreNotes(services, groups map[string]string) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	maps.Copy(sm.serviceNotes, services)
+	maps.Copy(sm.groupNotes, groups)
+}
+
+// findGroupOf returns the shown group of a service, or nil if it isn't shown
+func findGroupOf(serviceName string) *ServiceGroup {
+	for _, group := range GetCachedGroups() {
+		for _, service := range group.Services {
+			if service.Name == serviceName {
+				return group
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package homepage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotes(t *testing.T) {
+	StoreCachedGroups([]*ServiceGroup{{
+		Name:     "Apps",
+		Services: []*Service{{Name: "DB", Status: "ok"}, {Name: "Docs"}},
+	}})
+	defer StoreCachedGroups(nil)
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	sm.AddService(&Service{Name: "DB", Status: "ok"})
+
+	changes := 0
+	sm.SetNoteFunc(func() { changes++ })
+
+	require.NoError(t, sm.SetServiceNote("DB", "  migrating DB until 18:00 "))
+	// Services without a check can have notes too
+	require.NoError(t, sm.SetServiceNote("Docs", "moving to the wiki"))
+	require.NoError(t, sm.SetGroupNote("Apps", "maintenance window"))
+	assert.ErrorIs(t, sm.SetServiceNote("Missing", "note"), ErrUnknownService)
+	assert.ErrorIs(t, sm.SetGroupNote("Missing", "note"), ErrUnknownGroup)
+	assert.Equal(t, 3, changes)
+
+	assert.Equal(t, "migrating DB until 18:00", sm.ServiceNote("DB"))
+	snapshot := sm.Snapshot("site")
+	require.Len(t, snapshot.Groups, 1)
+	assert.Equal(t, "maintenance window", snapshot.Groups[0].Note)
+	assert.Equal(t, "migrating DB until 18:00", snapshot.Groups[0].Services[0].Note)
+
+	require.NoError(t, sm.SetServiceNote("Docs", " "))
+	services, groups := sm.Notes()
+	assert.Equal(t, map[string]string{"DB": "migrating DB until 18:00"}, services)
+	assert.Equal(t, map[string]string{"Apps": "maintenance window"}, groups)
+
+	// Restored notes may name services that are not loaded yet
+	sm.RestoreNotes(map[string]string{"Later": "restored"}, nil)
+	assert.Equal(t, "restored", sm.ServiceNote("Later"))
+	assert.Equal(t, 4, changes)
+}
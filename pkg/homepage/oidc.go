@@ -0,0 +1,238 @@
+package homepage
+
+import (
+	"cmp"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// oidcCheck fetches the discovery document of an OpenID Connect provider and
+// its signing keys, so a broken identity provider is noticed before every
+// application using it fails its logins
+type oidcCheck struct {
+	issuer     string
+	skipVerify bool
+	caFile     string
+	caDir      string
+	timeout    time.Duration
+}
+
+// oidcDiscovery is the part of a discovery document the check relies on
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jsonWebKey is a key of a JWKS document
+type jsonWebKey struct {
+	Kty string   `json:"kty"`
+	Kid string   `json:"kid"`
+	Use string   `json:"use"`
+	Alg string   `json:"alg"`
+	Crv string   `json:"crv"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	X   string   `json:"x"`
+	Y   string   `json:"y"`
+	X5c []string `json:"x5c"`
+}
+
+// newOIDCCheck creates an OIDC check for a service
+func newOIDCCheck(service *Service) *oidcCheck {
+	timeout := service.OIDCTimeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	return &oidcCheck{
+		issuer:     service.OIDC,
+		skipVerify: service.OIDCSkipVerify,
+		caFile:     service.CAFile,
+		caDir:      service.CADir,
+		timeout:    time.Duration(timeout) * time.Second,
+	}
+}
+
+// discoveryURL returns the URL of the discovery document of the issuer,
+// unless the configured URL already is one
+func (c *oidcCheck) discoveryURL() string {
+	if strings.HasSuffix(c.issuer, "/.well-known/openid-configuration") {
+		return c.issuer
+	}
+	return strings.TrimSuffix(c.issuer, "/") + "/.well-known/openid-configuration"
+}
+
+// run fetches the discovery document and the keys it points to
+func (c *oidcCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	tlsConfig, err := clientTLSConfig(c.skipVerify, c.caFile, c.caDir)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Error loading CA certificates: %v", err)}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialCached
+	transport.TLSClientConfig = tlsConfig
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: c.timeout, Transport: transport}
+
+	start := time.Now()
+	details := []StatusDetail{{Label: "Discovery", Value: c.discoveryURL()}}
+	var discovery oidcDiscovery
+	if err := fetchJSON(ctx, client, c.discoveryURL(), &discovery); err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Discovery failed: %v", err), Details: details}
+	}
+	discoveryTime := time.Since(start)
+	details = append(details, StatusDetail{Label: "Discovery time", Value: discoveryTime.Round(time.Microsecond).String()})
+
+	// Clients reject tokens whose issuer differs from the configured one,
+	// e.g. after a proxy changed the public URL of the provider
+	expected := strings.TrimSuffix(strings.TrimSuffix(c.issuer, "/.well-known/openid-configuration"), "/")
+	if strings.TrimSuffix(discovery.Issuer, "/") != expected {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Issuer is %q instead of %q", discovery.Issuer, expected), Details: details}
+	}
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"authorization_endpoint", discovery.AuthorizationEndpoint},
+		{"token_endpoint", discovery.TokenEndpoint},
+		{"jwks_uri", discovery.JWKSURI},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return &StatusResult{State: StatusCritical, Message: "Discovery document without " + strings.Join(missing, ", "), Details: details}
+	}
+
+	details = append(details, StatusDetail{Label: "JWKS", Value: discovery.JWKSURI})
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	jwksStart := time.Now()
+	if err := fetchJSON(ctx, client, discovery.JWKSURI, &jwks); err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("JWKS failed: %v", err), Details: details}
+	}
+	elapsed := time.Since(start)
+	details = append(details,
+		StatusDetail{Label: "JWKS time", Value: time.Since(jwksStart).Round(time.Microsecond).String()},
+		StatusDetail{Label: "Total", Value: elapsed.Round(time.Microsecond).String()},
+	)
+
+	var signing, invalid []string
+	var algorithms []string
+	for _, key := range jwks.Keys {
+		if key.Use == "enc" {
+			continue
+		}
+		name := cmp.Or(key.Kid, key.Kty)
+		if err := key.validate(); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s (%v)", name, err))
+			continue
+		}
+		signing = append(signing, name)
+		if alg := cmp.Or(key.Alg, key.Kty); !slices.Contains(algorithms, alg) {
+			algorithms = append(algorithms, alg)
+		}
+	}
+	if len(signing) > 0 {
+		details = append(details, StatusDetail{Label: "Signing keys", Value: strings.Join(signing, ", ")})
+	}
+	if len(invalid) > 0 {
+		details = append(details, StatusDetail{Label: "Invalid keys", Value: strings.Join(invalid, ", ")})
+	}
+
+	switch {
+	case len(signing) == 0:
+		return &StatusResult{State: StatusCritical, Message: "No usable signing keys", ResponseTime: elapsed, Details: details}
+	case len(invalid) > 0:
+		return &StatusResult{State: StatusWarning, Message: fmt.Sprintf("%d of %d signing keys invalid", len(invalid), len(signing)+len(invalid)), ResponseTime: elapsed, Details: details}
+	}
+	return &StatusResult{
+		State:        StatusOK,
+		Message:      fmt.Sprintf("%d signing keys, %s (%s)", len(signing), strings.Join(algorithms, ", "), elapsed.Round(time.Millisecond)),
+		ResponseTime: elapsed,
+		Details:      details,
+	}
+}
+
+// validate checks that the key material of a key decodes, as a provider
+// publishing truncated or empty keys fails every token validation
+func (k *jsonWebKey) validate() error {
+	decode := func(name, value string) ([]byte, error) {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+		if err != nil || len(data) == 0 {
+			return nil, fmt.Errorf("invalid %s", name)
+		}
+		return data, nil
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode("n", k.N)
+		if err != nil {
+			return err
+		}
+		if _, err := decode("e", k.E); err != nil {
+			return err
+		}
+		if bits := new(big.Int).SetBytes(n).BitLen(); bits < 2048 {
+			return fmt.Errorf("%d-bit RSA key", bits)
+		}
+	case "EC":
+		for _, value := range [][2]string{{"x", k.X}, {"y", k.Y}} {
+			if _, err := decode(value[0], value[1]); err != nil {
+				return err
+			}
+		}
+	case "OKP":
+		if _, err := decode("x", k.X); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+
+	if len(k.X5c) > 0 {
+		der, err := base64.StdEncoding.DecodeString(k.X5c[0])
+		if err != nil {
+			return fmt.Errorf("invalid x5c")
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return fmt.Errorf("invalid x5c")
+		}
+		if time.Now().After(cert.NotAfter) {
+			return fmt.Errorf("certificate expired %s", cert.NotAfter.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+// fetchJSON gets a URL and decodes its JSON body into value
+func fetchJSON(ctx context.Context, client *http.Client, url string, value any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package homepage
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveFakeOIDC serves a discovery document and the keys returned by keys
+func serveFakeOIDC(t *testing.T, issuer func(url string) string, keys func() []map[string]any) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/realms/main/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 issuer(server.URL + "/realms/main"),
+				"authorization_endpoint": server.URL + "/realms/main/auth",
+				"token_endpoint":         server.URL + "/realms/main/token",
+				"jwks_uri":               server.URL + "/realms/main/certs",
+			})
+		case "/realms/main/certs":
+			json.NewEncoder(w).Encode(map[string]any{"keys": keys()})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOIDCCheck(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	encode := base64.RawURLEncoding.EncodeToString
+	validKeys := []map[string]any{
+		{"kty": "RSA", "kid": "rsa1", "use": "sig", "alg": "RS256", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "OKP", "kid": "ed1", "crv": "Ed25519", "alg": "EdDSA", "x": encode(edKey)},
+		{"kty": "RSA", "kid": "enc1", "use": "enc", "n": "", "e": ""},
+	}
+	keys := validKeys
+	sameIssuer := func(url string) string { return url }
+	server := serveFakeOIDC(t, sameIssuer, func() []map[string]any { return keys })
+
+	result := newOIDCCheck(&Service{OIDC: server.URL + "/realms/main"}).run(context.Background(), "SSO")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "2 signing keys, RS256, EdDSA ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Signing keys", Value: "rsa1, ed1"})
+
+	// The discovery URL itself is accepted too
+	result = newOIDCCheck(&Service{OIDC: server.URL + "/realms/main/.well-known/openid-configuration"}).run(context.Background(), "SSO")
+	assert.Equal(t, StatusOK, result.State)
+
+	keys = append(validKeys, map[string]any{"kty": "RSA", "kid": "short", "n": encode(big.NewInt(65537).Bytes()), "e": "AQAB"})
+	result = newOIDCCheck(&Service{OIDC: server.URL + "/realms/main"}).run(context.Background(), "SSO")
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "1 of 3 signing keys invalid", result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Invalid keys", Value: "short (17-bit RSA key)"})
+
+	keys = nil
+	result = newOIDCCheck(&Service{OIDC: server.URL + "/realms/main"}).run(context.Background(), "SSO")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "No usable signing keys", result.Message)
+
+	result = newOIDCCheck(&Service{OIDC: server.URL + "/realms/other"}).run(context.Background(), "SSO")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Discovery failed: HTTP 404", result.Message)
+}
+
+func TestOIDCCheckIssuerMismatch(t *testing.T) {
+	server := serveFakeOIDC(t, func(string) string { return "https://sso.example.com/realms/main" }, func() []map[string]any { return nil })
+
+	result := newOIDCCheck(&Service{OIDC: server.URL + "/realms/main/"}).run(context.Background(), "SSO")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, `Issuer is "https://sso.example.com/realms/main" instead of "`+server.URL+`/realms/main"`, result.Message)
+}
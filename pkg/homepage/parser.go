@@ -1,8 +1,12 @@
 package homepage
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/deblasis/termhome/pkg/logging"
 	"gopkg.in/yaml.v3"
@@ -30,13 +34,13 @@ func LoadSettings(filePath string) (*Settings, error) {
 
 	// Attempt to handle the format with the initial dash separator
 	var settings Settings
-	err = yaml.Unmarshal(data, &settings)
+	err = decodeYAML(filePath, data, &settings)
 	if err != nil {
 		logging.Warn("Failed to unmarshal settings directly: %v", err)
 
 		// Try with a different approach - parse as a generic interface first
 		var rawData interface{}
-		if err := yaml.Unmarshal(data, &rawData); err != nil {
+		if err := decodeYAML(filePath, data, &rawData); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal settings file %s: %w", filePath, err)
 		}
 
@@ -74,6 +78,8 @@ func LoadSettings(filePath string) (*Settings, error) {
 
 // LoadServices loads the service configurations from the specified YAML file.
 // It expects the format to be an array of groups, consistent with gethomepage.dev.
+// Values that can't be decoded are left out and returned as an
+// *InvalidValuesError along with the groups.
 // Example:
 // - Group1:
 //   - Service1:
@@ -94,201 +100,318 @@ func LoadServices(filePath string) ([]*ServiceGroup, error) {
 
 	logging.Debug("Loading services from %s (expecting array format)", filePath)
 
-	// Expect the format to be an array of maps, where each map represents a group.
-	var arrayFormat []map[string]interface{}
-	logging.Debug("Attempting to unmarshal service data into arrayFormat...")
-	err = yaml.Unmarshal(data, &arrayFormat)
-
+	// Services are decoded from the parsed nodes rather than generic values,
+	// so invalid values are reported at their own file and line
+	root, origins, err := parseYAML(filePath, data)
+	if err == nil {
+		err = checkServicesFormat(root)
+	}
 	if err != nil {
-		// If unmarshaling fails, it's likely not the expected format or invalid YAML.
-		logging.Error("Unmarshal into arrayFormat failed: %v", err)
+		// If parsing fails, it's likely not the expected format or invalid YAML.
+		logging.Error("Parsing services as array format failed: %v", err)
 		return nil, fmt.Errorf("failed to unmarshal services file %s as array format: %w. Ensure it starts with a '-' for each group", filePath, err)
 	}
 
-	logging.Debug("Successfully unmarshaled service data into arrayFormat.")
-
 	// Process the array format
 	logging.Debug("Parsing services file as array format (gethomepage style with dashes)")
+	decoder := &serviceDecoder{file: filePath, origins: origins}
+	groupEntries := sequenceItems(root)
 	var serviceGroups []*ServiceGroup
 
+	// Settings of the top-level defaults entry apply to all services
+	var defaults *yaml.Node
+	for _, groupEntry := range groupEntries {
+		if pairs := mappingPairs(groupEntry); len(pairs) == 1 && pairs[0][0].Value == serviceDefaultsKey && pairs[0][1].Kind == yaml.MappingNode {
+			defaults = mergeNodes(defaults, pairs[0][1])
+		}
+	}
+
 	// Process each group entry in the array
-	for i, groupEntry := range arrayFormat {
-		if len(groupEntry) != 1 {
+	for i, groupEntry := range groupEntries {
+		pairs := mappingPairs(groupEntry)
+		if len(pairs) != 1 {
 			logging.Warn("Service group entry at index %d does not have exactly one key, skipping.", i)
 			continue // Expecting map like {"Group Name": [services...]}
 		}
 
-		for groupName, groupData := range groupEntry {
-			// Convert the services within this group
-			services, err := convertServicesData(groupData) // Use existing helper
-			if err != nil {
-				logging.Warn("Error converting services for group '%s': %v", groupName, err)
-				continue // Skip group if services conversion fails
-			}
+		groupName, groupData := pairs[0][0].Value, pairs[0][1]
+		if isExtensionKey(groupName) {
+			continue
+		}
+		if groupData.Kind == yaml.MappingNode && groupName == serviceDefaultsKey {
+			continue
+		}
 
-			// Add the parsed group to the list
-			group := &ServiceGroup{
-				Name:     groupName,
-				Services: services,
-			}
-			serviceGroups = append(serviceGroups, group)
+		// Convert the services within this group
+		services, err := decoder.convertServicesData(groupData, defaults)
+		if err != nil {
+			logging.Warn("Error converting services for group '%s': %v", groupName, err)
+			continue // Skip group if services conversion fails
+		}
+
+		// Add the parsed group to the list
+		group := &ServiceGroup{
+			Name:     groupName,
+			Services: services,
 		}
+		serviceGroups = append(serviceGroups, group)
 	}
 
 	logging.Debug("Loaded %d service groups using array format", len(serviceGroups))
+	if len(decoder.invalid) > 0 {
+		return serviceGroups, &InvalidValuesError{Errors: decoder.invalid}
+	}
 	return serviceGroups, nil
 }
 
-// Helper function to convert group data to services
-func convertServicesData(groupData interface{}) ([]*Service, error) {
+// checkServicesFormat checks that the root node of services.yaml is a list of
+// maps, or empty
+func checkServicesFormat(root *yaml.Node) error {
+	node := resolveNode(root)
+	if node == nil || node.Kind == 0 || node.ShortTag() == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: expected a list of groups", node.Line)
+	}
+	for _, item := range sequenceItems(node) {
+		if item.Kind != yaml.MappingNode {
+			return fmt.Errorf("line %d: expected a group", item.Line)
+		}
+	}
+	return nil
+}
+
+// InvalidValuesError reports the values of a configuration file that can't be
+// decoded, such as a malformed duration, at their file and line. The values
+// are left out and the rest of the file is still usable, so the error is
+// returned along with what was loaded.
+type InvalidValuesError struct {
+	Errors []string
+}
+
+func (e *InvalidValuesError) Error() string {
+	return "invalid values:\n  " + strings.Join(e.Errors, "\n  ")
+}
+
+// isExtensionKey reports whether a top-level entry of services.yaml or
+// bookmarks.yaml is an extension rather than a group. Like the x- fields of
+// Docker Compose files, extensions hold YAML anchors merged into services or
+// bookmarks with <<, so common settings are written once.
+func isExtensionKey(key string) bool {
+	return strings.HasPrefix(key, "x-")
+}
+
+// serviceDefaultsKey names the entries of services.yaml whose settings are
+// merged into every service: a top-level entry for all groups, or an entry
+// among the services of a group for that group
+const serviceDefaultsKey = "defaults"
+
+// mergeNodes returns a mapping node with the properties of the mapping
+// override set on those of base. Mappings present in both, such as
+// siteMonitorHeaders, are merged too, while other values of override replace
+// those of base. The value nodes are kept, so they still report their line.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base == nil {
+		return override
+	}
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: override.Line, Column: override.Column}
+	index := make(map[string]int)
+	for _, pair := range slices.Concat(mergedPairs(base), mergedPairs(override)) {
+		key, value := pair[0], pair[1]
+		i, ok := index[key.Value]
+		if !ok {
+			index[key.Value] = len(merged.Content)
+			merged.Content = append(merged.Content, key, value)
+			continue
+		}
+		if previous := merged.Content[i+1]; previous.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			value = mergeNodes(previous, value)
+		}
+		merged.Content[i+1] = value
+	}
+	return merged
+}
+
+// mergedPairs returns the key and value nodes of a mapping node with the
+// mappings merged with << expanded. Like for YAML decoding, the mapping's own
+// keys win over merged ones, and earlier merged mappings over later ones.
+func mergedPairs(node *yaml.Node) [][2]*yaml.Node {
+	var own, merged [][2]*yaml.Node
+	for _, pair := range mappingPairs(node) {
+		if pair[0].Value != "<<" {
+			own = append(own, pair)
+			continue
+		}
+		sources := sequenceItems(pair[1])
+		if sources == nil {
+			sources = []*yaml.Node{pair[1]}
+		}
+		for _, source := range sources {
+			merged = append(merged, mergedPairs(source)...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	pairs := make([][2]*yaml.Node, 0, len(own)+len(merged))
+	for _, pair := range slices.Concat(own, merged) {
+		if !seen[pair[0].Value] {
+			seen[pair[0].Value] = true
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// serviceDecoder decodes the services of services.yaml from its nodes and
+// collects the values that can't be decoded
+type serviceDecoder struct {
+	file    string
+	origins map[*yaml.Node]string // Files of the nodes of included files
+	invalid []string              // Invalid values, as "file:line: message"
+}
+
+// convertServicesData decodes the services of a group, a list of single key
+// maps from service name to its properties, merging the global defaults and
+// those of the group into each of them
+func (d *serviceDecoder) convertServicesData(groupData, defaults *yaml.Node) ([]*Service, error) {
 	// The groupData is expected to be a list of service maps
-	servicesList, ok := groupData.([]interface{})
-	if !ok {
+	if groupData.Kind != yaml.SequenceNode {
 		return nil, fmt.Errorf("service group data is not a list")
 	}
 
+	// The group's defaults entries apply to all of its services
+	items := sequenceItems(groupData)
+	groupDefaults := defaults
+	for _, item := range items {
+		if pairs := mappingPairs(item); len(pairs) == 1 && pairs[0][0].Value == serviceDefaultsKey && pairs[0][1].Kind == yaml.MappingNode {
+			groupDefaults = mergeNodes(groupDefaults, pairs[0][1])
+		}
+	}
+
 	var services []*Service
 
-	for _, serviceEntry := range servicesList {
+	for _, serviceEntry := range items {
 		// Each service entry is a map with a single key (the service name)
-		serviceMap, ok := serviceEntry.(map[string]interface{})
-		if !ok {
+		if serviceEntry.Kind != yaml.MappingNode {
 			logging.Warn("Service entry is not a map, skipping")
 			continue
 		}
 
-		if len(serviceMap) != 1 {
-			logging.Warn("Service map does not have exactly one key (the name), skipping: %v", serviceMap)
+		pairs := mappingPairs(serviceEntry)
+		if len(pairs) != 1 {
+			logging.Warn("Service map at line %d does not have exactly one key (the name), skipping", serviceEntry.Line)
 			continue
 		}
 
-		for serviceName, serviceDataRaw := range serviceMap {
-			servicePropsMap, ok := serviceDataRaw.(map[string]interface{})
-			if !ok {
-				// Handle cases where the service data might just be a URL string (if applicable)
-				// For now, assume it must be a map based on standard format.
-				logging.Warn("Service data for '%s' is not a map, skipping", serviceName)
+		serviceName, serviceProps := pairs[0][0].Value, pairs[0][1]
+		if serviceProps.Kind != yaml.MappingNode {
+			// Handle cases where the service data might just be a URL string (if applicable)
+			// For now, assume it must be a map based on standard format.
+			logging.Warn("Service data for '%s' is not a map, skipping", serviceName)
+			continue
+		}
+		if serviceName == serviceDefaultsKey {
+			continue
+		}
+		if groupDefaults != nil {
+			serviceProps = mergeNodes(groupDefaults, serviceProps)
+		}
+
+		var service Service
+		if err := serviceProps.Decode(&service); err != nil {
+			var typeErr *yaml.TypeError
+			if !errors.As(err, &typeErr) {
+				logging.Warn("Failed to decode service '%s', skipping: %v", serviceName, err)
 				continue
 			}
+			// Values of the wrong type are left out, as the rest of the
+			// service is still usable
+			logging.Debug("Invalid settings for service '%s': %v", serviceName, err)
+			d.invalid = append(d.invalid, d.invalidValues(serviceProps)...)
+		}
+		service.Name = serviceName
+		if service.Widget != nil && service.Widget.Type == "" {
+			logging.Warn("Invalid widget configuration for service '%s': widget type not specified", serviceName)
+			service.Widget = nil
+		}
 
-			// --- Log the raw properties map for debugging ---
-			logging.Debug("Raw properties for service '%s': %#v", serviceName, servicePropsMap)
-
-			// Manually create and populate the Service struct
-			service := Service{Name: serviceName}
+		// --- Log the final parsed service struct ---
+		logging.Debug("Parsed service '%s': Ping='%s', SiteMonitor='%s', Status='%s'", serviceName, service.Ping, service.SiteMonitor, service.Status)
 
-			if href, ok := servicePropsMap["href"].(string); ok {
-				service.Href = href
-			}
-			if description, ok := servicePropsMap["description"].(string); ok {
-				service.Description = description
-			}
-			if icon, ok := servicePropsMap["icon"].(string); ok {
-				service.Icon = icon
-			}
-			if status, ok := servicePropsMap["status"].(string); ok {
-				service.Status = status
-			}
-			// --- Handle Ping (string) and related fields ---
-			if pingRaw, exists := servicePropsMap["ping"]; exists {
-				logging.Debug("Found 'ping' key for '%s', type: %T, value: %v", serviceName, pingRaw, pingRaw)
-				if ping, ok := pingRaw.(string); ok {
-					service.Ping = ping
-				}
-			}
-			if pingCountRaw, exists := servicePropsMap["pingCount"]; exists {
-				logging.Debug("Found 'pingCount' key for '%s', type: %T, value: %v", serviceName, pingCountRaw, pingCountRaw)
-				if pingCount, ok := pingCountRaw.(int); ok {
-					service.PingCount = pingCount
-				} else if pingCountFloat, ok := pingCountRaw.(float64); ok {
-					service.PingCount = int(pingCountFloat) // Handle potential float from YAML
-				}
-			}
-			if pingIntervalRaw, exists := servicePropsMap["pingInterval"]; exists {
-				logging.Debug("Found 'pingInterval' key for '%s', type: %T, value: %v", serviceName, pingIntervalRaw, pingIntervalRaw)
-				if pingInterval, ok := pingIntervalRaw.(int); ok {
-					service.PingInterval = pingInterval
-				} else if pingIntervalFloat, ok := pingIntervalRaw.(float64); ok {
-					service.PingInterval = int(pingIntervalFloat)
-				}
-			}
+		services = append(services, &service)
+	}
 
-			// --- Handle SiteMonitor (string) and related fields ---
-			if siteMonitorRaw, exists := servicePropsMap["siteMonitor"]; exists {
-				logging.Debug("Found 'siteMonitor' key for '%s', type: %T, value: %v", serviceName, siteMonitorRaw, siteMonitorRaw)
-				if siteMonitor, ok := siteMonitorRaw.(string); ok {
-					service.SiteMonitor = siteMonitor
-				}
-			}
-			if siteMonitorMethod, ok := servicePropsMap["siteMonitorMethod"].(string); ok {
-				service.SiteMonitorMethod = siteMonitorMethod
-			}
-			if siteMonitorTimeout, ok := servicePropsMap["siteMonitorTimeout"].(int); ok {
-				service.SiteMonitorTimeout = siteMonitorTimeout
-			} else if siteMonitorTimeoutFloat, ok := servicePropsMap["siteMonitorTimeout"].(float64); ok {
-				service.SiteMonitorTimeout = int(siteMonitorTimeoutFloat)
-			}
-			if siteMonitorInterval, ok := servicePropsMap["siteMonitorInterval"].(int); ok {
-				service.SiteMonitorInterval = siteMonitorInterval
-			} else if siteMonitorIntervalFloat, ok := servicePropsMap["siteMonitorInterval"].(float64); ok {
-				service.SiteMonitorInterval = int(siteMonitorIntervalFloat)
-			}
-			if codesRaw, ok := servicePropsMap["siteMonitorExpectedCodes"].([]interface{}); ok {
-				var codes []int
-				for _, codeRaw := range codesRaw {
-					if codeInt, okInt := codeRaw.(int); okInt {
-						codes = append(codes, codeInt)
-					} else if codeFloat, okFloat := codeRaw.(float64); okFloat {
-						codes = append(codes, int(codeFloat))
-					}
-				}
-				service.SiteMonitorExpectedCodes = codes
-			}
-			if headersRaw, ok := servicePropsMap["siteMonitorHeaders"].(map[interface{}]interface{}); ok {
-				headers := make(map[string]string)
-				for kRaw, vRaw := range headersRaw {
-					if kStr, okK := kRaw.(string); okK {
-						if vStr, okV := vRaw.(string); okV {
-							headers[kStr] = vStr
-						}
-					}
-				}
-				service.SiteMonitorHeaders = headers
-			}
-			if skipVerify, ok := servicePropsMap["siteMonitorSkipVerify"].(bool); ok {
-				service.SiteMonitorSkipVerify = skipVerify
-			}
+	return services, nil
+}
 
-			// --- Handle other fields ---
-			if disableStatus, ok := servicePropsMap["disableStatus"].(bool); ok {
-				service.DisableStatus = disableStatus
-			}
-			if server, ok := servicePropsMap["server"].(string); ok {
-				service.Server = server
-			}
-			if container, ok := servicePropsMap["container"].(string); ok {
-				service.Container = container
-			}
-			if showStats, ok := servicePropsMap["showStats"].(bool); ok {
-				service.ShowStats = showStats
-			}
-			if subtitleUrl, ok := servicePropsMap["subtitleUrl"].(string); ok {
-				service.SubtitleURL = subtitleUrl
+// invalidValues decodes the properties of a service one at a time, as they
+// may come from different files, and returns the errors of those that can't
+// be decoded prefixed with their file and line
+func (d *serviceDecoder) invalidValues(props *yaml.Node) []string {
+	// Merged defaults come first, so report in the order of the file
+	pairs := mappingPairs(props)
+	slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int {
+		return cmp.Or(strings.Compare(d.fileOf(a[1]), d.fileOf(b[1])), cmp.Compare(a[1].Line, b[1].Line))
+	})
+
+	var messages []string
+	for _, pair := range pairs {
+		single := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{pair[0], pair[1]}}
+		var typeErr *yaml.TypeError
+		if err := single.Decode(&Service{}); !errors.As(err, &typeErr) {
+			continue
+		}
+		file := d.fileOf(pair[1])
+		for _, message := range typeErr.Errors {
+			// Errors of values start with "line N: "
+			if position, ok := strings.CutPrefix(message, "line "); ok {
+				messages = append(messages, file+":"+position)
+			} else {
+				messages = append(messages, file+": "+message)
 			}
-			// statusStyle and widget might need more complex handling if populated here
-			// For now, we assume they are handled correctly by the struct tags if needed
-			// or need specific logic based on their structure.
-			// service.StatusStyle = ...
-			// service.Widget = ...
+		}
+	}
+	return messages
+}
 
-			// --- Log the final parsed service struct ---
-			logging.Debug("Parsed service '%s': Ping='%s', SiteMonitor='%s', Status='%s'", serviceName, service.Ping, service.SiteMonitor, service.Status)
+// fileOf returns the file a node was parsed from
+func (d *serviceDecoder) fileOf(node *yaml.Node) string {
+	if file, ok := d.origins[node]; ok {
+		return file
+	}
+	return d.file
+}
 
-			services = append(services, &service)
-		}
+// decodeProps decodes properties parsed into generic values, such as those
+// of bookmarks, into out
+func decodeProps(props interface{}, out interface{}) error {
+	var node yaml.Node
+	if err := node.Encode(props); err != nil {
+		return err
 	}
+	return node.Decode(out)
+}
 
-	return services, nil
+// UnmarshalYAML decodes the settings of a service, accepting hidden as an
+// alias of showOnlyWhenDown and order as an alias of weight
+func (s *Service) UnmarshalYAML(node *yaml.Node) error {
+	type plainService Service
+	err := node.Decode((*plainService)(s))
+
+	var aliases struct {
+		Hidden bool `yaml:"hidden"`
+		Order  *int `yaml:"order"`
+		Weight *int `yaml:"weight"`
+	}
+	if aliasErr := node.Decode(&aliases); aliasErr != nil && err == nil {
+		err = aliasErr
+	}
+	s.ShowOnlyWhenDown = s.ShowOnlyWhenDown || aliases.Hidden
+	if aliases.Order != nil && aliases.Weight == nil {
+		s.Weight = *aliases.Order
+	}
+	return err
 }
 
 // LoadBookmarks loads the bookmark configurations from the specified YAML file.
@@ -307,7 +430,7 @@ func LoadBookmarks(filePath string) ([]*BookmarkGroup, error) {
 	// Expect the format to be an array of maps, where each map represents a group.
 	var arrayFormat []map[string]interface{}
 	logging.Debug("Attempting to unmarshal bookmark data into arrayFormat...")
-	err = yaml.Unmarshal(data, &arrayFormat)
+	err = decodeYAML(filePath, data, &arrayFormat)
 
 	if err != nil {
 		// If unmarshaling fails, it's likely not the expected format or invalid YAML.
@@ -329,6 +452,10 @@ func LoadBookmarks(filePath string) ([]*BookmarkGroup, error) {
 		}
 
 		for groupName, groupData := range groupEntry {
+			if isExtensionKey(groupName) {
+				continue
+			}
+
 			// Convert the bookmarks within this group
 			bookmarks, err := convertBookmarksData(groupData) // Use helper
 			if err != nil {
@@ -373,45 +500,13 @@ func convertBookmarksData(groupData interface{}) ([]*Bookmark, error) {
 		}
 
 		for bookmarkName, bookmarkDataRaw := range bookmarkMap {
-			var bookmarkPropsMap map[string]interface{}
-
-			// Try parsing as nested list format first (gethomepage standard)
-			if bookmarkDataList, okList := bookmarkDataRaw.([]interface{}); okList {
-				if len(bookmarkDataList) > 0 {
-					if props, okMap := bookmarkDataList[0].(map[string]interface{}); okMap {
-						bookmarkPropsMap = props // Successfully parsed nested list format
-					} else {
-						logging.Warn("Bookmark properties item within list for '%s' is not a map, skipping", bookmarkName)
-						continue
-					}
-				} else {
-					logging.Warn("Bookmark data list for '%s' is empty, skipping", bookmarkName)
-					continue
-				}
-			} else if props, okMap := bookmarkDataRaw.(map[string]interface{}); okMap {
-				// If not a list, try parsing as simple map format
-				bookmarkPropsMap = props // Successfully parsed simple map format
-			} else {
-				// If it's neither format, log warning and skip
-				logging.Warn("Bookmark data for '%s' is not a recognized format (list[map] or map), skipping", bookmarkName)
+			if bookmarkDataRaw == nil {
+				logging.Warn("Bookmark '%s' has no settings, skipping", bookmarkName)
 				continue
 			}
-
-			// --- Proceed with bookmark creation using bookmarkPropsMap ---
-			bookmarkWithName := map[string]interface{}{"name": bookmarkName}
-			for k, v := range bookmarkPropsMap {
-				bookmarkWithName[k] = v
-			}
-
-			marshaledData, err := yaml.Marshal(bookmarkWithName)
-			if err != nil {
-				logging.Warn("Failed to marshal bookmark data for '%s': %v", bookmarkName, err)
-				continue
-			}
-
-			var bookmark Bookmark
-			if err = yaml.Unmarshal(marshaledData, &bookmark); err != nil {
-				logging.Warn("Failed to unmarshal bookmark '%s': %v", bookmarkName, err)
+			bookmark := Bookmark{Name: bookmarkName}
+			if err := decodeProps(bookmarkDataRaw, &bookmark); err != nil {
+				logging.Warn("Failed to decode bookmark '%s', skipping: %v", bookmarkName, err)
 				continue
 			}
 			bookmarks = append(bookmarks, &bookmark)
@@ -420,6 +515,34 @@ func convertBookmarksData(groupData interface{}) ([]*Bookmark, error) {
 	return bookmarks, nil
 }
 
+// UnmarshalYAML decodes the settings of a bookmark, written either as a map or,
+// as gethomepage does, as a list holding a map. It accepts order as an alias
+// of weight.
+func (b *Bookmark) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		if len(node.Content) == 0 {
+			return fmt.Errorf("line %d: bookmark settings list is empty", node.Line)
+		}
+		node = node.Content[0]
+	}
+
+	type plainBookmark Bookmark
+	if err := node.Decode((*plainBookmark)(b)); err != nil {
+		return err
+	}
+	var aliases struct {
+		Order  *int `yaml:"order"`
+		Weight *int `yaml:"weight"`
+	}
+	if err := node.Decode(&aliases); err != nil {
+		return err
+	}
+	if aliases.Order != nil && aliases.Weight == nil {
+		b.Weight = *aliases.Order
+	}
+	return nil
+}
+
 // LoadDockerConfig loads the docker configuration from the specified YAML file.
 func LoadDockerConfig(filePath string) (*DockerConfig, error) {
 	data, err := os.ReadFile(filePath)
@@ -432,7 +555,7 @@ func LoadDockerConfig(filePath string) (*DockerConfig, error) {
 	}
 
 	var dockerConfig map[string]*DockerConfig
-	err = yaml.Unmarshal(data, &dockerConfig)
+	err = decodeYAML(filePath, data, &dockerConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal Docker config file %s: %w", filePath, err)
 	}
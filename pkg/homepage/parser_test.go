@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 // TestLoadSettings_ValidFile checks if a valid settings.yaml file is parsed correctly.
@@ -33,7 +35,7 @@ status:
 	assert.Equal(t, "My Test Dashboard", settings.Title, "Parsed title does not match")
 	assert.Equal(t, "Test Description", settings.Description, "Parsed description does not match")
 	assert.Equal(t, "light", settings.Theme, "Parsed theme does not match")
-	assert.Equal(t, 30, settings.Status.CheckInterval, "Parsed checkInterval does not match")
+	assert.Equal(t, Seconds(30), settings.Status.CheckInterval, "Parsed checkInterval does not match")
 
 }
 
@@ -50,7 +52,7 @@ func TestLoadSettings_NonExistentFile(t *testing.T) {
 	// Check for default values
 	assert.Equal(t, "Termhome Dashboard", settings.Title, "Default title is incorrect")
 	assert.Equal(t, "dark", settings.Theme, "Default theme is incorrect")
-	assert.Equal(t, 60, settings.Status.CheckInterval, "Default checkInterval is incorrect")
+	assert.Equal(t, Seconds(60), settings.Status.CheckInterval, "Default checkInterval is incorrect")
 }
 
 // TestLoadSettings_InvalidYAML checks behavior with malformed YAML.
@@ -134,26 +136,17 @@ func TestLoadServices_TwoGroupsOneServiceEach(t *testing.T) {
 
 // TestConvertServicesData verifies the helper function for converting service data.
 func TestConvertServicesData(t *testing.T) {
-	groupData := []interface{}{
-		map[string]interface{}{ // Service One - No ping
-			"Service One": map[string]interface{}{ // Use map[string]interface{} for properties
-				"href":        "http://one.com",
-				"description": "Desc 1",
-			},
-		},
-		map[string]interface{}{ // Service Two - With string ping
-			"Service Two": map[string]interface{}{ // Use map[string]interface{} for properties
-				"href": "http://two.net",
-				"icon": "icon-two",
-				"ping": "two.net", // Ping is now just a string
-				// Optionally add other ping fields if needed for the test
-				// "pingCount": 3,
-				// "pingInterval": 30,
-			},
-		},
-	}
-
-	services, err := convertServicesData(groupData)
+	groupData := parseTestNode(t, `
+- Service One: # No ping
+    href: http://one.com
+    description: Desc 1
+- Service Two: # With string ping
+    href: http://two.net
+    icon: icon-two
+    ping: two.net # Ping is now just a string
+`)
+
+	services, err := (&serviceDecoder{}).convertServicesData(groupData, nil)
 
 	assert.NoError(t, err, "convertServicesData returned an error")
 	assert.NotNil(t, services, "convertServicesData returned nil services")
@@ -173,6 +166,254 @@ func TestConvertServicesData(t *testing.T) {
 	assert.Equal(t, "two.net", services[1].Ping) // Check the Ping string directly
 }
 
+// TestConvertServicesData_Widget verifies that widget blocks are parsed into a WidgetConfig.
+func TestConvertServicesData_Widget(t *testing.T) {
+	groupData := parseTestNode(t, `
+- Firewall:
+    href: https://opnsense.lan
+    widget:
+      type: opnsense
+      url: https://opnsense.lan
+      username: key
+      password: secret
+      wan: igb0
+`)
+
+	services, err := (&serviceDecoder{}).convertServicesData(groupData, nil)
+
+	assert.NoError(t, err, "convertServicesData returned an error")
+	assert.Len(t, services, 1, "Expected 1 service to be converted")
+	assert.NotNil(t, services[0].Widget, "Widget should be parsed")
+	if services[0].Widget != nil {
+		assert.Equal(t, "opnsense", services[0].Widget.Type)
+		assert.Equal(t, "https://opnsense.lan", services[0].Widget.URL)
+		assert.Equal(t, "key", services[0].Widget.Username)
+		assert.Equal(t, "secret", services[0].Widget.Password)
+		assert.Equal(t, "igb0", services[0].Widget.String("wan", "wan"), "Type-specific option should be kept")
+	}
+}
+
+// parseTestNode parses YAML into the node of its content
+func parseTestNode(t *testing.T, data string) *yaml.Node {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(data), &root))
+	return resolveNode(&root)
+}
+
+// TestLoadServices_TypedFields checks that every field of Service is decoded,
+// that aliases are accepted and that a value of the wrong type only loses
+// that value.
+func TestLoadServices_TypedFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	writeTestFile(t, path, []byte(`- Media:
+    - Plex:
+        order: 3
+        hidden: true
+        siteMonitor: https://plex.lan
+        siteMonitorExpectedCodes: [200, 401]
+        statusStyle:
+          down:
+            icon: X
+    - Jellyfin:
+        order: 3
+        weight: 1
+        pingCount: many
+        ping: jellyfin.lan
+        tags: [media, home]
+    - Emby:
+        widget:
+          url: https://emby.lan
+`))
+
+	// The invalid value is reported at its line, along with the services
+	groups, err := LoadServices(path)
+	var invalidValues *InvalidValuesError
+	require.ErrorAs(t, err, &invalidValues)
+	assert.Equal(t, []string{path + ":13: cannot unmarshal !!str `many` into int"}, invalidValues.Errors)
+	require.Len(t, groups[0].Services, 3)
+
+	plex := groups[0].Services[0]
+	assert.Equal(t, 3, plex.Weight)
+	assert.True(t, plex.ShowOnlyWhenDown)
+	assert.Equal(t, []int{200, 401}, plex.SiteMonitorExpectedCodes)
+	assert.Equal(t, "X", plex.StatusStyle["down"].Icon)
+
+	jellyfin := groups[0].Services[1]
+	assert.Equal(t, 1, jellyfin.Weight, "weight should win over order")
+	assert.Equal(t, 0, jellyfin.PingCount)
+	assert.Equal(t, "jellyfin.lan", jellyfin.Ping)
+	assert.Equal(t, []string{"media", "home"}, jellyfin.Tags)
+
+	// A widget without a type is dropped
+	assert.Nil(t, groups[0].Services[2].Widget)
+}
+
+// TestLoadBookmarks_Formats checks that bookmarks are read both as a map and
+// as a list holding a map.
+func TestLoadBookmarks_Formats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.yaml")
+	writeTestFile(t, path, []byte(`- Developer:
+    - Github:
+        - abbr: GH
+          href: https://github.com/
+          order: 2
+    - Gitea:
+        href: https://gitea.lan
+        name: Forge
+        tags: [work]
+    - Broken: https://broken.lan
+`))
+
+	groups, err := LoadBookmarks(path)
+	require.NoError(t, err)
+	require.Len(t, groups[0].Bookmarks, 2)
+	assert.Equal(t, "Github", groups[0].Bookmarks[0].Name)
+	assert.Equal(t, 2, groups[0].Bookmarks[0].Weight)
+	assert.Equal(t, "Forge", groups[0].Bookmarks[1].Name)
+	assert.Equal(t, "https://gitea.lan", groups[0].Bookmarks[1].Href)
+	assert.Equal(t, []string{"work"}, groups[0].Bookmarks[1].Tags)
+}
+
+// TestLoadServices_AnchorsAndMergeKeys checks that settings shared through
+// anchors, aliases and merge keys end up in each service.
+func TestLoadServices_AnchorsAndMergeKeys(t *testing.T) {
+	testContent := `
+- x-monitor: &monitor
+    siteMonitorInterval: 30
+    siteMonitorTimeout: 5
+    siteMonitorHeaders:
+      Authorization: Bearer secret
+- x-internal: &internal
+    siteMonitorSkipVerify: true
+    siteMonitorExpectedCodes: &codes [200, 401]
+
+- Media:
+    - Plex: &plex
+        <<: *monitor
+        siteMonitor: https://plex.lan
+    - Jellyfin:
+        <<: [*monitor, *internal]
+        siteMonitor: https://jellyfin.lan
+        siteMonitorTimeout: 10
+    - Emby:
+        <<: *plex
+        siteMonitorExpectedCodes: *codes
+`
+	tempFile := filepath.Join(t.TempDir(), "services.yaml")
+	assert.NoError(t, os.WriteFile(tempFile, []byte(testContent), 0644))
+
+	serviceGroups, err := LoadServices(tempFile)
+	assert.NoError(t, err)
+
+	// Extension entries holding the anchors aren't groups
+	assert.Len(t, serviceGroups, 1)
+	services := serviceGroups[0].Services
+	assert.Len(t, services, 3)
+
+	plex := services[0]
+	assert.Equal(t, "https://plex.lan", plex.SiteMonitor)
+	assert.Equal(t, Seconds(30), plex.SiteMonitorInterval)
+	assert.Equal(t, Seconds(5), plex.SiteMonitorTimeout)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer secret"}, plex.SiteMonitorHeaders)
+
+	// Keys of the service override merged ones
+	jellyfin := services[1]
+	assert.Equal(t, Seconds(10), jellyfin.SiteMonitorTimeout)
+	assert.Equal(t, Seconds(30), jellyfin.SiteMonitorInterval)
+	assert.True(t, jellyfin.SiteMonitorSkipVerify)
+	assert.Equal(t, []int{200, 401}, jellyfin.SiteMonitorExpectedCodes)
+
+	emby := services[2]
+	assert.Equal(t, "Emby", emby.Name)
+	assert.Equal(t, "https://plex.lan", emby.SiteMonitor)
+	assert.Equal(t, Seconds(30), emby.SiteMonitorInterval)
+	assert.Equal(t, []int{200, 401}, emby.SiteMonitorExpectedCodes)
+}
+
+// TestLoadBookmarks_MergeKeys checks that bookmarks merge shared settings.
+func TestLoadBookmarks_MergeKeys(t *testing.T) {
+	testContent := `
+- x-docs: &docs
+    icon: docs.png
+    description: Documentation
+
+- Developer:
+    - Go:
+        - <<: *docs
+          href: https://go.dev/doc
+    - Python:
+        <<: *docs
+        href: https://docs.python.org
+        description: Python documentation
+`
+	tempFile := filepath.Join(t.TempDir(), "bookmarks.yaml")
+	assert.NoError(t, os.WriteFile(tempFile, []byte(testContent), 0644))
+
+	bookmarkGroups, err := LoadBookmarks(tempFile)
+	assert.NoError(t, err)
+	assert.Len(t, bookmarkGroups, 1)
+	bookmarks := bookmarkGroups[0].Bookmarks
+	assert.Len(t, bookmarks, 2)
+	assert.Equal(t, Bookmark{Name: "Go", Href: "https://go.dev/doc", Icon: "docs.png", Description: "Documentation"}, *bookmarks[0])
+	assert.Equal(t, Bookmark{Name: "Python", Href: "https://docs.python.org", Icon: "docs.png", Description: "Python documentation"}, *bookmarks[1])
+}
+
+// TestLoadServices_Defaults checks that global and group defaults are merged
+// into each service, the service's own settings winning.
+func TestLoadServices_Defaults(t *testing.T) {
+	testContent := `
+- defaults:
+    siteMonitorTimeout: 5
+    siteMonitorExpectedCodes: [200]
+    siteMonitorHeaders:
+      User-Agent: termhome
+
+- Media:
+    - defaults:
+        siteMonitorInterval: 30
+        siteMonitorExpectedCodes: [200, 401]
+    - Plex:
+        siteMonitor: https://plex.lan
+        siteMonitorHeaders:
+          X-Plex-Token: secret
+    - Jellyfin:
+        siteMonitor: https://jellyfin.lan
+        siteMonitorTimeout: 10
+
+- Network:
+    - Router:
+        ping: 192.168.1.1
+`
+	tempFile := filepath.Join(t.TempDir(), "services.yaml")
+	assert.NoError(t, os.WriteFile(tempFile, []byte(testContent), 0644))
+
+	serviceGroups, err := LoadServices(tempFile)
+	assert.NoError(t, err)
+
+	// The defaults entries are neither groups nor services
+	assert.Len(t, serviceGroups, 2)
+	media := serviceGroups[0].Services
+	assert.Len(t, media, 2)
+
+	plex := media[0]
+	assert.Equal(t, Seconds(5), plex.SiteMonitorTimeout)
+	assert.Equal(t, Seconds(30), plex.SiteMonitorInterval)
+	assert.Equal(t, []int{200, 401}, plex.SiteMonitorExpectedCodes)
+	assert.Equal(t, map[string]string{"User-Agent": "termhome", "X-Plex-Token": "secret"}, plex.SiteMonitorHeaders)
+
+	jellyfin := media[1]
+	assert.Equal(t, Seconds(10), jellyfin.SiteMonitorTimeout)
+	assert.Equal(t, Seconds(30), jellyfin.SiteMonitorInterval)
+	assert.Equal(t, map[string]string{"User-Agent": "termhome"}, jellyfin.SiteMonitorHeaders)
+
+	// Group defaults don't leak into other groups
+	router := serviceGroups[1].Services[0]
+	assert.Equal(t, "192.168.1.1", router.Ping)
+	assert.Equal(t, Seconds(5), router.SiteMonitorTimeout)
+	assert.Equal(t, Seconds(0), router.SiteMonitorInterval)
+	assert.Equal(t, []int{200}, router.SiteMonitorExpectedCodes)
+}
+
 // TestConvertBookmarksData verifies the helper function for converting bookmark data.
 func TestConvertBookmarksData(t *testing.T) {
 	// This groupData MUST match the nested list structure expected by the corrected convertBookmarksData
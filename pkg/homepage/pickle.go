@@ -0,0 +1,313 @@
+package homepage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// The fail2ban socket speaks Python pickle. encodePickle and decodePickle
+// implement the subset needed to send commands and read their responses:
+// numbers, strings, lists, tuples, dicts and sets, with other objects such as
+// exceptions decoded as pickleObject.
+
+// pickleList is a Python list, kept as a pointer so that memoized lists see
+// the items appended later
+type pickleList struct {
+	items []interface{}
+}
+
+// pickleDict is a Python dict as its key/value pairs
+type pickleDict struct {
+	keys, values []interface{}
+}
+
+// pickleGlobal is a reference to a Python class or function
+type pickleGlobal struct {
+	module, name string
+}
+
+// pickleObject is an object built by calling a class or function
+type pickleObject struct {
+	class pickleGlobal
+	args  []interface{}
+}
+
+// pickleMark separates the items of a tuple or list on the stack
+type pickleMark struct{}
+
+// encodePickle encodes a list of strings with pickle protocol 2
+func encodePickle(args []string) []byte {
+	buf := []byte{0x80, 2, ']', '('}
+	for _, arg := range args {
+		buf = append(buf, 'X')
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(arg)))
+		buf = append(buf, arg...)
+	}
+	return append(buf, 'e', '.')
+}
+
+// decodePickle decodes a pickled value
+func decodePickle(data []byte) (interface{}, error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	var stack []interface{}
+	memo := make(map[int]interface{})
+
+	pop := func() (interface{}, error) {
+		if len(stack) == 0 {
+			return nil, errors.New("pickle stack underflow")
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v, nil
+	}
+	// popMark pops the items above the topmost mark
+	popMark := func() ([]interface{}, error) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if _, ok := stack[i].(pickleMark); ok {
+				items := append([]interface{}{}, stack[i+1:]...)
+				stack = stack[:i]
+				return items, nil
+			}
+		}
+		return nil, errors.New("pickle mark not found")
+	}
+	read := func(n int) ([]byte, error) {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(r, buf)
+		return buf, err
+	}
+	readUint := func(n int) (int, error) {
+		buf, err := read(n)
+		if err != nil {
+			return 0, err
+		}
+		v := 0
+		for i := n - 1; i >= 0; i-- {
+			v = v<<8 | int(buf[i])
+		}
+		return v, nil
+	}
+	readString := func(lengthBytes int) (string, error) {
+		n, err := readUint(lengthBytes)
+		if err != nil {
+			return "", err
+		}
+		buf, err := read(n)
+		return string(buf), err
+	}
+	top := func() (interface{}, error) {
+		if len(stack) == 0 {
+			return nil, errors.New("pickle stack underflow")
+		}
+		return stack[len(stack)-1], nil
+	}
+
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("truncated pickle: %w", err)
+		}
+
+		var value interface{}
+		push := true
+		switch op {
+		case '.': // STOP
+			return pop()
+		case 0x80: // PROTO
+			_, err = r.ReadByte()
+			push = false
+		case 0x95: // FRAME
+			_, err = read(8)
+			push = false
+		case '(': // MARK
+			value = pickleMark{}
+		case 'N': // NONE
+			value = nil
+		case 0x88: // NEWTRUE
+			value = true
+		case 0x89: // NEWFALSE
+			value = false
+		case 'K': // BININT1
+			value, err = readUint(1)
+		case 'M': // BININT2
+			value, err = readUint(2)
+		case 'J': // BININT
+			var n int
+			n, err = readUint(4)
+			value = int(int32(n))
+		case 0x8a: // LONG1
+			var n int
+			var buf []byte
+			if n, err = readUint(1); err == nil {
+				buf, err = read(n)
+				value = decodeLong(buf)
+			}
+		case 'G': // BINFLOAT
+			var buf []byte
+			buf, err = read(8)
+			if err == nil {
+				value = math.Float64frombits(binary.BigEndian.Uint64(buf))
+			}
+		case 0x8c, 'U', 'C': // SHORT_BINUNICODE, SHORT_BINSTRING, SHORT_BINBYTES
+			value, err = readString(1)
+		case 'X', 'T', 'B': // BINUNICODE, BINSTRING, BINBYTES
+			value, err = readString(4)
+		case 0x8d, 0x8e: // BINUNICODE8, BINBYTES8
+			value, err = readString(8)
+		case ')': // EMPTY_TUPLE
+			value = []interface{}{}
+		case ']': // EMPTY_LIST
+			value = &pickleList{}
+		case '}': // EMPTY_DICT
+			value = &pickleDict{}
+		case 0x8f: // EMPTY_SET
+			value = &pickleList{}
+		case 't', 0x91: // TUPLE, FROZENSET
+			value, err = popMark()
+		case 'l': // LIST
+			var items []interface{}
+			items, err = popMark()
+			value = &pickleList{items: items}
+		case 0x85, 0x86, 0x87: // TUPLE1, TUPLE2, TUPLE3
+			n := int(op - 0x84)
+			if len(stack) < n {
+				return nil, errors.New("pickle stack underflow")
+			}
+			value = append([]interface{}{}, stack[len(stack)-n:]...)
+			stack = stack[:len(stack)-n]
+		case 'a', 'e', 0x90: // APPEND, APPENDS, ADDITEMS
+			var items []interface{}
+			if op == 'a' {
+				var item interface{}
+				item, err = pop()
+				items = []interface{}{item}
+			} else {
+				items, err = popMark()
+			}
+			if err == nil {
+				var target interface{}
+				target, err = top()
+				if list, ok := target.(*pickleList); ok {
+					list.items = append(list.items, items...)
+				}
+			}
+			push = false
+		case 's', 'u': // SETITEM, SETITEMS
+			var items []interface{}
+			if op == 's' {
+				if len(stack) < 2 {
+					return nil, errors.New("pickle stack underflow")
+				}
+				items = append([]interface{}{}, stack[len(stack)-2:]...)
+				stack = stack[:len(stack)-2]
+			} else {
+				items, err = popMark()
+			}
+			if err == nil {
+				var target interface{}
+				target, err = top()
+				if dict, ok := target.(*pickleDict); ok {
+					for i := 0; i+1 < len(items); i += 2 {
+						dict.keys = append(dict.keys, items[i])
+						dict.values = append(dict.values, items[i+1])
+					}
+				}
+			}
+			push = false
+		case 0x94: // MEMOIZE
+			value, err = top()
+			memo[len(memo)] = value
+			push = false
+		case 'q', 'r': // BINPUT, LONG_BINPUT
+			size := 1
+			if op == 'r' {
+				size = 4
+			}
+			var index int
+			index, err = readUint(size)
+			if err == nil {
+				memo[index], err = top()
+			}
+			push = false
+		case 'h', 'j': // BINGET, LONG_BINGET
+			size := 1
+			if op == 'j' {
+				size = 4
+			}
+			var index int
+			index, err = readUint(size)
+			var ok bool
+			if value, ok = memo[index]; !ok && err == nil {
+				err = fmt.Errorf("pickle memo %d not found", index)
+			}
+		case 'c': // GLOBAL
+			var module, name string
+			if module, err = r.ReadString('\n'); err == nil {
+				name, err = r.ReadString('\n')
+			}
+			value = pickleGlobal{module: strings.TrimSpace(module), name: strings.TrimSpace(name)}
+		case 0x93: // STACK_GLOBAL
+			if len(stack) < 2 {
+				return nil, errors.New("pickle stack underflow")
+			}
+			module, _ := stack[len(stack)-2].(string)
+			name, _ := stack[len(stack)-1].(string)
+			stack = stack[:len(stack)-2]
+			value = pickleGlobal{module: module, name: name}
+		case 'R', 0x81: // REDUCE, NEWOBJ
+			if len(stack) < 2 {
+				return nil, errors.New("pickle stack underflow")
+			}
+			class, _ := stack[len(stack)-2].(pickleGlobal)
+			args, _ := stack[len(stack)-1].([]interface{})
+			stack = stack[:len(stack)-2]
+			value = &pickleObject{class: class, args: args}
+		case 'b': // BUILD
+			// The state of objects is not needed
+			_, err = pop()
+			push = false
+		default:
+			return nil, fmt.Errorf("unsupported pickle opcode 0x%02x", op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid pickle: %w", err)
+		}
+		if push {
+			stack = append(stack, value)
+		}
+	}
+}
+
+// decodeLong decodes a little-endian two's complement integer
+func decodeLong(buf []byte) int {
+	if len(buf) == 0 {
+		return 0
+	}
+	be := make([]byte, len(buf))
+	for i, b := range buf {
+		be[len(buf)-1-i] = b
+	}
+	n := new(big.Int).SetBytes(be)
+	if buf[len(buf)-1]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(buf))))
+	}
+	return int(n.Int64())
+}
+
+// pickleItems returns the items of a decoded list, tuple or set
+func pickleItems(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case *pickleList:
+		return v.items, true
+	}
+	return nil, false
+}
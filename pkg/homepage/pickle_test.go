@@ -0,0 +1,47 @@
+package homepage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Pickles of fail2ban responses, as written by Python 3
+var (
+	fail2banServerStatus = []byte("\x80\x05\x95D\x00\x00\x00\x00\x00\x00\x00K\x00]\x94(\x8c\x0eNumber of jail\x94K\x02\x86\x94\x8c\tJail list\x94\x8c\x15sshd, nginx-http-auth\x94\x86\x94e\x86\x94.")
+	fail2banJailStatus   = []byte("\x80\x05\x95\xd6\x00\x00\x00\x00\x00\x00\x00K\x00]\x94(\x8c\x06Filter\x94]\x94(\x8c\x10Currently failed\x94K\x03\x86\x94\x8c\x0cTotal failed\x94K)\x86\x94\x8c\tFile list\x94]\x94\x8c\x11/var/log/auth.log\x94a\x86\x94e\x86\x94\x8c\x07Actions\x94]\x94(\x8c\x10Currently banned\x94K\x02\x86\x94\x8c\x0cTotal banned\x94K\x11\x86\x94\x8c\x0eBanned IP list\x94]\x94(\x8c\x0b203.0.113.7\x94\x8c\r198.51.100.23\x94e\x86\x94e\x86\x94e\x86\x94.")
+	fail2banError        = []byte("\x80\x05\x952\x00\x00\x00\x00\x00\x00\x00K\x01\x8c\x08builtins\x94\x8c\nValueError\x94\x93\x94\x8c\x0cUnknown jail\x94\x85\x94R\x94\x86\x94.")
+)
+
+func TestEncodePickle(t *testing.T) {
+	assert.Equal(t, []byte("\x80\x02](X\x06\x00\x00\x00statusX\x04\x00\x00\x00sshde."), encodePickle([]string{"status", "sshd"}))
+
+	value, err := decodePickle(encodePickle([]string{"status", "sshd"}))
+	require.NoError(t, err)
+	assert.Equal(t, &pickleList{items: []interface{}{"status", "sshd"}}, value)
+}
+
+func TestDecodePickle(t *testing.T) {
+	value, err := decodePickle(fail2banServerStatus)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{0, &pickleList{items: []interface{}{
+		[]interface{}{"Number of jail", 2},
+		[]interface{}{"Jail list", "sshd, nginx-http-auth"},
+	}}}, value)
+
+	value, err = decodePickle(fail2banError)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1, &pickleObject{
+		class: pickleGlobal{module: "builtins", name: "ValueError"},
+		args:  []interface{}{"Unknown jail"},
+	}}, value)
+
+	// Dict with memoized keys and a negative long
+	value, err = decodePickle([]byte("\x80\x02}q\x00(X\x01\x00\x00\x00aq\x01\x8a\x01\xfbh\x01\x88u."))
+	require.NoError(t, err)
+	assert.Equal(t, &pickleDict{keys: []interface{}{"a", "a"}, values: []interface{}{-5, true}}, value)
+
+	_, err = decodePickle([]byte("\x80\x02K"))
+	assert.Error(t, err)
+}
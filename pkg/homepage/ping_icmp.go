@@ -0,0 +1,155 @@
+//go:build !windows
+
+package homepage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	// echoTimeout is how long to wait for each echo reply
+	echoTimeout = time.Second
+
+	// echoInterval spaces the echo requests, as hosts rate limiting ICMP
+	// drop bursts; Linux allows no less to unprivileged users
+	echoInterval = 200 * time.Millisecond
+)
+
+// ping sends echo requests to ip itself, without the system ping command.
+// Raw ICMP sockets need root or CAP_NET_RAW, so without them the datagram
+// ICMP sockets are used, which Linux opens to the groups of
+// net.ipv4.ping_group_range and macOS to all users.
+func (c *pingCheck) ping(ctx context.Context, serviceName string, ip net.IP, details []StatusDetail) *StatusResult {
+	conn, privileged, err := listenICMP(ip)
+	if err != nil {
+		logging.Error("Ping check for %s: Failed to open ICMP socket: %v", serviceName, err)
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Ping failed: %v", err), Details: details}
+	}
+	defer conn.Close()
+	logging.Debug("Ping check for %s: Sending %d echo requests to %s (privileged: %t)", serviceName, c.count, ip, privileged)
+
+	startTime := time.Now()
+	stats := &pingStats{}
+	// The ID tells the replies to this check apart on raw sockets, which
+	// receive all ICMP messages; the kernel sets it on datagram sockets
+	id := rand.IntN(0xffff) + 1
+	for seq := 1; seq <= c.count && ctx.Err() == nil; seq++ {
+		if seq > 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(echoInterval):
+			}
+		}
+		stats.sent++
+		rtt, err := sendEcho(ctx, conn, ip, privileged, id, seq)
+		if err != nil {
+			logging.Debug("Ping check for %s: Echo request %d failed: %v", serviceName, seq, err)
+			continue
+		}
+		stats.rtts = append(stats.rtts, rtt)
+	}
+	elapsed := time.Since(startTime)
+	details = append(details, StatusDetail{Label: "Duration", Value: elapsed.Round(time.Millisecond).String()})
+
+	if ctx.Err() != nil && len(stats.rtts) == 0 {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Ping failed: %v", ctx.Err()), Details: details}
+	}
+	return pingStatus(serviceName, stats, details)
+}
+
+// listenICMP opens a raw ICMP socket for the family of ip, or a datagram one
+// if that isn't permitted, and reports whether the socket is raw
+func listenICMP(ip net.IP) (*icmp.PacketConn, bool, error) {
+	raw, datagram, address := "ip4:icmp", "udp4", "0.0.0.0"
+	if ip.To4() == nil {
+		raw, datagram, address = "ip6:ipv6-icmp", "udp6", "::"
+	}
+	if conn, err := icmp.ListenPacket(raw, address); err == nil {
+		return conn, true, nil
+	}
+	conn, err := icmp.ListenPacket(datagram, address)
+	if err != nil {
+		var sysErr *os.SyscallError
+		if errors.As(err, &sysErr) {
+			err = sysErr.Err
+		}
+		return nil, false, fmt.Errorf("ICMP socket not permitted: %w", err)
+	}
+	return conn, false, nil
+}
+
+// sendEcho sends one echo request and returns the round trip time of its
+// reply
+func sendEcho(ctx context.Context, conn *icmp.PacketConn, ip net.IP, privileged bool, id, seq int) (time.Duration, error) {
+	var requestType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	protocol := 1
+	if ip.To4() == nil {
+		requestType, replyType, protocol = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply, 58
+	}
+	payload := []byte(fmt.Sprintf("termhome ping check %d", seq))
+	request, err := (&icmp.Message{Type: requestType, Body: &icmp.Echo{ID: id, Seq: seq, Data: payload}}).Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+	var destination net.Addr = &net.IPAddr{IP: ip}
+	if !privileged {
+		destination = &net.UDPAddr{IP: ip}
+	}
+
+	deadline := time.Now().Add(echoTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetReadDeadline(deadline)
+	start := time.Now()
+	if _, err := conn.WriteTo(request, destination); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		rtt := time.Since(start)
+		data := buf[:n]
+		// Datagram sockets of macOS return the IPv4 header too
+		if protocol == 1 && n >= 20 && data[0]>>4 == 4 {
+			data = data[int(data[0]&0x0f)*4:]
+		}
+		reply, err := icmp.ParseMessage(protocol, data)
+		if err != nil || reply.Type != replyType || !sameIP(peer, ip) {
+			continue
+		}
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || (privileged && echo.ID != id) || !bytes.Equal(echo.Data, payload) {
+			// A reply to another check or to an earlier request
+			continue
+		}
+		return rtt, nil
+	}
+}
+
+// sameIP reports whether the address of a peer is ip
+func sameIP(peer net.Addr, ip net.IP) bool {
+	switch addr := peer.(type) {
+	case *net.IPAddr:
+		return addr.IP.Equal(ip)
+	case *net.UDPAddr:
+		return addr.IP.Equal(ip)
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package homepage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPingStatus(t *testing.T) {
+	result := pingStatus("Router", &pingStats{sent: 3, rtts: []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}}, nil)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Up (2.0ms)", result.Message)
+	assert.Equal(t, 2*time.Millisecond, result.ResponseTime)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Min/max RTT", Value: "1.0ms / 3.0ms"})
+
+	result = pingStatus("Router", &pingStats{sent: 3, rtts: []time.Duration{1500 * time.Microsecond}}, nil)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "Degraded (1.5ms) packet loss: 66%", result.Message)
+
+	result = pingStatus("Router", &pingStats{sent: 3}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Down (100% loss)", result.Message)
+}
+
+func TestPingLoopback(t *testing.T) {
+	result := newPingCheck(&Service{Ping: "127.0.0.1", PingCount: 2}).run(context.Background(), "Loopback")
+	if strings.Contains(result.Message, "not permitted") {
+		t.Skip("ICMP sockets not permitted: " + result.Message)
+	}
+	assert.Equal(t, StatusOK, result.State, result.Message)
+	assert.True(t, strings.HasPrefix(result.Message, "Up ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Packet loss", Value: "0%"})
+}
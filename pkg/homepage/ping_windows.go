@@ -0,0 +1,160 @@
+//go:build windows
+
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+	"unsafe"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"golang.org/x/sys/windows"
+)
+
+// The ICMP helper API sends echo requests without raw sockets, so it needs no
+// administrator rights, and unlike ping.exe it neither flashes a console
+// window nor prints localized output that would need parsing
+var (
+	iphlpapi            = windows.NewLazySystemDLL("iphlpapi.dll")
+	procIcmpCreateFile  = iphlpapi.NewProc("IcmpCreateFile")
+	procIcmp6CreateFile = iphlpapi.NewProc("Icmp6CreateFile")
+	procIcmpCloseHandle = iphlpapi.NewProc("IcmpCloseHandle")
+	procIcmpSendEcho    = iphlpapi.NewProc("IcmpSendEcho")
+	procIcmp6SendEcho2  = iphlpapi.NewProc("Icmp6SendEcho2")
+)
+
+const (
+	// Time to wait for each echo reply, in milliseconds
+	icmpTimeout = 1000
+	// Status of a successful echo reply
+	icmpSuccess = 0
+)
+
+// icmpPayload is the data sent with each echo request
+var icmpPayload = []byte("termhome ping check")
+
+// icmpEchoReply is the ICMP_ECHO_REPLY structure
+type icmpEchoReply struct {
+	Address       uint32
+	Status        uint32
+	RoundTripTime uint32
+	DataSize      uint16
+	Reserved      uint16
+	Data          uintptr
+	Options       struct {
+		TTL         uint8
+		TOS         uint8
+		Flags       uint8
+		OptionsSize uint8
+		OptionsData uintptr
+	}
+}
+
+// icmpv6EchoReply is the ICMPV6_ECHO_REPLY structure, whose packed 26 bytes
+// address is followed by aligned fields
+type icmpv6EchoReply struct {
+	Address       [26]byte
+	Status        uint32
+	RoundTripTime uint32
+}
+
+// ping sends echo requests to ip with the ICMP helper API
+func (c *pingCheck) ping(ctx context.Context, serviceName string, ip net.IP, details []StatusDetail) *StatusResult {
+	ipv4 := ip.To4()
+	createFile := procIcmpCreateFile
+	if ipv4 == nil {
+		createFile = procIcmp6CreateFile
+	}
+	handle, _, err := createFile.Call()
+	if windows.Handle(handle) == windows.InvalidHandle {
+		logging.Error("Ping check for %s: Failed to open ICMP handle: %v", serviceName, err)
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Ping failed: %v", err), Details: details}
+	}
+	defer procIcmpCloseHandle.Call(handle)
+
+	startTime := time.Now()
+	stats := &pingStats{}
+	for i := 0; i < c.count && ctx.Err() == nil; i++ {
+		stats.sent++
+		var rtt time.Duration
+		var err error
+		if ipv4 != nil {
+			rtt, err = icmpSendEcho(handle, ipv4)
+		} else {
+			rtt, err = icmp6SendEcho(handle, ip.To16())
+		}
+		if err != nil {
+			logging.Debug("Ping check for %s: Echo request %d failed: %v", serviceName, i+1, err)
+			continue
+		}
+		stats.rtts = append(stats.rtts, rtt)
+	}
+	elapsed := time.Since(startTime)
+	details = append(details, StatusDetail{Label: "Duration", Value: elapsed.Round(time.Millisecond).String()})
+
+	if ctx.Err() != nil && len(stats.rtts) == 0 {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Ping failed: %v", ctx.Err()), Details: details}
+	}
+	return pingStatus(serviceName, stats, details)
+}
+
+// icmpSendEcho sends an echo request to an IPv4 address and returns the round
+// trip time of the reply
+func icmpSendEcho(handle uintptr, ip net.IP) (time.Duration, error) {
+	reply := make([]byte, int(unsafe.Sizeof(icmpEchoReply{}))+len(icmpPayload)+8+16)
+	// IPAddr holds the address in network byte order
+	addr := *(*uint32)(unsafe.Pointer(&ip[0]))
+
+	n, _, err := procIcmpSendEcho.Call(
+		handle,
+		uintptr(addr),
+		uintptr(unsafe.Pointer(&icmpPayload[0])),
+		uintptr(len(icmpPayload)),
+		0,
+		uintptr(unsafe.Pointer(&reply[0])),
+		uintptr(len(reply)),
+		icmpTimeout,
+	)
+	if n == 0 {
+		return 0, err
+	}
+
+	echo := (*icmpEchoReply)(unsafe.Pointer(&reply[0]))
+	if echo.Status != icmpSuccess {
+		return 0, fmt.Errorf("echo reply status %d", echo.Status)
+	}
+	return time.Duration(echo.RoundTripTime) * time.Millisecond, nil
+}
+
+// icmp6SendEcho sends an echo request to an IPv6 address and returns the round
+// trip time of the reply
+func icmp6SendEcho(handle uintptr, ip net.IP) (time.Duration, error) {
+	source := windows.RawSockaddrInet6{Family: windows.AF_INET6}
+	destination := windows.RawSockaddrInet6{Family: windows.AF_INET6}
+	copy(destination.Addr[:], ip)
+
+	reply := make([]byte, int(unsafe.Sizeof(icmpv6EchoReply{}))+len(icmpPayload)+8+16)
+	n, _, err := procIcmp6SendEcho2.Call(
+		handle,
+		0, 0, 0,
+		uintptr(unsafe.Pointer(&source)),
+		uintptr(unsafe.Pointer(&destination)),
+		uintptr(unsafe.Pointer(&icmpPayload[0])),
+		uintptr(len(icmpPayload)),
+		0,
+		uintptr(unsafe.Pointer(&reply[0])),
+		uintptr(len(reply)),
+		icmpTimeout,
+	)
+	if n == 0 {
+		return 0, err
+	}
+
+	echo := (*icmpv6EchoReply)(unsafe.Pointer(&reply[0]))
+	if echo.Status != icmpSuccess {
+		return 0, fmt.Errorf("echo reply status %d", echo.Status)
+	}
+	return time.Duration(echo.RoundTripTime) * time.Millisecond, nil
+}
@@ -0,0 +1,231 @@
+package homepage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Plugins are executables that implement checks and widgets in any language.
+// A plugin is run once per check or refresh: it receives a PluginRequest as
+// JSON on stdin and must print a PluginResponse as JSON on stdout, e.g.
+//
+//	{"state": "ok", "message": "3 jobs queued", "fields": [{"label": "Queued", "value": "3"}]}
+//
+// A plugin that exits with a non-zero code without printing a response is
+// reported as critical, with the first line of its stderr as message.
+
+// PluginRequest is the JSON document written to the stdin of a plugin
+type PluginRequest struct {
+	Kind    string                 `json:"kind"`              // "check" or "widget"
+	Service string                 `json:"service,omitempty"` // Name of the service the plugin runs for
+	Config  map[string]interface{} `json:"config"`            // Plugin configuration from services.yaml
+}
+
+// PluginResponse is the JSON document a plugin prints on stdout
+type PluginResponse struct {
+	State   string        `json:"state"`   // ok, warning, critical or unknown
+	Message string        `json:"message"` // Short status message
+	Fields  []PluginField `json:"fields"`  // Optional label/value pairs shown as widget fields or check details
+}
+
+// PluginField is a label/value pair returned by a plugin
+type PluginField struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+	State string `json:"state"` // Optional: state used to color the value
+}
+
+// maxPluginOutput bounds the output read from a plugin
+const maxPluginOutput = 1 << 20
+
+var (
+	pluginDir      string
+	pluginDirMutex sync.RWMutex
+)
+
+// SetPluginDir sets the directory plugins are looked up in
+func SetPluginDir(dir string) {
+	pluginDirMutex.Lock()
+	defer pluginDirMutex.Unlock()
+	pluginDir = dir
+}
+
+// PluginPath returns the path of the executable of a plugin. Names that are
+// not absolute paths are resolved in the plugin directory.
+func PluginPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	pluginDirMutex.RLock()
+	defer pluginDirMutex.RUnlock()
+	return filepath.Join(pluginDir, name)
+}
+
+// RunPlugin runs a plugin with the given request and returns its response
+func RunPlugin(ctx context.Context, name string, request *PluginRequest) (*PluginResponse, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding plugin request: %w", err)
+	}
+
+	var stdout, stderr limitedBuffer
+	stdout.limit, stderr.limit = maxPluginOutput, maxPluginOutput
+
+	cmd := exec.CommandContext(ctx, PluginPath(name))
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("plugin %s timed out", name)
+	}
+
+	var response PluginResponse
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &response); err != nil {
+		if runErr != nil {
+			return nil, pluginRunError(name, runErr, stderr.String())
+		}
+		return nil, fmt.Errorf("plugin %s printed an invalid response: %w", name, err)
+	}
+	return &response, nil
+}
+
+// pluginRunError describes a plugin that failed without printing a response
+func pluginRunError(name string, err error, stderr string) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if line, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n"); line != "" {
+			return fmt.Errorf("plugin %s failed: %s", name, line)
+		}
+		return fmt.Errorf("plugin %s exited with code %d", name, exitErr.ExitCode())
+	}
+	return fmt.Errorf("plugin %s: %w", name, err)
+}
+
+// limitedBuffer is a buffer that silently drops writes beyond its limit
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// ReadFrom hides that of bytes.Buffer, through which io.Copy, used by exec to
+// collect the output of commands, would bypass the limit
+func (b *limitedBuffer) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{b}, r)
+}
+
+// pluginCheck is the status check of a service running an exec plugin
+type pluginCheck struct {
+	plugin  string
+	config  map[string]interface{}
+	timeout time.Duration
+}
+
+func newPluginCheck(service *Service) *pluginCheck {
+	timeout := service.PluginTimeout
+	if timeout <= 0 {
+		timeout = 30
+	}
+	return &pluginCheck{
+		plugin:  service.Plugin,
+		config:  service.PluginConfig,
+		timeout: time.Duration(timeout) * time.Second,
+	}
+}
+
+// run runs the plugin and converts its response to a status result
+func (c *pluginCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	response, err := RunPlugin(ctx, c.plugin, &PluginRequest{Kind: "check", Service: serviceName, Config: c.config})
+	elapsed := time.Since(startTime)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: err.Error(), ResponseTime: elapsed}
+	}
+
+	result := &StatusResult{
+		State:        parseStaticStatus(response.State),
+		Message:      response.Message,
+		ResponseTime: elapsed,
+	}
+	for _, field := range response.Fields {
+		result.Details = append(result.Details, StatusDetail{Label: field.Label, Value: field.Value})
+	}
+	return result
+}
+
+// pluginWidget is a widget whose data is provided by an exec plugin. All
+// widget options, including url and credentials, are passed to the plugin.
+type pluginWidget struct {
+	plugin string
+	config map[string]interface{}
+}
+
+func newPluginWidget(config *WidgetConfig) (Widget, error) {
+	plugin := config.String("plugin", "")
+	if plugin == "" {
+		return nil, fmt.Errorf("plugin widget requires a plugin")
+	}
+
+	options := make(map[string]interface{}, len(config.Options)+4)
+	for key, value := range config.Options {
+		if key != "plugin" {
+			options[key] = value
+		}
+	}
+	for key, value := range map[string]string{"url": config.URL, "username": config.Username, "password": config.Password, "key": config.Key} {
+		if value != "" {
+			options[key] = value
+		}
+	}
+	if config.SkipVerify {
+		options["skipVerify"] = true
+	}
+
+	return &pluginWidget{plugin: plugin, config: options}, nil
+}
+
+// Fetch runs the plugin and converts its response to widget fields
+func (w *pluginWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	response, err := RunPlugin(ctx, w.plugin, &PluginRequest{Kind: "widget", Config: w.config})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WidgetResult{State: parseStaticStatus(response.State), Message: response.Message, LastUpdated: time.Now()}
+	for _, field := range response.Fields {
+		result.Fields = append(result.Fields, WidgetField{Label: field.Label, Value: field.Value, State: parseFieldState(field.State)})
+	}
+	return result, nil
+}
+
+// parseFieldState converts the optional state of a plugin field, where an
+// empty state leaves the value uncolored
+func parseFieldState(state string) StatusState {
+	if state == "" {
+		return ""
+	}
+	return parseStaticStatus(state)
+}
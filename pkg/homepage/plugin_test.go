@@ -0,0 +1,76 @@
+package homepage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePlugin writes an executable shell script plugin to dir
+func writePlugin(t *testing.T, dir, name, script string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755))
+}
+
+func TestPluginCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	SetPluginDir(dir)
+	defer SetPluginDir("")
+
+	// Echo the request back in the message
+	writePlugin(t, dir, "echo", `read -r request
+printf '{"state":"warning","message":%s,"fields":[{"label":"Queued","value":"3"}]}' "$(printf '%s' "$request" | sed 's/"/\\"/g; s/^/"/; s/$/"/')"
+`)
+	writePlugin(t, dir, "fail", "echo 'queue unreachable' >&2\nexit 2\n")
+	writePlugin(t, dir, "garbage", "echo not json\n")
+
+	service := &Service{Name: "Queue", Plugin: "echo", PluginConfig: map[string]interface{}{"queue": "jobs"}}
+	result := CheckOnce(context.Background(), service, nil)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, `{"kind":"check","service":"Queue","config":{"queue":"jobs"}}`, result.Message)
+	assert.Equal(t, []StatusDetail{{Label: "Queued", Value: "3"}}, result.Details)
+
+	result = CheckOnce(context.Background(), &Service{Name: "Queue", Plugin: "fail"}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "plugin fail failed: queue unreachable", result.Message)
+
+	result = CheckOnce(context.Background(), &Service{Name: "Queue", Plugin: "garbage"}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "invalid response")
+
+	result = CheckOnce(context.Background(), &Service{Name: "Queue", Plugin: "missing"}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+}
+
+func TestPluginWidget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	SetPluginDir(dir)
+	defer SetPluginDir("")
+
+	writePlugin(t, dir, "disk", `echo '{"state":"ok","message":"42% used","fields":[{"label":"Used","value":"42%","state":"ok"},{"label":"Mount","value":"/"}]}'`)
+
+	_, err := NewWidget(&WidgetConfig{Type: "plugin"})
+	assert.Error(t, err)
+
+	widget, err := NewWidget(&WidgetConfig{Type: "plugin", Options: map[string]interface{}{"plugin": "disk"}})
+	require.NoError(t, err)
+
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "42% used", result.Message)
+	assert.Equal(t, []WidgetField{{Label: "Used", Value: "42%", State: StatusOK}, {Label: "Mount", Value: "/"}}, result.Fields)
+}
@@ -0,0 +1,82 @@
+package homepage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownProfile is returned when the selected profile is not in the settings
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// ApplyProfile applies the named profile of the settings to the groups and to
+// the remotes of the settings. It returns the groups kept by the profile, with
+// their URLs rewritten. An empty name leaves everything unchanged.
+func ApplyProfile(settings *Settings, name string, serviceGroups []*ServiceGroup, bookmarkGroups []*BookmarkGroup) ([]*ServiceGroup, []*BookmarkGroup, error) {
+	if name == "" {
+		return serviceGroups, bookmarkGroups, nil
+	}
+	profile, ok := settings.Profiles[name]
+	if !ok {
+		if len(settings.Profiles) == 0 {
+			return nil, nil, fmt.Errorf("%w %q, settings.yaml has no profiles", ErrUnknownProfile, name)
+		}
+		return nil, nil, fmt.Errorf("%w %q, expected one of: %s", ErrUnknownProfile, name, strings.Join(ProfileNames(settings), ", "))
+	}
+
+	if len(profile.Groups) > 0 {
+		serviceGroups = slices.DeleteFunc(serviceGroups, func(group *ServiceGroup) bool {
+			return !slices.Contains(profile.Groups, group.Name)
+		})
+		bookmarkGroups = slices.DeleteFunc(bookmarkGroups, func(group *BookmarkGroup) bool {
+			return !slices.Contains(profile.Groups, group.Name)
+		})
+	}
+
+	rewrite := profile.rewriteURL
+	for _, group := range serviceGroups {
+		for _, service := range group.Services {
+			service.Href = rewrite(service.Href)
+			service.SiteMonitor = rewrite(service.SiteMonitor)
+			service.SubtitleURL = rewrite(service.SubtitleURL)
+			if service.Widget != nil {
+				service.Widget.URL = rewrite(service.Widget.URL)
+			}
+		}
+	}
+	for _, group := range bookmarkGroups {
+		for _, bookmark := range group.Bookmarks {
+			bookmark.Href = rewrite(bookmark.Href)
+		}
+	}
+	for i := range settings.Remotes {
+		settings.Remotes[i].URL = rewrite(settings.Remotes[i].URL)
+	}
+	return serviceGroups, bookmarkGroups, nil
+}
+
+// ProfileNames returns the sorted names of the profiles of the settings
+func ProfileNames(settings *Settings) []string {
+	names := make([]string, 0, len(settings.Profiles))
+	for name := range settings.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rewriteURL replaces the longest base URL of the profile that u starts with
+func (p Profile) rewriteURL(u string) string {
+	longest := ""
+	for prefix := range p.BaseURLs {
+		if strings.HasPrefix(u, prefix) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+	if longest == "" {
+		return u
+	}
+	return p.BaseURLs[longest] + strings.TrimPrefix(u, longest)
+}
@@ -0,0 +1,67 @@
+package homepage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyProfile checks that a profile keeps its groups and rewrites the
+// URLs starting with its longest matching base URL.
+func TestApplyProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yaml")
+	writeTestFile(t, path, []byte(`profiles:
+  work:
+    groups: [Media, Dev]
+    baseUrls:
+      http://nas.lan: https://nas.example.com
+      http://nas.lan:8096: https://jellyfin.example.com
+  home: {}
+remotes:
+  - name: office
+    url: http://nas.lan/termhome
+`))
+	settings, err := LoadSettings(path)
+	require.NoError(t, err)
+	assert.Empty(t, CheckConfigKeys(path))
+	assert.Equal(t, []string{"home", "work"}, ProfileNames(settings))
+
+	newGroups := func() ([]*ServiceGroup, []*BookmarkGroup) {
+		return []*ServiceGroup{
+			{Name: "Media", Services: []*Service{{
+				Name:        "Jellyfin",
+				Href:        "http://nas.lan:8096/web",
+				SiteMonitor: "http://nas.lan/health",
+				Widget:      &WidgetConfig{Type: "jellyfin", URL: "http://other.lan"},
+			}}},
+			{Name: "Home", Services: []*Service{{Name: "Router"}}},
+		}, []*BookmarkGroup{
+			{Name: "Dev", Bookmarks: []*Bookmark{{Name: "Files", Href: "http://nas.lan/files"}}},
+			{Name: "Fun", Bookmarks: []*Bookmark{{Name: "Games"}}},
+		}
+	}
+
+	serviceGroups, bookmarkGroups := newGroups()
+	serviceGroups, bookmarkGroups, err = ApplyProfile(settings, "", serviceGroups, bookmarkGroups)
+	require.NoError(t, err)
+	assert.Len(t, serviceGroups, 2, "No profile should keep all groups")
+	assert.Len(t, bookmarkGroups, 2)
+
+	serviceGroups, bookmarkGroups = newGroups()
+	serviceGroups, bookmarkGroups, err = ApplyProfile(settings, "work", serviceGroups, bookmarkGroups)
+	require.NoError(t, err)
+	require.Len(t, serviceGroups, 1)
+	require.Len(t, bookmarkGroups, 1)
+	jellyfin := serviceGroups[0].Services[0]
+	assert.Equal(t, "https://jellyfin.example.com/web", jellyfin.Href, "The longest base URL should win")
+	assert.Equal(t, "https://nas.example.com/health", jellyfin.SiteMonitor)
+	assert.Equal(t, "http://other.lan", jellyfin.Widget.URL, "Other URLs should be kept")
+	assert.Equal(t, "https://nas.example.com/files", bookmarkGroups[0].Bookmarks[0].Href)
+	assert.Equal(t, "https://nas.example.com/termhome", settings.Remotes[0].URL)
+
+	_, _, err = ApplyProfile(settings, "travel", nil, nil)
+	assert.ErrorIs(t, err, ErrUnknownProfile)
+	assert.ErrorContains(t, err, "home, work")
+}
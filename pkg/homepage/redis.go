@@ -0,0 +1,231 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Default port of Redis servers
+	redisPort = "6379"
+
+	// Largest bulk string reply read, far above the size of INFO replication
+	maxRedisReply = 1 << 20
+)
+
+// redisCheck sends a PING to a Redis server, logging in first with the
+// credentials of the URL, and reports its role, for in-memory stores whose
+// port may be open while they refuse commands
+type redisCheck struct {
+	url        *url.URL
+	err        error // Error parsing the URL
+	skipVerify bool
+	caFile     string
+	caDir      string
+	timeout    time.Duration
+}
+
+// redisSession is what a conversation with a Redis server found
+type redisSession struct {
+	ping        time.Duration // Round trip of the PING
+	tls         string        // TLS version, empty without TLS
+	replication map[string]string
+}
+
+// newRedisCheck creates a Redis check for a service
+func newRedisCheck(service *Service) *redisCheck {
+	timeout := service.RedisTimeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	check := &redisCheck{
+		skipVerify: service.RedisSkipVerify,
+		caFile:     service.CAFile,
+		caDir:      service.CADir,
+		timeout:    time.Duration(timeout) * time.Second,
+	}
+	check.url, check.err = url.Parse(service.Redis)
+	if check.err == nil && check.url.Scheme != "redis" && check.url.Scheme != "rediss" {
+		check.err = fmt.Errorf("unsupported scheme %q, use redis or rediss", check.url.Scheme)
+	}
+	return check
+}
+
+// run pings the server and reports its role and the time it took
+func (c *redisCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	if c.err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid Redis URL: %v", c.err)}
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	session, err := c.ping(ctx)
+	elapsed := time.Since(start)
+
+	details := []StatusDetail{{Label: "Server", Value: c.url.Redacted()}}
+	if err != nil {
+		message := err.Error()
+		return &StatusResult{State: StatusCritical, Message: strings.ToUpper(message[:1]) + message[1:], Details: details}
+	}
+	if session.tls != "" {
+		details = append(details, StatusDetail{Label: "TLS", Value: session.tls})
+	}
+
+	state, message := StatusOK, "PONG"
+	switch role := session.replication["role"]; role {
+	case "":
+		// INFO may be denied to the user, the role is then unknown
+	case "master":
+		message += ", master"
+		if replicas := session.replication["connected_slaves"]; replicas != "" {
+			details = append(details, StatusDetail{Label: "Replicas", Value: replicas})
+		}
+	case "slave":
+		message += ", replica"
+		master := net.JoinHostPort(session.replication["master_host"], session.replication["master_port"])
+		details = append(details, StatusDetail{Label: "Master", Value: master})
+		if link := session.replication["master_link_status"]; link != "up" {
+			state, message = StatusWarning, message+", link to master "+link
+		}
+	default:
+		message += ", " + role
+	}
+	details = append(details,
+		StatusDetail{Label: "PING", Value: session.ping.Round(time.Microsecond).String()},
+		StatusDetail{Label: "Total", Value: elapsed.Round(time.Microsecond).String()},
+	)
+	return &StatusResult{
+		State:        state,
+		Message:      fmt.Sprintf("%s (%s)", message, elapsed.Round(time.Millisecond)),
+		ResponseTime: elapsed,
+		Details:      details,
+	}
+}
+
+// ping connects to the server, switching to TLS for rediss URLs, logs in,
+// sends a PING and reads the replication section of INFO
+func (c *redisCheck) ping(ctx context.Context) (*redisSession, error) {
+	address := c.url.Host
+	if c.url.Port() == "" {
+		address = net.JoinHostPort(c.url.Hostname(), redisPort)
+	}
+	conn, err := dialCached(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	session := &redisSession{}
+	if c.url.Scheme == "rediss" {
+		config, err := clientTLSConfig(c.skipVerify, c.caFile, c.caDir)
+		if err != nil {
+			return nil, fmt.Errorf("error loading CA certificates: %w", err)
+		}
+		config.ServerName = c.url.Hostname()
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		session.tls = tls.VersionName(tlsConn.ConnectionState().Version)
+		conn = tlsConn
+	}
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	// The user is optional, Redis 6 ACLs log in the default user without it
+	if password, ok := c.url.User.Password(); ok {
+		args := []string{"AUTH", password}
+		if user := c.url.User.Username(); user != "" {
+			args = []string{"AUTH", user, password}
+		}
+		if _, err := redisCommand(rw, args...); err != nil {
+			return nil, fmt.Errorf("AUTH failed: %w", err)
+		}
+	}
+
+	start := time.Now()
+	reply, err := redisCommand(rw, "PING")
+	if err != nil {
+		return nil, fmt.Errorf("PING failed: %w", err)
+	}
+	if reply != "PONG" {
+		return nil, fmt.Errorf("unexpected reply to PING: %q", firstLine(reply))
+	}
+	session.ping = time.Since(start)
+
+	if info, err := redisCommand(rw, "INFO", "replication"); err == nil {
+		session.replication = redisInfo(info)
+	}
+	return session, nil
+}
+
+// redisCommand sends a command and returns its reply, or the error the
+// server replied with
+func redisCommand(rw *bufio.ReadWriter, args ...string) (string, error) {
+	fmt.Fprintf(rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := rw.Flush(); err != nil {
+		return "", err
+	}
+	return redisReply(rw.Reader)
+}
+
+// redisReply reads a simple string, error, integer or bulk string reply
+func redisReply(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", errors.New(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid reply: %q", line)
+		}
+		if size < 0 {
+			return "", nil
+		}
+		if size > maxRedisReply {
+			return "", fmt.Errorf("reply of %d bytes is too large", size)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return "", err
+		}
+		return string(data[:size]), nil
+	}
+	return "", fmt.Errorf("not a Redis server: %q", line)
+}
+
+// redisInfo parses the field:value lines of a reply to INFO
+func redisInfo(info string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		if key, value, found := strings.Cut(strings.TrimSpace(line), ":"); found && !strings.HasPrefix(key, "#") {
+			fields[key] = value
+		}
+	}
+	return fields
+}
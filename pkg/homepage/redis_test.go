@@ -0,0 +1,121 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveFakeRedis answers PING, and AUTH with password if not empty, and
+// INFO replication with replication, over TLS with a self-signed certificate
+// if secure is set, returning the address of the server
+func serveFakeRedis(t *testing.T, password, replication string, secure bool) string {
+	dir := t.TempDir()
+	writeTestKeyPair(t, filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), "cache.test")
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	require.NoError(t, err)
+
+	return serveFakeBroker(t, func(conn net.Conn) {
+		if secure {
+			tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn = tlsConn
+		}
+		reader := bufio.NewReader(conn)
+		authenticated := password == ""
+		for {
+			// Commands are arrays of bulk strings
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			var args []string
+			for range count {
+				reader.ReadString('\n')
+				arg, _ := reader.ReadString('\n')
+				args = append(args, strings.TrimSpace(arg))
+			}
+			switch {
+			case args[0] == "AUTH" && args[len(args)-1] == password:
+				authenticated = true
+				fmt.Fprint(conn, "+OK\r\n")
+			case args[0] == "AUTH":
+				fmt.Fprint(conn, "-WRONGPASS invalid username-password pair or user is disabled.\r\n")
+			case !authenticated:
+				fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			case args[0] == "PING":
+				fmt.Fprint(conn, "+PONG\r\n")
+			case args[0] == "INFO" && replication != "":
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(replication), replication)
+			default:
+				fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+			}
+		}
+	})
+}
+
+func TestRedisCheck(t *testing.T) {
+	address := serveFakeRedis(t, "", "# Replication\r\nrole:master\r\nconnected_slaves:2\r\n", false)
+
+	result := newRedisCheck(&Service{Redis: "redis://" + address}).run(context.Background(), "Cache")
+	require.Equal(t, StatusOK, result.State, result.Message)
+	assert.Contains(t, result.Message, "PONG, master (")
+	assert.Positive(t, result.ResponseTime)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Replicas", Value: "2"})
+
+	// Replicas whose link to the master is down are a warning
+	replica := "# Replication\r\nrole:slave\r\nmaster_host:10.0.0.1\r\nmaster_port:6379\r\nmaster_link_status:down\r\n"
+	result = newRedisCheck(&Service{Redis: "redis://" + serveFakeRedis(t, "", replica, false)}).run(context.Background(), "Cache")
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Contains(t, result.Message, "PONG, replica, link to master down (")
+	assert.Contains(t, result.Details, StatusDetail{Label: "Master", Value: "10.0.0.1:6379"})
+
+	// The role is unknown if INFO is denied
+	result = newRedisCheck(&Service{Redis: "redis://" + serveFakeRedis(t, "", "", false)}).run(context.Background(), "Cache")
+	require.Equal(t, StatusOK, result.State, result.Message)
+	assert.Contains(t, result.Message, "PONG (")
+}
+
+func TestRedisCheckAuthAndTLS(t *testing.T) {
+	address := serveFakeRedis(t, "secret", "role:master\r\n", true)
+
+	result := newRedisCheck(&Service{Redis: "rediss://:secret@" + address, RedisSkipVerify: true}).run(context.Background(), "Cache")
+	require.Equal(t, StatusOK, result.State, result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "TLS", Value: "TLS 1.3"})
+	assert.Contains(t, result.Details, StatusDetail{Label: "Server", Value: "rediss://:xxxxx@" + address})
+
+	// Users of ACLs are sent with the password
+	result = newRedisCheck(&Service{Redis: "rediss://monitor:secret@" + address, RedisSkipVerify: true}).run(context.Background(), "Cache")
+	require.Equal(t, StatusOK, result.State, result.Message)
+
+	result = newRedisCheck(&Service{Redis: "rediss://:wrong@" + address, RedisSkipVerify: true}).run(context.Background(), "Cache")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "AUTH failed: WRONGPASS invalid username-password pair or user is disabled.", result.Message)
+
+	result = newRedisCheck(&Service{Redis: "rediss://" + address, RedisSkipVerify: true}).run(context.Background(), "Cache")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "PING failed: NOAUTH Authentication required.", result.Message)
+
+	// The self-signed certificate is not trusted
+	result = newRedisCheck(&Service{Redis: "rediss://:secret@" + address}).run(context.Background(), "Cache")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "TLS handshake failed")
+}
+
+func TestRedisCheckInvalidURL(t *testing.T) {
+	result := newRedisCheck(&Service{Redis: "memcached://cache.test"}).run(context.Background(), "Cache")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, `Invalid Redis URL: unsupported scheme "memcached", use redis or rediss`, result.Message)
+}
@@ -0,0 +1,269 @@
+package homepage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// RemotesGroupName is the group holding one entry per remote instance, showing
+// whether the remote is reachable
+const RemotesGroupName = "Remotes"
+
+// RemoteGroupName returns the name of the local group showing a group of a remote instance
+func RemoteGroupName(remote, group string) string {
+	return remote + " / " + group
+}
+
+// RemoteServiceName returns the name of the local service showing a service of
+// a remote instance. Service names are unique per monitor, so the remote name
+// is appended.
+func RemoteServiceName(remote, service string) string {
+	return service + " @ " + remote
+}
+
+// AddRemote starts pulling the statuses of a remote termhome instance. Its
+// services are shown as additional groups named after the remote.
+func (sm *StatusMonitor) AddRemote(remote RemoteConfig) {
+	if remote.Name == "" || remote.URL == "" {
+		logging.Warn("Remote without name or url, skipping: %+v", remote)
+		return
+	}
+
+	interval := remote.Interval
+	if interval <= 0 {
+		interval = 30
+	}
+
+	// The remote itself is listed in the Remotes group
+	service := &Service{Name: remote.Name, Href: remote.URL, Description: "Remote termhome instance", Remote: remote.Name}
+	if !sm.addPassiveService(service) {
+		logging.Warn("Remote %s: a service with the same name already exists, skipping", remote.Name)
+		return
+	}
+	AddDynamicServiceGroup(RemotesGroupName, service)
+
+	stopChan := sm.addStopChannel("remote:" + remote.Name)
+
+	logging.Info("Pulling statuses from remote %s (%s) every %d seconds", remote.Name, remote.URL, interval)
+
+	go func() {
+		ticker := sm.clock.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+
+		known := make(map[string]bool)
+		sm.pullRemote(remote, known)
+
+		for {
+			select {
+			case <-ticker.C():
+				if !sm.Paused() {
+					sm.pullRemote(remote, known)
+				}
+			case <-stopChan:
+				logging.Debug("Remote goroutine stopped for %s", remote.Name)
+				return
+			}
+		}
+	}()
+}
+
+// pullRemote fetches the snapshot of a remote and updates the services it
+// contains. known holds the local names of the services seen so far.
+func (sm *StatusMonitor) pullRemote(remote RemoteConfig, known map[string]bool) {
+	timeout := remote.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	startTime := time.Now()
+	snapshot, err := fetchRemoteSnapshot(ctx, remote)
+	if err != nil {
+		logging.Error("Remote %s: %v", remote.Name, err)
+		sm.updateServiceStatus(remote.Name, StatusCritical, fmt.Sprintf("Unreachable: %v", err))
+		for name := range known {
+			sm.updateServiceStatus(name, StatusUnknown, "Remote unreachable")
+		}
+		return
+	}
+	sm.recordResult(remote.Name, &StatusResult{
+		State:        StatusOK,
+		Message:      fmt.Sprintf("Up (%d ms)", time.Since(startTime).Milliseconds()),
+		ResponseTime: time.Since(startTime),
+	})
+
+	sm.applyRemoteSnapshot(remote.Name, snapshot, known)
+}
+
+// applyRemoteSnapshot updates the local copies of the services of a remote,
+// adding services and groups that were not seen before
+func (sm *StatusMonitor) applyRemoteSnapshot(remoteName string, snapshot *StatusSnapshot, known map[string]bool) {
+	seen := make(map[string]bool)
+	for _, group := range snapshot.Groups {
+		for _, status := range group.Services {
+			name := RemoteServiceName(remoteName, status.Name)
+			seen[name] = true
+
+			if !known[name] {
+				service := &Service{
+					Name:        name,
+					Href:        status.Href,
+					Description: status.Description,
+					Icon:        status.Icon,
+					Remote:      remoteName,
+				}
+				if sm.addPassiveService(service) {
+					AddDynamicServiceGroup(RemoteGroupName(remoteName, group.Name), service)
+				}
+				known[name] = true
+			}
+
+			sm.recordResult(name, &StatusResult{
+				State:        status.State,
+				Message:      status.Message,
+				ResponseTime: time.Duration(status.ResponseTimeMs) * time.Millisecond,
+			})
+		}
+	}
+
+	// Services removed on the remote are kept, but no longer have a status
+	for name := range known {
+		if !seen[name] {
+			sm.updateServiceStatus(name, StatusUnknown, "No longer reported by remote")
+		}
+	}
+}
+
+// addPassiveService registers a service whose status is set from outside
+// rather than by a check of its own. It returns false if a service with the
+// same name is already monitored.
+func (sm *StatusMonitor) addPassiveService(service *Service) bool {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	if _, exists := sm.services[service.Name]; exists {
+		return false
+	}
+	sm.services[service.Name] = service
+	sm.results[service.Name] = &StatusResult{State: StatusUnknown}
+	return true
+}
+
+// fetchRemoteSnapshot fetches the status snapshot of a remote instance
+func fetchRemoteSnapshot(ctx context.Context, remote RemoteConfig) (*StatusSnapshot, error) {
+	url := strings.TrimRight(remote.URL, "/") + "/api/status"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	if remote.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+remote.Token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{}
+	tlsConfig, err := clientTLSConfig(remote.SkipVerify, remote.CAFile, remote.CADir)
+	if err != nil {
+		return nil, err
+	}
+	if remote.SkipVerify || tlsConfig.RootCAs != nil {
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+
+	var snapshot StatusSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", url, err)
+	}
+	return &snapshot, nil
+}
+
+// pushAgent tracks an agent pushing its statuses to this instance
+type pushAgent struct {
+	known    map[string]bool // Local names of the services pushed so far
+	interval time.Duration   // Push interval announced by the agent
+	timer    Timer           // Fires when the agent stops pushing
+}
+
+// defaultPushInterval is assumed for agents that don't announce their interval
+const defaultPushInterval = 30 * time.Second
+
+// ApplyPushedSnapshot updates the services of an agent from a snapshot it
+// pushed. The agent is listed in the Remotes group and its services are shown
+// like those of pulled remotes. If the agent misses three pushes in a row, it
+// is marked critical and its services unknown.
+func (sm *StatusMonitor) ApplyPushedSnapshot(snapshot *StatusSnapshot) error {
+	name := snapshot.Instance
+	if name == "" {
+		return fmt.Errorf("instance name is required")
+	}
+
+	sm.agentsMutex.Lock()
+	defer sm.agentsMutex.Unlock()
+
+	agent, exists := sm.agents[name]
+	if !exists {
+		service := &Service{Name: name, Description: "Push agent", Remote: name}
+		if !sm.addPassiveService(service) {
+			return fmt.Errorf("a service named %q already exists", name)
+		}
+		AddDynamicServiceGroup(RemotesGroupName, service)
+		logging.Info("Agent %s started pushing statuses", name)
+
+		agent = &pushAgent{known: make(map[string]bool)}
+		agent.timer = sm.clock.AfterFunc(time.Hour, func() { sm.agentStale(name) })
+		sm.agents[name] = agent
+	}
+
+	agent.interval = time.Duration(snapshot.Interval) * time.Second
+	if agent.interval <= 0 {
+		agent.interval = defaultPushInterval
+	}
+	agent.timer.Reset(3 * agent.interval)
+
+	sm.updateServiceStatus(name, StatusOK, fmt.Sprintf("Pushing every %s", agent.interval))
+	sm.applyRemoteSnapshot(name, snapshot, agent.known)
+	return nil
+}
+
+// agentStale marks an agent that stopped pushing and its services
+func (sm *StatusMonitor) agentStale(name string) {
+	sm.agentsMutex.Lock()
+	defer sm.agentsMutex.Unlock()
+
+	agent, exists := sm.agents[name]
+	if !exists {
+		return
+	}
+	logging.Warn("Agent %s has not pushed for %s", name, 3*agent.interval)
+	sm.updateServiceStatus(name, StatusCritical, fmt.Sprintf("No push for %s", 3*agent.interval))
+	for service := range agent.known {
+		sm.updateServiceStatus(service, StatusUnknown, "Agent not reporting")
+	}
+}
+
+// stopAgents stops the staleness timers of all agents
+func (sm *StatusMonitor) stopAgents() {
+	sm.agentsMutex.Lock()
+	defer sm.agentsMutex.Unlock()
+
+	for _, agent := range sm.agents {
+		agent.timer.Stop()
+	}
+}
@@ -0,0 +1,61 @@
+package homepage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullRemote(t *testing.T) {
+	StoreCachedGroups(nil)
+	defer StoreCachedGroups(nil)
+
+	snapshot := StatusSnapshot{
+		Instance: "site-b",
+		Groups: []GroupStatus{{
+			Name: "Apps",
+			Services: []ServiceStatus{
+				{Name: "Grafana", State: StatusOK, Message: "Up (12 ms)", ResponseTimeMs: 12},
+				{Name: "NAS", State: StatusCritical, Message: "Down"},
+			},
+		}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/status" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(snapshot)
+	}))
+	defer srv.Close()
+
+	sm := NewStatusMonitor(nil)
+	remote := RemoteConfig{Name: "site-b", URL: srv.URL, Token: "secret"}
+	require.True(t, sm.addPassiveService(&Service{Name: remote.Name, Remote: remote.Name}))
+
+	known := make(map[string]bool)
+	sm.pullRemote(remote, known)
+
+	assert.Equal(t, StatusOK, sm.GetStatus("site-b").State)
+	assert.Equal(t, StatusOK, sm.GetStatus("Grafana @ site-b").State)
+	assert.Equal(t, StatusCritical, sm.GetStatus("NAS @ site-b").State)
+
+	groups := GetCachedGroups()
+	require.Len(t, groups, 1)
+	assert.Equal(t, "site-b / Apps", groups[0].Name)
+	assert.Len(t, groups[0].Services, 2)
+
+	// Remote services are not re-exported by the local snapshot
+	assert.Empty(t, sm.Snapshot("local").Groups)
+
+	// A failing remote marks its services unknown
+	remote.Token = "wrong"
+	sm.pullRemote(remote, known)
+	assert.Equal(t, StatusCritical, sm.GetStatus("site-b").State)
+	assert.Equal(t, StatusUnknown, sm.GetStatus("Grafana @ site-b").State)
+	assert.Len(t, GetCachedGroups(), 1)
+}
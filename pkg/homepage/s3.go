@@ -0,0 +1,230 @@
+package homepage
+
+import (
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// s3Check sends a HEAD request for a bucket or an object of an S3-compatible
+// object store, signed with the credentials of the environment, to verify
+// that the store answers and the credentials still work
+type s3Check struct {
+	url        string
+	region     string
+	accessKey  string // Environment variable of the access key ID
+	secretKey  string // Environment variable of the secret access key
+	skipVerify bool
+	caFile     string
+	caDir      string
+	timeout    time.Duration
+}
+
+// emptySHA256 is the hash of the empty payload of HEAD requests
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// newS3Check creates an S3 check for a service
+func newS3Check(service *Service) *s3Check {
+	timeout := service.S3Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	return &s3Check{
+		url:        service.S3,
+		region:     cmp.Or(service.S3Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		accessKey:  cmp.Or(service.S3AccessKeyEnv, "AWS_ACCESS_KEY_ID"),
+		secretKey:  cmp.Or(service.S3SecretKeyEnv, "AWS_SECRET_ACCESS_KEY"),
+		skipVerify: service.S3SkipVerify,
+		caFile:     service.CAFile,
+		caDir:      service.CADir,
+		timeout:    time.Duration(timeout) * time.Second,
+	}
+}
+
+// endpoint returns the HTTPS URL of an s3://bucket/key URL on AWS, and any
+// other URL unchanged as the path-style URL of another store
+func (c *s3Check) endpoint() (*url.URL, string, string, error) {
+	endpoint, err := url.Parse(c.url)
+	if err != nil {
+		return nil, "", "", err
+	}
+	switch endpoint.Scheme {
+	case "s3":
+		bucket, key := endpoint.Host, strings.TrimPrefix(endpoint.Path, "/")
+		endpoint = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, c.region), Path: "/" + key}
+		return endpoint, bucket, key, nil
+	case "http", "https":
+		bucket, key, _ := strings.Cut(strings.TrimPrefix(endpoint.Path, "/"), "/")
+		if bucket == "" {
+			return nil, "", "", fmt.Errorf("no bucket in %s", c.url)
+		}
+		return endpoint, bucket, key, nil
+	}
+	return nil, "", "", fmt.Errorf("unsupported scheme %q, use s3, http or https", endpoint.Scheme)
+}
+
+// run sends the HEAD request
+func (c *s3Check) run(ctx context.Context, serviceName string) *StatusResult {
+	endpoint, bucket, key, err := c.endpoint()
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid S3 URL: %v", err)}
+	}
+	details := []StatusDetail{{Label: "URL", Value: endpoint.String()}, {Label: "Region", Value: c.region}}
+
+	tlsConfig, err := clientTLSConfig(c.skipVerify, c.caFile, c.caDir)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Error loading CA certificates: %v", err), Details: details}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialCached
+	transport.TLSClientConfig = tlsConfig
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: c.timeout, Transport: transport}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint.String(), nil)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Error creating request: %v", err), Details: details}
+	}
+	// Public buckets are checked anonymously when no credentials are set
+	if accessKey, secretKey := os.Getenv(c.accessKey), os.Getenv(c.secretKey); accessKey != "" && secretKey != "" {
+		var sessionToken string
+		if c.accessKey == "AWS_ACCESS_KEY_ID" {
+			sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		}
+		signS3Request(req, accessKey, secretKey, sessionToken, c.region, time.Now())
+		details = append(details, StatusDetail{Label: "Access key", Value: accessKey})
+	} else {
+		details = append(details, StatusDetail{Label: "Access key", Value: "none, " + c.accessKey + " is not set"})
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	elapsed := time.Since(start)
+	details = append(details, StatusDetail{Label: "Total", Value: elapsed.Round(time.Microsecond).String()})
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Request failed: %v", err), Details: details}
+	}
+	resp.Body.Close()
+
+	what, name := "Bucket", bucket
+	if key != "" {
+		what, name = "Object", bucket+"/"+key
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Access to %s %s denied, check the credentials", strings.ToLower(what), name), ResponseTime: elapsed, Details: details}
+	case http.StatusNotFound:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("%s %s not found", what, name), ResponseTime: elapsed, Details: details}
+	case http.StatusMovedPermanently, http.StatusBadRequest:
+		if region := resp.Header.Get("X-Amz-Bucket-Region"); region != "" && region != c.region {
+			return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Bucket %s is in region %s", bucket, region), ResponseTime: elapsed, Details: details}
+		}
+		fallthrough
+	default:
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("%s %s returned HTTP %d", what, name, resp.StatusCode), ResponseTime: elapsed, Details: details}
+	}
+
+	message := fmt.Sprintf("%s %s answered (%s)", what, name, elapsed.Round(time.Millisecond))
+	if key != "" {
+		message = fmt.Sprintf("%s %s, %s (%s)", what, name, formatBytes(resp.ContentLength), elapsed.Round(time.Millisecond))
+		if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+			details = append(details, StatusDetail{Label: "Modified", Value: modified.Local().Format("2006-01-02 15:04:05")})
+		}
+	}
+	return &StatusResult{State: StatusOK, Message: message, ResponseTime: elapsed, Details: details}
+}
+
+// signS3Request signs a request without payload with AWS signature version
+// 4, covering the host and all the headers of the request
+func signS3Request(req *http.Request, accessKey, secretKey, sessionToken, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	// The path is sent as encoded here, so it matches the signed one
+	req.URL.RawPath = s3Encode(req.URL.Path, false)
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	queryNames := make([]string, 0, len(query))
+	for name := range query {
+		queryNames = append(queryNames, name)
+	}
+	slices.Sort(queryNames)
+	var canonicalQuery []string
+	for _, name := range queryNames {
+		for _, value := range query[name] {
+			canonicalQuery = append(canonicalQuery, s3Encode(name, true)+"="+s3Encode(value, true))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		cmp.Or(req.URL.RawPath, "/"),
+		strings.Join(canonicalQuery, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptySHA256,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Encode percent-encodes all but the unreserved characters of RFC 3986,
+// and slashes unless encodeSlash is set
+func s3Encode(s string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
@@ -0,0 +1,64 @@
+package homepage
+
+import (
+	"time"
+)
+
+// maxSavedStatusAge is the age beyond which saved statuses are not restored,
+// as they say little about the services anymore
+const maxSavedStatusAge = 24 * time.Hour
+
+// SavedStatus is the last known status of a service, kept across restarts so
+// the dashboard doesn't start with all services unknown
+type SavedStatus struct {
+	State          StatusState `json:"state"`
+	Message        string      `json:"message,omitempty"`
+	ResponseTimeMs int64       `json:"responseTimeMs,omitempty"`
+	LastChecked    time.Time   `json:"lastChecked"`
+}
+
+// result returns the stale result restored from a saved status
+func (s SavedStatus) result() *StatusResult {
+	return &StatusResult{
+		State:        s.State,
+		Message:      s.Message,
+		ResponseTime: time.Duration(s.ResponseTimeMs) * time.Millisecond,
+		LastChecked:  s.LastChecked,
+		Stale:        true,
+	}
+}
+
+// SavedStatuses returns the last known statuses of the checked services, by
+// service name, to be restored by the next run. Services pulled from remote
+// instances and services whose checks are turned off are left out.
+func (sm *StatusMonitor) SavedStatuses() map[string]SavedStatus {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	saved := make(map[string]SavedStatus)
+	for name, result := range sm.results {
+		service := sm.services[name]
+		if service == nil || service.Remote != "" || sm.disabled[name] || result.LastChecked.IsZero() {
+			continue
+		}
+		saved[name] = SavedStatus{
+			State:          result.State,
+			Message:        result.Message,
+			ResponseTimeMs: result.ResponseTime.Milliseconds(),
+			LastChecked:    result.LastChecked,
+		}
+	}
+	return saved
+}
+
+// RestoreStatuses sets the statuses saved by a previous run as the initial
+// statuses of the services added afterwards, marked as stale until they are
+// checked. Statuses older than a day are skipped.
+func (sm *StatusMonitor) RestoreStatuses(saved map[string]SavedStatus) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	for name, status := range saved {
+		if sm.clock.Now().Sub(status.LastChecked) < maxSavedStatusAge {
+			sm.restored[name] = status
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package homepage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedStatuses(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(nil)
+	sm.SetClock(clock)
+	defer sm.Stop()
+
+	sm.AddService(&Service{Name: "NAS", Status: "ok"})
+	sm.AddService(&Service{Name: "Remote NAS", Status: "ok", Remote: "site-b"})
+	sm.AddService(&Service{Name: "Router", Status: "ok"})
+	sm.SetServiceEnabled("Router", false)
+	sm.recordResult("NAS", &StatusResult{State: StatusCritical, Message: "Down", ResponseTime: 1500 * time.Millisecond})
+
+	// Remote and disabled services are left out
+	saved := sm.SavedStatuses()
+	assert.Equal(t, map[string]SavedStatus{
+		"NAS": {State: StatusCritical, Message: "Down", ResponseTimeMs: 1500, LastChecked: clock.Now()},
+	}, saved)
+
+	next := NewStatusMonitor(nil)
+	next.SetClock(clock)
+	defer next.Stop()
+	clock.Advance(time.Minute)
+	saved["Old"] = SavedStatus{State: StatusOK, LastChecked: clock.Now().Add(-48 * time.Hour)}
+	next.RestoreStatuses(saved)
+
+	// Restored statuses are shown before monitoring starts, and are stale
+	// until the service is checked
+	assert.True(t, next.GetStatus("NAS").Stale)
+	next.Pause()
+	next.AddService(&Service{Name: "NAS", Ping: "nas.invalid", PingInterval: 60})
+	result := next.GetStatus("NAS")
+	require.True(t, result.Stale)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Down", result.Message)
+	assert.Equal(t, 1500*time.Millisecond, result.ResponseTime)
+	assert.Equal(t, clock.Now().Add(-time.Minute), result.LastChecked)
+
+	next.recordResult("NAS", &StatusResult{State: StatusOK, Message: "Up"})
+	assert.False(t, next.GetStatus("NAS").Stale)
+
+	// Statuses older than a day are not restored
+	next.AddService(&Service{Name: "Old", Status: "warning"})
+	assert.False(t, next.GetStatus("Old").Stale)
+	assert.Equal(t, StatusWarning, next.GetStatus("Old").State)
+}
@@ -0,0 +1,222 @@
+package homepage
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SchemaFiles are the configuration files a JSON schema is generated for, by
+// name without the .yaml extension
+var SchemaFiles = []string{"settings", "services", "bookmarks", "docker"}
+
+// configSource holds the declarations of the configuration structs, whose
+// field comments describe the keys in the schemas
+//
+//go:embed config.go widget.go
+var configSource embed.FS
+
+var (
+	secondsType      = reflect.TypeOf(Seconds(0))
+	widgetConfigType = reflect.TypeOf(WidgetConfig{})
+)
+
+// schemaAliases are the keys accepted by the parser in addition to the yaml
+// tags of a struct, with the field they stand for
+var schemaAliases = map[reflect.Type]map[string]string{
+	serviceType:  {"hidden": "ShowOnlyWhenDown", "order": "Weight"},
+	bookmarkType: {"order": "Weight"},
+}
+
+// ConfigSchema returns the JSON schema of a configuration file named
+// settings, services, bookmarks or docker, so editors running the YAML
+// language server can complete and validate it. The schema is generated from
+// the configuration structs and their field comments.
+func ConfigSchema(name string) ([]byte, error) {
+	g := &schemaGenerator{definitions: make(map[string]map[string]any), comments: configComments()}
+	var schema map[string]any
+	switch strings.TrimSuffix(name, ".yaml") {
+	case "settings":
+		schema = g.structSchema(settingsType)
+	case "services":
+		schema = groupsSchema(g.schema(serviceType), g.schema(serviceType))
+	case "bookmarks":
+		bookmark := g.schema(bookmarkType)
+		// Bookmarks are either a map or a list holding a map
+		schema = groupsSchema(map[string]any{
+			"anyOf": []any{bookmark, map[string]any{"type": "array", "minItems": 1, "items": bookmark}},
+		}, nil)
+	case "docker":
+		schema = map[string]any{"type": "object", "additionalProperties": g.schema(dockerType.Elem())}
+	default:
+		return nil, fmt.Errorf("unknown configuration file %q, use one of %s", name, strings.Join(SchemaFiles, ", "))
+	}
+
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = fmt.Sprintf("termhome %s.yaml", strings.TrimSuffix(name, ".yaml"))
+	if len(g.definitions) > 0 {
+		schema["definitions"] = g.definitions
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// groupsSchema returns the schema of services.yaml or bookmarks.yaml: a list
+// of single key maps from group names to lists of single key maps from entry
+// names to their settings. A defaults schema allows defaults entries at both
+// levels; extension entries, prefixed with x-, may hold anything.
+func groupsSchema(entry, defaults map[string]any) map[string]any {
+	entries := map[string]any{"type": "object", "minProperties": 1, "maxProperties": 1, "additionalProperties": entry}
+	group := map[string]any{
+		"type":                 "object",
+		"minProperties":        1,
+		"maxProperties":        1,
+		"patternProperties":    map[string]any{"^x-": map[string]any{}},
+		"additionalProperties": map[string]any{"type": "array", "items": entries},
+	}
+	if defaults != nil {
+		group["properties"] = map[string]any{serviceDefaultsKey: defaults}
+	}
+	return map[string]any{"type": "array", "items": group}
+}
+
+// schemaGenerator generates the schema of a configuration file, collecting the
+// definitions of the structs it refers to
+type schemaGenerator struct {
+	definitions map[string]map[string]any
+	comments    map[string]string // Comments of the types and fields, e.g. Service or Service.Href
+}
+
+// schema returns the schema of a value of type t. Structs are defined once
+// and referred to.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == secondsType {
+		return map[string]any{
+			"type":    []string{"integer", "string"},
+			"minimum": 0,
+			"pattern": `^\s*([0-9]+|([0-9]*\.?[0-9]+(ns|us|µs|ms|s|m|h))+)\s*$`,
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.definitions[t.Name()]; !ok {
+			// Defined before its fields, in case they refer to it
+			g.definitions[t.Name()] = nil
+			g.definitions[t.Name()] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/definitions/" + t.Name()}
+	}
+	// Interfaces hold any value
+	return map[string]any{}
+}
+
+// structSchema returns the schema of a mapping decoded into a struct of type
+// t, with its yaml tags and the aliases handled by the parser as properties
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	schema := map[string]any{"type": "object", "additionalProperties": false}
+	if comment := g.comments[t.Name()]; comment != "" {
+		schema["description"] = comment
+	}
+
+	properties := make(map[string]any)
+	fields := make(map[string]map[string]any)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if options == "inline" {
+			// Extra keys are collected, such as the options of widgets
+			schema["additionalProperties"] = true
+			continue
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		property := g.schema(field.Type)
+		if comment := g.comments[t.Name()+"."+field.Name]; comment != "" {
+			property["description"] = comment
+		}
+		if t == widgetConfigType && field.Name == "Type" {
+			property["examples"] = slices.Sorted(maps.Keys(widgetFactories))
+		}
+		properties[name] = property
+		fields[field.Name] = property
+	}
+	for alias, fieldName := range schemaAliases[t] {
+		property := maps.Clone(fields[fieldName])
+		property["description"] = fmt.Sprintf("Alias of %s", fieldKey(t, fieldName))
+		properties[alias] = property
+	}
+
+	schema["properties"] = properties
+	return schema
+}
+
+// fieldKey returns the key of a field of a configuration struct
+func fieldKey(t reflect.Type, fieldName string) string {
+	field, _ := t.FieldByName(fieldName)
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	return name
+}
+
+// configComments returns the comments of the configuration structs and of
+// their fields, by type name and by type and field name such as Service.Href
+var configComments = sync.OnceValue(func() map[string]string {
+	comments := make(map[string]string)
+	files, _ := configSource.ReadDir(".")
+	for _, entry := range files {
+		data, err := configSource.ReadFile(entry.Name())
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), entry.Name(), data, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE || len(decl.Specs) != 1 {
+				continue
+			}
+			spec := decl.Specs[0].(*ast.TypeSpec)
+			structType, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			comments[spec.Name.Name] = strings.Join(strings.Fields(decl.Doc.Text()), " ")
+			for _, field := range structType.Fields.List {
+				for _, name := range field.Names {
+					comments[spec.Name.Name+"."+name.Name] = strings.TrimSpace(field.Comment.Text())
+				}
+			}
+		}
+	}
+	return comments
+})
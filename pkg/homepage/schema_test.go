@@ -0,0 +1,71 @@
+package homepage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadSchema generates the schema of a configuration file and decodes it
+func loadSchema(t *testing.T, name string) map[string]any {
+	data, err := ConfigSchema(name)
+	require.NoError(t, err)
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+	return schema
+}
+
+func TestConfigSchema(t *testing.T) {
+	for _, name := range SchemaFiles {
+		schema := loadSchema(t, name)
+		assert.Equal(t, "termhome "+name+".yaml", schema["title"])
+	}
+	_, err := ConfigSchema("widgets")
+	assert.ErrorContains(t, err, "unknown configuration file")
+
+	settings := loadSchema(t, "settings.yaml")
+	assert.Equal(t, false, settings["additionalProperties"])
+	status := settings["properties"].(map[string]any)["status"].(map[string]any)
+	assert.Equal(t, "#/definitions/StatusSettings", status["$ref"])
+	assert.Equal(t, "Optional: Status monitoring settings", status["description"])
+}
+
+func TestConfigSchema_Services(t *testing.T) {
+	schema := loadSchema(t, "services")
+	definitions := schema["definitions"].(map[string]any)
+	service := definitions["Service"].(map[string]any)
+	assert.Equal(t, "Service represents a single service entry within a group in services.yaml.", service["description"])
+	properties := service["properties"].(map[string]any)
+
+	// Intervals are numbers of seconds or durations
+	interval := properties["siteMonitorInterval"].(map[string]any)
+	assert.Equal(t, []any{"integer", "string"}, interval["type"])
+	assert.Contains(t, interval["description"], "Check interval for site monitor")
+	assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "integer"}, "description": "Optional: HTTP codes to consider \"up\" (default: [200])"}, properties["siteMonitorExpectedCodes"])
+	assert.Equal(t, map[string]any{}, properties["pluginConfig"].(map[string]any)["additionalProperties"])
+	assert.NotContains(t, properties, "Remote")
+
+	// Aliases are accepted
+	assert.Equal(t, map[string]any{"type": "boolean", "description": "Alias of showOnlyWhenDown"}, properties["hidden"])
+	assert.Equal(t, map[string]any{"type": "integer", "description": "Alias of weight"}, properties["order"])
+
+	// Widgets accept the options of their type
+	widget := definitions["WidgetConfig"].(map[string]any)
+	assert.Equal(t, true, widget["additionalProperties"])
+	assert.Contains(t, widget["properties"].(map[string]any)["type"].(map[string]any)["examples"], "docker")
+
+	group := schema["items"].(map[string]any)
+	assert.Equal(t, map[string]any{"$ref": "#/definitions/Service"}, group["properties"].(map[string]any)["defaults"])
+	assert.Contains(t, group["patternProperties"], "^x-")
+}
+
+func TestConfigSchema_Bookmarks(t *testing.T) {
+	schema := loadSchema(t, "bookmarks")
+	group := schema["items"].(map[string]any)
+	assert.NotContains(t, group, "properties")
+	entries := group["additionalProperties"].(map[string]any)["items"].(map[string]any)
+	bookmark := map[string]any{"$ref": "#/definitions/Bookmark"}
+	assert.Equal(t, []any{bookmark, map[string]any{"type": "array", "minItems": float64(1), "items": bookmark}}, entries["additionalProperties"].(map[string]any)["anyOf"])
+}
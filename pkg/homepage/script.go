@@ -0,0 +1,335 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"go.starlark.net/lib/json"
+	startime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// Scripts are Starlark files implementing custom check logic and rendering.
+// A script defines check(config), which receives the scriptConfig of the
+// service and returns a status dict like the response of a plugin:
+//
+//	def check(config):
+//	    resp = http.get(config["url"] + "/api/queue")
+//	    depth = json.decode(resp.body)["depth"]
+//	    return {"state": "warning" if depth > 100 else "ok", "message": "%d queued" % depth}
+//
+// It may also define render(status), which receives the returned status and
+// returns the lines (a string or a list of strings) shown on the service card.
+// Scripts can use the json and time modules and http.get.
+
+// maxScriptResponse bounds the size of the body read by http.get
+const maxScriptResponse = 4 << 20
+
+var (
+	scriptDir      string
+	scriptDirMutex sync.RWMutex
+)
+
+// SetScriptDir sets the directory relative script paths are resolved against
+func SetScriptDir(dir string) {
+	scriptDirMutex.Lock()
+	defer scriptDirMutex.Unlock()
+	scriptDir = dir
+}
+
+// ScriptPath returns the path of a script file
+func ScriptPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	scriptDirMutex.RLock()
+	defer scriptDirMutex.RUnlock()
+	return filepath.Join(scriptDir, name)
+}
+
+// scriptCheck is the status check of a service running a Starlark script
+type scriptCheck struct {
+	script  string
+	config  map[string]interface{}
+	timeout time.Duration
+}
+
+func newScriptCheck(service *Service) *scriptCheck {
+	timeout := service.ScriptTimeout
+	if timeout <= 0 {
+		timeout = 30
+	}
+	return &scriptCheck{
+		script:  service.Script,
+		config:  service.ScriptConfig,
+		timeout: time.Duration(timeout) * time.Second,
+	}
+}
+
+// run executes the script and converts the status it returns to a result.
+// The script file is read on every run, so edits apply without a restart.
+func (c *scriptCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	response, card, err := runScript(ctx, c.script, serviceName, c.config)
+	elapsed := time.Since(startTime)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: err.Error(), ResponseTime: elapsed}
+	}
+
+	result := &StatusResult{
+		State:        parseStaticStatus(response.State),
+		Message:      response.Message,
+		ResponseTime: elapsed,
+		Card:         card,
+	}
+	for _, field := range response.Fields {
+		result.Details = append(result.Details, StatusDetail{Label: field.Label, Value: field.Value})
+	}
+	return result
+}
+
+// runScript executes a script, calls its check function and, if defined, its
+// render function, and returns the status and the rendered card lines
+func runScript(ctx context.Context, name, serviceName string, config map[string]interface{}) (*PluginResponse, []string, error) {
+	path := ScriptPath(name)
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("script %s: %w", name, err)
+	}
+
+	thread := &starlark.Thread{
+		Name:  serviceName,
+		Print: func(_ *starlark.Thread, msg string) { logging.Debug("Script %s: %s", name, msg) },
+	}
+	thread.SetLocal("context", ctx)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel("timed out")
+		case <-done:
+		}
+	}()
+
+	globals, err := starlark.ExecFile(thread, path, src, scriptBuiltins)
+	if err != nil {
+		return nil, nil, scriptError(name, err)
+	}
+
+	check, ok := globals["check"].(starlark.Callable)
+	if !ok {
+		return nil, nil, fmt.Errorf("script %s does not define check(config)", name)
+	}
+	value, err := starlark.Call(thread, check, starlark.Tuple{toStarlark(config)}, nil)
+	if err != nil {
+		return nil, nil, scriptError(name, err)
+	}
+	response, err := scriptResponse(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("script %s: check returned %w", name, err)
+	}
+
+	render, ok := globals["render"].(starlark.Callable)
+	if !ok {
+		return response, nil, nil
+	}
+	value, err = starlark.Call(thread, render, starlark.Tuple{value}, nil)
+	if err != nil {
+		return nil, nil, scriptError(name, err)
+	}
+	card, err := scriptLines(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("script %s: render returned %w", name, err)
+	}
+	return response, card, nil
+}
+
+// scriptError describes an error raised while running a script, including
+// the script position for evaluation errors
+func scriptError(name string, err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("script %s: %s", name, evalErr.Backtrace())
+	}
+	return fmt.Errorf("script %s: %w", name, err)
+}
+
+// scriptResponse converts the status dict returned by a check function
+func scriptResponse(value starlark.Value) (*PluginResponse, error) {
+	dict, ok := value.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("%s, expected a dict", value.Type())
+	}
+
+	response := &PluginResponse{
+		State:   dictString(dict, "state"),
+		Message: dictString(dict, "message"),
+	}
+	fields, found, _ := dict.Get(starlark.String("fields"))
+	if !found || fields == starlark.None {
+		return response, nil
+	}
+	list, ok := fields.(starlark.Indexable)
+	if !ok {
+		return nil, fmt.Errorf("fields of type %s, expected a list", fields.Type())
+	}
+	for i := 0; i < list.Len(); i++ {
+		field, ok := list.Index(i).(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("field of type %s, expected a dict", list.Index(i).Type())
+		}
+		response.Fields = append(response.Fields, PluginField{
+			Label: dictString(field, "label"),
+			Value: dictString(field, "value"),
+			State: dictString(field, "state"),
+		})
+	}
+	return response, nil
+}
+
+// scriptLines converts the value returned by a render function to lines
+func scriptLines(value starlark.Value) ([]string, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.String:
+		return []string{string(v)}, nil
+	case starlark.Indexable:
+		lines := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			lines = append(lines, starlarkString(v.Index(i)))
+		}
+		return lines, nil
+	}
+	return nil, fmt.Errorf("%s, expected a string or a list of strings", value.Type())
+}
+
+// dictString returns a dict entry as a string, or "" if missing
+func dictString(dict *starlark.Dict, key string) string {
+	value, found, _ := dict.Get(starlark.String(key))
+	if !found || value == starlark.None {
+		return ""
+	}
+	return starlarkString(value)
+}
+
+// starlarkString returns strings unquoted and other values formatted
+func starlarkString(value starlark.Value) string {
+	if s, ok := starlark.AsString(value); ok {
+		return s
+	}
+	return value.String()
+}
+
+// toStarlark converts a value decoded from YAML to a Starlark value
+func toStarlark(v interface{}) starlark.Value {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None
+	case bool:
+		return starlark.Bool(v)
+	case int:
+		return starlark.MakeInt(v)
+	case float64:
+		return starlark.Float(v)
+	case string:
+		return starlark.String(v)
+	case []interface{}:
+		list := make([]starlark.Value, 0, len(v))
+		for _, item := range v {
+			list = append(list, toStarlark(item))
+		}
+		return starlark.NewList(list)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(v))
+		for _, key := range keys {
+			_ = dict.SetKey(starlark.String(key), toStarlark(v[key]))
+		}
+		return dict
+	}
+	return starlark.String(fmt.Sprint(v))
+}
+
+// scriptBuiltins are the modules predeclared for scripts
+var scriptBuiltins = starlark.StringDict{
+	"json": json.Module,
+	"time": startime.Module,
+	"http": &starlarkstruct.Module{
+		Name:    "http",
+		Members: starlark.StringDict{"get": starlark.NewBuiltin("http.get", scriptHTTPGet)},
+	},
+}
+
+// scriptHTTPGet implements http.get(url, headers={}, timeout=10, skip_verify=False),
+// returning a struct with the status code, body and headers of the response
+func scriptHTTPGet(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var url string
+	var headers *starlark.Dict
+	timeout := 10
+	skipVerify := false
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs,
+		"url", &url, "headers?", &headers, "timeout?", &timeout, "skip_verify?", &skipVerify); err != nil {
+		return nil, err
+	}
+
+	ctx, _ := thread.Local("context").(context.Context)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	if headers != nil {
+		for _, item := range headers.Items() {
+			req.Header.Set(starlarkString(item[0]), starlarkString(item[1]))
+		}
+	}
+
+	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+	tlsConfig, err := clientTLSConfig(skipVerify, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	if skipVerify || tlsConfig.RootCAs != nil {
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxScriptResponse))
+	if err != nil {
+		return nil, fmt.Errorf("%s: error reading response: %w", b.Name(), err)
+	}
+
+	respHeaders := starlark.NewDict(len(resp.Header))
+	for key := range resp.Header {
+		_ = respHeaders.SetKey(starlark.String(key), starlark.String(resp.Header.Get(key)))
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"status_code": starlark.MakeInt(resp.StatusCode),
+		"body":        starlark.String(body),
+		"headers":     respHeaders,
+	}), nil
+}
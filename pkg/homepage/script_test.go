@@ -0,0 +1,73 @@
+package homepage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		w.Write([]byte(`{"depth": 150}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	SetScriptDir(dir)
+	defer SetScriptDir("")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "queue.star"), []byte(`
+def check(config):
+    resp = http.get(config["url"], headers = {"X-Token": config["token"]})
+    depth = json.decode(resp.body)["depth"]
+    state = "warning" if depth > config["warnAbove"] else "ok"
+    return {"state": state, "message": "%d queued" % depth, "fields": [{"label": "Queued", "value": depth}]}
+
+def render(status):
+    return ["[yellow]" + status["message"], "Depth: %s" % status["fields"][0]["value"]]
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.star"), []byte("def check(config):\n    return 1 // 0\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nocheck.star"), []byte("x = 1\n"), 0644))
+
+	service := &Service{Name: "Queue", Script: "queue.star", ScriptConfig: map[string]interface{}{
+		"url": server.URL, "token": "secret", "warnAbove": 100,
+	}}
+	result := CheckOnce(context.Background(), service, nil)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "150 queued", result.Message)
+	assert.Equal(t, []StatusDetail{{Label: "Queued", Value: "150"}}, result.Details)
+	assert.Equal(t, []string{"[yellow]150 queued", "Depth: 150"}, result.Card)
+
+	result = CheckOnce(context.Background(), &Service{Name: "Broken", Script: "broken.star"}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "division by zero")
+
+	result = CheckOnce(context.Background(), &Service{Name: "No check", Script: "nocheck.star"}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "does not define check")
+}
+
+func TestScriptTimeout(t *testing.T) {
+	dir := t.TempDir()
+	SetScriptDir(dir)
+	defer SetScriptDir("")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "loop.star"), []byte(`
+def check(config):
+    for i in range(1000000000):
+        pass
+`), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := CheckOnce(ctx, &Service{Name: "Loop", Script: "loop.star"}, nil)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "timed out")
+}
@@ -0,0 +1,45 @@
+package homepage
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// searchProviders maps the built-in search providers to their URLs
+var searchProviders = map[string]string{
+	"duckduckgo": "https://duckduckgo.com/?q=",
+	"google":     "https://www.google.com/search?q=",
+	"bing":       "https://www.bing.com/search?q=",
+	"brave":      "https://search.brave.com/search?q=",
+	"startpage":  "https://www.startpage.com/do/search?q=",
+}
+
+// ProviderName returns the name of the configured search provider
+func (s SearchSettings) ProviderName() string {
+	if s.Provider == "" {
+		return "duckduckgo"
+	}
+	return strings.ToLower(s.Provider)
+}
+
+// SearchURL returns the URL searching the configured provider for query.
+// The {query} placeholder of the URL template is replaced by the escaped
+// query; templates without it get the query appended.
+func (s SearchSettings) SearchURL(query string) (string, error) {
+	template := s.URL
+	if provider := s.ProviderName(); provider != "custom" {
+		var ok bool
+		if template, ok = searchProviders[provider]; !ok {
+			return "", fmt.Errorf("unknown search provider %q", s.Provider)
+		}
+	} else if template == "" {
+		return "", fmt.Errorf("search provider custom requires a url")
+	}
+
+	escaped := url.QueryEscape(query)
+	if strings.Contains(template, "{query}") {
+		return strings.ReplaceAll(template, "{query}", escaped), nil
+	}
+	return template + escaped, nil
+}
@@ -0,0 +1,31 @@
+package homepage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchURL(t *testing.T) {
+	url, err := SearchSettings{}.SearchURL("go & tview")
+	require.NoError(t, err)
+	assert.Equal(t, "https://duckduckgo.com/?q=go+%26+tview", url)
+
+	url, err = SearchSettings{Provider: "Google"}.SearchURL("termhome")
+	require.NoError(t, err)
+	assert.Equal(t, "https://www.google.com/search?q=termhome", url)
+
+	url, err = SearchSettings{Provider: "custom", URL: "https://search.lan/?q={query}&lang=en"}.SearchURL("a b")
+	require.NoError(t, err)
+	assert.Equal(t, "https://search.lan/?q=a+b&lang=en", url)
+
+	url, err = SearchSettings{Provider: "custom", URL: "https://www.ecosia.org/search?q="}.SearchURL("trees")
+	require.NoError(t, err)
+	assert.Equal(t, "https://www.ecosia.org/search?q=trees", url)
+
+	_, err = SearchSettings{Provider: "custom"}.SearchURL("x")
+	assert.Error(t, err)
+	_, err = SearchSettings{Provider: "altavista"}.SearchURL("x")
+	assert.Error(t, err)
+}
@@ -0,0 +1,188 @@
+package homepage
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// smtpImplicitTLSPort is the submission port whose connections start with
+// TLS rather than upgrading with STARTTLS
+const smtpImplicitTLSPort = "465"
+
+// smtpCheck greets a mail server with the SMTP protocol, optionally saying
+// EHLO and upgrading the connection with STARTTLS, for mail servers whose
+// port may be open while they refuse mail
+type smtpCheck struct {
+	address    string
+	startTLS   bool
+	ehlo       string // Name sent with EHLO, empty to skip it
+	skipVerify bool
+	caFile     string
+	caDir      string
+	timeout    time.Duration
+}
+
+// smtpSession is what a conversation with a mail server found
+type smtpSession struct {
+	banner     string
+	extensions []string // Extensions listed in answer to EHLO, after STARTTLS if used
+	tls        string   // TLS version, empty without TLS
+	greeting   time.Duration
+}
+
+// newSMTPCheck creates an SMTP check for a service
+func newSMTPCheck(service *Service) *smtpCheck {
+	timeout := service.SMTPCheckTimeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	address := service.SMTPCheck
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), "25")
+	}
+	ehlo := service.SMTPCheckEHLO
+	if ehlo == "" && service.SMTPCheckStartTLS {
+		// STARTTLS is only offered in answer to EHLO
+		ehlo = "localhost"
+	}
+	return &smtpCheck{
+		address:    address,
+		startTLS:   service.SMTPCheckStartTLS,
+		ehlo:       ehlo,
+		skipVerify: service.SMTPCheckSkipVerify,
+		caFile:     service.CAFile,
+		caDir:      service.CADir,
+		timeout:    time.Duration(timeout) * time.Second,
+	}
+}
+
+// run greets the server and reports its banner and the time it took
+func (c *smtpCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	session, err := c.greet(ctx, start)
+	elapsed := time.Since(start)
+
+	details := []StatusDetail{{Label: "Server", Value: c.address}}
+	if err != nil {
+		message := err.Error()
+		return &StatusResult{State: StatusCritical, Message: strings.ToUpper(message[:1]) + message[1:], Details: details}
+	}
+	details = append(details, StatusDetail{Label: "Banner", Value: session.banner})
+	if session.tls != "" {
+		details = append(details, StatusDetail{Label: "TLS", Value: session.tls})
+	}
+	if len(session.extensions) > 0 {
+		details = append(details, StatusDetail{Label: "Extensions", Value: strings.Join(session.extensions, ", ")})
+	}
+	details = append(details,
+		StatusDetail{Label: "Greeting", Value: session.greeting.Round(time.Microsecond).String()},
+		StatusDetail{Label: "Total", Value: elapsed.Round(time.Microsecond).String()},
+	)
+
+	message := session.banner
+	if session.tls != "" {
+		message += ", " + session.tls
+	}
+	return &StatusResult{
+		State:        StatusOK,
+		Message:      fmt.Sprintf("%s (%s)", message, elapsed.Round(time.Millisecond)),
+		ResponseTime: elapsed,
+		Details:      details,
+	}
+}
+
+// greet connects to the server, reads its banner, says EHLO and switches to
+// TLS if configured, then quits
+func (c *smtpCheck) greet(ctx context.Context, start time.Time) (*smtpSession, error) {
+	conn, err := dialCached(ctx, "tcp", c.address)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	session := &smtpSession{}
+	host, port, _ := net.SplitHostPort(c.address)
+	if port == smtpImplicitTLSPort {
+		if conn, err = c.handshake(ctx, conn, host); err != nil {
+			return nil, err
+		}
+		session.tls = tls.VersionName(conn.(*tls.Conn).ConnectionState().Version)
+	}
+
+	text := textproto.NewConn(conn)
+	_, banner, err := ftpResponse(text, 220)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected greeting: %w", err)
+	}
+	session.banner = firstLine(banner)
+	session.greeting = time.Since(start)
+
+	if c.ehlo != "" {
+		if session.extensions, err = smtpEHLO(text, c.ehlo); err != nil {
+			return nil, err
+		}
+	}
+	if c.startTLS {
+		if !slices.Contains(session.extensions, "STARTTLS") {
+			return nil, fmt.Errorf("STARTTLS not offered by %s", session.banner)
+		}
+		if _, _, err := ftpCommand(text, 220, "STARTTLS"); err != nil {
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+		tlsConn, err := c.handshake(ctx, conn, host)
+		if err != nil {
+			return nil, err
+		}
+		session.tls = tls.VersionName(tlsConn.ConnectionState().Version)
+		// Extensions such as AUTH may only be offered over TLS
+		text = textproto.NewConn(tlsConn)
+		if session.extensions, err = smtpEHLO(text, c.ehlo); err != nil {
+			return nil, err
+		}
+	}
+	ftpCommand(text, 221, "QUIT")
+	return session, nil
+}
+
+// handshake starts TLS on a connection to the server
+func (c *smtpCheck) handshake(ctx context.Context, conn net.Conn, host string) (*tls.Conn, error) {
+	config, err := clientTLSConfig(c.skipVerify, c.caFile, c.caDir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading CA certificates: %w", err)
+	}
+	config.ServerName = host
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	return tlsConn, nil
+}
+
+// smtpEHLO greets the server with EHLO and returns the keywords of the
+// extensions it lists, such as STARTTLS or AUTH
+func smtpEHLO(text *textproto.Conn, name string) ([]string, error) {
+	_, message, err := ftpCommand(text, 250, "EHLO %s", name)
+	if err != nil {
+		return nil, fmt.Errorf("EHLO failed: %w", err)
+	}
+	// The first line holds the name of the server
+	var extensions []string
+	for _, line := range strings.Split(message, "\n")[1:] {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			extensions = append(extensions, strings.ToUpper(fields[0]))
+		}
+	}
+	return extensions, nil
+}
@@ -0,0 +1,100 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveFakeSMTP greets SMTP clients with banner and answers EHLO, offering
+// STARTTLS with a self-signed certificate if startTLS is set, returning the
+// address of the server
+func serveFakeSMTP(t *testing.T, banner string, startTLS bool) string {
+	dir := t.TempDir()
+	writeTestKeyPair(t, filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), "mail.test")
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	require.NoError(t, err)
+
+	return serveFakeBroker(t, func(conn net.Conn) {
+		fmt.Fprintf(conn, "%s\r\n", banner)
+		reader := bufio.NewReader(conn)
+		secure := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+			switch command {
+			case "EHLO":
+				fmt.Fprint(conn, "250-mail.test Hello\r\n250-SIZE 10240000\r\n")
+				if startTLS && !secure {
+					fmt.Fprint(conn, "250-STARTTLS\r\n")
+				}
+				if secure {
+					fmt.Fprint(conn, "250-AUTH PLAIN LOGIN\r\n")
+				}
+				fmt.Fprint(conn, "250 8BITMIME\r\n")
+			case "STARTTLS":
+				fmt.Fprint(conn, "220 Ready to start TLS\r\n")
+				tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+				if tlsConn.Handshake() != nil {
+					return
+				}
+				conn, reader, secure = tlsConn, bufio.NewReader(tlsConn), true
+			case "QUIT":
+				fmt.Fprint(conn, "221 Bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "502 Command not implemented\r\n")
+			}
+		}
+	})
+}
+
+func TestSMTPCheck(t *testing.T) {
+	address := serveFakeSMTP(t, "220 mail.test ESMTP Postfix", true)
+
+	result := newSMTPCheck(&Service{SMTPCheck: address}).run(context.Background(), "Mail")
+	require.Equal(t, StatusOK, result.State, result.Message)
+	assert.Contains(t, result.Message, "mail.test ESMTP Postfix (")
+	assert.Positive(t, result.ResponseTime)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Banner", Value: "mail.test ESMTP Postfix"})
+
+	result = newSMTPCheck(&Service{SMTPCheck: address, SMTPCheckEHLO: "dashboard.test"}).run(context.Background(), "Mail")
+	require.Equal(t, StatusOK, result.State, result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Extensions", Value: "SIZE, STARTTLS, 8BITMIME"})
+
+	// The extensions are listed again over TLS
+	result = newSMTPCheck(&Service{SMTPCheck: address, SMTPCheckStartTLS: true, SMTPCheckSkipVerify: true}).run(context.Background(), "Mail")
+	require.Equal(t, StatusOK, result.State, result.Message)
+	assert.Contains(t, result.Message, "mail.test ESMTP Postfix, TLS 1.3 (")
+	assert.Contains(t, result.Details, StatusDetail{Label: "Extensions", Value: "SIZE, AUTH, 8BITMIME"})
+
+	// The self-signed certificate is not trusted
+	result = newSMTPCheck(&Service{SMTPCheck: address, SMTPCheckStartTLS: true}).run(context.Background(), "Mail")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "TLS handshake failed")
+}
+
+func TestSMTPCheckFailures(t *testing.T) {
+	result := newSMTPCheck(&Service{SMTPCheck: serveFakeSMTP(t, "554 No SMTP service here", false)}).run(context.Background(), "Mail")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Unexpected greeting: 554 No SMTP service here", result.Message)
+
+	result = newSMTPCheck(&Service{SMTPCheck: serveFakeSMTP(t, "220 mail.test ESMTP", false), SMTPCheckStartTLS: true}).run(context.Background(), "Mail")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "STARTTLS not offered by mail.test ESMTP", result.Message)
+
+	// The default port is 25
+	assert.Equal(t, "mail.test:25", newSMTPCheck(&Service{SMTPCheck: "mail.test"}).address)
+	assert.Equal(t, "[fd00::25]:25", newSMTPCheck(&Service{SMTPCheck: "fd00::25"}).address)
+}
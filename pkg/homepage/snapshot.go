@@ -0,0 +1,128 @@
+package homepage
+
+import (
+	"sort"
+	"time"
+)
+
+// StatusEntry is a copy of the status of a single service
+type StatusEntry struct {
+	StatusResult          // Copy of the last result of the service
+	Name         string   // Name of the service
+	Group        string   // Group the service is shown in, empty if none
+	Note         string   // Note attached to the service, empty if none
+	Monitored    bool     // Whether the service is monitored, false for plain links
+	Service      *Service // Configuration of the service, shared and not to be modified
+}
+
+// GetAllStatuses returns a snapshot of the status of all services. Services
+// are listed in display order, once for each group they are shown in,
+// followed by the monitored services not shown in a group, ordered by name.
+// Services that aren't monitored are listed with an unknown state, or the
+// status restored from the previous run. The entries are copies and remain
+// valid while monitoring continues.
+func (sm *StatusMonitor) GetAllStatuses() []StatusEntry {
+	groups := GetCachedGroups()
+
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	entries := make([]StatusEntry, 0, len(sm.results))
+	add := func(service *Service, name, group string) {
+		entry := StatusEntry{Name: name, Group: group, Note: sm.serviceNotes[name], Service: service}
+		if result, exists := sm.results[name]; exists {
+			entry.StatusResult, entry.Monitored = *result, true
+		} else {
+			entry.StatusResult = *sm.unmonitoredResult(name)
+		}
+		entries = append(entries, entry)
+	}
+
+	grouped := make(map[string]bool)
+	for _, group := range groups {
+		for _, service := range group.Services {
+			add(service, service.Name, group.Name)
+			grouped[service.Name] = true
+		}
+	}
+
+	var rest []string
+	for name := range sm.results {
+		if !grouped[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		add(sm.services[name], name, "")
+	}
+	return entries
+}
+
+// StatusSnapshot is the status of all services of an instance, as served by
+// the HTTP API and pulled by remote instances
+type StatusSnapshot struct {
+	Instance  string        `json:"instance"`           // Name of the instance (settings instanceName)
+	Generated time.Time     `json:"generated"`          // When the snapshot was taken
+	Groups    []GroupStatus `json:"groups"`             // Service groups in display order
+	Interval  int           `json:"interval,omitempty"` // Push interval in seconds, set by agents
+}
+
+// GroupStatus is the status of the services of a group
+type GroupStatus struct {
+	Name     string          `json:"name"`
+	Note     string          `json:"note,omitempty"`
+	Services []ServiceStatus `json:"services"`
+}
+
+// ServiceStatus is the status of a single service
+type ServiceStatus struct {
+	Name           string      `json:"name"`
+	Href           string      `json:"href,omitempty"`
+	Description    string      `json:"description,omitempty"`
+	Icon           string      `json:"icon,omitempty"`
+	State          StatusState `json:"state"`
+	Message        string      `json:"message,omitempty"`
+	ResponseTimeMs int64       `json:"responseTimeMs,omitempty"`
+	LastChecked    time.Time   `json:"lastChecked"`
+	Note           string      `json:"note,omitempty"`
+}
+
+// Snapshot returns the current status of the services shown in groups.
+// Services that aren't monitored are exported as unknown, with their link.
+// Services pulled from remote instances are left out, so that instances
+// aggregating each other don't echo statuses back and forth.
+func (sm *StatusMonitor) Snapshot(instance string) *StatusSnapshot {
+	snapshot := &StatusSnapshot{
+		Instance:  instance,
+		Generated: sm.clock.Now(),
+		Groups:    []GroupStatus{},
+	}
+
+	for _, entry := range sm.GetAllStatuses() {
+		if entry.Group == "" || entry.Service == nil || entry.Service.Remote != "" {
+			continue
+		}
+
+		if len(snapshot.Groups) == 0 || snapshot.Groups[len(snapshot.Groups)-1].Name != entry.Group {
+			snapshot.Groups = append(snapshot.Groups, GroupStatus{Name: entry.Group, Note: sm.GroupNote(entry.Group), Services: []ServiceStatus{}})
+		}
+		status := ServiceStatus{
+			Name:        entry.Name,
+			Href:        entry.Service.Href,
+			Description: entry.Service.Description,
+			Icon:        entry.Service.Icon,
+			State:       StatusUnknown,
+			Note:        entry.Note,
+		}
+		if entry.Monitored {
+			status.State = entry.State
+			status.Message = entry.Message
+			status.ResponseTimeMs = entry.ResponseTime.Milliseconds()
+			status.LastChecked = entry.LastChecked
+		}
+		group := &snapshot.Groups[len(snapshot.Groups)-1]
+		group.Services = append(group.Services, status)
+	}
+	return snapshot
+}
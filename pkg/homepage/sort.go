@@ -0,0 +1,41 @@
+package homepage
+
+import "sort"
+
+// SortServiceGroups orders groups by their layout weight and the services within
+// each group by their own weight. Sorting is stable, so items with equal weight
+// keep the order in which they appear in the configuration.
+func SortServiceGroups(groups []*ServiceGroup, layout map[string]GroupLayout) {
+	sortGroups(groups, layout)
+	for _, group := range groups {
+		sortServices(group.Services)
+	}
+}
+
+// SortBookmarkGroups orders bookmark groups by their layout weight and the
+// bookmarks within each group by their own weight, keeping configuration order
+// for equal weights.
+func SortBookmarkGroups(groups []*BookmarkGroup, layout map[string]GroupLayout) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		return layout[groups[i].Name].Weight < layout[groups[j].Name].Weight
+	})
+	for _, group := range groups {
+		sort.SliceStable(group.Bookmarks, func(i, j int) bool {
+			return group.Bookmarks[i].Weight < group.Bookmarks[j].Weight
+		})
+	}
+}
+
+// sortServices orders services by weight, keeping configuration order for equal weights
+func sortServices(services []*Service) {
+	sort.SliceStable(services, func(i, j int) bool {
+		return services[i].Weight < services[j].Weight
+	})
+}
+
+// sortGroups orders service groups by their layout weight
+func sortGroups(groups []*ServiceGroup, layout map[string]GroupLayout) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		return layout[groups[i].Name].Weight < layout[groups[j].Name].Weight
+	})
+}
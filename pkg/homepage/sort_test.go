@@ -0,0 +1,31 @@
+package homepage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSortServiceGroups checks that groups and services are ordered by weight with stable ties.
+func TestSortServiceGroups(t *testing.T) {
+	groups := []*ServiceGroup{
+		{Name: "First", Services: []*Service{{Name: "A", Weight: 10}, {Name: "B"}, {Name: "C"}}},
+		{Name: "Second", Services: []*Service{{Name: "D"}}},
+		{Name: "Third", Services: []*Service{{Name: "E"}}},
+	}
+	layout := map[string]GroupLayout{
+		"Third": {Weight: -1},
+	}
+
+	SortServiceGroups(groups, layout)
+
+	assert.Equal(t, "Third", groups[0].Name, "Group with the lowest weight should come first")
+	assert.Equal(t, "First", groups[1].Name, "Groups with equal weight should keep their order")
+	assert.Equal(t, "Second", groups[2].Name, "Groups with equal weight should keep their order")
+
+	names := []string{}
+	for _, service := range groups[1].Services {
+		names = append(names, service.Name)
+	}
+	assert.Equal(t, []string{"B", "C", "A"}, names, "Services should be ordered by weight with stable ties")
+}
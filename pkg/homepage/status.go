@@ -1,21 +1,25 @@
 package homepage
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
-	"os/exec"
+	"net/http/httptrace"
 	"regexp"
-	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/deblasis/termhome/pkg/logging"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 )
 
 // StatusState represents the state of a service
@@ -37,50 +41,99 @@ type StatusUpdateFunc func(serviceName string, state StatusState, message string
 
 // StatusResult represents the result of a status check
 type StatusResult struct {
-	State        StatusState   // The state of the service (OK, Warning, Critical, Unknown)
-	Message      string        // A message with additional information (e.g. response time)
-	ResponseTime time.Duration // Time it took to get a response
-	LastChecked  time.Time     // When the status was last checked
+	State        StatusState    // The state of the service (OK, Warning, Critical, Unknown)
+	Message      string         // A message with additional information (e.g. response time)
+	ResponseTime time.Duration  // Time it took to get a response
+	LastChecked  time.Time      // When the status was last checked
+	Details      []StatusDetail // Diagnostic details of the last check (timings, headers, ...)
+	Card         []string       // Lines rendered by a script for the service card
+	Unconfirmed  StatusState    // State of the latest checks while too few agree to change State
+	Stale        bool           // Restored from a previous run and not checked since
+}
+
+// StatusDetail is a single diagnostic label/value pair of a status check
+type StatusDetail struct {
+	Label string
+	Value string
 }
 
 // StatusMonitor manages the status checking for services
 type StatusMonitor struct {
-	services       map[string]*Service      // Map of service names to services
-	results        map[string]*StatusResult // Map of service names to status results
-	stopChannels   map[string]chan struct{} // Channels to stop the monitoring goroutines
-	updateFunc     StatusUpdateFunc         // Function to call when a status changes
-	globalInterval int                      // Global interval override from settings
-	mutex          sync.RWMutex             // For thread-safe access to results map
+	services            map[string]*Service         // Map of service names to services
+	results             map[string]*StatusResult    // Map of service names to status results, replaced rather than modified
+	widgetResults       map[string]*WidgetResult    // Map of service names to widget results, replaced rather than modified
+	stopChannels        map[string]chan struct{}    // Channels to stop the monitoring goroutines
+	updateFunc          StatusUpdateFunc            // Function to call when a status changes
+	globalInterval      Seconds                     // Global interval override from settings
+	globalRetryInterval Seconds                     // Default retry interval for failing services from settings
+	mutex               sync.RWMutex                // Protects the maps, not the results they point to
+	agents              map[string]*pushAgent       // Agents pushing their statuses, by instance name
+	agentsMutex         sync.Mutex                  // Protects agents
+	heartbeats          map[string]*heartbeat       // Heartbeat checks by heartbeat id
+	heartbeatsMutex     sync.Mutex                  // Protects heartbeats
+	clock               Clock                       // Source of time and timers
+	paused              atomic.Bool                 // Skip scheduled checks while set
+	history             *statusHistory              // Recent status changes of each service
+	discovered          map[string]bool             // Services discovered from container labels
+	disabled            map[string]bool             // Services whose checks are turned off at runtime
+	serviceNotes        map[string]string           // Notes attached to services at runtime
+	groupNotes          map[string]string           // Notes attached to groups at runtime
+	noteFunc            func()                      // Function to call when a note changes
+	bookmarkResults     map[string]*BookmarkResult  // Results of the bookmark checks by link, replaced rather than modified
+	nextChecks          map[string]time.Time        // When the next check of each service is due, zero while one runs
+	mutes               map[string]time.Time        // When the mute of each muted service ends
+	unconfirmed         map[string]unconfirmedState // Changes of state not yet confirmed by enough checks
+	restored            map[string]SavedStatus      // Statuses saved by a previous run, until their service is added
 }
 
+// ErrUnknownService is returned for services that are not monitored
+var ErrUnknownService = errors.New("unknown service")
+
+// MonitoringDisabledMessage is the status message of services whose checks
+// are turned off at runtime
+const MonitoringDisabledMessage = "Monitoring disabled"
+
 // NewStatusMonitor creates a new status monitor
 func NewStatusMonitor(updateFunc StatusUpdateFunc) *StatusMonitor {
 	return &StatusMonitor{
-		services:       make(map[string]*Service),
-		results:        make(map[string]*StatusResult),
-		stopChannels:   make(map[string]chan struct{}),
-		updateFunc:     updateFunc,
-		globalInterval: 0, // No global override by default
-		mutex:          sync.RWMutex{},
+		services:        make(map[string]*Service),
+		results:         make(map[string]*StatusResult),
+		widgetResults:   make(map[string]*WidgetResult),
+		stopChannels:    make(map[string]chan struct{}),
+		updateFunc:      updateFunc,
+		globalInterval:  0, // No global override by default
+		mutex:           sync.RWMutex{},
+		agents:          make(map[string]*pushAgent),
+		heartbeats:      make(map[string]*heartbeat),
+		clock:           realClock{},
+		history:         newStatusHistory(),
+		discovered:      make(map[string]bool),
+		disabled:        make(map[string]bool),
+		serviceNotes:    make(map[string]string),
+		groupNotes:      make(map[string]string),
+		bookmarkResults: make(map[string]*BookmarkResult),
+		nextChecks:      make(map[string]time.Time),
+		mutes:           make(map[string]time.Time),
+		unconfirmed:     make(map[string]unconfirmedState),
+		restored:        make(map[string]SavedStatus),
 	}
 }
 
+// SetClock replaces the clock used for scheduling checks and timestamping
+// results. It must be called before any service is added.
+func (sm *StatusMonitor) SetClock(clock Clock) {
+	sm.clock = clock
+}
+
 // AddService adds a service to be monitored
 func (sm *StatusMonitor) AddService(service *Service) {
-	if service.DisableStatus {
-		logging.Info("Status monitoring disabled for service %s", service.Name)
+	if !isMonitorable(service) {
 		return
 	}
 
 	// Check if Docker container monitoring is enabled
 	hasDockerMonitoring := service.Container != ""
 
-	// Don't monitor if no monitoring config is provided
-	if service.Ping == "" && service.SiteMonitor == "" && service.Status == "" && !hasDockerMonitoring {
-		logging.Debug("Service %s has no monitoring configuration, not adding to monitor", service.Name)
-		return
-	}
-
 	// Add logging for Docker container service
 	if hasDockerMonitoring {
 		logging.Debug("Adding Docker container service %s to status monitor (container=%s, server=%s)",
@@ -90,34 +143,114 @@ func (sm *StatusMonitor) AddService(service *Service) {
 	}
 
 	sm.mutex.Lock()
+	if _, exists := sm.services[service.Name]; exists {
+		sm.mutex.Unlock()
+		logging.Warn("Service %s is already monitored, skipping", service.Name)
+		return
+	}
 	sm.services[service.Name] = service
 	sm.results[service.Name] = &StatusResult{
 		State:       StatusUnknown,
 		Message:     "",
 		LastChecked: time.Time{},
 	}
+	if saved, ok := sm.restored[service.Name]; ok {
+		// Shown until the first check replaces it
+		sm.results[service.Name] = saved.result()
+		delete(sm.restored, service.Name)
+	}
+	disabled := sm.disabled[service.Name]
 	sm.mutex.Unlock()
+	sm.applyConfiguredMute(service)
+
+	if disabled {
+		sm.updateServiceStatus(service.Name, StatusUnknown, MonitoringDisabledMessage)
+		return
+	}
 
 	// If there's a static status provided, use it as initial state
 	if service.Status != "" {
-		state := StatusUnknown
-		switch strings.ToLower(service.Status) {
-		case "ok":
-			state = StatusOK
-		case "warning":
-			state = StatusWarning
-		case "critical":
-			state = StatusCritical
-		}
 		// Use empty message for static status to avoid showing "Initial static status"
-		sm.updateServiceStatus(service.Name, state, "")
+		sm.updateServiceStatus(service.Name, parseStaticStatus(service.Status), "")
 	} else if hasDockerMonitoring {
 		// For Docker container services without a static status, set initial state to unknown
 		sm.updateServiceStatus(service.Name, StatusUnknown, "Waiting for container status...")
 	}
 
+	sm.startService(service)
+}
+
+// isMonitorable reports whether a service has monitoring configured and not
+// disabled
+func isMonitorable(service *Service) bool {
+	if service.DisableStatus {
+		logging.Info("Status monitoring disabled for service %s", service.Name)
+		return false
+	}
+
+	// Don't monitor if no monitoring config is provided
+	if !hasStatusCheck(service) && service.Widget == nil {
+		logging.Debug("Service %s has no monitoring configuration, not adding to monitor", service.Name)
+		return false
+	}
+	return true
+}
+
+// startService starts the goroutines checking a service
+func (sm *StatusMonitor) startService(service *Service) {
 	// Start the monitoring goroutine for this service
 	sm.startMonitoring(service)
+
+	// Wait for heartbeats if this is a passive heartbeat check
+	if service.HeartbeatPeriod > 0 {
+		sm.startHeartbeat(service)
+	}
+
+	// Start the widget goroutine if a widget is configured
+	if service.Widget != nil {
+		sm.startWidget(service)
+	}
+}
+
+// UpdateService replaces the configuration of a monitored service and
+// restarts its checks, keeping its status until they report. Services that
+// are not monitored are added, and those left without monitoring configured
+// are removed.
+func (sm *StatusMonitor) UpdateService(service *Service) {
+	if !isMonitorable(service) {
+		sm.RemoveService(service.Name)
+		return
+	}
+
+	sm.mutex.Lock()
+	previous, exists := sm.services[service.Name]
+	if !exists {
+		sm.mutex.Unlock()
+		sm.AddService(service)
+		return
+	}
+	sm.closeStopChannels(service.Name)
+	sm.services[service.Name] = service
+	if service.Widget == nil {
+		delete(sm.widgetResults, service.Name)
+	}
+	disabled := sm.disabled[service.Name]
+	sm.mutex.Unlock()
+	if service.MuteFor != previous.MuteFor {
+		sm.applyConfiguredMute(service)
+	}
+
+	if previous.HeartbeatPeriod > 0 {
+		sm.stopHeartbeat(previous)
+	}
+	if disabled {
+		return
+	}
+	if service.Status != "" {
+		sm.updateServiceStatus(service.Name, parseStaticStatus(service.Status), "")
+	}
+	sm.startService(service)
+	logging.Info("Updated service %s in status monitor", service.Name)
 }
 
 // AddDockerMonitoring adds Docker container monitoring
@@ -127,22 +260,21 @@ func (sm *StatusMonitor) AddDockerMonitoring(config *DockerConfig) error {
 	}
 
 	// Test Docker client connection
-	cli, err := createDockerClient(config)
+	api, err := newDockerAPI(config)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Docker: %w", err)
 	}
-	cli.Close()
+	api.Close()
 
 	interval := config.Interval
 	if interval <= 0 {
 		interval = 60 // Default to 60 seconds
 	}
 
-	stopChan := make(chan struct{})
-	sm.stopChannels["docker"] = stopChan
+	stopChan := sm.addStopChannel("docker")
 
 	go func() {
-		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		ticker := sm.clock.NewTicker(time.Duration(interval) * time.Second)
 		defer ticker.Stop()
 
 		// Do an initial check immediately
@@ -150,8 +282,10 @@ func (sm *StatusMonitor) AddDockerMonitoring(config *DockerConfig) error {
 
 		for {
 			select {
-			case <-ticker.C:
-				sm.checkDockerContainers(config)
+			case <-ticker.C():
+				if !sm.Paused() {
+					sm.checkDockerContainers(config)
+				}
 			case <-stopChan:
 				return
 			}
@@ -161,342 +295,914 @@ func (sm *StatusMonitor) AddDockerMonitoring(config *DockerConfig) error {
 	return nil
 }
 
-// GetStatus returns the current status of a service
+// GetStatus returns the current status of a service. The result is shared
+// and must not be modified; later updates replace it rather than change it.
+// Services not monitored yet have the status restored from a previous run, if
+// any.
 func (sm *StatusMonitor) GetStatus(serviceName string) *StatusResult {
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
 	result, exists := sm.results[serviceName]
 	if !exists {
-		return &StatusResult{
-			State:       StatusUnknown,
-			Message:     "Service not monitored",
-			LastChecked: time.Time{},
-		}
+		return sm.unmonitoredResult(serviceName)
 	}
 	return result
 }
 
+// unmonitoredResult returns the status of a service without a result: the
+// status restored from the previous run, shown before monitoring starts, or
+// an unknown state. The mutex must be held.
+func (sm *StatusMonitor) unmonitoredResult(serviceName string) *StatusResult {
+	if saved, ok := sm.restored[serviceName]; ok {
+		return saved.result()
+	}
+	return &StatusResult{
+		State:       StatusUnknown,
+		Message:     "Service not monitored",
+		LastChecked: time.Time{},
+	}
+}
+
+// IsMonitored reports whether a service with the given name is monitored
+func (sm *StatusMonitor) IsMonitored(serviceName string) bool {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	_, exists := sm.services[serviceName]
+	return exists
+}
+
+// GetWidgetResult returns the latest widget result of a service, or nil if
+// none is available. Like status results, it must not be modified.
+func (sm *StatusMonitor) GetWidgetResult(serviceName string) *WidgetResult {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	return sm.widgetResults[serviceName]
+}
+
+// RemoveService stops monitoring a service and forgets its status. Services
+// that are not monitored are ignored. The history of its status changes is
+// kept for services restarted after a configuration change, until evicted.
+func (sm *StatusMonitor) RemoveService(serviceName string) {
+	sm.mutex.Lock()
+	service, exists := sm.services[serviceName]
+	if !exists {
+		sm.mutex.Unlock()
+		return
+	}
+	sm.closeStopChannels(serviceName)
+	delete(sm.services, serviceName)
+	delete(sm.results, serviceName)
+	delete(sm.widgetResults, serviceName)
+	delete(sm.discovered, serviceName)
+	sm.mutex.Unlock()
+
+	if service.HeartbeatPeriod > 0 {
+		sm.stopHeartbeat(service)
+	}
+	logging.Info("Removed service %s from status monitor", serviceName)
+}
+
+// SetServiceEnabled turns the checks of a service on or off. A disabled
+// service stays listed with an unknown status until enabled again. Services
+// need not be monitored yet, so that those disabled in a previous run stay
+// disabled once added.
+func (sm *StatusMonitor) SetServiceEnabled(serviceName string, enabled bool) {
+	sm.mutex.Lock()
+	if sm.disabled[serviceName] != enabled {
+		sm.mutex.Unlock()
+		return
+	}
+	if enabled {
+		delete(sm.disabled, serviceName)
+	} else {
+		sm.disabled[serviceName] = true
+	}
+	service, exists := sm.services[serviceName]
+	if exists && !enabled {
+		sm.closeStopChannels(serviceName)
+		delete(sm.widgetResults, serviceName)
+	}
+	sm.mutex.Unlock()
+
+	if !exists {
+		return
+	}
+	if !enabled {
+		if service.HeartbeatPeriod > 0 {
+			sm.stopHeartbeat(service)
+		}
+		sm.updateServiceStatus(serviceName, StatusUnknown, MonitoringDisabledMessage)
+		logging.Info("Disabled monitoring of service %s", serviceName)
+		return
+	}
+
+	if service.Status != "" {
+		sm.updateServiceStatus(serviceName, parseStaticStatus(service.Status), "")
+	} else {
+		sm.updateServiceStatus(serviceName, StatusUnknown, "")
+	}
+	sm.startService(service)
+	logging.Info("Enabled monitoring of service %s", serviceName)
+}
+
+// IsServiceEnabled reports whether the checks of a service are turned on
+func (sm *StatusMonitor) IsServiceEnabled(serviceName string) bool {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return !sm.disabled[serviceName]
+}
+
+// DisabledServices returns the names of the services whose checks are turned
+// off, sorted
+func (sm *StatusMonitor) DisabledServices() []string {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	names := make([]string, 0, len(sm.disabled))
+	for name := range sm.disabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// closeStopChannels stops the check and widget goroutines of a service. The
+// mutex must be held.
+func (sm *StatusMonitor) closeStopChannels(serviceName string) {
+	delete(sm.nextChecks, serviceName)
+	delete(sm.unconfirmed, serviceName)
+	for _, key := range []string{serviceName, "widget:" + serviceName} {
+		if stopChan, ok := sm.stopChannels[key]; ok {
+			close(stopChan)
+			delete(sm.stopChannels, key)
+		}
+	}
+}
+
+// addStopChannel registers and returns the channel stopping the monitoring
+// goroutine identified by key
+func (sm *StatusMonitor) addStopChannel(key string) chan struct{} {
+	stopChan := make(chan struct{})
+	sm.mutex.Lock()
+	sm.stopChannels[key] = stopChan
+	sm.mutex.Unlock()
+	return stopChan
+}
+
 // Stop stops all monitoring goroutines
 func (sm *StatusMonitor) Stop() {
 	logging.Info("Stopping status monitor")
+	sm.mutex.Lock()
 	for _, stopChan := range sm.stopChannels {
 		close(stopChan)
 	}
 
 	// Clear channels
 	sm.stopChannels = make(map[string]chan struct{})
+	sm.nextChecks = make(map[string]time.Time)
+	sm.unconfirmed = make(map[string]unconfirmedState)
+	sm.mutex.Unlock()
+
+	sm.stopAgents()
+	sm.stopHeartbeats()
+}
+
+// statusCheck is an active status check that is run on a schedule
+type statusCheck interface {
+	// run performs the check once and returns its result
+	run(ctx context.Context, serviceName string) *StatusResult
+}
+
+// maxCheckDuration bounds the time a single check run may take
+const maxCheckDuration = 2 * time.Minute
+
+// statusCheckKind is a kind of active check, configured by a field of the
+// services
+type statusCheckKind struct {
+	name   string                                  // Short name of the check type
+	target func(s *Service) string                 // Configured target, empty if the check isn't used
+	create func(s *Service) (statusCheck, Seconds) // Creates the check and returns its interval
+}
+
+// statusCheckKinds lists the active checks by priority: a service configuring
+// several is checked with the first one. New checks are added at the end.
+var statusCheckKinds = []statusCheckKind{
+	{"Ping", func(s *Service) string { return s.Ping }, func(s *Service) (statusCheck, Seconds) {
+		return withResponseTimeThresholds(newPingCheck(s), s), s.PingInterval
+	}},
+	{"HTTP", func(s *Service) string { return s.SiteMonitor }, func(s *Service) (statusCheck, Seconds) {
+		return withResponseTimeThresholds(newHTTPCheck(s), s), s.SiteMonitorInterval
+	}},
+	{"TCP", func(s *Service) string { return s.TCPCheck }, func(s *Service) (statusCheck, Seconds) {
+		return withResponseTimeThresholds(newTCPCheck(s), s), s.TCPCheckInterval
+	}},
+	{"DNS", func(s *Service) string { return s.DNSCheck }, func(s *Service) (statusCheck, Seconds) {
+		return withResponseTimeThresholds(newDNSCheck(s), s), s.DNSCheckInterval
+	}},
+	{"SMTP", func(s *Service) string { return s.SMTPCheck }, func(s *Service) (statusCheck, Seconds) {
+		return withResponseTimeThresholds(newSMTPCheck(s), s), s.SMTPCheckInterval
+	}},
+	{"Redis", func(s *Service) string { return s.Redis }, func(s *Service) (statusCheck, Seconds) {
+		return withResponseTimeThresholds(newRedisCheck(s), s), s.RedisInterval
+	}},
+	{"Plugin", func(s *Service) string { return s.Plugin }, func(s *Service) (statusCheck, Seconds) {
+		return newPluginCheck(s), s.PluginInterval
+	}},
+	{"Script", func(s *Service) string { return s.Script }, func(s *Service) (statusCheck, Seconds) {
+		return newScriptCheck(s), s.ScriptInterval
+	}},
+	{"Windows service", func(s *Service) string { return s.WindowsService }, func(s *Service) (statusCheck, Seconds) {
+		return newWindowsServiceCheck(s), s.WindowsServiceInterval
+	}},
+	{"launchd", func(s *Service) string { return s.Launchd }, func(s *Service) (statusCheck, Seconds) {
+		return newLaunchdCheck(s), s.LaunchdInterval
+	}},
+	{"mdadm", func(s *Service) string { return s.Mdadm }, func(s *Service) (statusCheck, Seconds) {
+		return newMdadmCheck(s), s.MdadmInterval
+	}},
+	{"File age", func(s *Service) string { return s.FileAge }, func(s *Service) (statusCheck, Seconds) {
+		return newFileAgeCheck(s), s.FileAgeInterval
+	}},
+	{"Docker volume", func(s *Service) string { return s.DockerVolume }, func(s *Service) (statusCheck, Seconds) {
+		return newDockerVolumeCheck(s), s.DockerVolumeInterval
+	}},
+	{"Docker network", func(s *Service) string { return s.DockerNetwork }, func(s *Service) (statusCheck, Seconds) {
+		return newDockerNetworkCheck(s), s.DockerNetworkInterval
+	}},
+	{"File server", func(s *Service) string { return s.FileServer }, func(s *Service) (statusCheck, Seconds) {
+		return newFileServerCheck(s), s.FileServerInterval
+	}},
+	{"OIDC", func(s *Service) string { return s.OIDC }, func(s *Service) (statusCheck, Seconds) {
+		return newOIDCCheck(s), s.OIDCInterval
+	}},
+	{"Broker", func(s *Service) string { return s.Broker }, func(s *Service) (statusCheck, Seconds) {
+		return newBrokerCheck(s), s.BrokerInterval
+	}},
+	{"Elasticsearch", func(s *Service) string { return s.Elasticsearch }, func(s *Service) (statusCheck, Seconds) {
+		return newElasticsearchCheck(s), s.ElasticsearchInterval
+	}},
+	{"S3", func(s *Service) string { return s.S3 }, func(s *Service) (statusCheck, Seconds) {
+		return newS3Check(s), s.S3Interval
+	}},
+	{"Git", func(s *Service) string { return s.Git }, func(s *Service) (statusCheck, Seconds) {
+		return newGitCheck(s), s.GitInterval
+	}},
+}
+
+// hasActiveCheck reports whether a service configures one of the active checks
+func hasActiveCheck(service *Service) bool {
+	for _, kind := range statusCheckKinds {
+		if kind.target(service) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// newStatusCheck returns the active check configured for a service, its
+// configured interval in seconds and a short name of the check type.
+// The check is nil if the service has no active check.
+func newStatusCheck(service *Service) (statusCheck, Seconds, string) {
+	for _, kind := range statusCheckKinds {
+		if kind.target(service) != "" {
+			check, interval := kind.create(service)
+			return check, interval, kind.name
+		}
+	}
+	return nil, 0, ""
+}
+
+// responseTimeCheck turns the successful results of a check warning or
+// critical when they took too long, so a service answering in 4 seconds
+// isn't shown as healthy
+type responseTimeCheck struct {
+	check    statusCheck
+	warn     time.Duration // Zero for no warning threshold
+	critical time.Duration // Zero for no critical threshold
+}
+
+// withResponseTimeThresholds wraps a check measuring response times with the
+// thresholds of a service, if it has any
+func withResponseTimeThresholds(check statusCheck, service *Service) statusCheck {
+	if service.ResponseTimeWarn <= 0 && service.ResponseTimeCritical <= 0 {
+		return check
+	}
+	return &responseTimeCheck{
+		check:    check,
+		warn:     time.Duration(max(service.ResponseTimeWarn, 0)) * time.Millisecond,
+		critical: time.Duration(max(service.ResponseTimeCritical, 0)) * time.Millisecond,
+	}
+}
+
+// run runs the wrapped check and compares its response time to the
+// thresholds
+func (c *responseTimeCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	result := c.check.run(ctx, serviceName)
+	if result.State != StatusOK || result.ResponseTime <= 0 {
+		return result
+	}
+
+	state, threshold := StatusOK, time.Duration(0)
+	switch {
+	case c.critical > 0 && result.ResponseTime >= c.critical:
+		state, threshold = StatusCritical, c.critical
+	case c.warn > 0 && result.ResponseTime >= c.warn:
+		state, threshold = StatusWarning, c.warn
+	default:
+		return result
+	}
+	logging.Debug("Check for %s: Response time %s reached the %s threshold %s", serviceName, result.ResponseTime, state, threshold)
+	result.State = state
+	result.Message = fmt.Sprintf("%s, slower than %d ms", result.Message, threshold.Milliseconds())
+	result.Details = append(result.Details, StatusDetail{Label: "Response time threshold", Value: threshold.String()})
+	return result
 }
 
 // startMonitoring starts the monitoring goroutine for a service
 func (sm *StatusMonitor) startMonitoring(service *Service) {
-	stopChan := make(chan struct{})
-	sm.stopChannels[service.Name] = stopChan
-
-	if service.Ping != "" {
-		// Set default values if not specified
-		count := service.PingCount
-		if count <= 0 {
-			count = 3
-		}
-
-		// Determine the interval
-		interval := service.PingInterval
-		if sm.globalInterval > 0 {
-			logging.Debug("Ping check for %s: Using global interval %d", service.Name, sm.globalInterval)
-			interval = sm.globalInterval
-		} else if interval <= 0 {
-			logging.Debug("Ping check for %s: Service interval (%d) is invalid, using default 60s", service.Name, interval)
-			interval = 60
-		}
-
-		// Log the final interval decision
-		logging.Debug("Ping check for %s: Final interval determined as %d seconds", service.Name, interval)
-
-		// Check for non-positive interval BEFORE creating ticker
-		if interval <= 0 {
-			logging.Error("Ping check for %s: Invalid interval (%d), cannot start ticker.", service.Name, interval)
-			sm.updateServiceStatus(service.Name, StatusCritical, fmt.Sprintf("Invalid interval: %d", interval))
-			return
-		}
-
-		host := service.Ping
-		if host == "" {
-			host = service.Href
-			// Extract host from URL if it's a URL
-			if strings.HasPrefix(host, "http") {
-				host = strings.TrimPrefix(host, "http://")
-				host = strings.TrimPrefix(host, "https://")
-				host = strings.Split(host, "/")[0]
-				host = strings.Split(host, ":")[0]
-			}
-		}
+	check, interval, kind := newStatusCheck(service)
+	if check == nil {
+		return
+	}
+
+	// Use global interval if set, otherwise use service-specific or default
+	if sm.globalInterval > 0 {
+		logging.Debug("%s check for %s: Using global interval %d", kind, service.Name, sm.globalInterval)
+		interval = sm.globalInterval
+	} else if interval <= 0 {
+		logging.Debug("%s check for %s: Service interval (%d) is invalid, using default 60s", kind, service.Name, interval)
+		interval = 60
+	}
+
+	// Recheck failing services faster if a retry interval is set
+	retryInterval := service.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = sm.globalRetryInterval
+	}
+	if retryInterval <= 0 || retryInterval > interval {
+		retryInterval = interval
+	}
+
+	logging.Info("Starting %s monitoring for %s with interval %d seconds (retry interval %d seconds)",
+		kind, service.Name, interval, retryInterval)
 
-		logging.Info("Starting ping monitoring for %s (host: %s) with interval %d seconds", service.Name, host, interval)
+	stopChan := sm.addStopChannel(service.Name)
+	// The first check is due right away
+	sm.scheduleCheck(service.Name, stopChan, 0)
 
-		// Start ping monitoring goroutine
-		go func() {
-			logging.Debug("Ping goroutine started for %s", service.Name)
-			ticker := time.NewTicker(time.Duration(interval) * time.Second)
-			defer ticker.Stop()
+	go func() {
+		logging.Debug("%s goroutine started for %s", kind, service.Name)
 
-			// Do an initial ping immediately
-			sm.pingService(service.Name, host, count)
+		// Do an initial check immediately
+		timer := sm.clock.NewTimer(0)
+		defer timer.Stop()
 
-			for {
-				select {
-				case <-ticker.C:
-					sm.pingService(service.Name, host, count)
-				case <-stopChan:
-					logging.Debug("Ping goroutine stopped for %s", service.Name)
+		for {
+			select {
+			case <-timer.C():
+				// Both channels are ready if the service was stopped meanwhile
+				if isStopped(stopChan) {
 					return
 				}
-			}
-		}()
-	} else if service.SiteMonitor != "" {
-		// Set default values if not specified
-		// Use global interval if set, otherwise use service-specific or default
-		interval := service.SiteMonitorInterval
-		if sm.globalInterval > 0 {
-			logging.Debug("HTTP check for %s: Using global interval %d", service.Name, sm.globalInterval)
-			interval = sm.globalInterval
-		} else if interval <= 0 {
-			logging.Debug("HTTP check for %s: Service interval (%d) is invalid, using default 60s", service.Name, interval)
-			interval = 60
-		}
-
-		// Log the final interval decision
-		logging.Debug("HTTP check for %s: Final interval determined as %d seconds", service.Name, interval)
-
-		// Check for non-positive interval BEFORE creating ticker
-		if interval <= 0 {
-			logging.Error("HTTP site monitoring for %s: Invalid interval (%d), cannot start ticker.", service.Name, interval)
-			sm.updateServiceStatus(service.Name, StatusCritical, fmt.Sprintf("Invalid interval: %d", interval))
-			return
-		}
-
-		method := service.SiteMonitorMethod
-		if method == "" {
-			method = "HEAD"
-		}
-		timeout := service.SiteMonitorTimeout
-		if timeout <= 0 {
-			timeout = 10
-		}
-		expectedCodes := service.SiteMonitorExpectedCodes
-		if len(expectedCodes) == 0 {
-			expectedCodes = []int{http.StatusOK}
-		}
-		headers := service.SiteMonitorHeaders
-		skipVerify := service.SiteMonitorSkipVerify
-		url := service.SiteMonitor
-
-		logging.Info("Starting HTTP site monitoring for %s (url: %s) with interval %d seconds", service.Name, url, interval)
-
-		// Start HTTP monitoring goroutine
-		go func() {
-			logging.Debug("HTTP goroutine started for %s", service.Name)
-			ticker := time.NewTicker(time.Duration(interval) * time.Second)
-			defer ticker.Stop()
-
-			// Do an initial check immediately
-			sm.checkHTTPService(service.Name, url, method, timeout, expectedCodes, headers, skipVerify)
-
-			for {
-				select {
-				case <-ticker.C:
-					sm.checkHTTPService(service.Name, url, method, timeout, expectedCodes, headers, skipVerify)
-				case <-stopChan:
-					logging.Debug("HTTP goroutine stopped for %s", service.Name)
-					return
+				if sm.Paused() {
+					sm.scheduleCheck(service.Name, stopChan, time.Duration(interval)*time.Second)
+					timer.Reset(time.Duration(interval) * time.Second)
+					continue
+				}
+				sm.scheduleCheck(service.Name, stopChan, 0)
+				result := sm.runCheck(service.Name, check, stopChan)
+				delay := nextCheckDelay(result.State, interval, retryInterval)
+				if result.Unconfirmed != "" {
+					// Confirm or dismiss the change quickly
+					delay = retryInterval.Duration()
 				}
+				sm.scheduleCheck(service.Name, stopChan, delay)
+				timer.Reset(delay)
+			case <-stopChan:
+				logging.Debug("%s goroutine stopped for %s", kind, service.Name)
+				return
 			}
-		}()
-	}
+		}
+	}()
 }
 
-// updateServiceStatus updates the status for a service and triggers the callback
-func (sm *StatusMonitor) updateServiceStatus(serviceName string, state StatusState, message string) {
+// scheduleCheck records when the next check of a service is due, after
+// delay, or that a check is running for a zero delay. Nothing is recorded
+// once stop is closed, as the service was removed or restarted meanwhile.
+func (sm *StatusMonitor) scheduleCheck(serviceName string, stop <-chan struct{}, delay time.Duration) {
 	sm.mutex.Lock()
-	result, exists := sm.results[serviceName]
-	if !exists {
-		result = &StatusResult{
-			State:       state,
-			Message:     message,
-			LastChecked: time.Now(),
-		}
-		sm.results[serviceName] = result
+	defer sm.mutex.Unlock()
+	if isStopped(stop) {
+		return
+	}
+	var due time.Time
+	if delay > 0 {
+		due = sm.clock.Now().Add(delay)
+	}
+	sm.nextChecks[serviceName] = due
+}
 
-		logging.Info("Status created for %s: State=%s, Message='%s'",
-			serviceName, state, message)
-		// Trigger callback for new status
-		if sm.updateFunc != nil {
-			defer sm.updateFunc(serviceName, state, message)
+// NextCheck returns when the next scheduled check of a service is due, and
+// false for services without scheduled checks. The time is zero while a
+// check is running.
+func (sm *StatusMonitor) NextCheck(serviceName string) (time.Time, bool) {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	due, ok := sm.nextChecks[serviceName]
+	return due, ok
+}
+
+// NextScheduledCheck returns when the next check of any service is due, and
+// false if no check is scheduled. The time is zero while a check is running.
+func (sm *StatusMonitor) NextScheduledCheck() (time.Time, bool) {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	var next time.Time
+	scheduled := false
+	for _, due := range sm.nextChecks {
+		if !scheduled || due.Before(next) {
+			next, scheduled = due, true
 		}
-	} else {
-		// Update existing result
-		oldState := result.State
-		oldMessage := result.Message
-		result.State = state
-		result.Message = message
-		result.LastChecked = time.Now()
-
-		logging.Info("Status updated for %s: State=%s, Message='%s'",
-			serviceName, state, message)
+	}
+	return next, scheduled
+}
 
-		// Call the update function if the state or message has changed
-		if (oldState != state || oldMessage != message) && sm.updateFunc != nil {
-			logging.Info("Status change detected for %s: '%s:%s' -> '%s:%s', triggering callback",
-				serviceName, oldState, oldMessage, state, message)
-			defer sm.updateFunc(serviceName, state, message)
+// CheckProgress returns how many of the services with scheduled checks are
+// waiting for their next check, and how many there are. The others are being
+// checked, or were just added and wait for their first check.
+func (sm *StatusMonitor) CheckProgress() (int, int) {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	done := 0
+	for _, due := range sm.nextChecks {
+		if !due.IsZero() {
+			done++
 		}
 	}
-	sm.mutex.Unlock()
+	return done, len(sm.nextChecks)
 }
 
-// pingService pings a host and updates its status
-func (sm *StatusMonitor) pingService(serviceName, host string, count int) {
-	// Ensure count is valid
-	if count <= 0 {
-		count = 3 // Default if invalid
+// nextCheckDelay returns the time to wait before the next check, which is the
+// retry interval while a service is failing and the normal interval otherwise
+func nextCheckDelay(state StatusState, interval, retryInterval Seconds) time.Duration {
+	if state == StatusWarning || state == StatusCritical {
+		return retryInterval.Duration()
 	}
-	logging.Debug("Ping check for %s: Starting ping to %s with count %d", serviceName, host, count)
+	return interval.Duration()
+}
 
-	var cmd *exec.Cmd
-	var pingOpts []string
+// runCheck runs a check once, records its result and returns it, holding
+// back changes of state not yet confirmed by the retries of the service.
+// Closing stop cancels the check and drops its result, as the service was
+// removed or restarted with another configuration.
+func (sm *StatusMonitor) runCheck(serviceName string, check statusCheck, stop <-chan struct{}) *StatusResult {
+	ctx, cancel := stopContext(stop, maxCheckDuration)
+	defer cancel()
 
-	if host == "" {
-		sm.updateServiceStatus(serviceName, StatusCritical, "No host specified for ping")
-		return
+	result := check.run(ctx, serviceName)
+	// Drop results of services removed while the check was running
+	if !isStopped(stop) && sm.IsMonitored(serviceName) {
+		result = sm.confirmResult(serviceName, result)
+		sm.recordResult(serviceName, result)
 	}
+	return result
+}
 
-	// Different ping parameters for different operating systems
-	switch runtime.GOOS {
-	case "windows":
-		pingOpts = []string{"-n", fmt.Sprintf("%d", count), "-w", "1000"}
-	case "darwin", "linux":
-		pingOpts = []string{"-c", fmt.Sprintf("%d", count), "-W", "1"}
-	default:
-		sm.updateServiceStatus(serviceName, StatusWarning, fmt.Sprintf("Ping not supported on %s", runtime.GOOS))
-		return
+// unconfirmedState counts the consecutive checks of a service that returned
+// another state than its current one
+type unconfirmedState struct {
+	state StatusState
+	count int
+}
+
+// confirmResult returns the result of a check of a service with retries set
+// if it keeps the state of the service or enough consecutive checks agree on
+// the new state. Otherwise, the service keeps its state and message, with
+// the state of the check in Unconfirmed, so a single dropped packet doesn't
+// turn it critical. States restored from a previous run need no confirmation.
+func (sm *StatusMonitor) confirmResult(serviceName string, result *StatusResult) *StatusResult {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	service, current := sm.services[serviceName], sm.results[serviceName]
+	if service == nil || service.Retries <= 1 || current == nil || current.Stale ||
+		current.State == StatusUnknown || current.State == result.State {
+		delete(sm.unconfirmed, serviceName)
+		return result
 	}
 
-	// Run the ping command
-	startTime := time.Now()
-	logging.Debug("Ping check for %s: Running command 'ping %v %s'", serviceName, pingOpts, host)
-	cmd = exec.Command("ping", append(pingOpts, host)...)
-	output, err := cmd.CombinedOutput()
-	elapsed := time.Since(startTime)
+	unconfirmed := sm.unconfirmed[serviceName]
+	if unconfirmed.state != result.State {
+		unconfirmed = unconfirmedState{state: result.State}
+	}
+	unconfirmed.count++
+	if unconfirmed.count >= service.Retries {
+		delete(sm.unconfirmed, serviceName)
+		logging.Info("Check for %s: %s confirmed by %d consecutive checks", serviceName, result.State, unconfirmed.count)
+		return result
+	}
+	sm.unconfirmed[serviceName] = unconfirmed
+	logging.Debug("Check for %s: %s not confirmed yet, %d of %d checks", serviceName, result.State, unconfirmed.count, service.Retries)
+
+	held := &StatusResult{
+		State:       current.State,
+		Message:     current.Message,
+		Details:     append(slices.Clip(result.Details), StatusDetail{Label: "Unconfirmed", Value: fmt.Sprintf("%s, %d of %d checks: %s", result.State, unconfirmed.count, service.Retries, result.Message)}),
+		Card:        current.Card,
+		Unconfirmed: result.State,
+	}
+	return held
+}
 
-	// Parse the ping output
-	pingResults := string(output)
-	logging.Debug("Ping check for %s: Raw output:\n%s", serviceName, pingResults)
+// stopContext returns a context canceled after timeout or once stop is closed
+func stopContext(stop <-chan struct{}, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
 
-	if err != nil {
-		// Ping failed
-		logging.Error("Ping check for %s: Command failed: %v. Output: %s", serviceName, err, pingResults)
-		sm.updateServiceStatus(serviceName, StatusCritical, fmt.Sprintf("Ping failed: %v", err))
-		return
+// isStopped reports whether stop is closed. A nil channel is never closed.
+func isStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
 	}
+}
 
-	// Extract response time from ping output
-	var avgTime string
-	var packetLoss string
+// recordResult stores the result of a check and triggers the update callback
+func (sm *StatusMonitor) recordResult(serviceName string, result *StatusResult) {
+	sm.setStatus(serviceName, result.State, result.Message, func(updated *StatusResult) {
+		if result.ResponseTime > 0 {
+			updated.ResponseTime = result.ResponseTime
+		}
+		updated.Details = result.Details
+		updated.Card = result.Card
+		updated.Unconfirmed = result.Unconfirmed
+	})
+}
 
-	// Different regex for different OS output formats
-	switch runtime.GOOS {
-	case "windows":
-		avgTimeRe := regexp.MustCompile(`Average = (\d+)ms`)
-		matches := avgTimeRe.FindStringSubmatch(pingResults)
-		if len(matches) > 1 {
-			avgTime = matches[1] + "ms"
+// CheckNow runs the active check of a monitored service right away, records
+// its result and returns it. The name is matched case-insensitively. Services
+// without an active check return their current status.
+func (sm *StatusMonitor) CheckNow(ctx context.Context, name string) (string, *StatusResult, error) {
+	sm.mutex.RLock()
+	service, exists := sm.services[name]
+	if !exists {
+		for serviceName, candidate := range sm.services {
+			if strings.EqualFold(serviceName, name) {
+				service, exists = candidate, true
+				break
+			}
 		}
+	}
+	sm.mutex.RUnlock()
+	if !exists {
+		return "", nil, ErrUnknownService
+	}
+
+	check, _, _ := newStatusCheck(service)
+	if check == nil {
+		return service.Name, sm.GetStatus(service.Name), nil
+	}
+
+	result := check.run(ctx, service.Name)
+	sm.recordResult(service.Name, sm.confirmResult(service.Name, result))
+	return service.Name, result, nil
+}
+
+// Pause stops running scheduled checks, widget refreshes and remote pulls
+// until Resume is called. Passive heartbeats and pushes are still received.
+func (sm *StatusMonitor) Pause() {
+	if !sm.paused.Swap(true) {
+		logging.Info("Status monitoring paused")
+		RequestUIRebuild()
+	}
+}
+
+// Resume resumes monitoring paused by Pause. Checks run again at their next
+// scheduled time.
+func (sm *StatusMonitor) Resume() {
+	if sm.paused.Swap(false) {
+		logging.Info("Status monitoring resumed")
+		RequestUIRebuild()
+	}
+}
+
+// Paused reports whether monitoring is paused
+func (sm *StatusMonitor) Paused() bool {
+	return sm.paused.Load()
+}
+
+// CheckOnce runs the check configured for a service once and returns the
+// detailed result without recording it. Docker container services are looked
+// up through dockerConfig, which may be nil.
+func CheckOnce(ctx context.Context, service *Service, dockerConfig *DockerConfig) *StatusResult {
+	if check, _, _ := newStatusCheck(service); check != nil {
+		return check.run(ctx, service.Name)
+	}
 
-		lossRe := regexp.MustCompile(`(\d+)% loss`)
-		matches = lossRe.FindStringSubmatch(pingResults)
-		if len(matches) > 1 {
-			packetLoss = matches[1] + "%"
+	if service.HeartbeatPeriod > 0 {
+		return &StatusResult{
+			State:   StatusUnknown,
+			Message: "Passive heartbeat check, can only be checked by a running instance",
+			Details: []StatusDetail{{Label: "Heartbeat URL", Value: "/api/heartbeat/" + HeartbeatID(service)}},
 		}
-	case "darwin":
-		// Mac format: round-trip min/avg/max/stddev = 27.222/32.582/41.860/5.139 ms
-		avgTimeRe := regexp.MustCompile(`(?:round-trip|rtt).*?=.*?(\d+\.\d+).*?ms`)
-		matches := avgTimeRe.FindStringSubmatch(pingResults)
-		if len(matches) > 1 {
-			avgTime = matches[1] + "ms"
+	}
+
+	if service.Container != "" {
+		if dockerConfig == nil {
+			dockerConfig = &DockerConfig{}
 		}
+		return checkContainerOnce(ctx, service, dockerConfig)
+	}
 
-		lossRe := regexp.MustCompile(`(\d+\.?\d*)% packet loss`)
-		matches = lossRe.FindStringSubmatch(pingResults)
-		if len(matches) > 1 {
-			packetLoss = matches[1] + "%"
+	if service.Widget != nil {
+		widget, err := NewWidget(service.Widget)
+		if err != nil {
+			return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Widget error: %v", err)}
+		}
+		startTime := time.Now()
+		widgetResult, err := widget.Fetch(ctx)
+		if err != nil {
+			return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Widget error: %v", err), ResponseTime: time.Since(startTime)}
 		}
-	case "linux":
-		// Linux format: rtt min/avg/max/mdev = 0.083/0.153/0.223/0.070 ms
-		avgTimeRe := regexp.MustCompile(`rtt min/avg/max.*?= [0-9.]+/([0-9.]+)/[0-9.]+`)
-		matches := avgTimeRe.FindStringSubmatch(pingResults)
-		if len(matches) > 1 {
-			avgTime = matches[1] + "ms"
+		result := &StatusResult{State: widgetResult.State, Message: widgetResult.Message, ResponseTime: time.Since(startTime)}
+		for _, field := range widgetResult.Fields {
+			result.Details = append(result.Details, StatusDetail{Label: field.Label, Value: field.Value})
 		}
+		return result
+	}
+
+	if service.Status != "" {
+		return &StatusResult{State: parseStaticStatus(service.Status), Message: "Static status"}
+	}
+
+	return &StatusResult{State: StatusUnknown, Message: "No status check configured"}
+}
+
+// parseStaticStatus converts a static status string into a status state
+func parseStaticStatus(status string) StatusState {
+	switch strings.ToLower(status) {
+	case "ok":
+		return StatusOK
+	case "warning":
+		return StatusWarning
+	case "critical":
+		return StatusCritical
+	}
+	return StatusUnknown
+}
+
+// updateServiceStatus updates the status for a service and triggers the callback
+func (sm *StatusMonitor) updateServiceStatus(serviceName string, state StatusState, message string) {
+	sm.setStatus(serviceName, state, message, nil)
+}
+
+// setStatus replaces the result of a service with a copy holding the new
+// state and message, further changed by update if not nil, and triggers the
+// callback if the state or message changed. Stored results are never
+// modified, so readers can use them without holding the lock.
+func (sm *StatusMonitor) setStatus(serviceName string, state StatusState, message string, update func(*StatusResult)) {
+	sm.mutex.Lock()
+	existing, exists := sm.results[serviceName]
+	result := &StatusResult{}
+	if exists {
+		*result = *existing
+	}
+	result.State = state
+	result.Message = message
+	result.LastChecked = sm.clock.Now()
+	result.Stale = false
+	if update != nil {
+		update(result)
+	}
+	sm.results[serviceName] = result
+	sm.mutex.Unlock()
+
+	if !exists || existing.State != state || existing.Message != message {
+		sm.history.add(serviceName, StatusEvent{Time: result.LastChecked, State: state, Message: message})
+	}
 
-		lossRe := regexp.MustCompile(`(\d+\.?\d*)% packet loss`)
-		matches = lossRe.FindStringSubmatch(pingResults)
-		if len(matches) > 1 {
-			packetLoss = matches[1] + "%"
+	if !exists {
+		logging.Info("Status created for %s: State=%s, Message='%s'",
+			serviceName, state, message)
+		// Trigger callback for new status
+		if sm.updateFunc != nil {
+			sm.updateFunc(serviceName, state, message)
 		}
+		return
 	}
 
-	// If we couldn't extract avg time, use elapsed time
-	if avgTime == "" {
-		avgTime = fmt.Sprintf("%.1fms", float64(elapsed.Milliseconds()))
-		logging.Warn("Ping check for %s: Could not extract avg time, using elapsed: %s", serviceName, avgTime)
+	logging.Info("Status updated for %s: State=%s, Message='%s'",
+		serviceName, state, message)
+
+	// Call the update function if the state or message has changed, or a
+	// change of state awaits confirmation
+	if existing.Unconfirmed != result.Unconfirmed && existing.State == state && existing.Message == message && sm.updateFunc != nil {
+		sm.updateFunc(serviceName, state, message)
+		return
+	}
+	if (existing.State != state || existing.Message != message) && sm.updateFunc != nil {
+		logging.Info("Status change detected for %s: '%s:%s' -> '%s:%s', triggering callback",
+			serviceName, existing.State, existing.Message, state, message)
+		sm.updateFunc(serviceName, state, message)
 	}
+}
 
-	logging.Debug("Ping check for %s: Completed - avg time: %s, packet loss: %s",
-		serviceName, avgTime, packetLoss)
+// pingCheck checks a host with ICMP echo requests, sent over an ICMP socket
+// or, on Windows, with the ICMP helper API
+type pingCheck struct {
+	host  string
+	count int
+}
+
+// newPingCheck creates a ping check for a service
+func newPingCheck(service *Service) *pingCheck {
+	// Set default values if not specified
+	count := service.PingCount
+	if count <= 0 {
+		count = 3
+	}
 
-	// Update the status based on packet loss
-	if packetLoss == "0%" || packetLoss == "" {
-		// No packet loss, service is up
-		sm.mutex.Lock()
-		if result, exists := sm.results[serviceName]; exists {
-			result.ResponseTime = elapsed
+	host := service.Ping
+	if host == "" {
+		host = service.Href
+		// Extract host from URL if it's a URL
+		if strings.HasPrefix(host, "http") {
+			host = strings.TrimPrefix(host, "http://")
+			host = strings.TrimPrefix(host, "https://")
+			host = strings.Split(host, "/")[0]
+			host = strings.Split(host, ":")[0]
 		}
-		sm.mutex.Unlock()
+	}
+
+	return &pingCheck{host: host, count: count}
+}
+
+// run pings the host and returns the resulting status
+func (c *pingCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	logging.Debug("Ping check for %s: Starting ping to %s with count %d", serviceName, c.host, c.count)
+
+	if c.host == "" {
+		return &StatusResult{State: StatusCritical, Message: "No host specified for ping"}
+	}
+
+	details := []StatusDetail{{Label: "Host", Value: c.host}}
+	lookupStart := time.Now()
+	lookup, err := checkDNSCache.resolve(ctx, c.host)
+	if err != nil || len(lookup.ips) == 0 {
+		logging.Error("Ping check for %s: Cannot resolve %s: %v", serviceName, c.host, err)
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Ping failed: cannot resolve %s", c.host), Details: details}
+	}
+	ip := lookup.ips[0]
+	details = append(details, StatusDetail{Label: "Resolved IP", Value: ip.String()})
+	if net.ParseIP(c.host) == nil {
+		details = append(details, dnsLookupDetail(lookup.cached, time.Since(lookupStart)))
+		details = append(details, lookup.details()...)
+	}
+
+	return c.ping(ctx, serviceName, ip, details)
+}
+
+// pingStats holds the round trip times of the echo replies received for the
+// echo requests sent
+type pingStats struct {
+	sent int
+	rtts []time.Duration
+}
+
+// loss returns the percentage of echo requests without reply
+func (s *pingStats) loss() int {
+	if s.sent == 0 {
+		return 100
+	}
+	return (s.sent - len(s.rtts)) * 100 / s.sent
+}
+
+// average returns the average round trip time, or 0 without replies
+func (s *pingStats) average() time.Duration {
+	if len(s.rtts) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, rtt := range s.rtts {
+		total += rtt
+	}
+	return total / time.Duration(len(s.rtts))
+}
+
+// formatRTT formats a round trip time in milliseconds with one decimal
+func formatRTT(rtt time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(rtt)/float64(time.Millisecond))
+}
+
+// pingStatus determines the status of a ping from its round trip times and
+// packet loss
+func pingStatus(serviceName string, stats *pingStats, details []StatusDetail) *StatusResult {
+	average, loss := stats.average(), stats.loss()
+	logging.Debug("Ping check for %s: Completed - %d of %d replies, avg time: %s",
+		serviceName, len(stats.rtts), stats.sent, average)
+	details = append(details, StatusDetail{Label: "Packet loss", Value: fmt.Sprintf("%d%%", loss)})
 
-		sm.updateServiceStatus(serviceName, StatusOK, fmt.Sprintf("Up (%s)", avgTime))
-	} else if strings.HasPrefix(packetLoss, "100") {
+	if loss == 100 {
 		// All packets lost, service is down
-		sm.updateServiceStatus(serviceName, StatusCritical, fmt.Sprintf("Down (%s loss)", packetLoss))
-	} else {
-		// Some packets lost, service is having issues
-		sm.mutex.Lock()
-		if result, exists := sm.results[serviceName]; exists {
-			result.ResponseTime = elapsed
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Down (%d%% loss)", loss), Details: details}
+	}
+	details = append(details,
+		StatusDetail{Label: "Average RTT", Value: formatRTT(average)},
+		StatusDetail{Label: "Min/max RTT", Value: formatRTT(slices.Min(stats.rtts)) + " / " + formatRTT(slices.Max(stats.rtts))},
+	)
+	if loss == 0 {
+		return &StatusResult{State: StatusOK, Message: fmt.Sprintf("Up (%s)", formatRTT(average)), ResponseTime: average, Details: details}
+	}
+
+	// Some packets lost, service is having issues
+	// Format with time and packet loss (will be colored differently in UI)
+	return &StatusResult{
+		State:        StatusWarning,
+		Message:      fmt.Sprintf("Degraded (%s) packet loss: %d%%", formatRTT(average), loss),
+		ResponseTime: average,
+		Details:      details,
+	}
+}
+
+// httpCheck checks a URL with an HTTP request
+type httpCheck struct {
+	url           string
+	method        string
+	timeoutSec    int
+	expectedCodes []int
+	expectedBody  string         // Text the body must contain, unless bodyPattern is set
+	bodyPattern   *regexp.Regexp // Expression the body must match
+	bodyErr       error          // Error compiling the expression
+	headers       map[string]string
+	skipVerify    bool
+	clientCert    string // Client certificate file, for mutual TLS
+	clientKey     string // Private key file of the client certificate
+	caFile        string // CA bundle trusted in addition to the system and global CAs
+	caDir         string // Directory of CA certificates trusted in addition
+}
+
+// newHTTPCheck creates an HTTP check for a service
+func newHTTPCheck(service *Service) *httpCheck {
+	// Set default values if not specified
+	method := service.SiteMonitorMethod
+	if method == "" {
+		method = "HEAD"
+		// Responses to HEAD requests have no body to look into
+		if service.SiteMonitorExpectedBody != "" {
+			method = "GET"
 		}
-		sm.mutex.Unlock()
+	}
+	timeout := service.SiteMonitorTimeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	expectedCodes := service.SiteMonitorExpectedCodes
+	if len(expectedCodes) == 0 {
+		expectedCodes = []int{http.StatusOK}
+	}
 
-		// Format with time and packet loss (will be colored differently in UI)
-		sm.updateServiceStatus(serviceName, StatusWarning, fmt.Sprintf("Degraded (%s) packet loss: %s", avgTime, packetLoss))
+	check := &httpCheck{
+		url:           service.SiteMonitor,
+		method:        method,
+		timeoutSec:    int(timeout),
+		expectedCodes: expectedCodes,
+		expectedBody:  service.SiteMonitorExpectedBody,
+		headers:       service.SiteMonitorHeaders,
+		skipVerify:    service.SiteMonitorSkipVerify,
+		clientCert:    service.SiteMonitorClientCert,
+		clientKey:     service.SiteMonitorClientKey,
+		caFile:        service.CAFile,
+		caDir:         service.CADir,
+	}
+	// Bodies between slashes are regular expressions, such as /Jellyfin \d+/
+	if expr, ok := strings.CutPrefix(check.expectedBody, "/"); ok && len(expr) > 1 && strings.HasSuffix(expr, "/") {
+		check.bodyPattern, check.bodyErr = regexp.Compile(strings.TrimSuffix(expr, "/"))
 	}
+	return check
 }
 
-// checkHTTPService performs an HTTP check for a service
-func (sm *StatusMonitor) checkHTTPService(
-	serviceName, url, method string,
-	timeoutSec int,
-	expectedCodes []int,
-	headers map[string]string,
-	skipVerify bool,
-) {
+// run performs the HTTP request and returns the resulting status
+func (c *httpCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	url, method, timeoutSec := c.url, c.method, c.timeoutSec
 	logging.Debug("HTTP check for %s: Starting check for URL %s (Method: %s, Timeout: %ds)", serviceName, url, method, timeoutSec)
 	startTime := time.Now()
-	result := StatusResult{State: StatusUnknown}
 
 	if url == "" {
-		result.State = StatusCritical
-		result.Message = "No URL specified for HTTP check"
-		sm.updateServiceStatus(serviceName, result.State, result.Message)
-		return
+		return &StatusResult{State: StatusCritical, Message: "No URL specified for HTTP check"}
+	}
+	if c.bodyErr != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid siteMonitorExpectedBody: %v", c.bodyErr)}
 	}
 
 	// Create HTTP client with timeout
@@ -511,25 +1217,47 @@ func (sm *StatusMonitor) checkHTTPService(
 		},
 	}
 
-	// Configure TLS settings if needed
-	if skipVerify {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify},
+	details := []StatusDetail{{Label: "URL", Value: url}, {Label: "Method", Value: method}}
+
+	// Resolve hosts through the DNS cache, and configure TLS settings if needed
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialCached
+	tlsConfig, err := clientTLSConfig(c.skipVerify, c.caFile, c.caDir)
+	if err != nil {
+		logging.Error("HTTP check for %s: Error loading CA certificates: %v", serviceName, err)
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Error loading CA certificates: %v", err), Details: details}
+	}
+	transport.TLSClientConfig = tlsConfig
+	if c.clientCert != "" {
+		// Loaded on every run, so renewed certificates are picked up
+		keyFile := c.clientKey
+		if keyFile == "" {
+			keyFile = c.clientCert
 		}
+		cert, err := tls.LoadX509KeyPair(expandHome(c.clientCert), expandHome(keyFile))
+		if err != nil {
+			logging.Error("HTTP check for %s: Error loading client certificate: %v", serviceName, err)
+			return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Error loading client certificate: %v", err), Details: details}
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+		details = append(details, StatusDetail{Label: "Client certificate", Value: cert.Leaf.Subject.String()})
 	}
+	defer transport.CloseIdleConnections()
+	client.Transport = transport
+
+	// Trace the request phases for detailed diagnostics
+	timings := &httpTraceTimings{}
+	trace := timings.trace(startTime)
 
 	// Create the request
-	req, err := http.NewRequestWithContext(context.Background(), method, url, nil)
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), method, url, nil)
 	if err != nil {
 		logging.Error("HTTP check for %s: Error creating request: %v", serviceName, err)
-		result.State = StatusCritical
-		result.Message = fmt.Sprintf("Error creating request: %v", err)
-		sm.updateServiceStatus(serviceName, result.State, result.Message)
-		return
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Error creating request: %v", err)}
 	}
 
 	// Add custom headers
-	for key, value := range headers {
+	for key, value := range c.headers {
 		req.Header.Set(key, value)
 	}
 
@@ -541,12 +1269,21 @@ func (sm *StatusMonitor) checkHTTPService(
 	resp, err := client.Do(req)
 	responseTime := time.Since(startTime)
 
+	// The dial may still be running after a timeout, so the timings are
+	// copied under their lock
+	phases, remoteAddr := timings.snapshot()
+	details = append(details, phases...)
+	if remoteAddr != "" {
+		details = append(details, StatusDetail{Label: "Resolved IP", Value: remoteAddr})
+	}
+	if lookup := checkDNSCache.cached(req.URL.Hostname()); lookup != nil {
+		details = append(details, lookup.details()...)
+	}
+	details = append(details, StatusDetail{Label: "Total", Value: responseTime.Round(time.Microsecond).String()})
+
 	if err != nil {
 		logging.Error("HTTP check for %s: Request failed: %v", serviceName, err)
-		result.State = StatusCritical
-		result.Message = fmt.Sprintf("Request failed: %v", err)
-		sm.updateServiceStatus(serviceName, result.State, result.Message)
-		return
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Request failed: %v", err), Details: details}
 	}
 	defer resp.Body.Close()
 
@@ -554,144 +1291,157 @@ func (sm *StatusMonitor) checkHTTPService(
 	logging.Debug("HTTP check for %s: Received response code %d in %d ms",
 		serviceName, resp.StatusCode, responseTimeMs)
 
+	details = append(details, StatusDetail{Label: "Status code", Value: resp.Status})
+	headerNames := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		details = append(details, StatusDetail{Label: "Header " + name, Value: strings.Join(resp.Header[name], ", ")})
+	}
+
 	// Check if status code is in expected codes
 	codeIsExpected := false
-	for _, expectedCode := range expectedCodes {
+	for _, expectedCode := range c.expectedCodes {
 		if resp.StatusCode == expectedCode {
 			codeIsExpected = true
 			break
 		}
 	}
 
-	if codeIsExpected {
-		// Update the response time
-		sm.mutex.Lock()
-		if result, exists := sm.results[serviceName]; exists {
-			result.ResponseTime = responseTime
+	result := &StatusResult{Details: details}
+	if codeIsExpected && c.expectedBody != "" {
+		// Proxies may answer 200 with an error page, so the body tells
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			result.State = StatusCritical
+			result.Message = fmt.Sprintf("Error reading body: %v", err)
+			return result
 		}
-		sm.mutex.Unlock()
-
-		sm.updateServiceStatus(serviceName, StatusOK, fmt.Sprintf("Up (%d ms)", responseTimeMs))
+		result.Details = append(result.Details, StatusDetail{Label: "Expected body", Value: c.expectedBody})
+		if !c.bodyMatches(body) {
+			logging.Debug("HTTP check for %s: Body of %d bytes doesn't match %s", serviceName, len(body), c.expectedBody)
+			result.State = StatusCritical
+			result.Message = fmt.Sprintf("Unexpected body: %s not found", c.expectedBody)
+			return result
+		}
+	}
+	if codeIsExpected {
+		result.State = StatusOK
+		result.Message = fmt.Sprintf("Up (%d ms)", responseTimeMs)
+		result.ResponseTime = responseTime
 	} else if resp.StatusCode >= 500 {
 		result.State = StatusCritical
 		result.Message = fmt.Sprintf("Server error: %d", resp.StatusCode)
-		sm.updateServiceStatus(serviceName, result.State, result.Message)
 	} else if resp.StatusCode >= 400 {
 		result.State = StatusWarning
 		result.Message = fmt.Sprintf("Client error: %d", resp.StatusCode)
-		sm.updateServiceStatus(serviceName, result.State, result.Message)
 	} else {
 		result.State = StatusWarning
 		result.Message = fmt.Sprintf("Unexpected response: %d", resp.StatusCode)
-		sm.updateServiceStatus(serviceName, result.State, result.Message)
-	}
-}
-
-// Basic container information for status display
-type dockerContainer struct {
-	ID     string
-	Name   string
-	Image  string
-	Status string
-	Health string
-}
-
-// createDockerClient creates a Docker client based on the config
-func createDockerClient(config *DockerConfig) (*client.Client, error) {
-	var cli *client.Client
-	var err error
-
-	// Create client based on configuration
-	if config.Socket != "" {
-		// Socket connection
-		logging.Debug("Using Docker socket: %s", config.Socket)
-		cli, err = client.NewClientWithOpts(
-			client.WithHost(fmt.Sprintf("unix://%s", config.Socket)),
-			client.WithAPIVersionNegotiation(),
-		)
-	} else if config.Host != "" {
-		// Remote host connection
-		hostArg := config.Host
-		if config.Port > 0 {
-			hostArg = fmt.Sprintf("%s:%d", config.Host, config.Port)
-		}
-		logging.Debug("Using Docker remote host: %s", hostArg)
-		cli, err = client.NewClientWithOpts(
-			client.WithHost(fmt.Sprintf("tcp://%s", hostArg)),
-			client.WithAPIVersionNegotiation(),
-		)
-	} else {
-		// Default connection
-		logging.Debug("Using default Docker connection")
-		cli, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	}
+	return result
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+// httpTraceTimings collects the durations of the phases of an HTTP request.
+// The transport calls the trace hooks from its dial goroutine, which may
+// outlive the request when it times out, so they are guarded by a mutex.
+type httpTraceTimings struct {
+	mutex                            sync.Mutex
+	dnsStart, connectStart, tlsStart time.Time
+	details                          []StatusDetail
+	remoteAddr                       string
+}
+
+// trace returns the hooks recording the phases of a request started at start
+func (t *httpTraceTimings) trace(start time.Time) *httptrace.ClientTrace {
+	since := func(from time.Time) string { return time.Since(from).Round(time.Microsecond).String() }
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.record(func() { t.dnsStart = time.Now() }) },
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			t.record(func() { t.details = append(t.details, dnsLookupDetail(info.Coalesced, time.Since(t.dnsStart))) })
+		},
+		ConnectStart: func(string, string) { t.record(func() { t.connectStart = time.Now() }) },
+		ConnectDone: func(string, string, error) {
+			t.record(func() { t.details = append(t.details, StatusDetail{Label: "Connect", Value: since(t.connectStart)}) })
+		},
+		TLSHandshakeStart: func() { t.record(func() { t.tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func() { t.details = append(t.details, StatusDetail{Label: "TLS handshake", Value: since(t.tlsStart)}) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Conn == nil {
+				return
+			}
+			addr := info.Conn.RemoteAddr().String()
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+			t.record(func() {
+				if t.remoteAddr == "" {
+					t.remoteAddr = addr
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			t.record(func() { t.details = append(t.details, StatusDetail{Label: "Time to first byte", Value: since(start)}) })
+		},
 	}
+}
+
+// record runs f with the timings locked
+func (t *httpTraceTimings) record(f func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	f()
+}
+
+// snapshot returns a copy of the phases recorded so far and the address of
+// the server
+func (t *httpTraceTimings) snapshot() ([]StatusDetail, string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return slices.Clone(t.details), t.remoteAddr
+}
 
-	return cli, nil
+// bodyMatches reports whether a response body contains the expected text or
+// matches the expected expression
+func (c *httpCheck) bodyMatches(body []byte) bool {
+	if c.bodyPattern != nil {
+		return c.bodyPattern.Match(body)
+	}
+	return bytes.Contains(body, []byte(c.expectedBody))
 }
 
 // checkDockerContainers checks the status of docker containers
 func (sm *StatusMonitor) checkDockerContainers(config *DockerConfig) error {
 	logging.Debug("Checking Docker containers status...")
 
-	// Create Docker client
-	cli, err := createDockerClient(config)
-	if err != nil {
-		logging.Error("ERROR: Failed to create Docker client: %v", err)
-		return err
-	}
-	defer cli.Close()
-
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// List containers
-	containerList, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	api, err := newDockerAPI(config)
 	if err != nil {
-		logging.Error("ERROR: Docker container list error: %v", err)
+		logging.Error("ERROR: Failed to create Docker client: %v", err)
 		return err
 	}
+	defer api.Close()
 
-	// Convert to our internal container representation
-	var containers []dockerContainer
-	for _, c := range containerList {
-		// Container name comes with a leading slash we need to remove
-		name := c.Names[0]
-		if strings.HasPrefix(name, "/") {
-			name = name[1:]
-		}
-
-		// Determine health status if available
-		health := ""
-		if c.State == "running" && c.Status != "" {
-			if strings.Contains(c.Status, "(healthy)") {
-				health = "healthy"
-			} else if strings.Contains(c.Status, "(unhealthy)") {
-				health = "unhealthy"
-			} else if strings.Contains(c.Status, "(health: starting)") {
-				health = "starting"
-			}
-		}
-
-		containers = append(containers, dockerContainer{
-			ID:     c.ID,
-			Name:   name,
-			Image:  c.Image,
-			Status: c.Status,
-			Health: health,
-		})
+	containers, err := api.ListContainers(ctx)
+	if err != nil {
+		logging.Error("ERROR: Docker container list error: %v", err)
+		return err
 	}
 
 	logging.Debug("Found %d Docker containers", len(containers))
 
 	// Create a map of Docker services by container name for easier lookup
 	dockerServices := make(map[string][]*Service)
+	sm.mutex.RLock()
 	for serviceName, service := range sm.services {
-		if service.Container != "" {
+		if service.Container != "" && !sm.disabled[serviceName] {
 			logging.Debug("Service '%s' references container: '%s', server: '%s'",
 				serviceName, service.Container, service.Server)
 
@@ -699,6 +1449,7 @@ func (sm *StatusMonitor) checkDockerContainers(config *DockerConfig) error {
 			dockerServices[service.Container] = append(dockerServices[service.Container], service)
 		}
 	}
+	sm.mutex.RUnlock()
 
 	// Log the number of services with container references
 	logging.Debug("Found %d services with container references", len(dockerServices))
@@ -750,23 +1501,78 @@ func (sm *StatusMonitor) checkDockerContainers(config *DockerConfig) error {
 		}
 	}
 
-	// Mark any remaining services as not found
+	// Mark any remaining services as not found, and retire those discovered
+	// from the labels of a container that was removed
 	for containerName, services := range dockerServices {
 		for _, service := range services {
-			if !processedServices[service.Name] {
-				logging.Debug("No container found for service '%s' (container='%s')",
-					service.Name, containerName)
-				sm.updateServiceStatus(service.Name, StatusCritical, "Container not found")
+			if processedServices[service.Name] {
+				continue
 			}
+			sm.mutex.RLock()
+			discovered := sm.discovered[service.Name]
+			sm.mutex.RUnlock()
+			if discovered {
+				logging.Info("Container '%s' of discovered service '%s' was removed", containerName, service.Name)
+				sm.RemoveService(service.Name)
+				RemoveDynamicService(service.Name)
+				continue
+			}
+			logging.Debug("No container found for service '%s' (container='%s')",
+				service.Name, containerName)
+			sm.updateServiceStatus(service.Name, StatusCritical, "Container not found")
 		}
 	}
 
 	// Autodiscovery: Check for containers with homepage labels that aren't tracked yet
-	sm.discoverContainersWithLabels(containers, processedContainers, config)
+	sm.discoverContainersWithLabels(ctx, api, containers, processedContainers, config)
 
 	return nil
 }
 
+// checkContainerOnce looks up the container of a service and returns its status
+func checkContainerOnce(ctx context.Context, service *Service, config *DockerConfig) *StatusResult {
+	startTime := time.Now()
+	api, err := newDockerAPI(config)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Docker error: %v", err)}
+	}
+	defer api.Close()
+	containers, err := api.ListContainers(ctx)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Docker error: %v", err)}
+	}
+
+	// Prefer an exact name match, then fall back to substring matches
+	match := -1
+	for i, c := range containers {
+		if c.Name == service.Container {
+			match = i
+			break
+		}
+		if match < 0 && (strings.Contains(c.Name, service.Container) || strings.Contains(service.Container, c.Name)) {
+			match = i
+		}
+	}
+	if match < 0 {
+		return &StatusResult{State: StatusCritical, Message: "Container not found", ResponseTime: time.Since(startTime)}
+	}
+
+	c := containers[match]
+	state, message := containerStatus(c)
+	return &StatusResult{
+		State:        state,
+		Message:      message,
+		ResponseTime: time.Since(startTime),
+		Details: []StatusDetail{
+			{Label: "Container", Value: c.Name},
+			{Label: "ID", Value: c.ID},
+			{Label: "Image", Value: c.Image},
+			{Label: "Status", Value: c.Status},
+			{Label: "Health", Value: c.Health},
+		},
+	}
+}
+
 // Helper function to check if all services have been processed
 func allProcessed(services []*Service, processedServices map[string]bool) bool {
 	for _, service := range services {
@@ -778,7 +1584,7 @@ func allProcessed(services []*Service, processedServices map[string]bool) bool {
 }
 
 // discoverContainersWithLabels discovers containers with homepage labels and adds them as services
-func (sm *StatusMonitor) discoverContainersWithLabels(containers []dockerContainer, processedContainers map[string]bool, config *DockerConfig) {
+func (sm *StatusMonitor) discoverContainersWithLabels(ctx context.Context, api dockerAPI, containers []dockerContainer, processedContainers map[string]bool, config *DockerConfig) {
 	logging.Debug("Checking for containers with homepage labels...")
 
 	// Skip autodiscovery if disabled in config
@@ -790,17 +1596,6 @@ func (sm *StatusMonitor) discoverContainersWithLabels(containers []dockerContain
 	// Count discovered services for logging
 	discoveredCount := 0
 
-	// Create Docker client for label inspection
-	cli, err := createDockerClient(config)
-	if err != nil {
-		logging.Error("ERROR: Failed to create Docker client for autodiscovery: %v", err)
-		return
-	}
-	defer cli.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
 	logging.Debug("Examining %d containers for autodiscovery", len(containers))
 	for containerIndex, container := range containers {
 		// Skip containers we've already matched
@@ -813,7 +1608,7 @@ func (sm *StatusMonitor) discoverContainersWithLabels(containers []dockerContain
 			containerIndex+1, len(containers), container.Name)
 
 		// Inspect container to get labels
-		containerInfo, err := cli.ContainerInspect(ctx, container.ID)
+		containerInfo, err := api.Inspect(ctx, container.ID)
 		if err != nil {
 			logging.Error("ERROR: Failed to inspect container %s: %v", container.Name, err)
 			continue
@@ -821,7 +1616,7 @@ func (sm *StatusMonitor) discoverContainersWithLabels(containers []dockerContain
 
 		// Check for homepage labels
 		homepageLabels := false
-		labels := containerInfo.Config.Labels
+		labels := containerInfo.Labels
 		for key, value := range labels {
 			if strings.HasPrefix(key, "homepage.") {
 				homepageLabels = true
@@ -848,7 +1643,10 @@ func (sm *StatusMonitor) discoverContainersWithLabels(containers []dockerContain
 		}
 
 		// Add service to the monitor if not already being monitored
-		if _, exists := sm.services[name]; !exists {
+		sm.mutex.RLock()
+		_, exists := sm.services[name]
+		sm.mutex.RUnlock()
+		if !exists {
 			logging.Debug("Creating service for container '%s' with name '%s'",
 				container.Name, name)
 
@@ -869,9 +1667,24 @@ func (sm *StatusMonitor) discoverContainersWithLabels(containers []dockerContain
 				service.Href = val
 			}
 
+			// Set the tags if provided, separated by commas
+			if val, ok := labels["homepage.tags"]; ok {
+				service.Tags = ParseTags(val)
+			}
+
+			// Set the sort weight if provided
+			if val, ok := labels["homepage.weight"]; ok {
+				if weight, err := strconv.Atoi(val); err == nil {
+					service.Weight = weight
+				} else {
+					logging.Warn("Invalid homepage.weight label on container '%s': %s", container.Name, val)
+				}
+			}
+
 			// Add to services map and results
 			sm.mutex.Lock()
 			sm.services[service.Name] = service
+			sm.discovered[service.Name] = true
 			sm.results[service.Name] = &StatusResult{
 				State:       StatusUnknown,
 				Message:     "Discovered service",
@@ -892,24 +1705,25 @@ func (sm *StatusMonitor) discoverContainersWithLabels(containers []dockerContain
 	logging.Debug("Docker autodiscovery completed - found %d containers with homepage labels", discoveredCount)
 }
 
-// autodiscoverService adds a service discovered from container labels
+// autodiscoverService adds a service discovered from container labels to the
+// monitor and to its group in the UI, in weight order
 func (sm *StatusMonitor) autodiscoverService(service *Service, groupName string) {
 	logging.Info("Auto-discovering service '%s' in group '%s'", service.Name, groupName)
-
-	// Add service to status monitor
 	sm.AddService(service)
-
-	// Add to dynamic service group in UI
-	logging.Debug("Adding '%s' to dynamic service group '%s'", service.Name, groupName)
-	//AddDynamicServiceGroup(groupName, service)
-
-	// Log after adding to verify it was added
-	logging.Debug("Service '%s' has been added to group '%s' and should appear in the UI",
-		service.Name, groupName)
+	AddDynamicServiceGroup(groupName, service)
 }
 
 // updateDockerServiceStatus updates the status of a service based on Docker container state
 func (sm *StatusMonitor) updateDockerServiceStatus(serviceName string, service *Service, container dockerContainer) {
+	state, message := containerStatus(container)
+
+	// Update the status for this service
+	logging.Debug("Updating status for %s to %s: %s", serviceName, state, message)
+	sm.updateServiceStatus(serviceName, state, message)
+}
+
+// containerStatus determines the status of a container from its state and health
+func containerStatus(container dockerContainer) (StatusState, string) {
 	// Determine status based on container state and health
 	state := StatusUnknown
 	var message string
@@ -941,9 +1755,7 @@ func (sm *StatusMonitor) updateDockerServiceStatus(serviceName string, service *
 		message = fmt.Sprintf("Unknown (%s)", container.Status)
 	}
 
-	// Update the status for this service
-	logging.Debug("Updating status for %s to %s: %s", serviceName, state, message)
-	sm.updateServiceStatus(serviceName, state, message)
+	return state, message
 }
 
 // GetServiceStatusString returns a string representation of the service status
@@ -955,12 +1767,39 @@ func (sm *StatusMonitor) GetServiceStatusString(serviceName string) string {
 	return fmt.Sprintf("%s %s", string(result.State), result.Message)
 }
 
+// SetHistoryLimits sets the number of status changes kept per service and
+// the memory budget in bytes of the changes of all services, the oldest
+// changes being evicted beyond them. Values of 0 or less keep the defaults.
+func (sm *StatusMonitor) SetHistoryLimits(size, memory int) {
+	sm.history.setLimits(size, memory)
+}
+
+// History returns the recent status changes of a service, oldest first
+func (sm *StatusMonitor) History(serviceName string) []StatusEvent {
+	return sm.history.events(serviceName)
+}
+
+// LastIncident returns the service that turned critical last and when, or
+// false if none did in the kept history
+func (sm *StatusMonitor) LastIncident() (string, time.Time, bool) {
+	return sm.history.lastOutage()
+}
+
 // Add a method to set the global interval
-func (sm *StatusMonitor) SetGlobalInterval(seconds int) {
+func (sm *StatusMonitor) SetGlobalInterval(seconds Seconds) {
 	if seconds > 0 {
 		logging.Info("Setting global status check interval to %d seconds", seconds)
-		sm.globalInterval = seconds
 	}
+	sm.globalInterval = max(seconds, 0)
+}
+
+// SetGlobalRetryInterval sets the default interval used to recheck failing
+// services that don't set a retry interval of their own
+func (sm *StatusMonitor) SetGlobalRetryInterval(seconds Seconds) {
+	if seconds > 0 {
+		logging.Info("Setting global retry interval to %d seconds", seconds)
+	}
+	sm.globalRetryInterval = max(seconds, 0)
 }
 
 // RunInitialDockerDiscovery runs autodiscovery immediately during startup
@@ -0,0 +1,636 @@
+package homepage
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextCheckDelay(t *testing.T) {
+	assert.Equal(t, 60*time.Second, nextCheckDelay(StatusOK, 60, 10))
+	assert.Equal(t, 60*time.Second, nextCheckDelay(StatusUnknown, 60, 10))
+	assert.Equal(t, 10*time.Second, nextCheckDelay(StatusWarning, 60, 10))
+	assert.Equal(t, 10*time.Second, nextCheckDelay(StatusCritical, 60, 10))
+}
+
+func TestGetAllStatuses(t *testing.T) {
+	StoreCachedGroups([]*ServiceGroup{
+		{Name: "B", Services: []*Service{{Name: "Zeta"}, {Name: "Alpha"}}},
+		{Name: "A", Services: []*Service{{Name: "Static"}}},
+	})
+	defer StoreCachedGroups(nil)
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	for _, name := range []string{"Alpha", "Zeta", "Static", "Orphan", "Another"} {
+		sm.AddService(&Service{Name: name, Status: "ok"})
+	}
+	sm.updateServiceStatus("Zeta", StatusCritical, "Down")
+
+	statuses := sm.GetAllStatuses()
+	var names, groups []string
+	for _, entry := range statuses {
+		names = append(names, entry.Name)
+		groups = append(groups, entry.Group)
+	}
+	assert.Equal(t, []string{"Zeta", "Alpha", "Static", "Another", "Orphan"}, names)
+	assert.Equal(t, []string{"B", "B", "A", "", ""}, groups)
+	assert.Equal(t, StatusCritical, statuses[0].State)
+	assert.Equal(t, "Down", statuses[0].Message)
+
+	// Entries are copies
+	sm.updateServiceStatus("Zeta", StatusOK, "Up")
+	assert.Equal(t, StatusCritical, statuses[0].State)
+}
+
+func TestSnapshotLinksAndSharedServices(t *testing.T) {
+	nas := &Service{Name: "NAS", Status: "warning", Href: "https://nas.lan"}
+	StoreCachedGroups([]*ServiceGroup{
+		{Name: "Storage", Services: []*Service{nas, {Name: "Docs", Href: "https://docs.lan", Icon: "book"}}},
+		{Name: "Media", Services: []*Service{nas}},
+	})
+	defer StoreCachedGroups(nil)
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	sm.AddService(nas)
+
+	statuses := sm.GetAllStatuses()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "Docs", statuses[1].Name)
+	assert.False(t, statuses[1].Monitored)
+	assert.Equal(t, StatusUnknown, statuses[1].State)
+	assert.Equal(t, "Media", statuses[2].Group)
+	assert.Equal(t, StatusWarning, statuses[2].State)
+
+	snapshot := sm.Snapshot("site-a")
+	assert.Equal(t, []GroupStatus{
+		{Name: "Storage", Services: []ServiceStatus{
+			{Name: "NAS", Href: "https://nas.lan", State: StatusWarning, LastChecked: statuses[0].LastChecked},
+			{Name: "Docs", Href: "https://docs.lan", Icon: "book", State: StatusUnknown},
+		}},
+		{Name: "Media", Services: []ServiceStatus{
+			{Name: "NAS", Href: "https://nas.lan", State: StatusWarning, LastChecked: statuses[0].LastChecked},
+		}},
+	}, snapshot.Groups)
+}
+
+func TestMonitoringSchedule(t *testing.T) {
+	var requests, failing atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(nil)
+	sm.SetClock(clock)
+	defer sm.Stop()
+
+	sm.AddService(&Service{Name: "Web", SiteMonitor: server.URL, SiteMonitorInterval: 60, RetryInterval: 10})
+
+	// The first check runs right away, then the next one is scheduled
+	clock.BlockUntil(1)
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, StatusOK, sm.GetStatus("Web").State)
+	assert.Equal(t, clock.Now(), sm.GetStatus("Web").LastChecked)
+	next, ok := sm.NextCheck("Web")
+	require.True(t, ok)
+	assert.Equal(t, clock.Now().Add(60*time.Second), next)
+
+	failing.Store(1)
+	clock.Advance(59 * time.Second)
+	assert.Equal(t, int32(1), requests.Load())
+
+	clock.Advance(time.Second)
+	clock.BlockUntil(1)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, StatusCritical, sm.GetStatus("Web").State)
+
+	// A failing service is rechecked at the retry interval
+	next, _ = sm.NextCheck("Web")
+	assert.Equal(t, clock.Now().Add(10*time.Second), next)
+	clock.Advance(10 * time.Second)
+	clock.BlockUntil(1)
+	assert.Equal(t, int32(3), requests.Load())
+
+	failing.Store(0)
+	clock.Advance(10 * time.Second)
+	clock.BlockUntil(1)
+	assert.Equal(t, int32(4), requests.Load())
+	assert.Equal(t, StatusOK, sm.GetStatus("Web").State)
+
+	// Back to the normal interval once it recovers
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, int32(4), requests.Load())
+
+	// The earliest check of all services is due first
+	sm.AddService(&Service{Name: "Other", SiteMonitor: server.URL, SiteMonitorInterval: 30})
+	clock.BlockUntil(2)
+	next, ok = sm.NextScheduledCheck()
+	require.True(t, ok)
+	assert.Equal(t, clock.Now().Add(30*time.Second), next)
+}
+
+func TestRemoveService(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(nil)
+	sm.SetClock(clock)
+	defer sm.Stop()
+
+	sm.AddService(&Service{Name: "Backup", HeartbeatPeriod: 60})
+	sm.AddService(&Service{Name: "Static", Status: "ok"})
+	require.True(t, sm.IsMonitored("Backup"))
+	require.NoError(t, sm.RecordHeartbeat("backup", false))
+
+	sm.RemoveService("Backup")
+	assert.False(t, sm.IsMonitored("Backup"))
+	assert.Equal(t, "Service not monitored", sm.GetStatus("Backup").Message)
+	assert.ErrorIs(t, sm.RecordHeartbeat("backup", false), ErrUnknownHeartbeat)
+
+	// Other services are unaffected, and removed ones can be added again
+	assert.Equal(t, StatusOK, sm.GetStatus("Static").State)
+	sm.RemoveService("Missing")
+	sm.AddService(&Service{Name: "Backup", HeartbeatPeriod: 60})
+	assert.NoError(t, sm.RecordHeartbeat("backup", false))
+}
+
+func TestUpdateService(t *testing.T) {
+	var oldRequests, newRequests atomic.Int32
+	oldServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		oldRequests.Add(1)
+	}))
+	defer oldServer.Close()
+	newServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		newRequests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer newServer.Close()
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(nil)
+	sm.SetClock(clock)
+	defer sm.Stop()
+
+	sm.AddService(&Service{Name: "Web", SiteMonitor: oldServer.URL, SiteMonitorInterval: 60})
+	clock.BlockUntil(1)
+	require.Equal(t, StatusOK, sm.GetStatus("Web").State)
+
+	// The new configuration is checked right away, and the old one no longer
+	sm.UpdateService(&Service{Name: "Web", Description: "Updated", SiteMonitor: newServer.URL, SiteMonitorInterval: 60})
+	service, ok := monitoredService(sm, "Web")
+	require.True(t, ok)
+	assert.Equal(t, "Updated", service.Description)
+	require.Eventually(t, func() bool { return sm.GetStatus("Web").State == StatusCritical }, 5*time.Second, 10*time.Millisecond)
+
+	clock.Advance(60 * time.Second)
+	require.Eventually(t, func() bool { return newRequests.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), oldRequests.Load())
+
+	// Services left without checks are removed, and unknown ones added
+	sm.UpdateService(&Service{Name: "Web"})
+	assert.False(t, sm.IsMonitored("Web"))
+	sm.UpdateService(&Service{Name: "Static", Status: "ok"})
+	assert.Equal(t, StatusOK, sm.GetStatus("Static").State)
+}
+
+func TestSetServiceEnabled(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewStatusMonitor(nil)
+	sm.SetClock(clock)
+	defer sm.Stop()
+
+	// Services disabled before they are added are not checked
+	sm.SetServiceEnabled("Web", false)
+	sm.AddService(&Service{Name: "Web", SiteMonitor: server.URL, SiteMonitorInterval: 60})
+	assert.True(t, sm.IsMonitored("Web"))
+	assert.False(t, sm.IsServiceEnabled("Web"))
+	assert.Equal(t, MonitoringDisabledMessage, sm.GetStatus("Web").Message)
+	clock.Advance(60 * time.Second)
+	assert.Equal(t, int32(0), requests.Load())
+
+	sm.SetServiceEnabled("Web", true)
+	clock.BlockUntil(1)
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, StatusOK, sm.GetStatus("Web").State)
+
+	sm.SetServiceEnabled("Web", false)
+	assert.Equal(t, StatusUnknown, sm.GetStatus("Web").State)
+	clock.Advance(60 * time.Second)
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, []string{"Web"}, sm.DisabledServices())
+
+	// Updates keep the service disabled
+	sm.UpdateService(&Service{Name: "Web", SiteMonitor: server.URL, SiteMonitorInterval: 30})
+	clock.Advance(60 * time.Second)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestRemoveServiceCancelsCheck(t *testing.T) {
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer server.Close()
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	sm.AddService(&Service{Name: "Slow", SiteMonitor: server.URL})
+	<-started
+
+	sm.RemoveService("Slow")
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("check of the removed service was not canceled")
+	}
+	assert.Equal(t, "Service not monitored", sm.GetStatus("Slow").Message)
+}
+
+func TestCheckProgress(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+
+	// Services wait for their first check as soon as they are added
+	sm.AddService(&Service{Name: "Web", SiteMonitor: server.URL})
+	sm.AddService(&Service{Name: "Static", Status: "ok"})
+	done, total := sm.CheckProgress()
+	assert.Equal(t, 0, done)
+	assert.Equal(t, 1, total)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		done, _ := sm.CheckProgress()
+		return done == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// writeTestKeyPair writes a self-signed certificate and its private key as PEM
+// files, returning the certificate
+func writeTestKeyPair(t *testing.T, certFile, keyFile, name string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	writeTestFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if keyFile == certFile {
+		data, err := os.ReadFile(certFile)
+		require.NoError(t, err)
+		keyPEM = append(data, keyPEM...)
+	}
+	writeTestFile(t, keyFile, keyPEM)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestHTTPCheckClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	clientCert := writeTestKeyPair(t, filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key"), "admin")
+	combinedCert := writeTestKeyPair(t, filepath.Join(dir, "combined.pem"), filepath.Join(dir, "combined.pem"), "backup")
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	clientCAs.AddCert(combinedCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	check := newHTTPCheck(&Service{SiteMonitor: server.URL, SiteMonitorSkipVerify: true})
+	result := check.run(context.Background(), "Admin")
+	assert.Equal(t, StatusCritical, result.State)
+
+	check = newHTTPCheck(&Service{
+		SiteMonitor:           server.URL,
+		SiteMonitorSkipVerify: true,
+		SiteMonitorClientCert: filepath.Join(dir, "client.pem"),
+		SiteMonitorClientKey:  filepath.Join(dir, "client.key"),
+	})
+	result = check.run(context.Background(), "Admin")
+	assert.Equal(t, StatusOK, result.State, result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Client certificate", Value: "CN=admin"})
+
+	// The key defaults to the certificate file
+	check = newHTTPCheck(&Service{
+		SiteMonitor:           server.URL,
+		SiteMonitorSkipVerify: true,
+		SiteMonitorClientCert: filepath.Join(dir, "combined.pem"),
+	})
+	result = check.run(context.Background(), "Admin")
+	assert.Equal(t, StatusOK, result.State, result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Client certificate", Value: "CN=backup"})
+
+	check = newHTTPCheck(&Service{
+		SiteMonitor:           server.URL,
+		SiteMonitorClientCert: filepath.Join(dir, "missing.pem"),
+	})
+	result = check.run(context.Background(), "Admin")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "Error loading client certificate")
+}
+
+func TestHTTPCheckExpectedBody(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.URL.Path == "/broken" {
+			fmt.Fprint(w, "<h1>502 Bad Gateway</h1>")
+			return
+		}
+		fmt.Fprint(w, "<title>Jellyfin 10.9</title>")
+	}))
+	defer server.Close()
+
+	result := newHTTPCheck(&Service{SiteMonitor: server.URL, SiteMonitorExpectedBody: "Jellyfin"}).run(context.Background(), "Media")
+	assert.Equal(t, StatusOK, result.State, result.Message)
+	assert.Equal(t, []string{http.MethodGet}, methods, "GET replaces the default HEAD")
+
+	result = newHTTPCheck(&Service{SiteMonitor: server.URL + "/broken", SiteMonitorExpectedBody: "Jellyfin"}).run(context.Background(), "Media")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Unexpected body: Jellyfin not found", result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Expected body", Value: "Jellyfin"})
+
+	// Bodies between slashes are regular expressions
+	result = newHTTPCheck(&Service{SiteMonitor: server.URL, SiteMonitorExpectedBody: `/Jellyfin \d+\.\d+/`}).run(context.Background(), "Media")
+	assert.Equal(t, StatusOK, result.State, result.Message)
+	result = newHTTPCheck(&Service{SiteMonitor: server.URL + "/broken", SiteMonitorExpectedBody: `/Jellyfin \d+/`}).run(context.Background(), "Media")
+	assert.Equal(t, StatusCritical, result.State)
+
+	result = newHTTPCheck(&Service{SiteMonitor: server.URL, SiteMonitorExpectedBody: "/Jellyfin (/"}).run(context.Background(), "Media")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Contains(t, result.Message, "Invalid siteMonitorExpectedBody")
+}
+
+// fixedCheck is a check returning a copy of the same result every time
+type fixedCheck struct {
+	result StatusResult
+}
+
+func (c *fixedCheck) run(context.Context, string) *StatusResult {
+	result := c.result
+	return &result
+}
+
+func TestResponseTimeThresholds(t *testing.T) {
+	service := &Service{ResponseTimeWarn: 1000, ResponseTimeCritical: 3000}
+	run := func(state StatusState, responseTime time.Duration) *StatusResult {
+		check := withResponseTimeThresholds(&fixedCheck{StatusResult{State: state, Message: "Up", ResponseTime: responseTime}}, service)
+		return check.run(context.Background(), "Web")
+	}
+
+	assert.Equal(t, StatusOK, run(StatusOK, 999*time.Millisecond).State)
+	result := run(StatusOK, 1500*time.Millisecond)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "Up, slower than 1000 ms", result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Response time threshold", Value: "1s"})
+	result = run(StatusOK, 4*time.Second)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Up, slower than 3000 ms", result.Message)
+
+	// Failures keep their own state and message
+	result = run(StatusWarning, 4*time.Second)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "Up", result.Message)
+
+	// Checks without thresholds are left alone
+	check := &fixedCheck{}
+	assert.Same(t, check, withResponseTimeThresholds(check, &Service{}))
+
+	// Thresholds apply to the checks measuring a response time
+	created, _, _ := newStatusCheck(&Service{Ping: "localhost", ResponseTimeWarn: 100})
+	assert.IsType(t, &responseTimeCheck{}, created)
+	created, _, _ = newStatusCheck(&Service{Script: "true", ResponseTimeWarn: 100})
+	assert.IsType(t, &scriptCheck{}, created)
+}
+
+func TestStatusCheckKinds(t *testing.T) {
+	// The first configured check is used
+	_, interval, kind := newStatusCheck(&Service{Git: "https://git.lan/repo.git", GitInterval: 600, Script: "true"})
+	assert.Equal(t, "Script", kind)
+	assert.Equal(t, Seconds(0), interval)
+	_, interval, kind = newStatusCheck(&Service{Git: "https://git.lan/repo.git", GitInterval: 600})
+	assert.Equal(t, "Git", kind)
+	assert.Equal(t, Seconds(600), interval)
+
+	// Active checks, passive checks and widgets make a service monitorable
+	assert.True(t, hasStatusCheck(&Service{Redis: "redis://cache.lan"}))
+	assert.True(t, hasStatusCheck(&Service{HeartbeatPeriod: 60}))
+	assert.False(t, hasStatusCheck(&Service{Widget: &WidgetConfig{Type: "grafana"}}))
+	assert.True(t, isMonitorable(&Service{Widget: &WidgetConfig{Type: "grafana"}}))
+	assert.False(t, isMonitorable(&Service{Name: "Link"}))
+	assert.False(t, isMonitorable(&Service{Name: "Off", Ping: "localhost", DisableStatus: true}))
+}
+
+func TestRetriesConfirmStateChanges(t *testing.T) {
+	var updates []StatusState
+	sm := NewStatusMonitor(func(serviceName string, state StatusState, message string) {
+		updates = append(updates, state)
+	})
+	defer sm.Stop()
+	sm.AddService(&Service{Name: "Router", Status: "ok", Retries: 3})
+	updates = nil
+	up := &fixedCheck{StatusResult{State: StatusOK, Message: "Up"}}
+	down := &fixedCheck{StatusResult{State: StatusCritical, Message: "Timeout"}}
+
+	// A single failure keeps the service up
+	assert.Equal(t, StatusOK, sm.runCheck("Router", up, nil).State)
+	result := sm.runCheck("Router", down, nil)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, StatusCritical, result.Unconfirmed)
+	status := sm.GetStatus("Router")
+	assert.Equal(t, "Up", status.Message)
+	assert.Contains(t, status.Details, StatusDetail{Label: "Unconfirmed", Value: "critical, 1 of 3 checks: Timeout"})
+	sm.runCheck("Router", up, nil)
+	assert.Empty(t, sm.GetStatus("Router").Unconfirmed)
+
+	// Enough consecutive failures turn it critical
+	sm.runCheck("Router", down, nil)
+	sm.runCheck("Router", down, nil)
+	assert.Equal(t, StatusOK, sm.GetStatus("Router").State)
+	sm.runCheck("Router", down, nil)
+	assert.Equal(t, StatusCritical, sm.GetStatus("Router").State)
+	assert.Equal(t, "Timeout", sm.GetStatus("Router").Message)
+
+	// Recoveries are confirmed too
+	sm.runCheck("Router", up, nil)
+	sm.runCheck("Router", up, nil)
+	assert.Equal(t, StatusCritical, sm.GetStatus("Router").State)
+	sm.runCheck("Router", up, nil)
+	assert.Equal(t, StatusOK, sm.GetStatus("Router").State)
+	// The UI is also updated when a change starts or stops awaiting confirmation
+	assert.Equal(t, []StatusState{StatusOK, StatusOK, StatusOK, StatusOK, StatusCritical, StatusCritical, StatusOK}, updates)
+
+	// Without retries, the first failure counts
+	sm.AddService(&Service{Name: "Switch", Status: "ok"})
+	assert.Equal(t, StatusCritical, sm.runCheck("Switch", down, nil).State)
+}
+
+func TestStatusResultsImmutable(t *testing.T) {
+	sm := NewStatusMonitor(nil)
+	defer sm.Stop()
+	sm.AddService(&Service{Name: "Static", Status: "ok"})
+
+	before := sm.GetStatus("Static")
+	sm.recordResult("Static", &StatusResult{
+		State:        StatusCritical,
+		Message:      "Down",
+		ResponseTime: time.Second,
+		Details:      []StatusDetail{{Label: "Host", Value: "nas"}},
+	})
+	after := sm.GetStatus("Static")
+
+	// Results held by readers are replaced, not modified
+	assert.Equal(t, StatusOK, before.State)
+	assert.Empty(t, before.Details)
+	assert.Equal(t, StatusCritical, after.State)
+	assert.Equal(t, time.Second, after.ResponseTime)
+	assert.Equal(t, []StatusDetail{{Label: "Host", Value: "nas"}}, after.Details)
+
+	// The response time of failed checks is kept from the last successful one
+	sm.recordResult("Static", &StatusResult{State: StatusCritical, Message: "Still down"})
+	assert.Equal(t, time.Second, sm.GetStatus("Static").ResponseTime)
+}
+
+func TestConcurrentStatusReads(t *testing.T) {
+	sm := NewStatusMonitor(func(string, StatusState, string) {})
+	defer sm.Stop()
+	sm.AddService(&Service{Name: "Static", Status: "ok"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 1000 {
+			sm.recordResult("Static", &StatusResult{State: StatusOK, Message: strconv.Itoa(i), ResponseTime: time.Millisecond})
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			result := sm.GetStatus("Static")
+			_ = result.Message + string(result.State) + result.LastChecked.String() + result.ResponseTime.String()
+			_ = sm.GetAllStatuses()
+		}
+	}
+}
+
+// benchmarkMonitor returns a status monitor of services with static
+// statuses, whose changes are counted by the update function
+func benchmarkMonitor(b *testing.B, services int, updates *atomic.Int64) *StatusMonitor {
+	sm := NewStatusMonitor(func(string, StatusState, string) { updates.Add(1) })
+	b.Cleanup(sm.Stop)
+	for i := range services {
+		sm.AddService(&Service{Name: fmt.Sprintf("Service %d", i), Status: "ok"})
+	}
+	return sm
+}
+
+func BenchmarkStatusUpdate(b *testing.B) {
+	var updates atomic.Int64
+	sm := benchmarkMonitor(b, 500, &updates)
+	results := []*StatusResult{
+		{State: StatusOK, Message: "Up", ResponseTime: time.Millisecond},
+		{State: StatusCritical, Message: "Down"},
+	}
+
+	b.Run("serial", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := range b.N {
+			sm.recordResult(fmt.Sprintf("Service %d", i%500), results[i/500%2])
+		}
+	})
+
+	// Checks of different services finish concurrently
+	b.Run("parallel", func(b *testing.B) {
+		var next atomic.Int64
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				i := int(next.Add(1))
+				sm.recordResult(fmt.Sprintf("Service %d", i%500), results[i/500%2])
+			}
+		})
+	})
+
+	// Each result changes the status, so each calls the update function
+	if updates.Load() == 0 {
+		b.Fatal("The update function was never called")
+	}
+}
+
+func BenchmarkScheduler(b *testing.B) {
+	var updates atomic.Int64
+	sm := benchmarkMonitor(b, 500, &updates)
+	stop := make(chan struct{})
+	for i := range 500 {
+		sm.scheduleCheck(fmt.Sprintf("Service %d", i), stop, time.Duration(i)*time.Second)
+	}
+
+	b.Run("scheduleCheck", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := range b.N {
+			sm.scheduleCheck(fmt.Sprintf("Service %d", i%500), stop, time.Minute)
+		}
+	})
+	b.Run("NextScheduledCheck", func(b *testing.B) {
+		for range b.N {
+			sm.NextScheduledCheck()
+		}
+	})
+	b.Run("CheckProgress", func(b *testing.B) {
+		for range b.N {
+			sm.CheckProgress()
+		}
+	})
+}
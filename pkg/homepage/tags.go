@@ -0,0 +1,63 @@
+package homepage
+
+import (
+	"slices"
+	"strings"
+)
+
+// ParseTags splits a comma-separated list of tags, such as the value of the
+// homepage.tags label or of the --tags flag, dropping empty entries
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// MatchTags reports whether any of the tags is in the filter, ignoring case.
+// An empty filter matches everything.
+func MatchTags(tags, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(tags, func(tag string) bool {
+		return slices.ContainsFunc(filter, func(want string) bool {
+			return strings.EqualFold(tag, want)
+		})
+	})
+}
+
+// CollectTags returns the sorted tags used by the services, the bookmarks
+// and the group layouts, without duplicates ignoring case
+func CollectTags(serviceGroups []*ServiceGroup, bookmarkGroups []*BookmarkGroup, layout map[string]GroupLayout) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	add := func(list []string) {
+		for _, tag := range list {
+			if key := strings.ToLower(tag); !seen[key] {
+				seen[key] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	for _, group := range serviceGroups {
+		for _, service := range group.Services {
+			add(service.Tags)
+		}
+	}
+	for _, group := range bookmarkGroups {
+		for _, bookmark := range group.Bookmarks {
+			add(bookmark.Tags)
+		}
+	}
+	for _, group := range layout {
+		add(group.Tags)
+	}
+	slices.SortFunc(tags, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	return tags
+}
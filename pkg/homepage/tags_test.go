@@ -0,0 +1,34 @@
+package homepage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseTags checks that tags are split on commas and trimmed.
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{"prod", "media"}, ParseTags(" prod, ,media,"))
+	assert.Empty(t, ParseTags(""))
+}
+
+// TestMatchTags checks that any tag in the filter matches, ignoring case.
+func TestMatchTags(t *testing.T) {
+	assert.True(t, MatchTags(nil, nil), "An empty filter should match everything")
+	assert.True(t, MatchTags([]string{"Prod", "web"}, []string{"media", "prod"}))
+	assert.False(t, MatchTags([]string{"dev"}, []string{"prod"}))
+	assert.False(t, MatchTags(nil, []string{"prod"}), "Untagged items should not match a filter")
+}
+
+// TestCollectTags checks that the tags of services, bookmarks and groups are
+// gathered sorted and without duplicates.
+func TestCollectTags(t *testing.T) {
+	serviceGroups := []*ServiceGroup{{Name: "Media", Services: []*Service{
+		{Name: "Jellyfin", Tags: []string{"media", "Home"}},
+		{Name: "Sonarr", Tags: []string{"media"}},
+	}}}
+	bookmarkGroups := []*BookmarkGroup{{Name: "Dev", Bookmarks: []*Bookmark{{Name: "GitHub", Tags: []string{"work", "home"}}}}}
+	layout := map[string]GroupLayout{"Infra": {Tags: []string{"prod"}}}
+
+	assert.Equal(t, []string{"Home", "media", "prod", "work"}, CollectTags(serviceGroups, bookmarkGroups, layout))
+}
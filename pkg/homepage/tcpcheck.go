@@ -0,0 +1,83 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// tcpCheck connects to a TCP port, for services that speak neither HTTP nor
+// answer ping, such as databases, game servers or SSH
+type tcpCheck struct {
+	address string
+	timeout time.Duration
+}
+
+// newTCPCheck creates a TCP port check for a service
+func newTCPCheck(service *Service) *tcpCheck {
+	timeout := service.TCPCheckTimeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	return &tcpCheck{address: service.TCPCheck, timeout: time.Duration(timeout) * time.Second}
+}
+
+// run resolves the host and connects to the port, reporting the connect
+// latency apart from the DNS lookup
+func (c *tcpCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	details := []StatusDetail{{Label: "Address", Value: c.address}}
+	host, port, err := net.SplitHostPort(c.address)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid address, use host:port: %v", err), Details: details}
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	lookupStart := time.Now()
+	lookup, err := checkDNSCache.resolve(ctx, host)
+	if err != nil {
+		logging.Error("TCP check for %s: DNS lookup of %s failed: %v", serviceName, host, err)
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("DNS lookup failed: %v", err), Details: details}
+	}
+	if len(lookup.ips) == 0 {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("No address found for %s", host), Details: details}
+	}
+	if net.ParseIP(host) == nil {
+		details = append(details, dnsLookupDetail(lookup.cached, time.Since(lookupStart)))
+		details = append(details, lookup.details()...)
+	}
+
+	// Try the addresses in turn, as dialCached does, timing the one answering
+	var dialer net.Dialer
+	for _, ip := range lookup.ips {
+		start := time.Now()
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port))
+		elapsed := time.Since(start)
+		if err != nil {
+			continue
+		}
+		conn.Close()
+		logging.Debug("TCP check for %s: Connected to %s in %s", serviceName, ip, elapsed)
+		details = append(details,
+			StatusDetail{Label: "Connected IP", Value: ip.String()},
+			StatusDetail{Label: "Connect", Value: elapsed.Round(time.Microsecond).String()},
+		)
+		return &StatusResult{State: StatusOK, Message: fmt.Sprintf("Open (%s)", elapsed.Round(time.Millisecond)), ResponseTime: elapsed, Details: details}
+	}
+
+	logging.Debug("TCP check for %s: Connecting to %s failed: %v", serviceName, c.address, err)
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return &StatusResult{State: StatusCritical, Message: "Connection refused", Details: details}
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("No answer after %s", c.timeout), Details: details}
+	}
+	return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Connect failed: %v", err), Details: details}
+}
@@ -0,0 +1,32 @@
+package homepage
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCPCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	result := newTCPCheck(&Service{TCPCheck: addr}).run(context.Background(), "Postgres")
+	assert.Equal(t, StatusOK, result.State)
+	assert.True(t, strings.HasPrefix(result.Message, "Open ("), result.Message)
+	assert.Contains(t, result.Details, StatusDetail{Label: "Connected IP", Value: "127.0.0.1"})
+	assert.Positive(t, result.ResponseTime)
+
+	listener.Close()
+	result = newTCPCheck(&Service{TCPCheck: addr}).run(context.Background(), "Postgres")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Connection refused", result.Message)
+
+	result = newTCPCheck(&Service{TCPCheck: "db.local"}).run(context.Background(), "Postgres")
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Invalid address, use host:port: address db.local: missing port in address", result.Message)
+}
@@ -0,0 +1,147 @@
+package homepage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// Timestamp formats of the timestamps settings
+const (
+	TimestampRelative = "relative"
+	TimestampAbsolute = "absolute"
+	TimestampHidden   = "hidden"
+)
+
+// TimestampFormatter formats the time a service was last checked
+type TimestampFormatter struct {
+	format    string
+	location  *time.Location
+	clock12   bool
+	countdown bool
+}
+
+// NewTimestampFormatter creates a formatter from the timestamp settings.
+// Unknown formats fall back to relative and unknown timezones to the local one.
+func NewTimestampFormatter(settings TimestampSettings) *TimestampFormatter {
+	f := &TimestampFormatter{
+		format:    strings.ToLower(settings.Format),
+		location:  time.Local,
+		clock12:   strings.EqualFold(settings.Clock, "12h"),
+		countdown: !settings.HideCountdown,
+	}
+
+	switch f.format {
+	case TimestampRelative, TimestampAbsolute, TimestampHidden:
+	case "":
+		f.format = TimestampRelative
+	default:
+		logging.Warn("Unknown timestamps format %q, using %s", settings.Format, TimestampRelative)
+		f.format = TimestampRelative
+	}
+
+	if settings.Timezone != "" {
+		location, err := time.LoadLocation(settings.Timezone)
+		if err != nil {
+			logging.Warn("Unknown timezone %q, using local time: %v", settings.Timezone, err)
+		} else {
+			f.location = location
+		}
+	}
+	return f
+}
+
+// Relative reports whether timestamps are relative and change as time passes
+func (f *TimestampFormatter) Relative() bool {
+	return f.format == TimestampRelative
+}
+
+// ShowsCountdown reports whether group titles show the time until the next
+// check
+func (f *TimestampFormatter) ShowsCountdown() bool {
+	return f.countdown
+}
+
+// Format returns when t happened, e.g. "checked 12s ago" or "checked at
+// 15:04:05". It returns "" if t is zero or timestamps are hidden.
+func (f *TimestampFormatter) Format(t, now time.Time) string {
+	if t.IsZero() || f.format == TimestampHidden {
+		return ""
+	}
+	if f.format == TimestampRelative {
+		return "checked " + relativeTime(now.Sub(t))
+	}
+
+	return "checked at " + f.clockTime(t, now)
+}
+
+// Clock returns the time of day of t, e.g. "15:04:05", in the timezone and
+// clock of the timestamps
+func (f *TimestampFormatter) Clock(t time.Time) string {
+	return f.clockTime(t, t)
+}
+
+// Until returns until when something lasts, e.g. "until 15:04:05", in the
+// timezone and clock of the timestamps whatever their format
+func (f *TimestampFormatter) Until(t, now time.Time) string {
+	return "until " + f.clockTime(t, now)
+}
+
+// clockTime formats t as time of day, with the date unless it is on the day
+// of now
+func (f *TimestampFormatter) clockTime(t, now time.Time) string {
+	t, now = t.In(f.location), now.In(f.location)
+	layout := "15:04:05"
+	if f.clock12 {
+		layout = "3:04:05 PM"
+	}
+	if t.YearDay() != now.YearDay() || t.Year() != now.Year() {
+		layout = "Jan 2 15:04"
+		if f.clock12 {
+			layout = "Jan 2 3:04 PM"
+		}
+	}
+	return t.Format(layout)
+}
+
+// relativeTime formats an elapsed duration in its largest whole unit
+func relativeTime(elapsed time.Duration) string {
+	switch {
+	case elapsed < time.Second:
+		return "just now"
+	case elapsed < time.Minute:
+		return fmt.Sprintf("%ds ago", int(elapsed/time.Second))
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed/time.Minute))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(elapsed/(24*time.Hour)))
+}
+
+// FormatCountdown formats the time left until the next check, rounded up to
+// whole seconds, e.g. "next in 12s" or "next in 2m 05s"
+func FormatCountdown(left time.Duration) string {
+	return "next in " + FormatTimeLeft(left)
+}
+
+// FormatTimeLeft formats a duration left, rounded up to whole seconds, e.g.
+// "12s", "2m 05s" or "1h 30m"
+func FormatTimeLeft(left time.Duration) string {
+	seconds := int((left + time.Second - 1) / time.Second)
+	switch {
+	case seconds < 60:
+		return fmt.Sprintf("%ds", max(seconds, 0))
+	case seconds < 3600:
+		return fmt.Sprintf("%dm %02ds", seconds/60, seconds%60)
+	}
+	return fmt.Sprintf("%dh %02dm", seconds/3600, seconds/60%60)
+}
+
+// FormatAgo formats an elapsed duration in its largest whole unit, e.g.
+// "12s ago" or "3h ago", whatever the format of the timestamps
+func FormatAgo(elapsed time.Duration) string {
+	return relativeTime(elapsed)
+}
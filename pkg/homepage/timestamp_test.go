@@ -0,0 +1,58 @@
+package homepage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampFormatter(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	relative := NewTimestampFormatter(TimestampSettings{})
+	assert.True(t, relative.Relative())
+	assert.Equal(t, "", relative.Format(time.Time{}, now))
+	assert.Equal(t, "checked just now", relative.Format(now, now))
+	assert.Equal(t, "checked 12s ago", relative.Format(now.Add(-12*time.Second), now))
+	assert.Equal(t, "checked 5m ago", relative.Format(now.Add(-5*time.Minute-30*time.Second), now))
+	assert.Equal(t, "checked 2h ago", relative.Format(now.Add(-2*time.Hour), now))
+	assert.Equal(t, "checked 3d ago", relative.Format(now.Add(-72*time.Hour), now))
+
+	absolute := NewTimestampFormatter(TimestampSettings{Format: "absolute", Timezone: "UTC"})
+	assert.False(t, absolute.Relative())
+	assert.Equal(t, "checked at 15:29:48", absolute.Format(now.Add(-12*time.Second), now))
+	assert.Equal(t, "checked at Mar 9 15:30", absolute.Format(now.Add(-24*time.Hour), now))
+
+	clock12 := NewTimestampFormatter(TimestampSettings{Format: "absolute", Timezone: "UTC", Clock: "12h"})
+	assert.Equal(t, "checked at 3:29:48 PM", clock12.Format(now.Add(-12*time.Second), now))
+	assert.Equal(t, "checked at Mar 9 3:30 PM", clock12.Format(now.Add(-24*time.Hour), now))
+
+	// The day is compared in the configured timezone
+	tokyo := NewTimestampFormatter(TimestampSettings{Format: "absolute", Timezone: "Asia/Tokyo"})
+	assert.Equal(t, "checked at Mar 10 23:00", tokyo.Format(now.Add(-90*time.Minute), now))
+
+	hidden := NewTimestampFormatter(TimestampSettings{Format: "hidden"})
+	assert.Equal(t, "", hidden.Format(now, now))
+
+	// Ends are shown as time of day whatever the format
+	assert.Equal(t, "until 4:00:00 PM", NewTimestampFormatter(TimestampSettings{Timezone: "UTC", Clock: "12h"}).Until(now.Add(30*time.Minute), now))
+	assert.Equal(t, "until Mar 11 15:30", NewTimestampFormatter(TimestampSettings{Format: "hidden", Timezone: "UTC"}).Until(now.Add(24*time.Hour), now))
+	assert.Equal(t, "3:30:00 PM", clock12.Clock(now))
+	assert.Equal(t, "5m ago", FormatAgo(5*time.Minute))
+
+	// Unknown formats and timezones fall back to the defaults
+	assert.True(t, NewTimestampFormatter(TimestampSettings{Format: "fancy", Timezone: "Nowhere/Town"}).Relative())
+}
+
+func TestFormatCountdown(t *testing.T) {
+	assert.Equal(t, "next in 0s", FormatCountdown(-time.Second))
+	assert.Equal(t, "next in 1s", FormatCountdown(200*time.Millisecond))
+	assert.Equal(t, "next in 12s", FormatCountdown(12*time.Second))
+	assert.Equal(t, "next in 2m 05s", FormatCountdown(2*time.Minute+4500*time.Millisecond))
+	assert.Equal(t, "next in 1h 30m", FormatCountdown(90*time.Minute))
+	assert.Equal(t, "2m 05s", FormatTimeLeft(2*time.Minute+4500*time.Millisecond))
+
+	assert.True(t, NewTimestampFormatter(TimestampSettings{}).ShowsCountdown())
+	assert.False(t, NewTimestampFormatter(TimestampSettings{HideCountdown: true}).ShowsCountdown())
+}
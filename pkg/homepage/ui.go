@@ -2,6 +2,8 @@ package homepage
 
 import (
 	"fmt"
+	"slices"
+	"sync"
 
 	"github.com/deblasis/termhome/pkg/logging"
 )
@@ -35,26 +37,97 @@ var cachedServiceGroups []*ServiceGroup
 // Global cache of bookmark groups for layout rebuilding
 var cachedBookmarkGroups []*BookmarkGroup
 
+// Global cache of the group layout settings, used to order dynamic groups
+var cachedLayout map[string]GroupLayout
+
+// Protects the cached groups, which are updated by Docker autodiscovery
+var cacheMutex sync.RWMutex
+
 // StoreCachedGroups stores the service groups for later use
 func StoreCachedGroups(groups []*ServiceGroup) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
 	cachedServiceGroups = groups
 }
 
 // StoreCachedBookmarks stores the bookmark groups for later use
 func StoreCachedBookmarks(groups []*BookmarkGroup) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
 	cachedBookmarkGroups = groups
 }
 
+// StoreCachedLayout stores the group layout settings for later use
+func StoreCachedLayout(layout map[string]GroupLayout) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	cachedLayout = layout
+}
+
 // GetCachedGroups returns the cached service groups
 func GetCachedGroups() []*ServiceGroup {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
 	return cachedServiceGroups
 }
 
 // GetCachedBookmarks returns the cached bookmark groups
 func GetCachedBookmarks() []*BookmarkGroup {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
 	return cachedBookmarkGroups
 }
 
+// AddDynamicServiceGroup adds a discovered service to the named group, creating
+// the group if needed, and requests a UI rebuild. Groups and services are kept
+// in weight order. The cached slices are replaced rather than modified so that
+// readers holding the previous slices are unaffected.
+func AddDynamicServiceGroup(groupName string, service *Service) {
+	cacheMutex.Lock()
+	groups := make([]*ServiceGroup, 0, len(cachedServiceGroups)+1)
+	found := false
+	for _, group := range cachedServiceGroups {
+		if group.Name == groupName {
+			services := append(append([]*Service{}, group.Services...), service)
+			sortServices(services)
+			group = &ServiceGroup{Name: group.Name, Services: services}
+			found = true
+		}
+		groups = append(groups, group)
+	}
+	if !found {
+		groups = append(groups, &ServiceGroup{Name: groupName, Services: []*Service{service}})
+	}
+	sortGroups(groups, cachedLayout)
+	cachedServiceGroups = groups
+	cacheMutex.Unlock()
+
+	RegisterGroupService(service.Name, groupName)
+	RequestUIRebuild()
+}
+
+// RemoveDynamicService removes a discovered service from the cached groups,
+// and its group if left empty, and requests a UI rebuild
+func RemoveDynamicService(serviceName string) {
+	cacheMutex.Lock()
+	groups := make([]*ServiceGroup, 0, len(cachedServiceGroups))
+	for _, group := range cachedServiceGroups {
+		index := slices.IndexFunc(group.Services, func(service *Service) bool { return service.Name == serviceName })
+		if index >= 0 {
+			if len(group.Services) == 1 {
+				continue
+			}
+			group = &ServiceGroup{Name: group.Name, Services: slices.Delete(slices.Clone(group.Services), index, index+1)}
+		}
+		groups = append(groups, group)
+	}
+	cachedServiceGroups = groups
+	cacheMutex.Unlock()
+
+	delete(serviceToGroupMap, serviceName)
+	RequestUIRebuild()
+}
+
 // isDynamicGroup checks if a group is dynamically added (for now, Database is considered dynamic)
 func isDynamicGroup(groupName string) bool {
 	return groupName == "Database" || groupName == "Docker"
@@ -0,0 +1,282 @@
+package homepage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// Set when widgets must draw with ASCII characters only
+var asciiOnly atomic.Bool
+
+// SetASCIIOnly makes widgets draw sparklines and symbols with ASCII characters
+func SetASCIIOnly(ascii bool) {
+	asciiOnly.Store(ascii)
+}
+
+// WidgetConfig holds the configuration of a service widget.
+type WidgetConfig struct {
+	Type       string                 `yaml:"type"`       // Required: Widget type (e.g. opnsense, pfsense)
+	URL        string                 `yaml:"url"`        // Optional: Base URL of the widget API
+	Username   string                 `yaml:"username"`   // Optional: API username or key
+	Password   string                 `yaml:"password"`   // Optional: API password or secret
+	Key        string                 `yaml:"key"`        // Optional: API key or token
+	Interval   Seconds                `yaml:"interval"`   // Optional: Refresh interval in seconds (default: 60)
+	Timeout    Seconds                `yaml:"timeout"`    // Optional: Request timeout in seconds (default: 10)
+	SkipVerify bool                   `yaml:"skipVerify"` // Optional: Skip TLS certificate verification
+	CAFile     string                 `yaml:"caFile"`     // Optional: PEM bundle of CAs trusted in addition to the system and global ones
+	CADir      string                 `yaml:"caDir"`      // Optional: Directory of PEM CA certificates trusted in addition
+	Options    map[string]interface{} `yaml:",inline"`    // Type-specific options
+}
+
+// WidgetField is a single label/value pair displayed by a widget
+type WidgetField struct {
+	Label string      // Label shown before the value
+	Value string      // Formatted value
+	State StatusState // Optional: State used to color the value
+}
+
+// WidgetResult holds the latest data fetched by a widget
+type WidgetResult struct {
+	State       StatusState   // Overall widget state, used to color the service card
+	Message     string        // Short summary, used as service status when no check is configured
+	Fields      []WidgetField // Fields rendered under the service
+	LastUpdated time.Time     // When the widget was last refreshed
+}
+
+// Widget fetches data to display under a service
+type Widget interface {
+	Fetch(ctx context.Context) (*WidgetResult, error)
+}
+
+// intervalWidget is implemented by widgets refreshing at another pace than
+// once a minute unless an interval is configured
+type intervalWidget interface {
+	defaultInterval() int
+}
+
+// widgetFactory creates a widget from its configuration
+type widgetFactory func(config *WidgetConfig) (Widget, error)
+
+// widgetFactories maps widget types to their constructors
+var widgetFactories = map[string]widgetFactory{
+	"acme":        newACMEWidget,
+	"battery":     newBatteryWidget,
+	"ci":          newCIWidget,
+	"docker":      newDockerWidget,
+	"fail2ban":    newFail2banWidget,
+	"grafana":     newGrafanaWidget,
+	"journal":     newJournalWidget,
+	"mail":        newMailWidget,
+	"network":     newNetworkWidget,
+	"opnsense":    newOPNsenseWidget,
+	"pfsense":     newPfSenseWidget,
+	"plugin":      newPluginWidget,
+	"printer":     newPrinterWidget,
+	"temperature": newTemperatureWidget,
+	"updates":     newUpdatesWidget,
+	"upnp":        newUPnPWidget,
+	"zfs":         newZFSWidget,
+}
+
+// NewWidget creates a widget for the given configuration
+func NewWidget(config *WidgetConfig) (Widget, error) {
+	if config == nil || config.Type == "" {
+		return nil, fmt.Errorf("widget type not specified")
+	}
+	factory, ok := widgetFactories[strings.ToLower(config.Type)]
+	if !ok {
+		return nil, fmt.Errorf("unknown widget type %q", config.Type)
+	}
+	return factory(config)
+}
+
+// String returns a type-specific string option, or def if missing
+func (c *WidgetConfig) String(key, def string) string {
+	if v, ok := c.Options[key]; ok && v != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return def
+}
+
+// Int returns a type-specific integer option, or def if missing
+func (c *WidgetConfig) Int(key string, def int) int {
+	switch v := c.Options[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return def
+}
+
+// Bool returns a type-specific boolean option, or def if missing
+func (c *WidgetConfig) Bool(key string, def bool) bool {
+	if v, ok := c.Options[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// baseURL returns the widget URL without a trailing slash
+func (c *WidgetConfig) baseURL() string {
+	return strings.TrimRight(c.URL, "/")
+}
+
+// httpClient returns an HTTP client configured for the widget
+func (c *WidgetConfig) httpClient() *http.Client {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+	tlsConfig, err := clientTLSConfig(c.SkipVerify, c.CAFile, c.CADir)
+	switch {
+	case err != nil:
+		client.Transport = failingTransport{err: err}
+	case c.SkipVerify || tlsConfig.RootCAs != nil:
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+	return client
+}
+
+// getJSON performs a GET request against the widget API and decodes the JSON
+// response. Requests go through the shared cache and rate limits.
+func (c *WidgetConfig) getJSON(ctx context.Context, path string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL()+path, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	body, status, err := widgetHTTPCache.do(c.httpClient(), req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("%s returned HTTP %d", path, status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("error decoding %s: %w", path, err)
+	}
+	return nil
+}
+
+// worstState returns the most severe of the given states
+func worstState(states ...StatusState) StatusState {
+	rank := map[StatusState]int{StatusOK: 1, StatusUnknown: 0, StatusWarning: 2, StatusCritical: 3}
+	worst := StatusUnknown
+	for _, state := range states {
+		if rank[state] > rank[worst] {
+			worst = state
+		}
+	}
+	return worst
+}
+
+// startWidget starts the goroutine refreshing the widget of a service
+func (sm *StatusMonitor) startWidget(service *Service) {
+	widget, err := NewWidget(service.Widget)
+	if err != nil {
+		logging.Error("Widget for %s: %v", service.Name, err)
+		sm.storeWidgetResult(service, &WidgetResult{
+			State:       StatusCritical,
+			Message:     fmt.Sprintf("Widget error: %v", err),
+			LastUpdated: sm.clock.Now(),
+		})
+		return
+	}
+
+	interval := service.Widget.Interval
+	if interval <= 0 {
+		interval = 60
+		if w, ok := widget.(intervalWidget); ok {
+			interval = Seconds(w.defaultInterval())
+		}
+	}
+
+	stopChan := sm.addStopChannel("widget:" + service.Name)
+
+	logging.Info("Starting %s widget for %s with interval %d seconds", service.Widget.Type, service.Name, interval)
+
+	go func() {
+		ticker := sm.clock.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+
+		// Do an initial refresh immediately
+		sm.refreshWidget(service, widget, stopChan)
+
+		for {
+			select {
+			case <-ticker.C():
+				if !sm.Paused() {
+					sm.refreshWidget(service, widget, stopChan)
+				}
+			case <-stopChan:
+				logging.Debug("Widget goroutine stopped for %s", service.Name)
+				return
+			}
+		}
+	}()
+}
+
+// refreshWidget fetches fresh widget data and stores the result, unless stop
+// is closed meanwhile
+func (sm *StatusMonitor) refreshWidget(service *Service, widget Widget, stop <-chan struct{}) {
+	timeout := service.Widget.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	ctx, cancel := stopContext(stop, time.Duration(2*timeout)*time.Second)
+	defer cancel()
+
+	result, err := widget.Fetch(ctx)
+	if isStopped(stop) {
+		return
+	}
+	if err != nil {
+		logging.Error("Widget for %s: fetch failed: %v", service.Name, err)
+		result = &WidgetResult{
+			State:   StatusCritical,
+			Message: fmt.Sprintf("Widget error: %v", err),
+		}
+	}
+	result.LastUpdated = sm.clock.Now()
+	sm.storeWidgetResult(service, result)
+}
+
+// storeWidgetResult saves a widget result and notifies the UI. Services without
+// a status check of their own take their status from the widget.
+func (sm *StatusMonitor) storeWidgetResult(service *Service, result *WidgetResult) {
+	sm.mutex.Lock()
+	sm.widgetResults[service.Name] = result
+	sm.mutex.Unlock()
+
+	if !hasStatusCheck(service) {
+		sm.updateServiceStatus(service.Name, result.State, result.Message)
+		return
+	}
+
+	// Trigger a redraw with the unchanged status
+	if sm.updateFunc != nil {
+		status := sm.GetStatus(service.Name)
+		sm.updateFunc(service.Name, status.State, status.Message)
+	}
+}
+
+// hasStatusCheck reports whether a service has a status check besides its widget
+func hasStatusCheck(service *Service) bool {
+	return hasActiveCheck(service) || service.Status != "" || service.Container != "" || service.HeartbeatPeriod > 0
+}
@@ -0,0 +1,266 @@
+package homepage
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// acmeWidget lists the certificates stored by ACME clients (certbot, acme.sh
+// and Traefik) with the days until they expire. Clients renew certificates
+// when a third of their lifetime is left, 30 days for Let's Encrypt, so a
+// certificate closer to expiry than that means renewal is failing.
+type acmeWidget struct {
+	certbot       string // certbot configuration directory (default: /etc/letsencrypt)
+	acmesh        string // acme.sh home directory (default: ~/.acme.sh)
+	traefik       string // Traefik acme.json storage file (default: none)
+	warnBelow     int    // Days left turning a certificate warning, 0 for a fifth of its lifetime
+	criticalBelow int    // Days left turning a certificate critical, 0 for a tenth of its lifetime
+}
+
+// acmeCert is a stored certificate
+type acmeCert struct {
+	source    string
+	name      string
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+// traefikCertificate is a certificate in a Traefik acme.json file. Traefik v1
+// uses the same fields capitalized, which encoding/json matches as well.
+type traefikCertificate struct {
+	Domain struct {
+		Main string `json:"main"`
+	} `json:"domain"`
+	Certificate []byte `json:"certificate"` // Base64 encoded PEM
+}
+
+func newACMEWidget(config *WidgetConfig) (Widget, error) {
+	return &acmeWidget{
+		certbot:       expandHome(config.String("certbot", "/etc/letsencrypt")),
+		acmesh:        expandHome(config.String("acmesh", "~/.acme.sh")),
+		traefik:       expandHome(config.String("traefik", "")),
+		warnBelow:     config.Int("warnBelow", 0),
+		criticalBelow: config.Int("criticalBelow", 0),
+	}, nil
+}
+
+// defaultInterval checks hourly, as certificates are renewed at most daily
+func (w *acmeWidget) defaultInterval() int {
+	return 3600
+}
+
+// expandHome replaces a leading ~ in a path with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// Fetch scans the certificates of each ACME client
+func (w *acmeWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	var certs []acmeCert
+	for _, scan := range []func() ([]acmeCert, error){
+		func() ([]acmeCert, error) { return scanCertbot(w.certbot) },
+		func() ([]acmeCert, error) { return scanACMESh(w.acmesh) },
+		func() ([]acmeCert, error) { return scanTraefik(w.traefik) },
+	} {
+		found, err := scan()
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, found...)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return w.result(certs, time.Now()), nil
+}
+
+// scanCertbot reads the current certificates in the live directory of certbot
+func scanCertbot(dir string) ([]acmeCert, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "live"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("certbot: %w", err)
+	}
+
+	var certs []acmeCert
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		cert, err := readCertFile(filepath.Join(dir, "live", entry.Name(), "cert.pem"))
+		if err != nil {
+			return nil, fmt.Errorf("certbot: %w", err)
+		}
+		certs = append(certs, acmeCert{source: "certbot", name: entry.Name(), notBefore: cert.NotBefore, notAfter: cert.NotAfter})
+	}
+	return certs, nil
+}
+
+// scanACMESh reads the certificates of acme.sh, stored as <domain>/<domain>.cer
+// or <domain>_ecc/<domain>.cer for ECDSA keys
+func scanACMESh(dir string) ([]acmeCert, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("acme.sh: %w", err)
+	}
+
+	var certs []acmeCert
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		domain := strings.TrimSuffix(entry.Name(), "_ecc")
+		path := filepath.Join(dir, entry.Name(), domain+".cer")
+		if _, err := os.Stat(path); err != nil {
+			// Not a certificate directory, e.g. ca or dnsapi
+			continue
+		}
+		cert, err := readCertFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("acme.sh: %w", err)
+		}
+		certs = append(certs, acmeCert{source: "acme.sh", name: domain, notBefore: cert.NotBefore, notAfter: cert.NotAfter})
+	}
+	return certs, nil
+}
+
+// scanTraefik reads the certificates of a Traefik acme.json file, which holds
+// the certificates of each resolver (v2 and later) or of the single ACME
+// configuration (v1)
+func scanTraefik(path string) ([]acmeCert, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("traefik: %w", err)
+	}
+
+	var resolvers map[string]json.RawMessage
+	if err := json.Unmarshal(data, &resolvers); err != nil {
+		return nil, fmt.Errorf("traefik: error decoding %s: %w", path, err)
+	}
+	for key := range resolvers {
+		if strings.EqualFold(key, "Certificates") {
+			resolvers = map[string]json.RawMessage{"": data}
+			break
+		}
+	}
+
+	var certs []acmeCert
+	for resolver, raw := range resolvers {
+		var store struct {
+			Certificates []traefikCertificate `json:"certificates"`
+		}
+		if err := json.Unmarshal(raw, &store); err != nil {
+			return nil, fmt.Errorf("traefik: error decoding %s: %w", path, err)
+		}
+		for _, stored := range store.Certificates {
+			cert, err := parseCertPEM(stored.Certificate)
+			if err != nil {
+				return nil, fmt.Errorf("traefik: %s in %s: %w", stored.Domain.Main, resolver, err)
+			}
+			name := stored.Domain.Main
+			if name == "" && len(cert.DNSNames) > 0 {
+				name = cert.DNSNames[0]
+			}
+			certs = append(certs, acmeCert{source: "traefik", name: name, notBefore: cert.NotBefore, notAfter: cert.NotAfter})
+		}
+	}
+	return certs, nil
+}
+
+// readCertFile parses the first certificate of a PEM file
+func readCertFile(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := parseCertPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cert, nil
+}
+
+// parseCertPEM parses the first certificate of PEM data
+func parseCertPEM(data []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// result converts certificates to one field each, the closest to expiry first
+func (w *acmeWidget) result(certs []acmeCert, now time.Time) *WidgetResult {
+	sort.SliceStable(certs, func(i, j int) bool { return certs[i].notAfter.Before(certs[j].notAfter) })
+
+	result := &WidgetResult{LastUpdated: now}
+	var states []StatusState
+	var problems []string
+	for _, cert := range certs {
+		lifetime := cert.notAfter.Sub(cert.notBefore)
+		warnBelow, criticalBelow := lifetime/5, lifetime/10
+		if w.warnBelow > 0 {
+			warnBelow = time.Duration(w.warnBelow) * 24 * time.Hour
+		}
+		if w.criticalBelow > 0 {
+			criticalBelow = time.Duration(w.criticalBelow) * 24 * time.Hour
+		}
+
+		left := cert.notAfter.Sub(now)
+		days := int(left.Hours() / 24)
+		value := fmt.Sprintf("%d days left, %s", days, cert.notAfter.Local().Format("2006-01-02"))
+		state := StatusOK
+		switch {
+		case left <= 0:
+			state, value = StatusCritical, fmt.Sprintf("expired %s", cert.notAfter.Local().Format("2006-01-02"))
+			problems = append(problems, cert.name+" expired")
+		case left < criticalBelow:
+			state = StatusCritical
+			problems = append(problems, fmt.Sprintf("%s expires in %d days", cert.name, days))
+		case left < warnBelow:
+			state = StatusWarning
+			problems = append(problems, fmt.Sprintf("%s expires in %d days", cert.name, days))
+		}
+		states = append(states, state)
+		result.Fields = append(result.Fields, WidgetField{
+			Label: cert.name,
+			Value: value + " (" + cert.source + ")",
+			State: state,
+		})
+	}
+
+	result.State = worstState(states...)
+	if len(problems) > 0 {
+		result.Message = "Renewal stuck: " + strings.Join(problems, ", ")
+	} else {
+		result.Message = fmt.Sprintf("%d certificates, next expiry in %d days", len(certs), int(certs[0].notAfter.Sub(now).Hours()/24))
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package homepage
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertPEM creates a self-signed certificate valid between two times
+func testCertPEM(t *testing.T, domain string, notBefore, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func TestScanACMECertificates(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	day := 24 * time.Hour
+
+	writeTestFile(t, filepath.Join(dir, "letsencrypt/live/example.com/cert.pem"),
+		testCertPEM(t, "example.com", now.Add(-60*day), now.Add(30*day)))
+	writeTestFile(t, filepath.Join(dir, "letsencrypt/live/README"), []byte("certbot readme"))
+	writeTestFile(t, filepath.Join(dir, "acme.sh/mail.example.com_ecc/mail.example.com.cer"),
+		testCertPEM(t, "mail.example.com", now.Add(-80*day), now.Add(10*day)))
+	writeTestFile(t, filepath.Join(dir, "acme.sh/dnsapi/dns_cf.sh"), []byte("#!/bin/sh"))
+
+	certs, err := scanCertbot(filepath.Join(dir, "letsencrypt"))
+	require.NoError(t, err)
+	assert.Equal(t, []acmeCert{{source: "certbot", name: "example.com", notBefore: now.Add(-60 * day).UTC(), notAfter: now.Add(30 * day).UTC()}}, certs)
+
+	certs, err = scanACMESh(filepath.Join(dir, "acme.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, []acmeCert{{source: "acme.sh", name: "mail.example.com", notBefore: now.Add(-80 * day).UTC(), notAfter: now.Add(10 * day).UTC()}}, certs)
+
+	certs, err = scanCertbot(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, certs)
+
+	// Traefik v2 stores certificates per resolver, v1 at the top level
+	cert := testCertPEM(t, "app.example.com", now.Add(-10*day), now.Add(80*day))
+	v2, err := json.Marshal(map[string]interface{}{
+		"letsencrypt": map[string]interface{}{
+			"Account":      map[string]interface{}{"Email": "admin@example.com"},
+			"Certificates": []interface{}{map[string]interface{}{"domain": map[string]interface{}{"main": "app.example.com"}, "certificate": cert}},
+		},
+	})
+	require.NoError(t, err)
+	v1, err := json.Marshal(map[string]interface{}{
+		"Account":      map[string]interface{}{"Email": "admin@example.com"},
+		"Certificates": []interface{}{map[string]interface{}{"Domain": map[string]interface{}{"Main": "app.example.com"}, "Certificate": cert}},
+	})
+	require.NoError(t, err)
+	for _, data := range [][]byte{v2, v1} {
+		writeTestFile(t, filepath.Join(dir, "acme.json"), data)
+		certs, err = scanTraefik(filepath.Join(dir, "acme.json"))
+		require.NoError(t, err)
+		assert.Equal(t, []acmeCert{{source: "traefik", name: "app.example.com", notBefore: now.Add(-10 * day).UTC(), notAfter: now.Add(80 * day).UTC()}}, certs)
+	}
+}
+
+func TestACMEResult(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	day := 24 * time.Hour
+	cert := func(name string, daysLeft int) acmeCert {
+		notAfter := now.Add(time.Duration(daysLeft)*day + time.Hour)
+		return acmeCert{source: "certbot", name: name, notBefore: notAfter.Add(-90 * day), notAfter: notAfter}
+	}
+
+	widget := &acmeWidget{}
+	result := widget.result([]acmeCert{cert("a.example.com", 45), cert("b.example.com", 31)}, now)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "2 certificates, next expiry in 31 days", result.Message)
+	assert.Equal(t, WidgetField{Label: "b.example.com", Value: "31 days left, 2026-04-01 (certbot)", State: StatusOK}, result.Fields[0])
+
+	// A fifth of 90 days is 18 days and a tenth 9 days
+	result = widget.result([]acmeCert{cert("a.example.com", 45), cert("b.example.com", 12), cert("c.example.com", 5)}, now)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Renewal stuck: c.example.com expires in 5 days, b.example.com expires in 12 days", result.Message)
+	assert.Equal(t, []StatusState{StatusCritical, StatusWarning, StatusOK},
+		[]StatusState{result.Fields[0].State, result.Fields[1].State, result.Fields[2].State})
+
+	result = widget.result([]acmeCert{cert("a.example.com", -3)}, now)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Renewal stuck: a.example.com expired", result.Message)
+	assert.Equal(t, "expired 2026-02-26 (certbot)", result.Fields[0].Value)
+
+	widget = &acmeWidget{warnBelow: 50, criticalBelow: 20}
+	result = widget.result([]acmeCert{cert("a.example.com", 45)}, now)
+	assert.Equal(t, StatusWarning, result.State)
+}
@@ -0,0 +1,446 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// batteryWidget shows the charge, charging state and remaining time of the
+// battery of the local machine, or of a UPS monitored by NUT or apcupsd. The
+// charge turns warning and critical below thresholds while on battery.
+type batteryWidget struct {
+	config        *WidgetConfig
+	source        string // battery, nut or apcupsd (default: battery)
+	host          string // Address of upsd or apcupsd
+	ups           string // Name of the NUT UPS (default: the first one)
+	warnBelow     int    // Charge in percent turning the widget warning (default: 30)
+	criticalBelow int    // Charge in percent turning the widget critical (default: 10)
+}
+
+// powerStatus is the state of a battery or UPS
+type powerStatus struct {
+	charge     float64       // Charge in percent
+	state      string        // Charging state, e.g. charging or on battery
+	onBattery  bool          // Running from the battery
+	lowBattery bool          // Reported low by the UPS
+	remaining  time.Duration // Estimated time until empty, or full if untilFull, 0 if unknown
+	untilFull  bool          // The remaining time is until the battery is charged
+	load       float64       // UPS load in percent, negative if unknown
+}
+
+func newBatteryWidget(config *WidgetConfig) (Widget, error) {
+	w := &batteryWidget{
+		config:        config,
+		source:        strings.ToLower(config.String("source", "battery")),
+		ups:           config.String("ups", ""),
+		warnBelow:     config.Int("warnBelow", 30),
+		criticalBelow: config.Int("criticalBelow", 10),
+	}
+	switch w.source {
+	case "battery":
+	case "nut":
+		w.host = config.String("host", "localhost:3493")
+	case "apcupsd":
+		w.host = config.String("host", "localhost:3551")
+	default:
+		return nil, fmt.Errorf("unknown battery source %q", w.source)
+	}
+	return w, nil
+}
+
+// Fetch reads the power status from the configured source
+func (w *batteryWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	var status *powerStatus
+	var err error
+	switch w.source {
+	case "nut":
+		status, err = w.fetchNUT(ctx)
+	case "apcupsd":
+		status, err = w.fetchApcupsd(ctx)
+	default:
+		status, err = readLocalBattery(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.result(status), nil
+}
+
+// result converts a power status to widget fields
+func (w *batteryWidget) result(status *powerStatus) *WidgetResult {
+	chargeState := StatusOK
+	if status.onBattery {
+		switch {
+		case status.lowBattery || status.charge < float64(w.criticalBelow):
+			chargeState = StatusCritical
+		case status.charge < float64(w.warnBelow):
+			chargeState = StatusWarning
+		}
+	}
+
+	charge := fmt.Sprintf("%.0f%%", status.charge)
+	result := &WidgetResult{
+		State:   chargeState,
+		Message: fmt.Sprintf("%s %s", charge, status.state),
+		Fields: []WidgetField{
+			{Label: "Charge", Value: charge, State: chargeState},
+			{Label: "State", Value: status.state},
+		},
+		LastUpdated: time.Now(),
+	}
+	if status.remaining > 0 {
+		label := "Time left"
+		if status.untilFull {
+			label = "Until full"
+		}
+		result.Fields = append(result.Fields, WidgetField{Label: label, Value: formatRemaining(status.remaining)})
+	}
+	if status.load >= 0 {
+		result.Fields = append(result.Fields, WidgetField{Label: "Load", Value: fmt.Sprintf("%.0f%%", status.load)})
+	}
+	return result
+}
+
+// formatRemaining formats a remaining time as hours and minutes
+func formatRemaining(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// readLocalBattery reads the battery of the local machine
+func readLocalBattery(ctx context.Context) (*powerStatus, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return readSysfsBattery("/sys/class/power_supply")
+	case "darwin":
+		output, err := exec.CommandContext(ctx, "pmset", "-g", "batt").Output()
+		if err != nil {
+			return nil, fmt.Errorf("pmset failed: %w", err)
+		}
+		return parsePmset(string(output))
+	}
+	return nil, fmt.Errorf("battery widget not supported on %s", runtime.GOOS)
+}
+
+// readSysfsBattery reads the system batteries from the Linux power supply
+// class. The charge of several batteries is combined, weighted by capacity.
+func readSysfsBattery(dir string) (*powerStatus, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading power supplies: %w", err)
+	}
+
+	read := func(supply, name string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, supply, name))
+		return strings.TrimSpace(string(data))
+	}
+	readNumber := func(supply string, names ...string) float64 {
+		for _, name := range names {
+			if n, err := strconv.ParseFloat(read(supply, name), 64); err == nil {
+				return n
+			}
+		}
+		return 0
+	}
+
+	status := &powerStatus{load: -1}
+	var now, full, rate, capacity float64
+	batteries := 0
+	for _, entry := range entries {
+		supply := entry.Name()
+		// Peripherals such as mice report scope Device
+		if read(supply, "type") != "Battery" || read(supply, "scope") == "Device" {
+			continue
+		}
+		batteries++
+
+		switch state := strings.ToLower(read(supply, "status")); state {
+		case "discharging":
+			status.state, status.onBattery = state, true
+		case "charging":
+			if !status.onBattery {
+				status.state = state
+			}
+		default:
+			if status.state == "" {
+				status.state = state
+			}
+		}
+
+		// Energy is reported in µWh and µW, or charge in µAh and µA
+		now += readNumber(supply, "energy_now", "charge_now")
+		full += readNumber(supply, "energy_full", "charge_full")
+		rate += readNumber(supply, "power_now", "current_now")
+		capacity += readNumber(supply, "capacity")
+	}
+	if batteries == 0 {
+		return nil, fmt.Errorf("no battery found")
+	}
+	if status.state == "" {
+		status.state = "unknown"
+	}
+
+	status.charge = capacity / float64(batteries)
+	if full > 0 {
+		status.charge = now / full * 100
+	}
+	if rate > 0 {
+		switch status.state {
+		case "discharging":
+			status.remaining = time.Duration(now / rate * float64(time.Hour))
+		case "charging":
+			status.remaining = time.Duration((full - now) / rate * float64(time.Hour))
+			status.untilFull = true
+		}
+	}
+	return status, nil
+}
+
+// pmsetPattern matches the battery line of pmset -g batt, e.g.
+// " -InternalBattery-0 (id=4653155)	85%; discharging; 3:45 remaining present: true"
+var pmsetPattern = regexp.MustCompile(`(\d+)%;\s*([^;]+);\s*(?:(\d+):(\d+) remaining)?`)
+
+// parsePmset parses the output of pmset -g batt
+func parsePmset(output string) (*powerStatus, error) {
+	matches := pmsetPattern.FindStringSubmatch(output)
+	if matches == nil {
+		return nil, fmt.Errorf("no battery found")
+	}
+
+	status := &powerStatus{load: -1}
+	status.charge, _ = strconv.ParseFloat(matches[1], 64)
+	status.state = strings.TrimSpace(matches[2])
+	status.onBattery = strings.Contains(output, "'Battery Power'")
+	if matches[3] != "" {
+		hours, _ := strconv.Atoi(matches[3])
+		minutes, _ := strconv.Atoi(matches[4])
+		status.remaining = time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+		status.untilFull = status.state == "charging"
+	}
+	return status, nil
+}
+
+// dialUPS connects to a UPS daemon, bounded by the widget timeout
+func (w *batteryWidget) dialUPS(ctx context.Context) (net.Conn, error) {
+	timeout := w.config.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", w.host)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", w.host, err)
+	}
+	conn.SetDeadline(time.Now().Add(time.Duration(timeout) * time.Second))
+	return conn, nil
+}
+
+// fetchNUT reads the variables of a UPS from the NUT network server
+func (w *batteryWidget) fetchNUT(ctx context.Context) (*powerStatus, error) {
+	conn, err := w.dialUPS(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	ups := w.ups
+	if ups == "" {
+		upsList, err := nutList(conn, reader, "UPS")
+		if err != nil {
+			return nil, err
+		}
+		if len(upsList) == 0 {
+			return nil, fmt.Errorf("no UPS configured in upsd")
+		}
+		ups = upsList[0][0]
+	}
+
+	vars, err := nutList(conn, reader, "VAR", ups)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, v := range vars {
+		if len(v) == 3 {
+			values[v[1]] = v[2]
+		}
+	}
+	return nutStatus(values), nil
+}
+
+// nutList sends a LIST command to upsd and returns the fields following the
+// list type of each item, e.g. ["ups", "battery.charge", "100"] for LIST VAR ups
+func nutList(w io.Writer, r *bufio.Reader, args ...string) ([][]string, error) {
+	query := strings.Join(args, " ")
+	if _, err := fmt.Fprintf(w, "LIST %s\n", query); err != nil {
+		return nil, fmt.Errorf("error sending to upsd: %w", err)
+	}
+
+	var items [][]string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("error reading from upsd: %w", err)
+		}
+		fields := splitNUTLine(strings.TrimSpace(line))
+		switch {
+		case len(fields) > 0 && fields[0] == "ERR":
+			return nil, fmt.Errorf("upsd: %s", strings.Join(fields[1:], " "))
+		case len(fields) > 1 && fields[0] == "BEGIN":
+		case len(fields) > 1 && fields[0] == "END":
+			return items, nil
+		case len(fields) > 1 && fields[0] == args[0]:
+			items = append(items, fields[1:])
+		}
+	}
+}
+
+// splitNUTLine splits a line of the NUT protocol into words, unquoting
+// quoted words such as values with spaces
+func splitNUTLine(line string) []string {
+	var fields []string
+	for line != "" {
+		if line[0] == '"' {
+			end := 1
+			var word strings.Builder
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' && end+1 < len(line) {
+					end++
+				}
+				word.WriteByte(line[end])
+			}
+			fields = append(fields, word.String())
+			line = strings.TrimLeft(line[min(end+1, len(line)):], " ")
+			continue
+		}
+		word, rest, _ := strings.Cut(line, " ")
+		fields = append(fields, word)
+		line = strings.TrimLeft(rest, " ")
+	}
+	return fields
+}
+
+// nutStatus converts NUT variables to a power status. ups.status holds flags
+// such as OL (online), OB (on battery), LB (low battery) and CHRG (charging).
+func nutStatus(values map[string]string) *powerStatus {
+	status := &powerStatus{load: -1}
+	status.charge, _ = strconv.ParseFloat(values["battery.charge"], 64)
+	if seconds, err := strconv.ParseFloat(values["battery.runtime"], 64); err == nil {
+		status.remaining = time.Duration(seconds) * time.Second
+	}
+	if load, err := strconv.ParseFloat(values["ups.load"], 64); err == nil {
+		status.load = load
+	}
+
+	flags := strings.Fields(values["ups.status"])
+	status.state = "online"
+	for _, flag := range flags {
+		switch flag {
+		case "OB":
+			status.onBattery = true
+			status.state = "on battery"
+		case "LB":
+			status.lowBattery = true
+		case "CHRG":
+			if !status.onBattery {
+				status.state = "charging"
+			}
+		}
+	}
+	if len(flags) == 0 {
+		status.state = "unknown"
+	}
+	return status
+}
+
+// fetchApcupsd reads the status of a UPS from the apcupsd network information
+// server, which answers the status command with length-prefixed records
+func (w *batteryWidget) fetchApcupsd(ctx context.Context) (*powerStatus, error) {
+	conn, err := w.dialUPS(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	command := "status"
+	request := binary.BigEndian.AppendUint16(nil, uint16(len(command)))
+	if _, err := conn.Write(append(request, command...)); err != nil {
+		return nil, fmt.Errorf("error sending to apcupsd: %w", err)
+	}
+
+	values := make(map[string]string)
+	reader := bufio.NewReader(conn)
+	for {
+		var length uint16
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return nil, fmt.Errorf("error reading from apcupsd: %w", err)
+		}
+		if length == 0 {
+			break
+		}
+		record := make([]byte, length)
+		if _, err := io.ReadFull(reader, record); err != nil {
+			return nil, fmt.Errorf("error reading from apcupsd: %w", err)
+		}
+		// Records look like "BCHARGE  : 100.0 Percent"
+		if key, value, ok := strings.Cut(string(record), ":"); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return apcupsdStatus(values), nil
+}
+
+// apcupsdStatus converts apcupsd status records to a power status. STATUS
+// holds words such as ONLINE, ONBATT, LOWBATT and CHARGING.
+func apcupsdStatus(values map[string]string) *powerStatus {
+	number := func(key string) (float64, bool) {
+		// Values carry a unit, e.g. "45.0 Minutes"
+		value, _, _ := strings.Cut(values[key], " ")
+		n, err := strconv.ParseFloat(value, 64)
+		return n, err == nil
+	}
+
+	status := &powerStatus{load: -1}
+	status.charge, _ = number("BCHARGE")
+	if minutes, ok := number("TIMELEFT"); ok {
+		status.remaining = time.Duration(minutes * float64(time.Minute))
+	}
+	if load, ok := number("LOADPCT"); ok {
+		status.load = load
+	}
+
+	words := strings.Fields(values["STATUS"])
+	status.state = strings.ToLower(strings.Join(words, " "))
+	for _, word := range words {
+		switch word {
+		case "ONBATT":
+			status.onBattery = true
+			status.state = "on battery"
+		case "LOWBATT":
+			status.lowBattery = true
+		}
+	}
+	if len(words) == 0 {
+		status.state = "unknown"
+	}
+	return status
+}
@@ -0,0 +1,152 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSysfsBattery(t *testing.T) {
+	dir := t.TempDir()
+	writeSupply := func(name string, files map[string]string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+		for file, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name, file), []byte(content+"\n"), 0644))
+		}
+	}
+	writeSupply("AC", map[string]string{"type": "Mains", "online": "0"})
+	writeSupply("BAT0", map[string]string{"type": "Battery", "status": "Discharging", "energy_now": "20000000", "energy_full": "40000000", "power_now": "10000000"})
+	writeSupply("BAT1", map[string]string{"type": "Battery", "status": "Unknown", "energy_now": "10000000", "energy_full": "20000000"})
+	writeSupply("hidpp_battery_0", map[string]string{"type": "Battery", "scope": "Device", "capacity": "5"})
+
+	status, err := readSysfsBattery(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, status.charge)
+	assert.Equal(t, "discharging", status.state)
+	assert.True(t, status.onBattery)
+	assert.Equal(t, 3*time.Hour, status.remaining)
+	assert.False(t, status.untilFull)
+
+	_, err = readSysfsBattery(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestParsePmset(t *testing.T) {
+	status, err := parsePmset("Now drawing from 'Battery Power'\n -InternalBattery-0 (id=4653155)\t85%; discharging; 3:45 remaining present: true\n")
+	require.NoError(t, err)
+	assert.Equal(t, 85.0, status.charge)
+	assert.Equal(t, "discharging", status.state)
+	assert.True(t, status.onBattery)
+	assert.Equal(t, 3*time.Hour+45*time.Minute, status.remaining)
+
+	status, err = parsePmset("Now drawing from 'AC Power'\n -InternalBattery-0 (id=4653155)\t56%; charging; (no estimate) present: true\n")
+	require.NoError(t, err)
+	assert.Equal(t, "charging", status.state)
+	assert.False(t, status.onBattery)
+	assert.Zero(t, status.remaining)
+
+	_, err = parsePmset("Now drawing from 'AC Power'\n")
+	assert.Error(t, err)
+}
+
+func TestBatteryThresholds(t *testing.T) {
+	widget, err := newBatteryWidget(&WidgetConfig{Type: "battery", Options: map[string]interface{}{"warnBelow": 40}})
+	require.NoError(t, err)
+	w := widget.(*batteryWidget)
+
+	result := w.result(&powerStatus{charge: 35, state: "discharging", onBattery: true, remaining: 90 * time.Minute, load: -1})
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "35% discharging", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "Charge", Value: "35%", State: StatusWarning},
+		{Label: "State", Value: "discharging"},
+		{Label: "Time left", Value: "1h 30m"},
+	}, result.Fields)
+
+	assert.Equal(t, StatusCritical, w.result(&powerStatus{charge: 8, onBattery: true, load: -1}).State)
+	assert.Equal(t, StatusCritical, w.result(&powerStatus{charge: 80, onBattery: true, lowBattery: true, load: -1}).State)
+	// A low battery is fine while charging
+	assert.Equal(t, StatusOK, w.result(&powerStatus{charge: 8, state: "charging", load: -1}).State)
+
+	_, err = newBatteryWidget(&WidgetConfig{Type: "battery", Options: map[string]interface{}{"source": "solar"}})
+	assert.Error(t, err)
+}
+
+func TestFetchNUT(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch strings.TrimSpace(line) {
+			case "LIST UPS":
+				conn.Write([]byte("BEGIN LIST UPS\nUPS rack \"APC Smart-UPS 1500\"\nEND LIST UPS\n"))
+			case "LIST VAR rack":
+				conn.Write([]byte("BEGIN LIST VAR rack\n" +
+					"VAR rack battery.charge \"62\"\n" +
+					"VAR rack battery.runtime \"1260\"\n" +
+					"VAR rack ups.load \"23\"\n" +
+					"VAR rack ups.status \"OB DISCHRG\"\n" +
+					"END LIST VAR rack\n"))
+			default:
+				conn.Write([]byte("ERR UNKNOWN-COMMAND\n"))
+			}
+		}
+	}()
+
+	widget, err := newBatteryWidget(&WidgetConfig{Type: "battery", Options: map[string]interface{}{
+		"source": "nut",
+		"host":   listener.Addr().String(),
+	}})
+	require.NoError(t, err)
+
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "62% on battery", result.Message)
+	assert.Contains(t, result.Fields, WidgetField{Label: "Time left", Value: "21m"})
+	assert.Contains(t, result.Fields, WidgetField{Label: "Load", Value: "23%"})
+}
+
+func TestSplitNUTLine(t *testing.T) {
+	assert.Equal(t, []string{"UPS", "rack", "APC Smart-UPS 1500"}, splitNUTLine(`UPS rack "APC Smart-UPS 1500"`))
+	assert.Equal(t, []string{"VAR", "rack", "ups.mfr", `A "quoted" name`}, splitNUTLine(`VAR rack ups.mfr "A \"quoted\" name"`))
+}
+
+func TestApcupsdStatus(t *testing.T) {
+	status := apcupsdStatus(map[string]string{
+		"STATUS":   "ONBATT LOWBATT",
+		"BCHARGE":  "9.0 Percent",
+		"TIMELEFT": "4.5 Minutes",
+		"LOADPCT":  "31.0 Percent",
+	})
+	assert.Equal(t, 9.0, status.charge)
+	assert.Equal(t, "on battery", status.state)
+	assert.True(t, status.onBattery)
+	assert.True(t, status.lowBattery)
+	assert.Equal(t, 4*time.Minute+30*time.Second, status.remaining)
+	assert.Equal(t, 31.0, status.load)
+
+	status = apcupsdStatus(map[string]string{"STATUS": "ONLINE", "BCHARGE": "100.0 Percent"})
+	assert.Equal(t, "online", status.state)
+	assert.False(t, status.onBattery)
+}
@@ -0,0 +1,320 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ciWidget shows the latest pipeline of projects built on GitHub Actions,
+// GitLab CI or Drone, coloring the card by the worst build result, so the
+// dashboard doubles as a build radiator
+type ciWidget struct {
+	config   *WidgetConfig
+	provider string
+	projects []ciProject
+	workflow string // GitHub workflow file name or ID (default: all workflows)
+}
+
+// ciProject is a project of the widget, optionally limited to a branch
+type ciProject struct {
+	name   string
+	branch string
+}
+
+// ciPipeline is a pipeline, workflow run or build of any provider
+type ciPipeline struct {
+	number  int
+	branch  string
+	status  string // One of passed, failed, canceled, blocked, running or skipped
+	updated time.Time
+}
+
+// ciResultStates maps the results of finished pipelines to states
+var ciResultStates = map[string]StatusState{
+	"passed":   StatusOK,
+	"failed":   StatusCritical,
+	"canceled": StatusWarning,
+	"blocked":  StatusWarning,
+}
+
+// ciDefaultURLs holds the API of the hosted providers
+var ciDefaultURLs = map[string]string{
+	"github": "https://api.github.com",
+	"gitlab": "https://gitlab.com",
+}
+
+func newCIWidget(config *WidgetConfig) (Widget, error) {
+	provider := strings.ToLower(config.String("provider", ""))
+	if provider != "github" && provider != "gitlab" && provider != "drone" {
+		return nil, fmt.Errorf("ci widget requires a provider: github, gitlab or drone")
+	}
+	if config.URL == "" {
+		if ciDefaultURLs[provider] == "" {
+			return nil, fmt.Errorf("ci widget requires the url of the %s server", provider)
+		}
+		copied := *config
+		copied.URL = ciDefaultURLs[provider]
+		config = &copied
+	}
+
+	branch := config.String("branch", "")
+	var projects []ciProject
+	for _, name := range strings.Split(config.String("projects", ""), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		project := ciProject{name: name, branch: branch}
+		if name, branch, ok := strings.Cut(name, "@"); ok {
+			project = ciProject{name: name, branch: branch}
+		}
+		projects = append(projects, project)
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("ci widget requires projects, such as owner/repo")
+	}
+
+	return &ciWidget{
+		config:   config,
+		provider: provider,
+		projects: projects,
+		workflow: config.String("workflow", ""),
+	}, nil
+}
+
+// Fetch reads the recent pipelines of each project
+func (w *ciWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	result := &WidgetResult{State: StatusOK, LastUpdated: time.Now()}
+	var lastErr error
+	passed, failed, errors := 0, 0, 0
+	for _, project := range w.projects {
+		pipelines, err := w.pipelines(ctx, project)
+		if err != nil {
+			lastErr = err
+			errors++
+			result.Fields = append(result.Fields, WidgetField{Label: project.name, Value: fmt.Sprintf("Error: %v", err), State: StatusWarning})
+			result.State = worstState(result.State, StatusWarning)
+			continue
+		}
+		field := ciField(project.name, pipelines, time.Now())
+		switch field.State {
+		case StatusOK:
+			passed++
+		case StatusCritical:
+			failed++
+		}
+		result.State = worstState(result.State, field.State)
+		result.Fields = append(result.Fields, field)
+	}
+	// Projects that can't be read turn warning, unless none can
+	if errors == len(w.projects) {
+		return nil, lastErr
+	}
+
+	switch {
+	case len(w.projects) == 1:
+		result.Message = result.Fields[0].Value
+	case failed > 0:
+		result.Message = fmt.Sprintf("%d of %d pipelines failed", failed, len(w.projects))
+	case passed == len(w.projects):
+		result.Message = fmt.Sprintf("All %d pipelines passed", passed)
+	default:
+		result.Message = fmt.Sprintf("%d of %d pipelines passed", passed, len(w.projects))
+	}
+	return result, nil
+}
+
+// ciField summarizes the pipelines of a project, newest first: the latest
+// finished one gives the state, and a newer running one is mentioned
+func ciField(name string, pipelines []ciPipeline, now time.Time) WidgetField {
+	var latest, finished *ciPipeline
+	for i := range pipelines {
+		pipeline := &pipelines[i]
+		if pipeline.status == "skipped" {
+			continue
+		}
+		if latest == nil {
+			latest = pipeline
+		}
+		if _, ok := ciResultStates[pipeline.status]; ok {
+			finished = pipeline
+			break
+		}
+	}
+
+	switch {
+	case latest == nil:
+		return WidgetField{Label: name, Value: "No pipelines", State: StatusUnknown}
+	case finished == nil:
+		return WidgetField{Label: name, Value: "Running " + latest.describe(), State: StatusUnknown}
+	case latest != finished:
+		return WidgetField{
+			Label: name,
+			Value: fmt.Sprintf("Running #%d, last %s #%d", latest.number, finished.status, finished.number),
+			State: ciResultStates[finished.status],
+		}
+	}
+	status := strings.ToUpper(finished.status[:1]) + finished.status[1:]
+	return WidgetField{
+		Label: name,
+		Value: fmt.Sprintf("%s %s, %s", status, finished.describe(), relativeTime(now.Sub(finished.updated))),
+		State: ciResultStates[finished.status],
+	}
+}
+
+// describe returns the number and the branch of a pipeline
+func (p *ciPipeline) describe() string {
+	if p.branch == "" {
+		return fmt.Sprintf("#%d", p.number)
+	}
+	return fmt.Sprintf("#%d on %s", p.number, p.branch)
+}
+
+// pipelines reads the recent pipelines of a project from the provider
+func (w *ciWidget) pipelines(ctx context.Context, project ciProject) ([]ciPipeline, error) {
+	switch w.provider {
+	case "github":
+		return w.githubRuns(ctx, project)
+	case "gitlab":
+		return w.gitlabPipelines(ctx, project)
+	}
+	return w.droneBuilds(ctx, project)
+}
+
+// githubRuns reads the recent workflow runs of a repository, without those
+// of pull requests
+func (w *ciWidget) githubRuns(ctx context.Context, project ciProject) ([]ciPipeline, error) {
+	path := "/repos/" + project.name + "/actions/runs"
+	if w.workflow != "" {
+		path = "/repos/" + project.name + "/actions/workflows/" + url.PathEscape(w.workflow) + "/runs"
+	}
+	query := url.Values{"per_page": {"10"}, "exclude_pull_requests": {"true"}}
+	if project.branch != "" {
+		query.Set("branch", project.branch)
+	}
+	var headers map[string]string
+	if w.config.Key != "" {
+		headers = map[string]string{"Authorization": "Bearer " + w.config.Key}
+	}
+
+	var response struct {
+		WorkflowRuns []struct {
+			RunNumber  int       `json:"run_number"`
+			HeadBranch string    `json:"head_branch"`
+			Status     string    `json:"status"`
+			Conclusion string    `json:"conclusion"`
+			UpdatedAt  time.Time `json:"updated_at"`
+		} `json:"workflow_runs"`
+	}
+	if err := w.config.getJSON(ctx, path+"?"+query.Encode(), headers, &response); err != nil {
+		return nil, err
+	}
+
+	pipelines := make([]ciPipeline, 0, len(response.WorkflowRuns))
+	for _, run := range response.WorkflowRuns {
+		status := "running"
+		if run.Status == "completed" {
+			switch run.Conclusion {
+			case "success":
+				status = "passed"
+			case "cancelled":
+				status = "canceled"
+			case "action_required":
+				status = "blocked"
+			case "skipped", "neutral":
+				status = "skipped"
+			default: // failure, timed_out, startup_failure
+				status = "failed"
+			}
+		}
+		pipelines = append(pipelines, ciPipeline{number: run.RunNumber, branch: run.HeadBranch, status: status, updated: run.UpdatedAt})
+	}
+	return pipelines, nil
+}
+
+// gitlabPipelines reads the recent pipelines of a project, given by its path
+// or ID
+func (w *ciWidget) gitlabPipelines(ctx context.Context, project ciProject) ([]ciPipeline, error) {
+	query := url.Values{"per_page": {"10"}}
+	if project.branch != "" {
+		query.Set("ref", project.branch)
+	}
+	var headers map[string]string
+	if w.config.Key != "" {
+		headers = map[string]string{"PRIVATE-TOKEN": w.config.Key}
+	}
+
+	var response []struct {
+		IID       int       `json:"iid"`
+		Ref       string    `json:"ref"`
+		Status    string    `json:"status"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	path := "/api/v4/projects/" + url.PathEscape(project.name) + "/pipelines?" + query.Encode()
+	if err := w.config.getJSON(ctx, path, headers, &response); err != nil {
+		return nil, err
+	}
+
+	pipelines := make([]ciPipeline, 0, len(response))
+	for _, pipeline := range response {
+		status := "running"
+		switch pipeline.Status {
+		case "success":
+			status = "passed"
+		case "failed":
+			status = "failed"
+		case "canceled":
+			status = "canceled"
+		case "manual":
+			status = "blocked"
+		case "skipped":
+			status = "skipped"
+		}
+		pipelines = append(pipelines, ciPipeline{number: pipeline.IID, branch: pipeline.Ref, status: status, updated: pipeline.UpdatedAt})
+	}
+	return pipelines, nil
+}
+
+// droneBuilds reads the recent builds of a repository
+func (w *ciWidget) droneBuilds(ctx context.Context, project ciProject) ([]ciPipeline, error) {
+	query := url.Values{"per_page": {"10"}}
+	if project.branch != "" {
+		query.Set("branch", project.branch)
+	}
+	var headers map[string]string
+	if w.config.Key != "" {
+		headers = map[string]string{"Authorization": "Bearer " + w.config.Key}
+	}
+
+	var response []struct {
+		Number  int    `json:"number"`
+		Target  string `json:"target"`
+		Status  string `json:"status"`
+		Updated int64  `json:"updated"`
+	}
+	if err := w.config.getJSON(ctx, "/api/repos/"+project.name+"/builds?"+query.Encode(), headers, &response); err != nil {
+		return nil, err
+	}
+
+	pipelines := make([]ciPipeline, 0, len(response))
+	for _, build := range response {
+		status := "running"
+		switch build.Status {
+		case "success":
+			status = "passed"
+		case "failure", "error":
+			status = "failed"
+		case "killed":
+			status = "canceled"
+		case "blocked", "declined":
+			status = "blocked"
+		case "skipped":
+			status = "skipped"
+		}
+		pipelines = append(pipelines, ciPipeline{number: build.Number, branch: build.Target, status: status, updated: time.Unix(build.Updated, 0)})
+	}
+	return pipelines, nil
+}
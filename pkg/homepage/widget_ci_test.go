@@ -0,0 +1,93 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIField(t *testing.T) {
+	now := time.Now()
+	field := ciField("app", []ciPipeline{
+		{number: 43, branch: "main", status: "skipped", updated: now},
+		{number: 42, branch: "main", status: "passed", updated: now.Add(-2 * time.Hour)},
+	}, now)
+	assert.Equal(t, WidgetField{Label: "app", Value: "Passed #42 on main, 2h ago", State: StatusOK}, field)
+
+	field = ciField("app", []ciPipeline{
+		{number: 43, branch: "main", status: "running", updated: now},
+		{number: 42, branch: "main", status: "failed", updated: now.Add(-time.Hour)},
+	}, now)
+	assert.Equal(t, WidgetField{Label: "app", Value: "Running #43, last failed #42", State: StatusCritical}, field)
+
+	field = ciField("app", []ciPipeline{{number: 1, branch: "main", status: "running", updated: now}}, now)
+	assert.Equal(t, WidgetField{Label: "app", Value: "Running #1 on main", State: StatusUnknown}, field)
+
+	field = ciField("app", nil, now)
+	assert.Equal(t, WidgetField{Label: "app", Value: "No pipelines", State: StatusUnknown}, field)
+}
+
+func TestCIWidget(t *testing.T) {
+	updated := time.Now().Add(-5 * time.Minute).UTC().Format(time.RFC3339)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/api/actions/runs":
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			assert.Equal(t, "main", r.URL.Query().Get("branch"))
+			fmt.Fprintf(w, `{"workflow_runs": [{"run_number": 12, "head_branch": "main", "status": "completed", "conclusion": "success", "updated_at": %q}]}`, updated)
+		case "/repos/acme/web/actions/runs":
+			assert.Equal(t, "release", r.URL.Query().Get("branch"))
+			fmt.Fprintf(w, `{"workflow_runs": [{"run_number": 7, "head_branch": "release", "status": "completed", "conclusion": "timed_out", "updated_at": %q}]}`, updated)
+		case "/api/v4/projects/infra/ansible/pipelines":
+			assert.Equal(t, "/api/v4/projects/infra%2Fansible/pipelines", r.URL.RawPath)
+			assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+			fmt.Fprintf(w, `[{"iid": 3, "ref": "main", "status": "canceled", "updated_at": %q}]`, updated)
+		case "/api/repos/acme/site/builds":
+			fmt.Fprint(w, `[{"number": 9, "target": "main", "status": "running", "updated": 0}, {"number": 8, "target": "main", "status": "success", "updated": 0}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	widget, err := NewWidget(&WidgetConfig{Type: "ci", URL: server.URL, Key: "secret", Options: map[string]interface{}{
+		"provider": "github", "projects": "acme/api, acme/web@release, acme/gone", "branch": "main",
+	}})
+	require.NoError(t, err)
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "1 of 3 pipelines failed", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "acme/api", Value: "Passed #12 on main, 5m ago", State: StatusOK},
+		{Label: "acme/web", Value: "Failed #7 on release, 5m ago", State: StatusCritical},
+		{Label: "acme/gone", Value: "Error: /repos/acme/gone/actions/runs?branch=main&exclude_pull_requests=true&per_page=10 returned HTTP 404", State: StatusWarning},
+	}, result.Fields)
+
+	widget, err = NewWidget(&WidgetConfig{Type: "ci", URL: server.URL, Key: "secret", Options: map[string]interface{}{
+		"provider": "gitlab", "projects": "infra/ansible",
+	}})
+	require.NoError(t, err)
+	result, err = widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "Canceled #3 on main, 5m ago", result.Message)
+
+	widget, err = NewWidget(&WidgetConfig{Type: "ci", URL: server.URL, Options: map[string]interface{}{
+		"provider": "drone", "projects": "acme/site",
+	}})
+	require.NoError(t, err)
+	result, err = widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Running #9, last passed #8", result.Message)
+
+	_, err = NewWidget(&WidgetConfig{Type: "ci", Options: map[string]interface{}{"provider": "drone", "projects": "acme/site"}})
+	assert.EqualError(t, err, "ci widget requires the url of the drone server")
+}
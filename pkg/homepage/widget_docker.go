@@ -0,0 +1,91 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dockerWidget shows the engine-level state of a Docker daemon, like docker
+// info and docker system df do: how many containers run or are stopped, and
+// the disk used by images, volumes and the build cache. It gives capacity
+// context next to the status of single containers.
+type dockerWidget struct {
+	config *DockerConfig
+}
+
+func newDockerWidget(config *WidgetConfig) (Widget, error) {
+	// Without a URL, the daemon of DOCKER_HOST or the local socket is used
+	docker := &DockerConfig{}
+	switch url := config.URL; {
+	case strings.HasPrefix(url, "unix://"):
+		docker.Socket = strings.TrimPrefix(url, "unix://")
+	case url != "":
+		docker.Host = strings.TrimPrefix(url, "tcp://")
+	}
+	return &dockerWidget{config: docker}, nil
+}
+
+// defaultInterval refreshes every 5 minutes, as computing the disk usage
+// walks the images and volumes of the daemon
+func (w *dockerWidget) defaultInterval() int {
+	return 300
+}
+
+// Fetch gets the container counts and the disk usage of the daemon
+func (w *dockerWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	api, err := newDockerAPI(w.config)
+	if err != nil {
+		return nil, err
+	}
+	defer api.Close()
+
+	engine, err := api.Engine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return engine.result(time.Now()), nil
+}
+
+// result converts the state of the daemon to widget fields
+func (e dockerEngine) result(now time.Time) *WidgetResult {
+	containers := fmt.Sprintf("%d running", e.Running)
+	if e.Paused > 0 {
+		containers += fmt.Sprintf(", %d paused", e.Paused)
+	}
+	containers += fmt.Sprintf(", %d stopped", e.Stopped)
+
+	result := &WidgetResult{
+		State:       StatusOK,
+		Message:     fmt.Sprintf("%d/%d containers running", e.Running, e.Running+e.Paused+e.Stopped),
+		LastUpdated: now,
+		Fields: []WidgetField{
+			{Label: "Containers", Value: containers},
+			{Label: "Images", Value: fmt.Sprintf("%d, %s", e.Images, formatBytes(e.ImagesSize))},
+			{Label: "Volumes", Value: fmt.Sprintf("%d, %s", e.Volumes, formatBytes(e.VolumesSize))},
+		},
+	}
+	if e.BuildCacheSize > 0 {
+		result.Fields = append(result.Fields, WidgetField{Label: "Build cache", Value: formatBytes(e.BuildCacheSize)})
+	}
+	if e.Version != "" {
+		result.Fields = append(result.Fields, WidgetField{Label: "Engine", Value: e.Version})
+	}
+	return result
+}
+
+// formatBytes formats a size in bytes with decimal units, as Docker does
+func formatBytes(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f %s", value, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,56 @@
+package homepage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerWidget(t *testing.T) {
+	fake := &fakeDocker{engine: dockerEngine{
+		Version:     "28.0.4",
+		Running:     5,
+		Paused:      1,
+		Stopped:     2,
+		Images:      12,
+		ImagesSize:  4_100_000_000,
+		Volumes:     3,
+		VolumesSize: 1_250_000,
+	}}
+	useFakeDocker(t, fake)
+
+	widget, err := NewWidget(&WidgetConfig{Type: "docker", URL: "tcp://nas:2375"})
+	require.NoError(t, err)
+	assert.Equal(t, "nas:2375", widget.(*dockerWidget).config.Host)
+
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "5/8 containers running", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "Containers", Value: "5 running, 1 paused, 2 stopped"},
+		{Label: "Images", Value: "12, 4.1 GB"},
+		{Label: "Volumes", Value: "3, 1.2 MB"},
+		{Label: "Engine", Value: "28.0.4"},
+	}, result.Fields)
+	assert.Equal(t, 1, fake.closed, "The connection should be closed")
+
+	fake.err = errors.New("connection refused")
+	_, err = widget.Fetch(context.Background())
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestNewDockerWidget_Socket(t *testing.T) {
+	widget, err := NewWidget(&WidgetConfig{Type: "docker", URL: "unix:///run/user/1000/docker.sock"})
+	require.NoError(t, err)
+	assert.Equal(t, "/run/user/1000/docker.sock", widget.(*dockerWidget).config.Socket)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 kB", formatBytes(1500))
+	assert.Equal(t, "2.0 TB", formatBytes(2_000_000_000_000))
+}
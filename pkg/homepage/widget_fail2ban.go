@@ -0,0 +1,170 @@
+package homepage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// fail2banEndCommand terminates the pickled messages on the fail2ban socket
+const fail2banEndCommand = "<F2B_END_COMMAND>"
+
+// fail2banWidget shows the banned IPs and failed attempts of each fail2ban
+// jail, read from the fail2ban server socket. The socket is only accessible
+// by root unless its permissions are changed.
+type fail2banWidget struct {
+	socket  string   // Path of the server socket
+	jails   []string // Jails to show (default: all)
+	timeout time.Duration
+}
+
+// fail2banJail is the status of a jail
+type fail2banJail struct {
+	name                         string
+	currentlyFailed, totalFailed int
+	currentlyBanned, totalBanned int
+}
+
+func newFail2banWidget(config *WidgetConfig) (Widget, error) {
+	var jails []string
+	for _, name := range strings.Split(config.String("jails", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			jails = append(jails, name)
+		}
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	return &fail2banWidget{
+		socket:  config.String("socket", "/var/run/fail2ban/fail2ban.sock"),
+		jails:   jails,
+		timeout: time.Duration(timeout) * time.Second,
+	}, nil
+}
+
+// Fetch queries the status of the jails
+func (w *fail2banWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	jails := w.jails
+	if len(jails) == 0 {
+		status, err := w.command(ctx, "status")
+		if err != nil {
+			return nil, err
+		}
+		list, _ := fail2banValue(status, "Jail list").(string)
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				jails = append(jails, name)
+			}
+		}
+	}
+
+	var statuses []fail2banJail
+	for _, name := range jails {
+		status, err := w.command(ctx, "status", name)
+		if err != nil {
+			return nil, fmt.Errorf("jail %s: %w", name, err)
+		}
+		statuses = append(statuses, parseFail2banJail(name, status))
+	}
+	return fail2banResult(statuses), nil
+}
+
+// command sends a command to the fail2ban server and returns its response
+func (w *fail2banWidget) command(ctx context.Context, args ...string) (interface{}, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", w.socket)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to fail2ban: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(w.timeout))
+
+	if _, err := conn.Write(append(encodePickle(args), fail2banEndCommand...)); err != nil {
+		return nil, fmt.Errorf("error sending to fail2ban: %w", err)
+	}
+
+	var response []byte
+	buf := make([]byte, 4096)
+	for !bytes.HasSuffix(response, []byte(fail2banEndCommand)) {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("error reading from fail2ban: %w", err)
+		}
+		response = append(response, buf[:n]...)
+	}
+
+	value, err := decodePickle(bytes.TrimSuffix(response, []byte(fail2banEndCommand)))
+	if err != nil {
+		return nil, err
+	}
+	// Responses are (code, result) tuples, with an exception as result on errors
+	reply, ok := pickleItems(value)
+	if !ok || len(reply) != 2 {
+		return nil, fmt.Errorf("unexpected fail2ban response")
+	}
+	if code, _ := reply[0].(int); code != 0 {
+		if exception, ok := reply[1].(*pickleObject); ok && len(exception.args) > 0 {
+			return nil, fmt.Errorf("fail2ban: %s: %v", exception.class.name, exception.args[0])
+		}
+		return nil, fmt.Errorf("fail2ban returned error %d", code)
+	}
+	return reply[1], nil
+}
+
+// fail2banValue looks up a key in the list of (key, value) pairs returned by
+// status commands, searching nested lists such as the Filter and Actions
+// sections of a jail
+func fail2banValue(status interface{}, key string) interface{} {
+	pairs, _ := pickleItems(status)
+	for _, pair := range pairs {
+		items, ok := pickleItems(pair)
+		if !ok || len(items) != 2 {
+			continue
+		}
+		if items[0] == key {
+			return items[1]
+		}
+		if _, nested := pickleItems(items[1]); nested {
+			if value := fail2banValue(items[1], key); value != nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// parseFail2banJail reads the counters of a jail status
+func parseFail2banJail(name string, status interface{}) fail2banJail {
+	count := func(key string) int {
+		n, _ := fail2banValue(status, key).(int)
+		return n
+	}
+	return fail2banJail{
+		name:            name,
+		currentlyFailed: count("Currently failed"),
+		totalFailed:     count("Total failed"),
+		currentlyBanned: count("Currently banned"),
+		totalBanned:     count("Total banned"),
+	}
+}
+
+// fail2banResult converts jail statuses to one field per jail
+func fail2banResult(jails []fail2banJail) *WidgetResult {
+	result := &WidgetResult{State: StatusOK, LastUpdated: time.Now()}
+	banned, failed := 0, 0
+	for _, jail := range jails {
+		banned += jail.currentlyBanned
+		failed += jail.currentlyFailed
+		result.Fields = append(result.Fields, WidgetField{
+			Label: jail.name,
+			Value: fmt.Sprintf("%d banned (%d total), %d failed (%d total)",
+				jail.currentlyBanned, jail.totalBanned, jail.currentlyFailed, jail.totalFailed),
+		})
+	}
+	result.Message = fmt.Sprintf("%d banned, %d failed", banned, failed)
+	return result
+}
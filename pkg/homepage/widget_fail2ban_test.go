@@ -0,0 +1,72 @@
+package homepage
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFail2ban serves pickled responses by command on a unix socket
+func fakeFail2ban(t *testing.T, responses map[string][]byte) string {
+	socket := filepath.Join(t.TempDir(), "fail2ban.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			var request []byte
+			buf := make([]byte, 1024)
+			for !bytes.HasSuffix(request, []byte(fail2banEndCommand)) {
+				n, err := conn.Read(buf)
+				if err != nil {
+					break
+				}
+				request = append(request, buf[:n]...)
+			}
+			value, _ := decodePickle(bytes.TrimSuffix(request, []byte(fail2banEndCommand)))
+			args, _ := pickleItems(value)
+			var key []byte
+			for _, arg := range args {
+				key = append(key, arg.(string)+" "...)
+			}
+			conn.Write(append(responses[string(bytes.TrimSpace(key))], fail2banEndCommand...))
+			conn.Close()
+		}
+	}()
+	return socket
+}
+
+func TestFail2banWidget(t *testing.T) {
+	socket := fakeFail2ban(t, map[string][]byte{
+		"status":                 fail2banServerStatus,
+		"status sshd":            fail2banJailStatus,
+		"status nginx-http-auth": fail2banJailStatus,
+		"status recidive":        fail2banError,
+	})
+
+	widget, err := newFail2banWidget(&WidgetConfig{Options: map[string]interface{}{"socket": socket}})
+	require.NoError(t, err)
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "4 banned, 6 failed", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "sshd", Value: "2 banned (17 total), 3 failed (41 total)"},
+		{Label: "nginx-http-auth", Value: "2 banned (17 total), 3 failed (41 total)"},
+	}, result.Fields)
+
+	widget, err = newFail2banWidget(&WidgetConfig{Options: map[string]interface{}{"socket": socket, "jails": "recidive"}})
+	require.NoError(t, err)
+	_, err = widget.Fetch(context.Background())
+	assert.EqualError(t, err, "jail recidive: fail2ban: ValueError: Unknown jail")
+}
@@ -0,0 +1,264 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// opnsenseWidget shows WAN, gateway and firmware status from the OPNsense API.
+// Authentication uses the API key as username and the API secret as password.
+type opnsenseWidget struct {
+	config *WidgetConfig
+	wan    string // Interface identifier of the WAN (default: wan)
+}
+
+func newOPNsenseWidget(config *WidgetConfig) (Widget, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("opnsense widget requires a url")
+	}
+	return &opnsenseWidget{
+		config: config,
+		wan:    config.String("wan", "wan"),
+	}, nil
+}
+
+// Fetch queries the OPNsense API for WAN, gateway and firmware information
+func (w *opnsenseWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	var interfaces struct {
+		Rows []struct {
+			Identifier  string `json:"identifier"`
+			Description string `json:"description"`
+			Addr4       string `json:"addr4"`
+			Status      string `json:"status"`
+		} `json:"rows"`
+	}
+	if err := w.config.getJSON(ctx, "/api/interfaces/overview/interfacesInfo", nil, &interfaces); err != nil {
+		return nil, err
+	}
+
+	var gateways struct {
+		Items []struct {
+			Name             string `json:"name"`
+			Status           string `json:"status"`
+			StatusTranslated string `json:"status_translated"`
+			Delay            string `json:"delay"`
+			Loss             string `json:"loss"`
+		} `json:"items"`
+	}
+	if err := w.config.getJSON(ctx, "/api/routes/gateway/status", nil, &gateways); err != nil {
+		return nil, err
+	}
+
+	var firmware struct {
+		Status         string `json:"status"`
+		StatusMsg      string `json:"status_msg"`
+		ProductVersion string `json:"product_version"`
+	}
+	if err := w.config.getJSON(ctx, "/api/core/firmware/status", nil, &firmware); err != nil {
+		return nil, err
+	}
+
+	result := &WidgetResult{LastUpdated: time.Now()}
+
+	// WAN address
+	wanState := StatusCritical
+	wanValue := "not found"
+	for _, row := range interfaces.Rows {
+		if strings.EqualFold(row.Identifier, w.wan) {
+			wanValue = strings.Split(row.Addr4, "/")[0]
+			if wanValue == "" {
+				wanValue = "no address"
+			}
+			if strings.EqualFold(row.Status, "up") {
+				wanState = StatusOK
+			}
+			break
+		}
+	}
+	result.Fields = append(result.Fields, WidgetField{Label: "WAN IP", Value: wanValue, State: wanState})
+
+	// Gateways ("none" means no alarm in OPNsense)
+	gatewayStates := []StatusState{}
+	for _, gw := range gateways.Items {
+		state := StatusOK
+		switch strings.ToLower(gw.Status) {
+		case "none", "online":
+		case "down", "force_down":
+			state = StatusCritical
+		default:
+			state = StatusWarning
+		}
+		gatewayStates = append(gatewayStates, state)
+
+		value := gw.StatusTranslated
+		if value == "" {
+			value = gw.Status
+		}
+		if gw.Delay != "" {
+			value = fmt.Sprintf("%s (%s, loss %s)", value, gw.Delay, gw.Loss)
+		}
+		result.Fields = append(result.Fields, WidgetField{Label: gw.Name, Value: value, State: state})
+	}
+
+	// Firmware
+	firmwareState := StatusOK
+	firmwareValue := "Up to date"
+	switch firmware.Status {
+	case "update", "upgrade":
+		firmwareState = StatusWarning
+		firmwareValue = "Update available"
+	case "error":
+		firmwareState = StatusWarning
+		firmwareValue = "Check failed"
+	}
+	if firmware.ProductVersion != "" {
+		firmwareValue = fmt.Sprintf("%s (%s)", firmwareValue, firmware.ProductVersion)
+	}
+	result.Fields = append(result.Fields, WidgetField{Label: "Firmware", Value: firmwareValue, State: firmwareState})
+
+	result.State = worstState(append(gatewayStates, wanState)...)
+	result.Message = fmt.Sprintf("WAN %s", wanValue)
+	return result, nil
+}
+
+// pfsenseWidget shows WAN, gateway and firmware status from the pfSense REST API package.
+// Version 1 of the API authenticates with "username password" (client ID and token),
+// version 2 with an API key.
+type pfsenseWidget struct {
+	config  *WidgetConfig
+	wan     string // Interface name of the WAN (default: wan)
+	version int    // REST API version (1 or 2, default: 2)
+}
+
+func newPfSenseWidget(config *WidgetConfig) (Widget, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("pfsense widget requires a url")
+	}
+	version := config.Int("version", 2)
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("unsupported pfsense API version %d", version)
+	}
+	return &pfsenseWidget{
+		config:  config,
+		wan:     config.String("wan", "wan"),
+		version: version,
+	}, nil
+}
+
+// headers returns the authentication headers for the configured API version
+func (w *pfsenseWidget) headers() map[string]string {
+	if w.version == 1 {
+		return map[string]string{"Authorization": w.config.Username + " " + w.config.Password}
+	}
+	return map[string]string{"X-API-Key": w.config.Key}
+}
+
+// Fetch queries the pfSense API for WAN, gateway and firmware information
+func (w *pfsenseWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	// Basic auth is not used by the pfSense API
+	config := *w.config
+	config.Username, config.Password = "", ""
+
+	prefix := fmt.Sprintf("/api/v%d", w.version)
+	interfacesPath, gatewaysPath := prefix+"/status/interfaces", prefix+"/status/gateways"
+	if w.version == 1 {
+		interfacesPath, gatewaysPath = prefix+"/status/interface", prefix+"/status/gateway"
+	}
+
+	var interfaces struct {
+		Data []struct {
+			Name   string `json:"name"`
+			Descr  string `json:"descr"`
+			IPAddr string `json:"ipaddr"`
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	if err := config.getJSON(ctx, interfacesPath, w.headers(), &interfaces); err != nil {
+		return nil, err
+	}
+
+	var gateways struct {
+		Data []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Delay  string `json:"delay"`
+			Loss   string `json:"loss"`
+		} `json:"data"`
+	}
+	if err := config.getJSON(ctx, gatewaysPath, w.headers(), &gateways); err != nil {
+		return nil, err
+	}
+
+	result := &WidgetResult{LastUpdated: time.Now()}
+
+	// WAN address
+	wanState := StatusCritical
+	wanValue := "not found"
+	for _, iface := range interfaces.Data {
+		if strings.EqualFold(iface.Name, w.wan) || strings.EqualFold(iface.Descr, w.wan) {
+			wanValue = iface.IPAddr
+			if wanValue == "" {
+				wanValue = "no address"
+			}
+			if strings.EqualFold(iface.Status, "up") {
+				wanState = StatusOK
+			}
+			break
+		}
+	}
+	result.Fields = append(result.Fields, WidgetField{Label: "WAN IP", Value: wanValue, State: wanState})
+
+	// Gateways
+	gatewayStates := []StatusState{}
+	for _, gw := range gateways.Data {
+		state := StatusOK
+		switch strings.ToLower(gw.Status) {
+		case "online", "none":
+		case "down", "offline":
+			state = StatusCritical
+		default:
+			state = StatusWarning
+		}
+		gatewayStates = append(gatewayStates, state)
+
+		value := gw.Status
+		if gw.Delay != "" {
+			value = fmt.Sprintf("%s (%s, loss %s)", value, gw.Delay, gw.Loss)
+		}
+		result.Fields = append(result.Fields, WidgetField{Label: gw.Name, Value: value, State: state})
+	}
+
+	// Firmware update availability is only exposed by version 1 of the API
+	if w.version == 1 {
+		var upgrade struct {
+			Data struct {
+				InstalledVersion string `json:"installed_version"`
+				LatestVersion    string `json:"latest_version"`
+				UpdateAvailable  bool   `json:"update_available"`
+			} `json:"data"`
+		}
+		if err := config.getJSON(ctx, prefix+"/system/version/upgrade", w.headers(), &upgrade); err == nil {
+			field := WidgetField{Label: "Firmware", Value: "Up to date (" + upgrade.Data.InstalledVersion + ")", State: StatusOK}
+			if upgrade.Data.UpdateAvailable {
+				field.Value = fmt.Sprintf("Update available (%s)", upgrade.Data.LatestVersion)
+				field.State = StatusWarning
+			}
+			result.Fields = append(result.Fields, field)
+		}
+	} else {
+		var version struct {
+			Data struct {
+				Version string `json:"version"`
+			} `json:"data"`
+		}
+		if err := config.getJSON(ctx, prefix+"/system/version", w.headers(), &version); err == nil && version.Data.Version != "" {
+			result.Fields = append(result.Fields, WidgetField{Label: "Firmware", Value: version.Data.Version, State: StatusOK})
+		}
+	}
+
+	result.State = worstState(append(gatewayStates, wanState)...)
+	result.Message = fmt.Sprintf("WAN %s", wanValue)
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOPNsenseWidget(t *testing.T) {
+	firmware := `{"status": "none", "product_version": "24.7.1"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "key" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/interfaces/overview/interfacesInfo":
+			fmt.Fprint(w, `{"rows": [
+				{"identifier": "lan", "addr4": "192.168.1.1/24", "status": "up"},
+				{"identifier": "wan", "addr4": "203.0.113.7/24", "status": "up"}
+			]}`)
+		case "/api/routes/gateway/status":
+			fmt.Fprint(w, `{"items": [
+				{"name": "WAN_DHCP", "status": "none", "status_translated": "Online", "delay": "4.2 ms", "loss": "0.0 %"},
+				{"name": "WAN_BACKUP", "status": "down", "status_translated": "Offline"}
+			]}`)
+		case "/api/core/firmware/status":
+			fmt.Fprint(w, firmware)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	widget, err := NewWidget(&WidgetConfig{Type: "opnsense", URL: server.URL, Username: "key", Password: "secret"})
+	require.NoError(t, err)
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "WAN 203.0.113.7", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "WAN IP", Value: "203.0.113.7", State: StatusOK},
+		{Label: "WAN_DHCP", Value: "Online (4.2 ms, loss 0.0 %)", State: StatusOK},
+		{Label: "WAN_BACKUP", Value: "Offline", State: StatusCritical},
+		{Label: "Firmware", Value: "Up to date (24.7.1)", State: StatusOK},
+	}, result.Fields)
+
+	// A missing WAN interface is critical, pending updates are a warning
+	firmware = `{"status": "update", "product_version": "24.7.1"}`
+	widget, err = NewWidget(&WidgetConfig{Type: "opnsense", URL: server.URL, Username: "key", Password: "secret", Options: map[string]interface{}{"wan": "opt1"}})
+	require.NoError(t, err)
+	result, err = widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "WAN not found", result.Message)
+	assert.Equal(t, WidgetField{Label: "WAN IP", Value: "not found", State: StatusCritical}, result.Fields[0])
+	assert.Equal(t, WidgetField{Label: "Firmware", Value: "Update available (24.7.1)", State: StatusWarning}, result.Fields[3])
+
+	widget, err = NewWidget(&WidgetConfig{Type: "opnsense", URL: server.URL, Username: "key", Password: "wrong"})
+	require.NoError(t, err)
+	_, err = widget.Fetch(context.Background())
+	assert.EqualError(t, err, "/api/interfaces/overview/interfacesInfo returned HTTP 401")
+
+	_, err = NewWidget(&WidgetConfig{Type: "opnsense"})
+	assert.EqualError(t, err, "opnsense widget requires a url")
+}
+
+func TestPfSenseWidget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, basic := r.BasicAuth()
+		assert.False(t, basic, "pfSense requests must not use basic auth")
+		switch r.URL.Path {
+		case "/api/v2/status/interfaces", "/api/v2/status/gateways", "/api/v2/system/version":
+			if r.Header.Get("X-API-Key") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		default:
+			if r.Header.Get("Authorization") != "client token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		switch r.URL.Path {
+		case "/api/v2/status/interfaces", "/api/v1/status/interface":
+			fmt.Fprint(w, `{"data": [{"name": "wan", "descr": "WAN", "ipaddr": "198.51.100.2", "status": "up"}]}`)
+		case "/api/v2/status/gateways", "/api/v1/status/gateway":
+			fmt.Fprint(w, `{"data": [{"name": "WAN_DHCP", "status": "loss", "delay": "12ms", "loss": "15%"}]}`)
+		case "/api/v2/system/version":
+			fmt.Fprint(w, `{"data": {"version": "2.7.2-RELEASE"}}`)
+		case "/api/v1/system/version/upgrade":
+			fmt.Fprint(w, `{"data": {"installed_version": "2.7.0", "latest_version": "2.7.2", "update_available": true}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	widget, err := NewWidget(&WidgetConfig{Type: "pfsense", URL: server.URL, Key: "secret", Username: "ignored", Password: "ignored"})
+	require.NoError(t, err)
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "WAN 198.51.100.2", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "WAN IP", Value: "198.51.100.2", State: StatusOK},
+		{Label: "WAN_DHCP", Value: "loss (12ms, loss 15%)", State: StatusWarning},
+		{Label: "Firmware", Value: "2.7.2-RELEASE", State: StatusOK},
+	}, result.Fields)
+
+	widget, err = NewWidget(&WidgetConfig{Type: "pfsense", URL: server.URL, Username: "client", Password: "token", Options: map[string]interface{}{
+		"version": 1, "wan": "WAN",
+	}})
+	require.NoError(t, err)
+	result, err = widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, WidgetField{Label: "WAN IP", Value: "198.51.100.2", State: StatusOK}, result.Fields[0])
+	assert.Equal(t, WidgetField{Label: "Firmware", Value: "Update available (2.7.2)", State: StatusWarning}, result.Fields[2])
+
+	widget, err = NewWidget(&WidgetConfig{Type: "pfsense", URL: server.URL, Key: "wrong"})
+	require.NoError(t, err)
+	_, err = widget.Fetch(context.Background())
+	assert.EqualError(t, err, "/api/v2/status/interfaces returned HTTP 401")
+
+	_, err = NewWidget(&WidgetConfig{Type: "pfsense", URL: server.URL, Options: map[string]interface{}{"version": 3}})
+	assert.EqualError(t, err, "unsupported pfsense API version 3")
+}
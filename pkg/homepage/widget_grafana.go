@@ -0,0 +1,111 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// grafanaWidget shows the number of firing alerts by severity from Grafana's
+// alerting API. Authentication uses either basic auth or a service account
+// token set as key.
+type grafanaWidget struct {
+	config        *WidgetConfig
+	severityLabel string   // Label holding the alert severity (default: severity)
+	critical      []string // Severities considered critical (default: critical)
+}
+
+func newGrafanaWidget(config *WidgetConfig) (Widget, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("grafana widget requires a url")
+	}
+
+	critical := []string{"critical"}
+	if raw := config.String("criticalSeverities", ""); raw != "" {
+		critical = strings.Split(raw, ",")
+		for i := range critical {
+			critical[i] = strings.TrimSpace(critical[i])
+		}
+	}
+
+	return &grafanaWidget{
+		config:        config,
+		severityLabel: config.String("severityLabel", "severity"),
+		critical:      critical,
+	}, nil
+}
+
+// Fetch queries the Grafana Alertmanager API for active, unsilenced alerts
+func (w *grafanaWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	var headers map[string]string
+	if w.config.Key != "" {
+		headers = map[string]string{"Authorization": "Bearer " + w.config.Key}
+	}
+
+	var alerts []struct {
+		Labels map[string]string `json:"labels"`
+		Status struct {
+			State string `json:"state"`
+		} `json:"status"`
+	}
+	path := "/api/alertmanager/grafana/api/v2/alerts?active=true&silenced=false&inhibited=false"
+	if err := w.config.getJSON(ctx, path, headers, &alerts); err != nil {
+		return nil, err
+	}
+
+	// Count firing alerts by severity
+	counts := make(map[string]int)
+	total := 0
+	for _, alert := range alerts {
+		if alert.Status.State != "" && alert.Status.State != "active" {
+			continue
+		}
+		severity := alert.Labels[w.severityLabel]
+		if severity == "" {
+			severity = "none"
+		}
+		counts[strings.ToLower(severity)]++
+		total++
+	}
+
+	result := &WidgetResult{State: StatusOK, LastUpdated: time.Now()}
+	if total == 0 {
+		result.Message = "No alerts firing"
+		result.Fields = append(result.Fields, WidgetField{Label: "Firing", Value: "0", State: StatusOK})
+		return result, nil
+	}
+
+	severities := make([]string, 0, len(counts))
+	for severity := range counts {
+		severities = append(severities, severity)
+	}
+	sort.Strings(severities)
+
+	for _, severity := range severities {
+		state := StatusWarning
+		if w.isCritical(severity) {
+			state = StatusCritical
+		}
+		result.State = worstState(result.State, state)
+		result.Fields = append(result.Fields, WidgetField{
+			Label: strings.ToUpper(severity[:1]) + severity[1:],
+			Value: fmt.Sprintf("%d firing", counts[severity]),
+			State: state,
+		})
+	}
+
+	result.Message = fmt.Sprintf("%d alerts firing", total)
+	return result, nil
+}
+
+// isCritical reports whether a severity is considered critical
+func (w *grafanaWidget) isCritical(severity string) bool {
+	for _, critical := range w.critical {
+		if strings.EqualFold(critical, severity) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaWidget(t *testing.T) {
+	alerts := `[]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/alertmanager/grafana/api/v2/alerts" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer glsa_secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "true", r.URL.Query().Get("active"))
+		assert.Equal(t, "false", r.URL.Query().Get("silenced"))
+		fmt.Fprint(w, alerts)
+	}))
+	defer server.Close()
+
+	widget, err := NewWidget(&WidgetConfig{Type: "grafana", URL: server.URL, Key: "glsa_secret", Options: map[string]interface{}{
+		"criticalSeverities": "critical, page",
+	}})
+	require.NoError(t, err)
+
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "No alerts firing", result.Message)
+	assert.Equal(t, []WidgetField{{Label: "Firing", Value: "0", State: StatusOK}}, result.Fields)
+
+	alerts = `[
+		{"labels": {"alertname": "DiskFull", "severity": "Warning"}, "status": {"state": "active"}},
+		{"labels": {"alertname": "HighLoad", "severity": "warning"}, "status": {"state": "active"}},
+		{"labels": {"alertname": "NodeDown", "severity": "page"}, "status": {"state": "active"}},
+		{"labels": {"alertname": "Silenced", "severity": "critical"}, "status": {"state": "suppressed"}},
+		{"labels": {"alertname": "Unlabeled"}, "status": {}}
+	]`
+	result, err = widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "4 alerts firing", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "None", Value: "1 firing", State: StatusWarning},
+		{Label: "Page", Value: "1 firing", State: StatusCritical},
+		{Label: "Warning", Value: "2 firing", State: StatusWarning},
+	}, result.Fields)
+
+	widget, err = NewWidget(&WidgetConfig{Type: "grafana", URL: server.URL, Key: "wrong"})
+	require.NoError(t, err)
+	_, err = widget.Fetch(context.Background())
+	assert.EqualError(t, err, "/api/alertmanager/grafana/api/v2/alerts?active=true&silenced=false&inhibited=false returned HTTP 401")
+
+	alerts = `{"message": "not a list"}`
+	widget, err = NewWidget(&WidgetConfig{Type: "grafana", URL: server.URL, Key: "glsa_secret"})
+	require.NoError(t, err)
+	_, err = widget.Fetch(context.Background())
+	assert.ErrorContains(t, err, "error decoding")
+
+	_, err = NewWidget(&WidgetConfig{Type: "grafana"})
+	assert.EqualError(t, err, "grafana widget requires a url")
+}
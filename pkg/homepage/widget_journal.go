@@ -0,0 +1,203 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalPriorities are the syslog priorities accepted by journalctl -p
+var journalPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// journalWidget shows the last lines logged to journald by systemd units, as
+// context for their status. Lines logged with priority err or more urgent are
+// shown in red, warnings in yellow.
+type journalWidget struct {
+	units      []string // Units to show the lines of (default: all)
+	user       bool     // Units are user units
+	identifier string   // Syslog identifier to show the lines of
+	priority   string   // Most verbose priority shown, or a range such as err..warning
+	lines      int      // Number of lines shown (default: 10)
+}
+
+// journalEntry is a journal entry as exported by journalctl -o json
+type journalEntry struct {
+	RealtimeTimestamp string          `json:"__REALTIME_TIMESTAMP"` // Microseconds since the epoch
+	Priority          string          `json:"PRIORITY"`
+	Identifier        string          `json:"SYSLOG_IDENTIFIER"`
+	Message           json.RawMessage `json:"MESSAGE"` // A string, or an array of bytes if not valid UTF-8
+}
+
+func newJournalWidget(config *WidgetConfig) (Widget, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("journal widget not supported on %s", runtime.GOOS)
+	}
+
+	priority := strings.ToLower(config.String("priority", ""))
+	if priority != "" {
+		for _, p := range strings.Split(priority, "..") {
+			if _, ok := journalPriority(p); !ok {
+				return nil, fmt.Errorf("unknown journal priority %q", p)
+			}
+		}
+	}
+
+	var units []string
+	for _, unit := range strings.Split(config.String("unit", ""), ",") {
+		if unit = strings.TrimSpace(unit); unit != "" {
+			units = append(units, unit)
+		}
+	}
+	return &journalWidget{
+		units:      units,
+		user:       config.Bool("user", false),
+		identifier: config.String("identifier", ""),
+		priority:   priority,
+		lines:      config.Int("lines", 10),
+	}, nil
+}
+
+// defaultInterval refreshes often, so new lines show up soon after logged
+func (w *journalWidget) defaultInterval() int {
+	return 5
+}
+
+// journalPriority returns the level of a priority given by name or number
+func journalPriority(priority string) (int, bool) {
+	if level, err := strconv.Atoi(priority); err == nil {
+		return level, level >= 0 && level < len(journalPriorities)
+	}
+	for level, name := range journalPriorities {
+		if name == priority {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// args returns the journalctl arguments selecting the lines to show
+func (w *journalWidget) args() []string {
+	args := []string{"--no-pager", "--quiet", "-o", "json", "-n", strconv.Itoa(w.lines)}
+	if w.user {
+		args = append(args, "--user")
+	}
+	for _, unit := range w.units {
+		args = append(args, "-u", unit)
+	}
+	if w.identifier != "" {
+		args = append(args, "-t", w.identifier)
+	}
+	if w.priority != "" {
+		args = append(args, "-p", w.priority)
+	}
+	return args
+}
+
+// Fetch runs journalctl and returns the last lines
+func (w *journalWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	output, err := exec.CommandContext(ctx, "journalctl", w.args()...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			line, _, _ := strings.Cut(strings.TrimSpace(string(exitErr.Stderr)), "\n")
+			return nil, fmt.Errorf("journalctl: %s", line)
+		}
+		return nil, fmt.Errorf("journalctl failed: %w", err)
+	}
+	entries, err := parseJournal(string(output))
+	if err != nil {
+		return nil, err
+	}
+	return journalResult(entries, w.units), nil
+}
+
+// parseJournal parses the entries printed by journalctl -o json, one per line
+func parseJournal(output string) ([]journalEntry, error) {
+	var entries []journalEntry
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("error decoding journal entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// time returns when the entry was logged
+func (e *journalEntry) time() time.Time {
+	usec, _ := strconv.ParseInt(e.RealtimeTimestamp, 10, 64)
+	return time.UnixMicro(usec)
+}
+
+// message returns the logged message
+func (e *journalEntry) message() string {
+	var message string
+	if json.Unmarshal(e.Message, &message) == nil {
+		return message
+	}
+	var data []byte
+	var values []int
+	if json.Unmarshal(e.Message, &values) == nil {
+		for _, v := range values {
+			data = append(data, byte(v))
+		}
+	}
+	return strings.ToValidUTF8(string(data), "?")
+}
+
+// journalResult converts journal entries to one field per line, labeled with
+// the time and, for lines of several programs, the program logging it
+func journalResult(entries []journalEntry, units []string) *WidgetResult {
+	result := &WidgetResult{State: StatusOK, LastUpdated: time.Now()}
+	identifiers := make(map[string]bool)
+	for _, entry := range entries {
+		identifiers[entry.Identifier] = true
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		logged := entry.time().Local()
+		label := logged.Format("15:04:05")
+		if logged.YearDay() != now.YearDay() || logged.Year() != now.Year() {
+			label = logged.Format("Jan 2 15:04")
+		}
+		if len(identifiers) > 1 && entry.Identifier != "" {
+			label += " " + entry.Identifier
+		}
+
+		var state StatusState
+		if level, ok := journalPriority(entry.Priority); ok {
+			switch {
+			case level <= 3:
+				state = StatusCritical
+			case level == 4:
+				state = StatusWarning
+			}
+		}
+		message := strings.TrimSpace(strings.ReplaceAll(entry.message(), "\n", " "))
+		result.Fields = append(result.Fields, WidgetField{Label: label, Value: message, State: state})
+	}
+
+	switch {
+	case len(entries) == 0 && len(units) > 0:
+		result.Message = "No journal entries for " + strings.Join(units, ", ")
+	case len(entries) == 0:
+		result.Message = "No journal entries"
+	default:
+		result.Message = fmt.Sprintf("Last logged %s", relativeTime(now.Sub(entries[len(entries)-1].time())))
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package homepage
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalWidgetArgs(t *testing.T) {
+	widget := &journalWidget{units: []string{"nginx.service", "php-fpm"}, identifier: "certbot", priority: "err..warning", lines: 5}
+	assert.Equal(t, []string{"--no-pager", "--quiet", "-o", "json", "-n", "5",
+		"-u", "nginx.service", "-u", "php-fpm", "-t", "certbot", "-p", "err..warning"}, widget.args())
+
+	level, ok := journalPriority("warning")
+	assert.True(t, ok)
+	assert.Equal(t, 4, level)
+	_, ok = journalPriority("9")
+	assert.False(t, ok)
+}
+
+func TestJournalResult(t *testing.T) {
+	logged := time.Now().Add(-2 * time.Minute).Truncate(time.Second)
+	timestamp := strconv.FormatInt(logged.UnixMicro(), 10)
+	output := `{"__REALTIME_TIMESTAMP":"` + timestamp + `","PRIORITY":"6","SYSLOG_IDENTIFIER":"nginx","MESSAGE":"Started [nginx]"}
+{"__REALTIME_TIMESTAMP":"` + timestamp + `","PRIORITY":"3","SYSLOG_IDENTIFIER":"systemd","MESSAGE":"nginx.service: Failed\nwith result 'exit-code'."}
+{"__REALTIME_TIMESTAMP":"` + timestamp + `","PRIORITY":"4","SYSLOG_IDENTIFIER":"nginx","MESSAGE":[104,105,255]}
+`
+	entries, err := parseJournal(output)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	result := journalResult(entries, []string{"nginx"})
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Last logged 2m ago", result.Message)
+	label := logged.Format("15:04:05")
+	if logged.YearDay() != time.Now().YearDay() {
+		label = logged.Format("Jan 2 15:04")
+	}
+	assert.Equal(t, []WidgetField{
+		{Label: label + " nginx", Value: "Started [nginx]"},
+		{Label: label + " systemd", Value: "nginx.service: Failed with result 'exit-code'.", State: StatusCritical},
+		{Label: label + " nginx", Value: "hi?", State: StatusWarning},
+	}, result.Fields)
+
+	result = journalResult(nil, []string{"nginx"})
+	assert.Equal(t, "No journal entries for nginx", result.Message)
+	assert.Empty(t, result.Fields)
+}
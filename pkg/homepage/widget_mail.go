@@ -0,0 +1,295 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mailWidget shows the length of the Postfix queue of the local host, or of a
+// remote host over SSH, so a stuck outbound queue gets noticed, or the unread
+// messages of an IMAP mailbox
+type mailWidget struct {
+	config        *WidgetConfig
+	source        string // postfix or imap
+	host          string // SSH destination of the Postfix host (default: the local host)
+	mailbox       string // IMAP mailbox (default: INBOX)
+	warnAbove     int    // Queued or unread messages turning warning, 0 for never
+	criticalAbove int    // Queued or unread messages turning critical, 0 for never
+	timeout       time.Duration
+}
+
+// postfixQueue summarizes the messages listed by postqueue -j
+type postfixQueue struct {
+	queues map[string]int // Messages by queue, e.g. active or deferred
+	total  int
+	oldest time.Time // Arrival of the oldest message
+	reason string    // Delay reason of the oldest deferred message
+}
+
+func newMailWidget(config *WidgetConfig) (Widget, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	w := &mailWidget{
+		config:  config,
+		source:  strings.ToLower(config.String("source", "postfix")),
+		host:    config.String("host", ""),
+		mailbox: config.String("mailbox", "INBOX"),
+		timeout: time.Duration(timeout) * time.Second,
+	}
+	switch w.source {
+	case "postfix":
+		w.warnAbove = config.Int("warnAbove", 10)
+		w.criticalAbove = config.Int("criticalAbove", 100)
+	case "imap":
+		if config.URL == "" {
+			return nil, fmt.Errorf("mail widget requires the url of the IMAP server, such as imaps://mail.example.com")
+		}
+		w.warnAbove = config.Int("warnAbove", 0)
+		w.criticalAbove = config.Int("criticalAbove", 0)
+	default:
+		return nil, fmt.Errorf("unknown mail source %q, use postfix or imap", w.source)
+	}
+	return w, nil
+}
+
+// Fetch reads the Postfix queue or the IMAP mailbox
+func (w *mailWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	if w.source == "imap" {
+		return w.fetchIMAP(ctx)
+	}
+	output, err := runShell(ctx, w.host, "postqueue -j", w.timeout)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := parsePostqueue(output)
+	if err != nil {
+		return nil, err
+	}
+	return queue.result(w.thresholdState(queue.total), time.Now()), nil
+}
+
+// thresholdState returns the state of a count of queued or unread messages
+func (w *mailWidget) thresholdState(count int) StatusState {
+	switch {
+	case w.criticalAbove > 0 && count > w.criticalAbove:
+		return StatusCritical
+	case w.warnAbove > 0 && count > w.warnAbove:
+		return StatusWarning
+	}
+	return StatusOK
+}
+
+// parsePostqueue parses the JSON objects printed by postqueue -j, one per
+// line and message
+func parsePostqueue(output string) (*postfixQueue, error) {
+	queue := &postfixQueue{queues: make(map[string]int)}
+	var oldestDeferred time.Time
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var message struct {
+			QueueName   string `json:"queue_name"`
+			ArrivalTime int64  `json:"arrival_time"`
+			Recipients  []struct {
+				DelayReason string `json:"delay_reason"`
+			} `json:"recipients"`
+		}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			return nil, fmt.Errorf("error decoding postqueue output: %w", err)
+		}
+		arrival := time.Unix(message.ArrivalTime, 0)
+		queue.queues[message.QueueName]++
+		queue.total++
+		if queue.oldest.IsZero() || arrival.Before(queue.oldest) {
+			queue.oldest = arrival
+		}
+		if message.QueueName == "deferred" && len(message.Recipients) > 0 && (oldestDeferred.IsZero() || arrival.Before(oldestDeferred)) {
+			oldestDeferred = arrival
+			queue.reason = message.Recipients[0].DelayReason
+		}
+	}
+	return queue, nil
+}
+
+// result returns the widget result of the queue, in the given state
+func (q *postfixQueue) result(state StatusState, now time.Time) *WidgetResult {
+	result := &WidgetResult{State: state, LastUpdated: now}
+	result.Fields = append(result.Fields, WidgetField{Label: "Queued", Value: strconv.Itoa(q.total), State: state})
+	if q.total == 0 {
+		result.Message = "Mail queue empty"
+		return result
+	}
+
+	result.Fields = append(result.Fields, WidgetField{Label: "Active", Value: strconv.Itoa(q.queues["active"])})
+	deferred := WidgetField{Label: "Deferred", Value: strconv.Itoa(q.queues["deferred"])}
+	if q.queues["deferred"] > 0 {
+		deferred.State = StatusWarning
+	}
+	result.Fields = append(result.Fields, deferred)
+	if q.queues["hold"] > 0 {
+		result.Fields = append(result.Fields, WidgetField{Label: "Hold", Value: strconv.Itoa(q.queues["hold"])})
+	}
+	result.Fields = append(result.Fields, WidgetField{Label: "Oldest", Value: formatUptime(now.Sub(q.oldest))})
+	if q.reason != "" {
+		result.Fields = append(result.Fields, WidgetField{Label: "Reason", Value: q.reason})
+	}
+	result.Message = fmt.Sprintf("%d messages queued, %d deferred", q.total, q.queues["deferred"])
+	return result
+}
+
+// imapStatusPattern matches the counts of a STATUS response
+var imapStatusPattern = regexp.MustCompile(`(?i)\b(MESSAGES|UNSEEN) (\d+)`)
+
+// fetchIMAP logs in to the IMAP server and reads the status of the mailbox
+func (w *mailWidget) fetchIMAP(ctx context.Context) (*WidgetResult, error) {
+	server, err := url.Parse(w.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IMAP URL: %w", err)
+	}
+	if server.Scheme != "imap" && server.Scheme != "imaps" {
+		return nil, fmt.Errorf("unsupported scheme %q, use imap or imaps", server.Scheme)
+	}
+	username, password := w.config.Username, w.config.Password
+	if server.User != nil && username == "" {
+		username = server.User.Username()
+		password, _ = server.User.Password()
+	}
+	if username == "" {
+		return nil, fmt.Errorf("IMAP login requires a username and a password")
+	}
+	tlsConfig, err := clientTLSConfig(w.config.SkipVerify, w.config.CAFile, w.config.CADir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading CA certificates: %w", err)
+	}
+	tlsConfig.ServerName = server.Hostname()
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	address := server.Host
+	if server.Port() == "" {
+		address = net.JoinHostPort(server.Hostname(), map[string]string{"imap": "143", "imaps": "993"}[server.Scheme])
+	}
+	conn, err := dialCached(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() { conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if server.Scheme == "imaps" {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	client := &imapClient{conn: conn, reader: bufio.NewReader(conn)}
+	greeting, err := client.reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("no greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		return nil, fmt.Errorf("unexpected greeting: %s", strings.TrimSpace(greeting))
+	}
+
+	// Plain connections switch to TLS when the server offers it
+	if server.Scheme == "imap" {
+		capabilities, err := client.command("CAPABILITY")
+		if err != nil {
+			return nil, fmt.Errorf("CAPABILITY failed: %w", err)
+		}
+		if strings.Contains(strings.ToUpper(strings.Join(capabilities, " ")), "STARTTLS") {
+			if _, err := client.command("STARTTLS"); err != nil {
+				return nil, fmt.Errorf("STARTTLS failed: %w", err)
+			}
+			tlsConn := tls.Client(conn, tlsConfig)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				return nil, fmt.Errorf("TLS handshake failed: %w", err)
+			}
+			conn = tlsConn
+			client = &imapClient{conn: conn, reader: bufio.NewReader(conn), tag: client.tag}
+		}
+	}
+
+	if _, err := client.command("LOGIN %s %s", imapQuote(username), imapQuote(password)); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	status, err := client.command("STATUS %s (MESSAGES UNSEEN)", imapQuote(w.mailbox))
+	if err != nil {
+		return nil, fmt.Errorf("STATUS of %s failed: %w", w.mailbox, err)
+	}
+	client.command("LOGOUT")
+
+	counts := map[string]int{"MESSAGES": -1, "UNSEEN": -1}
+	for _, line := range status {
+		for _, match := range imapStatusPattern.FindAllStringSubmatch(line, -1) {
+			counts[strings.ToUpper(match[1])], _ = strconv.Atoi(match[2])
+		}
+	}
+	if counts["UNSEEN"] < 0 {
+		return nil, fmt.Errorf("no unread count in the STATUS response")
+	}
+
+	state := w.thresholdState(counts["UNSEEN"])
+	result := &WidgetResult{State: state, Message: fmt.Sprintf("%d unread in %s", counts["UNSEEN"], w.mailbox), LastUpdated: time.Now()}
+	result.Fields = append(result.Fields, WidgetField{Label: "Unread", Value: strconv.Itoa(counts["UNSEEN"]), State: state})
+	if counts["MESSAGES"] >= 0 {
+		result.Fields = append(result.Fields, WidgetField{Label: "Messages", Value: strconv.Itoa(counts["MESSAGES"])})
+	}
+	result.Fields = append(result.Fields, WidgetField{Label: "Mailbox", Value: w.mailbox})
+	return result, nil
+}
+
+// imapClient sends tagged IMAP commands over a connection
+type imapClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	tag    int
+}
+
+// command sends a command and returns the untagged lines of the response,
+// or the text of the server as error unless it completed with OK
+func (c *imapClient) command(format string, args ...any) ([]string, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+	var untagged []string
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		rest, ok := strings.CutPrefix(line, tag+" ")
+		if !ok {
+			untagged = append(untagged, line)
+			continue
+		}
+		if status, text, _ := strings.Cut(rest, " "); !strings.EqualFold(status, "OK") {
+			return nil, errors.New(text)
+		}
+		return untagged, nil
+	}
+}
+
+// imapQuote returns a string as IMAP quoted string
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
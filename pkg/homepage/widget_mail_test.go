@@ -0,0 +1,101 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePostqueue(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	output := fmt.Sprintf(`{"queue_name": "active", "queue_id": "4F2A1C0E12", "arrival_time": %d, "message_size": 1024, "sender": "root@nas", "recipients": [{"address": "admin@example.com"}]}
+{"queue_name": "deferred", "queue_id": "9B3D2E1F45", "arrival_time": %d, "message_size": 2048, "sender": "root@nas", "recipients": [{"address": "admin@example.com", "delay_reason": "connect to mx.example.com[203.0.113.5]:25: Connection timed out"}]}
+{"queue_name": "deferred", "queue_id": "1A2B3C4D5E", "arrival_time": %d, "message_size": 512, "sender": "root@nas", "recipients": [{"address": "ops@example.com", "delay_reason": "host mx.example.com said: 451 try again later"}]}
+`, now.Add(-time.Minute).Unix(), now.Add(-3*time.Hour).Unix(), now.Add(-time.Hour).Unix())
+
+	queue, err := parsePostqueue(output)
+	require.NoError(t, err)
+	result := queue.result(StatusOK, now)
+	assert.Equal(t, "3 messages queued, 2 deferred", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "Queued", Value: "3", State: StatusOK},
+		{Label: "Active", Value: "1"},
+		{Label: "Deferred", Value: "2", State: StatusWarning},
+		{Label: "Oldest", Value: "3h 00m"},
+		{Label: "Reason", Value: "connect to mx.example.com[203.0.113.5]:25: Connection timed out"},
+	}, result.Fields)
+
+	queue, err = parsePostqueue("")
+	require.NoError(t, err)
+	assert.Equal(t, "Mail queue empty", queue.result(StatusOK, now).Message)
+
+	_, err = parsePostqueue("postqueue: fatal: bad command")
+	assert.ErrorContains(t, err, "error decoding postqueue output")
+}
+
+// serveIMAP answers the commands of a single IMAP client without TLS
+func serveIMAP(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, command, _ := strings.Cut(strings.TrimSpace(line), " ")
+			switch {
+			case command == "CAPABILITY":
+				fmt.Fprintf(conn, "* CAPABILITY IMAP4rev1 AUTH=PLAIN\r\n%s OK done\r\n", tag)
+			case command == `LOGIN "alice" "pa\"ss"`:
+				fmt.Fprintf(conn, "%s OK logged in\r\n", tag)
+			case strings.HasPrefix(command, "LOGIN "):
+				fmt.Fprintf(conn, "%s NO [AUTHENTICATIONFAILED] Invalid credentials\r\n", tag)
+			case command == `STATUS "INBOX" (MESSAGES UNSEEN)`:
+				fmt.Fprintf(conn, "* STATUS INBOX (MESSAGES 42 UNSEEN 12)\r\n%s OK done\r\n", tag)
+			default:
+				fmt.Fprintf(conn, "%s BAD unknown command\r\n", tag)
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestMailWidgetIMAP(t *testing.T) {
+	widget, err := NewWidget(&WidgetConfig{Type: "mail", URL: "imap://" + serveIMAP(t), Username: "alice", Password: `pa"ss`,
+		Options: map[string]interface{}{"source": "imap", "warnAbove": 10}})
+	require.NoError(t, err)
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "12 unread in INBOX", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "Unread", Value: "12", State: StatusWarning},
+		{Label: "Messages", Value: "42"},
+		{Label: "Mailbox", Value: "INBOX"},
+	}, result.Fields)
+
+	widget, err = NewWidget(&WidgetConfig{Type: "mail", URL: "imap://alice:wrong@" + serveIMAP(t), Options: map[string]interface{}{"source": "imap"}})
+	require.NoError(t, err)
+	_, err = widget.Fetch(context.Background())
+	assert.EqualError(t, err, "login failed: [AUTHENTICATIONFAILED] Invalid credentials")
+
+	_, err = NewWidget(&WidgetConfig{Type: "mail", Options: map[string]interface{}{"source": "pop3"}})
+	assert.EqualError(t, err, `unknown mail source "pop3", use postfix or imap`)
+}
@@ -0,0 +1,231 @@
+package homepage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Glyphs of sparklines, from the lowest to the highest value
+var (
+	unicodeSparkline = []rune("▁▂▃▄▅▆▇█")
+	asciiSparkline   = []rune("_.-~=+*#")
+)
+
+// sparkline draws values as a line of bars scaled to their maximum
+func sparkline(values []float64) string {
+	levels := unicodeSparkline
+	if asciiOnly.Load() {
+		levels = asciiSparkline
+	}
+
+	var highest float64
+	for _, v := range values {
+		highest = max(highest, v)
+	}
+	line := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if highest > 0 {
+			level = int(v / highest * float64(len(levels)-1))
+		}
+		line[i] = levels[level]
+	}
+	return string(line)
+}
+
+// netCounters are the byte counters of a network interface
+type netCounters struct {
+	rx, tx uint64
+}
+
+// netHistory holds the recent rates of an interface in bytes per second
+type netHistory struct {
+	rx, tx []float64
+}
+
+// networkWidget shows the receive and transmit rates of network interfaces
+// with sparklines of their recent history, sampled from /proc/net/dev
+type networkWidget struct {
+	interfaces []string // Interfaces to show (default: all but loopback and veth)
+	history    int      // Number of samples in the sparklines (default: 10)
+	procPath   string
+	sysPath    string
+
+	last     map[string]netCounters
+	lastTime time.Time
+	rates    map[string]*netHistory
+}
+
+func newNetworkWidget(config *WidgetConfig) (Widget, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("network widget not supported on %s", runtime.GOOS)
+	}
+
+	var interfaces []string
+	for _, name := range strings.Split(config.String("interfaces", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			interfaces = append(interfaces, name)
+		}
+	}
+	return &networkWidget{
+		interfaces: interfaces,
+		history:    max(config.Int("history", 10), 2),
+		procPath:   "/proc/net/dev",
+		sysPath:    "/sys/class/net",
+		rates:      make(map[string]*netHistory),
+	}, nil
+}
+
+// defaultInterval refreshes rates every few seconds, as a minute long average
+// would hide most of the traffic
+func (w *networkWidget) defaultInterval() int {
+	return 5
+}
+
+// Fetch samples the interface counters and returns their rates since the
+// previous fetch
+func (w *networkWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	data, err := os.ReadFile(w.procPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading interface counters: %w", err)
+	}
+	return w.sample(parseProcNetDev(string(data)), time.Now()), nil
+}
+
+// sample records counters read at the given time and returns the widget result
+func (w *networkWidget) sample(counters map[string]netCounters, now time.Time) *WidgetResult {
+	names := w.interfaces
+	if len(names) == 0 {
+		for name := range counters {
+			if name != "lo" && !strings.HasPrefix(name, "veth") && w.operState(name) != "down" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	elapsed := now.Sub(w.lastTime).Seconds()
+	first := w.last == nil
+	result := &WidgetResult{State: StatusOK, LastUpdated: now}
+	var totalRx, totalTx float64
+	for _, name := range names {
+		current, ok := counters[name]
+		if !ok {
+			result.Fields = append(result.Fields, WidgetField{Label: name, Value: "not found", State: StatusCritical})
+			result.State = StatusCritical
+			continue
+		}
+		if w.operState(name) == "down" {
+			result.Fields = append(result.Fields, WidgetField{Label: name, Value: "down", State: StatusCritical})
+			result.State = StatusCritical
+			continue
+		}
+
+		previous, seen := w.last[name]
+		if first || !seen || elapsed <= 0 {
+			result.Fields = append(result.Fields, WidgetField{Label: name, Value: "measuring"})
+			continue
+		}
+
+		rx, tx := counterRate(previous.rx, current.rx, elapsed), counterRate(previous.tx, current.tx, elapsed)
+		totalRx, totalTx = totalRx+rx, totalTx+tx
+		history := w.rates[name]
+		if history == nil {
+			history = &netHistory{}
+			w.rates[name] = history
+		}
+		history.rx = appendSample(history.rx, rx, w.history)
+		history.tx = appendSample(history.tx, tx, w.history)
+
+		result.Fields = append(result.Fields, WidgetField{
+			Label: name,
+			Value: fmt.Sprintf("rx %s %s  tx %s %s",
+				formatRate(rx), sparkline(history.rx), formatRate(tx), sparkline(history.tx)),
+		})
+	}
+
+	w.last, w.lastTime = counters, now
+	if first {
+		result.Message = "Measuring"
+	} else {
+		result.Message = fmt.Sprintf("rx %s tx %s", formatRate(totalRx), formatRate(totalTx))
+	}
+	if result.State == StatusCritical {
+		result.Message = "Interface down"
+	}
+	return result
+}
+
+// operState returns the operational state of an interface, e.g. up or down
+func (w *networkWidget) operState(name string) string {
+	data, err := os.ReadFile(filepath.Join(w.sysPath, name, "operstate"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// counterRate returns the rate of a counter per second. A counter that went
+// backwards, e.g. because the interface was recreated, counts as no traffic.
+func counterRate(previous, current uint64, seconds float64) float64 {
+	if current < previous {
+		return 0
+	}
+	return float64(current-previous) / seconds
+}
+
+// appendSample appends a value to a history of at most size values
+func appendSample(history []float64, value float64, size int) []float64 {
+	history = append(history, value)
+	if len(history) > size {
+		history = history[len(history)-size:]
+	}
+	return history
+}
+
+// formatRate formats a rate in bytes per second with decimal units
+func formatRate(rate float64) string {
+	units := []string{"B/s", "kB/s", "MB/s", "GB/s"}
+	unit := 0
+	for rate >= 1000 && unit < len(units)-1 {
+		rate /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f %s", rate, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", rate, units[unit])
+}
+
+// parseProcNetDev parses the byte counters of /proc/net/dev, whose lines
+// after the two header lines look like
+// "  eth0: 1234 10 0 0 0 0 0 0 5678 20 0 0 0 0 0 0"
+func parseProcNetDev(data string) map[string]netCounters {
+	counters := make(map[string]netCounters)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		name, values, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(values)
+		if len(fields) < 9 {
+			continue
+		}
+		rx, errRx := strconv.ParseUint(fields[0], 10, 64)
+		tx, errTx := strconv.ParseUint(fields[8], 10, 64)
+		if errRx != nil || errTx != nil {
+			continue
+		}
+		counters[strings.TrimSpace(name)] = netCounters{rx: rx, tx: tx}
+	}
+	return counters
+}
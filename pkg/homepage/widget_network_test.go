@@ -0,0 +1,69 @@
+package homepage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const procNetDev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:  123456     100    0    0    0     0          0         0   123456     100    0    0    0     0       0          0
+  eth0: 1000000    2000    0    0    0     0          0         0   500000    1000    0    0    0     0       0          0
+vethab12:    42       1    0    0    0     0          0         0       42       1    0    0    0     0       0          0
+`
+
+func TestParseProcNetDev(t *testing.T) {
+	counters := parseProcNetDev(procNetDev)
+	assert.Len(t, counters, 3)
+	assert.Equal(t, netCounters{rx: 1000000, tx: 500000}, counters["eth0"])
+}
+
+func TestNetworkWidgetRates(t *testing.T) {
+	sysPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sysPath, "eth0"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sysPath, "eth0", "operstate"), []byte("up\n"), 0644))
+	w := &networkWidget{history: 3, sysPath: sysPath, rates: make(map[string]*netHistory)}
+
+	start := time.Now()
+	result := w.sample(parseProcNetDev(procNetDev), start)
+	assert.Equal(t, "Measuring", result.Message)
+	assert.Equal(t, []WidgetField{{Label: "eth0", Value: "measuring"}}, result.Fields)
+
+	result = w.sample(map[string]netCounters{"eth0": {rx: 3000000, tx: 500500}}, start.Add(2*time.Second))
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "rx 1.0 MB/s tx 250 B/s", result.Message)
+
+	w.sample(map[string]netCounters{"eth0": {rx: 3500000, tx: 501000}}, start.Add(4*time.Second))
+	result = w.sample(map[string]netCounters{"eth0": {rx: 3500000, tx: 501500}}, start.Add(6*time.Second))
+	assert.Equal(t, "rx 0 B/s █▂▁  tx 250 B/s ███", result.Fields[0].Value)
+
+	// Configured interfaces that are missing or down turn the widget critical
+	require.NoError(t, os.WriteFile(filepath.Join(sysPath, "eth0", "operstate"), []byte("down\n"), 0644))
+	w.interfaces = []string{"eth0", "wg0"}
+	result = w.sample(map[string]netCounters{"eth0": {}}, start.Add(8*time.Second))
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, []WidgetField{
+		{Label: "eth0", Value: "down", State: StatusCritical},
+		{Label: "wg0", Value: "not found", State: StatusCritical},
+	}, result.Fields)
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", sparkline([]float64{0, 50, 100}))
+	assert.Equal(t, "▁▁", sparkline([]float64{0, 0}))
+
+	SetASCIIOnly(true)
+	defer SetASCIIOnly(false)
+	assert.Equal(t, "_~#", sparkline([]float64{0, 50, 100}))
+}
+
+func TestFormatRate(t *testing.T) {
+	assert.Equal(t, "999 B/s", formatRate(999))
+	assert.Equal(t, "1.5 kB/s", formatRate(1500))
+	assert.Equal(t, "12.3 MB/s", formatRate(12300000))
+}
@@ -0,0 +1,270 @@
+package homepage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// printerWidget shows the state and the supply levels of a network printer,
+// read with an IPP Get-Printer-Attributes request, so low toner and paper
+// jams get noticed before someone needs to print
+type printerWidget struct {
+	config     *WidgetConfig
+	printerURI string // ipp:// or ipps:// URI of the printer
+	endpoint   string // HTTP URL the requests are posted to
+	warnBelow  int    // Supply level in percent turning warning
+}
+
+// IPP value tags of the attributes read
+const (
+	ippTagOperation = 0x01
+	ippTagEnd       = 0x03
+	ippTagInteger   = 0x21
+	ippTagEnum      = 0x23
+	ippTagKeyword   = 0x44
+	ippTagURI       = 0x45
+	ippTagCharset   = 0x47
+	ippTagLanguage  = 0x48
+)
+
+// printerStates names the values of the printer-state enum
+var printerStates = map[int]string{3: "idle", 4: "printing", 5: "stopped"}
+
+func newPrinterWidget(config *WidgetConfig) (Widget, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("printer widget requires the url of the printer, such as ipp://printer.lan/ipp/print")
+	}
+	printer, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid printer URL: %w", err)
+	}
+
+	// IPP runs over HTTP, on port 631 unless another one is given
+	endpoint := *printer
+	switch printer.Scheme {
+	case "ipp", "http":
+		endpoint.Scheme, printer.Scheme = "http", "ipp"
+	case "ipps", "https":
+		endpoint.Scheme, printer.Scheme = "https", "ipps"
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q, use ipp or ipps", printer.Scheme)
+	}
+	if printer.Port() == "" {
+		endpoint.Host = printer.Hostname() + ":631"
+	}
+	if printer.Path == "" {
+		printer.Path, endpoint.Path = "/ipp/print", "/ipp/print"
+	}
+
+	return &printerWidget{
+		config:     config,
+		printerURI: printer.String(),
+		endpoint:   endpoint.String(),
+		warnBelow:  config.Int("warnBelow", 15),
+	}, nil
+}
+
+// defaultInterval checks every 5 minutes, as supplies run low over days
+func (w *printerWidget) defaultInterval() int {
+	return 300
+}
+
+// Fetch requests the attributes of the printer
+func (w *printerWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	request := ippRequest(w.printerURI, "printer-state", "printer-state-reasons", "printer-state-message",
+		"printer-make-and-model", "marker-names", "marker-levels")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, bytes.NewReader(request))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/ipp")
+
+	resp, err := w.config.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("printer returned HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	status, attributes, err := parseIPPResponse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding IPP response: %w", err)
+	}
+	// Status codes from 0x0000 to 0x00FF are successful
+	if status > 0xFF {
+		return nil, fmt.Errorf("printer answered with IPP status 0x%04x", status)
+	}
+	return printerResult(attributes, w.warnBelow), nil
+}
+
+// printerResult returns the widget result of the attributes of a printer
+func printerResult(attributes map[string][]ippValue, warnBelow int) *WidgetResult {
+	result := &WidgetResult{State: StatusOK, LastUpdated: time.Now()}
+	state := "unknown"
+	if values := attributes["printer-state"]; len(values) > 0 && printerStates[values[0].integer] != "" {
+		state = printerStates[values[0].integer]
+	}
+	stateField := WidgetField{Label: "State", Value: state}
+	if state == "stopped" {
+		stateField.State = StatusWarning
+		result.State = StatusWarning
+	}
+	result.Fields = append(result.Fields, stateField)
+
+	var alerts []string
+	for _, value := range attributes["printer-state-reasons"] {
+		reason, alertState := printerReason(value.text)
+		if reason == "" {
+			continue
+		}
+		alerts = append(alerts, reason)
+		result.State = worstState(result.State, alertState)
+		result.Fields = append(result.Fields, WidgetField{Label: "Alert", Value: reason, State: alertState})
+	}
+
+	names, levels := attributes["marker-names"], attributes["marker-levels"]
+	for i, level := range levels {
+		name := fmt.Sprintf("Supply %d", i+1)
+		if i < len(names) {
+			name = names[i].text
+		}
+		// Negative levels are unknown, or -3 for some remaining
+		if level.integer < 0 {
+			result.Fields = append(result.Fields, WidgetField{Label: name, Value: "unknown"})
+			continue
+		}
+		field := WidgetField{Label: name, Value: fmt.Sprintf("%d%%", level.integer), State: StatusOK}
+		switch {
+		case level.integer == 0:
+			field.State = StatusCritical
+		case level.integer < warnBelow:
+			field.State = StatusWarning
+		}
+		result.State = worstState(result.State, field.State)
+		result.Fields = append(result.Fields, field)
+	}
+	if values := attributes["printer-make-and-model"]; len(values) > 0 {
+		result.Fields = append(result.Fields, WidgetField{Label: "Model", Value: values[0].text})
+	}
+
+	result.Message = strings.ToUpper(state[:1]) + state[1:]
+	if len(alerts) > 0 {
+		result.Message += ", " + strings.Join(alerts, ", ")
+	} else if values := attributes["printer-state-message"]; len(values) > 0 && values[0].text != "" {
+		result.Message += ", " + values[0].text
+	}
+	return result
+}
+
+// printerReason returns a printer-state-reasons keyword without its
+// severity suffix, and the state of the severity. Reports are informational
+// and reasons without suffix are errors, as RFC 8011 specifies.
+func printerReason(keyword string) (string, StatusState) {
+	switch {
+	case keyword == "none" || keyword == "":
+		return "", StatusOK
+	case strings.HasSuffix(keyword, "-report"):
+		return "", StatusOK
+	case strings.HasSuffix(keyword, "-warning"):
+		return strings.ReplaceAll(strings.TrimSuffix(keyword, "-warning"), "-", " "), StatusWarning
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(keyword, "-error"), "-", " "), StatusCritical
+}
+
+// ippRequest encodes a Get-Printer-Attributes request of IPP 2.0 for the
+// given attributes
+func ippRequest(printerURI string, attributes ...string) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{2, 0})                              // Version 2.0
+	binary.Write(&buf, binary.BigEndian, uint16(0x000B)) // Get-Printer-Attributes
+	binary.Write(&buf, binary.BigEndian, uint32(1))      // Request ID
+	buf.WriteByte(ippTagOperation)
+	writeIPPAttribute(&buf, ippTagCharset, "attributes-charset", "utf-8")
+	writeIPPAttribute(&buf, ippTagLanguage, "attributes-natural-language", "en")
+	writeIPPAttribute(&buf, ippTagURI, "printer-uri", printerURI)
+	for i, attribute := range attributes {
+		name := "requested-attributes"
+		if i > 0 {
+			name = "" // Additional value of the same attribute
+		}
+		writeIPPAttribute(&buf, ippTagKeyword, name, attribute)
+	}
+	buf.WriteByte(ippTagEnd)
+	return buf.Bytes()
+}
+
+func writeIPPAttribute(buf *bytes.Buffer, tag byte, name, value string) {
+	buf.WriteByte(tag)
+	binary.Write(buf, binary.BigEndian, uint16(len(name)))
+	buf.WriteString(name)
+	binary.Write(buf, binary.BigEndian, uint16(len(value)))
+	buf.WriteString(value)
+}
+
+// ippValue is a value of an IPP attribute, with integers and enums decoded
+type ippValue struct {
+	text    string
+	integer int
+}
+
+// parseIPPResponse decodes an IPP response into its status code and the
+// values of its attributes by name. Members of collections are appended to
+// the collection attribute, which isn't read.
+func parseIPPResponse(data []byte) (int, map[string][]ippValue, error) {
+	if len(data) < 8 {
+		return 0, nil, errors.New("response too short")
+	}
+	status := int(binary.BigEndian.Uint16(data[2:4]))
+	attributes := make(map[string][]ippValue)
+	data = data[8:]
+	var name string
+	for len(data) > 0 {
+		tag := data[0]
+		data = data[1:]
+		if tag == ippTagEnd {
+			return status, attributes, nil
+		}
+		if tag < 0x10 { // Delimiter of an attribute group
+			continue
+		}
+		if len(data) < 2 {
+			return 0, nil, errors.New("truncated attribute")
+		}
+		nameLength := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+nameLength+2 {
+			return 0, nil, errors.New("truncated attribute")
+		}
+		if nameLength > 0 {
+			name = string(data[2 : 2+nameLength])
+		}
+		data = data[2+nameLength:]
+		valueLength := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+valueLength {
+			return 0, nil, errors.New("truncated attribute value")
+		}
+		raw := data[2 : 2+valueLength]
+		data = data[2+valueLength:]
+
+		value := ippValue{text: string(raw)}
+		if (tag == ippTagInteger || tag == ippTagEnum) && len(raw) == 4 {
+			value.integer = int(int32(binary.BigEndian.Uint32(raw)))
+			value.text = strconv.Itoa(value.integer)
+		}
+		attributes[name] = append(attributes[name], value)
+	}
+	return 0, nil, errors.New("missing end of attributes")
+}
@@ -0,0 +1,83 @@
+package homepage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeIPPInteger writes an integer or enum attribute of an IPP message
+func writeIPPInteger(buf *bytes.Buffer, tag byte, name string, value int32) {
+	buf.WriteByte(tag)
+	binary.Write(buf, binary.BigEndian, uint16(len(name)))
+	buf.WriteString(name)
+	binary.Write(buf, binary.BigEndian, uint16(4))
+	binary.Write(buf, binary.BigEndian, value)
+}
+
+func TestPrinterWidget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "application/ipp", r.Header.Get("Content-Type"))
+		assert.Equal(t, []byte{2, 0, 0, 0x0B}, request[:4])
+		assert.Contains(t, string(request), "ipp://"+r.Host+"/ipp/print")
+
+		var buf bytes.Buffer
+		buf.Write([]byte{2, 0, 0, 0, 0, 0, 0, 1})
+		buf.WriteByte(ippTagOperation)
+		writeIPPAttribute(&buf, ippTagCharset, "attributes-charset", "utf-8")
+		buf.WriteByte(0x04) // Printer attributes
+		writeIPPInteger(&buf, ippTagEnum, "printer-state", 5)
+		writeIPPAttribute(&buf, ippTagKeyword, "printer-state-reasons", "media-jam-error")
+		writeIPPAttribute(&buf, ippTagKeyword, "", "toner-low-warning")
+		writeIPPAttribute(&buf, ippTagKeyword, "", "sleep-report")
+		writeIPPAttribute(&buf, 0x41, "marker-names", "Black Toner")
+		writeIPPAttribute(&buf, 0x41, "", "Drum")
+		writeIPPAttribute(&buf, 0x41, "", "Waste Toner")
+		writeIPPInteger(&buf, ippTagInteger, "marker-levels", 8)
+		writeIPPInteger(&buf, ippTagInteger, "", 70)
+		writeIPPInteger(&buf, ippTagInteger, "", -3)
+		writeIPPAttribute(&buf, 0x41, "printer-make-and-model", "Brother HL-L2350DW")
+		buf.WriteByte(ippTagEnd)
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	widget, err := NewWidget(&WidgetConfig{Type: "printer", URL: strings.Replace(server.URL, "http://", "ipp://", 1)})
+	require.NoError(t, err)
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "Stopped, media jam, toner low", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "State", Value: "stopped", State: StatusWarning},
+		{Label: "Alert", Value: "media jam", State: StatusCritical},
+		{Label: "Alert", Value: "toner low", State: StatusWarning},
+		{Label: "Black Toner", Value: "8%", State: StatusWarning},
+		{Label: "Drum", Value: "70%", State: StatusOK},
+		{Label: "Waste Toner", Value: "unknown"},
+		{Label: "Model", Value: "Brother HL-L2350DW"},
+	}, result.Fields)
+}
+
+func TestNewPrinterWidgetURL(t *testing.T) {
+	widget, err := newPrinterWidget(&WidgetConfig{URL: "ipps://printer.lan"})
+	require.NoError(t, err)
+	assert.Equal(t, "ipps://printer.lan/ipp/print", widget.(*printerWidget).printerURI)
+	assert.Equal(t, "https://printer.lan:631/ipp/print", widget.(*printerWidget).endpoint)
+
+	widget, err = newPrinterWidget(&WidgetConfig{URL: "ipp://cups.lan:8631/printers/office"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://cups.lan:8631/printers/office", widget.(*printerWidget).endpoint)
+
+	_, err = newPrinterWidget(&WidgetConfig{URL: "lpd://printer.lan"})
+	assert.EqualError(t, err, `unsupported scheme "lpd", use ipp or ipps`)
+}
@@ -0,0 +1,255 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hwmonChipKinds names the kind of component measured by common hwmon chips
+var hwmonChipKinds = map[string]string{
+	"coretemp":    "CPU",
+	"k10temp":     "CPU",
+	"zenpower":    "CPU",
+	"cpu_thermal": "CPU",
+	"amdgpu":      "GPU",
+	"radeon":      "GPU",
+	"nouveau":     "GPU",
+	"nvme":        "NVMe",
+	"drivetemp":   "Disk",
+	"acpitz":      "ACPI",
+}
+
+// tempReading is a temperature input of a hwmon chip, in degrees Celsius
+type tempReading struct {
+	label    string
+	temp     float64
+	max      float64 // Limit reported by the hardware, 0 if unknown
+	critical float64 // Critical limit reported by the hardware, 0 if unknown
+}
+
+// hwmonChip is a hwmon device with its temperature inputs
+type hwmonChip struct {
+	name     string
+	readings []tempReading
+}
+
+// temperatureWidget shows the temperature of each hwmon chip, e.g. CPU, GPU
+// and NVMe drives, turning warning and critical above thresholds
+type temperatureWidget struct {
+	sensors       []string // Chips or chip/label inputs to show (default: all)
+	fahrenheit    bool     // Show degrees Fahrenheit instead of Celsius
+	warnAbove     float64  // Warning threshold, 0 for the hardware limit or 80°C
+	criticalAbove float64  // Critical threshold, 0 for the hardware limit or 90°C
+	hwmonPath     string
+}
+
+func newTemperatureWidget(config *WidgetConfig) (Widget, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("temperature widget not supported on %s", runtime.GOOS)
+	}
+
+	var unit string
+	switch unit = strings.ToLower(config.String("unit", "celsius")); unit {
+	case "celsius", "c", "fahrenheit", "f":
+	default:
+		return nil, fmt.Errorf("unknown temperature unit %q", unit)
+	}
+
+	var sensors []string
+	for _, name := range strings.Split(config.String("sensors", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			sensors = append(sensors, strings.ToLower(name))
+		}
+	}
+	return &temperatureWidget{
+		sensors:       sensors,
+		fahrenheit:    strings.HasPrefix(unit, "f"),
+		warnAbove:     float64(config.Int("warnAbove", 0)),
+		criticalAbove: float64(config.Int("criticalAbove", 0)),
+		hwmonPath:     "/sys/class/hwmon",
+	}, nil
+}
+
+// Fetch reads the hwmon chips and returns the hottest input of each
+func (w *temperatureWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	chips, err := readHwmon(w.hwmonPath)
+	if err != nil {
+		return nil, err
+	}
+	return w.result(chips)
+}
+
+// result converts the readings of hwmon chips to widget fields
+func (w *temperatureWidget) result(chips []hwmonChip) (*WidgetResult, error) {
+	result := &WidgetResult{LastUpdated: time.Now()}
+	var states []StatusState
+	var hottest float64
+	kindCounts := make(map[string]int)
+	for _, chip := range chips {
+		reading, ok := w.hottest(chip)
+		if !ok {
+			continue
+		}
+
+		kind, ok := hwmonChipKinds[chip.name]
+		if !ok {
+			kind = chip.name
+		}
+		kindCounts[kind]++
+		label := kind
+		if n := kindCounts[kind]; n > 1 {
+			label = fmt.Sprintf("%s %d", kind, n)
+		}
+
+		state := w.state(reading)
+		states = append(states, state)
+		temp := w.format(reading.temp)
+		result.Fields = append(result.Fields, WidgetField{Label: label, Value: temp, State: state})
+		if len(result.Fields) == 1 || reading.temp > hottest {
+			hottest = reading.temp
+			result.Message = fmt.Sprintf("%s %s", label, temp)
+		}
+	}
+	if len(result.Fields) == 0 {
+		return nil, fmt.Errorf("no temperature sensor found")
+	}
+	result.State = worstState(states...)
+	return result, nil
+}
+
+// hottest returns the hottest input of a chip selected by the sensors option
+func (w *temperatureWidget) hottest(chip hwmonChip) (tempReading, bool) {
+	var hottest tempReading
+	found := false
+	for _, reading := range chip.readings {
+		if !w.selected(chip.name, reading.label) {
+			continue
+		}
+		if !found || reading.temp > hottest.temp {
+			hottest, found = reading, true
+		}
+	}
+	return hottest, found
+}
+
+// selected reports whether an input is selected by the sensors option, which
+// lists chip names (e.g. nvme) or inputs (e.g. coretemp/Package id 0)
+func (w *temperatureWidget) selected(chip, label string) bool {
+	if len(w.sensors) == 0 {
+		return true
+	}
+	for _, sensor := range w.sensors {
+		if sensor == chip || sensor == chip+"/"+strings.ToLower(label) {
+			return true
+		}
+	}
+	return false
+}
+
+// state returns the state of a reading. Without configured thresholds, the
+// limits reported by the hardware are used when they are lower than 80°C and
+// 90°C.
+func (w *temperatureWidget) state(reading tempReading) StatusState {
+	warn, critical := w.warnAbove, w.criticalAbove
+	if w.fahrenheit {
+		// Configured thresholds are in the displayed unit
+		warn, critical = fahrenheitToCelsius(warn), fahrenheitToCelsius(critical)
+	}
+	if w.warnAbove == 0 {
+		warn = hardwareLimit(reading.max, 80)
+	}
+	if w.criticalAbove == 0 {
+		critical = hardwareLimit(reading.critical, 90)
+	}
+
+	switch {
+	case reading.temp >= critical:
+		return StatusCritical
+	case reading.temp >= warn:
+		return StatusWarning
+	}
+	return StatusOK
+}
+
+// hardwareLimit returns the limit reported by the hardware if it is below the
+// default
+func hardwareLimit(limit, def float64) float64 {
+	if limit > 0 && limit < def {
+		return limit
+	}
+	return def
+}
+
+// format formats a temperature in the configured unit
+func (w *temperatureWidget) format(celsius float64) string {
+	degree := "°"
+	if asciiOnly.Load() {
+		degree = " "
+	}
+	if w.fahrenheit {
+		return fmt.Sprintf("%.0f%sF", celsius*9/5+32, degree)
+	}
+	return fmt.Sprintf("%.0f%sC", celsius, degree)
+}
+
+// fahrenheitToCelsius converts a temperature, keeping 0 for unset thresholds
+func fahrenheitToCelsius(fahrenheit float64) float64 {
+	if fahrenheit == 0 {
+		return 0
+	}
+	return (fahrenheit - 32) * 5 / 9
+}
+
+// readHwmon reads the temperature inputs of the hwmon chips in dir. Inputs are
+// in millidegrees Celsius, in files named temp<n>_input with an optional
+// temp<n>_label, temp<n>_max and temp<n>_crit.
+func readHwmon(dir string) ([]hwmonChip, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading hwmon devices: %w", err)
+	}
+
+	read := func(path string) string {
+		data, _ := os.ReadFile(path)
+		return strings.TrimSpace(string(data))
+	}
+	readMillidegrees := func(path string) (float64, bool) {
+		n, err := strconv.ParseFloat(read(path), 64)
+		return n / 1000, err == nil
+	}
+
+	var chips []hwmonChip
+	for _, entry := range entries {
+		device := filepath.Join(dir, entry.Name())
+		chip := hwmonChip{name: read(filepath.Join(device, "name"))}
+
+		inputs, _ := filepath.Glob(filepath.Join(device, "temp*_input"))
+		sort.Strings(inputs)
+		for _, input := range inputs {
+			temp, ok := readMillidegrees(input)
+			if !ok {
+				// Sensors of sleeping devices fail to read
+				continue
+			}
+			prefix := strings.TrimSuffix(input, "_input")
+			reading := tempReading{label: read(prefix + "_label"), temp: temp}
+			if reading.label == "" {
+				reading.label = filepath.Base(prefix)
+			}
+			reading.max, _ = readMillidegrees(prefix + "_max")
+			reading.critical, _ = readMillidegrees(prefix + "_crit")
+			chip.readings = append(chip.readings, reading)
+		}
+		if len(chip.readings) > 0 {
+			chips = append(chips, chip)
+		}
+	}
+	return chips, nil
+}
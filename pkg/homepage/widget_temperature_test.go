@@ -0,0 +1,55 @@
+package homepage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadHwmon(t *testing.T) {
+	dir := t.TempDir()
+	writeChip := func(device string, files map[string]string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, device), 0755))
+		for file, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, device, file), []byte(content+"\n"), 0644))
+		}
+	}
+	writeChip("hwmon0", map[string]string{"name": "coretemp",
+		"temp1_input": "54000", "temp1_label": "Package id 0", "temp1_max": "100000", "temp1_crit": "100000",
+		"temp2_input": "61000", "temp2_label": "Core 0",
+	})
+	writeChip("hwmon1", map[string]string{"name": "nvme", "temp1_input": "72850", "temp1_max": "70850"})
+	writeChip("hwmon2", map[string]string{"name": "nvme", "temp1_input": "41000"})
+	writeChip("hwmon3", map[string]string{"name": "BAT0"})
+
+	chips, err := readHwmon(dir)
+	require.NoError(t, err)
+	require.Len(t, chips, 3)
+	assert.Equal(t, hwmonChip{name: "coretemp", readings: []tempReading{
+		{label: "Package id 0", temp: 54, max: 100, critical: 100},
+		{label: "Core 0", temp: 61},
+	}}, chips[0])
+
+	w := &temperatureWidget{}
+	result, err := w.result(chips)
+	require.NoError(t, err)
+	assert.Equal(t, []WidgetField{
+		{Label: "CPU", Value: "61°C", State: StatusOK},
+		{Label: "NVMe", Value: "73°C", State: StatusWarning},
+		{Label: "NVMe 2", Value: "41°C", State: StatusOK},
+	}, result.Fields)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "NVMe 73°C", result.Message)
+
+	w = &temperatureWidget{sensors: []string{"coretemp/package id 0"}, fahrenheit: true, warnAbove: 120, criticalAbove: 125}
+	result, err = w.result(chips)
+	require.NoError(t, err)
+	assert.Equal(t, []WidgetField{{Label: "CPU", Value: "129°F", State: StatusCritical}}, result.Fields)
+
+	w = &temperatureWidget{sensors: []string{"amdgpu"}}
+	_, err = w.result(chips)
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+package homepage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// updatesScript detects the package manager and prints "key value" lines with
+// the number of pending updates and whether a reboot is required. It only
+// reads the package caches, so counts are as fresh as the last apt update or
+// dnf makecache, e.g. by unattended-upgrades or dnf-automatic.
+const updatesScript = `
+if command -v apt-get >/dev/null 2>&1; then
+	echo "manager apt"
+	apt-get -s -o Debug::NoLocking=true upgrade 2>/dev/null |
+		awk '/^Inst / {n++; if (/[Ss]ecurity/) s++} END {print "updates", n+0; print "security", s+0}'
+elif command -v dnf >/dev/null 2>&1; then
+	echo "manager dnf"
+	dnf -q -C check-update 2>/dev/null | awk '/^Obsoleting/ {exit} NF == 3 && $1 ~ /\./ {n++} END {print "updates", n+0}'
+	dnf -q -C updateinfo list --security 2>/dev/null | awk 'NF >= 3 {n++} END {print "security", n+0}'
+	needs-restarting -r >/dev/null 2>&1
+	[ $? -eq 1 ] && echo "reboot yes"
+elif command -v pacman >/dev/null 2>&1; then
+	echo "manager pacman"
+	if command -v checkupdates >/dev/null 2>&1; then checkupdates 2>/dev/null; else pacman -Qu 2>/dev/null; fi |
+		awk 'NF {n++} END {print "updates", n+0}'
+else
+	echo "manager none"
+fi
+[ -f /var/run/reboot-required ] && echo "reboot yes"
+if command -v needrestart >/dev/null 2>&1; then
+	needrestart -b 2>/dev/null | awk '/^NEEDRESTART-KSTA: [23]/ {print "reboot yes"} /^NEEDRESTART-SVC:/ {n++} END {print "services", n+0}'
+fi
+exit 0
+`
+
+// updatesWidget shows the pending package updates of the local host, or of a
+// remote host over SSH. Security updates and required reboots turn it warning.
+type updatesWidget struct {
+	host    string        // SSH destination, e.g. admin@nas (default: the local host)
+	timeout time.Duration // Time to connect to the host (default: 10s)
+}
+
+// pendingUpdates is the output of updatesScript
+type pendingUpdates struct {
+	manager  string
+	updates  int
+	security int // -1 if unknown
+	services int // Services to restart reported by needrestart, -1 if unknown
+	reboot   bool
+}
+
+func newUpdatesWidget(config *WidgetConfig) (Widget, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	return &updatesWidget{
+		host:    config.String("host", ""),
+		timeout: time.Duration(timeout) * time.Second,
+	}, nil
+}
+
+// defaultInterval checks hourly, as updates are published a few times a day
+func (w *updatesWidget) defaultInterval() int {
+	return 3600
+}
+
+// Fetch runs the updates script and returns the pending updates
+func (w *updatesWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	output, err := runShell(ctx, w.host, updatesScript, w.timeout)
+	if err != nil {
+		return nil, err
+	}
+	updates := parsePendingUpdates(output)
+	if updates.manager == "" || updates.manager == "none" {
+		return nil, fmt.Errorf("no supported package manager found")
+	}
+	return updates.result(), nil
+}
+
+// runShell runs a shell script on the local host, or on a remote host with
+// the ssh client, which uses the keys, agent and ~/.ssh/config of the user.
+// The timeout bounds connecting to the remote host.
+func runShell(ctx context.Context, host, script string, timeout time.Duration) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-s")
+	if host != "" {
+		cmd = exec.CommandContext(ctx, "ssh",
+			"-o", "BatchMode=yes",
+			"-o", fmt.Sprintf("ConnectTimeout=%d", int(timeout.Seconds())),
+			host, "sh", "-s")
+	}
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			line, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n")
+			return "", fmt.Errorf("%s: %s", cmd.Args[0], line)
+		}
+		return "", fmt.Errorf("%s failed: %w", cmd.Args[0], err)
+	}
+	return string(output), nil
+}
+
+// parsePendingUpdates parses the "key value" lines printed by updatesScript
+func parsePendingUpdates(output string) *pendingUpdates {
+	updates := &pendingUpdates{security: -1, services: -1}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		n, _ := strconv.Atoi(value)
+		switch key {
+		case "manager":
+			updates.manager = value
+		case "updates":
+			updates.updates = n
+		case "security":
+			updates.security = n
+		case "services":
+			updates.services = n
+		case "reboot":
+			updates.reboot = value == "yes"
+		}
+	}
+	return updates
+}
+
+// result converts pending updates to widget fields
+func (u *pendingUpdates) result() *WidgetResult {
+	result := &WidgetResult{State: StatusOK, LastUpdated: time.Now()}
+	result.Fields = append(result.Fields, WidgetField{Label: "Updates", Value: strconv.Itoa(u.updates)})
+	message := fmt.Sprintf("%d updates", u.updates)
+	if u.updates == 0 {
+		message = "Up to date"
+	}
+
+	if u.security >= 0 {
+		field := WidgetField{Label: "Security", Value: strconv.Itoa(u.security), State: StatusOK}
+		if u.security > 0 {
+			field.State = StatusWarning
+			result.State = StatusWarning
+			message += fmt.Sprintf(" (%d security)", u.security)
+		}
+		result.Fields = append(result.Fields, field)
+	}
+
+	reboot := WidgetField{Label: "Reboot", Value: "not required", State: StatusOK}
+	if u.reboot {
+		reboot.Value, reboot.State = "required", StatusWarning
+		result.State = StatusWarning
+		message += ", reboot required"
+	}
+	result.Fields = append(result.Fields, reboot)
+
+	if u.services > 0 {
+		result.Fields = append(result.Fields, WidgetField{Label: "Restart", Value: fmt.Sprintf("%d services", u.services)})
+	}
+	result.Fields = append(result.Fields, WidgetField{Label: "Manager", Value: u.manager})
+	result.Message = message
+	return result
+}
@@ -0,0 +1,44 @@
+package homepage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingUpdates(t *testing.T) {
+	updates := parsePendingUpdates("manager apt\nupdates 12\nsecurity 3\nreboot yes\nservices 2\n")
+	assert.Equal(t, &pendingUpdates{manager: "apt", updates: 12, security: 3, services: 2, reboot: true}, updates)
+
+	result := updates.result()
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "12 updates (3 security), reboot required", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "Updates", Value: "12"},
+		{Label: "Security", Value: "3", State: StatusWarning},
+		{Label: "Reboot", Value: "required", State: StatusWarning},
+		{Label: "Restart", Value: "2 services"},
+		{Label: "Manager", Value: "apt"},
+	}, result.Fields)
+
+	result = parsePendingUpdates("manager pacman\nupdates 0\n").result()
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Up to date", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "Updates", Value: "0"},
+		{Label: "Reboot", Value: "not required", State: StatusOK},
+		{Label: "Manager", Value: "pacman"},
+	}, result.Fields)
+}
+
+func TestRunShell(t *testing.T) {
+	output, err := runShell(context.Background(), "", "echo manager apt\n", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "manager apt\n", output)
+
+	_, err = runShell(context.Background(), "", "echo broken >&2\nexit 3\n", time.Second)
+	assert.EqualError(t, err, "sh: broken")
+}
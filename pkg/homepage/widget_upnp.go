@@ -0,0 +1,383 @@
+package homepage
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// upnpMaxMappings bounds the port mappings read from a router, as some
+// routers never report the end of the table
+const upnpMaxMappings = 256
+
+// ssdpAddr is the multicast address SSDP searches are sent to
+var ssdpAddr = &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: 1900}
+
+// upnpWANServices are the service types of the WAN connection of an Internet
+// gateway device
+var upnpWANServices = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:",
+	"urn:schemas-upnp-org:service:WANPPPConnection:",
+}
+
+// upnpWidget shows the WAN status and the port mappings of a router through
+// UPnP IGD. Port mappings opened by devices of the network without an entry in
+// allowed are flagged, as UPnP lets any device expose itself to the Internet.
+type upnpWidget struct {
+	config   *WidgetConfig
+	location string          // URL of the device description, discovered with SSDP when empty
+	allowed  map[string]bool // Expected mappings as "port/protocol" or "port"
+}
+
+// upnpMapping is a port mapping of the router
+type upnpMapping struct {
+	ExternalPort   int
+	Protocol       string // TCP or UDP
+	InternalClient string
+	InternalPort   int
+	Description    string
+	Enabled        bool
+}
+
+// upnpError is a UPnP fault returned by an action
+type upnpError struct {
+	Code        int
+	Description string
+}
+
+func (e *upnpError) Error() string {
+	return fmt.Sprintf("UPnP error %d: %s", e.Code, e.Description)
+}
+
+func newUPnPWidget(config *WidgetConfig) (Widget, error) {
+	allowed := make(map[string]bool)
+	for _, mapping := range strings.Split(config.String("allowed", ""), ",") {
+		if mapping = strings.ToUpper(strings.TrimSpace(mapping)); mapping != "" {
+			allowed[mapping] = true
+		}
+	}
+	return &upnpWidget{config: config, location: config.URL, allowed: allowed}, nil
+}
+
+// Fetch reads the WAN status and the port mappings of the router
+func (w *upnpWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	location := w.location
+	if location == "" {
+		conn, err := net.ListenPacket("udp4", ":0")
+		if err != nil {
+			return nil, fmt.Errorf("error opening SSDP socket: %w", err)
+		}
+		defer conn.Close()
+		if location, err = searchIGD(ctx, conn, ssdpAddr, w.timeout()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep the discovered router until it stops answering
+	client, err := w.wanConnection(ctx, location)
+	if err != nil {
+		w.location = w.config.URL
+		return nil, err
+	}
+	w.location = location
+
+	status, err := client.call(ctx, "GetStatusInfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	external, err := client.call(ctx, "GetExternalIPAddress", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []upnpMapping
+	for index := 0; index < upnpMaxMappings; index++ {
+		entry, err := client.call(ctx, "GetGenericPortMappingEntry", [][2]string{{"NewPortMappingIndex", strconv.Itoa(index)}})
+		var fault *upnpError
+		if errors.As(err, &fault) {
+			// Routers answer SpecifiedArrayIndexInvalid (713) past the last entry
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		externalPort, _ := strconv.Atoi(entry["NewExternalPort"])
+		internalPort, _ := strconv.Atoi(entry["NewInternalPort"])
+		mappings = append(mappings, upnpMapping{
+			ExternalPort:   externalPort,
+			Protocol:       strings.ToUpper(entry["NewProtocol"]),
+			InternalClient: entry["NewInternalClient"],
+			InternalPort:   internalPort,
+			Description:    entry["NewPortMappingDescription"],
+			Enabled:        entry["NewEnabled"] != "0",
+		})
+	}
+
+	uptime, _ := strconv.Atoi(status["NewUptime"])
+	return w.result(status["NewConnectionStatus"], external["NewExternalIPAddress"], uptime, mappings), nil
+}
+
+// timeout returns the timeout of the discovery and of each request
+func (w *upnpWidget) timeout() time.Duration {
+	if w.config.Timeout > 0 {
+		return time.Duration(w.config.Timeout) * time.Second
+	}
+	return 3 * time.Second
+}
+
+// result converts the state of the router to widget fields
+func (w *upnpWidget) result(connection, externalIP string, uptime int, mappings []upnpMapping) *WidgetResult {
+	result := &WidgetResult{LastUpdated: time.Now()}
+
+	wanState := StatusOK
+	wan := cmp.Or(connection, "Unknown")
+	if connection != "Connected" {
+		wanState = StatusCritical
+	}
+	if externalIP != "" {
+		wan += ", " + externalIP
+	}
+	result.Fields = append(result.Fields, WidgetField{Label: "WAN", Value: wan, State: wanState})
+	if uptime > 0 {
+		result.Fields = append(result.Fields, WidgetField{Label: "Uptime", Value: formatUptime(time.Duration(uptime) * time.Second)})
+	}
+
+	unexpected := 0
+	for _, mapping := range mappings {
+		port := fmt.Sprintf("%d/%s", mapping.ExternalPort, mapping.Protocol)
+		field := WidgetField{
+			Label: port,
+			Value: net.JoinHostPort(mapping.InternalClient, strconv.Itoa(mapping.InternalPort)),
+			State: StatusOK,
+		}
+		if mapping.Description != "" {
+			field.Value += " " + mapping.Description
+		}
+		if !mapping.Enabled {
+			field.Value += " (disabled)"
+		}
+		if len(w.allowed) > 0 && !w.allowed[port] && !w.allowed[strconv.Itoa(mapping.ExternalPort)] {
+			field.State = StatusWarning
+			unexpected++
+		}
+		result.Fields = append(result.Fields, field)
+	}
+	if len(mappings) == 0 {
+		result.Fields = append(result.Fields, WidgetField{Label: "Port mappings", Value: "None"})
+	}
+
+	switch {
+	case wanState != StatusOK:
+		result.State = wanState
+		result.Message = "WAN " + cmp.Or(connection, "status unknown")
+	case unexpected > 0:
+		result.State = StatusWarning
+		result.Message = fmt.Sprintf("%d unexpected port mappings", unexpected)
+	default:
+		result.State = StatusOK
+		result.Message = fmt.Sprintf("Connected, %d port mappings", len(mappings))
+	}
+	return result
+}
+
+// formatUptime formats an uptime in its two largest units
+func formatUptime(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+// searchIGD sends an SSDP search for Internet gateway devices to dst and
+// returns the location of the description of the first one answering
+func searchIGD(ctx context.Context, conn net.PacketConn, dst net.Addr, timeout time.Duration) (string, error) {
+	search := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1900\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n" +
+		"ST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n\r\n"
+	if _, err := conn.WriteTo([]byte(search), dst); err != nil {
+		return "", fmt.Errorf("error sending SSDP search: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return "", fmt.Errorf("no UPnP router found")
+			}
+			return "", fmt.Errorf("error reading SSDP answers: %w", err)
+		}
+		response, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		response.Body.Close()
+		if location := response.Header.Get("Location"); location != "" {
+			return location, nil
+		}
+	}
+}
+
+// upnpDevice is a device of a UPnP device description
+type upnpDevice struct {
+	DeviceType string `xml:"deviceType"`
+	Services   []struct {
+		ServiceType string `xml:"serviceType"`
+		ControlURL  string `xml:"controlURL"`
+	} `xml:"serviceList>service"`
+	Devices []upnpDevice `xml:"deviceList>device"`
+}
+
+// upnpClient calls the actions of the WAN connection service of a router
+type upnpClient struct {
+	client      *http.Client
+	controlURL  string
+	serviceType string
+}
+
+// wanConnection reads the device description at location and returns a
+// client of its WAN IP or PPP connection service
+func (w *upnpWidget) wanConnection(ctx context.Context, location string) (*upnpClient, error) {
+	client := w.config.httpClient()
+	client.Timeout = w.timeout()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("device description returned HTTP %d", resp.StatusCode)
+	}
+
+	var description struct {
+		URLBase string     `xml:"URLBase"`
+		Device  upnpDevice `xml:"device"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&description); err != nil {
+		return nil, fmt.Errorf("error decoding device description: %w", err)
+	}
+	base, err := url.Parse(cmp.Or(description.URLBase, location))
+	if err != nil {
+		return nil, fmt.Errorf("invalid device URL: %w", err)
+	}
+
+	devices := []upnpDevice{description.Device}
+	for len(devices) > 0 {
+		device := devices[0]
+		devices = append(devices[1:], device.Devices...)
+		for _, service := range device.Services {
+			for _, wanService := range upnpWANServices {
+				if !strings.HasPrefix(service.ServiceType, wanService) {
+					continue
+				}
+				control, err := base.Parse(service.ControlURL)
+				if err != nil {
+					return nil, fmt.Errorf("invalid control URL: %w", err)
+				}
+				return &upnpClient{client: client, controlURL: control.String(), serviceType: service.ServiceType}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("device has no WAN connection service")
+}
+
+// call invokes a SOAP action with its arguments and returns the values of the
+// response by name
+func (c *upnpClient) call(ctx context.Context, action string, args [][2]string) (map[string]string, error) {
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(&body, `<u:%s xmlns:u="%s">`, action, c.serviceType)
+	for _, arg := range args {
+		fmt.Fprintf(&body, "<%s>", arg[0])
+		xml.EscapeText(&body, []byte(arg[1]))
+		fmt.Fprintf(&body, "</%s>", arg[0])
+	}
+	fmt.Fprintf(&body, "</u:%s></s:Body></s:Envelope>", action)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.controlURL, strings.NewReader(body.String()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, c.serviceType, action))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s response: %w", action, err)
+	}
+
+	values, err := soapValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s response: %w", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if code, err := strconv.Atoi(values["errorCode"]); err == nil {
+			return nil, &upnpError{Code: code, Description: values["errorDescription"]}
+		}
+		return nil, fmt.Errorf("%s returned HTTP %d", action, resp.StatusCode)
+	}
+	return values, nil
+}
+
+// soapValues returns the text of the leaf elements of a SOAP message by
+// local name, such as the arguments of a response or the code of a fault
+func soapValues(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var name string
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			name = token.Name.Local
+			text.Reset()
+		case xml.CharData:
+			text.Write(token)
+		case xml.EndElement:
+			if name == token.Name.Local {
+				values[name] = strings.TrimSpace(text.String())
+			}
+			name = ""
+		}
+	}
+}
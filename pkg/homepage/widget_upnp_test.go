@@ -0,0 +1,142 @@
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upnpRouter serves the description and the WAN IP connection service of an
+// Internet gateway device with the given port mappings
+func upnpRouter(t *testing.T, mappings [][]string) *httptest.Server {
+	const serviceType = "urn:schemas-upnp-org:service:WANIPConnection:1"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rootDesc.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <deviceList><device>
+      <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+      <deviceList><device>
+        <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+        <serviceList><service>
+          <serviceType>`+serviceType+`</serviceType>
+          <controlURL>/ctl/IPConn</controlURL>
+        </service></serviceList>
+      </device></deviceList>
+    </device></deviceList>
+  </device>
+</root>`)
+	})
+	mux.HandleFunc("/ctl/IPConn", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		action := strings.TrimSuffix(strings.SplitN(r.Header.Get("SOAPAction"), "#", 2)[1], `"`)
+		values := ""
+		switch action {
+		case "GetStatusInfo":
+			values = "<NewConnectionStatus>Connected</NewConnectionStatus><NewUptime>93784</NewUptime>"
+		case "GetExternalIPAddress":
+			values = "<NewExternalIPAddress>203.0.113.7</NewExternalIPAddress>"
+		case "GetGenericPortMappingEntry":
+			var index int
+			fmt.Sscanf(string(body[strings.Index(string(body), "<NewPortMappingIndex>")+21:]), "%d", &index)
+			if index >= len(mappings) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>713</errorCode><errorDescription>SpecifiedArrayIndexInvalid</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+				return
+			}
+			m := mappings[index]
+			values = fmt.Sprintf("<NewExternalPort>%s</NewExternalPort><NewProtocol>%s</NewProtocol><NewInternalPort>%s</NewInternalPort><NewInternalClient>%s</NewInternalClient><NewEnabled>1</NewEnabled><NewPortMappingDescription>%s</NewPortMappingDescription>", m[0], m[1], m[2], m[3], m[4])
+		}
+		assert.Equal(t, `"`+serviceType+`#`+action+`"`, r.Header.Get("SOAPAction"))
+		fmt.Fprintf(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:%sResponse xmlns:u="%s">%s</u:%sResponse></s:Body></s:Envelope>`, action, serviceType, values, action)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUPnPWidget(t *testing.T) {
+	router := upnpRouter(t, [][]string{
+		{"51413", "TCP", "51413", "192.168.1.20", "Transmission"},
+		{"3074", "UDP", "3074", "192.168.1.42", "Xbox"},
+	})
+
+	widget, err := NewWidget(&WidgetConfig{
+		Type:    "upnp",
+		URL:     router.URL + "/rootDesc.xml",
+		Options: map[string]interface{}{"allowed": "51413/tcp, 32400"},
+	})
+	require.NoError(t, err)
+
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusWarning, result.State)
+	assert.Equal(t, "1 unexpected port mappings", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "WAN", Value: "Connected, 203.0.113.7", State: StatusOK},
+		{Label: "Uptime", Value: "1d 2h"},
+		{Label: "51413/TCP", Value: "192.168.1.20:51413 Transmission", State: StatusOK},
+		{Label: "3074/UDP", Value: "192.168.1.42:3074 Xbox", State: StatusWarning},
+	}, result.Fields)
+}
+
+func TestUPnPWidget_NoAllowedMappings(t *testing.T) {
+	router := upnpRouter(t, nil)
+	widget, err := NewWidget(&WidgetConfig{Type: "upnp", URL: router.URL + "/rootDesc.xml"})
+	require.NoError(t, err)
+
+	result, err := widget.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, result.State)
+	assert.Equal(t, "Connected, 0 port mappings", result.Message)
+	assert.Equal(t, WidgetField{Label: "Port mappings", Value: "None"}, result.Fields[2])
+
+	widget, err = NewWidget(&WidgetConfig{Type: "upnp", URL: router.URL + "/missing.xml"})
+	require.NoError(t, err)
+	_, err = widget.Fetch(context.Background())
+	assert.EqualError(t, err, "device description returned HTTP 404")
+}
+
+func TestSearchIGD(t *testing.T) {
+	responder, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer responder.Close()
+
+	go func() {
+		buf := make([]byte, 1500)
+		_, from, err := responder.ReadFrom(buf)
+		if err != nil || !strings.Contains(string(buf), "InternetGatewayDevice") {
+			return
+		}
+		responder.WriteTo([]byte("NOTIFY garbage"), from)
+		responder.WriteTo([]byte("HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age=120\r\nST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\nLOCATION: http://192.168.1.1:5000/rootDesc.xml\r\n\r\n"), from)
+	}()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	location, err := searchIGD(context.Background(), conn, responder.LocalAddr(), time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "http://192.168.1.1:5000/rootDesc.xml", location)
+
+	_, err = searchIGD(context.Background(), conn, responder.LocalAddr(), 50*time.Millisecond)
+	assert.EqualError(t, err, "no UPnP router found")
+}
+
+func TestFormatUptime(t *testing.T) {
+	assert.Equal(t, "12m", formatUptime(12*time.Minute))
+	assert.Equal(t, "4h 05m", formatUptime(4*time.Hour+5*time.Minute))
+	assert.Equal(t, "3d 4h", formatUptime(76*time.Hour))
+}
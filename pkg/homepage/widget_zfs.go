@@ -0,0 +1,224 @@
+package homepage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zfsWidget shows the health, capacity and scrub state of ZFS pools, from the
+// JSON output of zpool status and zpool list (OpenZFS 2.3 or later). Pools
+// that are not online turn critical, as do pools filled above a threshold.
+type zfsWidget struct {
+	pools         []string // Pools to show (default: all)
+	warnAbove     int      // Capacity in percent turning a pool warning (default: 80)
+	criticalAbove int      // Capacity in percent turning a pool critical (default: 90)
+}
+
+// zpoolValue is a value of the zpool JSON output, which is a string unless
+// --json-int is used
+type zpoolValue string
+
+func (v *zpoolValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = zpoolValue(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*v = zpoolValue(n.String())
+	return nil
+}
+
+// float returns the value as a number, ignoring a trailing percent sign
+func (v zpoolValue) float() (float64, bool) {
+	n, err := strconv.ParseFloat(strings.TrimSuffix(string(v), "%"), 64)
+	return n, err == nil
+}
+
+// zpoolStatus is the output of zpool status -j
+type zpoolStatus struct {
+	Pools map[string]struct {
+		Name       string     `json:"name"`
+		State      string     `json:"state"`
+		Status     string     `json:"status"`
+		ErrorCount zpoolValue `json:"error_count"`
+		ScanStats  *struct {
+			Function  string     `json:"function"`
+			State     string     `json:"state"`
+			EndTime   string     `json:"end_time"`
+			ToExamine zpoolValue `json:"to_examine"`
+			Examined  zpoolValue `json:"examined"`
+			Errors    zpoolValue `json:"errors"`
+		} `json:"scan_stats"`
+	} `json:"pools"`
+}
+
+// zpoolList is the output of zpool list -j
+type zpoolList struct {
+	Pools map[string]struct {
+		Properties map[string]struct {
+			Value zpoolValue `json:"value"`
+		} `json:"properties"`
+	} `json:"pools"`
+}
+
+// zpoolTimeLayout is the layout of the scan times of zpool status
+const zpoolTimeLayout = "Mon Jan _2 15:04:05 2006"
+
+func newZFSWidget(config *WidgetConfig) (Widget, error) {
+	var pools []string
+	for _, name := range strings.Split(config.String("pools", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			pools = append(pools, name)
+		}
+	}
+	return &zfsWidget{
+		pools:         pools,
+		warnAbove:     config.Int("warnAbove", 80),
+		criticalAbove: config.Int("criticalAbove", 90),
+	}, nil
+}
+
+// Fetch runs zpool status and zpool list and returns the state of each pool
+func (w *zfsWidget) Fetch(ctx context.Context) (*WidgetResult, error) {
+	var status zpoolStatus
+	if err := runZpool(ctx, &status, "status", "-j", "-p"); err != nil {
+		return nil, err
+	}
+	var list zpoolList
+	if err := runZpool(ctx, &list, "list", "-j", "-p"); err != nil {
+		return nil, err
+	}
+	return w.result(&status, &list, time.Now()), nil
+}
+
+// runZpool runs a zpool subcommand and decodes its JSON output
+func runZpool(ctx context.Context, out interface{}, args ...string) error {
+	output, err := exec.CommandContext(ctx, "zpool", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return fmt.Errorf("zpool %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return fmt.Errorf("zpool %s failed: %w", args[0], err)
+	}
+	if err := json.Unmarshal(output, out); err != nil {
+		return fmt.Errorf("error decoding zpool %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// result converts the zpool output to one field per pool
+func (w *zfsWidget) result(status *zpoolStatus, list *zpoolList, now time.Time) *WidgetResult {
+	names := w.pools
+	if len(names) == 0 {
+		for name := range status.Pools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	result := &WidgetResult{LastUpdated: now}
+	var states []StatusState
+	var problems []string
+	for _, name := range names {
+		pool, ok := status.Pools[name]
+		if !ok {
+			result.Fields = append(result.Fields, WidgetField{Label: name, Value: "not found", State: StatusCritical})
+			states = append(states, StatusCritical)
+			problems = append(problems, name+" not found")
+			continue
+		}
+
+		// The first problem found is shown as the widget message
+		state := StatusOK
+		var problem string
+		switch pool.State {
+		case "ONLINE":
+		case "OFFLINE":
+			state, problem = StatusWarning, pool.State
+		default:
+			// DEGRADED, FAULTED, UNAVAIL, SUSPENDED or REMOVED
+			state, problem = StatusCritical, pool.State
+		}
+		if errorCount, _ := pool.ErrorCount.float(); errorCount > 0 && state == StatusOK {
+			state, problem = StatusWarning, fmt.Sprintf("%.0f data errors", errorCount)
+		}
+		parts := []string{pool.State}
+
+		if capacity, ok := list.Pools[name].Properties["capacity"].Value.float(); ok {
+			used := fmt.Sprintf("%.0f%% used", capacity)
+			parts = append(parts, used)
+			capacityState := StatusOK
+			switch {
+			case capacity >= float64(w.criticalAbove):
+				capacityState = StatusCritical
+			case capacity >= float64(w.warnAbove):
+				capacityState = StatusWarning
+			}
+			if capacityState != StatusOK {
+				state = worstState(state, capacityState)
+				if problem == "" {
+					problem = used
+				}
+			}
+		}
+
+		if scan := pool.ScanStats; scan == nil {
+			parts = append(parts, "never scrubbed")
+		} else {
+			function := strings.ToLower(scan.Function)
+			switch scan.State {
+			case "SCANNING":
+				total, _ := scan.ToExamine.float()
+				examined, _ := scan.Examined.float()
+				if total > 0 {
+					parts = append(parts, fmt.Sprintf("%s %.0f%% done", function, examined/total*100))
+				} else {
+					parts = append(parts, function+" in progress")
+				}
+			case "FINISHED":
+				if end, err := time.ParseInLocation(zpoolTimeLayout, scan.EndTime, time.Local); err == nil {
+					parts = append(parts, fmt.Sprintf("last %s %s", function, end.Format("2006-01-02")))
+				}
+				if scanErrors, _ := scan.Errors.float(); scanErrors > 0 {
+					state = worstState(state, StatusWarning)
+					parts = append(parts, fmt.Sprintf("%.0f %s errors", scanErrors, function))
+					if problem == "" {
+						problem = parts[len(parts)-1]
+					}
+				}
+			case "CANCELED":
+				parts = append(parts, function+" canceled")
+			}
+		}
+
+		if problem != "" {
+			problems = append(problems, name+" "+problem)
+		}
+		states = append(states, state)
+		result.Fields = append(result.Fields, WidgetField{Label: name, Value: strings.Join(parts, ", "), State: state})
+	}
+
+	result.State = worstState(states...)
+	switch {
+	case len(names) == 0:
+		result.State = StatusUnknown
+		result.Message = "No pools"
+	case len(problems) > 0:
+		result.Message = strings.Join(problems, ", ")
+	default:
+		result.Message = "All pools online"
+	}
+	return result
+}
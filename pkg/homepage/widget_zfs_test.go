@@ -0,0 +1,82 @@
+package homepage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const zpoolStatusOutput = `{
+  "output_version": {"command": "zpool status", "vers_major": 0, "vers_minor": 1},
+  "pools": {
+    "tank": {
+      "name": "tank",
+      "state": "ONLINE",
+      "error_count": "0",
+      "scan_stats": {
+        "function": "SCRUB",
+        "state": "FINISHED",
+        "start_time": "Sun Jun  9 00:24:01 2024",
+        "end_time": "Sun Jun  9 03:10:42 2024",
+        "to_examine": "1000",
+        "examined": "1000",
+        "errors": "0"
+      }
+    },
+    "backup": {
+      "name": "backup",
+      "state": "DEGRADED",
+      "status": "One or more devices could not be used because the label is missing or invalid.",
+      "error_count": 0,
+      "scan_stats": {
+        "function": "RESILVER",
+        "state": "SCANNING",
+        "to_examine": 4000,
+        "examined": 1000,
+        "errors": 0
+      }
+    },
+    "scratch": {
+      "name": "scratch",
+      "state": "ONLINE",
+      "error_count": "0"
+    }
+  }
+}`
+
+const zpoolListOutput = `{
+  "output_version": {"command": "zpool list", "vers_major": 0, "vers_minor": 1},
+  "pools": {
+    "tank": {"name": "tank", "properties": {"capacity": {"value": "42"}, "health": {"value": "ONLINE"}}},
+    "backup": {"name": "backup", "properties": {"capacity": {"value": "12"}}},
+    "scratch": {"name": "scratch", "properties": {"capacity": {"value": "85%"}}}
+  }
+}`
+
+func TestZFSWidget(t *testing.T) {
+	var status zpoolStatus
+	require.NoError(t, json.Unmarshal([]byte(zpoolStatusOutput), &status))
+	var list zpoolList
+	require.NoError(t, json.Unmarshal([]byte(zpoolListOutput), &list))
+
+	widget, err := newZFSWidget(&WidgetConfig{Type: "zfs"})
+	require.NoError(t, err)
+	result := widget.(*zfsWidget).result(&status, &list, time.Now())
+
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "backup DEGRADED, scratch 85% used", result.Message)
+	assert.Equal(t, []WidgetField{
+		{Label: "backup", Value: "DEGRADED, 12% used, resilver 25% done", State: StatusCritical},
+		{Label: "scratch", Value: "ONLINE, 85% used, never scrubbed", State: StatusWarning},
+		{Label: "tank", Value: "ONLINE, 42% used, last scrub 2024-06-09", State: StatusOK},
+	}, result.Fields)
+
+	widget, err = newZFSWidget(&WidgetConfig{Type: "zfs", Options: map[string]interface{}{"pools": "tank, rpool"}})
+	require.NoError(t, err)
+	result = widget.(*zfsWidget).result(&status, &list, time.Now())
+	assert.Equal(t, StatusCritical, result.State)
+	assert.Equal(t, "rpool not found", result.Message)
+}
@@ -0,0 +1,12 @@
+package homepage
+
+// windowsServiceCheck checks the state of a service in the Windows service
+// control manager of the local machine
+type windowsServiceCheck struct {
+	name string
+}
+
+// newWindowsServiceCheck creates a Windows service check for a service
+func newWindowsServiceCheck(service *Service) *windowsServiceCheck {
+	return &windowsServiceCheck{name: service.WindowsService}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package homepage
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// run reports that Windows service checks need Windows
+func (c *windowsServiceCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	return &StatusResult{State: StatusWarning, Message: fmt.Sprintf("Windows service checks not supported on %s", runtime.GOOS)}
+}
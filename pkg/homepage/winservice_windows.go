@@ -0,0 +1,98 @@
+//go:build windows
+
+package homepage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// windowsServiceStates maps the states of a Windows service to a status
+var windowsServiceStates = map[svc.State]struct {
+	state   StatusState
+	message string
+}{
+	svc.Running:         {StatusOK, "Running"},
+	svc.StartPending:    {StatusWarning, "Starting"},
+	svc.ContinuePending: {StatusWarning, "Resuming"},
+	svc.PausePending:    {StatusWarning, "Pausing"},
+	svc.Paused:          {StatusWarning, "Paused"},
+	svc.StopPending:     {StatusCritical, "Stopping"},
+	svc.Stopped:         {StatusCritical, "Stopped"},
+}
+
+// windowsStartTypes names the start types of a Windows service
+var windowsStartTypes = map[uint32]string{
+	mgr.StartAutomatic:           "Automatic",
+	mgr.StartManual:              "Manual",
+	mgr.StartDisabled:            "Disabled",
+	windows.SERVICE_BOOT_START:   "Boot",
+	windows.SERVICE_SYSTEM_START: "System",
+}
+
+// run queries the service control manager for the state of the service. Only
+// the rights to connect and query are requested, so no administrator rights
+// are needed.
+func (c *windowsServiceCheck) run(ctx context.Context, serviceName string) *StatusResult {
+	details := []StatusDetail{{Label: "Service", Value: c.name}}
+
+	manager, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_CONNECT)
+	if err != nil {
+		logging.Error("Windows service check for %s: Failed to connect to the service control manager: %v", serviceName, err)
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Service control manager: %v", err), Details: details}
+	}
+	defer windows.CloseServiceHandle(manager)
+
+	name, err := windows.UTF16PtrFromString(c.name)
+	if err != nil {
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Invalid service name: %v", err), Details: details}
+	}
+	handle, err := windows.OpenService(manager, name, windows.SERVICE_QUERY_STATUS|windows.SERVICE_QUERY_CONFIG)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return &StatusResult{State: StatusCritical, Message: "Service not found", Details: details}
+	} else if err != nil {
+		logging.Error("Windows service check for %s: Failed to open service %s: %v", serviceName, c.name, err)
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Failed to open service: %v", err), Details: details}
+	}
+	service := &mgr.Service{Name: c.name, Handle: handle}
+	defer service.Close()
+
+	if config, err := service.Config(); err == nil {
+		details = append(details, StatusDetail{Label: "Display name", Value: config.DisplayName})
+		if startType, ok := windowsStartTypes[config.StartType]; ok {
+			details = append(details, StatusDetail{Label: "Start type", Value: startType})
+		}
+	}
+
+	status, err := service.Query()
+	if err != nil {
+		logging.Error("Windows service check for %s: Failed to query service %s: %v", serviceName, c.name, err)
+		return &StatusResult{State: StatusCritical, Message: fmt.Sprintf("Failed to query service: %v", err), Details: details}
+	}
+	if status.ProcessId != 0 {
+		details = append(details, StatusDetail{Label: "PID", Value: strconv.FormatUint(uint64(status.ProcessId), 10)})
+	}
+
+	mapped, ok := windowsServiceStates[status.State]
+	if !ok {
+		return &StatusResult{State: StatusUnknown, Message: fmt.Sprintf("Unknown state %d", status.State), Details: details}
+	}
+	message := mapped.message
+	if status.State == svc.Stopped && status.Win32ExitCode != 0 {
+		exitCode := status.Win32ExitCode
+		if exitCode == uint32(windows.ERROR_SERVICE_SPECIFIC_ERROR) {
+			exitCode = status.ServiceSpecificExitCode
+		}
+		message = fmt.Sprintf("Stopped (exit code %d)", exitCode)
+		details = append(details, StatusDetail{Label: "Exit code", Value: strconv.FormatUint(uint64(exitCode), 10)})
+	}
+	logging.Debug("Windows service check for %s: %s is %s", serviceName, c.name, message)
+	return &StatusResult{State: mapped.state, Message: message, Details: details}
+}
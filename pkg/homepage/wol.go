@@ -0,0 +1,60 @@
+package homepage
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// defaultWakeAddr is where Wake-on-LAN packets are sent unless configured:
+// the limited broadcast address and the discard port most cards listen on
+const defaultWakeAddr = "255.255.255.255:9"
+
+// magicPacket returns the Wake-on-LAN packet waking the card with the given
+// MAC address: 6 bytes 0xFF followed by 16 repetitions of the address
+func magicPacket(mac net.HardwareAddr) []byte {
+	return append(bytes.Repeat([]byte{0xFF}, 6), bytes.Repeat(mac, 16)...)
+}
+
+// WakeOnLAN sends a Wake-on-LAN packet for the MAC address to addr, a
+// broadcast address with an optional port (default: 255.255.255.255:9)
+func WakeOnLAN(mac, addr string) error {
+	hardwareAddr, err := net.ParseMAC(mac)
+	if err != nil || len(hardwareAddr) != 6 {
+		return fmt.Errorf("invalid MAC address %q", mac)
+	}
+	if addr == "" {
+		addr = defaultWakeAddr
+	} else if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "9")
+	}
+
+	conn, err := net.Dial("udp4", addr)
+	if err != nil {
+		return fmt.Errorf("error opening Wake-on-LAN socket: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(magicPacket(hardwareAddr)); err != nil {
+		return fmt.Errorf("error sending Wake-on-LAN packet: %w", err)
+	}
+	return nil
+}
+
+// WakeService sends a Wake-on-LAN packet to the host of a service. The status
+// message of a monitored service says so until its next check.
+func (sm *StatusMonitor) WakeService(service *Service) error {
+	if service.MAC == "" {
+		return fmt.Errorf("%s has no MAC address", service.Name)
+	}
+	if err := WakeOnLAN(service.MAC, service.WakeBroadcast); err != nil {
+		return err
+	}
+	logging.Info("Sent Wake-on-LAN packet to %s (%s)", service.Name, service.MAC)
+
+	if result := sm.GetStatus(service.Name); result != nil {
+		sm.updateServiceStatus(service.Name, result.State, "Wake-on-LAN packet sent")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package homepage
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMagicPacket(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	packet := magicPacket(mac)
+	require.Len(t, packet, 102)
+	assert.Equal(t, bytes.Repeat([]byte{0xFF}, 6), packet[:6])
+	for i := 6; i < len(packet); i += 6 {
+		assert.Equal(t, []byte(mac), packet[i:i+6])
+	}
+}
+
+// receiveWake listens for a Wake-on-LAN packet and returns its address
+func receiveWake(t *testing.T) (string, <-chan []byte) {
+	t.Helper()
+	listener, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	packets := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 256)
+		listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := listener.ReadFrom(buf)
+		if err == nil {
+			packets <- buf[:n]
+		}
+	}()
+	return listener.LocalAddr().String(), packets
+}
+
+func TestWakeOnLAN(t *testing.T) {
+	addr, packets := receiveWake(t)
+	require.NoError(t, WakeOnLAN("00:11:22:33:44:55", addr))
+	assert.Equal(t, magicPacket(net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}), <-packets)
+
+	assert.EqualError(t, WakeOnLAN("00:11:22:33:44", addr), `invalid MAC address "00:11:22:33:44"`)
+}
+
+func TestWakeService(t *testing.T) {
+	addr, packets := receiveWake(t)
+	sm := NewStatusMonitor(nil)
+	service := &Service{Name: "Desktop", MAC: "00-11-22-33-44-55", WakeBroadcast: addr}
+	sm.updateServiceStatus("Desktop", StatusCritical, "Host unreachable")
+
+	require.NoError(t, sm.WakeService(service))
+	assert.Len(t, <-packets, 102)
+	assert.Equal(t, StatusCritical, sm.GetStatus("Desktop").State)
+	assert.Equal(t, "Wake-on-LAN packet sent", sm.GetStatus("Desktop").Message)
+
+	assert.EqualError(t, sm.WakeService(&Service{Name: "NAS"}), "NAS has no MAC address")
+}
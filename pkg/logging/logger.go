@@ -231,3 +231,20 @@ func ParseLogLevel(level string) LogLevel {
 		return INFO
 	}
 }
+
+// RunWithTempLog runs the tests of a package with the global logger writing
+// to a temporary directory, removed afterwards, and returns their exit code.
+// Test packages call it from TestMain so that running the tests leaves the
+// working directory clean.
+func RunWithTempLog(m interface{ Run() int }) int {
+	dir, err := os.MkdirTemp("", "termhome-test")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions()
+	opts.LogDir = dir
+	SetGlobalLogger(New(opts))
+	return m.Run()
+}
@@ -0,0 +1,12 @@
+package server
+
+import (
+	"os"
+	"testing"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(logging.RunWithTempLog(m))
+}
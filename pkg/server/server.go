@@ -0,0 +1,167 @@
+// Package server implements the HTTP API of termhome, served by `termhome serve`
+// and by the dashboard when api.listen is set.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// DefaultListen is the address the API listens on when none is configured
+const DefaultListen = "127.0.0.1:8080"
+
+// Server serves the status of the services of a StatusMonitor over HTTP
+type Server struct {
+	monitor    *homepage.StatusMonitor
+	instance   string
+	token      string
+	statusPage homepage.StatusPageSettings
+	http       *http.Server
+}
+
+// New creates an API server for a status monitor. Requests must carry token as
+// a bearer token unless token is empty, in which case pushes are refused.
+func New(listen, token, instance string, monitor *homepage.StatusMonitor) *Server {
+	if listen == "" {
+		listen = DefaultListen
+	}
+	s := &Server{
+		monitor:  monitor,
+		instance: instance,
+		token:    token,
+	}
+	s.http = &http.Server{
+		Addr:              listen,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/health", s.handleHealth)
+	mux.Handle("GET /api/status", s.authenticated(http.HandlerFunc(s.handleStatus)))
+	// Pushed snapshots create services on the dashboard, so they are only
+	// accepted from agents knowing the token
+	if s.token != "" {
+		mux.Handle("POST /api/push", s.authenticated(http.HandlerFunc(s.handlePush)))
+	}
+	mux.HandleFunc("/api/heartbeat/{id}", s.handleHeartbeat)
+	mux.HandleFunc("/api/heartbeat/{id}/fail", s.handleHeartbeat)
+	mux.HandleFunc("GET /status", s.handleStatusPage)
+	return mux
+}
+
+// Start starts listening and serves requests in the background. It returns
+// once the listener is ready, so address errors are reported to the caller.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	logging.Info("API listening on %s", listener.Addr())
+
+	go func() {
+		if err := s.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Error("API server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown gracefully stops the server
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+// authenticated rejects requests without the configured bearer token
+func (s *Server) authenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized reports whether a request carries the configured bearer token
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// handleHealth reports that the server is up
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleStatus returns the status snapshot of all services
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.monitor.Snapshot(s.instance))
+}
+
+// handleHeartbeat records a heartbeat of a passive heartbeat check. Requests
+// to the /fail variant report a failed job. Heartbeats identified by a secret
+// token are accepted without the API token, so cron jobs can use a plain URL.
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if s.monitor.HeartbeatRequiresAuth(id) && !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	failed := strings.HasSuffix(r.URL.Path, "/fail")
+	if err := s.monitor.RecordHeartbeat(id, failed); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// maxPushSize limits the size of pushed snapshots
+const maxPushSize = 1 << 20
+
+// handlePush accepts a status snapshot pushed by an agent
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	var snapshot homepage.StatusSnapshot
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPushSize)).Decode(&snapshot); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid snapshot: " + err.Error()})
+		return
+	}
+	if snapshot.Instance == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "instance name is required"})
+		return
+	}
+	if err := s.monitor.ApplyPushedSnapshot(&snapshot); err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Error("Failed to encode API response: %v", err)
+	}
+}
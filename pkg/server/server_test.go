@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusEndpoint(t *testing.T) {
+	homepage.StoreCachedGroups([]*homepage.ServiceGroup{{
+		Name:     "Apps",
+		Services: []*homepage.Service{{Name: "Static", Status: "warning"}},
+	}})
+	defer homepage.StoreCachedGroups(nil)
+
+	monitor := homepage.NewStatusMonitor(nil)
+	defer monitor.Stop()
+	monitor.AddService(&homepage.Service{Name: "Static", Status: "warning"})
+
+	handler := New("", "secret", "site-a", monitor).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// The token must be sent with the Bearer scheme
+	for _, header := range []string{"secret", "Basic secret", "Bearer"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, header)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("Authorization", "bearer secret")
+	lower := httptest.NewRecorder()
+	handler.ServeHTTP(lower, req)
+	assert.Equal(t, http.StatusOK, lower.Code)
+
+	var snapshot homepage.StatusSnapshot
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&snapshot))
+	assert.Equal(t, "site-a", snapshot.Instance)
+	require.Len(t, snapshot.Groups, 1)
+	require.Len(t, snapshot.Groups[0].Services, 1)
+	assert.Equal(t, homepage.StatusWarning, snapshot.Groups[0].Services[0].State)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPushEndpoint(t *testing.T) {
+	homepage.StoreCachedGroups(nil)
+	defer homepage.StoreCachedGroups(nil)
+
+	monitor := homepage.NewStatusMonitor(nil)
+	defer monitor.Stop()
+
+	srv := httptest.NewServer(New("", "secret", "central", monitor).Handler())
+	defer srv.Close()
+
+	snapshot := &homepage.StatusSnapshot{
+		Instance: "nat-host",
+		Interval: 10,
+		Groups: []homepage.GroupStatus{{
+			Name:     "Backups",
+			Services: []homepage.ServiceStatus{{Name: "Restic", State: homepage.StatusOK, Message: "Up"}},
+		}},
+	}
+
+	err := Push(context.Background(), srv.URL, "wrong", false, snapshot)
+	assert.ErrorContains(t, err, "401")
+
+	require.NoError(t, Push(context.Background(), srv.URL, "secret", false, snapshot))
+	assert.Equal(t, homepage.StatusOK, monitor.GetStatus("nat-host").State)
+	assert.Equal(t, homepage.StatusOK, monitor.GetStatus(homepage.RemoteServiceName("nat-host", "Restic")).State)
+
+	err = Push(context.Background(), srv.URL, "secret", false, &homepage.StatusSnapshot{})
+	assert.ErrorContains(t, err, "instance name is required")
+
+	// Without an API token, pushes are refused
+	open := httptest.NewServer(New("", "", "central", monitor).Handler())
+	defer open.Close()
+	snapshot.Instance = "intruder"
+	assert.Error(t, Push(context.Background(), open.URL, "", false, snapshot))
+	assert.Equal(t, homepage.StatusUnknown, monitor.GetStatus("intruder").State)
+}
+
+func TestHeartbeatEndpoint(t *testing.T) {
+	monitor := homepage.NewStatusMonitor(nil)
+	defer monitor.Stop()
+	monitor.AddService(&homepage.Service{Name: "Backup", HeartbeatPeriod: 3600, HeartbeatToken: "s3cr3t"})
+	monitor.AddService(&homepage.Service{Name: "Cron", HeartbeatPeriod: 60})
+
+	handler := New("", "secret", "site-a", monitor).Handler()
+	do := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/heartbeat/s3cr3t", ""))
+	assert.Equal(t, homepage.StatusOK, monitor.GetStatus("Backup").State)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/api/heartbeat/s3cr3t/fail", ""))
+	assert.Equal(t, homepage.StatusCritical, monitor.GetStatus("Backup").State)
+
+	// Heartbeats identified by the service name need the API token
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/heartbeat/cron", ""))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/heartbeat/cron", "secret"))
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/api/heartbeat/unknown", "secret"))
+}
+
+func TestStatusPage(t *testing.T) {
+	homepage.StoreCachedGroups([]*homepage.ServiceGroup{{
+		Name: "Apps",
+		Services: []*homepage.Service{
+			{Name: "Wiki", Status: "warning"},
+			{Name: "Internal", Status: "ok"},
+		},
+	}})
+	defer homepage.StoreCachedGroups(nil)
+
+	monitor := homepage.NewStatusMonitor(nil)
+	defer monitor.Stop()
+	monitor.AddService(&homepage.Service{Name: "Wiki", Status: "warning"})
+	monitor.AddService(&homepage.Service{Name: "Internal", Status: "ok"})
+
+	require.NoError(t, monitor.SetServiceNote("Wiki", "Moving to a new host"))
+	monitor.AddService(&homepage.Service{Name: "Router", Status: "ok"})
+
+	srv := New("", "secret", "site-a", monitor)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	srv.SetStatusPage(homepage.StatusPageSettings{
+		Enabled:   true,
+		Title:     "Homelab <status>",
+		Groups:    []string{"Apps"},
+		Hide:      []string{"Internal"},
+		Incidents: []homepage.Incident{{Title: "NAS maintenance", Date: "2026-03-01"}},
+	})
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "<title>Homelab &lt;status&gt;</title>")
+	assert.Contains(t, body, "Some services are degraded")
+	assert.Contains(t, body, `<div class="card incident warning">`)
+	assert.Contains(t, body, "NAS maintenance")
+	assert.Contains(t, body, "<h2>Apps</h2>")
+	assert.Contains(t, body, "Wiki")
+	assert.NotContains(t, body, "Moving to a new host")
+	assert.NotContains(t, body, "Internal")
+	assert.NotContains(t, body, "Router")
+	assert.Equal(t, statusPageBars, strings.Count(body, `class="bar `))
+
+	// Notes are shown only when enabled
+	srv.SetStatusPage(homepage.StatusPageSettings{Enabled: true, Groups: []string{"Apps"}, ShowNotes: true})
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Contains(t, rec.Body.String(), `<p class="note">Moving to a new host</p>`)
+}
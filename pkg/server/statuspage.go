@@ -0,0 +1,169 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+const (
+	// statusPageWindow is the time covered by the uptime bars
+	statusPageWindow = 24 * time.Hour
+	// statusPageBars is the number of bars of a service, of 30 minutes each
+	statusPageBars = 48
+)
+
+// SetStatusPage configures the public status page served at /status. It must
+// be called before the server starts.
+func (s *Server) SetStatusPage(page homepage.StatusPageSettings) {
+	s.statusPage = page
+}
+
+// statusPageData is passed to the status page template
+type statusPageData struct {
+	Title     string
+	State     homepage.StatusState
+	Summary   string
+	Incidents []homepage.Incident
+	Groups    []statusPageGroup
+	Updated   time.Time
+}
+
+type statusPageGroup struct {
+	Name     string
+	Note     string
+	Services []statusPageService
+}
+
+type statusPageService struct {
+	Name   string
+	State  homepage.StatusState
+	Note   string
+	Uptime string
+	Bars   []homepage.UptimeBar
+}
+
+// handleStatusPage serves the status page. It is public, so it only shows
+// the state of the services of the listed groups, not their messages, and
+// their notes only when showNotes is set.
+func (s *Server) handleStatusPage(w http.ResponseWriter, r *http.Request) {
+	page := s.statusPage
+	if !page.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	now := time.Now()
+	data := statusPageData{
+		Title:   cmp.Or(page.Title, s.instance, "Status"),
+		State:   homepage.StatusOK,
+		Updated: now,
+	}
+	for _, incident := range page.Incidents {
+		incident.State = cmp.Or(incident.State, homepage.StatusWarning)
+		data.Incidents = append(data.Incidents, incident)
+	}
+
+	var states []homepage.StatusState
+	for _, entry := range s.monitor.GetAllStatuses() {
+		if !entry.Monitored || !slices.Contains(page.Groups, entry.Group) || slices.Contains(page.Hide, entry.Name) {
+			continue
+		}
+		bars, uptime := homepage.Uptime(s.monitor.History(entry.Name), now.Add(-statusPageWindow), now, statusPageBars)
+		service := statusPageService{Name: entry.Name, State: entry.State, Uptime: "n/a", Bars: bars}
+		if page.ShowNotes {
+			service.Note = entry.Note
+		}
+		if uptime >= 0 {
+			service.Uptime = fmt.Sprintf("%.2f%%", uptime)
+		}
+
+		group := cmp.Or(entry.Group, "Other")
+		if len(data.Groups) == 0 || data.Groups[len(data.Groups)-1].Name != group {
+			data.Groups = append(data.Groups, statusPageGroup{Name: group})
+			if page.ShowNotes {
+				data.Groups[len(data.Groups)-1].Note = s.monitor.GroupNote(entry.Group)
+			}
+		}
+		last := &data.Groups[len(data.Groups)-1]
+		last.Services = append(last.Services, service)
+		states = append(states, entry.State)
+	}
+
+	switch {
+	case slices.Contains(states, homepage.StatusCritical):
+		data.State, data.Summary = homepage.StatusCritical, "Some services are down"
+	case slices.Contains(states, homepage.StatusWarning):
+		data.State, data.Summary = homepage.StatusWarning, "Some services are degraded"
+	case !slices.Contains(states, homepage.StatusOK):
+		data.State, data.Summary = homepage.StatusUnknown, "Status unknown"
+	default:
+		data.Summary = "All services are operational"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusPageTemplate.Execute(w, data); err != nil {
+		logging.Error("Failed to render the status page: %v", err)
+	}
+}
+
+var statusPageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="60">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 56rem; margin: 2rem auto; padding: 0 1rem; color: #222; background: #f5f6f8; }
+h1 { font-size: 1.6rem; }
+h2 { font-size: 1.1rem; margin: 1.5rem 0 .5rem; }
+.card { background: #fff; border-radius: .5rem; padding: .75rem 1rem; margin-bottom: .5rem; box-shadow: 0 1px 2px rgba(0,0,0,.08); }
+.banner { color: #fff; font-weight: 600; font-size: 1.1rem; }
+.incident { border-left: .3rem solid; }
+.incident.ok { border-color: #2e9e5b; } .incident.warning { border-color: #d39e00; } .incident.critical { border-color: #c9302c; }
+.incident p { margin: .25rem 0 0; white-space: pre-line; }
+.date { color: #777; font-size: .85rem; }
+.service { display: flex; justify-content: space-between; align-items: center; }
+.dot { display: inline-block; width: .7rem; height: .7rem; border-radius: 50%; margin-right: .4rem; }
+.bars { display: flex; gap: 2px; margin-top: .5rem; }
+.bar { flex: 1; height: 1.6rem; border-radius: 2px; }
+.ok { background-color: #2e9e5b; } .warning { background-color: #d39e00; } .critical { background-color: #c9302c; } .unknown { background-color: #d5d8dc; }
+.incident.ok, .incident.warning, .incident.critical { background-color: #fff; }
+.uptime { color: #555; font-size: .9rem; }
+.note { color: #555; font-style: italic; margin: .25rem 0; }
+footer { color: #777; font-size: .8rem; margin-top: 2rem; text-align: center; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="card banner {{.State}}">{{.Summary}}</div>
+{{- range .Incidents}}
+<div class="card incident {{.State}}">
+<strong>{{.Title}}</strong>{{if .Date}} <span class="date">{{.Date}}</span>{{end}}
+{{- if .Message}}<p>{{.Message}}</p>{{end}}
+</div>
+{{- end}}
+{{- range .Groups}}
+<h2>{{.Name}}</h2>
+{{- if .Note}}
+<p class="note">{{.Note}}</p>
+{{- end}}
+{{- range .Services}}
+<div class="card">
+<div class="service"><span><span class="dot {{.State}}"></span>{{.Name}}</span><span class="uptime">{{.Uptime}}</span></div>
+{{- if .Note}}<p class="note">{{.Note}}</p>{{end}}
+<div class="bars">{{range .Bars}}<span class="bar {{.State}}" title="{{.Start.Format "Jan 2 15:04"}} - {{.End.Format "15:04"}}: {{.State}}"></span>{{end}}</div>
+</div>
+{{- end}}
+{{- end}}
+<footer>Uptime over the last 24 hours. Updated {{.Updated.Format "Jan 2 15:04:05 MST"}}.</footer>
+</body>
+</html>
+`))
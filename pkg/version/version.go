@@ -0,0 +1,100 @@
+// Package version holds the build information of termhome.
+//
+// The variables are set at build time with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/deblasis/termhome/pkg/version.Version=v1.2.3 \
+//	  -X github.com/deblasis/termhome/pkg/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/deblasis/termhome/pkg/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// When they are not set, the values recorded by the Go toolchain are used.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information injected at build time
+var (
+	Version = "" // Semantic version, e.g. v1.2.3
+	Commit  = "" // Git commit the binary was built from
+	Date    = "" // Build date in RFC 3339 format
+)
+
+// Info describes the build of the running binary
+type Info struct {
+	Version   string // Semantic version, "dev" if unknown
+	Commit    string // Git commit, empty if unknown
+	Date      string // Build date, empty if unknown
+	Modified  bool   // Whether the working tree had local changes
+	GoVersion string // Go version used to build the binary
+	Platform  string // Operating system and architecture
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	// Fall back to what the Go toolchain recorded, e.g. for `go install ...@v1.2.3`
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
+
+// Short returns the version followed by the commit when known, e.g. "v1.2.3 (abc1234)"
+func (i Info) Short() string {
+	if i.Commit == "" {
+		return i.Version
+	}
+	commit := i.Commit
+	if i.Modified {
+		commit += "-dirty"
+	}
+	return fmt.Sprintf("%s (%s)", i.Version, commit)
+}
+
+// String returns a multi-line description of the build
+func (i Info) String() string {
+	commit, date := i.Commit, i.Date
+	if commit == "" {
+		commit = "unknown"
+	} else if i.Modified {
+		commit += "-dirty"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return fmt.Sprintf("Version:    %s\nCommit:     %s\nBuilt:      %s\nGo version: %s\nPlatform:   %s\n",
+		i.Version, commit, date, i.GoVersion, i.Platform)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/rivo/tview"
+)
+
+const (
+	// Default longest wait for the first checks of the pre-flight screen
+	defaultPreflightTimeout = 30 * time.Second
+
+	// Default time the pre-flight summary is shown
+	defaultPreflightSummary = 5 * time.Second
+
+	// Most services not OK listed in the pre-flight summary
+	maxPreflightProblems = 10
+)
+
+var (
+	// Set while the pre-flight screen is shown instead of the dashboard
+	preflightActive bool
+
+	// Closed when the pre-flight screen is left
+	preflightEnded = make(chan struct{})
+)
+
+// newPreflightView creates the screen shown on startup while the first checks
+// run, followed by a summary of their results, before the dashboard. The
+// checks are awaited once started is closed, i.e. once the services are
+// monitored.
+func newPreflightView(ctx context.Context, settings homepage.PreflightSettings, monitor *homepage.StatusMonitor, started <-chan struct{}) tview.Primitive {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter)
+	view.SetBorder(true).SetTitle(" Pre-flight checks ")
+	view.SetText(preflightProgress(0, 0, 0))
+
+	preflightActive = true
+	go runPreflight(ctx, view, settings, monitor, started)
+	return view
+}
+
+// runPreflight shows the progress of the first checks until they are all done
+// or the timeout elapses, then the summary until the dashboard is shown
+func runPreflight(ctx context.Context, view *tview.TextView, settings homepage.PreflightSettings, monitor *homepage.StatusMonitor, started <-chan struct{}) {
+	timeout := settings.Timeout.Duration()
+	if timeout <= 0 {
+		timeout = defaultPreflightTimeout
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	select {
+	case <-started:
+	case <-deadline.C:
+	case <-preflightEnded:
+		return
+	case <-ctx.Done():
+		return
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timedOut := false
+	for frame := 0; !timedOut; frame++ {
+		done, total := monitor.CheckProgress()
+		if done == total {
+			break
+		}
+		app.QueueUpdateDraw(func() { view.SetText(preflightProgress(frame, done, total)) })
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			timedOut = true
+		case <-preflightEnded:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	summary := settings.Summary.Duration()
+	if summary <= 0 {
+		summary = defaultPreflightSummary
+	}
+	app.QueueUpdateDraw(func() {
+		view.SetTitle(" Pre-flight summary ")
+		view.SetText(preflightSummary(monitor, timedOut, timeout, summary))
+	})
+
+	select {
+	case <-time.After(summary):
+		app.QueueUpdateDraw(endPreflight)
+	case <-preflightEnded:
+	case <-ctx.Done():
+	}
+}
+
+// endPreflight replaces the pre-flight screen with the dashboard. It must run
+// on the UI goroutine.
+func endPreflight() {
+	if !preflightActive {
+		return
+	}
+	preflightActive = false
+	close(preflightEnded)
+	app.SetRoot(mainContainer, true)
+	if currentFocus != nil {
+		app.SetFocus(currentFocus)
+	}
+}
+
+// preflightProgress returns the text of the pre-flight screen while done of
+// total services were checked
+func preflightProgress(frame, done, total int) string {
+	frames := []rune(glyphs.spinner)
+	text := fmt.Sprintf("\n[yellow::b]%c Checking services...[-:-:-]\n\n", frames[frame%len(frames)])
+	if total > 0 {
+		text += fmt.Sprintf("%d of %d checked (%d%%)\n", done, total, done*100/total)
+	}
+	return text + fmt.Sprintf("\n[%s]Press any key to open the dashboard now[-]", colorMuted)
+}
+
+// preflightSummary returns the text of the pre-flight summary: the number of
+// services in each state, followed by the services that are not OK
+func preflightSummary(monitor *homepage.StatusMonitor, timedOut bool, timeout, summary time.Duration) string {
+	counts := make(map[homepage.StatusState]int)
+	var problems []string
+	for _, entry := range monitor.GetAllStatuses() {
+		if entry.Group == "" || !entry.Monitored || !serviceShown(entry.Service, entry.Group) {
+			continue
+		}
+		counts[entry.State]++
+		if entry.State == homepage.StatusWarning || entry.State == homepage.StatusCritical {
+			color, icon := statusIcon(entry.State)
+			problems = append(problems, fmt.Sprintf("[%s]%s[-] %s: %s", color, icon, tview.Escape(entry.Name), tview.Escape(entry.Message)))
+		}
+	}
+
+	parts := []string{fmt.Sprintf("[green]%d OK[-]", counts[homepage.StatusOK])}
+	if counts[homepage.StatusWarning] > 0 {
+		parts = append(parts, fmt.Sprintf("[yellow]%d warning[-]", counts[homepage.StatusWarning]))
+	}
+	parts = append(parts, fmt.Sprintf("[red]%d failing[-]", counts[homepage.StatusCritical]))
+	if counts[homepage.StatusUnknown] > 0 {
+		parts = append(parts, fmt.Sprintf("[gray]%d unknown[-]", counts[homepage.StatusUnknown]))
+	}
+	text := "\n[::b]" + strings.Join(parts, ", ") + "[::-]\n\n"
+
+	if len(problems) > maxPreflightProblems {
+		more := len(problems) - maxPreflightProblems
+		problems = append(problems[:maxPreflightProblems], fmt.Sprintf("and %d more", more))
+	}
+	if len(problems) > 0 {
+		text += strings.Join(problems, "\n") + "\n\n"
+	}
+	if timedOut {
+		text += fmt.Sprintf("[yellow]Some checks did not finish within %s[-]\n\n", timeout)
+	}
+	return text + fmt.Sprintf("[%s]The dashboard opens in %s, press any key to open it now[-]", colorMuted, summary)
+}
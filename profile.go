@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// startProfiler serves the pprof endpoints at addr, e.g. localhost:6060, to
+// profile startup and monitoring with `go tool pprof`. It returns nil if the
+// address can't be listened on.
+func startProfiler(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logging.Error("Failed to start profiler: %v", err)
+		return nil
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	logging.Info("Serving pprof endpoints at http://%s/debug/pprof/", listener.Addr())
+	return server
+}
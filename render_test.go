@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHiddenWhileHealthy(t *testing.T) {
+	service := &homepage.Service{Name: "Backup", ShowOnlyWhenDown: true}
+	assert.True(t, hiddenWhileHealthy(service, homepage.StatusOK))
+	assert.True(t, hiddenWhileHealthy(service, homepage.StatusUnknown))
+	assert.False(t, hiddenWhileHealthy(service, homepage.StatusWarning))
+	assert.False(t, hiddenWhileHealthy(service, homepage.StatusCritical))
+
+	service.ShowOnlyWhenDown = false
+	assert.False(t, hiddenWhileHealthy(service, homepage.StatusOK))
+}
+
+// benchmarkGroup returns a group of services with static statuses, half of
+// them failing, monitored by a new global status monitor and cached as the
+// only group
+func benchmarkGroup(b *testing.B, services int) *homepage.ServiceGroup {
+	monitor := homepage.NewStatusMonitor(nil)
+	b.Cleanup(monitor.Stop)
+	homepage.SetStatusMonitor(monitor)
+	b.Cleanup(func() { homepage.SetStatusMonitor(nil) })
+
+	group := &homepage.ServiceGroup{Name: "Benchmark"}
+	for i := range services {
+		status := "ok"
+		if i%2 == 1 {
+			status = "critical"
+		}
+		service := &homepage.Service{
+			Name:        fmt.Sprintf("Service %d", i),
+			Href:        fmt.Sprintf("https://service%d.lan", i),
+			Description: "A service rendered by the benchmarks, with a description long enough to wrap",
+			Status:      status,
+		}
+		monitor.AddService(service)
+		group.Services = append(group.Services, service)
+	}
+	homepage.StoreCachedGroups([]*homepage.ServiceGroup{group})
+	b.Cleanup(func() { homepage.StoreCachedGroups(nil) })
+	return group
+}
+
+func BenchmarkRenderServiceGroup(b *testing.B) {
+	for _, compact := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compact=%t", compact), func(b *testing.B) {
+			group := benchmarkGroup(b, 50)
+			view := tview.NewTextView().SetDynamicColors(true)
+			compactMode = compact
+			defer func() { compactMode = false }()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				renderServiceGroup(view, group)
+			}
+		})
+	}
+}
+
+func BenchmarkDrawServiceGroup(b *testing.B) {
+	group := benchmarkGroup(b, 50)
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		b.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(120, 40)
+
+	view := createServiceGroupBox(group)
+	view.SetRect(0, 0, 120, 40)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		renderServiceGroup(view, group)
+		view.Draw(screen)
+	}
+	if elapsed := b.Elapsed() / time.Duration(b.N); elapsed > drawBudget {
+		b.Errorf("Drawing a group of %d services takes %s, over the budget of %s", len(group.Services), elapsed, drawBudget)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/control"
+	"github.com/deblasis/termhome/pkg/homepage"
+)
+
+// reportFormats are the formats of `termhome report`, by name and file extension
+var reportFormats = map[string]func(io.Writer, *uptimeReport) error{
+	"md":   writeMarkdownReport,
+	"html": writeHTMLReport,
+	"csv":  writeCSVReport,
+}
+
+// uptimeReport is the availability of the services of an instance over a period
+type uptimeReport struct {
+	Start    time.Time
+	End      time.Time
+	Period   string
+	Services []reportService
+}
+
+// reportService is a row of an uptime report
+type reportService struct {
+	Name      string
+	Group     string
+	Uptime    float64       // Percent of the known time the service was up, -1 if unknown
+	Coverage  float64       // Percent of the period with a known state
+	Downtime  time.Duration // Time spent critical in the period
+	Incidents int           // Outages overlapping the period
+	MTTR      time.Duration // Mean time to recovery of the outages that ended
+}
+
+// newReportCommand creates the `termhome report` command, which computes the
+// uptime of the services of a running instance from its status history
+func newReportCommand() *cli.Command {
+	cmd := cli.NewCommand("report", "Write an uptime report of the services of the running instance")
+	socketPath, _ := addSocketFlags(cmd)
+	period := cmd.Flags.String("period", "30d", "Period covered by the report, e.g. 30d, 1w or 12h")
+	format := cmd.Flags.String("format", "md", "Format of the report: md, html or csv")
+	output := cmd.Flags.String("output", "", "File the report is written to, - for stdout (default: termhome-report-<date>.<format>)")
+	cmd.Run = func(args []string) int {
+		if len(args) != 0 {
+			cmd.PrintUsage()
+			return 2
+		}
+		write, ok := reportFormats[*format]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q, use md, html or csv\n", *format)
+			return 2
+		}
+		length, err := parsePeriod(*period)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+
+		resp, err := control.Call(socketPath(), &control.Request{Command: control.CommandHistory})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		end := time.Now()
+		report := buildReport(resp.History, end.Add(-length), end)
+		report.Period = *period
+
+		path := *output
+		if path == "" {
+			path = fmt.Sprintf("termhome-report-%s.%s", end.Format("2006-01-02"), *format)
+		}
+		if path == "-" {
+			err = write(os.Stdout, report)
+		} else {
+			err = writeReportFile(path, write, report)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if path != "-" {
+			fmt.Printf("Report of %d services written to %s\n", len(report.Services), path)
+		}
+		return 0
+	}
+	return cmd
+}
+
+// parsePeriod parses a duration, also accepting days (d) and weeks (w)
+func parsePeriod(period string) (time.Duration, error) {
+	var length time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(period, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		length = time.Duration(n) * 24 * time.Hour
+	} else if weeks, ok := strings.CutSuffix(period, "w"); ok {
+		var n int
+		n, err = strconv.Atoi(weeks)
+		length = time.Duration(n) * 7 * 24 * time.Hour
+	} else {
+		length, err = time.ParseDuration(period)
+	}
+	if err != nil || length <= 0 {
+		return 0, fmt.Errorf("invalid period %q, use e.g. 30d, 1w or 12h", period)
+	}
+	return length, nil
+}
+
+// buildReport computes the availability of each service between start and end
+func buildReport(history []control.ServiceHistory, start, end time.Time) *uptimeReport {
+	report := &uptimeReport{Start: start, End: end}
+	for _, service := range history {
+		events := make([]homepage.StatusEvent, len(service.Events))
+		for i, event := range service.Events {
+			events[i] = homepage.StatusEvent{Time: event.Time, State: event.State, Message: event.Message}
+		}
+		availability := homepage.ComputeAvailability(events, start, end)
+		report.Services = append(report.Services, reportService{
+			Name:      service.Name,
+			Group:     service.Group,
+			Uptime:    availability.Uptime(),
+			Coverage:  float64(availability.Known) / float64(end.Sub(start)) * 100,
+			Downtime:  availability.Down,
+			Incidents: len(availability.Outages),
+			MTTR:      availability.MTTR(),
+		})
+	}
+	return report
+}
+
+// writeReportFile writes a report to a file
+func writeReportFile(path string, write func(io.Writer, *uptimeReport) error, report *uptimeReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// formatPercent formats a percentage of the report, n/a if unknown
+func formatPercent(percent float64) string {
+	if percent < 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2f%%", percent)
+}
+
+// formatReportDuration formats a duration of the report to the second, - if zero
+func formatReportDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+// reportCoverageNote explains why the coverage of a report may be incomplete
+const reportCoverageNote = "Coverage is the part of the period with a known state. " +
+	"The status history is kept in memory since the instance started, up to historySize changes per service."
+
+// writeMarkdownReport writes a report as a Markdown table
+func writeMarkdownReport(w io.Writer, report *uptimeReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Uptime report\n\n")
+	fmt.Fprintf(&b, "%s to %s (%s)\n\n", report.Start.Format("2006-01-02 15:04"), report.End.Format("2006-01-02 15:04"), report.Period)
+	fmt.Fprintf(&b, "| Service | Group | Uptime | Downtime | Incidents | MTTR | Coverage |\n")
+	fmt.Fprintf(&b, "|---|---|--:|--:|--:|--:|--:|\n")
+	escape := strings.NewReplacer("|", `\|`)
+	for _, service := range report.Services {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %d | %s | %s |\n",
+			escape.Replace(service.Name), escape.Replace(service.Group), formatPercent(service.Uptime),
+			formatReportDuration(service.Downtime), service.Incidents, formatReportDuration(service.MTTR),
+			formatPercent(service.Coverage))
+	}
+	fmt.Fprintf(&b, "\n%s\n", reportCoverageNote)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeCSVReport writes a report as CSV, durations in seconds
+func writeCSVReport(w io.Writer, report *uptimeReport) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"service", "group", "uptime_percent", "downtime_seconds", "incidents", "mttr_seconds", "coverage_percent"})
+	for _, service := range report.Services {
+		uptime := ""
+		if service.Uptime >= 0 {
+			uptime = strconv.FormatFloat(service.Uptime, 'f', 3, 64)
+		}
+		writer.Write([]string{
+			service.Name,
+			service.Group,
+			uptime,
+			strconv.Itoa(int(service.Downtime.Seconds())),
+			strconv.Itoa(service.Incidents),
+			strconv.Itoa(int(service.MTTR.Seconds())),
+			strconv.FormatFloat(service.Coverage, 'f', 3, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeHTMLReport writes a report as an HTML page
+func writeHTMLReport(w io.Writer, report *uptimeReport) error {
+	return reportTemplate.Execute(w, report)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent":  formatPercent,
+	"duration": formatReportDuration,
+	"note":     func() string { return reportCoverageNote },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Uptime report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; }
+th, td { padding: .3rem .8rem; border-bottom: 1px solid #ddd; text-align: left; }
+td.number { text-align: right; }
+.note { color: #777; font-size: .9rem; }
+</style>
+</head>
+<body>
+<h1>Uptime report</h1>
+<p>{{.Start.Format "2006-01-02 15:04"}} to {{.End.Format "2006-01-02 15:04"}} ({{.Period}})</p>
+<table>
+<tr><th>Service</th><th>Group</th><th>Uptime</th><th>Downtime</th><th>Incidents</th><th>MTTR</th><th>Coverage</th></tr>
+{{- range .Services}}
+<tr><td>{{.Name}}</td><td>{{.Group}}</td><td class="number">{{percent .Uptime}}</td><td class="number">{{duration .Downtime}}</td><td class="number">{{.Incidents}}</td><td class="number">{{duration .MTTR}}</td><td class="number">{{percent .Coverage}}</td></tr>
+{{- end}}
+</table>
+<p class="note">{{note}}</p>
+</body>
+</html>
+`))
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/homepage"
+)
+
+// newSchemaCommand creates the `termhome schema` command, which prints the
+// JSON schema of a configuration file, or writes those of all the files to a
+// directory, for editors to complete and validate the configuration
+func newSchemaCommand() *cli.Command {
+	cmd := cli.NewCommand("schema", "Print the JSON schema of a configuration file for editor completion and validation")
+	cmd.Usage = "[flags] <settings|services|bookmarks|docker>"
+	dir := cmd.Flags.String("dir", "", "Write the schemas of all the files to this directory as <file>.schema.json instead")
+	cmd.FlagValues["dir"] = cli.CompleteDirs
+	cmd.Args = cli.CompleteValues(homepage.SchemaFiles...)
+	cmd.Run = func(args []string) int {
+		if *dir != "" && len(args) == 0 {
+			return writeSchemas(*dir)
+		}
+		if *dir != "" || len(args) != 1 {
+			cmd.PrintUsage()
+			return 2
+		}
+		schema, err := homepage.ConfigSchema(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(schema))
+		return 0
+	}
+	return cmd
+}
+
+// writeSchemas writes the schemas of all the configuration files to dir
+func writeSchemas(dir string) int {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, name := range homepage.SchemaFiles {
+		schema, err := homepage.ConfigSchema(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		path := filepath.Join(dir, name+".schema.json")
+		if err := os.WriteFile(path, append(schema, '\n'), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Set while the search input is shown, so keys go to the input
+var searchActive bool
+
+// openSearch shows an input line below the dashboard. Enter opens the query
+// with the configured search provider in the browser, Esc cancels.
+func openSearch() {
+	if isMaximized {
+		toggleMaximize()
+	}
+
+	search := globalSettings.Search
+	input := tview.NewInputField().
+		SetLabel(fmt.Sprintf("Search %s: ", search.ProviderName())).
+		SetFieldWidth(0)
+
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(originalLayout, 0, 1, false).
+		AddItem(input, 1, 0, true)
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		query := input.GetText()
+		closeSearch()
+		if key != tcell.KeyEnter || query == "" {
+			return
+		}
+
+		url, err := search.SearchURL(query)
+		if err == nil {
+			err = openBrowser(url)
+		}
+		if err != nil {
+			logging.Error("Search failed: %v", err)
+			headerError = "Search failed: " + err.Error()
+			rebuildLayout()
+		} else if headerError != "" {
+			headerError = ""
+			rebuildLayout()
+		}
+	})
+
+	searchActive = true
+	app.SetRoot(layout, true)
+	app.SetFocus(input)
+}
+
+// closeSearch removes the search input
+func closeSearch() {
+	searchActive = false
+	app.SetRoot(originalLayout, true)
+	if currentFocus != nil {
+		app.SetFocus(currentFocus)
+	}
+}
+
+// openBrowser opens a URL in the default browser
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+	// Reap the process without waiting for the browser
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/cli"
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/deblasis/termhome/pkg/server"
+)
+
+// newServeCommand creates the `termhome serve` command, which monitors the
+// configured services without a terminal UI and serves their status over HTTP
+func newServeCommand() *cli.Command {
+	cmd := cli.NewCommand("serve", "Monitor services headless and serve their status over HTTP")
+	configDir := addConfigDirFlag(cmd)
+	logLevel := addLogLevelFlag(cmd)
+	profile := addProfileFlag(cmd)
+	listen := cmd.Flags.String("listen", "", "Address to listen on, overrides api.listen (default \""+server.DefaultListen+"\")")
+	cmd.Run = func(args []string) int {
+		selectProfile(*profile)
+		return runServe(*configDir, *logLevel, *listen)
+	}
+	return cmd
+}
+
+// runServe runs the status monitor and the API server until interrupted
+func runServe(configDir, logLevel, listen string) int {
+	logging.SetGlobalLogLevel(logging.ParseLogLevel(logLevel))
+	logging.Info("Using config directory: %s", configDir)
+
+	cfg := loadConfig(configDir)
+	settings := cfg.settings
+	if listen == "" {
+		listen = settings.API.Listen
+	}
+	if listen == "" {
+		listen = server.DefaultListen
+	}
+
+	statusMonitor := homepage.NewStatusMonitor(nil)
+	homepage.SetStatusMonitor(statusMonitor)
+	defer statusMonitor.Stop()
+
+	// Services disabled and notes set from a dashboard or with `termhome ctl
+	// note` are shared through the state file
+	path := statePath(configDir, settings)
+	restoreState(statusMonitor, path)
+	statusMonitor.SetNoteFunc(func() {
+		if err := saveMonitorState(statusMonitor, path); err != nil {
+			logging.Error("Failed to save the state: %v", err)
+		}
+	})
+
+	startStatusMonitor(statusMonitor, cfg)
+	for _, remote := range settings.Remotes {
+		statusMonitor.AddRemote(remote)
+	}
+	statusMonitor.StartMDNSDiscovery(settings.MDNS)
+
+	instance := settings.InstanceName
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	srv := server.New(listen, settings.API.Token, instance, statusMonitor)
+	srv.SetStatusPage(settings.API.StatusPage)
+	if err := srv.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start API server: %v\n", err)
+		return 1
+	}
+	if settings.API.Token == "" {
+		logging.Warn("api.token is not set, the status API is served without authentication and pushes are refused")
+	}
+	fmt.Printf("Serving status API of %q on %s\n", instance, listen)
+
+	// Accept commands from `termhome ctl`
+	var reloadMutex sync.Mutex
+	reload := func() error {
+		reloadMutex.Lock()
+		defer reloadMutex.Unlock()
+		reloaded, err := reloadConfig(configDir, cfg, statusMonitor)
+		if err == nil {
+			cfg = reloaded
+		}
+		return err
+	}
+	if ctl := startControlSocket(settings, statusMonitor, reload); ctl != nil {
+		defer ctl.Close()
+	}
+
+	// Run until interrupted
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	<-sigCh
+	logging.Info("Received signal, shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logging.Warn("API server shutdown: %v", err)
+	}
+	if err := saveMonitorState(statusMonitor, path); err != nil {
+		logging.Error("Failed to save the state: %v", err)
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+)
+
+// dashboardState holds what is changed from the dashboard and kept across
+// restarts, apart from the configuration files so they are never rewritten,
+// along with the last known statuses
+type dashboardState struct {
+	DisabledServices []string                        `json:"disabledServices,omitempty"` // Services whose checks are turned off
+	ServiceNotes     map[string]string               `json:"serviceNotes,omitempty"`     // Notes attached to services
+	GroupNotes       map[string]string               `json:"groupNotes,omitempty"`       // Notes attached to groups
+	MutedServices    map[string]time.Time            `json:"mutedServices,omitempty"`    // End of the mutes of services
+	Statuses         map[string]homepage.SavedStatus `json:"statuses,omitempty"`         // Last known statuses, shown as stale until rechecked
+}
+
+// stateMutex serializes the writes of the state file, so that a stale state
+// can't replace a newer one
+var stateMutex sync.Mutex
+
+// statePath returns the path of the state file, relative paths being
+// relative to the config directory
+func statePath(configDir string, settings *homepage.Settings) string {
+	path := settings.StateFile
+	if path == "" {
+		path = "state.json"
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(configDir, path)
+}
+
+// loadState reads the state file. A missing file is an empty state.
+func loadState(path string) (*dashboardState, error) {
+	state := &dashboardState{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// saveState writes the state file, replacing it at once so that a crash
+// can't leave it truncated
+func saveState(path string, state *dashboardState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), ".state-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// restoreState applies the state kept by a previous run to a status monitor
+func restoreState(monitor *homepage.StatusMonitor, path string) {
+	state, err := loadState(path)
+	if err != nil {
+		logging.Warn("Warning: %v", err)
+		return
+	}
+	for _, name := range state.DisabledServices {
+		monitor.SetServiceEnabled(name, false)
+	}
+	monitor.RestoreNotes(state.ServiceNotes, state.GroupNotes)
+	monitor.RestoreMutes(state.MutedServices)
+	monitor.RestoreStatuses(state.Statuses)
+}
+
+// saveMonitorState saves the state changed at runtime of a status monitor and
+// the statuses of its services
+func saveMonitorState(monitor *homepage.StatusMonitor, path string) error {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	services, groups := monitor.Notes()
+	return saveState(path, &dashboardState{
+		DisabledServices: monitor.DisabledServices(),
+		ServiceNotes:     services,
+		GroupNotes:       groups,
+		MutedServices:    monitor.Mutes(),
+		Statuses:         monitor.SavedStatuses(),
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// Longest draw that keeps the dashboard responsive, one frame at 30 Hz,
+	// which low-end hardware such as a Raspberry Pi should meet
+	drawBudget = 33 * time.Millisecond
+
+	// Period over which the slowest draw and the number of draws are measured
+	statsWindow = time.Second
+)
+
+// drawStats times the draws of the application for the --stats overlay
+type drawStats struct {
+	start time.Time     // Start of the current draw
+	last  time.Duration // Time the last draw took
+	peak  time.Duration // Slowest draw of the current window
+	shown time.Duration // Slowest draw of the previous window, shown in the overlay
+	since time.Time     // Start of the current window
+	draws int           // Draws of the current window
+	rate  int           // Draws of the previous window
+}
+
+// enableStatsOverlay times each draw of the application and shows the time
+// it took, the slowest draw and the number of draws of the last second and
+// the number of goroutines in the top right corner. The draw functions
+// already set keep running before and after each draw.
+func enableStatsOverlay() {
+	stats := &drawStats{since: time.Now()}
+	before, after := app.GetBeforeDrawFunc(), app.GetAfterDrawFunc()
+	app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		stats.start = time.Now()
+		return before != nil && before(screen)
+	})
+	app.SetAfterDrawFunc(func(screen tcell.Screen) {
+		now := time.Now()
+		stats.record(now.Sub(stats.start), now)
+		if after != nil {
+			after(screen)
+		}
+		width, _ := screen.Size()
+		tview.Print(screen, stats.text(runtime.NumGoroutine()), 0, 0, width-1, tview.AlignRight, tcell.ColorDefault)
+	})
+}
+
+// record adds a draw that took elapsed and ended at now, starting a new
+// window once the current one is over
+func (s *drawStats) record(elapsed time.Duration, now time.Time) {
+	if now.Sub(s.since) >= statsWindow {
+		s.shown, s.rate = s.peak, s.draws
+		s.peak, s.draws, s.since = 0, 0, now
+	}
+	s.last = elapsed
+	s.peak = max(s.peak, elapsed)
+	s.draws++
+}
+
+// text returns the overlay, with the draw times over the budget in red
+func (s *drawStats) text(goroutines int) string {
+	return fmt.Sprintf("[black:white] draw %s, max %s, %d/s, %d goroutines [-:-]",
+		budgetColored(s.last), budgetColored(max(s.shown, s.peak)), s.rate, goroutines)
+}
+
+// budgetColored formats a draw time, in red if it is over the budget
+func budgetColored(elapsed time.Duration) string {
+	text := elapsed.Round(10 * time.Microsecond).String()
+	if elapsed > drawBudget {
+		return "[red::b]" + text + "[black::-]"
+	}
+	return text
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/rivo/tview"
+)
+
+var (
+	// Footer of the current layout, showing the status segments before the key hints
+	statusBar      *tview.TextView
+	statusBarHints string
+)
+
+// newStatusBar creates the footer of a layout, with the key hints after the
+// status segments enabled in the settings. It is refreshed every second by
+// refreshTimestamps.
+func newStatusBar(hints string) *tview.TextView {
+	statusBar = tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter)
+	statusBar.SetBorder(false)
+	statusBarHints = hints
+	updateStatusBar(time.Now())
+	return statusBar
+}
+
+// updateStatusBar renders the status segments as of now followed by the key
+// hints
+func updateStatusBar(now time.Time) {
+	if statusBar == nil {
+		return
+	}
+	var segments []string
+	if globalSettings != nil {
+		segments = statusSegments(globalSettings.StatusBar, now)
+	}
+	if statusBarHints != "" {
+		segments = append(segments, fmt.Sprintf("[red]%s[-]", statusBarHints))
+	}
+	statusBar.SetText(strings.Join(segments, " | "))
+}
+
+// footerHints returns the key hints of the footer: the configured ones if
+// set, none if set to off, and the defaults otherwise
+func footerHints(configured, defaults string) string {
+	switch configured {
+	case "":
+		return defaults
+	case "off":
+		return ""
+	}
+	return configured
+}
+
+// newFooterLines creates the view of the static lines configured below the
+// status bar, one per row
+func newFooterLines(lines []string) *tview.TextView {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false).
+		SetTextAlign(tview.AlignCenter).
+		SetText(strings.Join(lines, "\n"))
+	view.SetBorder(false)
+	return view
+}
+
+// statusSegments returns the segments of the status bar: the current time,
+// the time until the next check, the last incident and the number of services
+// not watched, each unless hidden in the settings
+func statusSegments(settings homepage.StatusBarSettings, now time.Time) []string {
+	var segments []string
+	if !settings.HideClock {
+		segments = append(segments, fmt.Sprintf("[white::b]%s[-:-:-]", timestamps.Clock(now)))
+	}
+
+	monitor := homepage.GetStatusMonitor()
+	if monitor == nil {
+		return segments
+	}
+	if !settings.HideNextCheck && !monitor.Paused() {
+		if next, ok := monitor.NextScheduledCheck(); ok {
+			if next.IsZero() {
+				segments = append(segments, "[gray]checking[-]")
+			} else {
+				segments = append(segments, fmt.Sprintf("[gray]next check in %s[-]", homepage.FormatTimeLeft(next.Sub(now))))
+			}
+		}
+	}
+	if !settings.HideLastIncident {
+		if name, start, ok := monitor.LastIncident(); ok {
+			segments = append(segments, fmt.Sprintf("[gray]last incident %s ([-]%s[gray])[-]", homepage.FormatAgo(now.Sub(start)), tview.Escape(name)))
+		} else {
+			segments = append(segments, "[gray]no incident[-]")
+		}
+	}
+	if !settings.HideMuted {
+		var counts []string
+		if disabled := len(monitor.DisabledServices()); disabled > 0 {
+			counts = append(counts, fmt.Sprintf("%d disabled", disabled))
+		}
+		if muted := len(monitor.Mutes()); muted > 0 {
+			counts = append(counts, fmt.Sprintf("%d muted", muted))
+		}
+		if len(counts) > 0 {
+			segments = append(segments, fmt.Sprintf("[yellow]%s[-]", strings.Join(counts, ", ")))
+		}
+	}
+	return segments
+}
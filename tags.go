@@ -0,0 +1,119 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/rivo/tview"
+)
+
+// Only the services and bookmarks with one of these tags are shown, all of
+// them if empty. Set by the --tags flag and the tag picker.
+var activeTags []string
+
+// itemTags returns the tags of an item together with those of its group
+func itemTags(tags []string, groupName string) []string {
+	if globalSettings == nil {
+		return tags
+	}
+	return append(slices.Clip(tags), globalSettings.Layout[groupName].Tags...)
+}
+
+// serviceShown reports whether a service of the group matches the tag filter
+func serviceShown(service *homepage.Service, groupName string) bool {
+	return homepage.MatchTags(itemTags(service.Tags, groupName), activeTags)
+}
+
+// bookmarkShown reports whether a bookmark of the group matches the tag filter
+func bookmarkShown(bookmark *homepage.Bookmark, groupName string) bool {
+	return homepage.MatchTags(itemTags(bookmark.Tags, groupName), activeTags)
+}
+
+// filterServiceGroups drops the groups without a service matching the tag filter
+func filterServiceGroups(groups []*homepage.ServiceGroup) []*homepage.ServiceGroup {
+	if len(activeTags) == 0 {
+		return groups
+	}
+	var shown []*homepage.ServiceGroup
+	for _, group := range groups {
+		if slices.ContainsFunc(group.Services, func(service *homepage.Service) bool {
+			return serviceShown(service, group.Name)
+		}) {
+			shown = append(shown, group)
+		}
+	}
+	return shown
+}
+
+// filterBookmarkGroups drops the groups without a bookmark matching the tag filter
+func filterBookmarkGroups(groups []*homepage.BookmarkGroup) []*homepage.BookmarkGroup {
+	if len(activeTags) == 0 {
+		return groups
+	}
+	var shown []*homepage.BookmarkGroup
+	for _, group := range groups {
+		if slices.ContainsFunc(group.Bookmarks, func(bookmark *homepage.Bookmark) bool {
+			return bookmarkShown(bookmark, group.Name)
+		}) {
+			shown = append(shown, group)
+		}
+	}
+	return shown
+}
+
+// tagActive reports whether a tag is part of the filter, ignoring case
+func tagActive(tag string) bool {
+	return slices.ContainsFunc(activeTags, func(active string) bool {
+		return strings.EqualFold(active, tag)
+	})
+}
+
+// openTagPicker lists the tags of the configuration below the dashboard.
+// Enter adds the selected tag to the filter or removes it, Esc cancels.
+func openTagPicker() {
+	var layout map[string]homepage.GroupLayout
+	if globalSettings != nil {
+		layout = globalSettings.Layout
+	}
+	tags := homepage.CollectTags(homepage.GetCachedGroups(), homepage.GetCachedBookmarks(), layout)
+	if len(tags) == 0 {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(" Filter by tag ")
+	list.AddItem("All tags", "", 0, func() {
+		activeTags = nil
+		applyTagFilter()
+	})
+	for _, tag := range tags {
+		label := tag
+		if tagActive(tag) {
+			label += " (filtered)"
+		}
+		list.AddItem(tview.Escape(label), "", 0, func() {
+			toggleTag(tag)
+			applyTagFilter()
+		})
+	}
+	list.SetDoneFunc(closeServicePicker)
+	showPicker(list, min(list.GetItemCount(), 10)+2)
+}
+
+// toggleTag adds a tag to the filter, or removes it if already there
+func toggleTag(tag string) {
+	if tagActive(tag) {
+		activeTags = slices.DeleteFunc(activeTags, func(active string) bool {
+			return strings.EqualFold(active, tag)
+		})
+		return
+	}
+	activeTags = append(activeTags, tag)
+}
+
+// applyTagFilter closes the tag picker and redraws the dashboard
+func applyTagFilter() {
+	closeServicePicker()
+	rebuildLayout()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/rivo/tview"
+)
+
+var (
+	// Set while the service picker is shown, so keys go to it
+	pickerActive bool
+
+	// Path of the state file, where services disabled from the dashboard are kept
+	activeStatePath string
+)
+
+// restoreDashboardState applies the state kept by a previous run, such as
+// disabled services and notes, and saves it again whenever a note changes
+func restoreDashboardState(monitor *homepage.StatusMonitor, path string) {
+	activeStatePath = path
+	restoreState(monitor, path)
+	monitor.SetNoteFunc(func() { dashboardStateChanged(monitor) })
+}
+
+// openServicePicker lists the monitored services of the focused group below
+// the dashboard. Enter turns the monitoring of the selected service on or
+// off, Esc cancels.
+func openServicePicker() {
+	group := focusedServiceGroup()
+	monitor := homepage.GetStatusMonitor()
+	if group == nil || monitor == nil || refuseReadOnly() {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(fmt.Sprintf(" Monitoring of %s ", group.Name))
+	for _, service := range group.Services {
+		if !monitor.IsMonitored(service.Name) {
+			continue
+		}
+		label := service.Name + " (monitored)"
+		if !monitor.IsServiceEnabled(service.Name) {
+			label = service.Name + " (disabled)"
+		}
+		name := service.Name
+		list.AddItem(tview.Escape(label), "", 0, func() {
+			closeServicePicker()
+			go toggleServiceMonitoring(monitor, name)
+		})
+	}
+	if list.GetItemCount() == 0 {
+		return
+	}
+	list.SetDoneFunc(closeServicePicker)
+	showPicker(list, min(list.GetItemCount(), 10)+2)
+}
+
+// showPicker shows a list or an input of the given height below the dashboard
+func showPicker(picker tview.Primitive, height int) {
+	if isMaximized {
+		toggleMaximize()
+	}
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(originalLayout, 0, 1, false).
+		AddItem(picker, height, 0, true)
+
+	pickerActive = true
+	app.SetRoot(layout, true)
+	app.SetFocus(picker)
+}
+
+// closeServicePicker removes the service picker
+func closeServicePicker() {
+	pickerActive = false
+	app.SetRoot(originalLayout, true)
+	if currentFocus != nil {
+		app.SetFocus(currentFocus)
+	}
+}
+
+// focusedServiceGroup returns the service group of the focused box, or nil if
+// another box is focused
+func focusedServiceGroup() *homepage.ServiceGroup {
+	for name, view := range serviceViews {
+		if view == currentFocus {
+			for _, group := range homepage.GetCachedGroups() {
+				if group.Name == name {
+					return group
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// toggleServiceMonitoring turns the monitoring of a service on or off,
+// recording who did, and saves the disabled services to the state file. It
+// must not be called from the UI goroutine.
+func toggleServiceMonitoring(monitor *homepage.StatusMonitor, name string) {
+	enabled := !monitor.IsServiceEnabled(name)
+	monitor.SetServiceEnabled(name, enabled)
+	if enabled {
+		monitor.RecordAudit(name, homepage.AuditUser(), "Turned monitoring on")
+	} else {
+		monitor.RecordAudit(name, homepage.AuditUser(), "Turned monitoring off")
+	}
+	dashboardStateChanged(monitor)
+}
+
+// dashboardStateChanged saves the state file and redraws the dashboard. It
+// must not be called from the UI goroutine.
+func dashboardStateChanged(monitor *homepage.StatusMonitor) {
+	err := saveMonitorState(monitor, activeStatePath)
+	if err != nil {
+		logging.Error("Failed to save the dashboard state: %v", err)
+	}
+	if !appInitialized || app == nil {
+		return
+	}
+
+	app.QueueUpdateDraw(func() {
+		if err != nil {
+			headerError = "Failed to save state: " + err.Error()
+		}
+		rebuildLayout()
+	})
+}
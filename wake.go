@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/deblasis/termhome/pkg/homepage"
+	"github.com/deblasis/termhome/pkg/logging"
+	"github.com/rivo/tview"
+)
+
+// openWakePicker lists the services of the focused group with a MAC address
+// whose host is not up. Enter sends a Wake-on-LAN packet to the selected
+// one, Esc cancels.
+func openWakePicker() {
+	group := focusedServiceGroup()
+	monitor := homepage.GetStatusMonitor()
+	if group == nil || monitor == nil || refuseReadOnly() {
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(fmt.Sprintf(" Wake up a host of %s ", group.Name))
+	statuses := groupStatuses(group.Name)
+	for _, service := range group.Services {
+		if service.MAC == "" {
+			continue
+		}
+		label := service.Name + " (not monitored)"
+		if status := statuses[service.Name]; status != nil && status.Monitored {
+			if status.State == homepage.StatusOK {
+				continue
+			}
+			label = fmt.Sprintf("%s (%s)", service.Name, status.State)
+		}
+		service := service
+		list.AddItem(tview.Escape(label), "", 0, func() {
+			closeServicePicker()
+			go wakeService(monitor, service)
+		})
+	}
+	if list.GetItemCount() == 0 {
+		return
+	}
+	list.SetDoneFunc(closeServicePicker)
+	showPicker(list, min(list.GetItemCount(), 10)+2)
+}
+
+// wakeService sends a Wake-on-LAN packet to the host of a service, recording
+// who did, and shows the error in the header if it fails. It must not be
+// called from the UI goroutine.
+func wakeService(monitor *homepage.StatusMonitor, service *homepage.Service) {
+	err := monitor.WakeService(service)
+	if err == nil {
+		monitor.RecordAudit(service.Name, homepage.AuditUser(), "Sent Wake-on-LAN packet")
+		return
+	}
+	logging.Error("Failed to wake %s: %v", service.Name, err)
+	app.QueueUpdateDraw(func() {
+		headerError = "Wake-on-LAN failed: " + err.Error()
+		rebuildLayout()
+	})
+}